@@ -0,0 +1,264 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/aeciopires/pires-cli/internal/config"
+	"github.com/aeciopires/pires-cli/pkg/pireslib/common"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// Sources of config values reported by 'config explain' command. See explainConfig function
+const (
+	configSourceDefault    = "default"
+	configSourceEnv        = "env"
+	configSourceConfigFile = "config-file"
+	configSourceFlag       = "flag"
+	configSourceComputed   = "computed"
+)
+
+// configFlagNames are the root persistent flags that set the config keys
+var configFlagNames = map[string]string{
+	"cli_config_file":     "config-file",
+	"cli_environment":     "environment",
+	"cli_gcp_project":     "gcp-project",
+	"cli_gcp_region":      "gcp-region",
+	"cli_database_type":   "database-type",
+	"cli_vpn_host_target": "vpn-address-target",
+}
+
+// configComputedKeys are the config keys redefined by CLI after the config is loaded. See initConfig function
+var configComputedKeys = []string{"cli_gsa_base_account", "cli_gsa_account"}
+
+// configSensitiveKeyWords are the words of config keys whose values are redacted by 'config export-env' command
+var configSensitiveKeyWords = []string{"password", "secret", "token", "credential", "private_key"}
+
+// Local variables
+var (
+	// configCmd represents the base config command
+	configCmd = &cobra.Command{
+		Use:   "config",
+		Short: "Inspect the configuration of the CLI",
+		Long:  `Provides commands to inspect the config files and profiles of the CLI.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			fmt.Println("Config command requires a subcommand (e.g., list-profiles).")
+			cmd.Help()
+		},
+	}
+
+	// --- List Profiles Subcommand ---
+	configListProfilesCmd = &cobra.Command{
+		Use:   "list-profiles",
+		Short: "List the config profiles available for --profile option",
+		Long: `Lists the config profiles found in the directory of profiles ($HOME/.config/pires-cli on Linux).
+	Each profile is a config file named <profile>.env, used with the --profile <profile> option.`,
+		Annotations: map[string]string{skipStartupChecksAnnotation: "true"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			profilesDir, err := config.ProfilesDir()
+			if err != nil {
+				return err
+			}
+			profiles, err := config.ListProfiles()
+			if err != nil {
+				return err
+			}
+			if len(profiles) == 0 {
+				common.Logger("info", "No config profiles found in: %s", profilesDir)
+				return nil
+			}
+			for _, profile := range profiles {
+				fmt.Println(profile)
+			}
+			return nil
+		},
+	}
+
+	// --- Check Env Subcommand ---
+	configCheckEnvRequired []string
+
+	configCheckEnvCmd = &cobra.Command{
+		Use:   "check-env",
+		Short: "Check that the required CLI_* environment variables are set",
+		Long: `Checks that each required environment variable is set and not empty. Exit with non-zero code listing the missing ones.
+	The names follow the same rules of config file keys, so cli_gcp_project, CLI_GCP_PROJECT and gcp-project are the same variable.
+	Useful to check the pipelines before running other commands.`,
+		Example:     `  pires-cli config check-env --required cli_gcp_project,cli_gcp_region`,
+		Annotations: map[string]string{skipStartupChecksAnnotation: "true"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			knownKeys := configKeys()
+			var missing []string
+			for _, name := range configCheckEnvRequired {
+				key := normalizeConfigKey(name)
+				if !slices.Contains(knownKeys, key) {
+					return common.NewValidationError("unknown config variable '%s'. Supported variables: %s", name, strings.Join(knownKeys, ", "))
+				}
+				envVar := configEnvVarName(key)
+				if strings.TrimSpace(os.Getenv(envVar)) == "" {
+					missing = append(missing, envVar)
+					continue
+				}
+				common.Logger("info", "%s is set", envVar)
+			}
+
+			if len(missing) > 0 {
+				return common.NewValidationError("missing required environment variable(s): %s", strings.Join(missing, ", "))
+			}
+			common.Logger("info", "All %d required environment variable(s) are set.", len(configCheckEnvRequired))
+			return nil
+		},
+	}
+
+	// --- Explain Subcommand ---
+	configExplainOutputFormat string
+
+	configExplainCmd = &cobra.Command{
+		Use:   "explain",
+		Short: "Show where each config value comes from",
+		Long: `Shows the final value of each config key and its source: the environment variable (env), the config file (config-file),
+	the command line option (flag), the built-in default value (default) or a value redefined by CLI (computed).
+	The precedence is: environment variables, config file, command line options and default values.`,
+		Example:     `  pires-cli config explain -o json`,
+		Annotations: map[string]string{skipStartupChecksAnnotation: "true"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			if configExplainOutputFormat != "text" && configExplainOutputFormat != "json" {
+				return common.NewValidationError("Unsupported output format '%s'. Supported values: text or json", configExplainOutputFormat)
+			}
+
+			values := explainConfig()
+
+			if configExplainOutputFormat == "json" {
+				valuesJSON, err := json.MarshalIndent(values, "", "  ")
+				if err != nil {
+					return fmt.Errorf("failed to encode config values: %w", err)
+				}
+				fmt.Println(string(valuesJSON))
+				return nil
+			}
+
+			var rows [][]string
+			for _, value := range values {
+				rows = append(rows, []string{value.Key, value.Value, value.Source, value.Default})
+			}
+			return common.WriteTable(os.Stdout, []string{"KEY", "VALUE", "SOURCE", "DEFAULT"}, rows)
+		},
+	}
+
+	// --- Export Env Subcommand ---
+	configExportEnvCmd = &cobra.Command{
+		Use:   "export-env",
+		Short: "Print the config values as shell export statements",
+		Long: `Prints an 'export CLI_*=value' line for each config key with its final value, reproducing the config in another shell.
+	The values are single-quoted for POSIX shells. The sensitive values (e.g. passwords and tokens) are not exported:
+	they are printed as comments.`,
+		Example: `  eval "$(pires-cli config export-env)"
+  pires-cli config export-env > cli.env`,
+		Annotations: map[string]string{skipStartupChecksAnnotation: "true"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			values := configFieldValues(config.Properties)
+			for _, key := range configKeys() {
+				fmt.Println(configExportLine(key, values[key]))
+			}
+			return nil
+		},
+	}
+)
+
+// configExportLine returns the shell export statement of a config key, or a comment if the key is sensitive.
+func configExportLine(key, value string) string {
+	envVar := configEnvVarName(key)
+	for _, word := range configSensitiveKeyWords {
+		if strings.Contains(key, word) {
+			return fmt.Sprintf("# %s is sensitive and was not exported", envVar)
+		}
+	}
+	return fmt.Sprintf("export %s='%s'", envVar, strings.ReplaceAll(value, "'", `'\''`))
+}
+
+// configValue is a config value reported by 'config explain' command.
+type configValue struct {
+	Key     string `json:"key"`
+	EnvVar  string `json:"envVar"`
+	Value   string `json:"value"`
+	Source  string `json:"source"`
+	Default string `json:"default"`
+}
+
+// explainConfig returns the final config values (config.Properties) with their sources, in the order of fields.
+// The source follows the precedence of initConfig function: the environment variables and the config file read by viper
+// override the command line options, that override the default values (config.DefaultProperties function).
+func explainConfig() []configValue {
+	current := configFieldValues(config.Properties)
+	defaults := configFieldValues(config.DefaultProperties())
+
+	var values []configValue
+	for _, key := range configKeys() {
+		value := configValue{
+			Key:     key,
+			EnvVar:  configEnvVarName(key),
+			Value:   current[key],
+			Default: defaults[key],
+		}
+		flagName, hasFlag := configFlagNames[key]
+		switch {
+		case slices.Contains(configComputedKeys, key):
+			value.Source = configSourceComputed
+		case os.Getenv(value.EnvVar) != "":
+			value.Source = configSourceEnv
+		case viper.InConfig(key):
+			value.Source = configSourceConfigFile
+		case hasFlag && rootCmd.PersistentFlags().Changed(flagName):
+			value.Source = configSourceFlag
+		case value.Value == value.Default:
+			value.Source = configSourceDefault
+		default:
+			// E.g. the config file of --profile option
+			value.Source = configSourceComputed
+		}
+		values = append(values, value)
+	}
+	return values
+}
+
+// configFieldValues returns the values of config.Properties fields by their viper keys (mapstructure tags).
+func configFieldValues(properties config.PropertiesStruct) map[string]string {
+	auxType := reflect.TypeOf(properties)
+	auxValue := reflect.ValueOf(properties)
+
+	values := make(map[string]string)
+	// Interate over the fields of the struct
+	for i := 0; i < auxType.NumField(); i++ {
+		if key := auxType.Field(i).Tag.Get("mapstructure"); key != "" {
+			values[key] = fmt.Sprint(auxValue.Field(i).Interface())
+		}
+	}
+	return values
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd) // Add configCmd to the root command
+
+	// Add subcommands to configCmd
+	configCmd.AddCommand(configListProfilesCmd)
+	configCmd.AddCommand(configCheckEnvCmd)
+	configCmd.AddCommand(configExplainCmd)
+	configCmd.AddCommand(configExportEnvCmd)
+
+	// Flags for 'config check-env'
+	configCheckEnvCmd.Flags().StringSliceVarP(&configCheckEnvRequired, "required", "r", nil, "Comma-separated list of required variables (e.g. cli_gcp_project,cli_gcp_region) (required)")
+
+	// Flags are required
+	_ = configCheckEnvCmd.MarkFlagRequired("required")
+
+	// Flags for 'config explain'
+	configExplainCmd.Flags().StringVarP(&configExplainOutputFormat, "output-format", "o", "text", "Output format. Supported values: text or json")
+
+}
@@ -0,0 +1,169 @@
+package cmd
+
+import (
+	"os/exec"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/aeciopires/pires-cli/internal/config"
+	"github.com/aeciopires/pires-cli/pkg/pireslib/common"
+	"github.com/spf13/viper"
+)
+
+// runConfigCheckEnv runs 'config check-env' with the required variables and returns its error
+func runConfigCheckEnv(t *testing.T, required ...string) error {
+	t.Helper()
+	previous := configCheckEnvRequired
+	t.Cleanup(func() { configCheckEnvRequired = previous })
+	configCheckEnvRequired = required
+	return configCheckEnvCmd.RunE(configCheckEnvCmd, nil)
+}
+
+func TestNormalizeConfigKey(t *testing.T) {
+	for _, name := range []string{"CLI_GCP_PROJECT", "cli_gcp_project", "gcp_project", "gcp-project", " GCP-PROJECT "} {
+		if got := normalizeConfigKey(name); got != "cli_gcp_project" {
+			t.Errorf("normalizeConfigKey(%q) = %s, want cli_gcp_project", name, got)
+		}
+	}
+	if got := configEnvVarName("cli_gcp_project"); got != "CLI_GCP_PROJECT" {
+		t.Errorf("configEnvVarName() = %s, want CLI_GCP_PROJECT", got)
+	}
+}
+
+func TestConfigKeys(t *testing.T) {
+	keys := configKeys()
+	for _, key := range []string{"cli_environment", "cli_gcp_project", "cli_gcp_region"} {
+		if !slices.Contains(keys, key) {
+			t.Errorf("configKeys() = %v, want %s", keys, key)
+		}
+	}
+}
+
+func TestConfigCheckEnv(t *testing.T) {
+	t.Setenv("CLI_GCP_PROJECT", "nonprod")
+	t.Setenv("CLI_GCP_REGION", "us-central1")
+	if err := runConfigCheckEnv(t, "cli_gcp_project", "gcp-region"); err != nil {
+		t.Errorf("error = %v, want all variables set", err)
+	}
+}
+
+func TestConfigCheckEnvMissing(t *testing.T) {
+	t.Setenv("CLI_GCP_PROJECT", "nonprod")
+	// The blank variable is missing too
+	t.Setenv("CLI_GCP_REGION", " ")
+
+	err := runConfigCheckEnv(t, "cli_gcp_project", "cli_gcp_region")
+	if common.ExitCode(err) != common.ExitCodeValidation {
+		t.Fatalf("error = %v, want validation error", err)
+	}
+	if !strings.Contains(err.Error(), "CLI_GCP_REGION") || strings.Contains(err.Error(), "CLI_GCP_PROJECT") {
+		t.Errorf("error = %v, want only CLI_GCP_REGION missing", err)
+	}
+}
+
+func TestConfigCheckEnvUnknownVariable(t *testing.T) {
+	err := runConfigCheckEnv(t, "cli_gcp_zone")
+	if common.ExitCode(err) != common.ExitCodeValidation || !strings.Contains(err.Error(), "unknown config variable") {
+		t.Errorf("error = %v, want unknown variable error", err)
+	}
+}
+
+// findConfigValue returns the value of explainConfig with the key
+func findConfigValue(t *testing.T, values []configValue, key string) configValue {
+	t.Helper()
+	for _, value := range values {
+		if value.Key == key {
+			return value
+		}
+	}
+	t.Fatalf("key %q not found in %v", key, values)
+	return configValue{}
+}
+
+func TestExplainConfig(t *testing.T) {
+	previousProperties := config.Properties
+	t.Cleanup(func() {
+		config.Properties = previousProperties
+		viper.Reset()
+	})
+	viper.Reset()
+	viper.SetConfigType("env")
+	if err := viper.ReadConfig(strings.NewReader("CLI_GCP_REGION=file-region\n")); err != nil {
+		t.Fatal(err)
+	}
+	config.Properties = config.DefaultProperties()
+	config.Properties.DefaultGCPProject = "env-project"
+	config.Properties.DefaultGCPRegion = "file-region"
+	t.Setenv("CLI_GCP_PROJECT", "env-project")
+	t.Setenv("CLI_GCP_REGION", "")
+	t.Setenv("CLI_DATABASE_TYPE", "")
+
+	values := explainConfig()
+
+	tests := []struct {
+		key    string
+		value  string
+		source string
+	}{
+		{"cli_gcp_project", "env-project", configSourceEnv},
+		{"cli_gcp_region", "file-region", configSourceConfigFile},
+		{"cli_database_type", config.DefaultProperties().DefaultDatabaseType, configSourceDefault},
+	}
+	for _, tt := range tests {
+		value := findConfigValue(t, values, tt.key)
+		if value.Value != tt.value || value.Source != tt.source {
+			t.Errorf("%s = %q from %s, want %q from %s", tt.key, value.Value, value.Source, tt.value, tt.source)
+		}
+	}
+	if project := findConfigValue(t, values, "cli_gcp_project"); project.EnvVar != "CLI_GCP_PROJECT" || project.Default == "env-project" {
+		t.Errorf("cli_gcp_project = %+v, want the variable and the default value", project)
+	}
+}
+
+func TestConfigExportLine(t *testing.T) {
+	if got, want := configExportLine("cli_gcp_project", "nonprod"), "export CLI_GCP_PROJECT='nonprod'"; got != want {
+		t.Errorf("configExportLine() = %s, want %s", got, want)
+	}
+	if got := configExportLine("cli_db_password", "secret"); strings.Contains(got, "secret") || !strings.HasPrefix(got, "# CLI_DB_PASSWORD") {
+		t.Errorf("configExportLine() of sensitive key = %s, want comment without value", got)
+	}
+
+	// The quoted value is read back by the shell without changes
+	value := `it's $HOME "quoted"`
+	output, err := exec.Command("sh", "-c", configExportLine("cli_note", value)+`; printf '%s' "$CLI_NOTE"`).Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(output) != value {
+		t.Errorf("value read by shell = %q, want %q", output, value)
+	}
+}
+
+func TestConfigExportEnv(t *testing.T) {
+	previousProperties := config.Properties
+	t.Cleanup(func() { config.Properties = previousProperties })
+	config.Properties.DefaultGCPProject = "nonprod"
+
+	var err error
+	output := captureStdout(t, func() {
+		err = configExportEnvCmd.RunE(configExportEnvCmd, nil)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	keys := configKeys()
+	if len(lines) != len(keys) {
+		t.Fatalf("%d line(s), want one per config key (%d):\n%s", len(lines), len(keys), output)
+	}
+	for i, key := range keys {
+		if !strings.HasPrefix(lines[i], "export "+configEnvVarName(key)+"=") && !strings.HasPrefix(lines[i], "# "+configEnvVarName(key)+" ") {
+			t.Errorf("line %d = %q, want export of %s", i+1, lines[i], configEnvVarName(key))
+		}
+	}
+	if !slices.Contains(lines, "export CLI_GCP_PROJECT='nonprod'") {
+		t.Errorf("output does not export the project:\n%s", output)
+	}
+}
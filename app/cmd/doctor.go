@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/aeciopires/pires-cli/internal/config"
+	"github.com/aeciopires/pires-cli/pkg/pireslib/common"
+	"github.com/aeciopires/pires-cli/pkg/pireslib/fileeditor"
+	"github.com/aeciopires/pires-cli/pkg/pireslib/gcp"
+	"github.com/spf13/cobra"
+)
+
+// Status of the checks performed by doctor command
+const (
+	doctorStatusPass = "PASS"
+	doctorStatusFail = "FAIL"
+	doctorStatusSkip = "SKIP"
+)
+
+// doctorResult is the result of one check performed by doctor command
+type doctorResult struct {
+	Name        string
+	Status      string
+	Detail      string
+	Remediation string
+	Required    bool
+}
+
+// Local variables
+var (
+	// doctorCmd represents the doctor command
+	doctorCmd = &cobra.Command{
+		Use:   "doctor",
+		Short: "Check if the environment is ready to use the CLI",
+		Long: `Checks the required and optional commands, the gcloud authentication, the configuration,
+	the embedded yq and (if --vpn-check-connection is true) the VPN connectivity.
+	Prints a checklist with the remediation of each failed check.
+	Exit with non-zero code if any required check fails.`,
+		// The startup checks are skipped, because this command reports them
+		Annotations: map[string]string{skipStartupChecksAnnotation: "true"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			results := runDoctorChecks()
+
+			failed := 0
+			for _, result := range results {
+				fmt.Printf("[%s] %s: %s\n", result.Status, result.Name, result.Detail)
+				if result.Status == doctorStatusFail {
+					fmt.Printf("       Remediation: %s\n", result.Remediation)
+					if result.Required {
+						failed++
+					}
+				}
+			}
+
+			if failed > 0 {
+				return fmt.Errorf("%d required check(s) failed", failed)
+			}
+			common.Logger("info", "All required checks passed.")
+			return nil
+		},
+	}
+)
+
+// runDoctorChecks performs all checks of doctor command and returns their results.
+// The checks are performed in order and don't stop on failures.
+func runDoctorChecks() []doctorResult {
+	var results []doctorResult
+
+	// Required commands
+	requiredCommands := config.RequiredCommands()
+	missingCommands := common.FindMissingCommands(requiredCommands)
+	commandsResult := doctorResult{Name: "Commands", Required: true}
+	if len(missingCommands) > 0 {
+		commandsResult.Status = doctorStatusFail
+		commandsResult.Detail = fmt.Sprintf("not found in PATH: %s", strings.Join(missingCommands, ", "))
+		commandsResult.Remediation = "Install the missing commands and ensure they are accessible in your PATH."
+	} else {
+		commandsResult.Status = doctorStatusPass
+		commandsResult.Detail = fmt.Sprintf("found in PATH: %s", strings.Join(requiredCommands, ", "))
+	}
+	results = append(results, commandsResult)
+
+	// Optional commands
+	optionalCommandsResult := doctorResult{Name: "Optional commands", Required: false}
+	if missingOptional := common.FindMissingCommands(config.OptionalCommandsToCheck); len(missingOptional) > 0 {
+		optionalCommandsResult.Status = doctorStatusFail
+		optionalCommandsResult.Detail = fmt.Sprintf("not found in PATH: %s", strings.Join(missingOptional, ", "))
+		optionalCommandsResult.Remediation = "Install them only if you use the commands that require them (e.g. conftest for 'k8s policy-check')."
+	} else {
+		optionalCommandsResult.Status = doctorStatusPass
+		optionalCommandsResult.Detail = fmt.Sprintf("found in PATH: %s", strings.Join(config.OptionalCommandsToCheck, ", "))
+	}
+	results = append(results, optionalCommandsResult)
+
+	// gcloud authentication
+	authResult := doctorResult{Name: "gcloud authentication", Required: true}
+	if slices.Contains(missingCommands, config.GcloudPath) {
+		authResult.Status = doctorStatusSkip
+		authResult.Detail = "gcloud is not installed"
+	} else if account, err := gcp.GetGcloudActiveAccount(); err != nil {
+		authResult.Status = doctorStatusFail
+		authResult.Detail = err.Error()
+		authResult.Remediation = "Run 'gcloud auth login' and 'gcloud auth application-default login' commands."
+	} else {
+		authResult.Status = doctorStatusPass
+		authResult.Detail = fmt.Sprintf("authenticated with account '%s'", account)
+	}
+	results = append(results, authResult)
+
+	// Configuration
+	configResult := doctorResult{Name: "Configuration", Required: true}
+	if configValidationErr != nil {
+		configResult.Status = doctorStatusFail
+		configResult.Detail = strings.TrimSpace(configValidationErr.Error())
+		configResult.Remediation = "Fix the values in the config file (-C option) or in the CLI_* environment variables."
+	} else {
+		configResult.Status = doctorStatusPass
+		configResult.Detail = fmt.Sprintf("valid (environment '%s', project '%s')", config.Properties.DefaultEnvironment, config.Properties.DefaultGCPProject)
+	}
+	results = append(results, configResult)
+
+	// Embedded yq
+	yqResult := doctorResult{Name: "yq", Required: true}
+	if version, err := fileeditor.CheckYq(); err != nil {
+		yqResult.Status = doctorStatusFail
+		yqResult.Detail = err.Error()
+		yqResult.Remediation = "Ensure the temporary directory is writable and allows executables, then run 'pires-cli update' if the problem persists."
+	} else {
+		yqResult.Status = doctorStatusPass
+		yqResult.Detail = version
+	}
+	results = append(results, yqResult)
+
+	// VPN connectivity (optional)
+	vpnResult := doctorResult{Name: "VPN connectivity", Required: config.VPNCheckConnection}
+	if !config.VPNCheckConnection {
+		vpnResult.Status = doctorStatusSkip
+		vpnResult.Detail = "use --vpn-check-connection option to check"
+	} else if err := common.CheckVPNConnection(config.Properties.DefaultVPNAddressTarget); err != nil {
+		vpnResult.Status = doctorStatusFail
+		vpnResult.Detail = err.Error()
+		vpnResult.Remediation = "Connect to the VPN or review the --vpn-address-target option."
+	} else {
+		vpnResult.Status = doctorStatusPass
+		vpnResult.Detail = fmt.Sprintf("connected to %s", config.Properties.DefaultVPNAddressTarget)
+	}
+	results = append(results, vpnResult)
+
+	return results
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd) // Add doctor to parent root command
+
+}
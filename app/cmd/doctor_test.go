@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeCommandsPath replaces PATH by a directory with a script for each command during the test
+func fakeCommandsPath(t *testing.T, scripts map[string]string) {
+	t.Helper()
+	dir := t.TempDir()
+	for name, body := range scripts {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"+body+"\n"), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("PATH", dir)
+}
+
+// findDoctorResult returns the result of the check named name
+func findDoctorResult(t *testing.T, results []doctorResult, name string) doctorResult {
+	t.Helper()
+	for _, result := range results {
+		if result.Name == name {
+			return result
+		}
+	}
+	t.Fatalf("check %q not found in %v", name, results)
+	return doctorResult{}
+}
+
+func TestRunDoctorChecksMissingGcloud(t *testing.T) {
+	fakeCommandsPath(t, map[string]string{"git": "exit 0", "kubectl": "exit 0"})
+
+	results := runDoctorChecks()
+
+	commands := findDoctorResult(t, results, "Commands")
+	if commands.Status != doctorStatusFail || !commands.Required {
+		t.Errorf("Commands = %+v, want required failed check", commands)
+	}
+	if !strings.Contains(commands.Detail, "gcloud") || strings.Contains(commands.Detail, "kubectl") {
+		t.Errorf("Commands detail = %q, want only gcloud missing", commands.Detail)
+	}
+	if commands.Remediation == "" {
+		t.Error("Commands failed without remediation")
+	}
+
+	// The authentication can't be checked without gcloud
+	if auth := findDoctorResult(t, results, "gcloud authentication"); auth.Status != doctorStatusSkip {
+		t.Errorf("gcloud authentication status = %s, want %s", auth.Status, doctorStatusSkip)
+	}
+	if vpn := findDoctorResult(t, results, "VPN connectivity"); vpn.Status != doctorStatusSkip || vpn.Required {
+		t.Errorf("VPN connectivity = %+v, want optional skipped check", vpn)
+	}
+}
+
+func TestRunDoctorChecksGcloudAuthenticated(t *testing.T) {
+	fakeCommandsPath(t, map[string]string{
+		"git":      "exit 0",
+		"kubectl":  "exit 0",
+		"gcloud":   "echo operator@example.com",
+		"conftest": "exit 0",
+	})
+
+	results := runDoctorChecks()
+
+	if commands := findDoctorResult(t, results, "Commands"); commands.Status != doctorStatusPass {
+		t.Errorf("Commands = %+v, want passed check", commands)
+	}
+	if optional := findDoctorResult(t, results, "Optional commands"); optional.Status != doctorStatusPass {
+		t.Errorf("Optional commands = %+v, want passed check", optional)
+	}
+	auth := findDoctorResult(t, results, "gcloud authentication")
+	if auth.Status != doctorStatusPass || !strings.Contains(auth.Detail, "operator@example.com") {
+		t.Errorf("gcloud authentication = %+v, want passed check with the account", auth)
+	}
+}
+
+func TestRunDoctorChecksGcloudNotAuthenticated(t *testing.T) {
+	fakeCommandsPath(t, map[string]string{"git": "exit 0", "kubectl": "exit 0", "gcloud": "exit 0"})
+
+	auth := findDoctorResult(t, runDoctorChecks(), "gcloud authentication")
+	if auth.Status != doctorStatusFail || auth.Remediation == "" {
+		t.Errorf("gcloud authentication = %+v, want failed check with remediation", auth)
+	}
+}
@@ -1,8 +1,13 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
+	"strings"
 
+	"github.com/aeciopires/pires-cli/internal/config"
+	"github.com/aeciopires/pires-cli/pkg/pireslib/common"
+	"github.com/aeciopires/pires-cli/pkg/pireslib/gcp"
 	"github.com/spf13/cobra"
 )
 
@@ -12,20 +17,329 @@ var (
 	gcpCmd = &cobra.Command{
 		Use:   "gcp",
 		Short: "Perform Google Cloud Platform operations",
-		Long:  `Provides commands to interact with GCP services like Cloud SQL, IAM, etc.`,
+		Long: `Provides commands to interact with GCP services like Cloud SQL, IAM, etc.
+	The arguments after '--' are appended verbatim to the primary gcloud command executed by the subcommand (e.g. the
+	create, patch, delete or export), after the arguments built by CLI (e.g. 'pires-cli gcp cloudsql create-instance ... -- --labels=team=ops').
+	The helper gcloud commands (admin permissions and region checks, lists, describes and waits of operations) don't
+	receive them. The subcommands without a primary gcloud command reject them.`,
 		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 			// This runs before any gcp subcommand
+			// ATTENTION!!! Cobra runs only the nearest PersistentPreRun, so the subcommands
+			// with their own PersistentPreRunE (cloudsql, iam, firewall, gke) call this function too.
 
-			return nil
+			// Validate the region early, avoiding late failures in the middle of operations
+			return gcp.ValidateGCPRegion(config.Properties.DefaultGCPProject, config.Properties.DefaultGCPRegion)
 		},
 		Run: func(cmd *cobra.Command, args []string) {
 			fmt.Println("GCP command requires a subcommand (e.g., cloudsql, iam).")
 			cmd.Help()
 		},
 	}
+
+	// --- Auth Status Subcommand ---
+	gcpAuthStatusOutputFormat string
+
+	gcpAuthStatusCmd = &cobra.Command{
+		Use:   "auth-status",
+		Short: "Show the gcloud authentication status",
+		Long: `Shows the active account of gcloud, the status of Application Default Credentials (ADC)
+	and the configured project. It doesn't fail when gcloud is not authenticated.`,
+		// Override the gcp PersistentPreRunE, because this command is read-only and must work without a valid region
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error { return nil },
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			if gcpAuthStatusOutputFormat != "text" && gcpAuthStatusOutputFormat != "json" {
+				return common.NewValidationError("Unsupported output format '%s'. Supported values: text or json", gcpAuthStatusOutputFormat)
+			}
+
+			status := gcp.GetGcloudAuthStatus(config.Properties.DefaultGCPProject)
+
+			if gcpAuthStatusOutputFormat == "json" {
+				statusJSON, err := json.MarshalIndent(status, "", "  ")
+				if err != nil {
+					return fmt.Errorf("failed to encode auth status: %w", err)
+				}
+				fmt.Println(string(statusJSON))
+				return nil
+			}
+
+			account := "not authenticated"
+			if status.Authenticated {
+				account = status.Account
+			}
+			adc := "not available"
+			if status.ADCAvailable {
+				adc = "available"
+			}
+			fmt.Printf("Account:        %s\n", account)
+			fmt.Printf("ADC:            %s\n", adc)
+			fmt.Printf("Project:        %s\n", status.Project)
+			fmt.Printf("gcloud project: %s\n", status.GcloudProject)
+			return nil
+		},
+	}
+
+	// --- Check ADC Subcommand ---
+	gcpCheckADCCmd = &cobra.Command{
+		Use:   "check-adc",
+		Short: "Check if the Application Default Credentials (ADC) of gcloud are valid",
+		Long: `Runs 'gcloud auth application-default print-access-token' to check if the Application Default Credentials (ADC),
+	used by automation and client libraries, are valid. The access token is never printed.
+	Exit with non-zero code if ADC are missing or expired. Use 'refresh-adc' command to renew them.`,
+		// Override the gcp PersistentPreRunE, because this command is read-only and must work without a valid region
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error { return nil },
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			if err := gcp.CheckGcloudADC(); err != nil {
+				fmt.Println("ADC: invalid")
+				return err
+			}
+			fmt.Println("ADC: valid")
+			return nil
+		},
+	}
+
+	// --- Refresh ADC Subcommand ---
+	gcpRefreshADCForce bool
+
+	gcpRefreshADCCmd = &cobra.Command{
+		Use:   "refresh-adc",
+		Short: "Renew the Application Default Credentials (ADC) of gcloud when they are invalid",
+		Long: `Checks the Application Default Credentials (ADC) like 'check-adc' command and, if they are missing or expired,
+	runs 'gcloud auth application-default login' interactively. Use --force to renew valid credentials too.
+	It requires an interactive terminal.`,
+		// Override the gcp PersistentPreRunE, because this command must work without a valid region
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error { return nil },
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			if !gcpRefreshADCForce {
+				errADC := gcp.CheckGcloudADC()
+				if errADC == nil {
+					common.Logger("info", "Application Default Credentials are valid. Use --force to renew them anyway.")
+					return nil
+				}
+				common.Logger("warning", "%v", errADC)
+			}
+
+			if err := gcp.RefreshGcloudADC(); err != nil {
+				return err
+			}
+			if err := gcp.CheckGcloudADC(); err != nil {
+				return err
+			}
+			common.Logger("info", "Application Default Credentials renewed successfully.")
+			return nil
+		},
+	}
+
+	// --- Config Status Subcommand ---
+	gcpConfigStatusOutputFormat string
+
+	gcpConfigStatusCmd = &cobra.Command{
+		Use:   "config-status",
+		Short: "Compare the active configuration of gcloud with the CLI configuration",
+		Long: `Shows the active account, project and region of gcloud and compares the project and region
+	with the ones configured in CLI (-P/-R options or CLI_GCP_PROJECT/CLI_GCP_REGION variables).
+	Warns when they differ, because the commands of CLI use its own project and region.`,
+		// Override the gcp PersistentPreRunE, because this command is read-only and must work without a valid region
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error { return nil },
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			if gcpConfigStatusOutputFormat != "text" && gcpConfigStatusOutputFormat != "json" {
+				return common.NewValidationError("Unsupported output format '%s'. Supported values: text or json", gcpConfigStatusOutputFormat)
+			}
+
+			status, err := gcp.GetGcloudConfigStatus(config.Properties.DefaultGCPProject, config.Properties.DefaultGCPRegion)
+			if err != nil {
+				return err
+			}
+
+			if gcpConfigStatusOutputFormat == "json" {
+				statusJSON, err := json.MarshalIndent(status, "", "  ")
+				if err != nil {
+					return fmt.Errorf("failed to encode config status: %w", err)
+				}
+				fmt.Println(string(statusJSON))
+				return nil
+			}
+
+			for _, warning := range status.Warnings {
+				common.Logger("warning", "%s", warning)
+			}
+			fmt.Printf("Account:        %s\n", status.Account)
+			fmt.Printf("gcloud project: %s\n", status.GcloudProject)
+			fmt.Printf("gcloud region:  %s\n", status.GcloudRegion)
+			fmt.Printf("CLI project:    %s\n", status.Project)
+			fmt.Printf("CLI region:     %s\n", status.Region)
+			return nil
+		},
+	}
+
+	// --- Set Project Subcommand ---
+	gcpSetProjectRegion bool
+
+	gcpSetProjectCmd = &cobra.Command{
+		Use:   "set-project",
+		Short: "Set the active project of gcloud to the project of CLI",
+		Long: `Runs 'gcloud config set project' with the project configured in CLI (-P option or CLI_GCP_PROJECT variable),
+	keeping gcloud and CLI in sync. Use --region to set the compute region of gcloud to the region of CLI too.
+	The active values before and after the change are printed.`,
+		Example: `  pires-cli gcp set-project --region`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			properties := [][2]string{{"project", config.Properties.DefaultGCPProject}}
+			if gcpSetProjectRegion {
+				properties = append(properties, [2]string{"compute/region", config.Properties.DefaultGCPRegion})
+			}
+
+			var changes []string
+			for _, property := range properties {
+				changes = append(changes, fmt.Sprintf("%s to '%s'", property[0], property[1]))
+			}
+			confirmed, err := common.Confirm(fmt.Sprintf("Set the gcloud %s?", strings.Join(changes, " and ")))
+			if err != nil {
+				return err
+			}
+			if !confirmed {
+				common.Logger("info", "Operation cancelled.")
+				return nil
+			}
+
+			for _, property := range properties {
+				before, err := gcp.SetGcloudConfigValue(property[0], property[1])
+				if err != nil {
+					return err
+				}
+				if before == "" {
+					before = "(unset)"
+				}
+				fmt.Printf("gcloud %s: %s -> %s\n", property[0], before, property[1])
+			}
+			return nil
+		},
+	}
+
+	// --- Verify Context Subcommand ---
+	gcpVerifyContextOutputFormat string
+
+	gcpVerifyContextCmd = &cobra.Command{
+		Use:   "verify-context",
+		Short: "Verify that gcloud and kubectl point to the same project",
+		Long: `Compares the active project of gcloud with the project in the name of current kubectl context
+	(gke_{project}_{location}_{cluster}). Warns when they differ, avoiding to apply manifests to the cluster of wrong project.
+	The absence of current context of kubectl is only a warning.`,
+		// Override the gcp PersistentPreRunE, because this command is read-only and must work without a valid region
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error { return nil },
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			if gcpVerifyContextOutputFormat != "text" && gcpVerifyContextOutputFormat != "json" {
+				return common.NewValidationError("Unsupported output format '%s'. Supported values: text or json", gcpVerifyContextOutputFormat)
+			}
+
+			status, err := gcp.GetGKEContextStatus()
+			if err != nil {
+				return err
+			}
+
+			if gcpVerifyContextOutputFormat == "json" {
+				statusJSON, err := json.MarshalIndent(status, "", "  ")
+				if err != nil {
+					return fmt.Errorf("failed to encode context status: %w", err)
+				}
+				fmt.Println(string(statusJSON))
+				return nil
+			}
+
+			for _, warning := range status.Warnings {
+				common.Logger("warning", "%s", warning)
+			}
+			fmt.Printf("gcloud project:  %s\n", status.GcloudProject)
+			fmt.Printf("kubectl context: %s\n", status.KubectlContext)
+			fmt.Printf("context project: %s\n", status.ContextProject)
+			if !status.Mismatch && status.ContextProject != "" {
+				common.Logger("info", "gcloud and kubectl point to the same project.")
+			}
+			return nil
+		},
+	}
 )
 
 func init() {
 	rootCmd.AddCommand(gcpCmd) // Add gcpCmd to the root command
 
+	// Add subcommands to gcpCmd
+	gcpCmd.AddCommand(gcpAuthStatusCmd)
+	gcpCmd.AddCommand(gcpCheckADCCmd)
+	gcpCmd.AddCommand(gcpRefreshADCCmd)
+	gcpCmd.AddCommand(gcpConfigStatusCmd)
+	gcpCmd.AddCommand(gcpVerifyContextCmd)
+	gcpCmd.AddCommand(gcpSetProjectCmd)
+
+	// Flags for all gcp subcommands
+	gcpCmd.PersistentFlags().StringVar(&config.GCPImpersonateServiceAccount, "impersonate-service-account", "", "Email of service account impersonated by all gcloud commands. The admin permissions are checked for this service account (e.g. admin-gsa@nonprod.iam.gserviceaccount.com)")
+
+	// Flags for 'gcp auth-status'
+	gcpAuthStatusCmd.Flags().StringVarP(&gcpAuthStatusOutputFormat, "output-format", "o", "text", "Output format. Supported values: text or json")
+
+	// Flags for 'gcp refresh-adc'
+	gcpRefreshADCCmd.Flags().BoolVarP(&gcpRefreshADCForce, "force", "f", false, "Renew the credentials even if they are valid")
+
+	// Flags for 'gcp config-status'
+	gcpConfigStatusCmd.Flags().StringVarP(&gcpConfigStatusOutputFormat, "output-format", "o", "text", "Output format. Supported values: text or json")
+
+	// Flags for 'gcp set-project'
+	gcpSetProjectCmd.Flags().BoolVar(&gcpSetProjectRegion, "region", false, "Set the compute region of gcloud to the region of CLI too")
+
+	// Flags for 'gcp verify-context'
+	gcpVerifyContextCmd.Flags().StringVarP(&gcpVerifyContextOutputFormat, "output-format", "o", "text", "Output format. Supported values: text or json")
+
+}
+
+// gcloudExtraArgsAnnotation is the annotation of gcp commands that pass the arguments after '--'
+// to their primary gcloud command. See enableGcloudExtraArgs function
+const gcloudExtraArgsAnnotation = "gcloudExtraArgs"
+
+// enableGcloudExtraArgs wraps the Run(E) of cmd and its subcommands, so the arguments after '--' are stored
+// in config.GcloudExtraArgs and appended to the primary gcloud command (see gcp.RunGcloudPrimaryCommand function).
+// They are set only when RunE starts, so the checks of PersistentPreRun(E) don't receive them.
+// The commands without gcloudExtraArgsAnnotation reject them, instead of silently ignore.
+func enableGcloudExtraArgs(cmd *cobra.Command) {
+	for _, subcommand := range cmd.Commands() {
+		enableGcloudExtraArgs(subcommand)
+	}
+
+	if cmd.Run != nil {
+		run := cmd.Run
+		cmd.Run = func(cmd *cobra.Command, args []string) {
+			if err := checkGcloudExtraArgsSupported(cmd); err != nil {
+				common.Exit(err)
+			}
+			run(cmd, args)
+		}
+	}
+	if cmd.RunE == nil {
+		return
+	}
+
+	runE := cmd.RunE
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		if err := checkGcloudExtraArgsSupported(cmd); err != nil {
+			return err
+		}
+		if dashIndex := cmd.ArgsLenAtDash(); dashIndex >= 0 {
+			config.GcloudExtraArgs = args[dashIndex:]
+			args = args[:dashIndex]
+			common.Logger("debug", "Extra arguments of gcloud: %s", strings.Join(config.GcloudExtraArgs, " "))
+		}
+		return runE(cmd, args)
+	}
+}
+
+// checkGcloudExtraArgsSupported returns a validation error if arguments were passed after '--'
+// to a command without gcloudExtraArgsAnnotation.
+func checkGcloudExtraArgsSupported(cmd *cobra.Command) error {
+	if cmd.ArgsLenAtDash() < 0 || cmd.Annotations[gcloudExtraArgsAnnotation] == "true" {
+		return nil
+	}
+	return common.NewValidationError("The command '%s' doesn't support extra gcloud arguments after '--'", cmd.CommandPath())
 }
@@ -1,36 +1,49 @@
 package cmd
 
 import (
+	"encoding/json"
+	"fmt"
+	"os"
 	"reflect"
-	"syscall"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/aeciopires/pires-cli/internal/config"
 	"github.com/aeciopires/pires-cli/pkg/pireslib/common"
 	"github.com/aeciopires/pires-cli/pkg/pireslib/gcp"
 	"github.com/spf13/cobra"
-	"golang.org/x/term"
 )
 
 // Local variables
 var (
-	cloudsqlInstanceID    string
-	cloudsqlUserName      string
-	cloudsqlPassword      string // Consider prompting for password
-	cloudsqlHost          string
-	cloudsqlAddress       string
-	cloudsqlPort          string
-	cloudsqlDBName        string
-	cloudsqlDBCharset     string
-	cloudsqlDBCollation   string
-	cloudsqlDBIgnoreRegex string
-	cloudsqlSSLRequired   bool
-	outputReportDir       string
+	cloudsqlInstanceID      string
+	cloudsqlUserName        string
+	cloudsqlPassword        string // Consider prompting for password
+	cloudsqlHost            string
+	cloudsqlAddress         string
+	cloudsqlPort            string
+	cloudsqlDBName          string
+	cloudsqlDBCharset       string
+	cloudsqlDBCollation     string
+	cloudsqlDBIgnoreRegex   string
+	cloudsqlDBInclude       []string
+	cloudsqlConnectionName  string
+	cloudsqlSSLRequired     bool
+	outputReportDir         string
+	reportFilenameTemplate  string
+	auditFilenameTemplate   string // Separate from reportFilenameTemplate, because each command has its own default value
+	sizesFilenameTemplate   string // Separate from reportFilenameTemplate, because each command has its own default value
+	reportAppendTo          string
+	reportMetadata          common.ReportMetadata
+	reportAuditLogsFormat   string
+	reportPermissionsFormat string
 
 	// cloudsqlCmd represents the cloudsql command
 	cloudsqlCmd = &cobra.Command{
 		Use:   "cloudsql",
 		Short: "Manage Cloud SQL instances, users, and databases",
-		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 
 			// Debug message is displayed if -D option was passed
 			common.Logger("debug", "====> Values loaded in cmd/gcp-cloudsql subcommand")
@@ -44,9 +57,14 @@ var (
 				common.Logger("debug", "Field: %s, Value: %v", fieldName, fieldValue)
 			}
 
+			// Run the gcp PersistentPreRunE, because cobra runs only the nearest PersistentPreRun
+			if err := gcpCmd.PersistentPreRunE(cmd, args); err != nil {
+				return err
+			}
+
 			// GCP Admin Permissions Check
 			common.Logger("debug", "Performing admin permission checks as requested...")
-			gcp.CheckGcloudAdminPermissions(config.Properties.DefaultGCPProject)
+			return gcp.CheckGcloudAdminPermissions(config.Properties.DefaultGCPProject)
 		},
 	}
 
@@ -54,10 +72,11 @@ var (
 	cloudsqlCreateUserCmd = &cobra.Command{
 		Use:   "create-user",
 		Short: "Create a new user in a Cloud SQL instance",
+		// The arguments after '--' are passed to the primary gcloud command
+		Annotations: map[string]string{gcloudExtraArgsAnnotation: "true"},
 		RunE: func(cmd *cobra.Command, args []string) error {
 
-			gcp.CreateGCPCloudSQLUser(config.Properties.DefaultGCPProject, cloudsqlInstanceID, cloudsqlUserName, cloudsqlPassword, cloudsqlHost)
-			return nil
+			return gcp.CreateGCPCloudSQLUser(config.Properties.DefaultGCPProject, cloudsqlInstanceID, cloudsqlUserName, cloudsqlPassword, cloudsqlHost)
 		},
 	}
 
@@ -65,36 +84,141 @@ var (
 	cloudsqlCreateDatabaseCmd = &cobra.Command{
 		Use:   "create-database",
 		Short: "Create a new database in a Cloud SQL instance",
+		// The arguments after '--' are passed to the primary gcloud command
+		Annotations: map[string]string{gcloudExtraArgsAnnotation: "true"},
 		RunE: func(cmd *cobra.Command, args []string) error {
 
-			gcp.CreateGCPCloudSQLDatabase(config.Properties.DefaultGCPProject, cloudsqlInstanceID, cloudsqlDBName, cloudsqlDBCharset, cloudsqlDBCollation)
-			return nil
+			return gcp.CreateGCPCloudSQLDatabase(config.Properties.DefaultGCPProject, cloudsqlInstanceID, cloudsqlDBName, cloudsqlDBCharset, cloudsqlDBCollation)
 		},
 	}
 
 	// --- Export PostgreSQL Users Permissions Subcommand ---
+	cloudsqlExportResume bool
+
 	exportPostgreSQLUsersPermissionsCmd = &cobra.Command{
 		Use:   "export-postgresql-users-permissions",
 		Short: "Exports PostgreSQL users and permissions from a Cloud SQL instance.",
 		Long: `Connects to a specified PostgreSQL database within a Cloud SQL instance and
-	exports a list of all roles (users), their attributes, and memberships to a .txt file.`,
-		Run: func(cmd *cobra.Command, args []string) {
-			// Prompt for password if not provided via flag for better security
-			if cloudsqlPassword == "" {
-				common.Logger("info", "Enter password for user '%s': ", cloudsqlUserName)
-
-				// ReadPassword takes a file descriptor (int) as input.
-				// syscall.Stdin represents the standard input file descriptor.
-				bytePassword, err := term.ReadPassword(int(syscall.Stdin))
-				if err != nil {
-					common.Logger("fatal", "Error reading password: %v", err)
+	exports a list of all roles (users), their attributes, and memberships to a .txt file.
+	The progress is saved in a hidden state file of the output directory. If some databases fail (or the export is
+	cancelled), use --resume to retry only the remaining databases and complete the same report.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if config.PostgresQueryAttempts < 1 {
+				return common.NewValidationError("--query-attempts must be greater than or equal to 1")
+			}
+			if config.PostgresConnectTimeout < time.Second {
+				return common.NewValidationError("--db-connect-timeout must be greater than or equal to 1s")
+			}
+			if config.PostgresMaxOpenConns < 1 {
+				return common.NewValidationError("--db-max-open-conns must be greater than or equal to 1")
+			}
+			if reportPermissionsFormat != gcp.PermissionsReportFormatText && reportPermissionsFormat != gcp.PermissionsReportFormatJSON {
+				return common.NewValidationError("Unsupported format '%s'. Supported values: text or json", reportPermissionsFormat)
+			}
+
+			projectID, err := resolvePostgresConnection(cmd)
+			if err != nil {
+				return err
+			}
+
+			return gcp.ExportPostgresUsersAndPermissions(gcp.ExportPostgresOptions{
+				ProjectID:        projectID,
+				InstanceID:       cloudsqlInstanceID,
+				Host:             cloudsqlAddress,
+				Port:             cloudsqlPort,
+				User:             cloudsqlUserName,
+				Password:         cloudsqlPassword,
+				SSLRequired:      cloudsqlSSLRequired,
+				OutputDir:        outputReportDir,
+				IncludeDatabases: cloudsqlDBInclude,
+				ExcludePattern:   cloudsqlDBIgnoreRegex,
+				FilenameTemplate: reportFilenameTemplate,
+				Format:           reportPermissionsFormat,
+				Resume:           cloudsqlExportResume,
+				Metadata:         reportMetadata,
+			})
+		},
+	}
+
+	// --- Export Database Sizes Subcommand ---
+	exportPostgreSQLDatabaseSizesCmd = &cobra.Command{
+		Use:   "export-postgresql-database-sizes",
+		Short: "Exports the size and number of tables of PostgreSQL databases from a Cloud SQL instance.",
+		Long: `Connects to the PostgreSQL databases of a Cloud SQL instance (like the 'export-postgresql-users-permissions' command)
+	and exports the size (human-readable, like 1.5 GiB) and the number of tables of each database to a .txt file, for capacity planning.`,
+		Example: `  pires-cli gcp cloudsql export-postgresql-database-sizes -i nonprod-psql -a mydb.example.com -u postgres -o $HOME/reports`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if config.PostgresQueryAttempts < 1 {
+				return common.NewValidationError("--query-attempts must be greater than or equal to 1")
+			}
+			if config.PostgresConnectTimeout < time.Second {
+				return common.NewValidationError("--db-connect-timeout must be greater than or equal to 1s")
+			}
+			if config.PostgresMaxOpenConns < 1 {
+				return common.NewValidationError("--db-max-open-conns must be greater than or equal to 1")
+			}
+
+			projectID, err := resolvePostgresConnection(cmd)
+			if err != nil {
+				return err
+			}
+
+			return gcp.ExportPostgresDatabaseSizes(projectID, cloudsqlInstanceID, cloudsqlAddress, cloudsqlPort, cloudsqlUserName, cloudsqlPassword, outputReportDir, cloudsqlDBIgnoreRegex, sizesFilenameTemplate, cloudsqlDBInclude, cloudsqlSSLRequired)
+		},
+	}
+
+	// --- Grant DB Access Subcommand ---
+	cloudsqlGrantUser       string
+	cloudsqlGrantSchema     string
+	cloudsqlGrantPrivileges []string
+	cloudsqlGrantDryRun     bool
+
+	cloudsqlGrantDBAccessCmd = &cobra.Command{
+		Use:   "grant-db-access",
+		Short: "Grant privileges on the tables of a PostgreSQL database to a user",
+		Long: `Connects to a database of PostgreSQL Cloud SQL instance (like the 'export-postgresql-users-permissions' command)
+	and grants the privileges on all tables of schema to the user (e.g. an IAM database user), plus the CONNECT on database
+	and USAGE on schema required to use them. All statements run in a single transaction.
+	Supported privileges: ` + strings.Join(gcp.PostgresGrantablePrivileges, ", ") + `.`,
+		Example: `  pires-cli gcp cloudsql grant-db-access -i nonprod-psql -a mydb.example.com -u postgres --user app@nonprod.iam --database app-db --privileges select,insert,update`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			statements, err := gcp.BuildPostgresGrantSQL(cloudsqlDBName, cloudsqlGrantSchema, cloudsqlGrantUser, cloudsqlGrantPrivileges)
+			if err != nil {
+				return err
+			}
+
+			if cloudsqlGrantDryRun {
+				common.Logger("info", "[DRY-RUN] The following statements would be run in database '%s':", cloudsqlDBName)
+				for _, statement := range statements {
+					fmt.Println(statement)
 				}
+				return nil
+			}
 
-				// Convert the byte slice to a string for use.
-				cloudsqlPassword = string(bytePassword)
+			projectID, err := resolvePostgresConnection(cmd)
+			if err != nil {
+				return err
 			}
 
-			gcp.ExportPostgresUsersAndPermissions(config.Properties.DefaultGCPProject, cloudsqlInstanceID, cloudsqlAddress, cloudsqlPort, cloudsqlUserName, cloudsqlPassword, outputReportDir, cloudsqlDBIgnoreRegex, cloudsqlSSLRequired)
+			confirmed, err := common.Confirm(fmt.Sprintf("Grant %s on tables of schema '%s' in database '%s' of instance '%s' to user '%s'?",
+				strings.Join(cloudsqlGrantPrivileges, ", "), cloudsqlGrantSchema, cloudsqlDBName, cloudsqlInstanceID, cloudsqlGrantUser))
+			if err != nil {
+				return err
+			}
+			if !confirmed {
+				common.Logger("info", "Operation cancelled.")
+				return nil
+			}
+
+			if err := gcp.GrantPostgresDatabaseAccess(cloudsqlAddress, cloudsqlPort, cloudsqlUserName, cloudsqlPassword, cloudsqlDBName, cloudsqlSSLRequired, statements); err != nil {
+				return err
+			}
+			for _, statement := range statements {
+				common.Logger("info", "Granted: %s", statement)
+			}
+			common.Logger("info", "Successfully granted access in database '%s' of instance '%s' in project '%s' to user '%s'", cloudsqlDBName, cloudsqlInstanceID, projectID, cloudsqlGrantUser)
+			return nil
 		},
 	}
 
@@ -106,8 +230,392 @@ var (
 	filtering for INSERT, UPDATE, and DELETE statements. This requires the 'cloudsql.enable_pgaudit'
 	database flag to be enabled on the instance. More details: https://cloud.google.com/sql/docs/postgres/flags and
 	https://cloud.google.com/sql/docs/postgres/pg-audit`,
-		Run: func(cmd *cobra.Command, args []string) {
-			gcp.ExportPostgresAuditLogs(config.Properties.DefaultGCPProject, cloudsqlInstanceID, outputReportDir)
+		// The arguments after '--' are passed to the primary gcloud command
+		Annotations: map[string]string{gcloudExtraArgsAnnotation: "true"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			if reportAuditLogsFormat != gcp.AuditLogsFormatText && reportAuditLogsFormat != gcp.AuditLogsFormatJSON {
+				return common.NewValidationError("Unsupported format '%s'. Supported values: text or json", reportAuditLogsFormat)
+			}
+			if reportAuditLogsFormat == gcp.AuditLogsFormatJSON && reportAppendTo != "" {
+				return common.NewValidationError("The --append-to option is not supported with json format, because the result would be an invalid JSON file")
+			}
+
+			return gcp.ExportPostgresAuditLogs(config.Properties.DefaultGCPProject, cloudsqlInstanceID, outputReportDir, auditFilenameTemplate, reportAppendTo, reportAuditLogsFormat)
+		},
+	}
+
+	// --- Check Audit Readiness Subcommand ---
+	cloudsqlAuditReadinessOutputFormat string
+
+	cloudsqlCheckAuditReadinessCmd = &cobra.Command{
+		Use:   "check-audit-readiness",
+		Short: "Check if the audit logs of a PostgreSQL instance can be exported",
+		Long: `Checks the requirements of 'export-postgresql-audit-logs' command before running it: the database flag
+	'cloudsql.enable_pgaudit' must be on, the database flag 'pgaudit.log' must include the 'write' class (or 'all')
+	and the log of PostgreSQL must exist in Cloud Logging. The problems are listed with their remediations
+	(e.g. the 'set-flag' command) and the exit code is non-zero if the instance is not ready.`,
+		Example: `  pires-cli gcp cloudsql check-audit-readiness -i nonprod-psql`,
+		// Override the cloudsql PersistentPreRun, because this command is read-only and doesn't require admin permissions
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {},
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			if cloudsqlAuditReadinessOutputFormat != "text" && cloudsqlAuditReadinessOutputFormat != "json" {
+				return common.NewValidationError("Unsupported output format '%s'. Supported values: text or json", cloudsqlAuditReadinessOutputFormat)
+			}
+
+			readiness, err := gcp.CheckPostgresAuditReadiness(config.Properties.DefaultGCPProject, cloudsqlInstanceID)
+			if err != nil {
+				return err
+			}
+
+			if cloudsqlAuditReadinessOutputFormat == "json" {
+				readinessJSON, err := json.MarshalIndent(readiness, "", "  ")
+				if err != nil {
+					return fmt.Errorf("failed to encode audit readiness: %w", err)
+				}
+				fmt.Println(string(readinessJSON))
+			} else {
+				pgauditLog := readiness.PgauditLog
+				if pgauditLog == "" {
+					pgauditLog = "(unset)"
+				}
+				fmt.Printf("%s:  %t\n", gcp.PgauditEnableFlag, readiness.PgauditEnabled)
+				fmt.Printf("%s:              %s\n", gcp.PgauditLogFlag, pgauditLog)
+				fmt.Printf("Log exists:               %t (%s)\n", readiness.LogExists, readiness.LogName)
+				for i, problem := range readiness.Problems {
+					common.Logger("warning", "Problem: %s. Remediation: %s", problem, readiness.Remediations[i])
+				}
+			}
+
+			if !readiness.Ready {
+				return common.NewFindingsError("instance '%s' is not ready to export audit logs: %d problem(s) found", cloudsqlInstanceID, len(readiness.Problems))
+			}
+			common.Logger("info", "Instance '%s' is ready to export audit logs.", cloudsqlInstanceID)
+			return nil
+		},
+	}
+
+	// --- Wait Operation Subcommand ---
+	cloudsqlOperationID      string
+	cloudsqlOperationTimeout time.Duration
+
+	cloudsqlWaitCmd = &cobra.Command{
+		Use:   "wait",
+		Short: "Wait for a Cloud SQL operation to complete",
+		Long: `Polls a Cloud SQL operation (e.g. create or patch of instance) until its status is DONE or the timeout is reached.
+	Exit with non-zero code if the operation finished with errors.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			return gcp.WaitForGCPCloudSQLOperation(config.Properties.DefaultGCPProject, cloudsqlOperationID, cloudsqlOperationTimeout)
+		},
+	}
+
+	// --- List Instances Subcommand ---
+	cloudsqlListFilter string
+
+	cloudsqlListInstancesCmd = &cobra.Command{
+		Use:   "list-instances",
+		Short: "List Cloud SQL instances in a project",
+		// Override the cloudsql PersistentPreRun, because this command is read-only and doesn't require admin permissions
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {},
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			instances, err := gcp.ListGCPCloudSQLInstances(config.Properties.DefaultGCPProject)
+			if err != nil {
+				return err
+			}
+
+			var rows [][]string
+			for _, instance := range instances {
+				if cloudsqlListFilter != "" && !strings.Contains(instance.Name, cloudsqlListFilter) {
+					continue
+				}
+				rows = append(rows, []string{instance.Name, instance.DatabaseVersion, instance.Region, instance.State})
+			}
+			return common.WriteTable(os.Stdout, []string{"NAME", "DATABASE_VERSION", "REGION", "STATE"}, rows)
+		},
+	}
+
+	// --- Export Backup Subcommand ---
+	cloudsqlBackupBucket    string
+	cloudsqlBackupDatabases []string
+
+	cloudsqlExportBackupCmd = &cobra.Command{
+		Use:   "export-backup",
+		Short: "Export the databases of a Cloud SQL instance to a GCS bucket",
+		Long: `Exports the databases of a Cloud SQL instance to a timestamped SQL file in a GCS bucket.
+	For PostgreSQL instances, inform the database with --database.
+	The service account of the instance needs write permission on the bucket.`,
+		// The arguments after '--' are passed to the primary gcloud command
+		Annotations: map[string]string{gcloudExtraArgsAnnotation: "true"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			backupURI, err := gcp.ExportGCPCloudSQLBackup(config.Properties.DefaultGCPProject, cloudsqlInstanceID, cloudsqlBackupBucket, cloudsqlBackupDatabases)
+			if err != nil {
+				return err
+			}
+			fmt.Println(backupURI)
+			return nil
+		},
+	}
+
+	// --- List Backups Subcommand ---
+	cloudsqlListBackupsCmd = &cobra.Command{
+		Use:   "list-backups",
+		Short: "List the backups of a Cloud SQL instance",
+		Long: `Lists the backups of a Cloud SQL instance (automated and on-demand), useful to choose one before a restore.
+	The start time of backup window is shown in RFC3339 format.`,
+		Example: `  pires-cli gcp cloudsql list-backups -i nonprod-psql`,
+		// Override the cloudsql PersistentPreRun, because this command is read-only and doesn't require admin permissions
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {},
+		// The arguments after '--' are passed to the primary gcloud command
+		Annotations: map[string]string{gcloudExtraArgsAnnotation: "true"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			backups, err := gcp.ListGCPCloudSQLBackups(config.Properties.DefaultGCPProject, cloudsqlInstanceID)
+			if err != nil {
+				return err
+			}
+			if len(backups) == 0 {
+				common.Logger("info", "No backups found for instance '%s'", cloudsqlInstanceID)
+				return nil
+			}
+
+			var rows [][]string
+			for _, backup := range backups {
+				rows = append(rows, []string{backup.ID, backup.WindowStartTime.Format(time.RFC3339), backup.Status, backup.Type})
+			}
+			return common.WriteTable(os.Stdout, []string{"ID", "WINDOW_START_TIME", "STATUS", "TYPE"}, rows)
+		},
+	}
+
+	// --- Create Backup Subcommand ---
+	cloudsqlBackupDescription string
+	cloudsqlBackupWait        bool
+	cloudsqlBackupTimeout     time.Duration
+
+	cloudsqlCreateBackupCmd = &cobra.Command{
+		Use:   "create-backup",
+		Short: "Create an on-demand backup of a Cloud SQL instance",
+		Long: `Creates an on-demand backup of a Cloud SQL instance, useful before risky changes.
+	Use --wait to wait for the backup and print its ID (or 'cloudsql wait' command later). Without --wait, the operation ID is printed.`,
+		Example: `  pires-cli gcp cloudsql create-backup -i nonprod-psql --description "before migration" --wait`,
+		// The arguments after '--' are passed to the primary gcloud command
+		Annotations: map[string]string{gcloudExtraArgsAnnotation: "true"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			confirmed, err := common.Confirm(fmt.Sprintf("Create an on-demand backup of instance '%s'?", cloudsqlInstanceID))
+			if err != nil {
+				return err
+			}
+			if !confirmed {
+				common.Logger("info", "Operation cancelled.")
+				return nil
+			}
+
+			operationID, err := gcp.CreateGCPCloudSQLBackup(config.Properties.DefaultGCPProject, cloudsqlInstanceID, cloudsqlBackupDescription)
+			if err != nil {
+				return err
+			}
+
+			if !cloudsqlBackupWait {
+				fmt.Println(operationID)
+				return nil
+			}
+			if err := gcp.WaitForGCPCloudSQLOperation(config.Properties.DefaultGCPProject, operationID, cloudsqlBackupTimeout); err != nil {
+				return err
+			}
+
+			backupID, err := gcp.GetGCPCloudSQLBackupID(config.Properties.DefaultGCPProject, operationID)
+			if err != nil {
+				return err
+			}
+			common.Logger("info", "Backup '%s' of instance '%s' created successfully.", backupID, cloudsqlInstanceID)
+			fmt.Println(backupID)
+			return nil
+		},
+	}
+
+	// --- Set Flag Subcommand ---
+	cloudsqlFlagName    string
+	cloudsqlFlagValue   string
+	cloudsqlFlagListAll bool
+
+	cloudsqlSetFlagCmd = &cobra.Command{
+		Use:   "set-flag",
+		Short: "Set a database flag of a Cloud SQL instance",
+		Long: `Reads the current database flags of a Cloud SQL instance, merges the new flag and applies all of them.
+	The existing flags are never dropped. ATTENTION!!! Some flags require the restart of instance.
+	Use --list-current to only show the current flags.`,
+		// The arguments after '--' are passed to the primary gcloud command
+		Annotations: map[string]string{gcloudExtraArgsAnnotation: "true"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			if cloudsqlFlagListAll {
+				currentFlags, err := gcp.GetGCPCloudSQLInstanceFlags(config.Properties.DefaultGCPProject, cloudsqlInstanceID)
+				if err != nil {
+					return err
+				}
+				if len(currentFlags) == 0 {
+					common.Logger("info", "No database flags set in instance '%s'.", cloudsqlInstanceID)
+				}
+				for _, flag := range currentFlags {
+					fmt.Printf("%s=%s\n", flag.Name, flag.Value)
+				}
+				return nil
+			}
+
+			if cloudsqlFlagName == "" {
+				return common.NewValidationError("the --flag option is required (or use --list-current)")
+			}
+
+			common.Logger("warning", "Setting database flag may restart the instance '%s'.", cloudsqlInstanceID)
+			confirmed, err := common.Confirm(fmt.Sprintf("Set flag '%s=%s' in instance '%s'?", cloudsqlFlagName, cloudsqlFlagValue, cloudsqlInstanceID))
+			if err != nil {
+				return err
+			}
+			if !confirmed {
+				common.Logger("info", "Operation cancelled.")
+				return nil
+			}
+
+			return gcp.SetGCPCloudSQLInstanceFlag(config.Properties.DefaultGCPProject, cloudsqlInstanceID, cloudsqlFlagName, cloudsqlFlagValue)
+		},
+	}
+
+	// --- Rotate Password Subcommand ---
+	cloudsqlRotateLength        int
+	cloudsqlRotateCharset       string
+	cloudsqlRotateSecretName    string
+	cloudsqlRotatePrintPassword bool
+
+	cloudsqlRotatePasswordCmd = &cobra.Command{
+		Use:   "rotate-password",
+		Short: "Set a random password to a Cloud SQL user and store it in Secret Manager",
+		Long: `Generates a strong random password, stores it as a new version of a Secret Manager secret (--secret-name)
+	and sets it to the user of Cloud SQL instance. The secret must exist. The password is never displayed in the logs.
+	Use --print-password to display it in the standard output instead of (or besides) storing it in a secret.
+	ATTENTION!!! The applications using the old password lose the access to database.`,
+		// The arguments after '--' are passed to the primary gcloud command
+		Annotations: map[string]string{gcloudExtraArgsAnnotation: "true"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			password, err := common.GeneratePassword(cloudsqlRotateLength, cloudsqlRotateCharset)
+			if err != nil {
+				return err
+			}
+
+			confirmed, err := common.Confirm(fmt.Sprintf("Rotate password of user '%s' in instance '%s'?", cloudsqlUserName, cloudsqlInstanceID))
+			if err != nil {
+				return err
+			}
+			if !confirmed {
+				common.Logger("info", "Operation cancelled.")
+				return nil
+			}
+
+			// The secret is written first, so the new password is never lost if the rotation succeeds
+			secretVersion := ""
+			if cloudsqlRotateSecretName != "" {
+				secretVersion, err = gcp.AddGCPSecretVersion(config.Properties.DefaultGCPProject, cloudsqlRotateSecretName, password)
+				if err != nil {
+					return err
+				}
+			}
+
+			if err := gcp.SetGCPCloudSQLUserPassword(config.Properties.DefaultGCPProject, cloudsqlInstanceID, cloudsqlUserName, cloudsqlHost, password); err != nil {
+				if secretVersion != "" {
+					common.Logger("warning", "The secret version '%s' contains a password that was not applied. Disable it with: gcloud secrets versions disable %s --secret %s --project %s", secretVersion, secretVersion, cloudsqlRotateSecretName, config.Properties.DefaultGCPProject)
+				}
+				return err
+			}
+
+			if cloudsqlRotatePrintPassword {
+				fmt.Println(password)
+			}
+			return nil
+		},
+	}
+
+	// --- Create Instance Subcommand ---
+	cloudsqlInstanceDBVersion string
+	cloudsqlInstanceTier      string
+	cloudsqlInstanceWait      bool
+	cloudsqlInstanceTimeout   time.Duration
+
+	cloudsqlCreateInstanceCmd = &cobra.Command{
+		Use:   "create-instance",
+		Short: "Create a Cloud SQL instance",
+		Long: `Creates a Cloud SQL instance in the region of -R option. The instance creation takes some minutes,
+	so use --wait to wait for the operation (or 'cloudsql wait' command later).`,
+		// The arguments after '--' are passed to the primary gcloud command
+		Annotations: map[string]string{gcloudExtraArgsAnnotation: "true"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			operationID, err := gcp.CreateGCPCloudSQLInstance(config.Properties.DefaultGCPProject, cloudsqlInstanceID, cloudsqlInstanceDBVersion, cloudsqlInstanceTier, config.Properties.DefaultGCPRegion)
+			if err != nil {
+				return err
+			}
+			if operationID == "" {
+				return nil
+			}
+
+			if !cloudsqlInstanceWait {
+				fmt.Println(operationID)
+				return nil
+			}
+			return gcp.WaitForGCPCloudSQLOperation(config.Properties.DefaultGCPProject, operationID, cloudsqlInstanceTimeout)
+		},
+	}
+
+	// --- Check SSL Subcommand ---
+	cloudsqlSSLDaysThreshold int
+
+	cloudsqlCheckSSLCmd = &cobra.Command{
+		Use:   "check-ssl",
+		Short: "Check the expiration of server CA certificates of a Cloud SQL instance",
+		Long: `Shows the expiration of server CA certificates of a Cloud SQL instance and warns about the certificates
+	expiring within --days-threshold days. Exit with non-zero code if any certificate is already expired.`,
+		// Override the cloudsql PersistentPreRun, because this command is read-only and doesn't require admin permissions
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {},
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			if cloudsqlSSLDaysThreshold < 0 {
+				return common.NewValidationError("--days-threshold must be greater than or equal to 0")
+			}
+
+			certs, err := gcp.ListGCPCloudSQLServerCACerts(config.Properties.DefaultGCPProject, cloudsqlInstanceID)
+			if err != nil {
+				return err
+			}
+			if len(certs) == 0 {
+				common.Logger("warning", "No server CA certificates found for instance '%s'", cloudsqlInstanceID)
+				return nil
+			}
+
+			checks := gcp.CheckGCPCloudSQLServerCACerts(certs, time.Now(), cloudsqlSSLDaysThreshold)
+			var rows [][]string
+			expired := 0
+			for _, check := range checks {
+				switch check.Status {
+				case gcp.CloudSQLCertStatusExpired:
+					expired++
+				case gcp.CloudSQLCertStatusExpiring:
+					common.Logger("warning", "Server CA certificate '%s' of instance '%s' expires in %d days (%s)", check.Cert.SHA1Fingerprint, cloudsqlInstanceID, check.DaysLeft, check.Cert.ExpirationTime.Format(time.RFC3339))
+				}
+				rows = append(rows, []string{
+					check.Cert.SHA1Fingerprint, check.Cert.CommonName, check.Cert.ExpirationTime.Format(time.RFC3339), strconv.Itoa(check.DaysLeft), check.Status,
+				})
+			}
+			if err := common.WriteTable(os.Stdout, []string{"SHA1_FINGERPRINT", "COMMON_NAME", "EXPIRATION", "DAYS_LEFT", "STATUS"}, rows); err != nil {
+				return err
+			}
+
+			if expired > 0 {
+				return common.NewFindingsError("%d server CA certificate(s) of instance '%s' expired", expired, cloudsqlInstanceID)
+			}
+			return nil
 		},
 	}
 )
@@ -120,6 +628,18 @@ func init() {
 	cloudsqlCmd.AddCommand(cloudsqlCreateDatabaseCmd)
 	cloudsqlCmd.AddCommand(exportPostgreSQLUsersPermissionsCmd)
 	cloudsqlCmd.AddCommand(exportPostgreSQLAuditLogsCmd)
+	cloudsqlCmd.AddCommand(exportPostgreSQLDatabaseSizesCmd)
+	cloudsqlCmd.AddCommand(cloudsqlGrantDBAccessCmd)
+	cloudsqlCmd.AddCommand(cloudsqlWaitCmd)
+	cloudsqlCmd.AddCommand(cloudsqlListInstancesCmd)
+	cloudsqlCmd.AddCommand(cloudsqlExportBackupCmd)
+	cloudsqlCmd.AddCommand(cloudsqlListBackupsCmd)
+	cloudsqlCmd.AddCommand(cloudsqlCreateBackupCmd)
+	cloudsqlCmd.AddCommand(cloudsqlSetFlagCmd)
+	cloudsqlCmd.AddCommand(cloudsqlCheckAuditReadinessCmd)
+	cloudsqlCmd.AddCommand(cloudsqlRotatePasswordCmd)
+	cloudsqlCmd.AddCommand(cloudsqlCreateInstanceCmd)
+	cloudsqlCmd.AddCommand(cloudsqlCheckSSLCmd)
 
 	// Flags for 'cloudsql create-user'
 	cloudsqlCreateUserCmd.Flags().StringVarP(&cloudsqlInstanceID, "instance", "i", "", "Cloud SQL instance ID (e.g. nonprod-psql) (required)")
@@ -150,18 +670,240 @@ func init() {
 	exportPostgreSQLUsersPermissionsCmd.Flags().StringVarP(&cloudsqlPort, "port", "t", "5432", "Port for the PostgreSQL instance (e.g 5432)")
 	exportPostgreSQLUsersPermissionsCmd.Flags().StringVarP(&cloudsqlAddress, "address", "a", "mydb.example.com", "Address (IP or DNS) of the PostgreSQL instance (e.g. 'mydb.example.com')")
 	exportPostgreSQLUsersPermissionsCmd.Flags().StringVarP(&cloudsqlDBIgnoreRegex, "regex-ignore-databases", "r", "^prisma_migrate", "Regular expression to ignore specific databases (e.g. '^prisma_migrate')")
+	exportPostgreSQLUsersPermissionsCmd.Flags().StringSliceVarP(&cloudsqlDBInclude, "include-databases", "b", nil, "Only check these databases, comma-separated or repeated. Takes precedence over --regex-ignore-databases (e.g. 'app1-db,app2-db')")
+	exportPostgreSQLUsersPermissionsCmd.Flags().StringVar(&reportMetadata.Title, "report-title", "", "Custom title of the report, e.g. with the ticket number (default is 'User and Role Permissions Report for Instance...')")
+	exportPostgreSQLUsersPermissionsCmd.Flags().StringVar(&reportMetadata.Note, "report-note", "", "Note appended to the end of the report (e.g. 'Requested by ticket OPS-123')")
+	exportPostgreSQLUsersPermissionsCmd.Flags().StringVarP(&cloudsqlConnectionName, "instance-connection-name", "c", "", "Connection name of instance in 'project:region:instance' format. Overrides the project and instance, and the address is resolved if --address is not provided (e.g. 'other-project:us-central1:nonprod-psql')")
+	exportPostgreSQLUsersPermissionsCmd.Flags().IntVar(&config.PostgresQueryAttempts, "query-attempts", config.PostgresQueryAttempts, "Attempts of each query on transient connection errors (e.g. connection reset). Other errors are not retried")
+	exportPostgreSQLUsersPermissionsCmd.Flags().DurationVar(&config.PostgresQueryRetryBackoff, "query-retry-backoff", config.PostgresQueryRetryBackoff, "Wait before the first retry of a query. It grows linearly in the next retries (e.g. 2s, 4s...)")
+	exportPostgreSQLUsersPermissionsCmd.Flags().DurationVar(&config.PostgresConnectTimeout, "db-connect-timeout", config.PostgresConnectTimeout, "Connect timeout of each database connection. It is rounded up to seconds (e.g. 10s, 1m)")
+	exportPostgreSQLUsersPermissionsCmd.Flags().IntVar(&config.PostgresMaxOpenConns, "db-max-open-conns", config.PostgresMaxOpenConns, "Maximum of database connections opened at the same time. Each database is checked by its own connection. It is limited by --max-concurrency too")
 	exportPostgreSQLUsersPermissionsCmd.Flags().BoolVarP(&cloudsqlSSLRequired, "ssl-required", "s", false, "Force SSL connection to the PostgreSQL instance (default is false)")
+	exportPostgreSQLUsersPermissionsCmd.Flags().StringVarP(&reportFilenameTemplate, "filename-template", "F", config.PostgresPermissionsFilenameTemplate, "Template of the report filename. Placeholders: {project}, {instance}, {timestamp}, {date}")
+	exportPostgreSQLUsersPermissionsCmd.Flags().BoolVar(&cloudsqlExportResume, "resume", false, "Resume the previous export that failed or was cancelled, skipping the databases already completed and completing its report")
+	exportPostgreSQLUsersPermissionsCmd.Flags().StringVarP(&reportPermissionsFormat, "format", "f", gcp.PermissionsReportFormatText, "Format of the report. Supported values: text or json ({database: {grantee: {table: [privileges]}}}, with .json extension)")
 
 	// Flags are required
-	_ = exportPostgreSQLUsersPermissionsCmd.MarkFlagRequired("instance")
 	_ = exportPostgreSQLUsersPermissionsCmd.MarkFlagRequired("username")
-	_ = exportPostgreSQLUsersPermissionsCmd.MarkFlagRequired("address")
+	exportPostgreSQLUsersPermissionsCmd.MarkFlagsOneRequired("instance", "instance-connection-name")
+	exportPostgreSQLUsersPermissionsCmd.MarkFlagsMutuallyExclusive("instance", "instance-connection-name")
+	exportPostgreSQLUsersPermissionsCmd.MarkFlagsOneRequired("address", "instance-connection-name")
+
+	// Flags for 'cloudsql export-postgresql-database-sizes'
+	exportPostgreSQLDatabaseSizesCmd.Flags().StringVarP(&cloudsqlInstanceID, "instance", "i", "", "Cloud SQL instance ID (e.g. nonprod-psql) (required)")
+	exportPostgreSQLDatabaseSizesCmd.Flags().StringVarP(&cloudsqlConnectionName, "instance-connection-name", "c", "", "Connection name of instance in 'project:region:instance' format. Overrides the project and instance, and the address is resolved if --address is not provided (e.g. 'other-project:us-central1:nonprod-psql')")
+	exportPostgreSQLDatabaseSizesCmd.Flags().StringVarP(&cloudsqlAddress, "address", "a", "mydb.example.com", "Address (IP or DNS) of the PostgreSQL instance (e.g. 'mydb.example.com')")
+	exportPostgreSQLDatabaseSizesCmd.Flags().StringVarP(&cloudsqlPort, "port", "t", "5432", "Port for the PostgreSQL instance (e.g 5432)")
+	exportPostgreSQLDatabaseSizesCmd.Flags().StringVarP(&cloudsqlUserName, "username", "u", "", "Username used to connect to the databases (e.g. postgres) (required)")
+	exportPostgreSQLDatabaseSizesCmd.Flags().StringVarP(&cloudsqlPassword, "password", "p", "", "Password of --username (prompt if not provided) (e.g. changeme)")
+	exportPostgreSQLDatabaseSizesCmd.Flags().BoolVarP(&cloudsqlSSLRequired, "ssl-required", "s", false, "Force SSL connection to the PostgreSQL instance (default is false)")
+	exportPostgreSQLDatabaseSizesCmd.Flags().StringVarP(&outputReportDir, "output-dir", "o", "", "Custom output directory for the sizes report (default is current directory)")
+	exportPostgreSQLDatabaseSizesCmd.Flags().StringVarP(&cloudsqlDBIgnoreRegex, "regex-ignore-databases", "r", "^prisma_migrate", "Regular expression to ignore specific databases (e.g. '^prisma_migrate')")
+	exportPostgreSQLDatabaseSizesCmd.Flags().StringSliceVarP(&cloudsqlDBInclude, "include-databases", "b", nil, "Only check these databases, comma-separated or repeated. Takes precedence over --regex-ignore-databases (e.g. 'app1-db,app2-db')")
+	exportPostgreSQLDatabaseSizesCmd.Flags().StringVarP(&sizesFilenameTemplate, "filename-template", "F", config.PostgresDatabaseSizesFilenameTemplate, "Template of the report filename. Placeholders: {project}, {instance}, {timestamp}, {date}")
+	exportPostgreSQLDatabaseSizesCmd.Flags().IntVar(&config.PostgresQueryAttempts, "query-attempts", config.PostgresQueryAttempts, "Attempts of each query on transient connection errors (e.g. connection reset). Other errors are not retried")
+	exportPostgreSQLDatabaseSizesCmd.Flags().DurationVar(&config.PostgresQueryRetryBackoff, "query-retry-backoff", config.PostgresQueryRetryBackoff, "Wait before the first retry of a query. It grows linearly in the next retries (e.g. 2s, 4s...)")
+	exportPostgreSQLDatabaseSizesCmd.Flags().DurationVar(&config.PostgresConnectTimeout, "db-connect-timeout", config.PostgresConnectTimeout, "Connect timeout of each database connection. It is rounded up to seconds (e.g. 10s, 1m)")
+	exportPostgreSQLDatabaseSizesCmd.Flags().IntVar(&config.PostgresMaxOpenConns, "db-max-open-conns", config.PostgresMaxOpenConns, "Maximum of database connections opened at the same time. Each database is checked by its own connection. It is limited by --max-concurrency too")
+
+	// Flags are required
+	_ = exportPostgreSQLDatabaseSizesCmd.MarkFlagRequired("username")
+	exportPostgreSQLDatabaseSizesCmd.MarkFlagsOneRequired("instance", "instance-connection-name")
+	exportPostgreSQLDatabaseSizesCmd.MarkFlagsMutuallyExclusive("instance", "instance-connection-name")
+	exportPostgreSQLDatabaseSizesCmd.MarkFlagsOneRequired("address", "instance-connection-name")
+
+	// Flags for 'cloudsql grant-db-access'
+	cloudsqlGrantDBAccessCmd.Flags().StringVarP(&cloudsqlInstanceID, "instance", "i", "", "Cloud SQL instance ID (e.g. nonprod-psql) (required)")
+	cloudsqlGrantDBAccessCmd.Flags().StringVarP(&cloudsqlConnectionName, "instance-connection-name", "c", "", "Connection name of instance in 'project:region:instance' format. Overrides the project and instance, and the address is resolved if --address is not provided (e.g. 'other-project:us-central1:nonprod-psql')")
+	cloudsqlGrantDBAccessCmd.Flags().StringVarP(&cloudsqlAddress, "address", "a", "mydb.example.com", "Address (IP or DNS) of the PostgreSQL instance (e.g. 'mydb.example.com')")
+	cloudsqlGrantDBAccessCmd.Flags().StringVarP(&cloudsqlPort, "port", "t", "5432", "Port for the PostgreSQL instance (e.g 5432)")
+	cloudsqlGrantDBAccessCmd.Flags().StringVarP(&cloudsqlUserName, "username", "u", "", "Username used to connect and grant the privileges (e.g. postgres) (required)")
+	cloudsqlGrantDBAccessCmd.Flags().StringVarP(&cloudsqlPassword, "password", "p", "", "Password of --username (prompt if not provided) (e.g. changeme)")
+	cloudsqlGrantDBAccessCmd.Flags().BoolVarP(&cloudsqlSSLRequired, "ssl-required", "s", false, "Force SSL connection to the PostgreSQL instance (default is false)")
+	cloudsqlGrantDBAccessCmd.Flags().StringVarP(&cloudsqlGrantUser, "user", "U", "", "User receiving the privileges, like an IAM database user (e.g. app@nonprod.iam) (required)")
+	cloudsqlGrantDBAccessCmd.Flags().StringVarP(&cloudsqlDBName, "database", "d", "", "Database where the privileges are granted (e.g. app-db) (required)")
+	cloudsqlGrantDBAccessCmd.Flags().StringVar(&cloudsqlGrantSchema, "schema", "public", "Schema of the tables (e.g. public)")
+	cloudsqlGrantDBAccessCmd.Flags().StringSliceVarP(&cloudsqlGrantPrivileges, "privileges", "g", nil, "Privileges on the tables, comma-separated or repeated (e.g. select,insert,update) (required)")
+	cloudsqlGrantDBAccessCmd.Flags().BoolVarP(&cloudsqlGrantDryRun, "dry-run", "n", false, "Only print the GRANT statements, without connecting to the database")
+
+	// Flags are required
+	_ = cloudsqlGrantDBAccessCmd.MarkFlagRequired("username")
+	_ = cloudsqlGrantDBAccessCmd.MarkFlagRequired("user")
+	_ = cloudsqlGrantDBAccessCmd.MarkFlagRequired("database")
+	_ = cloudsqlGrantDBAccessCmd.MarkFlagRequired("privileges")
+	cloudsqlGrantDBAccessCmd.MarkFlagsOneRequired("instance", "instance-connection-name")
+	cloudsqlGrantDBAccessCmd.MarkFlagsMutuallyExclusive("instance", "instance-connection-name")
+	cloudsqlGrantDBAccessCmd.MarkFlagsOneRequired("address", "instance-connection-name")
 
 	// Flags for 'cloudsql export-postgresql-audit-logs'
 	exportPostgreSQLAuditLogsCmd.Flags().StringVarP(&cloudsqlInstanceID, "instance", "i", "", "Cloud SQL instance ID (e.g. nonprod-psql) (required)")
 	exportPostgreSQLAuditLogsCmd.Flags().StringVarP(&outputReportDir, "output-dir", "o", "", "Custom output directory for the audit logs (default is current directory)")
+	exportPostgreSQLAuditLogsCmd.Flags().StringVarP(&auditFilenameTemplate, "filename-template", "F", config.PostgresAuditLogsFilenameTemplate, "Template of the report filename. Placeholders: {project}, {instance}, {timestamp}, {date}")
+	exportPostgreSQLAuditLogsCmd.Flags().StringVarP(&reportAuditLogsFormat, "format", "f", gcp.AuditLogsFormatText, "Format of the audit logs. Supported values: text or json (structured entries with timestamp, severity, textPayload and labels)")
+	exportPostgreSQLAuditLogsCmd.Flags().StringVarP(&reportAppendTo, "append-to", "A", "", "Append the logs to this file (relative to --output-dir) instead of creating a new timestamped file (e.g. 'audit-logs-daily.txt')")
 
 	// Flags are required
 	_ = exportPostgreSQLAuditLogsCmd.MarkFlagRequired("instance")
 
+	// Flags for 'cloudsql wait'
+	cloudsqlWaitCmd.Flags().StringVarP(&cloudsqlOperationID, "operation", "O", "", "Cloud SQL operation ID (required)")
+	cloudsqlWaitCmd.Flags().DurationVar(&cloudsqlOperationTimeout, "timeout", 10*time.Minute, "Max time to wait for the operation (e.g. 30s, 10m)")
+	cloudsqlWaitCmd.Flags().DurationVar(&config.GCPCloudSQLOperationPollInterval, "poll-interval", config.GCPCloudSQLOperationPollInterval, "Interval between checks of the operation status (e.g. 5s)")
+
+	// Flags are required
+	_ = cloudsqlWaitCmd.MarkFlagRequired("operation")
+
+	// Flags for 'cloudsql list-instances'
+	cloudsqlListInstancesCmd.Flags().StringVarP(&cloudsqlListFilter, "filter", "f", "", "Show only instances with name containing this substring (optional)")
+
+	// Flags for 'cloudsql export-backup'
+	cloudsqlExportBackupCmd.Flags().StringVarP(&cloudsqlInstanceID, "instance", "i", "", "Cloud SQL instance ID (e.g. nonprod-psql) (required)")
+	cloudsqlExportBackupCmd.Flags().StringVarP(&cloudsqlBackupBucket, "bucket", "b", "", "GCS bucket to save the backup (e.g. gs://my-backups) (required)")
+	cloudsqlExportBackupCmd.Flags().StringSliceVarP(&cloudsqlBackupDatabases, "database", "d", nil, "Databases to export. Required for PostgreSQL instances (optional)")
+
+	// Flags are required
+	_ = cloudsqlExportBackupCmd.MarkFlagRequired("instance")
+	_ = cloudsqlExportBackupCmd.MarkFlagRequired("bucket")
+
+	// Flags for 'cloudsql list-backups'
+	cloudsqlListBackupsCmd.Flags().StringVarP(&cloudsqlInstanceID, "instance", "i", "", "Cloud SQL instance ID (e.g. nonprod-psql) (required)")
+
+	// Flags are required
+	_ = cloudsqlListBackupsCmd.MarkFlagRequired("instance")
+
+	// Flags for 'cloudsql create-backup'
+	cloudsqlCreateBackupCmd.Flags().StringVarP(&cloudsqlInstanceID, "instance", "i", "", "Cloud SQL instance ID (e.g. nonprod-psql) (required)")
+	cloudsqlCreateBackupCmd.Flags().StringVar(&cloudsqlBackupDescription, "description", "", "Description of the backup (e.g. 'before migration') (optional)")
+	cloudsqlCreateBackupCmd.Flags().BoolVarP(&cloudsqlBackupWait, "wait", "w", false, "Wait for the backup and print its ID (optional)")
+	cloudsqlCreateBackupCmd.Flags().DurationVar(&cloudsqlBackupTimeout, "timeout", 30*time.Minute, "Max time to wait for the backup with --wait (e.g. 30m)")
+
+	// Flags are required
+	_ = cloudsqlCreateBackupCmd.MarkFlagRequired("instance")
+
+	// Flags for 'cloudsql set-flag'
+	cloudsqlSetFlagCmd.Flags().StringVarP(&cloudsqlInstanceID, "instance", "i", "", "Cloud SQL instance ID (e.g. nonprod-psql) (required)")
+	cloudsqlSetFlagCmd.Flags().StringVarP(&cloudsqlFlagName, "flag", "f", "", "Name of database flag (e.g. cloudsql.enable_pgaudit)")
+	cloudsqlSetFlagCmd.Flags().StringVarP(&cloudsqlFlagValue, "value", "v", "", "Value of database flag (e.g. on)")
+	cloudsqlSetFlagCmd.Flags().BoolVarP(&cloudsqlFlagListAll, "list-current", "l", false, "Only show the current database flags of instance (optional)")
+
+	// Flags are required
+	_ = cloudsqlSetFlagCmd.MarkFlagRequired("instance")
+
+	// Flags for 'cloudsql rotate-password'
+	cloudsqlRotatePasswordCmd.Flags().StringVarP(&cloudsqlInstanceID, "instance", "i", "", "Cloud SQL instance ID (e.g. nonprod-psql) (required)")
+	cloudsqlRotatePasswordCmd.Flags().StringVarP(&cloudsqlUserName, "username", "u", "", "Username of SQL user (e.g. app-name) (required)")
+	cloudsqlRotatePasswordCmd.Flags().StringVarP(&cloudsqlHost, "source-host", "s", "", "Host of SQL user. Required only for MySQL instances (e.g., '%') (optional)")
+	cloudsqlRotatePasswordCmd.Flags().IntVarP(&cloudsqlRotateLength, "length", "l", 32, fmt.Sprintf("Length of the generated password (minimum %d)", common.MinPasswordLength))
+	cloudsqlRotatePasswordCmd.Flags().StringVarP(&cloudsqlRotateCharset, "charset", "c", common.DefaultPasswordCharset, "Characters used in the generated password")
+	cloudsqlRotatePasswordCmd.Flags().StringVarP(&cloudsqlRotateSecretName, "secret-name", "n", "", "Name of Secret Manager secret to store the password as a new version (e.g. app-name-db-password)")
+	cloudsqlRotatePasswordCmd.Flags().BoolVar(&cloudsqlRotatePrintPassword, "print-password", false, "Display the generated password in the standard output")
+
+	// Flags are required
+	_ = cloudsqlRotatePasswordCmd.MarkFlagRequired("instance")
+	_ = cloudsqlRotatePasswordCmd.MarkFlagRequired("username")
+	// The password must be stored or displayed, otherwise it's lost
+	cloudsqlRotatePasswordCmd.MarkFlagsOneRequired("secret-name", "print-password")
+
+	// Flags for 'cloudsql create-instance'
+	cloudsqlCreateInstanceCmd.Flags().StringVarP(&cloudsqlInstanceID, "instance", "i", "", "ID of the new Cloud SQL instance (e.g. nonprod-psql) (required)")
+	cloudsqlCreateInstanceCmd.Flags().StringVarP(&cloudsqlInstanceDBVersion, "database-version", "v", "", "Database version (e.g. POSTGRES_16, MYSQL_8_0) (required)")
+	cloudsqlCreateInstanceCmd.Flags().StringVarP(&cloudsqlInstanceTier, "tier", "t", "", "Machine type of instance (e.g. db-custom-2-7680, db-f1-micro) (required)")
+	cloudsqlCreateInstanceCmd.Flags().BoolVarP(&cloudsqlInstanceWait, "wait", "w", false, "Wait for the creation of instance (optional)")
+	cloudsqlCreateInstanceCmd.Flags().DurationVar(&cloudsqlInstanceTimeout, "timeout", 30*time.Minute, "Max time to wait for the creation with --wait (e.g. 30m)")
+
+	// Flags are required
+	_ = cloudsqlCreateInstanceCmd.MarkFlagRequired("instance")
+	_ = cloudsqlCreateInstanceCmd.MarkFlagRequired("database-version")
+	_ = cloudsqlCreateInstanceCmd.MarkFlagRequired("tier")
+
+	// Flags for 'cloudsql check-ssl'
+	cloudsqlCheckSSLCmd.Flags().StringVarP(&cloudsqlInstanceID, "instance", "i", "", "Cloud SQL instance ID (e.g. nonprod-psql) (required)")
+	cloudsqlCheckSSLCmd.Flags().IntVarP(&cloudsqlSSLDaysThreshold, "days-threshold", "d", 30, "Warn about certificates expiring within this number of days")
+
+	// Flags are required
+	_ = cloudsqlCheckSSLCmd.MarkFlagRequired("instance")
+
+	// Flags for 'cloudsql check-audit-readiness'
+	cloudsqlCheckAuditReadinessCmd.Flags().StringVarP(&cloudsqlInstanceID, "instance", "i", "", "Cloud SQL instance ID (e.g. nonprod-psql) (required)")
+	cloudsqlCheckAuditReadinessCmd.Flags().StringVarP(&cloudsqlAuditReadinessOutputFormat, "output-format", "o", "text", "Output format. Supported values: text or json")
+
+	// Flags are required
+	_ = cloudsqlCheckAuditReadinessCmd.MarkFlagRequired("instance")
+
+	// Pick the instance interactively when --instance is omitted. Not used by 'create-instance', because its instance is new
+	for _, subcommand := range []*cobra.Command{
+		cloudsqlCreateUserCmd, cloudsqlCreateDatabaseCmd, exportPostgreSQLUsersPermissionsCmd, cloudsqlGrantDBAccessCmd,
+		exportPostgreSQLAuditLogsCmd, cloudsqlExportBackupCmd, cloudsqlListBackupsCmd, cloudsqlCreateBackupCmd,
+		cloudsqlSetFlagCmd, cloudsqlRotatePasswordCmd, cloudsqlCheckSSLCmd, cloudsqlCheckAuditReadinessCmd,
+		exportPostgreSQLDatabaseSizesCmd,
+	} {
+		subcommand.PreRunE = pickCloudSQLInstance
+	}
+
+}
+
+// pickCloudSQLInstance lists the Cloud SQL instances of project and asks the user to select one when
+// the --instance option (and --instance-connection-name, if supported) is omitted and the standard input is a terminal.
+// In non-interactive contexts (or with --yes), nothing is done, so the command fails as the option is required.
+func pickCloudSQLInstance(cmd *cobra.Command, args []string) error {
+	if cmd.Flags().Changed("instance") || cmd.Flags().Changed("instance-connection-name") {
+		return nil
+	}
+	if config.AssumeYes || !common.IsInteractive() {
+		return nil
+	}
+
+	instances, err := gcp.ListGCPCloudSQLInstances(config.Properties.DefaultGCPProject)
+	if err != nil {
+		return err
+	}
+	if len(instances) == 0 {
+		common.Logger("warning", "No Cloud SQL instances found in project '%s'", config.Properties.DefaultGCPProject)
+		return nil
+	}
+
+	options := make([]string, 0, len(instances))
+	for _, instance := range instances {
+		options = append(options, fmt.Sprintf("%s (%s, %s, %s)", instance.Name, instance.DatabaseVersion, instance.Region, instance.State))
+	}
+	selected, err := common.PromptSelect("Select the Cloud SQL instance", options)
+	if err != nil {
+		return err
+	}
+
+	// Setting the flag marks it as changed, so the validation of required flags passes
+	return cmd.Flags().Set("instance", instances[selected].Name)
+}
+
+// resolvePostgresConnection prompts for the password of PostgreSQL user if not provided via flag and
+// resolves the project, instance and address of --instance-connection-name option, if provided.
+// It returns the project of instance.
+func resolvePostgresConnection(cmd *cobra.Command) (string, error) {
+	// Prompt for password if not provided via flag for better security
+	if cloudsqlPassword == "" {
+		password, err := common.PromptPassword(fmt.Sprintf("Enter password for user '%s': ", cloudsqlUserName))
+		if err != nil {
+			return "", err
+		}
+		cloudsqlPassword = password
+	}
+
+	projectID := config.Properties.DefaultGCPProject
+	// The connection name overrides the project and instance, e.g. for instances in other project (shared VPC)
+	if cloudsqlConnectionName != "" {
+		connectionProjectID, _, connectionInstanceID, err := gcp.ParseGCPCloudSQLConnectionName(cloudsqlConnectionName)
+		if err != nil {
+			return "", err
+		}
+		projectID, cloudsqlInstanceID = connectionProjectID, connectionInstanceID
+
+		if !cmd.Flags().Changed("address") {
+			address, err := gcp.GetGCPCloudSQLInstanceAddress(projectID, cloudsqlInstanceID)
+			if err != nil {
+				return "", err
+			}
+			cloudsqlAddress = address
+		}
+	}
+	return projectID, nil
 }
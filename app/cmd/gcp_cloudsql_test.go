@@ -0,0 +1,238 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aeciopires/pires-cli/internal/config"
+	"github.com/aeciopires/pires-cli/pkg/pireslib/common"
+	"github.com/spf13/cobra"
+)
+
+// setCloudSQLConnectionFlags replaces the connection flags of cloudsql commands during the test
+func setCloudSQLConnectionFlags(t *testing.T, instanceID, connectionName, address string) {
+	t.Helper()
+	previousInstance, previousConnection, previousAddress, previousPassword := cloudsqlInstanceID, cloudsqlConnectionName, cloudsqlAddress, cloudsqlPassword
+	cloudsqlInstanceID, cloudsqlConnectionName, cloudsqlAddress, cloudsqlPassword = instanceID, connectionName, address, "secret"
+	t.Cleanup(func() {
+		cloudsqlInstanceID, cloudsqlConnectionName, cloudsqlAddress, cloudsqlPassword = previousInstance, previousConnection, previousAddress, previousPassword
+	})
+}
+
+// newConnectionTestCommand returns a command with the --address option, like the PostgreSQL commands of cloudsql
+func newConnectionTestCommand(t *testing.T, args ...string) *cobra.Command {
+	t.Helper()
+	command := &cobra.Command{Use: "test"}
+	command.Flags().StringVar(&cloudsqlAddress, "address", cloudsqlAddress, "")
+	if err := command.Flags().Parse(args); err != nil {
+		t.Fatal(err)
+	}
+	return command
+}
+
+// fakeGcloudPath replaces config.GcloudPath by a shell script with the body during the test
+func fakeGcloudPath(t *testing.T, body string) {
+	t.Helper()
+	script := filepath.Join(t.TempDir(), "gcloud")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\n"+body+"\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	previous := config.GcloudPath
+	config.GcloudPath = script
+	t.Cleanup(func() { config.GcloudPath = previous })
+}
+
+func TestResolvePostgresConnectionName(t *testing.T) {
+	setCloudSQLConnectionFlags(t, "", "other-project:us-central1:shared-psql", "")
+	fakeGcloudPath(t, `case "$*" in
+"sql instances describe shared-psql --project other-project --format=json(ipAddresses)") echo '{"ipAddresses": [{"ipAddress": "10.0.0.5", "type": "PRIVATE"}]}' ;;
+*) exit 1 ;;
+esac`)
+
+	projectID, err := resolvePostgresConnection(newConnectionTestCommand(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The connection name overrides the project and instance, and the address is resolved
+	if projectID != "other-project" || cloudsqlInstanceID != "shared-psql" || cloudsqlAddress != "10.0.0.5" {
+		t.Errorf("project, instance, address = %s, %s, %s, want other-project, shared-psql, 10.0.0.5", projectID, cloudsqlInstanceID, cloudsqlAddress)
+	}
+}
+
+func TestResolvePostgresConnectionNameWithAddress(t *testing.T) {
+	setCloudSQLConnectionFlags(t, "", "other-project:us-central1:shared-psql", "")
+	// The address is not resolved when --address is provided
+	fakeGcloudPath(t, "exit 1")
+
+	projectID, err := resolvePostgresConnection(newConnectionTestCommand(t, "--address", "127.0.0.1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if projectID != "other-project" || cloudsqlInstanceID != "shared-psql" || cloudsqlAddress != "127.0.0.1" {
+		t.Errorf("project, instance, address = %s, %s, %s, want other-project, shared-psql, 127.0.0.1", projectID, cloudsqlInstanceID, cloudsqlAddress)
+	}
+}
+
+func TestResolvePostgresConnectionWithoutName(t *testing.T) {
+	setCloudSQLConnectionFlags(t, "nonprod-psql", "", "10.0.0.9")
+	previousProject := config.Properties.DefaultGCPProject
+	config.Properties.DefaultGCPProject = "my-project"
+	t.Cleanup(func() { config.Properties.DefaultGCPProject = previousProject })
+
+	projectID, err := resolvePostgresConnection(newConnectionTestCommand(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if projectID != "my-project" || cloudsqlInstanceID != "nonprod-psql" || cloudsqlAddress != "10.0.0.9" {
+		t.Errorf("project, instance, address = %s, %s, %s, want my-project, nonprod-psql, 10.0.0.9", projectID, cloudsqlInstanceID, cloudsqlAddress)
+	}
+}
+
+func TestResolvePostgresConnectionInvalidName(t *testing.T) {
+	setCloudSQLConnectionFlags(t, "", "shared-psql", "")
+
+	if _, err := resolvePostgresConnection(newConnectionTestCommand(t)); err == nil {
+		t.Fatal("expected error for connection name without region")
+	}
+}
+
+// setInteractive simulates a terminal (or not) with the answers of prompts during the test
+func setInteractive(t *testing.T, interactive bool, answers string) {
+	t.Helper()
+	previousInteractive, previousInput := common.IsInteractive, common.PromptInput
+	common.IsInteractive = func() bool { return interactive }
+	common.PromptInput = strings.NewReader(answers)
+	t.Cleanup(func() { common.IsInteractive, common.PromptInput = previousInteractive, previousInput })
+}
+
+// newInstanceTestCommand returns a command with the --instance option, like the cloudsql commands
+func newInstanceTestCommand(t *testing.T, args ...string) *cobra.Command {
+	t.Helper()
+	previous := cloudsqlInstanceID
+	t.Cleanup(func() { cloudsqlInstanceID = previous })
+	cloudsqlInstanceID = ""
+
+	command := &cobra.Command{Use: "test"}
+	command.Flags().StringVarP(&cloudsqlInstanceID, "instance", "i", "", "")
+	if err := command.Flags().Parse(args); err != nil {
+		t.Fatal(err)
+	}
+	return command
+}
+
+// instancesListScript is a fake gcloud listing two Cloud SQL instances of project 'nonprod'
+const instancesListScript = `[ "$*" = "sql instances list --project nonprod --format=json" ] || exit 1
+echo '[{"name": "nonprod-psql", "databaseVersion": "POSTGRES_15", "region": "us-central1", "state": "RUNNABLE"}, {"name": "nonprod-mysql", "databaseVersion": "MYSQL_8_0", "region": "us-east1", "state": "RUNNABLE"}]'`
+
+func TestPickCloudSQLInstance(t *testing.T) {
+	setCloudSQLProject(t, "nonprod")
+	fakeGcloudPath(t, instancesListScript)
+	setInteractive(t, true, "2\n")
+	command := newInstanceTestCommand(t)
+
+	if err := pickCloudSQLInstance(command, nil); err != nil {
+		t.Fatal(err)
+	}
+	if cloudsqlInstanceID != "nonprod-mysql" || !command.Flags().Changed("instance") {
+		t.Errorf("instance = %q (changed %t), want nonprod-mysql selected", cloudsqlInstanceID, command.Flags().Changed("instance"))
+	}
+}
+
+func TestPickCloudSQLInstanceInvalidSelection(t *testing.T) {
+	setCloudSQLProject(t, "nonprod")
+	fakeGcloudPath(t, instancesListScript)
+	setInteractive(t, true, "3\n")
+
+	err := pickCloudSQLInstance(newInstanceTestCommand(t), nil)
+	if common.ExitCode(err) != common.ExitCodeValidation || cloudsqlInstanceID != "" {
+		t.Errorf("error = %v, instance = %q, want validation error without instance", err, cloudsqlInstanceID)
+	}
+}
+
+func TestPickCloudSQLInstanceSkipped(t *testing.T) {
+	setCloudSQLProject(t, "nonprod")
+	// gcloud must not be executed when the instance isn't picked
+	fakeGcloudPath(t, "exit 1")
+
+	tests := []struct {
+		name        string
+		interactive bool
+		assumeYes   bool
+		args        []string
+		want        string
+	}{
+		{"non-interactive", false, false, nil, ""},
+		{"assume yes", true, true, nil, ""},
+		{"instance option", true, false, []string{"--instance", "prod-psql"}, "prod-psql"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setInteractive(t, tt.interactive, "1\n")
+			previousAssumeYes := config.AssumeYes
+			config.AssumeYes = tt.assumeYes
+			t.Cleanup(func() { config.AssumeYes = previousAssumeYes })
+
+			if err := pickCloudSQLInstance(newInstanceTestCommand(t, tt.args...), nil); err != nil {
+				t.Fatal(err)
+			}
+			if cloudsqlInstanceID != tt.want {
+				t.Errorf("instance = %q, want %q", cloudsqlInstanceID, tt.want)
+			}
+		})
+	}
+}
+
+// setCloudSQLProject replaces the project of CLI during the test
+func setCloudSQLProject(t *testing.T, projectID string) {
+	t.Helper()
+	previous := config.Properties.DefaultGCPProject
+	config.Properties.DefaultGCPProject = projectID
+	t.Cleanup(func() { config.Properties.DefaultGCPProject = previous })
+}
+
+func TestCloudSQLListBackups(t *testing.T) {
+	setCloudSQLProject(t, "nonprod")
+	setCloudSQLConnectionFlags(t, "nonprod-psql", "", "")
+	// Only the list of backups is allowed: the command is read-only and must not check the admin permissions
+	fakeGcloudPath(t, `[ "$*" = "sql backups list --instance nonprod-psql --project nonprod --format=json" ] || exit 1
+echo '[{"id": "1736000000000", "windowStartTime": "2025-01-04T14:13:20Z", "status": "SUCCESSFUL", "type": "AUTOMATED"}]'`)
+
+	var err error
+	output := captureStdout(t, func() {
+		cloudsqlListBackupsCmd.PersistentPreRun(cloudsqlListBackupsCmd, nil)
+		err = cloudsqlListBackupsCmd.RunE(cloudsqlListBackupsCmd, nil)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"WINDOW_START_TIME", "1736000000000", "2025-01-04T14:13:20Z", "SUCCESSFUL", "AUTOMATED"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output does not contain %q:\n%s", want, output)
+		}
+	}
+}
+
+func TestCloudSQLPreRunReturnsErrors(t *testing.T) {
+	setCloudSQLProject(t, "nonprod")
+	previousRegion := config.Properties.DefaultGCPRegion
+	config.Properties.DefaultGCPRegion = "us-central1"
+	t.Cleanup(func() { config.Properties.DefaultGCPRegion = previousRegion })
+
+	// gcloud isn't authenticated, so the error reaches Execute with the exit code of missing permissions
+	fakeGcloudPath(t, `[ "$1" = compute ] && echo UP; exit 0`)
+	if got := common.ExitCode(cloudsqlCmd.PersistentPreRunE(cloudsqlCmd, nil)); got != common.ExitCodePermissionDenied {
+		t.Errorf("exit code = %d, want %d", got, common.ExitCodePermissionDenied)
+	}
+
+	// The failure of gcloud is an external command error
+	fakeGcloudPath(t, `echo "ERROR: (gcloud.sql.databases.create) HTTPError 404" >&2; exit 1`)
+	setCloudSQLConnectionFlags(t, "nonprod-psql", "", "")
+	previousDBName := cloudsqlDBName
+	cloudsqlDBName = "app"
+	t.Cleanup(func() { cloudsqlDBName = previousDBName })
+	if got := common.ExitCode(cloudsqlCreateDatabaseCmd.RunE(cloudsqlCreateDatabaseCmd, nil)); got != common.ExitCodeExternalCommand {
+		t.Errorf("exit code of create-database = %d, want %d", got, common.ExitCodeExternalCommand)
+	}
+}
@@ -1,7 +1,12 @@
 package cmd
 
 import (
+	"fmt"
+	"os"
 	"reflect"
+	"slices"
+	"strconv"
+	"strings"
 
 	"github.com/aeciopires/pires-cli/internal/config"
 	"github.com/aeciopires/pires-cli/pkg/pireslib/common"
@@ -15,7 +20,7 @@ var (
 	firewallCmd = &cobra.Command{
 		Use:   "firewall",
 		Short: "Manage GCP Firewall rules",
-		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 			// This runs before any firewall subcommand
 
 			// Debug message is displayed if -D option was passed
@@ -30,24 +35,244 @@ var (
 				common.Logger("debug", "Field: %s, Value: %v", fieldName, fieldValue)
 			}
 
-			// GCP Admin Permissions Check
+			// Run the gcp PersistentPreRunE, because cobra runs only the nearest PersistentPreRun
+			if err := gcpCmd.PersistentPreRunE(cmd, args); err != nil {
+				return err
+			}
+
+			// GCP Admin Permissions Check, on each project exported by 'export-rules' (--project and --projects-file options)
 			common.Logger("debug", "Performing admin permission checks as requested...")
-			gcp.CheckGcloudAdminPermissions(config.Properties.DefaultGCPProject)
+			projectIDs, err := resolveFirewallProjects()
+			if err != nil {
+				return err
+			}
+			if len(projectIDs) == 0 {
+				projectIDs = []string{config.Properties.DefaultGCPProject}
+			}
+			for _, projectID := range projectIDs {
+				if err := gcp.CheckGcloudAdminPermissions(projectID); err != nil {
+					return err
+				}
+			}
+			return nil
 		},
 	}
 
-	outputDir string
+	outputDir                string
+	firewallFilenameTemplate string
+	firewallAppendTo         string
+	firewallProjects         []string
+	firewallProjectsFile     string
 
 	// --- Export fireall rules Subcommand ---
 	exportFirewallRulesCmd = &cobra.Command{
 		Use:   "export-rules",
 		Short: "Export GCP firewall rules",
+		Long: `Exports the firewall rules of the project to a CSV file.
+	Use --project (repeated or comma-separated) and/or --projects-file to export many projects at once, one report
+	per project, in parallel (see --max-concurrency). A failure in a project doesn't abort the others.
+	With many projects, the --filename-template must contain {project} and --append-to adds a 'project' column
+	to the CSV. The admin permissions are checked on each project.`,
+		Example: `  pires-cli gcp firewall export-rules -o $HOME --project nonprod --project prod
+  pires-cli gcp firewall export-rules -o $HOME --projects-file projects.txt`,
+		// The arguments after '--' are passed to the primary gcloud command
+		Annotations: map[string]string{gcloudExtraArgsAnnotation: "true"},
 		RunE: func(cmd *cobra.Command, args []string) error {
 
 			if config.GCPFirewallRulesOutputType != "csv" {
-				common.Logger("fatal", "Unsupported output type '%s'. Only 'csv' is supported.", config.GCPFirewallRulesOutputType)
-			} else {
-				gcp.ExportGCPFirewallRulesToCSV(config.Properties.DefaultGCPProject, outputDir)
+				return common.NewValidationError("Unsupported output type '%s'. Only 'csv' is supported.", config.GCPFirewallRulesOutputType)
+			}
+
+			projectIDs, err := resolveFirewallProjects()
+			if err != nil {
+				return err
+			}
+			if len(projectIDs) == 0 {
+				return gcp.ExportGCPFirewallRulesToCSV(config.Properties.DefaultGCPProject, outputDir, firewallFilenameTemplate, firewallAppendTo, config.GCPFirewallRulesColumns)
+			}
+			return gcp.ExportGCPFirewallRulesForProjects(projectIDs, outputDir, firewallFilenameTemplate, firewallAppendTo, config.GCPFirewallRulesColumns)
+		},
+	}
+
+	// --- Rules For Subcommand ---
+	firewallTargetTag string
+
+	firewallRulesForCmd = &cobra.Command{
+		Use:   "rules-for",
+		Short: "Show the firewall rules applied to VMs with a network tag",
+		Long: `Shows the firewall rules whose target tags include the tag, and the rules without targets (applied to all VMs),
+	sorted by effective order: priority (lower first) and DENY before ALLOW in the same priority.`,
+		// Override the firewall PersistentPreRun, because this command is read-only and doesn't require admin permissions
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {},
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			rules, err := gcp.ListGCPFirewallRules(config.Properties.DefaultGCPProject)
+			if err != nil {
+				return err
+			}
+
+			var rows [][]string
+			for _, rule := range gcp.FilterGCPFirewallRulesForTag(rules, firewallTargetTag) {
+				targetTags := strings.Join(rule.TargetTags, ",")
+				if targetTags == "" {
+					targetTags = "(all instances)"
+				}
+				rows = append(rows, []string{
+					strconv.Itoa(rule.Priority), rule.Name, rule.Direction, rule.Action(), rule.ProtocolsString(),
+					strings.Join(rule.SourceRanges, ","), targetTags, strconv.FormatBool(rule.Disabled),
+				})
+			}
+			return common.WriteTable(os.Stdout, []string{"PRIORITY", "NAME", "DIRECTION", "ACTION", "PROTOCOLS", "SOURCE_RANGES", "TARGET_TAGS", "DISABLED"}, rows)
+		},
+	}
+
+	// --- Delete Rules Subcommand ---
+	firewallDeleteFilter string
+	firewallDeleteDryRun bool
+
+	firewallDeleteRulesCmd = &cobra.Command{
+		Use:   "delete-rules",
+		Short: "Delete the firewall rules matching a filter",
+		Long: `Lists the firewall rules matching the gcloud filter expression and deletes each one, after confirmation.
+	By default, only shows the rules that would be deleted (dry-run). Use --dry-run=false to delete them.
+	An empty filter is refused to avoid the deletion of all rules.`,
+		Example: `  pires-cli gcp firewall delete-rules --filter "name~^incident-123-"
+  pires-cli gcp firewall delete-rules --filter "name~^incident-123-" --dry-run=false --yes`,
+		// The arguments after '--' are passed to the primary gcloud command
+		Annotations: map[string]string{gcloudExtraArgsAnnotation: "true"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			if strings.TrimSpace(firewallDeleteFilter) == "" {
+				return common.NewValidationError("--filter can't be empty, because all firewall rules would be deleted")
+			}
+
+			rules, err := gcp.ListGCPFirewallRulesWithFilter(config.Properties.DefaultGCPProject, firewallDeleteFilter)
+			if err != nil {
+				return err
+			}
+			if len(rules) == 0 {
+				common.Logger("info", "No firewall rules match the filter '%s' on project '%s'.", firewallDeleteFilter, config.Properties.DefaultGCPProject)
+				return nil
+			}
+
+			var rows [][]string
+			for _, rule := range rules {
+				rows = append(rows, []string{rule.Name, rule.Network, rule.Direction, strconv.Itoa(rule.Priority), rule.Action(), rule.ProtocolsString()})
+			}
+			if err := common.WriteTable(os.Stdout, []string{"NAME", "NETWORK", "DIRECTION", "PRIORITY", "ACTION", "PROTOCOLS"}, rows); err != nil {
+				return err
+			}
+
+			if !firewallDeleteDryRun {
+				confirmed, err := common.Confirm(fmt.Sprintf("Delete %d firewall rule(s) on project '%s'?", len(rules), config.Properties.DefaultGCPProject))
+				if err != nil {
+					return err
+				}
+				if !confirmed {
+					common.Logger("info", "Deletion cancelled by user.")
+					return nil
+				}
+			}
+
+			deleted, failed, err := gcp.DeleteGCPFirewallRules(config.Properties.DefaultGCPProject, rules, firewallDeleteDryRun)
+			if err != nil {
+				common.Logger("warning", "Deletion interrupted: %d of %d firewall rule(s) deleted before the interruption.", len(deleted), len(rules))
+				return err
+			}
+			if firewallDeleteDryRun {
+				common.Logger("info", "%d firewall rule(s) would be deleted. Use --dry-run=false to delete them.", len(deleted))
+				return nil
+			}
+
+			common.Logger("info", "Summary: %d rule(s) deleted, %d rule(s) failed.", len(deleted), len(failed))
+			for rule, errRule := range failed {
+				common.Logger("warning", "  - FAILED: %s: %v", rule, errRule)
+			}
+			if len(failed) > 0 {
+				return fmt.Errorf("%d of %d firewall rule(s) could not be deleted", len(failed), len(rules))
+			}
+			return nil
+		},
+	}
+
+	// --- Sync Subcommand ---
+	firewallSyncFile  string
+	firewallSyncApply bool
+
+	firewallSyncCmd = &cobra.Command{
+		Use:   "sync",
+		Short: "Compare the firewall rules of a file with the project and apply the differences",
+		Long: `Compares the firewall rules of a JSON file (in the format of 'gcloud compute firewall-rules list --format=json')
+	with the current rules of the project, by name, and shows the rules that would be created, updated or are unchanged.
+	Use --apply to create and update them, after confirmation. The rules of the project missing in the file are never deleted.
+	The rules with network, direction or action changed can't be updated by gcloud: they are reported and never touched.`,
+		Example: `  gcloud compute firewall-rules list --project nonprod --format=json > firewall-rules.json
+  pires-cli gcp firewall sync --file firewall-rules.json
+  pires-cli gcp firewall sync --file firewall-rules.json --apply`,
+		// The arguments after '--' are passed to the primary gcloud command
+		Annotations: map[string]string{gcloudExtraArgsAnnotation: "true"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			desired, err := gcp.LoadGCPFirewallRulesFile(firewallSyncFile)
+			if err != nil {
+				return err
+			}
+			current, err := gcp.ListGCPFirewallRules(config.Properties.DefaultGCPProject)
+			if err != nil {
+				return err
+			}
+			plan := gcp.PlanGCPFirewallSync(desired, current)
+
+			var rows [][]string
+			for _, rule := range plan.Create {
+				rows = append(rows, []string{"CREATE", rule.Name, ""})
+			}
+			for _, change := range plan.Update {
+				rows = append(rows, []string{"UPDATE", change.Rule.Name, strings.Join(change.Changes, ",")})
+			}
+			for _, change := range plan.Replace {
+				rows = append(rows, []string{"REPLACE (not supported)", change.Rule.Name, strings.Join(change.Changes, ",")})
+			}
+			for _, name := range plan.Unchanged {
+				rows = append(rows, []string{"UNCHANGED", name, ""})
+			}
+			if err := common.WriteTable(os.Stdout, []string{"ACTION", "NAME", "CHANGES"}, rows); err != nil {
+				return err
+			}
+			common.Logger("info", "Summary: %d rule(s) to create, %d rule(s) to update, %d rule(s) to replace, %d rule(s) unchanged.",
+				len(plan.Create), len(plan.Update), len(plan.Replace), len(plan.Unchanged))
+			if len(plan.Replace) > 0 {
+				common.Logger("warning", "The network, direction or action of %d rule(s) changed. gcloud can't update them: delete and recreate them manually.", len(plan.Replace))
+			}
+
+			toApply := len(plan.Create) + len(plan.Update)
+			if !firewallSyncApply || toApply == 0 {
+				if toApply > 0 {
+					common.Logger("info", "Use --apply to create and update the rules.")
+				}
+				return nil
+			}
+
+			confirmed, err := common.Confirm(fmt.Sprintf("Create %d and update %d firewall rule(s) on project '%s'?", len(plan.Create), len(plan.Update), config.Properties.DefaultGCPProject))
+			if err != nil {
+				return err
+			}
+			if !confirmed {
+				common.Logger("info", "Operation cancelled.")
+				return nil
+			}
+
+			applied, failed, err := gcp.ApplyGCPFirewallSync(config.Properties.DefaultGCPProject, plan)
+			if err != nil {
+				common.Logger("warning", "Sync interrupted: %d of %d firewall rule(s) applied before the interruption.", len(applied), toApply)
+				return err
+			}
+			common.Logger("info", "Summary: %d rule(s) applied, %d rule(s) failed.", len(applied), len(failed))
+			for rule, errRule := range failed {
+				common.Logger("warning", "  - FAILED: %s: %v", rule, errRule)
+			}
+			if len(failed) > 0 {
+				return fmt.Errorf("%d of %d firewall rule(s) could not be applied", len(failed), toApply)
 			}
 			return nil
 		},
@@ -59,12 +284,55 @@ func init() {
 
 	// Add subcommands to firewallCmd
 	firewallCmd.AddCommand(exportFirewallRulesCmd)
+	firewallCmd.AddCommand(firewallRulesForCmd)
+	firewallCmd.AddCommand(firewallDeleteRulesCmd)
+	firewallCmd.AddCommand(firewallSyncCmd)
 
 	// Flags for 'firewall export-rules'
 	exportFirewallRulesCmd.Flags().StringVarP(&outputDir, "output-dir", "o", "", "Custom output directory for the CSV file (default is current directory)")
 	exportFirewallRulesCmd.Flags().StringVarP(&config.GCPFirewallRulesOutputType, "output-type", "t", config.GCPFirewallRulesOutputType, "Output type for file rules")
+	exportFirewallRulesCmd.Flags().StringVarP(&firewallFilenameTemplate, "filename-template", "F", config.GCPFirewallRulesFilenameTemplate, "Template of the CSV filename. Placeholders: {project}, {timestamp}, {date}")
+	exportFirewallRulesCmd.Flags().StringVarP(&firewallAppendTo, "append-to", "A", "", "Append the rules to this CSV file (relative to --output-dir) instead of creating a new timestamped file. The header is written only once")
+	exportFirewallRulesCmd.Flags().StringSliceVar(&firewallProjects, "project", nil, "Export the rules of these projects instead of the project of CLI, comma-separated or repeated (e.g. nonprod,prod)")
+	exportFirewallRulesCmd.Flags().StringVar(&firewallProjectsFile, "projects-file", "", "File with one project per line to export. Blank lines and comments (starting with '#') are skipped")
+	exportFirewallRulesCmd.Flags().StringSliceVar(&config.GCPFirewallRulesColumns, "columns", config.GCPFirewallRulesColumns, "Comma-separated list of CSV columns. Supported columns: "+strings.Join(gcp.SupportedGCPFirewallRulesColumns(), ", "))
 
 	// Flags are required
 	_ = exportFirewallRulesCmd.MarkFlagRequired("output-dir")
 
+	// Flags for 'firewall rules-for'
+	firewallRulesForCmd.Flags().StringVarP(&firewallTargetTag, "target-tag", "g", "", "Network tag of VMs (e.g. gke-nonprod-node) (required)")
+
+	// Flags are required
+	_ = firewallRulesForCmd.MarkFlagRequired("target-tag")
+
+	// Flags for 'firewall delete-rules'
+	firewallDeleteRulesCmd.Flags().StringVarP(&firewallDeleteFilter, "filter", "f", "", "gcloud filter expression of the rules to delete (e.g. 'name~^incident-123-') (required)")
+	firewallDeleteRulesCmd.Flags().BoolVarP(&firewallDeleteDryRun, "dry-run", "n", true, "Only show the rules that would be deleted")
+
+	// Flags are required
+	_ = firewallDeleteRulesCmd.MarkFlagRequired("filter")
+
+	// Flags for 'firewall sync'
+	firewallSyncCmd.Flags().StringVarP(&firewallSyncFile, "file", "f", "", "JSON file with the desired firewall rules, in the format of 'gcloud compute firewall-rules list --format=json' (required)")
+	firewallSyncCmd.Flags().BoolVarP(&firewallSyncApply, "apply", "a", false, "Create and update the rules, after confirmation (optional)")
+
+	// Flags are required
+	_ = firewallSyncCmd.MarkFlagRequired("file")
+
+}
+
+// resolveFirewallProjects returns the sorted and unique projects of --project and --projects-file options
+// of 'export-rules' command, or an empty list if none was passed (the project of CLI is used).
+func resolveFirewallProjects() ([]string, error) {
+	projectIDs := slices.Clone(firewallProjects)
+	if firewallProjectsFile != "" {
+		fileProjectIDs, err := gcp.LoadGCPProjectsFile(firewallProjectsFile)
+		if err != nil {
+			return nil, err
+		}
+		projectIDs = append(projectIDs, fileProjectIDs...)
+	}
+	slices.Sort(projectIDs)
+	return slices.Compact(projectIDs), nil
 }
@@ -0,0 +1,29 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aeciopires/pires-cli/pkg/pireslib/common"
+)
+
+func TestFirewallDeleteRulesEmptyFilter(t *testing.T) {
+	// gcloud must not be executed with an empty filter
+	callsFile := filepath.Join(t.TempDir(), "calls")
+	t.Setenv("CLI_TEST_CALLS", callsFile)
+	fakeGcloudPath(t, `echo "$*" >> "$CLI_TEST_CALLS"`)
+	previousFilter, previousDryRun := firewallDeleteFilter, firewallDeleteDryRun
+	t.Cleanup(func() { firewallDeleteFilter, firewallDeleteDryRun = previousFilter, previousDryRun })
+
+	for _, filter := range []string{"", "   "} {
+		firewallDeleteFilter, firewallDeleteDryRun = filter, false
+		err := firewallDeleteRulesCmd.RunE(firewallDeleteRulesCmd, nil)
+		if common.ExitCode(err) != common.ExitCodeValidation {
+			t.Errorf("filter %q: error = %v, want validation error", filter, err)
+		}
+	}
+	if _, err := os.Stat(callsFile); !os.IsNotExist(err) {
+		t.Error("gcloud executed with an empty filter")
+	}
+}
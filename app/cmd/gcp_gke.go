@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/aeciopires/pires-cli/internal/config"
+	"github.com/aeciopires/pires-cli/pkg/pireslib/common"
+	"github.com/aeciopires/pires-cli/pkg/pireslib/gcp"
+	"github.com/aeciopires/pires-cli/pkg/pireslib/k8s"
+	"github.com/spf13/cobra"
+)
+
+// Local variables
+var (
+	// gkeCmd represents the gke command
+	gkeCmd = &cobra.Command{
+		Use:   "gke",
+		Short: "Manage GKE clusters",
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			// This runs before any gke subcommand
+			// The admin permissions check is not performed, because the gke subcommands
+			// only read the clusters of project.
+
+			// Debug message is displayed if -D option was passed
+			common.Logger("debug", "====> Values loaded in cmd/gcp-gke subcommand")
+			auxValue := reflect.ValueOf(config.Properties)
+			auxType := reflect.TypeOf(config.Properties)
+
+			// Interate over the fields of the struct
+			for i := 0; i < auxValue.NumField(); i++ {
+				fieldName := auxType.Field(i).Name
+				fieldValue := auxValue.Field(i).Interface()
+				common.Logger("debug", "Field: %s, Value: %v", fieldName, fieldValue)
+			}
+
+			// Run the gcp PersistentPreRunE, because cobra runs only the nearest PersistentPreRun
+			return gcpCmd.PersistentPreRunE(cmd, args)
+		},
+	}
+
+	// --- Connect All Subcommand ---
+	gkeContextPrefix string
+
+	gkeConnectAllCmd = &cobra.Command{
+		Use:   "connect-all",
+		Short: "Get the credentials of all GKE clusters of the project",
+		Long: `Lists the GKE clusters of the project and adds the credentials of each one to the kubeconfig file
+	(the KUBECONFIG environment variable is respected). The failure of one cluster doesn't abort the others.
+	Use --context-prefix to rename the contexts to <prefix><cluster-name>.`,
+		// The arguments after '--' are passed to the primary gcloud command
+		Annotations: map[string]string{gcloudExtraArgsAnnotation: "true"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			result, err := gcp.ConnectAllGKEClusters(config.Properties.DefaultGCPProject, gkeContextPrefix)
+			if err != nil {
+				return err
+			}
+
+			clusterNames := make([]string, 0, len(result.Contexts))
+			for clusterName := range result.Contexts {
+				clusterNames = append(clusterNames, clusterName)
+			}
+			sort.Strings(clusterNames)
+			for _, clusterName := range clusterNames {
+				fmt.Printf("[OK]     %s -> context '%s'\n", clusterName, result.Contexts[clusterName])
+			}
+
+			clusterNames = clusterNames[:0]
+			for clusterName := range result.Failed {
+				clusterNames = append(clusterNames, clusterName)
+			}
+			sort.Strings(clusterNames)
+			for _, clusterName := range clusterNames {
+				fmt.Printf("[FAILED] %s: %v\n", clusterName, result.Failed[clusterName])
+			}
+
+			common.Logger("info", "Summary: %d cluster(s) connected, %d cluster(s) failed on project '%s'.", len(result.Contexts), len(result.Failed), config.Properties.DefaultGCPProject)
+			if len(result.Failed) > 0 {
+				return fmt.Errorf("%d cluster(s) could not be connected", len(result.Failed))
+			}
+			return nil
+		},
+	}
+
+	// --- Test Connection Subcommand ---
+	gkeKubeconfig string
+
+	gkeTestConnectionCmd = &cobra.Command{
+		Use:   "test-connection",
+		Short: "Check if the cluster of current kubectl context is reachable",
+		Long: `Runs 'kubectl get --raw /healthz' against the current context to confirm kubectl can talk to the cluster,
+	e.g. after 'gke connect-all' command. Exit with non-zero code if the cluster is unreachable.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			currentContext, err := k8s.TestConnection(gkeKubeconfig)
+			if err != nil {
+				fmt.Printf("[UNREACHABLE] context '%s': %v\n", currentContext, err)
+				return err
+			}
+			fmt.Printf("[REACHABLE] context '%s'\n", currentContext)
+			return nil
+		},
+	}
+)
+
+func init() {
+	gcpCmd.AddCommand(gkeCmd) // Add gke to parent gcp command
+
+	// Add subcommands to gkeCmd
+	gkeCmd.AddCommand(gkeConnectAllCmd)
+	gkeCmd.AddCommand(gkeTestConnectionCmd)
+
+	// Flags for 'gke connect-all'
+	gkeConnectAllCmd.Flags().StringVarP(&gkeContextPrefix, "context-prefix", "x", "", "Prefix of the kubeconfig contexts. The contexts are named <prefix><cluster-name> (e.g. 'nonprod-') (optional)")
+
+	// Flags for 'gke test-connection'
+	gkeTestConnectionCmd.Flags().StringVarP(&gkeKubeconfig, "kubeconfig", "k", "", "Path of kubeconfig file (default is KUBECONFIG environment variable or $HOME/.kube/config)")
+
+}
@@ -1,7 +1,12 @@
 package cmd
 
 import (
+	"encoding/json"
+	"fmt"
+	"os"
 	"reflect"
+	"strconv"
+	"time"
 
 	"github.com/aeciopires/pires-cli/internal/config"
 	"github.com/aeciopires/pires-cli/pkg/pireslib/common"
@@ -15,7 +20,7 @@ var (
 	iamCmd = &cobra.Command{
 		Use:   "iam",
 		Short: "Manage GCP IAM resources (service accounts, roles, permissions)",
-		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 			// This runs before any iam subcommand
 
 			// Debug message is displayed if -D option was passed
@@ -30,9 +35,14 @@ var (
 				common.Logger("debug", "Field: %s, Value: %v", fieldName, fieldValue)
 			}
 
+			// Run the gcp PersistentPreRunE, because cobra runs only the nearest PersistentPreRun
+			if err := gcpCmd.PersistentPreRunE(cmd, args); err != nil {
+				return err
+			}
+
 			// GCP Admin Permissions Check
 			common.Logger("debug", "Performing admin permission checks as requested...")
-			gcp.CheckGcloudAdminPermissions(config.Properties.DefaultGCPProject)
+			return gcp.CheckGcloudAdminPermissions(config.Properties.DefaultGCPProject)
 		},
 	}
 
@@ -43,16 +53,18 @@ var (
 	iamCreateSaCmd = &cobra.Command{
 		Use:   "create-sa",
 		Short: "Create a new service account",
+		// The arguments after '--' are passed to the primary gcloud command
+		Annotations: map[string]string{gcloudExtraArgsAnnotation: "true"},
 		RunE: func(cmd *cobra.Command, args []string) error {
 
-			gcp.CreateGCPIAMServiceAccount(config.Properties.DefaultGCPProject, iamCreateSaAccountID, iamCreateSaDescription)
-			return nil
+			return gcp.CreateGCPIAMServiceAccount(config.Properties.DefaultGCPProject, iamCreateSaAccountID, iamCreateSaDescription)
 		},
 	}
 
 	// --- Grant Role Subcommand ---
-	iamGrantRoleMember string
-	iamGrantRoleName   string
+	iamGrantRoleMember      string
+	iamGrantRoleMembersFile string
+	iamGrantRoleName        string
 
 	iamGrantRoleCmd = &cobra.Command{
 		Use:   "grant-role",
@@ -65,13 +77,579 @@ var (
 	  - domain:{domain} (e.g., domain:company.com)
 	Role format:
 	  - roles/{SERVICE_NAME}.{ROLE_NAME} (e.g., roles/storage.objectViewer)
-	  - projects/{PROJECT_ID}/roles/{CUSTOM_ROLE_ID} for custom roles`,
+	  - projects/{PROJECT_ID}/roles/{CUSTOM_ROLE_ID} for custom roles
+	Use --members-file to grant the role to many members, one per line. Blank lines and '#' comments are skipped.
+	Invalid members are reported per line without aborting the grants of the other members.`,
+		// The arguments after '--' are passed to the primary gcloud command
+		Annotations: map[string]string{gcloudExtraArgsAnnotation: "true"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			if iamGrantRoleMembersFile == "" {
+				return gcp.GrantGCPIAMRoleToMember(config.Properties.DefaultGCPProject, iamGrantRoleMember, iamGrantRoleName)
+			}
+
+			if err := gcp.ValidateGCPIAMRole(iamGrantRoleName); err != nil {
+				return err
+			}
+			members, invalidLines, err := gcp.LoadGCPIAMMembersFile(iamGrantRoleMembersFile)
+			if err != nil {
+				return err
+			}
+			if iamGrantRoleMember != "" {
+				members = append([]string{iamGrantRoleMember}, members...)
+			}
+
+			bindings := make([]gcp.IAMBinding, 0, len(members))
+			for _, member := range members {
+				bindings = append(bindings, gcp.IAMBinding{Member: member, Role: iamGrantRoleName})
+			}
+			result := gcp.ApplyGCPIAMBindings(config.Properties.DefaultGCPProject, bindings, false)
+
+			common.Logger("info", "Summary: %d member(s) granted, %d member(s) failed, %d invalid line(s).", len(result.Succeeded), len(result.Failed), len(invalidLines))
+			for binding, errBinding := range result.Failed {
+				common.Logger("warning", "  - FAILED: %s: %v", binding, errBinding)
+			}
+			for _, invalidLine := range invalidLines {
+				common.Logger("warning", "  - INVALID: %s", invalidLine)
+			}
+			if len(result.Failed) > 0 || len(invalidLines) > 0 {
+				return fmt.Errorf("%d of %d member(s) failed and %d invalid line(s) in members file '%s'", len(result.Failed), len(bindings), len(invalidLines), iamGrantRoleMembersFile)
+			}
+			return nil
+		},
+	}
+
+	// --- Apply Bindings Subcommand ---
+	iamApplyBindingsFile   string
+	iamApplyBindingsDryRun bool
+
+	iamApplyBindingsCmd = &cobra.Command{
+		Use:   "apply-bindings",
+		Short: "Grant many IAM roles to members on the project from a YAML/JSON file",
+		Long: `Reads a YAML or JSON file with a list of member/role pairs (and optional condition)
+	and grants each one on the project. All entries are validated before any change.
+	File format:
+	  bindings:
+	    - member: user:name.surname@company.com
+	      role: roles/cloudsql.client
+	    - member: serviceAccount:app-name-gsa@change-project.iam.gserviceaccount.com
+	      role: roles/storage.objectViewer
+	      condition:
+	        title: only-app-bucket
+	        expression: resource.name.startsWith("projects/_/buckets/app-bucket")`,
+		// The arguments after '--' are passed to the primary gcloud command
+		Annotations: map[string]string{gcloudExtraArgsAnnotation: "true"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			bindings, err := gcp.LoadGCPIAMBindingsFile(iamApplyBindingsFile)
+			if err != nil {
+				return err
+			}
+
+			result := gcp.ApplyGCPIAMBindings(config.Properties.DefaultGCPProject, bindings, iamApplyBindingsDryRun)
+			if iamApplyBindingsDryRun {
+				common.Logger("info", "[DRY-RUN] Summary: %d binding(s) would be granted. Nothing was changed.", len(result.DryRun))
+				return nil
+			}
+
+			common.Logger("info", "Summary: %d binding(s) granted, %d binding(s) failed.", len(result.Succeeded), len(result.Failed))
+			for binding, errBinding := range result.Failed {
+				common.Logger("warning", "  - FAILED: %s: %v", binding, errBinding)
+			}
+			if len(result.Failed) > 0 {
+				return fmt.Errorf("%d of %d binding(s) failed", len(result.Failed), len(bindings))
+			}
+			return nil
+		},
+	}
+
+	// --- GSA Email Subcommand ---
+	iamGSAEmailBase string
+
+	iamGSAEmailCmd = &cobra.Command{
+		Use:   "gsa-email",
+		Short: "Print the email of a Google Service Account (GSA) for a base name and project",
+		Long: `Prints the email of a GSA in the format <base>@<project>.iam.gserviceaccount.com.
+	The base name is validated with the same rules of the config: lowercase, no underscore and max 30 characters.`,
+		// The startup checks are skipped, because this command only formats the email
+		Annotations: map[string]string{skipStartupChecksAnnotation: "true"},
+		// Override the iam PersistentPreRun, because this command doesn't call GCP APIs
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {},
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			if err := config.ValidateGSABaseAccountName(iamGSAEmailBase); err != nil {
+				return common.NewValidationError("%w", err)
+			}
+			// The config validation doesn't exit on startup for this command, so the project is checked here
+			if config.Properties.DefaultGCPProject == "" {
+				return common.NewValidationError("the GCP project is required (-P option or CLI_GCP_PROJECT variable)")
+			}
+			fmt.Println(config.BuildGSAEmail(iamGSAEmailBase, config.Properties.DefaultGCPProject))
+			return nil
+		},
+	}
+
+	// --- Validate SA ID Subcommand ---
+	iamValidateSAID string
+
+	iamValidateSAIDCmd = &cobra.Command{
+		Use:   "validate-sa-id",
+		Short: "Validate the ID of a service account with the rules of GCP",
+		Long: `Checks the ID of a service account (the part before '@' of email) with the rules of GCP: between 6 and 30 characters,
+	only lowercase letters, digits and hyphens, starting with a letter and not ending with a hyphen.
+	All violations are reported and the exit code is non-zero, avoiding failures in the creation of the service account.`,
+		Example: `  pires-cli gcp iam validate-sa-id --id app-name-gsa`,
+		// The startup checks are skipped, because this command only validates the ID
+		Annotations: map[string]string{skipStartupChecksAnnotation: "true"},
+		// Override the iam PersistentPreRun, because this command doesn't call GCP APIs
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {},
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			violations := config.ServiceAccountIDViolations(iamValidateSAID)
+			for _, violation := range violations {
+				fmt.Printf("Violation: %s\n", violation)
+			}
+			if len(violations) > 0 {
+				return common.NewFindingsError("service account ID '%s' has %d violation(s)", iamValidateSAID, len(violations))
+			}
+			common.Logger("info", "Service account ID '%s' is valid.", iamValidateSAID)
+			return nil
+		},
+	}
+
+	// --- Revoke All Subcommand ---
+	iamRevokeAllMember  string
+	iamRevokeAllConfirm string
+	iamRevokeAllDryRun  bool
+
+	iamRevokeAllCmd = &cobra.Command{
+		Use:   "revoke-all",
+		Short: "Revoke all IAM roles of a member on the project (e.g. offboarding)",
+		Long: `Lists all roles granted directly to the member on the project and revokes each one.
+	Bindings of other members are never changed. This is a dangerous operation, so
+	the --confirm flag must match exactly the --member flag.`,
+		// The arguments after '--' are passed to the primary gcloud command
+		Annotations: map[string]string{gcloudExtraArgsAnnotation: "true"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			if iamRevokeAllConfirm != iamRevokeAllMember {
+				return common.NewValidationError("confirmation '%s' does not match member '%s'", iamRevokeAllConfirm, iamRevokeAllMember)
+			}
+
+			revoked, failed, err := gcp.RevokeAllGCPIAMRolesForMember(config.Properties.DefaultGCPProject, iamRevokeAllMember, iamRevokeAllDryRun)
+			if err != nil {
+				return err
+			}
+
+			common.Logger("info", "Summary: %d role(s) revoked, %d role(s) failed for member '%s'.", len(revoked), len(failed), iamRevokeAllMember)
+			for role, errRole := range failed {
+				common.Logger("warning", "  - FAILED: %s: %v", role, errRole)
+			}
+			if len(failed) > 0 {
+				return fmt.Errorf("%d role(s) could not be revoked", len(failed))
+			}
+			return nil
+		},
+	}
+
+	// --- Describe Member Subcommand ---
+	iamDescribeMember       string
+	iamDescribeOutputFormat string
+
+	iamDescribeMemberCmd = &cobra.Command{
+		Use:   "describe-member",
+		Short: "Summarize the access of a member on the project",
+		Long: `Shows in one view the roles granted directly to the member on the project, the bindings with condition
+	and, for service accounts, if the service account exists in the project and its status.`,
+		// Override the iam PersistentPreRun, because this command is read-only and doesn't require admin permissions
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {},
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			if iamDescribeOutputFormat != "text" && iamDescribeOutputFormat != "json" {
+				return common.NewValidationError("Unsupported output format '%s'. Supported values: text or json", iamDescribeOutputFormat)
+			}
+
+			description, err := gcp.DescribeGCPIAMMember(config.Properties.DefaultGCPProject, iamDescribeMember)
+			if err != nil {
+				return err
+			}
+
+			if iamDescribeOutputFormat == "json" {
+				descriptionJSON, errJSON := json.MarshalIndent(description, "", "  ")
+				if errJSON != nil {
+					return fmt.Errorf("failed to encode member description: %w", errJSON)
+				}
+				fmt.Println(string(descriptionJSON))
+				return nil
+			}
+
+			fmt.Printf("Member:          %s\n", description.Member)
+			if description.IsServiceAccount {
+				status := "not found in project"
+				if description.ServiceAccount != nil {
+					status = "enabled"
+					if description.ServiceAccount.Disabled {
+						status = "disabled"
+					}
+				}
+				fmt.Printf("Service account: %s\n", status)
+			}
+			fmt.Printf("Roles (%d):\n", len(description.Roles))
+			for _, role := range description.Roles {
+				fmt.Printf("  - %s\n", role)
+			}
+			fmt.Printf("Conditional bindings (%d):\n", len(description.ConditionalBindings))
+			for _, binding := range description.ConditionalBindings {
+				fmt.Printf("  - %s: %s\n", binding, binding.Condition.Expression)
+			}
+			return nil
+		},
+	}
+
+	// --- List Members Subcommand ---
+	iamListMembersRole         string
+	iamListMembersOutputFormat string
+
+	iamListMembersCmd = &cobra.Command{
+		Use:   "list-members",
+		Short: "List the members granted with a role on the project",
+		Long: `Lists the members granted directly with a role on the project, with their type prefix (user:, serviceAccount:, group:, etc),
+	including the members of bindings with condition. Useful to audit who has sensitive roles, like roles/owner.`,
+		Example: `  pires-cli gcp iam list-members --role roles/owner`,
+		// Override the iam PersistentPreRun, because this command is read-only and doesn't require admin permissions
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {},
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			if iamListMembersOutputFormat != "text" && iamListMembersOutputFormat != "json" {
+				return common.NewValidationError("Unsupported output format '%s'. Supported values: text or json", iamListMembersOutputFormat)
+			}
+
+			members, err := gcp.ListGCPIAMMembersForRole(config.Properties.DefaultGCPProject, iamListMembersRole)
+			if err != nil {
+				return err
+			}
+
+			if iamListMembersOutputFormat == "json" {
+				membersJSON, errJSON := json.MarshalIndent(members, "", "  ")
+				if errJSON != nil {
+					return fmt.Errorf("failed to encode members: %w", errJSON)
+				}
+				fmt.Println(string(membersJSON))
+				return nil
+			}
+
+			if len(members) == 0 {
+				common.Logger("info", "No members found with role '%s'.", iamListMembersRole)
+				return nil
+			}
+			for _, member := range members {
+				fmt.Println(member)
+			}
+			return nil
+		},
+	}
+
+	// --- Check Service Account Subcommand ---
+	iamCheckSAEmail      string
+	iamCheckSAMaxAgeDays int
+
+	iamCheckSACmd = &cobra.Command{
+		Use:   "check-sa",
+		Short: "Check that a service account and its keys are healthy",
+		Long: `Shows if the service account is enabled and lists its keys with their age and status: OK, OLD (user managed keys
+	older than --max-age-days), EXPIRED or DISABLED. The system managed keys are rotated by Google, so they are never OLD.
+	Exit with non-zero code if the service account is disabled.`,
+		Example: `  pires-cli gcp iam check-sa --service-account-email app-gsa@nonprod.iam.gserviceaccount.com --max-age-days 90`,
+		// Override the iam PersistentPreRun, because this command is read-only and doesn't require admin permissions
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {},
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			if iamCheckSAMaxAgeDays < 1 {
+				return common.NewValidationError("--max-age-days must be greater than 0")
+			}
+
+			serviceAccount, err := gcp.DescribeGCPIAMServiceAccount(config.Properties.DefaultGCPProject, iamCheckSAEmail)
+			if err != nil {
+				return err
+			}
+			keys, err := gcp.ListGCPIAMServiceAccountKeys(config.Properties.DefaultGCPProject, iamCheckSAEmail)
+			if err != nil {
+				return err
+			}
+
+			status := "enabled"
+			if serviceAccount.Disabled {
+				status = "disabled"
+			}
+			fmt.Printf("Service account: %s (%s)\n", serviceAccount.Email, status)
+
+			checks := gcp.CheckGCPIAMServiceAccountKeys(keys, time.Now(), iamCheckSAMaxAgeDays)
+			var rows [][]string
+			for _, check := range checks {
+				switch check.Status {
+				case gcp.IAMKeyStatusOld:
+					common.Logger("warning", "Key '%s' of service account '%s' is %d days old. Rotate it.", check.Key.ID(), iamCheckSAEmail, check.AgeDays)
+				case gcp.IAMKeyStatusExpired:
+					common.Logger("warning", "Key '%s' of service account '%s' expired at %s.", check.Key.ID(), iamCheckSAEmail, check.Key.ValidBeforeTime.Format(time.RFC3339))
+				}
+				rows = append(rows, []string{
+					check.Key.ID(), check.Key.KeyType, check.Key.ValidAfterTime.Format(time.RFC3339), check.Key.ValidBeforeTime.Format(time.RFC3339), strconv.Itoa(check.AgeDays), check.Status,
+				})
+			}
+			if err := common.WriteTable(os.Stdout, []string{"KEY_ID", "TYPE", "CREATED", "EXPIRES", "AGE_DAYS", "STATUS"}, rows); err != nil {
+				return err
+			}
+
+			if serviceAccount.Disabled {
+				return common.NewFindingsError("service account '%s' is disabled", iamCheckSAEmail)
+			}
+			return nil
+		},
+	}
+
+	// --- List Grantable Roles Subcommand ---
+	iamListRolesFilter       string
+	iamListRolesOutputFormat string
+
+	iamListGrantableRolesCmd = &cobra.Command{
+		Use:   "list-grantable-roles",
+		Short: "List the predefined and custom roles available in the project",
+		Long: `Lists the predefined roles and the custom roles of the project, sorted by name.
+	Use --filter to show only the roles whose name or title contains a text, e.g. before using the 'grant-role' command.`,
+		Example: `  pires-cli gcp iam list-grantable-roles --filter cloudsql`,
+		// Override the iam PersistentPreRun, because this command is read-only and doesn't require admin permissions
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {},
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			if iamListRolesOutputFormat != "text" && iamListRolesOutputFormat != "json" {
+				return common.NewValidationError("Unsupported output format '%s'. Supported values: text or json", iamListRolesOutputFormat)
+			}
+
+			roles, err := gcp.ListGCPIAMGrantableRoles(config.Properties.DefaultGCPProject)
+			if err != nil {
+				return err
+			}
+			roles = gcp.FilterGCPIAMRoles(roles, iamListRolesFilter)
+
+			if iamListRolesOutputFormat == "json" {
+				if roles == nil {
+					roles = []gcp.IAMRole{}
+				}
+				rolesJSON, errJSON := json.MarshalIndent(roles, "", "  ")
+				if errJSON != nil {
+					return fmt.Errorf("failed to encode roles: %w", errJSON)
+				}
+				fmt.Println(string(rolesJSON))
+				return nil
+			}
+
+			if len(roles) == 0 {
+				common.Logger("info", "No roles found.")
+				return nil
+			}
+			var rows [][]string
+			for _, role := range roles {
+				roleType := "predefined"
+				if role.Custom {
+					roleType = "custom"
+				}
+				rows = append(rows, []string{role.Name, role.Title, roleType, role.Stage})
+			}
+			return common.WriteTable(os.Stdout, []string{"NAME", "TITLE", "TYPE", "STAGE"}, rows)
+		},
+	}
+
+	// --- List Custom Roles Subcommand ---
+	iamListCustomRolesOutputFormat string
+
+	iamListCustomRolesCmd = &cobra.Command{
+		Use:   "list-custom-roles",
+		Short: "List the custom roles of the project",
+		Long: `Lists the custom roles of the project with their title and launch stage, sorted by name.
+	Use the 'describe-role' command to show the permissions of a custom role.`,
+		Example: `  pires-cli gcp iam list-custom-roles -o json`,
+		// Override the iam PersistentPreRun, because this command is read-only and doesn't require admin permissions
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {},
+		// The arguments after '--' are passed to the primary gcloud command
+		Annotations: map[string]string{gcloudExtraArgsAnnotation: "true"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			if iamListCustomRolesOutputFormat != "text" && iamListCustomRolesOutputFormat != "json" {
+				return common.NewValidationError("Unsupported output format '%s'. Supported values: text or json", iamListCustomRolesOutputFormat)
+			}
+
+			roles, err := gcp.ListGCPIAMCustomRoles(config.Properties.DefaultGCPProject)
+			if err != nil {
+				return err
+			}
+
+			if iamListCustomRolesOutputFormat == "json" {
+				rolesJSON, errJSON := json.MarshalIndent(roles, "", "  ")
+				if errJSON != nil {
+					return fmt.Errorf("failed to encode custom roles: %w", errJSON)
+				}
+				fmt.Println(string(rolesJSON))
+				return nil
+			}
+
+			if len(roles) == 0 {
+				common.Logger("info", "No custom roles found in project '%s'.", config.Properties.DefaultGCPProject)
+				return nil
+			}
+			var rows [][]string
+			for _, role := range roles {
+				rows = append(rows, []string{role.ID(), role.Title, role.Stage, role.Name})
+			}
+			return common.WriteTable(os.Stdout, []string{"ID", "TITLE", "STAGE", "NAME"}, rows)
+		},
+	}
+
+	// --- Describe Role Subcommand ---
+	iamDescribeRoleID           string
+	iamDescribeRoleOutputFormat string
+
+	iamDescribeRoleCmd = &cobra.Command{
+		Use:   "describe-role",
+		Short: "Describe a custom role of the project and its permissions",
+		Long: `Shows the title, description, launch stage and the included permissions (sorted) of a custom role of the project.
+	The role can be the ID (e.g. appDeployer) or the full name (e.g. projects/nonprod/roles/appDeployer).`,
+		Example: `  pires-cli gcp iam describe-role --role appDeployer`,
+		// Override the iam PersistentPreRun, because this command is read-only and doesn't require admin permissions
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {},
+		// The arguments after '--' are passed to the primary gcloud command
+		Annotations: map[string]string{gcloudExtraArgsAnnotation: "true"},
 		RunE: func(cmd *cobra.Command, args []string) error {
 
-			gcp.GrantGCPIAMRoleToMember(config.Properties.DefaultGCPProject, iamGrantRoleMember, iamGrantRoleName)
+			if iamDescribeRoleOutputFormat != "text" && iamDescribeRoleOutputFormat != "json" {
+				return common.NewValidationError("Unsupported output format '%s'. Supported values: text or json", iamDescribeRoleOutputFormat)
+			}
+
+			role, err := gcp.DescribeGCPIAMCustomRole(config.Properties.DefaultGCPProject, iamDescribeRoleID)
+			if err != nil {
+				return err
+			}
+
+			if iamDescribeRoleOutputFormat == "json" {
+				roleJSON, errJSON := json.MarshalIndent(role, "", "  ")
+				if errJSON != nil {
+					return fmt.Errorf("failed to encode custom role: %w", errJSON)
+				}
+				fmt.Println(string(roleJSON))
+				return nil
+			}
+
+			fmt.Printf("Name: %s\n", role.Name)
+			fmt.Printf("Title: %s\n", role.Title)
+			if role.Description != "" {
+				fmt.Printf("Description: %s\n", role.Description)
+			}
+			fmt.Printf("Stage: %s\n", role.Stage)
+			if role.Deleted {
+				fmt.Println("Deleted: true")
+			}
+			fmt.Printf("Permissions (%d):\n", len(role.IncludedPermissions))
+			for _, permission := range role.IncludedPermissions {
+				fmt.Printf("  - %s\n", permission)
+			}
 			return nil
 		},
 	}
+
+	// --- Export Service Accounts Roles Subcommand ---
+	iamExportSARolesOutputDir string
+	iamExportSARolesFormat    string
+
+	iamExportSARolesCmd = &cobra.Command{
+		Use:   "export-sa-roles",
+		Short: "Export the roles of all service accounts of the project",
+		Long: `Lists the service accounts of the project and writes a timestamped report with the roles
+	granted directly to each one on the project (access inventory). The roles of service accounts are listed in parallel
+	(see --max-concurrency option).`,
+		// Override the iam PersistentPreRun, because this command is read-only and doesn't require admin permissions
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {},
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			_, err := gcp.ExportGCPIAMServiceAccountsRoles(config.Properties.DefaultGCPProject, iamExportSARolesOutputDir, iamExportSARolesFormat)
+			return err
+		},
+	}
+
+	// --- Export Policy Subcommand ---
+	iamExportPolicyOutputDir    string
+	iamExportPolicyOutputFormat string
+
+	iamExportPolicyCmd = &cobra.Command{
+		Use:   "export-policy",
+		Short: "Export the full IAM policy of the project to a file",
+		Long: `Writes the full IAM policy of the project to a timestamped JSON or YAML file (compliance snapshot).
+	Only the resourcemanager.projects.getIamPolicy permission is required.`,
+		// Override the iam PersistentPreRun, because this command is read-only and doesn't require admin permissions
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {},
+		// The arguments after '--' are passed to the primary gcloud command
+		Annotations: map[string]string{gcloudExtraArgsAnnotation: "true"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			_, err := gcp.ExportGCPIAMPolicy(config.Properties.DefaultGCPProject, iamExportPolicyOutputDir, iamExportPolicyOutputFormat)
+			return err
+		},
+	}
+
+	// --- Snapshot Subcommand ---
+	iamSnapshotOutput string
+
+	iamSnapshotCmd = &cobra.Command{
+		Use:   "snapshot",
+		Short: "Save the IAM policy of the project to a JSON file",
+		Long: `Saves the IAM policy of the project to a JSON file. Compare two snapshots with 'iam diff-snapshot' command
+	to detect the drift of IAM policy.`,
+		// Override the iam PersistentPreRun, because this command is read-only and doesn't require admin permissions
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {},
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			return gcp.SaveGCPIAMPolicySnapshot(config.Properties.DefaultGCPProject, iamSnapshotOutput)
+		},
+	}
+
+	// --- Diff Snapshot Subcommand ---
+	iamDiffSnapshotOld string
+	iamDiffSnapshotNew string
+
+	iamDiffSnapshotCmd = &cobra.Command{
+		Use:   "diff-snapshot",
+		Short: "Show the IAM bindings added and removed between two snapshots",
+		Long: `Compares two IAM policy snapshots saved by 'iam snapshot' command by member/role pair, ignoring the order.
+	Exit with non-zero code when differences exist.`,
+		// The startup checks are skipped, because this command only reads local snapshot files
+		Annotations: map[string]string{skipStartupChecksAnnotation: "true"},
+		// Override the iam PersistentPreRun, because this command doesn't call GCP APIs
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {},
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			oldPolicy, err := gcp.LoadGCPIAMPolicySnapshot(iamDiffSnapshotOld)
+			if err != nil {
+				return err
+			}
+			newPolicy, err := gcp.LoadGCPIAMPolicySnapshot(iamDiffSnapshotNew)
+			if err != nil {
+				return err
+			}
+
+			added, removed := gcp.DiffGCPIAMPolicies(oldPolicy, newPolicy)
+			if len(added) == 0 && len(removed) == 0 {
+				common.Logger("info", "No differences found between '%s' and '%s'.", iamDiffSnapshotOld, iamDiffSnapshotNew)
+				return nil
+			}
+
+			for _, binding := range removed {
+				fmt.Printf("- %s\n", binding)
+			}
+			for _, binding := range added {
+				fmt.Printf("+ %s\n", binding)
+			}
+			common.Logger("info", "Summary: %d binding(s) added, %d binding(s) removed.", len(added), len(removed))
+			return common.NewFindingsError("differences found between '%s' and '%s'", iamDiffSnapshotOld, iamDiffSnapshotNew)
+		},
+	}
 )
 
 func init() {
@@ -80,6 +658,20 @@ func init() {
 	// Add subcommands to iamCmd
 	iamCmd.AddCommand(iamCreateSaCmd)
 	iamCmd.AddCommand(iamGrantRoleCmd)
+	iamCmd.AddCommand(iamApplyBindingsCmd)
+	iamCmd.AddCommand(iamGSAEmailCmd)
+	iamCmd.AddCommand(iamValidateSAIDCmd)
+	iamCmd.AddCommand(iamRevokeAllCmd)
+	iamCmd.AddCommand(iamDescribeMemberCmd)
+	iamCmd.AddCommand(iamListGrantableRolesCmd)
+	iamCmd.AddCommand(iamListCustomRolesCmd)
+	iamCmd.AddCommand(iamDescribeRoleCmd)
+	iamCmd.AddCommand(iamListMembersCmd)
+	iamCmd.AddCommand(iamCheckSACmd)
+	iamCmd.AddCommand(iamExportSARolesCmd)
+	iamCmd.AddCommand(iamExportPolicyCmd)
+	iamCmd.AddCommand(iamSnapshotCmd)
+	iamCmd.AddCommand(iamDiffSnapshotCmd)
 
 	// Flags for 'iam create-sa'
 	iamCreateSaCmd.Flags().StringVarP(&iamCreateSaAccountID, "service-account-id", "s", "", "Unique ID for the new service account (e.g., app-name-gsa) (required)")
@@ -91,9 +683,95 @@ func init() {
 	// Flags for 'iam grant-role'
 	iamGrantRoleCmd.Flags().StringVarP(&iamGrantRoleMember, "member", "m", "", "Member to grant the role to (e.g., user:name.surname@company.com, serviceAccount:app-name-gsa@change-project.iam.gserviceaccount.com) (required)")
 	iamGrantRoleCmd.Flags().StringVarP(&iamGrantRoleName, "role", "r", "roles/cloudsql.editor", "IAM role to grant (e.g., roles/storage.admin) (required)")
+	iamGrantRoleCmd.Flags().StringVar(&iamGrantRoleMembersFile, "members-file", "", "File with one member per line to grant the role to. Blank lines and '#' comments are skipped")
 
 	// Flags are required
-	_ = iamGrantRoleCmd.MarkFlagRequired("member")
+	iamGrantRoleCmd.MarkFlagsOneRequired("member", "members-file")
 	_ = iamGrantRoleCmd.MarkFlagRequired("role")
 
+	// Flags for 'iam apply-bindings'
+	iamApplyBindingsCmd.Flags().StringVarP(&iamApplyBindingsFile, "file", "f", "", "YAML or JSON file with the list of bindings (required)")
+	iamApplyBindingsCmd.Flags().BoolVarP(&iamApplyBindingsDryRun, "dry-run", "n", false, "Only show the bindings that would be granted (optional)")
+
+	// Flags are required
+	_ = iamApplyBindingsCmd.MarkFlagRequired("file")
+
+	// Flags for 'iam gsa-email'
+	iamGSAEmailCmd.Flags().StringVarP(&iamGSAEmailBase, "base", "b", "", "Base name of the GSA (e.g., app-name-gsa) (required)")
+
+	// Flags are required
+	_ = iamGSAEmailCmd.MarkFlagRequired("base")
+
+	// Flags for 'iam validate-sa-id'
+	iamValidateSAIDCmd.Flags().StringVarP(&iamValidateSAID, "id", "i", "", "ID of the service account, without '@' and domain (e.g., app-name-gsa) (required)")
+
+	// Flags are required
+	_ = iamValidateSAIDCmd.MarkFlagRequired("id")
+
+	// Flags for 'iam revoke-all'
+	iamRevokeAllCmd.Flags().StringVarP(&iamRevokeAllMember, "member", "m", "", "Member to revoke all roles (e.g., user:name.surname@company.com) (required)")
+	iamRevokeAllCmd.Flags().StringVarP(&iamRevokeAllConfirm, "confirm", "c", "", "Type the member again to confirm the operation (required)")
+	iamRevokeAllCmd.Flags().BoolVarP(&iamRevokeAllDryRun, "dry-run", "n", false, "Only show the roles that would be revoked (optional)")
+
+	// Flags are required
+	_ = iamRevokeAllCmd.MarkFlagRequired("member")
+	_ = iamRevokeAllCmd.MarkFlagRequired("confirm")
+
+	// Flags for 'iam describe-member'
+	iamDescribeMemberCmd.Flags().StringVarP(&iamDescribeMember, "member", "m", "", "Member to describe (e.g., user:name.surname@company.com, serviceAccount:app-name-gsa@change-project.iam.gserviceaccount.com) (required)")
+	iamDescribeMemberCmd.Flags().StringVarP(&iamDescribeOutputFormat, "output-format", "o", "text", "Output format. Supported values: text or json")
+
+	// Flags are required
+	_ = iamDescribeMemberCmd.MarkFlagRequired("member")
+
+	// Flags for 'iam list-grantable-roles'
+	iamListGrantableRolesCmd.Flags().StringVarP(&iamListRolesFilter, "filter", "f", "", "Only show the roles whose name or title contains this text, case insensitive (e.g. cloudsql)")
+	iamListGrantableRolesCmd.Flags().StringVarP(&iamListRolesOutputFormat, "output-format", "o", "text", "Output format. Supported values: text or json")
+
+	// Flags for 'iam list-custom-roles'
+	iamListCustomRolesCmd.Flags().StringVarP(&iamListCustomRolesOutputFormat, "output-format", "o", "text", "Output format. Supported values: text or json")
+
+	// Flags for 'iam describe-role'
+	iamDescribeRoleCmd.Flags().StringVarP(&iamDescribeRoleID, "role", "r", "", "ID or full name of the custom role (e.g., appDeployer or projects/nonprod/roles/appDeployer) (required)")
+	iamDescribeRoleCmd.Flags().StringVarP(&iamDescribeRoleOutputFormat, "output-format", "o", "text", "Output format. Supported values: text or json")
+
+	// Flags are required
+	_ = iamDescribeRoleCmd.MarkFlagRequired("role")
+
+	// Flags for 'iam list-members'
+	iamListMembersCmd.Flags().StringVarP(&iamListMembersRole, "role", "r", "", "IAM role of the members (e.g., roles/owner) (required)")
+	iamListMembersCmd.Flags().StringVarP(&iamListMembersOutputFormat, "output-format", "o", "text", "Output format. Supported values: text or json")
+
+	// Flags are required
+	_ = iamListMembersCmd.MarkFlagRequired("role")
+
+	// Flags for 'iam check-sa'
+	iamCheckSACmd.Flags().StringVarP(&iamCheckSAEmail, "service-account-email", "e", "", "Email of service account (e.g., app-name-gsa@change-project.iam.gserviceaccount.com) (required)")
+	iamCheckSACmd.Flags().IntVarP(&iamCheckSAMaxAgeDays, "max-age-days", "a", 90, "Flag the user managed keys older than this number of days")
+
+	// Flags are required
+	_ = iamCheckSACmd.MarkFlagRequired("service-account-email")
+
+	// Flags for 'iam export-sa-roles'
+	iamExportSARolesCmd.Flags().StringVarP(&iamExportSARolesOutputDir, "output-dir", "o", "", "Custom output directory for the report (default is current directory)")
+	iamExportSARolesCmd.Flags().StringVarP(&iamExportSARolesFormat, "format", "f", gcp.ServiceAccountsRolesFormatCSV, "Format of the report. Supported values: csv or txt")
+
+	// Flags for 'iam export-policy'
+	iamExportPolicyCmd.Flags().StringVarP(&iamExportPolicyOutputDir, "output-dir", "o", "", "Custom output directory for the IAM policy (default is current directory)")
+	iamExportPolicyCmd.Flags().StringVarP(&iamExportPolicyOutputFormat, "output-format", "f", gcp.IAMPolicyFormatJSON, "Format of the IAM policy. Supported values: json or yaml")
+
+	// Flags for 'iam snapshot'
+	iamSnapshotCmd.Flags().StringVarP(&iamSnapshotOutput, "output", "o", "", "Path of JSON file to save the IAM policy (e.g. iam-policy-before.json) (required)")
+
+	// Flags are required
+	_ = iamSnapshotCmd.MarkFlagRequired("output")
+
+	// Flags for 'iam diff-snapshot'
+	iamDiffSnapshotCmd.Flags().StringVarP(&iamDiffSnapshotOld, "old", "o", "", "Path of old IAM policy snapshot (required)")
+	iamDiffSnapshotCmd.Flags().StringVarP(&iamDiffSnapshotNew, "new", "n", "", "Path of new IAM policy snapshot (required)")
+
+	// Flags are required
+	_ = iamDiffSnapshotCmd.MarkFlagRequired("old")
+	_ = iamDiffSnapshotCmd.MarkFlagRequired("new")
+
 }
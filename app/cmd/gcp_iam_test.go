@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aeciopires/pires-cli/pkg/pireslib/common"
+)
+
+// checkSAScript is a fake gcloud with a disabled service account of project 'nonprod' and an old user managed key
+const checkSAScript = `case "$*" in
+  "iam service-accounts describe app@nonprod.iam.gserviceaccount.com --project nonprod --format=json")
+    echo '{"email": "app@nonprod.iam.gserviceaccount.com", "disabled": true}' ;;
+  "iam service-accounts keys list --iam-account app@nonprod.iam.gserviceaccount.com --project nonprod --format=json")
+    echo '[{"name": "projects/nonprod/serviceAccounts/app@nonprod.iam.gserviceaccount.com/keys/abc123", "keyType": "USER_MANAGED", "validAfterTime": "2020-01-01T00:00:00Z", "validBeforeTime": "9999-12-31T23:59:59Z"}]' ;;
+  *) exit 1 ;;
+esac`
+
+func TestIamCheckSADisabled(t *testing.T) {
+	setCloudSQLProject(t, "nonprod")
+	fakeGcloudPath(t, checkSAScript)
+	previousEmail, previousMaxAgeDays := iamCheckSAEmail, iamCheckSAMaxAgeDays
+	iamCheckSAEmail, iamCheckSAMaxAgeDays = "app@nonprod.iam.gserviceaccount.com", 90
+	t.Cleanup(func() { iamCheckSAEmail, iamCheckSAMaxAgeDays = previousEmail, previousMaxAgeDays })
+
+	var err error
+	output := captureStdout(t, func() {
+		err = iamCheckSACmd.RunE(iamCheckSACmd, nil)
+	})
+
+	// The disabled service account exits with non-zero code
+	if common.ExitCode(err) != common.ExitCodeFindings {
+		t.Errorf("error = %v, want findings error", err)
+	}
+	for _, want := range []string{"app@nonprod.iam.gserviceaccount.com (disabled)", "abc123", "USER_MANAGED", "OLD"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output does not contain %q:\n%s", want, output)
+		}
+	}
+}
+
+func TestIamCheckSAInvalidMaxAge(t *testing.T) {
+	// gcloud must not be executed
+	fakeGcloudPath(t, "exit 1")
+	previousMaxAgeDays := iamCheckSAMaxAgeDays
+	iamCheckSAMaxAgeDays = 0
+	t.Cleanup(func() { iamCheckSAMaxAgeDays = previousMaxAgeDays })
+
+	if err := iamCheckSACmd.RunE(iamCheckSACmd, nil); common.ExitCode(err) != common.ExitCodeValidation {
+		t.Errorf("error = %v, want validation error", err)
+	}
+}
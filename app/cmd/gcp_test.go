@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aeciopires/pires-cli/internal/config"
+	"github.com/aeciopires/pires-cli/pkg/pireslib/common"
+	"github.com/spf13/cobra"
+)
+
+func TestCheckGcloudExtraArgsSupported(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        []string
+		annotations map[string]string
+		wantCode    int
+	}{
+		{"without dash", []string{"value"}, nil, 0},
+		{"annotated command", []string{"--", "--labels=team=ops"}, map[string]string{gcloudExtraArgsAnnotation: "true"}, 0},
+		{"not annotated command", []string{"--", "--labels=team=ops"}, nil, common.ExitCodeValidation},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			command := &cobra.Command{Use: "test", Annotations: tt.annotations}
+			if err := command.Flags().Parse(tt.args); err != nil {
+				t.Fatal(err)
+			}
+			if got := common.ExitCode(checkGcloudExtraArgsSupported(command)); got != tt.wantCode {
+				t.Errorf("exit code = %d, want %d", got, tt.wantCode)
+			}
+		})
+	}
+}
+
+// captureStdout returns what fn writes to os.Stdout
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous := os.Stdout
+	os.Stdout = writer
+	defer func() { os.Stdout = previous }()
+
+	fn()
+	writer.Close()
+	output, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(output)
+}
+
+func TestGcpSetProject(t *testing.T) {
+	// The fake gcloud has the project 'nonprod' and no region before the change
+	callsFile := filepath.Join(t.TempDir(), "calls")
+	t.Setenv("CLI_TEST_CALLS", callsFile)
+	fakeGcloudPath(t, `case "$*" in
+"config get-value project") echo nonprod ;;
+"config get-value compute/region") echo "(unset)" ;;
+"config set "*) echo "$*" >> "$CLI_TEST_CALLS" ;;
+*) exit 1 ;;
+esac`)
+	previousProperties, previousAssumeYes, previousRegion := config.Properties, config.AssumeYes, gcpSetProjectRegion
+	t.Cleanup(func() {
+		config.Properties, config.AssumeYes, gcpSetProjectRegion = previousProperties, previousAssumeYes, previousRegion
+	})
+	config.Properties.DefaultGCPProject, config.Properties.DefaultGCPRegion = "prod", "us-central1"
+	config.AssumeYes, gcpSetProjectRegion = true, true
+
+	var err error
+	output := captureStdout(t, func() { err = gcpSetProjectCmd.RunE(gcpSetProjectCmd, nil) })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "gcloud project: nonprod -> prod\ngcloud compute/region: (unset) -> us-central1\n"; output != want {
+		t.Errorf("output = %q, want %q", output, want)
+	}
+	calls, err := os.ReadFile(callsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "config set project prod\nconfig set compute/region us-central1\n"; string(calls) != want {
+		t.Errorf("gcloud calls = %q, want %q", calls, want)
+	}
+}
+
+func TestGcpCheckADC(t *testing.T) {
+	fakeGcloudPath(t, `[ "$*" = "auth application-default print-access-token" ] && echo ya29.secret-token`)
+
+	var err error
+	output := captureStdout(t, func() {
+		err = gcpCheckADCCmd.RunE(gcpCheckADCCmd, nil)
+	})
+	if err != nil || output != "ADC: valid\n" {
+		t.Errorf("check-adc = %q, %v, want valid ADC", output, err)
+	}
+	// The access token is never printed
+	if strings.Contains(output, "ya29") {
+		t.Errorf("check-adc printed the access token: %q", output)
+	}
+
+	fakeGcloudPath(t, "exit 1")
+	output = captureStdout(t, func() {
+		err = gcpCheckADCCmd.RunE(gcpCheckADCCmd, nil)
+	})
+	if common.ExitCode(err) != common.ExitCodeExternalCommand || output != "ADC: invalid\n" {
+		t.Errorf("check-adc = %q, %v, want invalid ADC with external command error", output, err)
+	}
+}
+
+func TestGcpRefreshADC(t *testing.T) {
+	setInteractive(t, true, "")
+	// The ADC are valid only after the login, which creates $CLI_TEST_CONFIG/adc
+	t.Setenv("CLI_TEST_CONFIG", t.TempDir())
+	fakeGcloudPath(t, `case "$*" in
+  "auth application-default login") touch "$CLI_TEST_CONFIG/adc"; echo login >> "$CLI_TEST_CONFIG/calls" ;;
+  "auth application-default print-access-token") [ -f "$CLI_TEST_CONFIG/adc" ] && echo token ;;
+esac`)
+	logins := func() int {
+		content, _ := os.ReadFile(filepath.Join(os.Getenv("CLI_TEST_CONFIG"), "calls"))
+		return strings.Count(string(content), "login")
+	}
+
+	if err := gcpRefreshADCCmd.RunE(gcpRefreshADCCmd, nil); err != nil {
+		t.Fatal(err)
+	}
+	if logins() != 1 {
+		t.Errorf("%d login(s), want 1 with invalid ADC", logins())
+	}
+
+	// The valid ADC are not renewed without --force
+	if err := gcpRefreshADCCmd.RunE(gcpRefreshADCCmd, nil); err != nil {
+		t.Fatal(err)
+	}
+	if logins() != 1 {
+		t.Errorf("%d login(s), want no login with valid ADC", logins())
+	}
+}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/aeciopires/pires-cli/pkg/pireslib/common"
+	"github.com/aeciopires/pires-cli/pkg/pireslib/fileeditor"
+	"github.com/spf13/cobra"
+)
+
+// Local variables
+var (
+	// housekeepingCmd represents the base housekeeping command
+	housekeepingCmd = &cobra.Command{
+		Use:   "housekeeping",
+		Short: "Clean up files left by the CLI",
+		Long:  `Provides commands to clean up files left by previous runs of CLI.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			fmt.Println("Housekeeping command requires a subcommand (e.g., clean-temp).")
+			cmd.Help()
+		},
+	}
+
+	// --- Clean Temp Subcommand ---
+	housekeepingTempDir string
+	housekeepingDryRun  bool
+
+	housekeepingCleanTempCmd = &cobra.Command{
+		Use:   "clean-temp",
+		Short: "Remove the yq temporary files left by previous runs",
+		Long: `Removes the files of the embedded yq (yq-<digits>) left in the temporary directory by previous runs of CLI.
+	Other files are never touched. By default, only lists the files (dry-run). Use --dry-run=false to remove them.`,
+		// The startup checks are skipped, because this command doesn't use gcloud and must not extract a new yq file
+		Annotations: map[string]string{skipStartupChecksAnnotation: "true"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			files, err := fileeditor.FindYqTempFiles(housekeepingTempDir)
+			if err != nil {
+				return err
+			}
+
+			if housekeepingDryRun {
+				var size int64
+				for _, file := range files {
+					fmt.Printf("[DRY-RUN] Would remove: %s (%s)\n", file.Path, common.FormatBytes(file.Size))
+					size += file.Size
+				}
+				common.Logger("info", "%d yq temporary file(s) would be removed, total size: %s. Use --dry-run=false to remove them.", len(files), common.FormatBytes(size))
+				return nil
+			}
+
+			removed, size, errRemove := fileeditor.RemoveYqTempFiles(files)
+			common.Logger("info", "%d yq temporary file(s) removed, total size: %s.", removed, common.FormatBytes(size))
+			return errRemove
+		},
+	}
+)
+
+func init() {
+	rootCmd.AddCommand(housekeepingCmd) // Add housekeeping to parent root command
+
+	// Add subcommands to housekeepingCmd
+	housekeepingCmd.AddCommand(housekeepingCleanTempCmd)
+
+	// Flags for 'housekeeping clean-temp'
+	housekeepingCleanTempCmd.Flags().StringVarP(&housekeepingTempDir, "temp-dir", "d", "", "Temporary directory to scan (default is the CLI_TEMP_DIR variable or the OS temporary directory)")
+	housekeepingCleanTempCmd.Flags().BoolVarP(&housekeepingDryRun, "dry-run", "n", true, "Only list the files that would be removed")
+
+}
@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/aeciopires/pires-cli/internal/config"
+	"github.com/aeciopires/pires-cli/pkg/pireslib/common"
+	"github.com/aeciopires/pires-cli/pkg/pireslib/fileeditor"
+	"github.com/spf13/cobra"
+)
+
+// Local variables
+var (
+	// initCmd represents the base init command
+	initCmd = &cobra.Command{
+		Use:   "init",
+		Short: "Scaffold the files used by the CLI",
+		Long:  `Provides commands to create the config files and templates of new environments.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			fmt.Println("Init command requires a subcommand (e.g., environment).")
+			cmd.Help()
+		},
+	}
+
+	// --- Environment Subcommand ---
+	initEnvironmentName        string
+	initEnvironmentDir         string
+	initEnvironmentTemplate    string
+	initEnvironmentForce       bool
+	initEnvironmentNoTemplates bool
+
+	initEnvironmentCmd = &cobra.Command{
+		Use:   "environment",
+		Short: "Create the config file and templates of a new environment",
+		Long: `Creates the config file '.env.<name>' with the default values to be customized and copies
+	the embedded template set to the '<name>/' directory, both inside --dir.
+	The existing files are never overwritten, unless --force is used.`,
+		Example: `  pires-cli init environment --name staging --dir $HOME/pires-cli`,
+		// The config of new environment doesn't exist yet, so the startup checks are skipped
+		Annotations: map[string]string{skipStartupChecksAnnotation: "true"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			allowedEnvironments := config.AllowedEnvironmentsList()
+			if !slices.Contains(allowedEnvironments, initEnvironmentName) {
+				return common.NewValidationError("Unsupported environment '%s'. Allowed environments: %s (CLI_ALLOWED_ENVIRONMENTS variable)", initEnvironmentName, strings.Join(allowedEnvironments, ", "))
+			}
+
+			if !initEnvironmentNoTemplates {
+				templates, err := fileeditor.ListEmbeddedTemplates()
+				if err != nil {
+					return err
+				}
+				if !slices.Contains(templates, initEnvironmentTemplate) {
+					return common.NewValidationError("template set '%s' not found. Run 'templates list' to see the available template sets or use --no-templates to create only the config file", initEnvironmentTemplate)
+				}
+			}
+
+			configPath := filepath.Join(initEnvironmentDir, ".env."+initEnvironmentName)
+			templatesDir := filepath.Join(initEnvironmentDir, initEnvironmentName)
+
+			// Check both destinations before any change, so nothing is created when one of them exists
+			if !initEnvironmentForce {
+				if _, err := os.Stat(configPath); err == nil {
+					return common.NewValidationError("config file '%s' already exists. Use --force to overwrite it", configPath)
+				}
+				if entries, err := os.ReadDir(templatesDir); err == nil && len(entries) > 0 && !initEnvironmentNoTemplates {
+					return common.NewValidationError("directory '%s' already exists and is not empty. Use --force to overwrite its files", templatesDir)
+				}
+			}
+
+			if err := os.MkdirAll(initEnvironmentDir, config.PermissionDir); err != nil {
+				return fmt.Errorf("failed to create directory '%s': %w", initEnvironmentDir, err)
+			}
+			if err := common.WriteFileAtomic(configPath, []byte(config.BuildEnvConfigTemplate(initEnvironmentName)), config.PermissionFile); err != nil {
+				return fmt.Errorf("failed to write config file '%s': %w", configPath, err)
+			}
+			common.Logger("info", "Config file created: %s", configPath)
+
+			if initEnvironmentNoTemplates {
+				return nil
+			}
+
+			if err := fileeditor.CopyTemplateFiles(initEnvironmentTemplate, templatesDir); err != nil {
+				return err
+			}
+			common.Logger("info", "Template set '%s' copied to: %s", initEnvironmentTemplate, templatesDir)
+			return nil
+		},
+	}
+)
+
+func init() {
+	rootCmd.AddCommand(initCmd) // Add initCmd to the root command
+
+	// Add subcommands to initCmd
+	initCmd.AddCommand(initEnvironmentCmd)
+
+	// Flags for 'init environment'
+	initEnvironmentCmd.Flags().StringVarP(&initEnvironmentName, "name", "n", "", "Name of the new environment (e.g. staging) (required)")
+	initEnvironmentCmd.Flags().StringVarP(&initEnvironmentDir, "dir", "d", ".", "Directory of the config file and templates")
+	initEnvironmentCmd.Flags().StringVarP(&initEnvironmentTemplate, "template", "t", "templates/common", "Embedded template set copied to the environment directory. Run 'templates list' to see the available template sets")
+	initEnvironmentCmd.Flags().BoolVarP(&initEnvironmentForce, "force", "f", false, "Overwrite the existing files (optional)")
+	initEnvironmentCmd.Flags().BoolVar(&initEnvironmentNoTemplates, "no-templates", false, "Create only the config file, without copying the template set (optional)")
+	initEnvironmentCmd.MarkFlagsMutuallyExclusive("template", "no-templates")
+
+	// Flags are required
+	_ = initEnvironmentCmd.MarkFlagRequired("name")
+
+}
@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aeciopires/pires-cli/internal/config"
+	"github.com/aeciopires/pires-cli/pkg/pireslib/common"
+	"github.com/aeciopires/pires-cli/pkg/pireslib/fileeditor"
+)
+
+// runInitEnvironment runs 'init environment' with the flags during the test and returns its error
+func runInitEnvironment(t *testing.T, name, dir, template string, force, noTemplates bool) error {
+	t.Helper()
+	previousName, previousDir, previousTemplate := initEnvironmentName, initEnvironmentDir, initEnvironmentTemplate
+	previousForce, previousNoTemplates := initEnvironmentForce, initEnvironmentNoTemplates
+	previousAllowed := config.Properties.AllowedEnvironments
+	t.Cleanup(func() {
+		initEnvironmentName, initEnvironmentDir, initEnvironmentTemplate = previousName, previousDir, previousTemplate
+		initEnvironmentForce, initEnvironmentNoTemplates = previousForce, previousNoTemplates
+		config.Properties.AllowedEnvironments = previousAllowed
+	})
+
+	initEnvironmentName, initEnvironmentDir, initEnvironmentTemplate = name, dir, template
+	initEnvironmentForce, initEnvironmentNoTemplates = force, noTemplates
+	config.Properties.AllowedEnvironments = ""
+	return initEnvironmentCmd.RunE(initEnvironmentCmd, nil)
+}
+
+func TestInitEnvironmentCreatesConfigAndTemplates(t *testing.T) {
+	templates, err := fileeditor.ListEmbeddedTemplates()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(templates) == 0 {
+		t.Skip("no template set embedded in this build")
+	}
+	dir := t.TempDir()
+
+	if err := runInitEnvironment(t, "staging", dir, templates[0], false, false); err != nil {
+		t.Fatalf("init environment: %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(dir, ".env.staging"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(content), `CLI_ENVIRONMENT="staging"`) {
+		t.Errorf("config file = %q, want the environment", content)
+	}
+	if entries, err := os.ReadDir(filepath.Join(dir, "staging")); err != nil || len(entries) == 0 {
+		t.Errorf("template directory not created: %v", err)
+	}
+}
+
+func TestInitEnvironmentWithoutTemplates(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "new")
+
+	if err := runInitEnvironment(t, "dev", dir, "", false, true); err != nil {
+		t.Fatalf("init environment: %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(dir, ".env.dev"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != config.BuildEnvConfigTemplate("dev") {
+		t.Errorf("config file = %q, want the config template", content)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "dev")); !os.IsNotExist(err) {
+		t.Errorf("template directory created with --no-templates: %v", err)
+	}
+}
+
+func TestInitEnvironmentExistingConfig(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, ".env.dev")
+	if err := os.WriteFile(configPath, []byte("CLI_GCP_PROJECT=custom\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The existing file is never overwritten without --force
+	err := runInitEnvironment(t, "dev", dir, "", false, true)
+	if common.ExitCode(err) != common.ExitCodeValidation {
+		t.Fatalf("error = %v, want validation error", err)
+	}
+	if content, _ := os.ReadFile(configPath); string(content) != "CLI_GCP_PROJECT=custom\n" {
+		t.Errorf("config file overwritten: %q", content)
+	}
+
+	if err := runInitEnvironment(t, "dev", dir, "", true, true); err != nil {
+		t.Fatalf("init environment --force: %v", err)
+	}
+	if content, _ := os.ReadFile(configPath); string(content) != config.BuildEnvConfigTemplate("dev") {
+		t.Errorf("config file = %q, want the config template with --force", content)
+	}
+}
+
+func TestInitEnvironmentInvalid(t *testing.T) {
+	tests := []struct {
+		name        string
+		environment string
+		template    string
+		noTemplates bool
+	}{
+		{"unsupported environment", "sandbox", "", true},
+		{"missing template set", "dev", "templates/missing", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			err := runInitEnvironment(t, tt.environment, dir, tt.template, false, tt.noTemplates)
+			if common.ExitCode(err) != common.ExitCodeValidation {
+				t.Fatalf("error = %v, want validation error", err)
+			}
+			// Nothing is created
+			if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+				t.Errorf("files created after validation error: %v", entries)
+			}
+		})
+	}
+}
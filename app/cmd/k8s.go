@@ -0,0 +1,192 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/aeciopires/pires-cli/pkg/pireslib/common"
+	"github.com/aeciopires/pires-cli/pkg/pireslib/k8s"
+	"github.com/spf13/cobra"
+)
+
+// Local variables
+var (
+	// k8sCmd represents the base k8s command
+	k8sCmd = &cobra.Command{
+		Use:   "k8s",
+		Short: "Perform operations in Kubernetes clusters and manifests",
+		Long:  `Provides commands to validate Kubernetes manifests using kubectl or offline.`,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			// This runs before any k8s subcommand, except the ones with their own PersistentPreRunE
+
+			// Check that kubectl reaches the cluster of current context, avoiding late failures in the middle of operations
+			return k8s.CheckKubectlContext()
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			fmt.Println("K8S command requires a subcommand (e.g., validate).")
+			cmd.Help()
+		},
+	}
+
+	// --- Validate Subcommand ---
+	k8sValidateDir        string
+	k8sValidateDryRunMode string
+
+	k8sValidateCmd = &cobra.Command{
+		Use:   "validate",
+		Short: "Validate Kubernetes manifests using kubectl dry-run",
+		Long: `Runs 'kubectl apply --dry-run=<mode>' for each YAML file (except *.patch.yaml) of a directory and its subdirectories.
+	The server mode sends the manifests to the cluster of current context, detecting deprecated or removed APIs
+	of the target Kubernetes version. The client mode skips the cluster check and the server-side validation,
+	but kubectl still uses the API server of current context to discover the resource types, so it isn't offline.
+	Exit with non-zero code if any manifest is invalid.`,
+		// Override the k8s PersistentPreRunE, because only the server mode requires a checked cluster context.
+		// ValidateManifests doesn't check the context again.
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if k8sValidateDryRunMode == k8s.DryRunModeClient {
+				return nil
+			}
+			return k8s.CheckKubectlContext()
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			files, failed, err := k8s.ValidateManifests(k8sValidateDir, k8sValidateDryRunMode)
+			if err != nil {
+				return err
+			}
+
+			for _, file := range files {
+				if errFile, exists := failed[file]; exists {
+					fmt.Printf("[FAILED] %s: %v\n", file, errFile)
+					continue
+				}
+				fmt.Printf("[OK]     %s\n", file)
+			}
+
+			common.Logger("info", "Summary: %d manifest(s) valid, %d manifest(s) invalid.", len(files)-len(failed), len(failed))
+			if len(failed) > 0 {
+				return common.NewFindingsError("%d manifest(s) are invalid", len(failed))
+			}
+			return nil
+		},
+	}
+
+	// --- Check Refs Subcommand ---
+	k8sCheckRefsRootDir string
+
+	k8sCheckRefsCmd = &cobra.Command{
+		Use:   "check-refs",
+		Short: "Check that the ConfigMaps and Secrets referenced by manifests are declared",
+		Long: `Parses the YAML files (except *.patch.yaml) of a directory and its subdirectories, including multi-document files,
+	and lists the references to ConfigMaps and Secrets (envFrom, env[].valueFrom, volumes and imagePullSecrets)
+	without a declaration in the same manifests. The optional references are ignored. It doesn't require a cluster.
+	Exit with non-zero code if any reference is dangling.`,
+		Example: `  pires-cli k8s check-refs --root-dir ./manifests`,
+		// The startup checks are skipped, because this command only reads local files
+		Annotations: map[string]string{skipStartupChecksAnnotation: "true"},
+		// Override the k8s PersistentPreRunE, because this command doesn't require a cluster
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error { return nil },
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			dangling, err := k8s.CheckManifestRefs(k8sCheckRefsRootDir)
+			if err != nil {
+				return err
+			}
+			if len(dangling) == 0 {
+				common.Logger("info", "All ConfigMaps and Secrets referenced by manifests of '%s' are declared.", k8sCheckRefsRootDir)
+				return nil
+			}
+
+			var rows [][]string
+			for _, ref := range dangling {
+				rows = append(rows, []string{
+					ref.File,
+					fmt.Sprintf("%s/%s", ref.Resource.Kind, ref.Resource.Name),
+					fmt.Sprintf("%s/%s", ref.Ref.Kind, ref.Ref.Name),
+				})
+			}
+			if err := common.WriteTable(os.Stdout, []string{"FILE", "RESOURCE", "MISSING_REFERENCE"}, rows); err != nil {
+				return err
+			}
+			return common.NewFindingsError("%d reference(s) to ConfigMaps or Secrets not declared in '%s'", len(dangling), k8sCheckRefsRootDir)
+		},
+	}
+
+	// --- Policy Check Subcommand ---
+	k8sPolicyCheckPolicyDir     string
+	k8sPolicyCheckRootDir       string
+	k8sPolicyCheckAllNamespaces bool
+
+	k8sPolicyCheckCmd = &cobra.Command{
+		Use:   "policy-check",
+		Short: "Check Kubernetes manifests against OPA policies using conftest",
+		Long: `Runs 'conftest test' with the Rego policies of a directory over the YAML files (except *.patch.yaml)
+	of a directory and its subdirectories, and lists the failures (deny/violation rules) and warnings (warn rules)
+	with their files and rules. It requires conftest (https://www.conftest.dev/install/), but doesn't require a cluster.
+	Exit with non-zero code if any policy fails.`,
+		Example: `  pires-cli k8s policy-check --policy-dir ./policy --root-dir ./manifests`,
+		// The startup checks are skipped, because this command only reads local files
+		Annotations: map[string]string{skipStartupChecksAnnotation: "true"},
+		// Override the k8s PersistentPreRunE, because this command doesn't require a cluster
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error { return nil },
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			checked, results, err := k8s.CheckManifestPolicies(k8sPolicyCheckPolicyDir, k8sPolicyCheckRootDir, k8sPolicyCheckAllNamespaces)
+			if err != nil {
+				return err
+			}
+
+			failures := 0
+			var rows [][]string
+			for _, result := range results {
+				if result.Result == k8s.PolicyResultFailure {
+					failures++
+				}
+				rows = append(rows, []string{result.File, result.Result, result.Rule, result.Message})
+			}
+			if len(rows) > 0 {
+				if err := common.WriteTable(os.Stdout, []string{"FILE", "RESULT", "RULE", "MESSAGE"}, rows); err != nil {
+					return err
+				}
+			}
+
+			common.Logger("info", "Summary: %d file(s) checked, %d failure(s), %d warning(s).", checked, failures, len(results)-failures)
+			if failures > 0 {
+				return common.NewFindingsError("%d policy failure(s) in manifests of '%s'", failures, k8sPolicyCheckRootDir)
+			}
+			return nil
+		},
+	}
+)
+
+func init() {
+	rootCmd.AddCommand(k8sCmd) // Add k8s to parent root command
+
+	// Add subcommands to k8sCmd
+	k8sCmd.AddCommand(k8sValidateCmd)
+	k8sCmd.AddCommand(k8sCheckRefsCmd)
+	k8sCmd.AddCommand(k8sPolicyCheckCmd)
+
+	// Flags for 'k8s validate'
+	k8sValidateCmd.Flags().StringVarP(&k8sValidateDir, "dir", "d", "", "Directory of Kubernetes manifests (required)")
+	k8sValidateCmd.Flags().StringVarP(&k8sValidateDryRunMode, "dry-run-mode", "m", k8s.DryRunModeServer, "Dry-run mode. Supported values: client or server")
+
+	// Flags are required
+	_ = k8sValidateCmd.MarkFlagRequired("dir")
+
+	// Flags for 'k8s check-refs'
+	k8sCheckRefsCmd.Flags().StringVarP(&k8sCheckRefsRootDir, "root-dir", "d", "", "Directory of Kubernetes manifests (required)")
+
+	// Flags are required
+	_ = k8sCheckRefsCmd.MarkFlagRequired("root-dir")
+
+	// Flags for 'k8s policy-check'
+	k8sPolicyCheckCmd.Flags().StringVarP(&k8sPolicyCheckPolicyDir, "policy-dir", "p", "", "Directory of Rego policies used by conftest (required)")
+	k8sPolicyCheckCmd.Flags().StringVarP(&k8sPolicyCheckRootDir, "root-dir", "d", "", "Directory of Kubernetes manifests (required)")
+	k8sPolicyCheckCmd.Flags().BoolVarP(&k8sPolicyCheckAllNamespaces, "all-namespaces", "a", false, "Use the rules of all namespaces of policies, instead of only the 'main' namespace")
+
+	// Flags are required
+	_ = k8sPolicyCheckCmd.MarkFlagRequired("policy-dir")
+	_ = k8sPolicyCheckCmd.MarkFlagRequired("root-dir")
+
+}
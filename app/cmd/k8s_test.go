@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aeciopires/pires-cli/pkg/pireslib/common"
+)
+
+func TestK8sCheckKubectlContext(t *testing.T) {
+	tests := []struct {
+		name     string
+		kubectl  string
+		wantCode int
+	}{
+		{"reachable", `[ "$1" = config ] && echo gke_p_us-central1_c; exit 0`, 0},
+		{"no current context", `[ "$1" = config ] && echo "error: current-context is not set" >&2; exit 1`, common.ExitCodeValidation},
+		{"unreachable", `[ "$1" = config ] && echo gke_p_us-central1_c && exit 0; exit 1`, common.ExitCodeExternalCommand},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeCommandsPath(t, map[string]string{"kubectl": tt.kubectl})
+			if got := common.ExitCode(k8sCmd.PersistentPreRunE(k8sCmd, nil)); got != tt.wantCode {
+				t.Errorf("exit code = %d, want %d", got, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestK8sCommandsWithoutCluster(t *testing.T) {
+	// kubectl fails without context, but it must not be required
+	fakeCommandsPath(t, map[string]string{"kubectl": "exit 1"})
+	previousMode := k8sValidateDryRunMode
+	k8sValidateDryRunMode = "client"
+	t.Cleanup(func() { k8sValidateDryRunMode = previousMode })
+
+	if err := k8sValidateCmd.PersistentPreRunE(k8sValidateCmd, nil); err != nil {
+		t.Errorf("validate --dry-run client: %v, want no check of context", err)
+	}
+	if err := k8sCheckRefsCmd.PersistentPreRunE(k8sCheckRefsCmd, nil); err != nil {
+		t.Errorf("check-refs: %v, want no check of context", err)
+	}
+
+	// The server mode checks the context
+	k8sValidateDryRunMode = "server"
+	if err := k8sValidateCmd.PersistentPreRunE(k8sValidateCmd, nil); err == nil {
+		t.Error("validate --dry-run server: expected error without context")
+	}
+}
+
+func TestK8sPolicyCheckDenial(t *testing.T) {
+	rootDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(rootDir, "deployment.yaml"), []byte("kind: Deployment\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	fakeCommandsPath(t, map[string]string{"conftest": `echo '[{"filename": "deployment.yaml", "failures": [{"msg": "containers must not run as root", "metadata": {"query": "data.main.deny"}}]}]'
+exit 1`})
+	k8sPolicyCheckPolicyDir, k8sPolicyCheckRootDir = t.TempDir(), rootDir
+	t.Cleanup(func() { k8sPolicyCheckPolicyDir, k8sPolicyCheckRootDir = "", "" })
+
+	var err error
+	output := captureStdout(t, func() { err = k8sPolicyCheckCmd.RunE(k8sPolicyCheckCmd, nil) })
+	if err == nil || common.ExitCode(err) == 0 {
+		t.Errorf("error = %v, want non-zero exit code on denial", err)
+	}
+	for _, want := range []string{"deployment.yaml", "FAIL", "data.main.deny", "containers must not run as root"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output does not contain %q:\n%s", want, output)
+		}
+	}
+}
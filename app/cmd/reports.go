@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aeciopires/pires-cli/internal/config"
+	"github.com/aeciopires/pires-cli/pkg/pireslib/common"
+	"github.com/spf13/cobra"
+)
+
+// Local variables
+var (
+	// reportsCmd represents the base reports command
+	reportsCmd = &cobra.Command{
+		Use:   "reports",
+		Short: "Manage the reports exported by the CLI",
+		Long:  `Provides commands to manage the timestamped reports exported by the CLI (firewall rules, permissions, audit logs, etc).`,
+		Run: func(cmd *cobra.Command, args []string) {
+			fmt.Println("Reports command requires a subcommand (e.g., prune).")
+			cmd.Help()
+		},
+	}
+
+	// --- Prune Subcommand ---
+	reportsPruneDir       string
+	reportsPruneOlderThan time.Duration
+	reportsPruneDryRun    bool
+
+	reportsPruneCmd = &cobra.Command{
+		Use:   "prune",
+		Short: "Remove the old reports exported by the CLI",
+		Long: `Removes the reports of a directory (not recursive) modified before --older-than, like: 720h (30 days).
+	Only the files matching the default filename templates of exports are touched (firewall rules, PostgreSQL permissions
+	audit logs and database sizes, service accounts roles and IAM policy), so reports with custom --filename-template are kept.
+	By default, only lists the files (dry-run). Use --dry-run=false to remove them.`,
+		Example: `  pires-cli reports prune --dir $HOME/reports --older-than 720h --dry-run=false`,
+		// The startup checks are skipped, because this command only removes local files
+		Annotations: map[string]string{skipStartupChecksAnnotation: "true"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			if reportsPruneOlderThan <= 0 {
+				return common.NewValidationError("--older-than must be greater than 0 (e.g. 720h)")
+			}
+
+			files, err := common.FindReportFiles(reportsPruneDir, config.DefaultReportFilenameTemplates(), reportsPruneOlderThan, time.Now())
+			if err != nil {
+				return err
+			}
+
+			if reportsPruneDryRun {
+				var size int64
+				for _, file := range files {
+					fmt.Printf("[DRY-RUN] Would remove: %s (%s, modified at %s)\n", file.Path, common.FormatBytes(file.Size), file.ModTime.Format(time.RFC3339))
+					size += file.Size
+				}
+				common.Logger("info", "%d report(s) would be removed, total size: %s. Use --dry-run=false to remove them.", len(files), common.FormatBytes(size))
+				return nil
+			}
+
+			removed, size, errRemove := common.RemoveReportFiles(files)
+			common.Logger("info", "%d report(s) removed, freed space: %s.", removed, common.FormatBytes(size))
+			return errRemove
+		},
+	}
+)
+
+func init() {
+	rootCmd.AddCommand(reportsCmd) // Add reports to parent root command
+
+	// Add subcommands to reportsCmd
+	reportsCmd.AddCommand(reportsPruneCmd)
+
+	// Flags for 'reports prune'
+	reportsPruneCmd.Flags().StringVarP(&reportsPruneDir, "dir", "d", ".", "Directory of the reports")
+	reportsPruneCmd.Flags().DurationVarP(&reportsPruneOlderThan, "older-than", "a", 0, "Remove the reports modified before this age, like: 720h (30 days) (required)")
+	reportsPruneCmd.Flags().BoolVarP(&reportsPruneDryRun, "dry-run", "n", true, "Only list the reports that would be removed")
+
+	// Flags are required
+	_ = reportsPruneCmd.MarkFlagRequired("older-than")
+
+}
@@ -1,25 +1,39 @@
 package cmd
 
 import (
+	"context"
 	"errors" // Required for errors.As
 	"fmt"
+	"io"
+	"net/http"
+	"net/url"
 	"os"
+	"os/exec"
+	"path"
 	"reflect"
+	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/aeciopires/pires-cli/internal/config"
 	"github.com/aeciopires/pires-cli/internal/getinfo"
 	"github.com/aeciopires/pires-cli/pkg/pireslib/common"
+	"github.com/aeciopires/pires-cli/pkg/pireslib/fileeditor"
 	"github.com/go-playground/validator/v10"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"gopkg.in/yaml.v2"
 )
 
+// skipStartupChecksAnnotation is the annotation of commands that run their own checks. See SkipStartupChecks function
+const skipStartupChecksAnnotation = "skipStartupChecks"
+
 // Local variables
 var (
 	longVersion  *bool
 	shortVersion *bool
+	// configValidationErr stores the config validation error when the startup checks are skipped
+	configValidationErr error
 
 	// rootCmd represents the base command when called without any subcommands
 	rootCmd = &cobra.Command{
@@ -41,10 +55,15 @@ var (
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
-func Execute() {
-	err := rootCmd.Execute()
+// The ctx is cancelled on SIGINT/SIGTERM and is available to commands by cmd.Context().
+func Execute(ctx context.Context) {
+	// The gcp commands pass the arguments after '--' to gcloud
+	enableGcloudExtraArgs(gcpCmd)
+
+	// Errors are mapped to distinct exit codes. See common.ExitCode function
+	err := rootCmd.ExecuteContext(ctx)
 	if err != nil {
-		os.Exit(1)
+		os.Exit(common.ExitCode(err))
 	}
 
 	// Show longVersion. *longVersion contains the pointer address. If the content is true print longVersion, system and arch
@@ -75,19 +94,37 @@ func Execute() {
 func init() {
 	config.Config()
 	cobra.OnInitialize(initConfig)
+	// Flags were parsed successfully, so errors returned by commands from here are not usage errors.
+	// Don't print the usage text for them (e.g. failure of gcloud command or Ctrl-C).
+	cobra.OnInitialize(func() { rootCmd.SilenceUsage = true })
+
+	// Invalid flags are validation errors
+	rootCmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return common.NewValidationError("%w", err)
+	})
 
 	// Here you will define your flags and configuration settings.
 	// Cobra supports persistent flags, which, if defined here,
 	// will be global for your application.
 	rootCmd.PersistentFlags().StringVarP(&config.Properties.DefaultConfigFile, "config-file", "C", config.Properties.DefaultConfigFile, "config file path")
-	rootCmd.PersistentFlags().StringVarP(&config.Properties.DefaultEnvironment, "environment", "E", config.Properties.DefaultEnvironment, "Name of environment. Supported values: dev, staging or production")
+	rootCmd.PersistentFlags().StringVarP(&config.Properties.DefaultEnvironment, "environment", "E", config.Properties.DefaultEnvironment, "Name of environment. Supported values: dev, staging or production, or the values of CLI_ALLOWED_ENVIRONMENTS variable")
 	rootCmd.PersistentFlags().StringVarP(&config.Properties.DefaultGCPProject, "gcp-project", "P", config.Properties.DefaultGCPProject, "GCP name project.")
 	rootCmd.PersistentFlags().StringVarP(&config.Properties.DefaultGCPRegion, "gcp-region", "R", config.Properties.DefaultGCPRegion, "GCP region.")
 	rootCmd.PersistentFlags().StringVarP(&config.Properties.DefaultDatabaseType, "database-type", "T", config.Properties.DefaultDatabaseType, "Database type. Supported values: postgresql or mongodb or none")
 	rootCmd.PersistentFlags().StringVarP(&config.Properties.DefaultVPNAddressTarget, "vpn-address-target", "I", config.Properties.DefaultVPNAddressTarget, "Address for VPN connectivity check. Required if --vpn-check-connection is true. Must be a valid URL (http or https).")
+	rootCmd.PersistentFlags().DurationVar(&config.VPNTimeout, "vpn-timeout", config.VPNTimeout, "Timeout of the VPN connectivity check (e.g. 5s, 1m).")
 	rootCmd.PersistentFlags().BoolVarP(&config.VPNCheckConnection, "vpn-check-connection", "J", false, "VPN check or not connection. If true, it will check the VPN connection using the --vpn-address-target flag.")
 
 	config.Debug = rootCmd.PersistentFlags().BoolP("debug", "D", false, "Enable debug mode.")
+	rootCmd.PersistentFlags().BoolVarP(&config.AssumeYes, "yes", "y", false, "Automatically answer 'yes' to all prompts (non-interactive mode).")
+	rootCmd.PersistentFlags().BoolVar(&config.AssumeYes, "assume-yes", false, "Alias of --yes.")
+	rootCmd.PersistentFlags().StringVar(&config.GcloudPath, "gcloud-path", config.GcloudPath, "Path of gcloud binary. It can be set by CLI_GCLOUD_PATH environment variable too.")
+	rootCmd.PersistentFlags().StringVar(&config.PsqlPath, "psql-path", config.PsqlPath, "Path of psql binary. It can be set by CLI_PSQL_PATH environment variable too.")
+	rootCmd.PersistentFlags().StringVar(&config.TempDir, "temp-dir", "", "Directory of temporary files (embedded yq, merged YAML files, remote config file). It must be writable and allow execution. It can be set by CLI_TEMP_DIR environment variable too. Default is the OS temporary directory.")
+	rootCmd.PersistentFlags().IntVar(&config.MaxConcurrency, "max-concurrency", config.MaxConcurrency, "Maximum number of operations performed in parallel (minimum 1). Use 1 to force serial execution.")
+	rootCmd.PersistentFlags().BoolVar(&config.ShowExternalStderr, "show-external-stderr", false, "Show the stderr of external commands (gcloud, psql, kubectl, yq) that succeeded. By default, it is shown only in debug mode.")
+	rootCmd.PersistentFlags().StringVar(&config.ConfigProfile, "profile", "", "Name of config profile. The config file $HOME/.config/pires-cli/<profile>.env is used instead of --config-file. Run 'config list-profiles' to see the available profiles.")
+	rootCmd.PersistentFlags().BoolVar(&config.NoConfigFile, "no-config-file", false, "Don't read any config file. Only environment variables (CLI_*) and default values are used.")
 
 	// Cobra also supports local flags, which will only run
 	// when this action is called directly.
@@ -100,6 +137,10 @@ func init() {
 	//	"vpn-address-target",
 	//)
 
+	// The profile defines the config file
+	rootCmd.MarkFlagsMutuallyExclusive("config-file", "profile")
+	rootCmd.MarkFlagsMutuallyExclusive("no-config-file", "profile")
+
 	// Flags must be provided together
 	rootCmd.MarkFlagsRequiredTogether(
 		//"config-file",
@@ -113,6 +154,8 @@ func init() {
 // initConfig reads in config file and ENV variables if set.
 // This function is performaded in cmd/root.go and cmd/subcommand.go
 func initConfig() {
+	// The flags are parsed, so the --debug flag defines the log level from here
+	common.ConfigureLogLevel()
 	// Environment variables expect with prefix CLI_ . This helps avoid conflicts.
 	viper.SetEnvPrefix("cli")
 	// Type file
@@ -120,74 +163,106 @@ func initConfig() {
 	// Environment variables can't have dashes in them, so bind them to their equivalent
 	// keys with underscores, e.g. --gcp-region to CLI_GCP_REGION
 	viper.SetEnvKeyReplacer(strings.NewReplacer("-", "_"))
-	// Attempt to read the SPECIFIC config file (passed by default value or -c option)
-	common.Logger("debug", "Attempting to read specific config file: %s", config.Properties.DefaultConfigFile)
-	// Tell Viper the exact file path
-	viper.SetConfigFile(config.Properties.DefaultConfigFile)
-	// Attempt to read the specific file
-	err := viper.ReadInConfig()
-	// Handle outcome of reading the specific file
-	if err == nil {
-		// SUCCESS reading specific file
-		common.Logger("debug", "Using config file: %v", viper.ConfigFileUsed())
-	} else {
-		// FAILURE reading specific file - Log details and attempt fallback
-		common.Logger("error", "Could not read specific config file '%s': %v\n", viper.ConfigFileUsed(), err)
-		// Check if the error was specifically "file not found"
-		var configFileNotFoundError viper.ConfigFileNotFoundError
-		if errors.As(err, &configFileNotFoundError) {
-			common.Logger("info", "Specific config file not found. Falling back to search for '.env' file.")
-		} else {
-			// A different error occurred (permissions, format, etc.)
-			common.Logger("warning", "Error occurred while reading specific config file '%s'.: %v\n", viper.ConfigFileUsed(), err)
-			common.Logger("warning", "Check %v file permissions and format.", viper.ConfigFileUsed())
+	// Resolve the temporary directory before the remote config file is downloaded
+	if err := resolveTempDir(); err != nil {
+		if !SkipStartupChecks() {
+			common.Exit(err)
 		}
+		configValidationErr = errors.Join(configValidationErr, err)
+	}
+	// The config file can be disabled by environment variable too
+	if noConfigFile, errParse := strconv.ParseBool(os.Getenv("CLI_NO_CONFIG_FILE")); errParse == nil && noConfigFile {
+		config.NoConfigFile = true
+	}
 
-		// Configure and attempt fallback search for ".env"
-		common.Logger("debug", "Setting up fallback search for '.env' in paths: '.', '/app'")
-		viper.SetConfigName(".env") // Target filename for fallback
-		viper.SetConfigType("env")  // Expected format for fallback
-		viper.AddConfigPath(".")    // Search current directory
-		viper.AddConfigPath("/app") // Search /app directory
-
-		// Attempt to read AGAIN, performing the search defined above
-		if fallbackErr := viper.ReadInConfig(); fallbackErr == nil {
-			// SUCCESS reading fallback .env file
-			common.Logger("debug", "Using fallback config file: %v", viper.ConfigFileUsed())
-		} else {
-			// FAILURE reading fallback .env file
-			if errors.As(fallbackErr, &configFileNotFoundError) {
-				// This is expected if no .env file exists in the search paths
-				common.Logger("info", "No '.env' config file found in search paths either. Using defaults and environment variables.")
-			} else {
-				// An error occurred reading the fallback .env file (permissions, format?)
-				common.Logger("warning", "Error reading fallback '.env' file: %v\n", fallbackErr)
-				common.Logger("warning", "Check %v file permissions and format.", viper.ConfigFileUsed())
-			}
+	// The config file of profile overrides the default config file
+	if config.ConfigProfile != "" {
+		profileConfigFile, errProfile := config.ProfileConfigFile(config.ConfigProfile)
+		if errProfile != nil {
+			common.Exit(common.NewValidationError("%w", errProfile))
+		}
+		// Don't fall back to other config files, because the wrong project could be used
+		if _, errStat := os.Stat(profileConfigFile); errStat != nil {
+			common.Exit(common.NewValidationError("config file of profile '%s' not found: %s. Run 'config list-profiles' to see the available profiles", config.ConfigProfile, profileConfigFile))
 		}
+		common.Logger("debug", "Using config file of profile '%s': %s", config.ConfigProfile, profileConfigFile)
+		config.Properties.DefaultConfigFile = profileConfigFile
+	}
+
+	if config.NoConfigFile {
+		common.Logger("debug", "Config file disabled. Using only environment variables and default values.")
+	} else if err := readSpecificConfigFile(config.Properties.DefaultConfigFile); err != nil {
+		// Attempt to read the SPECIFIC config file (passed by default value or -c option)
+		// FAILURE reading specific file - attempt fallback
+		readFallbackConfigFile()
 	}
 
 	// Read in environment variables that match Viper keys or have the CLI_ prefix
 	// Read environment variables *now*. They might be overridden by config file.
 	viper.AutomaticEnv()
+	// AutomaticEnv only works for keys already known by viper (e.g. read from config file).
+	// Bind the environment variables of all fields to support the use without config file.
+	bindEnvVars()
 
 	// Unmarshal the final configuration
 	// Viper now contains the merged view: Defaults overridden by Env Vars overridden by (potentially) a loaded Config File.
 	common.Logger("debug", "Unmarshaling final configuration into struct.")
 	if err := viper.Unmarshal(&config.Properties); err != nil {
-		common.Logger("fatal", "Error unmarshaling config: %s", err)
+		common.Exit(common.NewValidationError("Error unmarshaling config: %w", err))
 	}
 
 	// Redefining variables
 	config.Properties.DefaultGSABaseAccountName = "todo-gsa"
-	config.Properties.DefaultGSAAccountName = config.Properties.DefaultGSABaseAccountName + "@" + config.Properties.DefaultGCPProject + ".iam.gserviceaccount.com"
+	config.Properties.DefaultGSAAccountName = config.BuildGSAEmail(config.Properties.DefaultGSABaseAccountName, config.Properties.DefaultGCPProject)
 
 	// Validate the populated struct
+	if err := validateConfig(); err != nil {
+		if !SkipStartupChecks() {
+			// Log error and exit with validation exit code
+			common.Exit(err)
+		}
+		// The command (e.g. doctor) reports the error by itself
+		configValidationErr = err
+	}
+
+	// Resolve the binaries executed by CLI and check the required commands
+	if err := resolveBinaryPaths(); err != nil {
+		if !SkipStartupChecks() {
+			common.Exit(err)
+		}
+		configValidationErr = errors.Join(configValidationErr, err)
+	}
+	if !SkipStartupChecks() {
+		if err := common.CheckCommandsAvailable(config.RequiredCommands()); err != nil {
+			common.Exit(err)
+		}
+		// yq is extracted to the temporary directory resolved above (--temp-dir flag or CLI_TEMP_DIR variable)
+		if err := fileeditor.EnsureYq(); err != nil {
+			common.Exit(err)
+		}
+		// The VPN connection is checked here because of --vpn-check-connection, --vpn-address-target and --vpn-timeout flags
+		if config.VPNCheckConnection {
+			if err := common.CheckVPNConnection(config.Properties.DefaultVPNAddressTarget); err != nil {
+				common.Exit(err)
+			}
+		}
+	}
+
+	// Optional: Log the final loaded configuration for verification
+	finalConfigBytes, _ := yaml.Marshal(config.Properties) // Or use json.MarshalIndent
+	common.Logger("debug", "Final Configuration Loaded:\n%s\n", string(finalConfigBytes))
+
+}
+
+// validateConfig validates the populated config.Properties struct and returns a validation error
+// with a user-friendly message if any field is invalid.
+func validateConfig() error {
 	common.Logger("debug", "Validating final configuration...")
 	// Create a new validator instance
 	validate := validator.New(validator.WithRequiredStructEnabled())
 	// Register custom validators
 	validate.RegisterValidation("noUnderscore", config.NoUnderscores)
+	validate.RegisterValidation("allowedEnvironment", config.AllowedEnvironment)
 
 	// Validate the Properties struct (pass by reference)
 	if err := validate.Struct(&config.Properties); err != nil {
@@ -202,17 +277,235 @@ func initConfig() {
 					fieldErr.Tag(),             // e.g., "required", "oneof"
 					fieldErr.Value(),           // The actual invalid value
 				)
+				if fieldErr.Tag() == "allowedEnvironment" {
+					errorMsg += fmt.Sprintf("    Allowed environments: %s (see CLI_ALLOWED_ENVIRONMENTS variable)\n", strings.Join(config.AllowedEnvironmentsList(), ", "))
+				}
+			}
+			return common.NewValidationError("%s", errorMsg)
+		}
+		// Handle other potential errors during validation itself (less common)
+		return common.NewValidationError("An unexpected error occurred during configuration validation: %w", err)
+	}
+
+	if config.GCPImpersonateServiceAccount != "" {
+		if err := config.ValidateServiceAccountEmail(config.GCPImpersonateServiceAccount); err != nil {
+			return common.NewValidationError("Invalid value of --impersonate-service-account option: %w", err)
+		}
+	}
+
+	if config.MaxConcurrency < 1 {
+		return common.NewValidationError("Invalid value '%d' of --max-concurrency option. The minimum value is 1", config.MaxConcurrency)
+	}
+
+	return nil
+}
+
+// resolveBinaryPaths resolves the paths of gcloud and psql binaries, once at startup.
+// The order of precedence is: --gcloud-path/--psql-path flags, CLI_GCLOUD_PATH/CLI_PSQL_PATH environment variables
+// and the default values (searched in PATH). The configured paths must be executable.
+func resolveBinaryPaths() error {
+	binaries := []struct {
+		flagName string
+		envVar   string
+		path     *string
+	}{
+		{"gcloud-path", "CLI_GCLOUD_PATH", &config.GcloudPath},
+		{"psql-path", "CLI_PSQL_PATH", &config.PsqlPath},
+	}
+
+	for _, binary := range binaries {
+		if !rootCmd.PersistentFlags().Changed(binary.flagName) {
+			envValue := os.Getenv(binary.envVar)
+			if envValue == "" {
+				// The default value is checked by CheckCommandsAvailable function (or when executed, for psql)
+				continue
 			}
-			// Log as fatal error and exit
-			common.Logger("fatal", "%s", errorMsg)
+			*binary.path = envValue
+		}
+
+		resolvedPath, err := exec.LookPath(*binary.path)
+		if err != nil {
+			return common.NewValidationError("Invalid value '%s' of --%s option (or %s environment variable): %w", *binary.path, binary.flagName, binary.envVar, err)
+		}
+		common.Logger("debug", "Using %s binary: %s", binary.flagName, resolvedPath)
+		*binary.path = resolvedPath
+	}
+
+	return nil
+}
+
+// resolveTempDir resolves the directory of temporary files, once at startup.
+// The order of precedence is: --temp-dir flag, CLI_TEMP_DIR environment variable and the OS temporary directory.
+// The configured directory must be writable and allow execution, because the embedded yq is executed from there.
+func resolveTempDir() error {
+	if !rootCmd.PersistentFlags().Changed("temp-dir") {
+		config.TempDir = os.Getenv("CLI_TEMP_DIR")
+	}
+	if config.TempDir == "" {
+		return nil
+	}
+
+	if err := common.ValidateTempDir(config.TempDir); err != nil {
+		return common.NewValidationError("Invalid value '%s' of --temp-dir option (or CLI_TEMP_DIR environment variable): %w", config.TempDir, err)
+	}
+	common.Logger("debug", "Using temporary directory: %s", config.TempDir)
+	return nil
+}
+
+// SkipStartupChecks returns true if the command to be executed with the CLI arguments
+// has the skipStartupChecksAnnotation (e.g. doctor). Those commands run their own checks,
+// so the startup checks and the config validation must not exit before the command runs.
+func SkipStartupChecks() bool {
+	command, _, err := rootCmd.Find(os.Args[1:])
+	return err == nil && command.Annotations[skipStartupChecksAnnotation] == "true"
+}
+
+// readSpecificConfigFile reads the config file passed by default value or -C option.
+// If the config file is an http(s):// URL, it is downloaded to a temporary file before read.
+func readSpecificConfigFile(configFile string) error {
+	common.Logger("debug", "Attempting to read specific config file: %s", configFile)
+
+	if strings.HasPrefix(configFile, "http://") || strings.HasPrefix(configFile, "https://") {
+		tmpConfigFile, configType, errFetch := fetchRemoteConfigFile(configFile)
+		if errFetch != nil {
+			common.Logger("warning", "Could not fetch remote config file '%s': %v", configFile, errFetch)
+			common.Logger("warning", "Falling back to search for '.env' file.")
+			return errFetch
+		}
+		defer os.Remove(tmpConfigFile)
+		configFile = tmpConfigFile
+		viper.SetConfigType(configType)
+	}
+
+	// Tell Viper the exact file path
+	viper.SetConfigFile(configFile)
+	// Attempt to read the specific file
+	err := viper.ReadInConfig()
+	// Handle outcome of reading the specific file
+	if err == nil {
+		// SUCCESS reading specific file
+		common.Logger("debug", "Using config file: %v", viper.ConfigFileUsed())
+		return nil
+	}
+
+	// FAILURE reading specific file - Log details
+	common.Logger("error", "Could not read specific config file '%s': %v\n", viper.ConfigFileUsed(), err)
+	// Check if the error was specifically "file not found"
+	var configFileNotFoundError viper.ConfigFileNotFoundError
+	if errors.As(err, &configFileNotFoundError) {
+		common.Logger("info", "Specific config file not found. Falling back to search for '.env' file.")
+	} else {
+		// A different error occurred (permissions, format, etc.)
+		common.Logger("warning", "Error occurred while reading specific config file '%s'.: %v\n", viper.ConfigFileUsed(), err)
+		common.Logger("warning", "Check %v file permissions and format.", viper.ConfigFileUsed())
+	}
+	return err
+}
+
+// readFallbackConfigFile searches for a '.env' file in the current directory and /app directory.
+func readFallbackConfigFile() {
+	// Configure and attempt fallback search for ".env"
+	common.Logger("debug", "Setting up fallback search for '.env' in paths: '.', '/app'")
+	viper.SetConfigName(".env") // Target filename for fallback
+	viper.SetConfigType("env")  // Expected format for fallback
+	viper.AddConfigPath(".")    // Search current directory
+	viper.AddConfigPath("/app") // Search /app directory
+
+	// Attempt to read AGAIN, performing the search defined above
+	var configFileNotFoundError viper.ConfigFileNotFoundError
+	if fallbackErr := viper.ReadInConfig(); fallbackErr == nil {
+		// SUCCESS reading fallback .env file
+		common.Logger("debug", "Using fallback config file: %v", viper.ConfigFileUsed())
+	} else {
+		// FAILURE reading fallback .env file
+		if errors.As(fallbackErr, &configFileNotFoundError) {
+			// This is expected if no .env file exists in the search paths
+			common.Logger("info", "No '.env' config file found in search paths either. Using defaults and environment variables.")
 		} else {
-			// Handle other potential errors during validation itself (less common)
-			common.Logger("fatal", "An unexpected error occurred during configuration validation: %s", err)
+			// An error occurred reading the fallback .env file (permissions, format?)
+			common.Logger("warning", "Error reading fallback '.env' file: %v\n", fallbackErr)
+			common.Logger("warning", "Check %v file permissions and format.", viper.ConfigFileUsed())
 		}
 	}
+}
 
-	// Optional: Log the final loaded configuration for verification
-	finalConfigBytes, _ := yaml.Marshal(config.Properties) // Or use json.MarshalIndent
-	common.Logger("debug", "Final Configuration Loaded:\n%s\n", string(finalConfigBytes))
+// fetchRemoteConfigFile downloads a config file from an http(s):// URL to a temporary file.
+// It returns the path of the temporary file and the config type inferred from the URL extension
+// ("env" if the URL has no extension). The caller must remove the temporary file.
+func fetchRemoteConfigFile(configURL string) (string, string, error) {
+	parsedURL, err := url.Parse(configURL)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid config URL '%s': %w", configURL, err)
+	}
+
+	configType := strings.TrimPrefix(path.Ext(parsedURL.Path), ".")
+	if configType == "" || !slices.Contains(viper.SupportedExts, configType) {
+		configType = "env"
+	}
+
+	common.Logger("debug", "Downloading remote config file from %s (type: %s)...", configURL, configType)
+	client := http.Client{
+		Timeout: config.ExternalCommandTimeout,
+	}
+	resp, err := client.Get(configURL)
+	if err != nil {
+		return "", "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", "", fmt.Errorf("bad status: %s", resp.Status)
+	}
+
+	tmpFile, err := os.CreateTemp(config.TempDir, "pires-cli-config-*."+configType)
+	if err != nil {
+		return "", "", fmt.Errorf("could not create temporary file for remote config: %w", err)
+	}
+	defer tmpFile.Close()
+
+	if _, err := io.Copy(tmpFile, resp.Body); err != nil {
+		os.Remove(tmpFile.Name())
+		return "", "", fmt.Errorf("could not write remote config to temporary file: %w", err)
+	}
+
+	return tmpFile.Name(), configType, nil
+}
+
+// bindEnvVars binds each field of config.Properties to its environment variable,
+// named as the uppercase mapstructure tag. Example: cli_gcp_project => CLI_GCP_PROJECT
+func bindEnvVars() {
+	for _, key := range configKeys() {
+		if err := viper.BindEnv(key, configEnvVarName(key)); err != nil {
+			common.Logger("warning", "Could not bind environment variable %s: %v", configEnvVarName(key), err)
+		}
+	}
+}
+
+// configKeys returns the viper keys of config.Properties fields (their mapstructure tags), like: cli_gcp_project
+func configKeys() []string {
+	auxType := reflect.TypeOf(config.Properties)
 
+	var keys []string
+	// Interate over the fields of the struct
+	for i := 0; i < auxType.NumField(); i++ {
+		if key := auxType.Field(i).Tag.Get("mapstructure"); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// configEnvVarName returns the environment variable of a viper key, like: CLI_GCP_PROJECT for cli_gcp_project
+func configEnvVarName(key string) string {
+	return strings.ToUpper(key)
+}
+
+// normalizeConfigKey returns the viper key of a name in any supported form, applying the same rules of viper
+// prefix and key replacer: CLI_GCP_PROJECT, cli_gcp_project, gcp_project and gcp-project return cli_gcp_project.
+func normalizeConfigKey(name string) string {
+	key := strings.ReplaceAll(strings.ToLower(strings.TrimSpace(name)), "-", "_")
+	if !strings.HasPrefix(key, "cli_") {
+		key = "cli_" + key
+	}
+	return key
 }
@@ -0,0 +1,264 @@
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aeciopires/pires-cli/internal/config"
+	"github.com/aeciopires/pires-cli/pkg/pireslib/common"
+	"github.com/spf13/viper"
+)
+
+func TestFetchRemoteConfigFile(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/config.env", "/config", "/config.yaml":
+			w.Write([]byte("CLI_GCP_PROJECT=remote-project\n"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	previousTempDir := config.TempDir
+	config.TempDir = t.TempDir()
+	t.Cleanup(func() { config.TempDir = previousTempDir })
+
+	tests := []struct {
+		path     string
+		wantType string
+	}{
+		{"/config.env", "env"},
+		{"/config", "env"},
+		{"/config.yaml", "yaml"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			tmpFile, configType, err := fetchRemoteConfigFile(server.URL + tt.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.Remove(tmpFile)
+
+			if configType != tt.wantType {
+				t.Errorf("config type = %q, want %q", configType, tt.wantType)
+			}
+			content, err := os.ReadFile(tmpFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(content) != "CLI_GCP_PROJECT=remote-project\n" {
+				t.Errorf("content = %q", content)
+			}
+		})
+	}
+
+	t.Run("not found", func(t *testing.T) {
+		if _, _, err := fetchRemoteConfigFile(server.URL + "/missing.env"); err == nil {
+			t.Fatal("expected error for missing remote config file")
+		}
+	})
+}
+
+func TestSkipStartupChecks(t *testing.T) {
+	tests := []struct {
+		args []string
+		want bool
+	}{
+		{[]string{"doctor"}, true},
+		{[]string{"gcp", "iam", "gsa-email", "--base", "app-gsa"}, true},
+		{[]string{"gcp", "iam", "diff-snapshot", "--old", "a.json", "--new", "b.json"}, true},
+		{[]string{"gcp", "iam", "validate-sa-id", "--id", "app-gsa"}, true},
+		{[]string{"gcp", "iam", "check-sa", "--service-account-email", "app@p.iam.gserviceaccount.com"}, false},
+	}
+	previousArgs := os.Args
+	t.Cleanup(func() { os.Args = previousArgs })
+
+	for _, tt := range tests {
+		os.Args = append([]string{"pires-cli"}, tt.args...)
+		if got := SkipStartupChecks(); got != tt.want {
+			t.Errorf("SkipStartupChecks() for %v = %t, want %t", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestInitConfigWithoutConfigFile(t *testing.T) {
+	// Both the specific config file and the fallback '.env' file exist, but must not be read
+	dir := t.TempDir()
+	t.Chdir(dir)
+	configFile := filepath.Join(dir, "custom.env")
+	for _, name := range []string{configFile, filepath.Join(dir, ".env")} {
+		if err := os.WriteFile(name, []byte("CLI_GCP_PROJECT=file-project\nCLI_GCP_REGION=file-region\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	previousProperties, previousArgs := config.Properties, os.Args
+	t.Cleanup(func() {
+		config.Properties, os.Args = previousProperties, previousArgs
+		config.NoConfigFile = false
+		viper.Reset()
+	})
+	config.Properties.DefaultConfigFile = configFile
+	t.Setenv("CLI_NO_CONFIG_FILE", "true")
+	t.Setenv("CLI_GCP_PROJECT", "env-project")
+	t.Setenv("CLI_GCP_REGION", "")
+	// The startup checks of doctor don't exit, so the config is only loaded
+	os.Args = []string{"pires-cli", "doctor"}
+
+	initConfig()
+
+	if used := viper.ConfigFileUsed(); used != "" {
+		t.Errorf("config file %q was read", used)
+	}
+	if config.Properties.DefaultGCPProject != "env-project" {
+		t.Errorf("project = %q, want value of environment variable", config.Properties.DefaultGCPProject)
+	}
+	if config.Properties.DefaultGCPRegion == "file-region" {
+		t.Error("region was read from config file")
+	}
+}
+
+// setBinaryPaths restores config.GcloudPath, config.PsqlPath and the --gcloud-path and --psql-path options after the test
+func setBinaryPaths(t *testing.T) {
+	t.Helper()
+	previousGcloud, previousPsql := config.GcloudPath, config.PsqlPath
+	t.Cleanup(func() {
+		for _, name := range []string{"gcloud-path", "psql-path"} {
+			flag := rootCmd.PersistentFlags().Lookup(name)
+			flag.Value.Set(flag.DefValue)
+			flag.Changed = false
+		}
+		config.GcloudPath, config.PsqlPath = previousGcloud, previousPsql
+	})
+	t.Setenv("CLI_GCLOUD_PATH", "")
+	t.Setenv("CLI_PSQL_PATH", "")
+}
+
+// writeExecutable writes a shell script named name in dir and returns its path
+func writeExecutable(t *testing.T, dir, name string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte("#!/bin/sh\nexit 0\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestResolveBinaryPathsFromEnvironment(t *testing.T) {
+	setBinaryPaths(t)
+	dir := t.TempDir()
+	gcloudPath := writeExecutable(t, dir, "gcloud-sdk-490")
+	psqlPath := writeExecutable(t, dir, "psql-16")
+	t.Setenv("CLI_GCLOUD_PATH", gcloudPath)
+	t.Setenv("CLI_PSQL_PATH", psqlPath)
+
+	if err := resolveBinaryPaths(); err != nil {
+		t.Fatal(err)
+	}
+	if config.GcloudPath != gcloudPath || config.PsqlPath != psqlPath {
+		t.Errorf("paths = %s, %s, want %s, %s", config.GcloudPath, config.PsqlPath, gcloudPath, psqlPath)
+	}
+}
+
+func TestResolveBinaryPathsFlagOverridesEnvironment(t *testing.T) {
+	setBinaryPaths(t)
+	dir := t.TempDir()
+	flagPath := writeExecutable(t, dir, "gcloud-flag")
+	t.Setenv("CLI_GCLOUD_PATH", writeExecutable(t, dir, "gcloud-env"))
+	if err := rootCmd.PersistentFlags().Set("gcloud-path", flagPath); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := resolveBinaryPaths(); err != nil {
+		t.Fatal(err)
+	}
+	if config.GcloudPath != flagPath {
+		t.Errorf("gcloud path = %s, want value of --gcloud-path option %s", config.GcloudPath, flagPath)
+	}
+}
+
+func TestResolveBinaryPathsNotExecutable(t *testing.T) {
+	setBinaryPaths(t)
+	path := filepath.Join(t.TempDir(), "psql")
+	if err := os.WriteFile(path, []byte("not executable"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("CLI_PSQL_PATH", path)
+
+	if err := resolveBinaryPaths(); common.ExitCode(err) != common.ExitCodeValidation {
+		t.Errorf("error = %v, want validation error", err)
+	}
+}
+
+// setValidProperties replaces config.Properties by a valid configuration in the environment during the test
+func setValidProperties(t *testing.T, environment, allowedEnvironments string) {
+	t.Helper()
+	previous := config.Properties
+	t.Cleanup(func() { config.Properties = previous })
+	config.Properties = config.PropertiesStruct{
+		DefaultEnvironment:        environment,
+		AllowedEnvironments:       allowedEnvironments,
+		DefaultGCPProject:         "nonprod",
+		DefaultGCPRegion:          "us-central1",
+		DefaultDatabaseType:       "postgresql",
+		DefaultVPNAddressTarget:   "https://vpn.example.com",
+		DefaultGSABaseAccountName: "app-gsa",
+		DefaultGSAAccountName:     "app-gsa@nonprod.iam.gserviceaccount.com",
+	}
+}
+
+func TestValidateConfigAllowedEnvironments(t *testing.T) {
+	tests := []struct {
+		name                string
+		environment         string
+		allowedEnvironments string
+		wantCode            int
+	}{
+		{"default environments", "staging", "", 0},
+		{"environment out of default environments", "qa", "", common.ExitCodeValidation},
+		{"custom environments", "qa", "dev,qa,production", 0},
+		{"environment out of custom environments", "staging", "dev,qa,production", common.ExitCodeValidation},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setValidProperties(t, tt.environment, tt.allowedEnvironments)
+
+			err := validateConfig()
+			if got := common.ExitCode(err); got != tt.wantCode {
+				t.Fatalf("validateConfig() = %v, want exit code %d", err, tt.wantCode)
+			}
+			if err != nil && !strings.Contains(err.Error(), "Allowed environments:") {
+				t.Errorf("error = %v, want the allowed environments", err)
+			}
+		})
+	}
+}
+
+func TestResolveTempDir(t *testing.T) {
+	previousTempDir := config.TempDir
+	t.Cleanup(func() { config.TempDir = previousTempDir })
+
+	dir := t.TempDir()
+	config.TempDir = ""
+	t.Setenv("CLI_TEMP_DIR", dir)
+	if err := resolveTempDir(); err != nil || config.TempDir != dir {
+		t.Errorf("resolveTempDir() = %v, temporary directory %q, want %q of CLI_TEMP_DIR", err, config.TempDir, dir)
+	}
+
+	t.Setenv("CLI_TEMP_DIR", filepath.Join(dir, "missing"))
+	if err := resolveTempDir(); common.ExitCode(err) != common.ExitCodeValidation {
+		t.Errorf("resolveTempDir() error = %v, want validation error", err)
+	}
+
+	// Without the variable, the OS temporary directory is used
+	config.TempDir = "ignored"
+	t.Setenv("CLI_TEMP_DIR", "")
+	if err := resolveTempDir(); err != nil || config.TempDir != "" {
+		t.Errorf("resolveTempDir() = %v, temporary directory %q, want empty", err, config.TempDir)
+	}
+}
@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/aeciopires/pires-cli/pkg/pireslib/common"
+	"github.com/aeciopires/pires-cli/pkg/pireslib/fileeditor"
+	"github.com/spf13/cobra"
+)
+
+// Local variables
+var (
+	// templatesCmd represents the base templates command
+	templatesCmd = &cobra.Command{
+		Use:   "templates",
+		Short: "Inspect the templates embedded in the CLI",
+		Long:  `Provides commands to list and extract the templates embedded in the CLI.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			fmt.Println("Templates command requires a subcommand (e.g., list, extract).")
+			cmd.Help()
+		},
+	}
+
+	// --- List Subcommand ---
+	templatesListCmd = &cobra.Command{
+		Use:   "list",
+		Short: "List the template sets embedded in the CLI",
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			templates, err := fileeditor.ListEmbeddedTemplates()
+			if err != nil {
+				return err
+			}
+			if len(templates) == 0 {
+				common.Logger("info", "No templates embedded in this version of the CLI.")
+				return nil
+			}
+			for _, template := range templates {
+				fmt.Println(template)
+			}
+			return nil
+		},
+	}
+
+	// --- Extract Subcommand ---
+	templatesExtractSource         string
+	templatesExtractDest           string
+	templatesExtractMerge          bool
+	templatesExtractPreferExisting bool
+	templatesExtractStrict         bool
+
+	templatesExtractCmd = &cobra.Command{
+		Use:   "extract",
+		Short: "Copy a template set embedded in the CLI to disk",
+		Long: `Copies a template set embedded in the CLI to a destination directory for inspection or customization.
+	Run 'templates list' to see the available template sets.
+	With --merge, the YAML files existing in the destination are merged with the embedded versions instead of
+	overwritten (the embedded values win in the Kubernetes keys, like spec and metadata, and the other keys keep the
+	values of destination). Use --prefer-existing to keep the customized values of destination
+	during upgrades, adding only the new keys of embedded versions. Use --strict to fail when a key has values of
+	different kinds (e.g. a scalar in the destination and a mapping in the embedded version), instead of overriding it.`,
+		Example: `  pires-cli templates extract -s templates/common -d ./templates
+  pires-cli templates extract -s templates/common -d ./templates --merge --prefer-existing`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			templates, err := fileeditor.ListEmbeddedTemplates()
+			if err != nil {
+				return err
+			}
+			if !slices.Contains(templates, templatesExtractSource) {
+				return common.NewValidationError("template set '%s' not found. Run 'templates list' to see the available template sets", templatesExtractSource)
+			}
+
+			if (templatesExtractPreferExisting || templatesExtractStrict) && !templatesExtractMerge {
+				return common.NewValidationError("--prefer-existing and --strict require --merge")
+			}
+			if templatesExtractMerge {
+				if err := fileeditor.CopyAndMergeYAMLDir(templatesExtractSource, templatesExtractDest, templatesExtractPreferExisting, templatesExtractStrict); err != nil {
+					return err
+				}
+			} else if err := fileeditor.CopyTemplateFiles(templatesExtractSource, templatesExtractDest); err != nil {
+				return err
+			}
+			common.Logger("info", "Template set '%s' extracted to: %s", templatesExtractSource, templatesExtractDest)
+			return nil
+		},
+	}
+)
+
+func init() {
+	rootCmd.AddCommand(templatesCmd) // Add templatesCmd to the root command
+
+	// Add subcommands to templatesCmd
+	templatesCmd.AddCommand(templatesListCmd)
+	templatesCmd.AddCommand(templatesExtractCmd)
+
+	// Flags for 'templates extract'
+	templatesExtractCmd.Flags().StringVarP(&templatesExtractSource, "source", "s", "", "Template set to extract (e.g., templates/common) (required)")
+	templatesExtractCmd.Flags().StringVarP(&templatesExtractDest, "dest", "d", "", "Destination directory (required)")
+	templatesExtractCmd.Flags().BoolVar(&templatesExtractMerge, "merge", false, "Merge the YAML files existing in the destination with the embedded versions, instead of overwriting them")
+	templatesExtractCmd.Flags().BoolVar(&templatesExtractPreferExisting, "prefer-existing", false, "With --merge, the values of destination win and only the new keys of embedded versions are added")
+	templatesExtractCmd.Flags().BoolVar(&templatesExtractStrict, "strict", false, "With --merge, fail when a key has values of different kinds (scalar, mapping or sequence) in the files")
+
+	// Flags are required
+	_ = templatesExtractCmd.MarkFlagRequired("source")
+	_ = templatesExtractCmd.MarkFlagRequired("dest")
+
+}
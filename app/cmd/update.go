@@ -20,28 +20,35 @@ var (
 		Long: `Checks for the latest release on GitHub. If a newer version is found
 for your operating system and architecture, it downloads and replaces the
 current application binary.`,
-		Run: func(cmd *cobra.Command, args []string) {
+		RunE: func(cmd *cobra.Command, args []string) error {
 			common.Logger("info", "Checking for updates...")
 
-			release := update.CheckForUpdate(githubRepo)
+			release, err := update.CheckForUpdate(githubRepo)
+			if err != nil {
+				return err
+			}
 
 			if release == nil {
 				common.Logger("warning", "You are already on the latest version: %s\n", config.CLIVersion)
-				return
+				return nil
 			}
 
-			common.Logger("info", "A new version is available: %s. Do you want to update? (y/n): ", release.TagName)
-			var response string
-			fmt.Scanln(&response)
-
-			if response != "y" && response != "Y" {
-				common.Logger("fatal", "Update cancelled.")
+			confirmed, err := common.Confirm(fmt.Sprintf("A new version is available: %s. Do you want to update?", release.TagName))
+			if err != nil {
+				return err
+			}
+			if !confirmed {
+				common.Logger("info", "Update cancelled.")
+				return nil
 			}
 
 			common.Logger("info", "Updating to version: %s", release.TagName)
-			update.ApplyUpdate(release)
+			if err := update.ApplyUpdate(release); err != nil {
+				return err
+			}
 
 			common.Logger("info", "Update complete! Please run the CLI again.")
+			return nil
 		},
 	}
 )
@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aeciopires/pires-cli/internal/config"
+	"github.com/aeciopires/pires-cli/pkg/pireslib/common"
+	"github.com/spf13/cobra"
+)
+
+// Local variables
+var (
+	// vpnCmd represents the base vpn command
+	vpnCmd = &cobra.Command{
+		Use:   "vpn",
+		Short: "Check the VPN connectivity",
+		Long:  `Provides commands to test the VPN settings (--vpn-address-target and --vpn-timeout), independent of other operations.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			fmt.Println("VPN command requires a subcommand (e.g., check).")
+			cmd.Help()
+		},
+	}
+
+	// --- Check Subcommand ---
+	vpnCheckCmd = &cobra.Command{
+		Use:   "check",
+		Short: "Print the VPN check configuration and check the connectivity",
+		Long: `Prints the resolved VPN check configuration (target, timeout and --vpn-check-connection) and requests the
+	--vpn-address-target address, like the VPN check of other commands. The result and the latency are reported.
+	Exit with non-zero code if the target is unreachable or doesn't return HTTP status 200.`,
+		Example: `  pires-cli vpn check -I https://vpn-only.example.com --vpn-timeout 5s`,
+		// The startup checks are skipped, because this command only requires the VPN settings
+		Annotations: map[string]string{skipStartupChecksAnnotation: "true"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			if config.VPNTimeout <= 0 {
+				return common.NewValidationError("--vpn-timeout must be greater than 0 (e.g. 15s)")
+			}
+
+			fmt.Printf("Target: %s\n", config.Properties.DefaultVPNAddressTarget)
+			fmt.Printf("Timeout: %s\n", config.VPNTimeout)
+			fmt.Printf("Check on other commands (--vpn-check-connection): %t\n", config.VPNCheckConnection)
+
+			start := time.Now()
+			errCheck := common.CheckVPNConnection(config.Properties.DefaultVPNAddressTarget)
+			latency := time.Since(start).Round(time.Millisecond)
+			if errCheck != nil {
+				fmt.Printf("Result: FAILED (after %s)\n", latency)
+				// The exit code of error is kept, e.g. validation for an invalid target
+				return errCheck
+			}
+			fmt.Printf("Result: OK (latency %s)\n", latency)
+			return nil
+		},
+	}
+)
+
+func init() {
+	rootCmd.AddCommand(vpnCmd) // Add vpn to parent root command
+
+	// Add subcommands to vpnCmd
+	vpnCmd.AddCommand(vpnCheckCmd)
+
+}
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aeciopires/pires-cli/internal/config"
+	"github.com/aeciopires/pires-cli/pkg/pireslib/common"
+)
+
+// setVPNCheckConfig sets the VPN target and timeout during the test
+func setVPNCheckConfig(t *testing.T, target string, timeout time.Duration) {
+	t.Helper()
+	previousTarget, previousTimeout := config.Properties.DefaultVPNAddressTarget, config.VPNTimeout
+	config.Properties.DefaultVPNAddressTarget, config.VPNTimeout = target, timeout
+	t.Cleanup(func() {
+		config.Properties.DefaultVPNAddressTarget, config.VPNTimeout = previousTarget, previousTimeout
+	})
+}
+
+func TestVpnCheck(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok":
+		case "/slow":
+			// Slower than the timeout of check
+			select {
+			case <-time.After(time.Second):
+			case <-r.Context().Done():
+			}
+		default:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name       string
+		path       string
+		wantResult string
+		wantCode   int
+	}{
+		{"reachable", "/ok", "Result: OK", 0},
+		{"unexpected status", "/unavailable", "Result: FAILED", common.ExitCodeGeneric},
+		{"timeout", "/slow", "Result: FAILED", common.ExitCodeGeneric},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setVPNCheckConfig(t, server.URL+tt.path, 100*time.Millisecond)
+
+			var err error
+			output := captureStdout(t, func() { err = vpnCheckCmd.RunE(vpnCheckCmd, nil) })
+			if code := common.ExitCode(err); code != tt.wantCode {
+				t.Errorf("exit code = %d (error %v), want %d", code, err, tt.wantCode)
+			}
+			for _, want := range []string{"Target: " + server.URL + tt.path, "Timeout: 100ms", tt.wantResult} {
+				if !strings.Contains(output, want) {
+					t.Errorf("output does not contain %q:\n%s", want, output)
+				}
+			}
+		})
+	}
+}
+
+func TestVpnCheckInvalidConfig(t *testing.T) {
+	setVPNCheckConfig(t, "http://vpn.example.com", 0)
+	if err := vpnCheckCmd.RunE(vpnCheckCmd, nil); common.ExitCode(err) != common.ExitCodeValidation {
+		t.Errorf("error = %v, want validation error for --vpn-timeout 0", err)
+	}
+
+	setVPNCheckConfig(t, "vpn.example.com", time.Second)
+	var err error
+	captureStdout(t, func() { err = vpnCheckCmd.RunE(vpnCheckCmd, nil) })
+	if common.ExitCode(err) != common.ExitCodeValidation {
+		t.Errorf("error = %v, want validation error for target without scheme", err)
+	}
+}
@@ -0,0 +1,500 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/aeciopires/pires-cli/internal/config"
+	"github.com/aeciopires/pires-cli/internal/update"
+	"github.com/aeciopires/pires-cli/pkg/pireslib/common"
+	"github.com/aeciopires/pires-cli/pkg/pireslib/fileeditor"
+	"github.com/spf13/cobra"
+)
+
+// Local variables
+var (
+	// yamlCmd represents the base yaml command
+	yamlCmd = &cobra.Command{
+		Use:   "yaml",
+		Short: "Perform operations in YAML files",
+		Long:  `Provides commands to compare, validate and edit YAML files, like Kubernetes manifests.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			fmt.Println("YAML command requires a subcommand (e.g., diff).")
+			cmd.Help()
+		},
+	}
+
+	// --- Diff Subcommand ---
+	yamlDiffCmd = &cobra.Command{
+		Use:   "diff <file1> <file2>",
+		Short: "Show the differences between two YAML files",
+		Long: `Normalizes both YAML files (indentation, key order and comments) and shows the differences.
+	Cosmetic formatting differences are ignored. Exit with non-zero code when differences exist.`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			diff, err := fileeditor.DiffYAMLFiles(args[0], args[1])
+			if err != nil {
+				return common.NewValidationError("%w", err)
+			}
+			if diff == "" {
+				common.Logger("info", "No differences found between '%s' and '%s'.", args[0], args[1])
+				return nil
+			}
+
+			fmt.Print(diff)
+			return common.NewFindingsError("differences found between '%s' and '%s'", args[0], args[1])
+		},
+	}
+
+	// --- Diff Dirs Subcommand ---
+	yamlDiffDirsLeft  string
+	yamlDiffDirsRight string
+
+	yamlDiffDirsCmd = &cobra.Command{
+		Use:   "diff-dirs",
+		Short: "Show the drift between two directories of YAML files",
+		Long: `Walks both directories and compares their YAML files by relative path, like a "desired" templates directory
+	and a "live" exported directory. Shows the added and removed files and the differences of changed files.
+	The files are normalized like the 'yaml diff' command. Exit with non-zero code when any drift exists.`,
+		Example: `  pires-cli yaml diff-dirs --left ./templates --right ./exported`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dirDiff, err := fileeditor.DiffYAMLDirs(yamlDiffDirsLeft, yamlDiffDirsRight)
+			if err != nil {
+				return common.NewValidationError("%w", err)
+			}
+			if !dirDiff.HasDrift() {
+				common.Logger("info", "No drift found between '%s' and '%s'.", yamlDiffDirsLeft, yamlDiffDirsRight)
+				return nil
+			}
+
+			for _, relPath := range dirDiff.Removed {
+				fmt.Printf("Only in %s: %s\n", yamlDiffDirsLeft, relPath)
+			}
+			for _, relPath := range dirDiff.Added {
+				fmt.Printf("Only in %s: %s\n", yamlDiffDirsRight, relPath)
+			}
+			for _, fileDiff := range dirDiff.Changed {
+				fmt.Print(fileDiff.Diff)
+			}
+			return common.NewFindingsError("drift found between '%s' and '%s': %d added, %d removed and %d changed file(s)",
+				yamlDiffDirsLeft, yamlDiffDirsRight, len(dirDiff.Added), len(dirDiff.Removed), len(dirDiff.Changed))
+		},
+	}
+
+	// --- Check Images Subcommand ---
+	yamlCheckImagesRootDir string
+
+	yamlCheckImagesCmd = &cobra.Command{
+		Use:   "check-images",
+		Short: "Check that the images of manifests are pinned",
+		Long: `Walks the YAML files (except *.patch.yaml) of a directory and its subdirectories and checks all 'image' fields.
+	The images using the ':latest' tag or without tag are listed with their files. Use digests (e.g. app@sha256:...) or fixed tags.
+	Exit with non-zero code when any image is not pinned.`,
+		Example: `  pires-cli yaml check-images --root-dir ./manifests`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			checked, violations, err := fileeditor.CheckYAMLImages(yamlCheckImagesRootDir)
+			if err != nil {
+				return err
+			}
+
+			if len(violations) > 0 {
+				var rows [][]string
+				for _, violation := range violations {
+					rows = append(rows, []string{violation.File, violation.Image, violation.Reason})
+				}
+				if err := common.WriteTable(os.Stdout, []string{"FILE", "IMAGE", "REASON"}, rows); err != nil {
+					return err
+				}
+			}
+
+			common.Logger("info", "Summary: %d image(s) checked, %d image(s) not pinned.", checked, len(violations))
+			if len(violations) > 0 {
+				return common.NewFindingsError("%d image(s) not pinned in '%s'", len(violations), yamlCheckImagesRootDir)
+			}
+			return nil
+		},
+	}
+
+	// --- Style Check Subcommand ---
+	yamlStyleCheckRootDir string
+	yamlStyleCheckFix     bool
+
+	yamlStyleCheckCmd = &cobra.Command{
+		Use:   "style-check",
+		Short: "Check the indentation and style of YAML files",
+		Long: `Walks the YAML files (including *.patch.yaml) of a directory and its subdirectories and compares each file
+	with its canonical format: indentation of 2 spaces and no trailing whitespace. The key order and the comments are kept.
+	The files not canonically formatted are listed and the exit code is non-zero. Use --fix to rewrite them.`,
+		Example: `  pires-cli yaml style-check --root-dir ./manifests --fix`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			checked, unformatted, err := fileeditor.CheckYAMLStyle(yamlStyleCheckRootDir, yamlStyleCheckFix)
+			if err != nil {
+				return err
+			}
+
+			for _, file := range unformatted {
+				if yamlStyleCheckFix {
+					fmt.Printf("Formatted: %s\n", file)
+				} else {
+					fmt.Printf("Not formatted: %s\n", file)
+				}
+			}
+
+			if yamlStyleCheckFix {
+				common.Logger("info", "Summary: %d file(s) checked, %d file(s) formatted.", checked, len(unformatted))
+				return nil
+			}
+			common.Logger("info", "Summary: %d file(s) checked, %d file(s) not formatted.", checked, len(unformatted))
+			if len(unformatted) > 0 {
+				return common.NewFindingsError("%d file(s) not formatted in '%s'. Use --fix to format them", len(unformatted), yamlStyleCheckRootDir)
+			}
+			return nil
+		},
+	}
+
+	// --- Check Newline Subcommand ---
+	yamlCheckNewlineRootDir string
+	yamlCheckNewlineFix     bool
+
+	yamlCheckNewlineCmd = &cobra.Command{
+		Use:   "check-newline",
+		Short: "Check that YAML files end with a newline",
+		Long: `Walks the YAML files (including *.patch.yaml) of a directory and its subdirectories and lists the files
+	without a trailing newline (required by POSIX and by pre-commit hooks), like the files edited or merged by other tools.
+	The empty files are ignored. The exit code is non-zero when any file is listed. Use --fix to append the newline.`,
+		Example: `  pires-cli yaml check-newline --root-dir ./manifests --fix`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			checked, missing, err := fileeditor.CheckYAMLTrailingNewline(yamlCheckNewlineRootDir, yamlCheckNewlineFix)
+			if err != nil {
+				return err
+			}
+
+			for _, file := range missing {
+				if yamlCheckNewlineFix {
+					fmt.Printf("Fixed: %s\n", file)
+				} else {
+					fmt.Printf("Missing trailing newline: %s\n", file)
+				}
+			}
+
+			if yamlCheckNewlineFix {
+				common.Logger("info", "Summary: %d file(s) checked, %d file(s) fixed.", checked, len(missing))
+				return nil
+			}
+			common.Logger("info", "Summary: %d file(s) checked, %d file(s) without trailing newline.", checked, len(missing))
+			if len(missing) > 0 {
+				return common.NewFindingsError("%d file(s) without trailing newline in '%s'. Use --fix to append it", len(missing), yamlCheckNewlineRootDir)
+			}
+			return nil
+		},
+	}
+
+	// --- Canonicalize Subcommand ---
+	yamlCanonicalizeCmd = &cobra.Command{
+		Use:   "canonicalize <file>...",
+		Short: "Rewrite YAML files in canonical form",
+		Long: `Rewrites the YAML files in-place in canonical form, producing deterministic files for diffs and reviews:
+	indentation of 2 spaces, no trailing whitespace and the top-level keys sorted by the preferred order
+	(apiVersion, kind, metadata, namespace, spec, resources, images, patches), followed by the other keys in their original order.
+	The comments are kept. The files already canonical are not touched.`,
+		Example: `  pires-cli yaml canonicalize ./manifests/deployment.yaml ./manifests/service.yaml`,
+		Args:    cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			for _, file := range args {
+				if err := fileeditor.CanonicalizeYAML(file); err != nil {
+					return common.NewValidationError("%w", err)
+				}
+				common.Logger("info", "YAML file canonicalized: %s", file)
+			}
+			return nil
+		},
+	}
+
+	// --- Combine Subcommand ---
+	yamlCombineRootDir string
+	yamlCombineOutput  string
+
+	yamlCombineCmd = &cobra.Command{
+		Use:   "combine",
+		Short: "Combine the YAML files of a directory into a single multi-document file",
+		Long: `Walks the YAML files (except *.patch.yaml) of a directory and its subdirectories, in sorted order of their paths,
+	and writes all their documents to a single file separated by '---', like the input of 'kubectl apply -f'.
+	The documents are re-encoded with indentation of 2 spaces and the empty documents are skipped.`,
+		Example: `  pires-cli yaml combine --root-dir ./manifests --output ./all.yaml`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return fileeditor.CombineYAMLFiles(yamlCombineRootDir, yamlCombineOutput)
+		},
+	}
+
+	// --- Split Subcommand ---
+	yamlSplitOutputDir string
+
+	yamlSplitCmd = &cobra.Command{
+		Use:   "split <file>",
+		Short: "Split a multi-document YAML file into one file per document",
+		Long: `Writes each document of a multi-document YAML file to its own file, named by the kind and metadata.name of
+	document in lowercase (e.g. deployment-app.yaml). The documents without name, or with a name already used, are named
+	by the kind (or 'document') and their position in the file (e.g. document-3.yaml). The inverse of 'yaml combine'.`,
+		Example: `  pires-cli yaml split ./all.yaml --output-dir ./manifests`,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return fileeditor.SplitYAMLFile(args[0], yamlSplitOutputDir)
+		},
+	}
+
+	// --- Checksum Subcommand ---
+	yamlChecksumRootDir string
+	yamlChecksumOutput  string
+
+	yamlChecksumCmd = &cobra.Command{
+		Use:   "checksum",
+		Short: "Compute the checksum of the YAML files of a directory",
+		Long: `Computes a single SHA-256 digest of the YAML files (including *.patch.yaml) of a directory and its subdirectories.
+	The files are sorted by path and normalized before hashing, so cosmetic formatting changes (indentation, key order, comments)
+	don't change the digest. Use --output to write the digest to a file too, e.g. to compare it in the next CI runs.`,
+		Example: `  pires-cli yaml checksum --root-dir ./manifests --output manifests.sha256`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			digest, files, err := fileeditor.ComputeYAMLChecksum(yamlChecksumRootDir)
+			if err != nil {
+				return err
+			}
+			common.Logger("debug", "Checksum computed from %d YAML file(s) of '%s'.", files, yamlChecksumRootDir)
+			fmt.Println(digest)
+
+			if yamlChecksumOutput != "" {
+				if err := common.WriteFileAtomic(yamlChecksumOutput, []byte(digest+"\n"), config.PermissionFile); err != nil {
+					return err
+				}
+				common.Logger("info", "Checksum written to: %s", yamlChecksumOutput)
+			}
+			return nil
+		},
+	}
+
+	// --- Apply Expression Subcommand ---
+	yamlApplyExpressionRootDir           string
+	yamlApplyExpression                  string
+	yamlApplyExpressionKind              string
+	yamlApplyExpressionIncludePatchFiles bool
+	yamlApplyExpressionContinueOnError   bool
+
+	yamlApplyExpressionCmd = &cobra.Command{
+		Use:   "apply-expression",
+		Short: "Apply a yq expression in-place to the YAML files of a directory",
+		Long: `Applies a yq expression in-place to the YAML files of a directory and its subdirectories.
+	Use --kind to edit only the resources of a kind (e.g. Deployment): the files without documents of the kind are skipped
+	and, in multi-document files, only the documents of the kind are edited.
+	By default, it stops at the first file error. Use --continue-on-error to edit the other files and list the failing ones at the end.`,
+		Example: `  pires-cli yaml apply-expression --root-dir ./manifests --kind Deployment --expression '.spec.replicas = 2'`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := fileeditor.ValidateYqExpression(yamlApplyExpression); err != nil {
+				return common.NewValidationError("%w", err)
+			}
+			if err := fileeditor.ApplyYqExpressionRecursively(yamlApplyExpressionRootDir, yamlApplyExpression, fileeditor.ApplyYqOptions{
+				Kind:              yamlApplyExpressionKind,
+				IncludePatchFiles: yamlApplyExpressionIncludePatchFiles,
+				ContinueOnError:   yamlApplyExpressionContinueOnError,
+			}); err != nil {
+				return err
+			}
+			common.Logger("info", "The yq expression was applied to the YAML files of '%s'.", yamlApplyExpressionRootDir)
+			return nil
+		},
+	}
+
+	// --- Validate Expression Subcommand ---
+	yamlValidateExpressionCmd = &cobra.Command{
+		Use:   "validate-expression <expression>",
+		Short: "Validate the syntax of a yq expression",
+		Long: `Evaluates the yq expression against a null document to report syntax errors
+	without touching any file. Useful to check expressions before bulk edits.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := fileeditor.ValidateYqExpression(args[0]); err != nil {
+				return common.NewValidationError("%w", err)
+			}
+			common.Logger("info", "The yq expression '%s' is valid.", args[0])
+			return nil
+		},
+	}
+
+	// --- Envsubst Subcommand ---
+	yamlEnvsubstStrict bool
+
+	yamlEnvsubstCmd = &cobra.Command{
+		Use:   "envsubst <file>...",
+		Short: "Substitute environment variables in YAML files",
+		Long: `Replaces the ${VAR} and $VAR placeholders in-place with the values of environment variables, like envsubst command.
+	The '$$' escapes are not touched. Unset variables are replaced with empty string, unless --strict is used.
+	In strict mode, the command fails when a referenced variable is unset and the file is not changed.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			for _, file := range args {
+				if err := fileeditor.SubstituteEnvVars(file, yamlEnvsubstStrict); err != nil {
+					return common.NewValidationError("%w", err)
+				}
+				common.Logger("info", "Environment variables substituted in: %s", file)
+			}
+			return nil
+		},
+	}
+
+	// --- Apply Patches Subcommand ---
+	yamlApplyPatchesCmd = &cobra.Command{
+		Use:   "apply-patches <dir>",
+		Short: "Apply the *.patch.yaml files to their base manifests",
+		Long: `Merges each *.patch.yaml and *.patch.yml file under the directory on top of its base manifest and removes the patch file.
+	The base manifest is defined by the root 'target' key of patch (relative to the patch file) or by the naming convention:
+	app.patch.yaml patches app.yaml or app.yml. No file is changed when a base manifest is missing.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := fileeditor.ApplyPatchFiles(args[0]); err != nil {
+				return common.NewValidationError("%w", err)
+			}
+			return nil
+		},
+	}
+
+	// --- Yq Info Subcommand ---
+	yamlYqInfoOutputFormat string
+
+	yamlYqInfoCmd = &cobra.Command{
+		Use:   "yq-info",
+		Short: "Show the yq executable used by the CLI",
+		Long: `Shows the path, version and source of the yq executable used by the CLI. The source is 'installed'
+	for the yq installed by 'yaml update-yq' command (preferred) or 'embedded' for the yq embedded in the CLI.`,
+		Annotations: map[string]string{skipStartupChecksAnnotation: "true"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			if yamlYqInfoOutputFormat != "text" && yamlYqInfoOutputFormat != "json" {
+				return common.NewValidationError("Unsupported output format '%s'. Supported values: text or json", yamlYqInfoOutputFormat)
+			}
+
+			info, err := fileeditor.GetYqInfo()
+			if err != nil {
+				return err
+			}
+
+			if yamlYqInfoOutputFormat == "json" {
+				infoJSON, err := json.MarshalIndent(info, "", "  ")
+				if err != nil {
+					return fmt.Errorf("failed to encode yq info: %w", err)
+				}
+				fmt.Println(string(infoJSON))
+				return nil
+			}
+
+			fmt.Printf("Path:    %s\n", info.Path)
+			fmt.Printf("Version: %s\n", info.Version)
+			fmt.Printf("Source:  %s\n", info.Source)
+			return nil
+		},
+	}
+
+	// --- Update Yq Subcommand ---
+	yamlUpdateYqVersion string
+
+	yamlUpdateYqCmd = &cobra.Command{
+		Use:   "update-yq",
+		Short: "Install another version of yq to use instead of the embedded one",
+		Long: `Downloads the official yq release of the version for the current platform, verifies its SHA-256 checksum
+	against the published checksums and installs it to the user cache directory.
+	The installed yq is preferred over the embedded yq in the next runs.`,
+		Example:     `  pires-cli yaml update-yq --version v4.45.1`,
+		Annotations: map[string]string{skipStartupChecksAnnotation: "true"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			installedPath, err := update.InstallYq(yamlUpdateYqVersion)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("yq %s installed to: %s\n", yamlUpdateYqVersion, installedPath)
+			return nil
+		},
+	}
+)
+
+func init() {
+	rootCmd.AddCommand(yamlCmd) // Add yamlCmd to the root command
+
+	// Add subcommands to yamlCmd
+	yamlCmd.AddCommand(yamlDiffCmd)
+	yamlCmd.AddCommand(yamlDiffDirsCmd)
+	yamlCmd.AddCommand(yamlCheckImagesCmd)
+	yamlCmd.AddCommand(yamlStyleCheckCmd)
+	yamlCmd.AddCommand(yamlCheckNewlineCmd)
+	yamlCmd.AddCommand(yamlApplyExpressionCmd)
+	yamlCmd.AddCommand(yamlCanonicalizeCmd)
+	yamlCmd.AddCommand(yamlCombineCmd)
+	yamlCmd.AddCommand(yamlSplitCmd)
+	yamlCmd.AddCommand(yamlChecksumCmd)
+	yamlCmd.AddCommand(yamlValidateExpressionCmd)
+	yamlCmd.AddCommand(yamlEnvsubstCmd)
+	yamlCmd.AddCommand(yamlUpdateYqCmd)
+	yamlCmd.AddCommand(yamlApplyPatchesCmd)
+	yamlCmd.AddCommand(yamlYqInfoCmd)
+
+	// Flags for 'yaml diff-dirs'
+	yamlDiffDirsCmd.Flags().StringVarP(&yamlDiffDirsLeft, "left", "l", "", "Left directory, like the desired templates (required)")
+	yamlDiffDirsCmd.Flags().StringVarP(&yamlDiffDirsRight, "right", "r", "", "Right directory, like the live exported manifests (required)")
+	// Flags are required
+	_ = yamlDiffDirsCmd.MarkFlagRequired("left")
+	_ = yamlDiffDirsCmd.MarkFlagRequired("right")
+
+	// Flags for 'yaml check-images'
+	yamlCheckImagesCmd.Flags().StringVarP(&yamlCheckImagesRootDir, "root-dir", "d", "", "Directory of manifests (required)")
+	// Flags are required
+	_ = yamlCheckImagesCmd.MarkFlagRequired("root-dir")
+
+	// Flags for 'yaml style-check'
+	yamlStyleCheckCmd.Flags().StringVarP(&yamlStyleCheckRootDir, "root-dir", "d", "", "Directory of YAML files (required)")
+	yamlStyleCheckCmd.Flags().BoolVarP(&yamlStyleCheckFix, "fix", "f", false, "Rewrite the files not canonically formatted (optional)")
+	// Flags are required
+	_ = yamlStyleCheckCmd.MarkFlagRequired("root-dir")
+
+	// Flags for 'yaml check-newline'
+	yamlCheckNewlineCmd.Flags().StringVarP(&yamlCheckNewlineRootDir, "root-dir", "d", "", "Directory of YAML files (required)")
+	yamlCheckNewlineCmd.Flags().BoolVarP(&yamlCheckNewlineFix, "fix", "f", false, "Append the trailing newline to the files without it (optional)")
+	// Flags are required
+	_ = yamlCheckNewlineCmd.MarkFlagRequired("root-dir")
+
+	// Flags for 'yaml apply-expression'
+	yamlApplyExpressionCmd.Flags().StringVarP(&yamlApplyExpressionRootDir, "root-dir", "d", "", "Directory of YAML files (required)")
+	yamlApplyExpressionCmd.Flags().StringVarP(&yamlApplyExpression, "expression", "e", "", "yq expression applied to the files (e.g. '.spec.replicas = 2') (required)")
+	yamlApplyExpressionCmd.Flags().StringVarP(&yamlApplyExpressionKind, "kind", "k", "", "Edit only the documents of this kind (e.g. Deployment) (optional)")
+	yamlApplyExpressionCmd.Flags().BoolVarP(&yamlApplyExpressionIncludePatchFiles, "include-patch-files", "p", false, "Edit the *.patch.yaml and *.patch.yml files too (optional)")
+	yamlApplyExpressionCmd.Flags().BoolVarP(&yamlApplyExpressionContinueOnError, "continue-on-error", "c", false, "Edit the other files when a file fails and list the failing files at the end (optional)")
+	// Flags are required
+	_ = yamlApplyExpressionCmd.MarkFlagRequired("root-dir")
+	_ = yamlApplyExpressionCmd.MarkFlagRequired("expression")
+
+	// Flags for 'yaml combine'
+	yamlCombineCmd.Flags().StringVarP(&yamlCombineRootDir, "root-dir", "d", "", "Directory of YAML files (required)")
+	yamlCombineCmd.Flags().StringVarP(&yamlCombineOutput, "output", "o", "", "Combined file to write (required)")
+	// Flags are required
+	_ = yamlCombineCmd.MarkFlagRequired("root-dir")
+	_ = yamlCombineCmd.MarkFlagRequired("output")
+
+	// Flags for 'yaml split'
+	yamlSplitCmd.Flags().StringVarP(&yamlSplitOutputDir, "output-dir", "o", "", "Directory of the split files (required)")
+	// Flags are required
+	_ = yamlSplitCmd.MarkFlagRequired("output-dir")
+
+	// Flags for 'yaml checksum'
+	yamlChecksumCmd.Flags().StringVarP(&yamlChecksumRootDir, "root-dir", "d", "", "Directory of YAML files (required)")
+	yamlChecksumCmd.Flags().StringVarP(&yamlChecksumOutput, "output", "o", "", "File to write the digest too (optional)")
+	// Flags are required
+	_ = yamlChecksumCmd.MarkFlagRequired("root-dir")
+
+	// Flags for 'yaml envsubst'
+	yamlEnvsubstCmd.Flags().BoolVarP(&yamlEnvsubstStrict, "strict", "s", false, "Fail when a referenced environment variable is unset (optional)")
+
+	// Flags for 'yaml yq-info'
+	yamlYqInfoCmd.Flags().StringVarP(&yamlYqInfoOutputFormat, "output-format", "o", "text", "Output format. Supported values: text or json")
+
+	// Flags for 'yaml update-yq'
+	yamlUpdateYqCmd.Flags().StringVarP(&yamlUpdateYqVersion, "version", "v", "", "Version of yq to install, like: v4.45.1 (required)")
+	// Flags are required
+	_ = yamlUpdateYqCmd.MarkFlagRequired("version")
+
+}
@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aeciopires/pires-cli/pkg/pireslib/common"
+)
+
+func TestYamlDiff(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"a.yaml": "image:\n    tag: '1.0.0'\nreplicas: 1\n",
+		"b.yaml": "image: {tag: \"1.0.0\"}\nreplicas: 1\n",
+		"c.yaml": "image:\n  tag: '2.0.0'\nreplicas: 1\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	path := func(name string) string { return filepath.Join(dir, name) }
+
+	// The formatting differences are not reported
+	var err error
+	output := captureStdout(t, func() { err = yamlDiffCmd.RunE(yamlDiffCmd, []string{path("a.yaml"), path("b.yaml")}) })
+	if err != nil || output != "" {
+		t.Errorf("diff of equivalent files = %q, %v, want no differences", output, err)
+	}
+
+	// The differences exit with non-zero code, for CI gating
+	output = captureStdout(t, func() { err = yamlDiffCmd.RunE(yamlDiffCmd, []string{path("a.yaml"), path("c.yaml")}) })
+	if common.ExitCode(err) != common.ExitCodeFindings || !strings.Contains(output, "+  tag: 2.0.0") {
+		t.Errorf("diff = %q, exit code %d, want the differences with exit code %d", output, common.ExitCode(err), common.ExitCodeFindings)
+	}
+
+	if err := yamlDiffCmd.RunE(yamlDiffCmd, []string{path("a.yaml"), path("missing.yaml")}); common.ExitCode(err) != common.ExitCodeValidation {
+		t.Errorf("error = %v, want validation error for missing file", err)
+	}
+}
@@ -2,8 +2,14 @@
 package config
 
 import (
+	"errors"
+	"fmt"
 	"os"
+	"path/filepath"
 	"regexp"
+	"runtime"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/go-playground/validator/v10"
@@ -17,7 +23,9 @@ type PropertiesStruct struct {
 	// File/Directory Paths (Basic check: required)
 	// Attention!!! The validator do not support ˜, $HOME or file globbing in values.
 	DefaultConfigFile  string `mapstructure:"cli_config_file" validate:"omitempty"`
-	DefaultEnvironment string `mapstructure:"cli_environment" validate:"required,lowercase,oneof=dev staging production"`
+	DefaultEnvironment string `mapstructure:"cli_environment" validate:"required,lowercase,allowedEnvironment"`
+	// Comma-separated list of environments accepted by DefaultEnvironment. See AllowedEnvironmentsList function
+	AllowedEnvironments string `mapstructure:"cli_allowed_environments" validate:"omitempty,lowercase"`
 	// GCP Settings (Basic checks)
 	// 'alphanum' allows only letters and numbers. Might need a custom validator
 	// for hyphens if project IDs can contain them (e.g., register a custom 'alphanumhyphen').
@@ -41,6 +49,21 @@ var (
 	CLIName    = "pires-cli"
 
 	CommandsToCheck = []string{"git", "kubectl", "gcloud"}
+	// OptionalCommandsToCheck are only required by some commands (e.g. conftest by 'k8s policy-check'),
+	// so they are reported by the doctor command, but not checked at startup
+	OptionalCommandsToCheck = []string{"conftest"}
+
+	// DefaultAllowedEnvironments are the environments accepted when CLI_ALLOWED_ENVIRONMENTS is not set
+	DefaultAllowedEnvironments = []string{"dev", "staging", "production"}
+
+	// GcloudPath and PsqlPath are the binaries executed by CLI (--gcloud-path and --psql-path flags,
+	// or CLI_GCLOUD_PATH and CLI_PSQL_PATH environment variables). By default, they are searched in PATH.
+	GcloudPath = "gcloud"
+	PsqlPath   = "psql"
+
+	// TempDir is the directory of temporary files, like the embedded yq binary and the YAML files merged
+	// (--temp-dir flag or CLI_TEMP_DIR environment variable). By default (empty), the OS temporary directory is used.
+	TempDir string
 
 	// Properties is a global variable of PropertiesStruct type
 	Properties PropertiesStruct
@@ -48,6 +71,25 @@ var (
 	// Log configurations
 	Debug *bool
 
+	// AssumeYes automatically confirms all prompts (--yes/--assume-yes flag)
+	AssumeYes bool
+
+	// ShowExternalStderr logs the stderr of external commands that succeeded in info level (--show-external-stderr flag).
+	// By default, it is logged in debug level. See common.LogExternalStderr function
+	ShowExternalStderr bool
+
+	// ConfigProfile is the name of config profile (--profile flag). The config file of profile is
+	// <user config dir>/pires-cli/<profile>.env (e.g. $HOME/.config/pires-cli/client-a.env). See ProfileConfigFile function
+	ConfigProfile string
+
+	// NoConfigFile disables the read of any config file (--no-config-file flag or CLI_NO_CONFIG_FILE=true).
+	// Only environment variables and default values are used.
+	NoConfigFile bool
+
+	// MaxConcurrency is the maximum number of operations performed in parallel (--max-concurrency flag).
+	// All parallel operations must use it (see common.ForEachConcurrently). The value 1 forces serial execution.
+	MaxConcurrency = runtime.NumCPU()
+
 	//----------------------------
 	// Kubernetes configurations
 	//----------------------------
@@ -83,28 +125,245 @@ var (
 	//----------------------------
 	// Role required by perform the actions on GCP
 	GCPRequiredRole string = "roles/owner"
+	// Service account impersonated by all gcloud commands (--impersonate-service-account flag).
+	// If empty, the credentials of the active gcloud account are used.
+	GCPImpersonateServiceAccount string
+	// Extra arguments appended verbatim to the gcloud commands, after the arguments built by CLI
+	// (the arguments after '--' in gcp commands, e.g. 'pires-cli gcp cloudsql create-instance ... -- --labels=team=ops').
+	GcloudExtraArgs []string
 	// Default output type for firewall rules export
 	GCPFirewallRulesOutputType string = "csv"
 	GCPFirewallRulesPrefix     string = "gcp-firewall-rules"
+	// Default columns of firewall rules CSV (--columns flag). See gcp.SupportedGCPFirewallRulesColumns function
+	GCPFirewallRulesColumns = []string{
+		"name", "network", "direction", "priority", "sourceRanges", "destinationRanges",
+		"allowed", "denied", "sourceTags", "targetTags", "disabled",
+	}
+	// Default templates of report filenames. See common.BuildReportFilename function
+	GCPFirewallRulesFilenameTemplate        string = GCPFirewallRulesPrefix + "-{project}-{timestamp}.csv"
+	PostgresPermissionsFilenameTemplate     string = "{project}_{instance}_database_permissions_{timestamp}.txt"
+	PostgresAuditLogsFilenameTemplate       string = "{project}_{instance}_audit_logs_{timestamp}.txt"
+	PostgresDatabaseSizesFilenameTemplate   string = "{project}_{instance}_database_sizes_{timestamp}.txt"
+	IAMServiceAccountsRolesFilenameTemplate string = "{project}_service_accounts_roles_{timestamp}.csv"
+	IAMPolicyFilenameTemplate               string = "{project}_iam_policy_{timestamp}.json"
+	// State file of the PostgreSQL permissions export, used by --resume. It isn't a report, so it has no timestamp.
+	PostgresPermissionsStateFilenameTemplate string = ".{project}_{instance}_database_permissions.state.json"
+	// Interval between checks of status of Cloud SQL operations
+	GCPCloudSQLOperationPollInterval time.Duration = 5 * time.Second
+	// Attempts of the psql queries of permissions export on transient connection errors (--query-attempts flag).
+	// The wait between attempts grows linearly: backoff, 2*backoff...
+	PostgresQueryAttempts     int           = 3
+	PostgresQueryRetryBackoff time.Duration = 2 * time.Second
+	// Connect timeout of each psql connection of permissions export (--db-connect-timeout flag)
+	PostgresConnectTimeout time.Duration = 10 * time.Second
+	// Maximum of psql connections opened at the same time by permissions export (--db-max-open-conns flag).
+	// Each database is checked by its own psql, so it limits the databases checked concurrently.
+	PostgresMaxOpenConns int = 2
+
+	//----------------------------
+	// External commands configurations
+	//----------------------------
+	// Timeout of the download of remote config file (see --config-file option). The external commands (gcloud, psql...)
+	// are not limited by it: they run until finished or cancelled by SIGINT/SIGTERM (Ctrl-C)
+	ExternalCommandTimeout = 60 * time.Second
+	// Base URL of yq releases downloaded by 'yaml update-yq' command
+	YqReleasesURL string = "https://github.com/mikefarah/yq/releases/download"
+	// Base URL of GitHub API used by 'update' command to get the latest release of CLI
+	GitHubAPIURL string = "https://api.github.com"
 
 	//----------------------------
 	// VPN configurations
 	//----------------------------
 	VPNCheckConnection bool
-	VPNTimeout         time.Duration = 15
+	VPNTimeout         time.Duration = 15 * time.Second // --vpn-timeout flag
 )
 
 // Config set default values to Properties variable
 func Config() {
-	Properties.DefaultConfigFile = ".env"
+	Properties = DefaultProperties()
+}
+
+// DefaultProperties returns the default values of properties
+func DefaultProperties() PropertiesStruct {
+	var properties PropertiesStruct
+	properties.DefaultConfigFile = ".env"
 	// Attention!!! The validator do not support ˜, $HOME or file globbing in values.
-	Properties.DefaultEnvironment = "dev"
-	Properties.DefaultGCPProject = "change-here"
-	Properties.DefaultGCPRegion = "change-here"
-	Properties.DefaultDatabaseType = "none"
-	Properties.DefaultVPNAddressTarget = "http://change-here.com"
-	Properties.DefaultGSABaseAccountName = "change-here-gsa"
-	Properties.DefaultGSAAccountName = Properties.DefaultGSABaseAccountName + "@" + Properties.DefaultGCPProject + ".iam.gserviceaccount.com"
+	properties.DefaultEnvironment = "dev"
+	properties.DefaultGCPProject = "change-here"
+	properties.DefaultGCPRegion = "change-here"
+	properties.DefaultDatabaseType = "none"
+	properties.DefaultVPNAddressTarget = "http://change-here.com"
+	properties.DefaultGSABaseAccountName = "change-here-gsa"
+	properties.DefaultGSAAccountName = BuildGSAEmail(properties.DefaultGSABaseAccountName, properties.DefaultGCPProject)
+	return properties
+}
+
+// profileNameRegex matches the names of config profiles, like: client-a or client_b
+var profileNameRegex = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_-]*$`)
+
+// profileFileExtension is the extension of config files of profiles
+const profileFileExtension = ".env"
+
+// ProfilesDir returns the directory of config profiles: <user config dir>/pires-cli
+// (e.g. $HOME/.config/pires-cli on Linux).
+func ProfilesDir() (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user config directory: %w", err)
+	}
+	return filepath.Join(configDir, CLIName), nil
+}
+
+// ProfileConfigFile returns the path of config file of the profile, like: $HOME/.config/pires-cli/client-a.env.
+// Neither the file nor the directory of profiles are created, so they may not exist.
+func ProfileConfigFile(profile string) (string, error) {
+	if !profileNameRegex.MatchString(profile) {
+		return "", fmt.Errorf("invalid profile name '%s'. Use only letters, numbers, '-' and '_'", profile)
+	}
+	profilesDir, err := ProfilesDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(profilesDir, profile+profileFileExtension), nil
+}
+
+// ListProfiles returns the sorted names of config profiles found in the directory of profiles (see ProfilesDir).
+// An empty list is returned if the directory doesn't exist.
+func ListProfiles() ([]string, error) {
+	profilesDir, err := ProfilesDir()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(profilesDir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return []string{}, nil
+		}
+		return nil, fmt.Errorf("failed to read directory of profiles '%s': %w", profilesDir, err)
+	}
+
+	profiles := []string{}
+	for _, entry := range entries {
+		name := strings.TrimSuffix(entry.Name(), profileFileExtension)
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), profileFileExtension) || !profileNameRegex.MatchString(name) {
+			continue
+		}
+		profiles = append(profiles, name)
+	}
+	slices.Sort(profiles)
+	return profiles, nil
+}
+
+// BuildEnvConfigTemplate returns the content of a config file (.env format) for the environment,
+// with the default values of DefaultProperties function to be customized. It is used by 'init environment' command.
+func BuildEnvConfigTemplate(environment string) string {
+	defaults := DefaultProperties()
+	var template strings.Builder
+	template.WriteString(fmt.Sprintf("# Config file of %s for the '%s' environment. Replace the 'change-here' values.\n", CLIName, environment))
+	template.WriteString(fmt.Sprintf("CLI_ENVIRONMENT=%q\n", environment))
+	template.WriteString(fmt.Sprintf("CLI_GCP_PROJECT=%q\n", defaults.DefaultGCPProject))
+	template.WriteString(fmt.Sprintf("CLI_GCP_REGION=%q\n", defaults.DefaultGCPRegion))
+	template.WriteString(fmt.Sprintf("CLI_DATABASE_TYPE=%q\n", defaults.DefaultDatabaseType))
+	template.WriteString(fmt.Sprintf("CLI_VPN_HOST_TARGET=%q\n", defaults.DefaultVPNAddressTarget))
+	template.WriteString(fmt.Sprintf("CLI_GSA_BASE_ACCOUNT=%q\n", defaults.DefaultGSABaseAccountName))
+	return template.String()
+}
+
+// DefaultReportFilenameTemplates returns the default templates of report filenames of all exports,
+// used to find the reports generated by the CLI (e.g. by 'reports prune' command).
+func DefaultReportFilenameTemplates() []string {
+	return []string{
+		GCPFirewallRulesFilenameTemplate,
+		PostgresPermissionsFilenameTemplate,
+		PostgresAuditLogsFilenameTemplate,
+		PostgresDatabaseSizesFilenameTemplate,
+		IAMServiceAccountsRolesFilenameTemplate,
+		IAMPolicyFilenameTemplate,
+	}
+}
+
+// BuildGSAEmail returns the email of a Google Service Account (GSA) from the base name and project.
+// Example: BuildGSAEmail("app-name-gsa", "nonprod") returns "app-name-gsa@nonprod.iam.gserviceaccount.com"
+func BuildGSAEmail(base, project string) string {
+	return base + "@" + project + ".iam.gserviceaccount.com"
+}
+
+// ValidateGSABaseAccountName checks the base name of a GSA with the same rules of DefaultGSABaseAccountName field.
+func ValidateGSABaseAccountName(base string) error {
+	validate := validator.New()
+	validate.RegisterValidation("noUnderscore", NoUnderscores)
+	if err := validate.Var(base, "required,lowercase,noUnderscore,max=30"); err != nil {
+		var validationErrors validator.ValidationErrors
+		if errors.As(err, &validationErrors) && len(validationErrors) > 0 {
+			return fmt.Errorf("GSA base name '%s' failed on validation rule '%s'", base, validationErrors[0].Tag())
+		}
+		return err
+	}
+	return nil
+}
+
+// Rules of GCP for service account IDs (the part before '@' of email). See ServiceAccountIDViolations function
+var (
+	serviceAccountIDStartRegex = regexp.MustCompile(`^[a-z]`)
+	serviceAccountIDCharsRegex = regexp.MustCompile(`^[a-z0-9_-]*$`) // The underscores are reported by noUnderscore rule
+)
+
+// ServiceAccountIDViolations checks the ID of a service account with the rules of GCP: between 6 and 30 characters,
+// only lowercase letters, digits and hyphens, starting with a letter and not ending with a hyphen.
+// It returns all violations found, or nil if the ID is valid.
+func ServiceAccountIDViolations(id string) []string {
+	validate := validator.New()
+	validate.RegisterValidation("noUnderscore", NoUnderscores)
+
+	var violations []string
+	if validate.Var(id, "min=6,max=30") != nil {
+		violations = append(violations, fmt.Sprintf("must have between 6 and 30 characters (has %d)", len(id)))
+	}
+	if validate.Var(id, "noUnderscore") != nil {
+		violations = append(violations, "must not contain underscores")
+	}
+	if !serviceAccountIDCharsRegex.MatchString(id) {
+		violations = append(violations, "must contain only lowercase letters, digits and hyphens")
+	}
+	if !serviceAccountIDStartRegex.MatchString(id) {
+		violations = append(violations, "must start with a lowercase letter")
+	}
+	if strings.HasSuffix(id, "-") {
+		violations = append(violations, "must not end with a hyphen")
+	}
+	return violations
+}
+
+// serviceAccountEmailRegex matches emails of service accounts, including the Google-managed ones.
+// Example: app-name-gsa@nonprod.iam.gserviceaccount.com or 123456789-compute@developer.gserviceaccount.com
+var serviceAccountEmailRegex = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*@[a-z0-9][a-z0-9.-]*\.gserviceaccount\.com$`)
+
+// ValidateServiceAccountEmail checks if the email has the format of a service account email.
+func ValidateServiceAccountEmail(email string) error {
+	if !serviceAccountEmailRegex.MatchString(email) {
+		return fmt.Errorf("invalid service account email '%s'. Expected format: {name}@{project}.iam.gserviceaccount.com", email)
+	}
+	return nil
+}
+
+// AllowedEnvironmentsList returns the environments of Properties.AllowedEnvironments
+// (CLI_ALLOWED_ENVIRONMENTS variable, e.g. dev,staging,qa,production), or DefaultAllowedEnvironments if it's empty.
+func AllowedEnvironmentsList() []string {
+	var environments []string
+	for _, environment := range strings.Split(Properties.AllowedEnvironments, ",") {
+		if environment = strings.TrimSpace(environment); environment != "" {
+			environments = append(environments, environment)
+		}
+	}
+	if len(environments) == 0 {
+		return DefaultAllowedEnvironments
+	}
+	return environments
+}
+
+// AllowedEnvironment is a custom validator to accept only the environments of AllowedEnvironmentsList function
+func AllowedEnvironment(fl validator.FieldLevel) bool {
+	return slices.Contains(AllowedEnvironmentsList(), fl.Field().String())
 }
 
 // NoUnderscores is a custom validator to reject string with underscore '_'
@@ -112,3 +371,15 @@ func NoUnderscores(fl validator.FieldLevel) bool {
 	matched, _ := regexp.MatchString(`_`, fl.Field().String())
 	return !matched
 }
+
+// RequiredCommands returns CommandsToCheck, replacing gcloud with its configured path (see GcloudPath).
+func RequiredCommands() []string {
+	commands := make([]string, 0, len(CommandsToCheck))
+	for _, command := range CommandsToCheck {
+		if command == "gcloud" {
+			command = GcloudPath
+		}
+		commands = append(commands, command)
+	}
+	return commands
+}
@@ -0,0 +1,146 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestProfileConfigFile(t *testing.T) {
+	configDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configDir)
+
+	got, err := ProfileConfigFile("client-a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(configDir, CLIName, "client-a.env"); got != want {
+		t.Errorf("ProfileConfigFile = %s, want %s", got, want)
+	}
+	// Resolving the path of profile doesn't create the directory of profiles
+	if _, err := os.Stat(filepath.Dir(got)); !os.IsNotExist(err) {
+		t.Errorf("directory of profiles was created: %v", err)
+	}
+
+	for _, invalid := range []string{"", "../client", "-client", "client a"} {
+		if _, err := ProfileConfigFile(invalid); err == nil {
+			t.Errorf("ProfileConfigFile(%q) returned no error", invalid)
+		}
+	}
+}
+
+func TestListProfiles(t *testing.T) {
+	configDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configDir)
+
+	// No directory of profiles
+	profiles, err := ListProfiles()
+	if err != nil || len(profiles) != 0 {
+		t.Fatalf("ListProfiles = %v, %v, want empty list", profiles, err)
+	}
+
+	profilesDir := filepath.Join(configDir, CLIName)
+	if err := os.MkdirAll(filepath.Join(profilesDir, "dir.env"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"client-b.env", "client-a.env", "notes.txt", ".hidden.env"} {
+		if err := os.WriteFile(filepath.Join(profilesDir, name), nil, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	profiles, err = ListProfiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"client-a", "client-b"}; !slices.Equal(profiles, want) {
+		t.Errorf("ListProfiles = %v, want %v", profiles, want)
+	}
+}
+
+func TestBuildGSAEmail(t *testing.T) {
+	if got, want := BuildGSAEmail("app-name-gsa", "nonprod"), "app-name-gsa@nonprod.iam.gserviceaccount.com"; got != want {
+		t.Errorf("BuildGSAEmail = %s, want %s", got, want)
+	}
+}
+
+func TestValidateGSABaseAccountName(t *testing.T) {
+	tests := []struct {
+		base     string
+		wantRule string
+	}{
+		{"app-name-gsa", ""},
+		{strings.Repeat("a", 30), ""},
+		{strings.Repeat("a", 31), "max"},
+		{"app_name_gsa", "noUnderscore"},
+		{"App-gsa", "lowercase"},
+		{"", "required"},
+	}
+	for _, tt := range tests {
+		err := ValidateGSABaseAccountName(tt.base)
+		if tt.wantRule == "" {
+			if err != nil {
+				t.Errorf("ValidateGSABaseAccountName(%q) = %v, want nil", tt.base, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), "'"+tt.wantRule+"'") {
+			t.Errorf("ValidateGSABaseAccountName(%q) = %v, want rule %s", tt.base, err, tt.wantRule)
+		}
+	}
+}
+
+func TestServiceAccountIDViolations(t *testing.T) {
+	tests := []struct {
+		id   string
+		want []string
+	}{
+		{"app-gsa", nil},
+		{"a12345-gsa-with-thirty-chars1", nil},
+		{"app", []string{"must have between 6 and 30 characters (has 3)"}},
+		{"app-gsa-with-a-very-long-name-too", []string{"must have between 6 and 30 characters (has 33)"}},
+		// The underscores are reported only once
+		{"app_gsa", []string{"must not contain underscores"}},
+		{"app.gsa", []string{"must contain only lowercase letters, digits and hyphens"}},
+		{"App-gsa", []string{"must contain only lowercase letters, digits and hyphens", "must start with a lowercase letter"}},
+		{"1app-gsa", []string{"must start with a lowercase letter"}},
+		{"app-gsa-", []string{"must not end with a hyphen"}},
+	}
+	for _, tt := range tests {
+		if got := ServiceAccountIDViolations(tt.id); !slices.Equal(got, tt.want) {
+			t.Errorf("ServiceAccountIDViolations(%q) = %q, want %q", tt.id, got, tt.want)
+		}
+	}
+}
+
+func TestValidateServiceAccountEmail(t *testing.T) {
+	tests := map[string]bool{
+		"admin@nonprod.iam.gserviceaccount.com":                  true,
+		"123456789-compute@developer.gserviceaccount.com":        true,
+		"admin@nonprod.iam.gserviceaccount.com.attacker.example": false,
+		"user@example.com": false,
+		"":                 false,
+	}
+	for email, valid := range tests {
+		if err := ValidateServiceAccountEmail(email); (err == nil) != valid {
+			t.Errorf("ValidateServiceAccountEmail(%q) = %v, want valid %t", email, err, valid)
+		}
+	}
+}
+
+func TestAllowedEnvironmentsList(t *testing.T) {
+	previous := Properties.AllowedEnvironments
+	t.Cleanup(func() { Properties.AllowedEnvironments = previous })
+
+	Properties.AllowedEnvironments = ""
+	if got := AllowedEnvironmentsList(); !slices.Equal(got, DefaultAllowedEnvironments) {
+		t.Errorf("AllowedEnvironmentsList() = %v, want default %v", got, DefaultAllowedEnvironments)
+	}
+
+	Properties.AllowedEnvironments = "dev, staging,,qa ,production"
+	if got, want := AllowedEnvironmentsList(), []string{"dev", "staging", "qa", "production"}; !slices.Equal(got, want) {
+		t.Errorf("AllowedEnvironmentsList() = %v, want %v", got, want)
+	}
+}
@@ -33,23 +33,23 @@ type GitHubRelease struct {
 
 // CheckForUpdate checks for a new version of the application on GitHub.
 // It returns the release info if an update is available, otherwise nil.
-func CheckForUpdate(repo string) *GitHubRelease {
-	apiURL := fmt.Sprintf("https://api.github.com/repos/%s/releases/latest", repo)
+func CheckForUpdate(repo string) (*GitHubRelease, error) {
+	apiURL := fmt.Sprintf("%s/repos/%s/releases/latest", strings.TrimSuffix(config.GitHubAPIURL, "/"), repo)
 	common.Logger("debug", "Checking for updates at: %s", apiURL)
 
 	resp, err := http.Get(apiURL)
 	if err != nil {
-		common.Logger("fatal", "Failed to fetch latest release from GitHub %s: %w", apiURL, err)
+		return nil, fmt.Errorf("failed to fetch latest release from GitHub %s: %w", apiURL, err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		common.Logger("fatal", "Failed to get latest release from %s: GitHub API returned status %s", apiURL, resp.Status)
+		return nil, fmt.Errorf("failed to get latest release from %s: GitHub API returned status %s", apiURL, resp.Status)
 	}
 
 	var release GitHubRelease
 	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
-		common.Logger("fatal", "Failed to parse GitHub release JSON: %w", err)
+		return nil, fmt.Errorf("failed to parse GitHub release JSON: %w", err)
 	}
 
 	latestVersion := release.TagName
@@ -57,15 +57,25 @@ func CheckForUpdate(repo string) *GitHubRelease {
 
 	common.Logger("info", "Current version: %s, Latest version on GitHub: %s", currentVersion, latestVersion)
 
-	if currentVersion != latestVersion {
-		return &release
+	// Avoid downgrades when the current version is newer than the latest release (e.g. development builds)
+	result, err := common.CompareSemver(currentVersion, latestVersion)
+	if err != nil {
+		common.Logger("warning", "Could not compare the versions: %v", err)
+		if currentVersion != latestVersion {
+			return &release, nil
+		}
+		return nil, nil
+	}
+	if result < 0 {
+		return &release, nil
 	}
 
-	return nil // No update available
+	return nil, nil // No update available
 }
 
 // ApplyUpdate downloads and applies a new binary from a GitHub release.
-func ApplyUpdate(release *GitHubRelease) {
+// The current binary is only replaced after the checksum verification.
+func ApplyUpdate(release *GitHubRelease) error {
 	// Determine the asset name based on OS and architecture
 	assetName := fmt.Sprintf("%s-%s-%s", config.CLIName, runtime.GOOS, runtime.GOARCH)
 	common.Logger("debug", "Looking for asset: %s", assetName)
@@ -83,77 +93,87 @@ func ApplyUpdate(release *GitHubRelease) {
 	}
 
 	if binaryAsset == nil {
-		common.Logger("fatal", "Could not find a release asset for your platform (%s/%s)", runtime.GOOS, runtime.GOARCH)
+		return fmt.Errorf("could not find a release asset for your platform (%s/%s) in release %s", runtime.GOOS, runtime.GOARCH, release.TagName)
 	}
 	if checksumsAsset == nil {
-		common.Logger("fatal", "Could not find checksums.txt in the release assets")
+		return fmt.Errorf("could not find checksums.txt in the assets of release %s", release.TagName)
 	}
 
 	common.Logger("info", "Downloading checksums from %s...", checksumsAsset.DownloadURL)
 	checksums, err := DownloadFile(checksumsAsset.DownloadURL)
 	if err != nil {
-		common.Logger("fatal", "Failed to download checksums: %w", err)
+		return fmt.Errorf("failed to download checksums: %w", err)
 	}
 
 	// Download the new binary to a temporary file
 	common.Logger("info", "Downloading new version from %s...", binaryAsset.DownloadURL)
 	newBinaryBytes, err := DownloadFile(binaryAsset.DownloadURL)
 	if err != nil {
-		common.Logger("fatal", "Failed to download new binary: %w", err)
+		return fmt.Errorf("failed to download new binary: %w", err)
 	}
 
 	// Verify the checksum
 	expectedChecksum, err := ParseChecksum(string(checksums), assetName)
 	if err != nil {
-		common.Logger("fatal", "Failed to find checksum for asset %s: %w", assetName, err)
+		return fmt.Errorf("failed to find checksum for asset %s: %w", assetName, err)
 	}
 
 	actualChecksum := sha256.Sum256(newBinaryBytes)
 	actualChecksumStr := hex.EncodeToString(actualChecksum[:])
 
 	if actualChecksumStr != expectedChecksum {
-		common.Logger("fatal", "Checksum mismatch: expected %s, got %s", expectedChecksum, actualChecksumStr)
+		return fmt.Errorf("checksum mismatch: expected %s, got %s", expectedChecksum, actualChecksumStr)
 	}
 	common.Logger("info", "Checksum verified successfully.")
 
 	// Replace the current executable
 	executablePath, err := os.Executable()
 	if err != nil {
-		common.Logger("fatal", "Could not determine executable path: %w", err)
+		return fmt.Errorf("could not determine executable path: %w", err)
 	}
 
 	// Create a temporary file with the new binary content
 	tmpFile, err := os.CreateTemp(filepath.Dir(executablePath), "update-*.tmp")
 	if err != nil {
-		common.Logger("fatal", "Could not create temporary file for update: %w", err)
+		return updatePathError("could not create temporary file for update", err)
 	}
 	defer tmpFile.Close()
 	defer os.Remove(tmpFile.Name())
 
 	if _, err := tmpFile.Write(newBinaryBytes); err != nil {
-		common.Logger("fatal", "Failed to write new binary to temporary file: %w", err)
+		return fmt.Errorf("failed to write new binary to temporary file: %w", err)
 	}
 	tmpFile.Close() // Close the file so we can rename it
 
 	// Set executable permissions on the new binary
 	if err := os.Chmod(tmpFile.Name(), config.PermissionBinary); err != nil {
-		common.Logger("fatal", "Failed to set executable permission on new binary: %w", err)
+		return fmt.Errorf("failed to set executable permission on new binary: %w", err)
 	}
 
 	// Rename the old binary
 	oldPath := executablePath + ".old"
 	if err := os.Rename(executablePath, oldPath); err != nil {
-		common.Logger("fatal", "Failed to rename old binary: %w", err)
+		return updatePathError("failed to rename old binary", err)
 	}
 
 	// Move the new binary into place
 	if err := os.Rename(tmpFile.Name(), executablePath); err != nil {
 		// Attempt to restore the old binary if the final rename fails
 		os.Rename(oldPath, executablePath)
-		common.Logger("fatal", "Failed to move new binary into place: %w", err)
+		return updatePathError("failed to move new binary into place", err)
 	}
 
 	common.Logger("info", "Update successful! The old binary is at %s. It can be removed manually.", oldPath)
+	return nil
+}
+
+// updatePathError returns a permission denied error if the directory of executable isn't writable by the
+// current user (e.g. /usr/local/bin), so the user can run the update again with the required permissions.
+func updatePathError(message string, err error) error {
+	if os.IsPermission(err) {
+		return common.NewPermissionDeniedError("%s: %w. Run the update with a user allowed to write the CLI binary", message, err)
+	}
+	return fmt.Errorf("%s: %w", message, err)
 }
 
 // DownloadFile is a helper to download a file from a URL.
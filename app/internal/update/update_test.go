@@ -0,0 +1,71 @@
+package update
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/aeciopires/pires-cli/internal/config"
+)
+
+// fakeGitHubAPI serves the latest release of aeciopires/pires-cli with the JSON of release, or the status if the
+// release is empty, and replaces config.GitHubAPIURL during the test.
+func fakeGitHubAPI(t *testing.T, status int, release string) {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/aeciopires/pires-cli/releases/latest" || release == "" {
+			w.WriteHeader(status)
+			return
+		}
+		w.Write([]byte(release))
+	}))
+	t.Cleanup(server.Close)
+
+	previous := config.GitHubAPIURL
+	config.GitHubAPIURL = server.URL
+	t.Cleanup(func() { config.GitHubAPIURL = previous })
+}
+
+func TestCheckForUpdate(t *testing.T) {
+	previousVersion := config.CLIVersion
+	config.CLIVersion = "v1.2.0"
+	t.Cleanup(func() { config.CLIVersion = previousVersion })
+
+	fakeGitHubAPI(t, http.StatusOK, `{"tag_name": "v1.3.0", "assets": [{"name": "checksums.txt"}]}`)
+	release, err := CheckForUpdate("aeciopires/pires-cli")
+	if err != nil || release == nil || release.TagName != "v1.3.0" {
+		t.Errorf("CheckForUpdate() = %+v, %v, want release v1.3.0", release, err)
+	}
+
+	// Downgrades are not offered
+	fakeGitHubAPI(t, http.StatusOK, `{"tag_name": "v1.1.0"}`)
+	if release, err := CheckForUpdate("aeciopires/pires-cli"); err != nil || release != nil {
+		t.Errorf("CheckForUpdate(older release) = %+v, %v, want no update", release, err)
+	}
+}
+
+func TestCheckForUpdateErrors(t *testing.T) {
+	fakeGitHubAPI(t, http.StatusForbidden, "")
+	if _, err := CheckForUpdate("aeciopires/pires-cli"); err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("error = %v, want the status of GitHub API", err)
+	}
+
+	fakeGitHubAPI(t, http.StatusOK, "not json")
+	if _, err := CheckForUpdate("aeciopires/pires-cli"); err == nil {
+		t.Error("expected error for invalid JSON")
+	}
+}
+
+func TestApplyUpdateMissingAssets(t *testing.T) {
+	// The errors are returned before the download, so the binary of test is never replaced
+	if err := ApplyUpdate(&GitHubRelease{TagName: "v1.3.0", Assets: []GitHubReleaseAsset{{Name: "checksums.txt"}}}); err == nil || !strings.Contains(err.Error(), runtime.GOOS) {
+		t.Errorf("error = %v, want missing asset of platform", err)
+	}
+
+	assetName := config.CLIName + "-" + runtime.GOOS + "-" + runtime.GOARCH
+	if err := ApplyUpdate(&GitHubRelease{TagName: "v1.3.0", Assets: []GitHubReleaseAsset{{Name: assetName}}}); err == nil || !strings.Contains(err.Error(), "checksums.txt") {
+		t.Errorf("error = %v, want missing checksums", err)
+	}
+}
@@ -0,0 +1,104 @@
+package update
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
+	"strings"
+
+	"github.com/aeciopires/pires-cli/internal/config"
+	"github.com/aeciopires/pires-cli/pkg/pireslib/common"
+	"github.com/aeciopires/pires-cli/pkg/pireslib/fileeditor"
+)
+
+// yqVersionRegex matches the versions of yq releases, like: v4.45.1
+var yqVersionRegex = regexp.MustCompile(`^v[0-9]+\.[0-9]+\.[0-9]+$`)
+
+// YqAssetName returns the name of yq release asset for the platform, like: yq_linux_amd64
+func YqAssetName(goos, goarch string) string {
+	assetName := fmt.Sprintf("yq_%s_%s", goos, goarch)
+	if goos == "windows" {
+		assetName += ".exe"
+	}
+	return assetName
+}
+
+// ParseYqChecksum finds the SHA-256 checksum of assetName in the 'checksums' file of yq releases.
+// Each line of that file has the asset name followed by many hashes, in the order listed
+// by the 'checksums_hashes_order' file (one hash name per line, e.g. SHA-256).
+func ParseYqChecksum(checksumsContent, hashesOrderContent, assetName string) (string, error) {
+	hashesOrder := strings.Fields(hashesOrderContent)
+	sha256Index := slices.Index(hashesOrder, "SHA-256")
+	if sha256Index < 0 {
+		return "", fmt.Errorf("SHA-256 not found in the hashes order of yq checksums")
+	}
+
+	for _, line := range strings.Split(checksumsContent, "\n") {
+		parts := strings.Fields(line)
+		if len(parts) > 0 && parts[0] == assetName {
+			if len(parts) <= sha256Index+1 {
+				return "", fmt.Errorf("SHA-256 checksum for %s not found", assetName)
+			}
+			return parts[sha256Index+1], nil
+		}
+	}
+	return "", fmt.Errorf("checksum for %s not found", assetName)
+}
+
+// InstallYq downloads the yq release of version for the current platform from config.YqReleasesURL,
+// verifies its SHA-256 checksum and installs it to fileeditor.InstalledYqPath. The binary is never written
+// before the checksum verification. The installed yq is preferred over the embedded yq in the next runs.
+// It returns the path of installed yq.
+func InstallYq(version string) (string, error) {
+	if !yqVersionRegex.MatchString(version) {
+		return "", common.NewValidationError("invalid yq version '%s'. Expected format: vX.Y.Z (e.g. v4.45.1)", version)
+	}
+
+	assetName := YqAssetName(runtime.GOOS, runtime.GOARCH)
+	baseURL := strings.TrimSuffix(config.YqReleasesURL, "/") + "/" + version
+
+	common.Logger("info", "Downloading checksums of yq %s...", version)
+	checksums, err := DownloadFile(baseURL + "/checksums")
+	if err != nil {
+		return "", fmt.Errorf("failed to download checksums of yq %s: %w", version, err)
+	}
+	hashesOrder, err := DownloadFile(baseURL + "/checksums_hashes_order")
+	if err != nil {
+		return "", fmt.Errorf("failed to download hashes order of yq %s: %w", version, err)
+	}
+	expectedChecksum, err := ParseYqChecksum(string(checksums), string(hashesOrder), assetName)
+	if err != nil {
+		return "", err
+	}
+
+	common.Logger("info", "Downloading %s %s...", assetName, version)
+	binary, err := DownloadFile(baseURL + "/" + assetName)
+	if err != nil {
+		return "", fmt.Errorf("failed to download yq %s: %w", version, err)
+	}
+
+	actualChecksum := sha256.Sum256(binary)
+	if actualChecksumStr := hex.EncodeToString(actualChecksum[:]); actualChecksumStr != expectedChecksum {
+		return "", fmt.Errorf("checksum mismatch of yq %s: expected %s, got %s", version, expectedChecksum, actualChecksumStr)
+	}
+	common.Logger("info", "Checksum verified successfully.")
+
+	installedPath, err := fileeditor.InstalledYqPath()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(installedPath), config.PermissionDir); err != nil {
+		return "", fmt.Errorf("failed to create directory '%s': %w", filepath.Dir(installedPath), err)
+	}
+	if err := common.WriteFileAtomic(installedPath, binary, config.PermissionBinary); err != nil {
+		return "", fmt.Errorf("failed to install yq to '%s': %w", installedPath, err)
+	}
+
+	common.Logger("info", "yq %s installed to: %s", version, installedPath)
+	return installedPath, nil
+}
@@ -0,0 +1,130 @@
+package update
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/aeciopires/pires-cli/internal/config"
+	"github.com/aeciopires/pires-cli/pkg/pireslib/common"
+)
+
+// fakeYqBinary is the content of yq binary served by the fake release server
+const fakeYqBinary = "#!/bin/sh\necho 'yq (https://github.com/mikefarah/yq/) version v4.45.1'\n"
+
+// fakeYqReleaseServer serves the yq release v4.45.1 for the current platform, with the checksum of binary
+// (a wrong checksum if the binary must not be accepted), and replaces config.YqReleasesURL during the test.
+// The user cache directory is replaced by a temporary directory.
+func fakeYqReleaseServer(t *testing.T, binary string) {
+	t.Helper()
+	checksum := sha256.Sum256([]byte(binary))
+	assetName := YqAssetName(runtime.GOOS, runtime.GOARCH)
+	files := map[string]string{
+		"/v4.45.1/checksums_hashes_order": "MD5\nSHA-1\nSHA-256\nSHA-512\n",
+		"/v4.45.1/checksums": "yq_other_arch  md5 sha1 0000 sha512\n" +
+			assetName + "  md5 sha1 " + hex.EncodeToString(checksum[:]) + " sha512\n",
+		"/v4.45.1/" + assetName: fakeYqBinary,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content, found := files[r.URL.Path]
+		if !found {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(content))
+	}))
+	t.Cleanup(server.Close)
+
+	previous := config.YqReleasesURL
+	config.YqReleasesURL = server.URL
+	t.Cleanup(func() { config.YqReleasesURL = previous })
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+}
+
+func TestParseYqChecksum(t *testing.T) {
+	checksums := "yq_linux_amd64  md5hash  sha1hash  sha256hash  sha512hash\nyq_linux_arm64  a  b  c  d\n"
+
+	checksum, err := ParseYqChecksum(checksums, "MD5\nSHA-1\nSHA-256\nSHA-512\n", "yq_linux_amd64")
+	if err != nil || checksum != "sha256hash" {
+		t.Errorf("ParseYqChecksum() = %q, %v, want sha256hash", checksum, err)
+	}
+	if _, err := ParseYqChecksum(checksums, "MD5\nSHA-1\n", "yq_linux_amd64"); err == nil {
+		t.Error("expected error without SHA-256 in hashes order")
+	}
+	if _, err := ParseYqChecksum(checksums, "MD5\nSHA-1\nSHA-256\n", "yq_darwin_arm64"); err == nil {
+		t.Error("expected error for missing asset")
+	}
+}
+
+func TestYqAssetName(t *testing.T) {
+	if got := YqAssetName("linux", "amd64"); got != "yq_linux_amd64" {
+		t.Errorf("YqAssetName(linux) = %s", got)
+	}
+	if got := YqAssetName("windows", "amd64"); got != "yq_windows_amd64.exe" {
+		t.Errorf("YqAssetName(windows) = %s", got)
+	}
+}
+
+func TestInstallYq(t *testing.T) {
+	fakeYqReleaseServer(t, fakeYqBinary)
+
+	installedPath, err := InstallYq("v4.45.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := os.ReadFile(installedPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != fakeYqBinary {
+		t.Errorf("installed yq = %q, want downloaded binary", content)
+	}
+	info, err := os.Stat(installedPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm()&0o100 == 0 {
+		t.Errorf("installed yq isn't executable: %s", info.Mode())
+	}
+}
+
+func TestInstallYqChecksumMismatch(t *testing.T) {
+	// The published checksum is of other binary
+	fakeYqReleaseServer(t, "other binary")
+
+	_, err := InstallYq("v4.45.1")
+	if err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Fatalf("error = %v, want checksum mismatch", err)
+	}
+	installedPath, err := os.UserCacheDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entries, _ := os.ReadDir(installedPath); len(entries) != 0 {
+		t.Errorf("files written to cache directory before the checksum verification: %v", entries)
+	}
+}
+
+func TestInstallYqInvalidVersion(t *testing.T) {
+	fakeYqReleaseServer(t, fakeYqBinary)
+
+	for _, version := range []string{"4.45.1", "v4.45", "latest", "v4.45.1/../../x"} {
+		if _, err := InstallYq(version); common.ExitCode(err) != common.ExitCodeValidation {
+			t.Errorf("InstallYq(%q) error = %v, want validation error", version, err)
+		}
+	}
+}
+
+func TestInstallYqMissingRelease(t *testing.T) {
+	fakeYqReleaseServer(t, fakeYqBinary)
+
+	if _, err := InstallYq("v9.9.9"); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("error = %v, want download error", err)
+	}
+}
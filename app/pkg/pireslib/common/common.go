@@ -2,15 +2,22 @@
 package common
 
 import (
+	"bufio"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"reflect"
+	"regexp"
 	"runtime"
+	"strconv"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/aeciopires/pires-cli/internal/config"
@@ -20,6 +27,7 @@ import (
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	zerolog_pkgerrors "github.com/rs/zerolog/pkgerrors"
+	"golang.org/x/term"
 )
 
 // FindExecutable checks if a file exists at the given path and is executable.
@@ -125,38 +133,8 @@ func GetParamName(data interface{}, param string) string {
 func Logger(level string, message string, args ...interface{}) {
 	level = strings.ToLower(level)
 
-	log.Logger = log.Output(zerolog.ConsoleWriter{
-		Out:        os.Stdout,
-		TimeFormat: "2006-01-02 15:04:05",
-		FormatLevel: func(i interface{}) string {
-			return strings.ToUpper(fmt.Sprint(i))
-		},
-		FormatMessage: func(i interface{}) string {
-			return fmt.Sprint(i)
-		},
-		FormatTimestamp: func(i interface{}) string {
-			if ts, ok := i.(string); ok {
-				return ts
-			}
-			if t, ok := i.(time.Time); ok {
-				return t.Format("2006-01-02 15:04:05")
-			}
-			return fmt.Sprint(i)
-		},
-	})
-
-	// Set time some configurations of zerolog
-	zerolog.TimeFieldFormat = time.RFC3339
-	zerolog.ErrorStackMarshaler = zerolog_pkgerrors.MarshalStack
-
-	// Default level is info, unless debug flag is present
-	zerolog.SetGlobalLevel(zerolog.InfoLevel)
-	if config.Debug != nil && *config.Debug {
-		zerolog.SetGlobalLevel(zerolog.DebugLevel)
-	}
-
-	// Get the message and arguments from Sprintf
-	formatted := fmt.Sprintf(message, args...)
+	// Get the message and arguments from Sprintf. The registered secrets are never displayed
+	formatted := RedactSecrets(fmt.Sprintf(message, args...))
 
 	// Get stack trace with line and file where the error occurred
 	if level == "error" || level == "fatal" || level == "panic" {
@@ -189,6 +167,56 @@ func Logger(level string, message string, args ...interface{}) {
 	}
 }
 
+// init configures the output of Logger once. Logger only emits messages, because it's called
+// by concurrent operations (see ForEachConcurrently) and the global logger must not be reassigned while in use.
+func init() {
+	log.Logger = log.Output(zerolog.ConsoleWriter{
+		Out:        os.Stdout,
+		TimeFormat: "2006-01-02 15:04:05",
+		FormatLevel: func(i interface{}) string {
+			return strings.ToUpper(fmt.Sprint(i))
+		},
+		FormatMessage: func(i interface{}) string {
+			return fmt.Sprint(i)
+		},
+		FormatTimestamp: func(i interface{}) string {
+			if ts, ok := i.(string); ok {
+				return ts
+			}
+			if t, ok := i.(time.Time); ok {
+				return t.Format("2006-01-02 15:04:05")
+			}
+			return fmt.Sprint(i)
+		},
+	})
+
+	// Set time some configurations of zerolog
+	zerolog.TimeFieldFormat = time.RFC3339
+	zerolog.ErrorStackMarshaler = zerolog_pkgerrors.MarshalStack
+
+	// Default level is info. See ConfigureLogLevel function
+	zerolog.SetGlobalLevel(zerolog.InfoLevel)
+}
+
+// ConfigureLogLevel sets the level of Logger to debug if the --debug flag is present, otherwise info.
+// It's called once, after the flags are parsed (see cmd/root.go).
+func ConfigureLogLevel() {
+	zerolog.SetGlobalLevel(zerolog.InfoLevel)
+	if config.Debug != nil && *config.Debug {
+		zerolog.SetGlobalLevel(zerolog.DebugLevel)
+	}
+}
+
+// LogExternalStderr logs the stderr of an external command (gcloud, psql, kubectl, yq...) that succeeded (exit code 0).
+// Usually it contains only informational messages, so it is logged in debug level, unless --show-external-stderr flag is set.
+func LogExternalStderr(command, stderr string) {
+	level := "debug"
+	if config.ShowExternalStderr {
+		level = "info"
+	}
+	Logger(level, "%s command stderr (exit code 0):\n%s", command, stderr)
+}
+
 // StringToEnvVar transform strings to uppercase and substitue '-' by '_' if exists
 func StringToEnvVar(s string) string {
 	s = strings.ToUpper(s)
@@ -196,15 +224,14 @@ func StringToEnvVar(s string) string {
 	return s
 }
 
-// CheckVPNConnection attempts a basic check for VPN connectivity.
+// CheckVPNConnection attempts a basic check for VPN connectivity and returns an error if it fails.
 // This is a placeholder and might not be reliable for all VPN setups.
 // It tries to HTTP request GET a host that should only be accessible via VPN.
-func CheckVPNConnection(vpnCheckURL string) {
+func CheckVPNConnection(vpnCheckURL string) error {
 	// Parse the URL to validate the format
 	parsedURL, err := url.Parse(vpnCheckURL)
 	if err != nil || parsedURL.Scheme == "" || parsedURL.Host == "" {
-		Logger("fatal", "Invalid URL format to check VPN connection. Expected format protocol://host:port")
-		return
+		return NewValidationError("Invalid URL format to check VPN connection. Expected format protocol://host:port")
 	}
 
 	// Log the attempt to connect
@@ -212,13 +239,13 @@ func CheckVPNConnection(vpnCheckURL string) {
 
 	// Create an HTTP client with a timeout
 	client := http.Client{
-		Timeout: config.VPNTimeout * time.Second,
+		Timeout: config.VPNTimeout,
 	}
 
 	// Send a GET request to the URL
 	resp, err := client.Get(vpnCheckURL)
 	if err != nil {
-		Logger("fatal", "VPN connection check failed: Could not connect to %s. Ensure VPN is active.", vpnCheckURL)
+		return fmt.Errorf("VPN connection check failed: Could not connect to %s. Ensure VPN is active", vpnCheckURL)
 	}
 	// Ensure the response body is closed
 	// client.Get() can be return resp == nil along with an err != nil:
@@ -231,18 +258,29 @@ func CheckVPNConnection(vpnCheckURL string) {
 
 	// Check if the status code is 200 OK
 	if resp.StatusCode != http.StatusOK {
-		Logger("fatal", "VPN connection check failed: Received HTTP status %d from %s", resp.StatusCode, vpnCheckURL)
-		return
+		return fmt.Errorf("VPN connection check failed: Received HTTP status %d from %s", resp.StatusCode, vpnCheckURL)
 	}
 
 	Logger("debug", "VPN connection check successful to %s.", vpnCheckURL)
+	return nil
 }
 
 // CheckCommandsAvailable verifies if all specified command-line tools are installed
 // and accessible in the system's PATH.
-// It returns a list of missing commands and an error if any are not found.
-// If all commands are found, it returns nil, nil.
-func CheckCommandsAvailable(commands []string) {
+// It returns an external command error listing the missing commands if any are not found.
+func CheckCommandsAvailable(commands []string) error {
+	missingCommands := FindMissingCommands(commands)
+
+	if len(missingCommands) > 0 {
+		return NewExternalCommandError("the following required command(s) were not found in your system PATH: %s. Please install them and ensure they are accessible.", strings.Join(missingCommands, ", "))
+	}
+
+	Logger("debug", "All specified commands (%v) are available in system PATH.", commands)
+	return nil
+}
+
+// FindMissingCommands returns the specified command-line tools that are not found in the system's PATH.
+func FindMissingCommands(commands []string) []string {
 	missingCommands := []string{}
 
 	if len(commands) == 0 {
@@ -271,9 +309,444 @@ func CheckCommandsAvailable(commands []string) {
 		}
 	}
 
-	if len(missingCommands) > 0 {
-		Logger("fatal", "the following required command(s) were not found in your system PATH: %s. Please install them and ensure they are accessible.", strings.Join(missingCommands, ", "))
+	return missingCommands
+}
+
+// PromptInput is the reader used by the prompt functions to read the user answers.
+// It defaults to the standard input.
+var PromptInput io.Reader = os.Stdin
+
+// promptReader buffers PromptInput across the prompts, so the answers piped together
+// (e.g. printf 'y\ny\n' | pires-cli ...) aren't lost in the buffer of a previous prompt.
+// It's recreated only when PromptInput changes.
+var (
+	promptReader       *bufio.Reader
+	promptReaderSource io.Reader
+)
+
+// readPromptLine prints the prompt message and reads a single line from PromptInput.
+func readPromptLine(prompt string) (string, error) {
+	fmt.Print(prompt)
+	if promptReader == nil || promptReaderSource != PromptInput {
+		promptReader = bufio.NewReader(PromptInput)
+		promptReaderSource = PromptInput
+	}
+	line, errRead := promptReader.ReadString('\n')
+	if errRead != nil && !(errors.Is(errRead, io.EOF) && line != "") {
+		return "", fmt.Errorf("[ERROR] Failed to read answer from input: %w", errRead)
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// Confirm asks a yes/no question and returns true if the user answered 'y' or 'yes'.
+// If the --yes/--assume-yes flag was passed, it returns true without prompting.
+func Confirm(prompt string) (bool, error) {
+	if config.AssumeYes {
+		Logger("debug", "Assuming 'yes' for prompt: %s", prompt)
+		return true, nil
 	}
 
-	Logger("debug", "All specified commands (%v) are available in system PATH.", commands)
+	answer, errRead := readPromptLine(prompt + " (y/n): ")
+	if errRead != nil {
+		return false, errRead
+	}
+
+	switch strings.ToLower(answer) {
+	case "y", "yes":
+		return true, nil
+	default:
+		return false, nil
+	}
+}
+
+// PromptMatch asks the user to type the expected value to confirm a destructive operation.
+// It returns an error if the typed value does not match exactly.
+// If the --yes/--assume-yes flag was passed, it returns nil without prompting.
+func PromptMatch(prompt, expected string) error {
+	if config.AssumeYes {
+		Logger("debug", "Assuming confirmation of '%s' for prompt: %s", expected, prompt)
+		return nil
+	}
+
+	answer, errRead := readPromptLine(fmt.Sprintf("%s Type '%s' to confirm: ", prompt, expected))
+	if errRead != nil {
+		return errRead
+	}
+
+	if answer != expected {
+		return fmt.Errorf("[ERROR] Confirmation failed: typed value '%s' does not match '%s'", answer, expected)
+	}
+	return nil
+}
+
+// IsInteractive returns true if the standard input is a terminal, so the user can answer the prompts.
+// It is a variable, like PromptInput, so the tests can simulate a terminal.
+var IsInteractive = func() bool {
+	return term.IsTerminal(int(os.Stdin.Fd()))
+}
+
+// PromptSelect prints the numbered options and asks the user to select one by its number.
+// It returns the index of the selected option or an error if the answer is not a valid number.
+func PromptSelect(prompt string, options []string) (int, error) {
+	if len(options) == 0 {
+		return -1, fmt.Errorf("[ERROR] No options to select")
+	}
+
+	for i, option := range options {
+		fmt.Printf("  %d) %s\n", i+1, option)
+	}
+	answer, errRead := readPromptLine(fmt.Sprintf("%s [1-%d]: ", prompt, len(options)))
+	if errRead != nil {
+		return -1, errRead
+	}
+
+	selected, errAtoi := strconv.Atoi(answer)
+	if errAtoi != nil || selected < 1 || selected > len(options) {
+		return -1, NewValidationError("invalid selection '%s'. Expected a number between 1 and %d", answer, len(options))
+	}
+	return selected - 1, nil
+}
+
+// PromptPassword asks for a password without echoing the typed characters in the terminal.
+func PromptPassword(prompt string) (string, error) {
+	Logger("info", "%s", prompt)
+
+	// ReadPassword takes a file descriptor (int) as input.
+	// syscall.Stdin represents the standard input file descriptor.
+	bytePassword, errRead := term.ReadPassword(int(syscall.Stdin))
+	fmt.Println()
+	if errRead != nil {
+		return "", fmt.Errorf("[ERROR] Error reading password: %w", errRead)
+	}
+
+	// Convert the byte slice to a string for use.
+	return string(bytePassword), nil
+}
+
+// BuildReportFilename replaces the placeholders of the template, in the format {name},
+// by the values of vars. Supported placeholders by export functions: {project}, {instance},
+// {timestamp} and {date}. Unknown placeholders are kept as is.
+// The template can contain directories, e.g. "{date}/{project}-{timestamp}.csv".
+//
+// Example:
+//
+// BuildReportFilename("{project}_{instance}_{timestamp}.txt", map[string]string{"project": "nonprod", "instance": "psql", "timestamp": "20250101-120000"})
+//
+// Output:
+//
+// nonprod_psql_20250101-120000.txt
+func BuildReportFilename(template string, vars map[string]string) string {
+	replacements := make([]string, 0, len(vars)*2)
+	for key, value := range vars {
+		replacements = append(replacements, "{"+key+"}", value)
+	}
+	return strings.NewReplacer(replacements...).Replace(template)
+}
+
+// ReportFilenameVars returns the default placeholders used by BuildReportFilename,
+// with {timestamp} and {date} based on the current time.
+func ReportFilenameVars(projectID, instanceID string) map[string]string {
+	now := time.Now()
+	return map[string]string{
+		"project":   projectID,
+		"instance":  instanceID,
+		"timestamp": now.Format("20060102-150405"),
+		"date":      now.Format("20060102"),
+	}
+}
+
+// ForEachConcurrently calls fn for each index from 0 to count-1, running at most
+// config.MaxConcurrency calls in parallel. It returns after all calls finish.
+// If config.MaxConcurrency is 1 (or less), the calls are performed serially in order.
+// fn must be safe for concurrent use, e.g. each call writing only to its own index of a slice.
+func ForEachConcurrently(count int, fn func(i int)) {
+	ForEachConcurrentlyWithLimit(count, config.MaxConcurrency, fn)
+}
+
+// ForEachConcurrentlyWithLimit is like ForEachConcurrently, but running at most limit calls in parallel,
+// for operations with their own limit (e.g. database connections). The limit is never greater than config.MaxConcurrency.
+func ForEachConcurrentlyWithLimit(count, limit int, fn func(i int)) {
+	limit = min(limit, config.MaxConcurrency)
+	if limit <= 1 {
+		for i := 0; i < count; i++ {
+			fn(i)
+		}
+		return
+	}
+
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, limit)
+	for i := 0; i < count; i++ {
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+			fn(i)
+		}(i)
+	}
+	wg.Wait()
+}
+
+// WriteFileAtomic writes data to a temporary file in the same directory of filePath and renames it over filePath.
+// Readers never see a half-written file: if the write fails, the temporary file is removed and
+// filePath is not created (or keeps its previous content).
+func WriteFileAtomic(filePath string, data []byte, perm os.FileMode) error {
+	dir, fileName := filepath.Split(filePath)
+	if dir == "" {
+		dir = "."
+	}
+
+	tmpFile, err := os.CreateTemp(dir, "."+fileName+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file for '%s': %w", filePath, err)
+	}
+	tmpFilePath := tmpFile.Name()
+	// Remove the temporary file if any step fails. After the rename, it doesn't exist anymore.
+	defer os.Remove(tmpFilePath)
+
+	if _, err := tmpFile.Write(data); err != nil {
+		tmpFile.Close()
+		return fmt.Errorf("failed to write temporary file '%s': %w", tmpFilePath, err)
+	}
+	// Flush the data to disk before rename, so a crash doesn't leave an empty file
+	if err := tmpFile.Sync(); err != nil {
+		tmpFile.Close()
+		return fmt.Errorf("failed to sync temporary file '%s': %w", tmpFilePath, err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		return fmt.Errorf("failed to close temporary file '%s': %w", tmpFilePath, err)
+	}
+	if err := os.Chmod(tmpFilePath, perm); err != nil {
+		return fmt.Errorf("failed to set permissions of temporary file '%s': %w", tmpFilePath, err)
+	}
+	if err := os.Rename(tmpFilePath, filePath); err != nil {
+		return fmt.Errorf("failed to rename temporary file '%s' to '%s': %w", tmpFilePath, filePath, err)
+	}
+
+	return nil
+}
+
+// ValidateTempDir checks that dir is a writable directory where the files can be executed, creating and
+// running a small script. Directories of filesystems mounted with noexec are rejected, because the
+// embedded yq binary is extracted to the temporary directory. The execution is not checked on Windows.
+func ValidateTempDir(dir string) error {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return fmt.Errorf("failed to access temporary directory: %w", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("'%s' is not a directory", dir)
+	}
+
+	tmpFile, err := os.CreateTemp(dir, "pires-cli-check-*")
+	if err != nil {
+		return fmt.Errorf("temporary directory is not writable: %w", err)
+	}
+	tmpFilePath := tmpFile.Name()
+	defer os.Remove(tmpFilePath)
+
+	if _, err := tmpFile.WriteString("#!/bin/sh\nexit 0\n"); err != nil {
+		tmpFile.Close()
+		return fmt.Errorf("temporary directory is not writable: %w", err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		return fmt.Errorf("temporary directory is not writable: %w", err)
+	}
+
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+	if err := os.Chmod(tmpFilePath, config.PermissionBinary); err != nil {
+		return fmt.Errorf("failed to set permissions of file in temporary directory: %w", err)
+	}
+	if err := exec.Command(tmpFilePath).Run(); err != nil {
+		return fmt.Errorf("files can't be executed in temporary directory (is it mounted with noexec?): %w", err)
+	}
+	return nil
+}
+
+// AppendToFile appends data to the end of filePath, creating it if it doesn't exist.
+// A line break is added to data if missing. The data is written by a single call in
+// O_APPEND mode, so concurrent appends of different executions don't mix their lines.
+func AppendToFile(filePath string, data []byte, perm os.FileMode) error {
+	if len(data) > 0 && data[len(data)-1] != '\n' {
+		data = append(data, '\n')
+	}
+
+	file, err := os.OpenFile(filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, perm)
+	if err != nil {
+		return fmt.Errorf("failed to open file '%s' to append: %w", filePath, err)
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to append to file '%s': %w", filePath, err)
+	}
+	return file.Close()
+}
+
+// ResolveAppendPath returns the path of file used by the --append-to option of export commands.
+// Relative paths are relative to outputDir.
+func ResolveAppendPath(outputDir, appendTo string) string {
+	if filepath.IsAbs(appendTo) {
+		return appendTo
+	}
+	return filepath.Join(outputDir, appendTo)
+}
+
+// ReportMetadata is the custom title and note of generated reports (--report-title and --report-note flags).
+type ReportMetadata struct {
+	Title string
+	Note  string
+}
+
+// BuildReportHeader returns the header of a report: the title (defaultTitle if metadata.Title is empty),
+// the generation timestamp, the operator account and the CLI version.
+func BuildReportHeader(metadata ReportMetadata, defaultTitle, timestamp, operator string) string {
+	title := metadata.Title
+	if title == "" {
+		title = defaultTitle
+	}
+	if operator == "" {
+		operator = "unknown"
+	}
+
+	var header strings.Builder
+	header.WriteString(title + "\n")
+	header.WriteString(fmt.Sprintf("Generated at: %s\n", timestamp))
+	header.WriteString(fmt.Sprintf("Operator: %s\n", operator))
+	header.WriteString(fmt.Sprintf("CLI version: %s %s\n\n", config.CLIName, config.CLIVersion))
+	return header.String()
+}
+
+// BuildReportFooter returns the note block appended to the end of a report, or empty string if there is no note.
+func BuildReportFooter(metadata ReportMetadata) string {
+	if metadata.Note == "" {
+		return ""
+	}
+	return fmt.Sprintf("========================================\n NOTE\n========================================\n%s\n", metadata.Note)
+}
+
+// reportPlaceholderPatterns are the regular expressions of placeholders of report filenames, used by BuildReportFilenameRegex
+var reportPlaceholderPatterns = map[string]string{
+	"project":   `[a-z][a-z0-9-]*`,
+	"instance":  `[a-z][a-z0-9-]*`,
+	"timestamp": `[0-9]{8}-[0-9]{6}`,
+	"date":      `[0-9]{8}`,
+}
+
+// reportExtensions are the extensions of reports written by the CLI, depending on the format of each export
+var reportExtensions = []string{".txt", ".csv", ".json", ".yaml"}
+
+// BuildReportFilenameRegex returns the regular expression matching the names of files generated with
+// the template (see BuildReportFilename). Only the name of file (last element of template) is matched
+// and the extension can be any of the reports extensions (e.g. .txt or .json, depending on the format).
+func BuildReportFilenameRegex(template string) *regexp.Regexp {
+	name := filepath.Base(template)
+	name = strings.TrimSuffix(name, filepath.Ext(name))
+
+	var pattern strings.Builder
+	pattern.WriteString("^")
+	for name != "" {
+		start := strings.Index(name, "{")
+		end := strings.Index(name, "}")
+		if start < 0 || end < start {
+			pattern.WriteString(regexp.QuoteMeta(name))
+			break
+		}
+		pattern.WriteString(regexp.QuoteMeta(name[:start]))
+		if placeholderPattern, known := reportPlaceholderPatterns[name[start+1:end]]; known {
+			pattern.WriteString(placeholderPattern)
+		} else {
+			pattern.WriteString(regexp.QuoteMeta(name[start : end+1]))
+		}
+		name = name[end+1:]
+	}
+
+	var extensions []string
+	for _, extension := range reportExtensions {
+		extensions = append(extensions, regexp.QuoteMeta(extension))
+	}
+	pattern.WriteString("(" + strings.Join(extensions, "|") + ")$")
+	return regexp.MustCompile(pattern.String())
+}
+
+// ReportFile is a report file found by FindReportFiles function.
+type ReportFile struct {
+	Path    string
+	Size    int64
+	ModTime time.Time
+}
+
+// FindReportFiles returns the regular files in dir (not recursive) whose names match any of the templates
+// (see BuildReportFilenameRegex) and were modified before now minus olderThan.
+// Only the CLI filename conventions are matched, to avoid removing unrelated files.
+func FindReportFiles(dir string, templates []string, olderThan time.Duration, now time.Time) ([]ReportFile, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read reports directory '%s': %w", dir, err)
+	}
+
+	var patterns []*regexp.Regexp
+	for _, template := range templates {
+		patterns = append(patterns, BuildReportFilenameRegex(template))
+	}
+
+	limit := now.Add(-olderThan)
+	var files []ReportFile
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		matched := false
+		for _, pattern := range patterns {
+			if pattern.MatchString(entry.Name()) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			continue
+		}
+		info, errInfo := entry.Info()
+		if errInfo != nil {
+			// The file was removed in the meantime
+			continue
+		}
+		if info.ModTime().Before(limit) {
+			files = append(files, ReportFile{Path: filepath.Join(dir, entry.Name()), Size: info.Size(), ModTime: info.ModTime()})
+		}
+	}
+	return files, nil
+}
+
+// RemoveReportFiles removes the report files found by FindReportFiles function.
+// Returns the number and the total size of removed files.
+func RemoveReportFiles(files []ReportFile) (int, int64, error) {
+	var errs []error
+	removed, size := 0, int64(0)
+	for _, file := range files {
+		if err := os.Remove(file.Path); err != nil {
+			errs = append(errs, fmt.Errorf("failed to remove '%s': %w", file.Path, err))
+			continue
+		}
+		Logger("debug", "Removed report file: %s", file.Path)
+		removed++
+		size += file.Size
+	}
+	return removed, size, errors.Join(errs...)
+}
+
+// FormatBytes returns the size in human readable format, like: 1.5 MiB
+func FormatBytes(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
 }
@@ -0,0 +1,437 @@
+package common
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aeciopires/pires-cli/internal/config"
+	"github.com/rs/zerolog"
+)
+
+// setMaxConcurrency replaces config.MaxConcurrency during the test
+func setMaxConcurrency(t *testing.T, maxConcurrency int) {
+	t.Helper()
+	previous := config.MaxConcurrency
+	config.MaxConcurrency = maxConcurrency
+	t.Cleanup(func() { config.MaxConcurrency = previous })
+}
+
+// runConcurrently calls ForEachConcurrentlyWithLimit with count calls and returns
+// the order of calls and the maximum number of calls running at the same time
+func runConcurrently(count, limit int) (order []int, maxRunning int32) {
+	var running atomic.Int32
+	var mutex sync.Mutex
+	ForEachConcurrentlyWithLimit(count, limit, func(i int) {
+		current := running.Add(1)
+		defer running.Add(-1)
+
+		mutex.Lock()
+		order = append(order, i)
+		maxRunning = max(maxRunning, current)
+		mutex.Unlock()
+
+		time.Sleep(5 * time.Millisecond)
+	})
+	return order, maxRunning
+}
+
+func TestForEachConcurrentlySerial(t *testing.T) {
+	setMaxConcurrency(t, 1)
+
+	// The limit of operation is never greater than config.MaxConcurrency
+	order, maxRunning := runConcurrently(5, 4)
+	if maxRunning != 1 {
+		t.Errorf("max running calls = %d, want 1", maxRunning)
+	}
+	if want := []int{0, 1, 2, 3, 4}; !slices.Equal(order, want) {
+		t.Errorf("order of calls = %v, want %v", order, want)
+	}
+}
+
+func TestForEachConcurrentlyWithLimit(t *testing.T) {
+	setMaxConcurrency(t, 8)
+
+	order, maxRunning := runConcurrently(10, 3)
+	if maxRunning > 3 {
+		t.Errorf("max running calls = %d, want at most 3", maxRunning)
+	}
+	slices.Sort(order)
+	if want := []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}; !slices.Equal(order, want) {
+		t.Errorf("calls = %v, want %v", order, want)
+	}
+}
+
+func TestBuildReportFilename(t *testing.T) {
+	vars := map[string]string{
+		"project":   "nonprod",
+		"instance":  "nonprod-psql",
+		"timestamp": "20250102-030405",
+		"date":      "20250102",
+	}
+	tests := []struct {
+		template string
+		want     string
+	}{
+		{"{date}/{project}_{instance}_{timestamp}.txt", "20250102/nonprod_nonprod-psql_20250102-030405.txt"},
+		{"{project}-firewall.csv", "nonprod-firewall.csv"},
+		{"{unknown}_{project}.csv", "{unknown}_nonprod.csv"},
+		{"report.csv", "report.csv"},
+	}
+	for _, tt := range tests {
+		if got := BuildReportFilename(tt.template, vars); got != tt.want {
+			t.Errorf("BuildReportFilename(%q) = %q, want %q", tt.template, got, tt.want)
+		}
+	}
+}
+
+func TestReportFilenameVars(t *testing.T) {
+	vars := ReportFilenameVars("nonprod", "nonprod-psql")
+	if vars["project"] != "nonprod" || vars["instance"] != "nonprod-psql" {
+		t.Errorf("vars = %v, want project and instance", vars)
+	}
+	if !regexp.MustCompile(`^\d{8}-\d{6}$`).MatchString(vars["timestamp"]) || !regexp.MustCompile(`^\d{8}$`).MatchString(vars["date"]) {
+		t.Errorf("timestamp = %q and date = %q, want formats 20060102-150405 and 20060102", vars["timestamp"], vars["date"])
+	}
+}
+
+// dirEntries returns the names of files in dir
+func dirEntries(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	return names
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "report.txt")
+	if err := os.WriteFile(filePath, []byte("old report"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := WriteFileAtomic(filePath, []byte("new report"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "new report" {
+		t.Errorf("content = %q, want %q", content, "new report")
+	}
+	info, err := os.Stat(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("permissions = %o, want 600", info.Mode().Perm())
+	}
+	// The temporary file is renamed, so only the report remains
+	if names := dirEntries(t, dir); !slices.Equal(names, []string{"report.txt"}) {
+		t.Errorf("files = %v, want only report.txt", names)
+	}
+}
+
+func TestWriteFileAtomicFailureBeforeRename(t *testing.T) {
+	dir := t.TempDir()
+	// The rename fails, because the target is a non-empty directory
+	filePath := filepath.Join(dir, "report.txt")
+	if err := os.MkdirAll(filepath.Join(filePath, "keep"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := WriteFileAtomic(filePath, []byte("new report"), 0o600); err == nil {
+		t.Fatal("expected error renaming over a directory")
+	}
+
+	info, err := os.Stat(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.IsDir() {
+		t.Error("target file was written, want no report file")
+	}
+	// The temporary file is removed
+	if names := dirEntries(t, dir); !slices.Equal(names, []string{"report.txt"}) {
+		t.Errorf("files = %v, want no temporary file", names)
+	}
+}
+
+func TestWriteFileAtomicMissingDirectory(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "missing", "report.txt")
+
+	if err := WriteFileAtomic(filePath, []byte("report"), 0o600); err == nil {
+		t.Fatal("expected error for missing directory")
+	}
+	if _, err := os.Stat(filePath); !os.IsNotExist(err) {
+		t.Errorf("report file exists after failure: %v", err)
+	}
+}
+
+func TestAppendToFile(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "daily.log")
+
+	// The line break is added when missing
+	for _, data := range []string{"first run", "second run\n"} {
+		if err := AppendToFile(filePath, []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "first run\nsecond run\n" {
+		t.Errorf("content = %q, want both runs", content)
+	}
+}
+
+func TestAppendToFileConcurrentLines(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "daily.log")
+	line := strings.Repeat("x", 4096)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := AppendToFile(filePath, []byte(line), 0o600); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+	if len(lines) != 20 {
+		t.Fatalf("lines = %d, want 20", len(lines))
+	}
+	for i, got := range lines {
+		if got != line {
+			t.Fatalf("line %d has %d bytes, want %d (mixed appends)", i, len(got), len(line))
+		}
+	}
+}
+
+func TestResolveAppendPath(t *testing.T) {
+	if got, want := ResolveAppendPath("reports", "audit/daily.log"), filepath.Join("reports", "audit", "daily.log"); got != want {
+		t.Errorf("ResolveAppendPath(relative) = %s, want %s", got, want)
+	}
+	if got := ResolveAppendPath("reports", "/var/log/daily.log"); got != "/var/log/daily.log" {
+		t.Errorf("ResolveAppendPath(absolute) = %s, want /var/log/daily.log", got)
+	}
+}
+
+func TestBuildReportHeader(t *testing.T) {
+	header := BuildReportHeader(ReportMetadata{Title: "Audit of ticket OPS-123"}, "Default title", "20250102-030405", "operator@example.com")
+	want := "Audit of ticket OPS-123\n" +
+		"Generated at: 20250102-030405\n" +
+		"Operator: operator@example.com\n" +
+		"CLI version: " + config.CLIName + " " + config.CLIVersion + "\n\n"
+	if header != want {
+		t.Errorf("BuildReportHeader() = %q, want %q", header, want)
+	}
+}
+
+func TestBuildReportHeaderDefaults(t *testing.T) {
+	header := BuildReportHeader(ReportMetadata{}, "Default title", "20250102-030405", "")
+	if !strings.HasPrefix(header, "Default title\n") || !strings.Contains(header, "Operator: unknown\n") {
+		t.Errorf("BuildReportHeader() = %q, want default title and unknown operator", header)
+	}
+}
+
+func TestBuildReportFooter(t *testing.T) {
+	if footer := BuildReportFooter(ReportMetadata{}); footer != "" {
+		t.Errorf("BuildReportFooter() without note = %q, want empty", footer)
+	}
+	if footer := BuildReportFooter(ReportMetadata{Note: "Reviewed by the security team"}); !strings.HasSuffix(footer, " NOTE\n========================================\nReviewed by the security team\n") {
+		t.Errorf("BuildReportFooter() = %q, want note block", footer)
+	}
+}
+
+func TestLogExternalStderr(t *testing.T) {
+	previousLevel, previousShow := zerolog.GlobalLevel(), config.ShowExternalStderr
+	zerolog.SetGlobalLevel(zerolog.InfoLevel)
+	t.Cleanup(func() {
+		zerolog.SetGlobalLevel(previousLevel)
+		config.ShowExternalStderr = previousShow
+	})
+
+	tests := []struct {
+		show bool
+		want string
+	}{
+		{false, ""},
+		{true, `"level":"info"`},
+	}
+	for _, tt := range tests {
+		logs := captureLogs(t)
+		config.ShowExternalStderr = tt.show
+
+		LogExternalStderr("gcloud", "Updated property [core/project].")
+
+		if tt.want == "" && logs.Len() > 0 {
+			t.Errorf("stderr logged in info level without --show-external-stderr: %s", logs.String())
+		}
+		if tt.want != "" && (!strings.Contains(logs.String(), tt.want) || !strings.Contains(logs.String(), "Updated property")) {
+			t.Errorf("logs = %q, want stderr in info level with --show-external-stderr", logs.String())
+		}
+	}
+}
+
+func TestBuildReportFilenameRegex(t *testing.T) {
+	tests := []struct {
+		template string
+		name     string
+		want     bool
+	}{
+		{"{project}_{instance}_database_permissions_{timestamp}.txt", "nonprod_nonprod-psql_database_permissions_20250102-030405.txt", true},
+		// The extension depends on the format of export
+		{"{project}_{instance}_database_permissions_{timestamp}.txt", "nonprod_nonprod-psql_database_permissions_20250102-030405.json", true},
+		{"reports/{date}/{project}.csv", "nonprod.csv", true},
+		{"{project}_iam_policy_{timestamp}.json", "nonprod_iam_policy_20250102.json", false},
+		{"{project}_iam_policy_{timestamp}.json", "nonprod_iam_policy_20250102-030405.json.bak", false},
+		{"{project}_iam_policy_{timestamp}.json", "notes.txt", false},
+		{"{project}_iam_policy_{timestamp}.json", "Nonprod_iam_policy_20250102-030405.json", false},
+	}
+	for _, tt := range tests {
+		if got := BuildReportFilenameRegex(tt.template).MatchString(tt.name); got != tt.want {
+			t.Errorf("BuildReportFilenameRegex(%q) matches %q = %t, want %t", tt.template, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFindAndRemoveReportFiles(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	old := now.Add(-60 * 24 * time.Hour)
+	files := map[string]time.Time{
+		"nonprod_nonprod-psql_database_permissions_20250101-000000.txt": old,
+		"nonprod_nonprod-psql_audit_logs_20250101-000000.json":          old,
+		"nonprod_iam_policy_20250228-000000.json":                       now.Add(-time.Hour),
+		"notes.txt": old,
+		"nonprod_iam_policy_20250101-000000.json.bak": old,
+	}
+	for name, modTime := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("report"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The subdirectories are not matched, even with a report name
+	if err := os.Mkdir(filepath.Join(dir, "nonprod_iam_policy_20240101-000000.json"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	found, err := FindReportFiles(dir, config.DefaultReportFilenameTemplates(), 30*24*time.Hour, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, file := range found {
+		names = append(names, filepath.Base(file.Path))
+	}
+	want := []string{"nonprod_nonprod-psql_audit_logs_20250101-000000.json", "nonprod_nonprod-psql_database_permissions_20250101-000000.txt"}
+	if !slices.Equal(names, want) {
+		t.Errorf("old reports = %v, want %v", names, want)
+	}
+
+	removed, size, err := RemoveReportFiles(found)
+	if err != nil || removed != 2 || size != int64(2*len("report")) {
+		t.Errorf("RemoveReportFiles() = %d, %d, %v, want 2 files of %d bytes", removed, size, err, 2*len("report"))
+	}
+	if remaining := dirEntries(t, dir); len(remaining) != 4 {
+		t.Errorf("remaining files = %v, want the recent report and the unrelated files", remaining)
+	}
+}
+
+func TestFindReportFilesMissingDirectory(t *testing.T) {
+	if _, err := FindReportFiles(filepath.Join(t.TempDir(), "missing"), config.DefaultReportFilenameTemplates(), time.Hour, time.Now()); err == nil {
+		t.Error("expected error for missing directory")
+	}
+}
+
+func TestValidateTempDir(t *testing.T) {
+	dir := t.TempDir()
+	if err := ValidateTempDir(dir); err != nil {
+		t.Fatalf("ValidateTempDir(%s): %v", dir, err)
+	}
+	// The file of the check is removed
+	if entries := dirEntries(t, dir); len(entries) != 0 {
+		t.Errorf("files left in temporary directory: %v", entries)
+	}
+
+	filePath := filepath.Join(dir, "file.txt")
+	if err := os.WriteFile(filePath, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, invalid := range []string{filepath.Join(dir, "missing"), filePath} {
+		if err := ValidateTempDir(invalid); err == nil {
+			t.Errorf("ValidateTempDir(%s): expected error", invalid)
+		}
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := map[int64]string{
+		0:                          "0 B",
+		1023:                       "1023 B",
+		1024:                       "1.0 KiB",
+		1536:                       "1.5 KiB",
+		5 * 1024 * 1024:            "5.0 MiB",
+		3 * 1024 * 1024 * 1024 / 2: "1.5 GiB",
+	}
+	for size, want := range tests {
+		if got := FormatBytes(size); got != want {
+			t.Errorf("FormatBytes(%d) = %s, want %s", size, got, want)
+		}
+	}
+}
+
+func TestCheckVPNConnection(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/ok" {
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	defer server.Close()
+	previousTimeout := config.VPNTimeout
+	config.VPNTimeout = time.Second
+	t.Cleanup(func() { config.VPNTimeout = previousTimeout })
+
+	if err := CheckVPNConnection(server.URL + "/ok"); err != nil {
+		t.Errorf("CheckVPNConnection() = %v, want success", err)
+	}
+	if err := CheckVPNConnection(server.URL + "/private"); err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("error = %v, want the HTTP status", err)
+	}
+	for _, target := range []string{"", "vpn.example.com"} {
+		if err := CheckVPNConnection(target); ExitCode(err) != ExitCodeValidation {
+			t.Errorf("CheckVPNConnection(%q) = %v, want validation error", target, err)
+		}
+	}
+}
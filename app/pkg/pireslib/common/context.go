@@ -0,0 +1,59 @@
+// Package common has common functions reusable
+package common
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// appContext is cancelled when the CLI receives SIGINT or SIGTERM.
+// It is used by long-running operations (exports, external commands) to stop early.
+var appContext = context.Background()
+
+// Context returns the context of the CLI used by long-running operations.
+func Context() context.Context {
+	return appContext
+}
+
+// SetContext changes the context of the CLI used by long-running operations.
+func SetContext(ctx context.Context) {
+	appContext = ctx
+}
+
+// NotifyInterrupt returns a context cancelled on the first SIGINT/SIGTERM, and sets it as context of the CLI.
+// After the first signal, the default behavior is restored, so a second signal forces the exit.
+func NotifyInterrupt() (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		// Restore the default behavior of signals. A second signal interrupts the program immediately.
+		stop()
+		Logger("warning", "Interrupt received. Stopping the operation... Press Ctrl-C again to force exit.")
+	}()
+	SetContext(ctx)
+	return ctx, stop
+}
+
+// commandWaitDelay is the time to wait for the output of a command killed by the interruption.
+// The children of the command (e.g. python of gcloud) may keep its output open after the kill.
+const commandWaitDelay = 2 * time.Second
+
+// CommandContext returns a command killed when the CLI receives SIGINT/SIGTERM (see Context).
+// After the kill, the command returns in at most commandWaitDelay, even if its children keep the output open.
+func CommandContext(name string, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(appContext, name, args...)
+	cmd.WaitDelay = commandWaitDelay
+	return cmd
+}
+
+// CheckInterrupted returns an error if the context of the CLI was cancelled by SIGINT/SIGTERM.
+func CheckInterrupted() error {
+	if err := appContext.Err(); err != nil {
+		return NewInterruptedError("operation cancelled: %w", err)
+	}
+	return nil
+}
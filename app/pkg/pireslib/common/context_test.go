@@ -0,0 +1,52 @@
+package common
+
+import (
+	"context"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// setTestContext replaces the context of the CLI during the test
+func setTestContext(t *testing.T, ctx context.Context) {
+	t.Helper()
+	previous := Context()
+	SetContext(ctx)
+	t.Cleanup(func() { SetContext(previous) })
+}
+
+func TestCheckInterrupted(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	setTestContext(t, ctx)
+
+	if err := CheckInterrupted(); err != nil {
+		t.Fatalf("CheckInterrupted before cancel = %v, want nil", err)
+	}
+	cancel()
+	if err := CheckInterrupted(); ExitCode(err) != ExitCodeInterrupted {
+		t.Errorf("CheckInterrupted after cancel = %v, want interrupted error", err)
+	}
+}
+
+func TestNotifyInterrupt(t *testing.T) {
+	previous := Context()
+	t.Cleanup(func() { SetContext(previous) })
+
+	ctx, stop := NotifyInterrupt()
+	defer stop()
+	if Context() != ctx {
+		t.Fatal("NotifyInterrupt didn't set the context of the CLI")
+	}
+
+	if err := syscall.Kill(syscall.Getpid(), syscall.SIGINT); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-ctx.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("context not cancelled by SIGINT")
+	}
+	if ExitCode(CheckInterrupted()) != ExitCodeInterrupted {
+		t.Error("CheckInterrupted doesn't report the interruption")
+	}
+}
@@ -0,0 +1,85 @@
+// Package common has common functions reusable
+package common
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// Exit codes returned by the CLI. Scripts can use them to distinguish the kind of failure.
+const (
+	ExitCodeGeneric          = 1   // Generic or unknown failure
+	ExitCodeValidation       = 2   // Invalid configuration, flags or arguments
+	ExitCodeExternalCommand  = 3   // External command (gcloud, psql, yq, kubectl...) failed
+	ExitCodePermissionDenied = 4   // Current user doesn't have the required permissions
+	ExitCodeFindings         = 5   // Check completed and found problems (e.g. differences, violations or drift)
+	ExitCodeInterrupted      = 130 // Operation cancelled by SIGINT/SIGTERM (Ctrl-C)
+)
+
+// CLIError is an error with an associated exit code.
+type CLIError struct {
+	Code int
+	Err  error
+}
+
+// Error returns the message of the wrapped error.
+func (e *CLIError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("exit code %d", e.Code)
+	}
+	return e.Err.Error()
+}
+
+// Unwrap returns the wrapped error, allowing the use of errors.Is and errors.As.
+func (e *CLIError) Unwrap() error {
+	return e.Err
+}
+
+// NewValidationError returns an error for invalid configuration, flags or arguments.
+func NewValidationError(format string, args ...interface{}) error {
+	return &CLIError{Code: ExitCodeValidation, Err: fmt.Errorf(format, args...)}
+}
+
+// NewExternalCommandError returns an error for failures of external commands.
+func NewExternalCommandError(format string, args ...interface{}) error {
+	return &CLIError{Code: ExitCodeExternalCommand, Err: fmt.Errorf(format, args...)}
+}
+
+// NewPermissionDeniedError returns an error for missing permissions.
+func NewPermissionDeniedError(format string, args ...interface{}) error {
+	return &CLIError{Code: ExitCodePermissionDenied, Err: fmt.Errorf(format, args...)}
+}
+
+// NewFindingsError returns an error for checks that completed and found problems,
+// so scripts can distinguish them from failures to run the check.
+func NewFindingsError(format string, args ...interface{}) error {
+	return &CLIError{Code: ExitCodeFindings, Err: fmt.Errorf(format, args...)}
+}
+
+// NewInterruptedError returns an error for operations cancelled by SIGINT/SIGTERM.
+func NewInterruptedError(format string, args ...interface{}) error {
+	return &CLIError{Code: ExitCodeInterrupted, Err: fmt.Errorf(format, args...)}
+}
+
+// ExitCode maps an error to the exit code of the CLI.
+// It returns 0 for nil errors and ExitCodeGeneric for errors without an associated code.
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var cliErr *CLIError
+	if errors.As(err, &cliErr) {
+		return cliErr.Code
+	}
+	return ExitCodeGeneric
+}
+
+// Exit logs the error and interrupts the program with the exit code mapped by ExitCode.
+func Exit(err error) {
+	if err == nil {
+		return
+	}
+	Logger("error", "%v", err)
+	os.Exit(ExitCode(err))
+}
@@ -0,0 +1,77 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"testing"
+)
+
+// exitTestErrors are the errors passed to Exit by the subprocess of TestExitProcessCode
+var exitTestErrors = map[string]error{
+	"generic":    errors.New("generic failure"),
+	"validation": NewValidationError("invalid value '%s'", "x"),
+	"external":   NewExternalCommandError("gcloud failed"),
+	"permission": NewPermissionDeniedError("missing roles/owner"),
+	"findings":   NewFindingsError("2 image(s) not pinned"),
+	"interrupt":  NewInterruptedError("cancelled"),
+}
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"nil", nil, 0},
+		{"generic", errors.New("failure"), ExitCodeGeneric},
+		{"validation", NewValidationError("invalid"), ExitCodeValidation},
+		{"external command", NewExternalCommandError("failed"), ExitCodeExternalCommand},
+		{"permission denied", NewPermissionDeniedError("denied"), ExitCodePermissionDenied},
+		{"interrupted", NewInterruptedError("cancelled"), ExitCodeInterrupted},
+		{"findings", NewFindingsError("differences found"), ExitCodeFindings},
+		{"wrapped validation", fmt.Errorf("context: %w", NewValidationError("invalid")), ExitCodeValidation},
+		{"joined external command", errors.Join(errors.New("other"), NewExternalCommandError("failed")), ExitCodeExternalCommand},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExitCode(tt.err); got != tt.want {
+				t.Errorf("ExitCode(%v) = %d, want %d", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExitProcessCode(t *testing.T) {
+	// The subprocess calls Exit, which interrupts the program with the mapped exit code
+	if name := os.Getenv("CLI_TEST_EXIT_ERROR"); name != "" {
+		Exit(exitTestErrors[name])
+		return
+	}
+
+	tests := map[string]int{
+		"generic":    ExitCodeGeneric,
+		"validation": ExitCodeValidation,
+		"external":   ExitCodeExternalCommand,
+		"permission": ExitCodePermissionDenied,
+		"findings":   ExitCodeFindings,
+		"interrupt":  ExitCodeInterrupted,
+	}
+	for name, want := range tests {
+		t.Run(name, func(t *testing.T) {
+			command := exec.Command(os.Args[0], "-test.run=^TestExitProcessCode$")
+			command.Env = append(os.Environ(), "CLI_TEST_EXIT_ERROR="+name)
+			err := command.Run()
+
+			var exitErr *exec.ExitError
+			if !errors.As(err, &exitErr) {
+				t.Fatalf("expected exit error, got %v", err)
+			}
+			if got := exitErr.ExitCode(); got != want {
+				t.Errorf("exit code = %d, want %d", got, want)
+			}
+		})
+	}
+}
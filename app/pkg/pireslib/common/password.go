@@ -0,0 +1,106 @@
+package common
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+)
+
+// Character sets used by GeneratePassword function
+const (
+	PasswordLowercase = "abcdefghijklmnopqrstuvwxyz"
+	PasswordUppercase = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	PasswordDigits    = "0123456789"
+	// PasswordSymbols are safe in connection strings and URLs
+	PasswordSymbols = "-_.~"
+	// DefaultPasswordCharset is the default character set of generated passwords
+	DefaultPasswordCharset = PasswordLowercase + PasswordUppercase + PasswordDigits + PasswordSymbols
+	// MinPasswordLength is the minimum length of generated passwords
+	MinPasswordLength = 12
+)
+
+var (
+	// secrets are the values never displayed by Logger, like generated passwords. See RegisterSecret function
+	secrets      []string
+	secretsMutex sync.RWMutex
+)
+
+// RegisterSecret registers a value to be redacted from all messages of Logger, e.g. a password passed to external commands.
+func RegisterSecret(secret string) {
+	if secret == "" {
+		return
+	}
+	secretsMutex.Lock()
+	defer secretsMutex.Unlock()
+	secrets = append(secrets, secret)
+}
+
+// RedactSecrets replaces the values registered by RegisterSecret with '********' in the message.
+func RedactSecrets(message string) string {
+	secretsMutex.RLock()
+	defer secretsMutex.RUnlock()
+	for _, secret := range secrets {
+		message = strings.ReplaceAll(message, secret, "********")
+	}
+	return message
+}
+
+// GeneratePassword returns a random password using crypto/rand, with the length and characters of charset
+// (DefaultPasswordCharset if empty). The password contains at least one character of each class
+// (lowercase, uppercase, digit and symbol) present in charset.
+// The password is registered as secret, so it is never displayed by Logger. See RegisterSecret function
+func GeneratePassword(length int, charset string) (string, error) {
+	if charset == "" {
+		charset = DefaultPasswordCharset
+	}
+	if length < MinPasswordLength {
+		return "", NewValidationError("password length must be greater than or equal to %d", MinPasswordLength)
+	}
+
+	// Classes of characters required in the password
+	var requiredClasses []string
+	for _, class := range []string{PasswordLowercase, PasswordUppercase, PasswordDigits} {
+		if strings.ContainsAny(charset, class) {
+			requiredClasses = append(requiredClasses, class)
+		}
+	}
+	if symbols := strings.Map(func(r rune) rune {
+		if strings.ContainsRune(PasswordLowercase+PasswordUppercase+PasswordDigits, r) {
+			return -1
+		}
+		return r
+	}, charset); symbols != "" {
+		requiredClasses = append(requiredClasses, symbols)
+	}
+	if len(requiredClasses) > length {
+		return "", NewValidationError("password length %d is too short for the charset", length)
+	}
+
+	chars := []rune(charset)
+	max := big.NewInt(int64(len(chars)))
+	password := make([]rune, length)
+	for {
+		for i := range password {
+			n, err := rand.Int(rand.Reader, max)
+			if err != nil {
+				return "", fmt.Errorf("failed to generate random password: %w", err)
+			}
+			password[i] = chars[n.Int64()]
+		}
+
+		// Generate again if any class is missing. It's rare with the default length
+		complete := true
+		for _, class := range requiredClasses {
+			if !strings.ContainsAny(string(password), class) {
+				complete = false
+				break
+			}
+		}
+		if complete {
+			RegisterSecret(string(password))
+			return string(password), nil
+		}
+	}
+}
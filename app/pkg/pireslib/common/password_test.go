@@ -0,0 +1,87 @@
+package common
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// captureLogs redirects the messages of Logger to the returned buffer during the test
+func captureLogs(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buffer bytes.Buffer
+	previous := log.Logger
+	log.Logger = zerolog.New(&buffer)
+	t.Cleanup(func() { log.Logger = previous })
+	return &buffer
+}
+
+func TestGeneratePassword(t *testing.T) {
+	password, err := GeneratePassword(24, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(password) != 24 {
+		t.Errorf("length = %d, want 24", len(password))
+	}
+	for _, r := range password {
+		if !strings.ContainsRune(DefaultPasswordCharset, r) {
+			t.Errorf("character %q not in default charset", r)
+		}
+	}
+	for _, class := range []string{PasswordLowercase, PasswordUppercase, PasswordDigits, PasswordSymbols} {
+		if !strings.ContainsAny(password, class) {
+			t.Errorf("password has no character of %q", class)
+		}
+	}
+}
+
+func TestGeneratePasswordCustomCharset(t *testing.T) {
+	charset := "abc123"
+	for i := 0; i < 20; i++ {
+		password, err := GeneratePassword(MinPasswordLength, charset)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Trim(password, charset) != "" {
+			t.Fatalf("password %q has characters out of charset %q", password, charset)
+		}
+		if !strings.ContainsAny(password, "abc") || !strings.ContainsAny(password, "123") {
+			t.Fatalf("password %q doesn't have letters and digits", password)
+		}
+	}
+}
+
+func TestGeneratePasswordTooShort(t *testing.T) {
+	if _, err := GeneratePassword(MinPasswordLength-1, ""); ExitCode(err) != ExitCodeValidation {
+		t.Errorf("error = %v, want validation error", err)
+	}
+}
+
+func TestGeneratedPasswordRedactedFromLogs(t *testing.T) {
+	logs := captureLogs(t)
+
+	password, err := GeneratePassword(20, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	Logger("info", "Generated password: %s", password)
+	Logger("warning", "Retrying with password=%s", password)
+
+	if strings.Contains(logs.String(), password) {
+		t.Errorf("password displayed in logs:\n%s", logs.String())
+	}
+	if strings.Count(logs.String(), "********") != 2 {
+		t.Errorf("logs = %s, want the password redacted twice", logs.String())
+	}
+}
+
+func TestRegisterSecretIgnoresEmptyValue(t *testing.T) {
+	RegisterSecret("")
+	if got := RedactSecrets("message without secrets"); got != "message without secrets" {
+		t.Errorf("RedactSecrets() = %q, want message unchanged", got)
+	}
+}
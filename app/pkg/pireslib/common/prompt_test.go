@@ -0,0 +1,88 @@
+package common
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aeciopires/pires-cli/internal/config"
+)
+
+// setPromptInput replaces PromptInput by the answers during the test
+func setPromptInput(t *testing.T, answers string) {
+	t.Helper()
+	previous := PromptInput
+	PromptInput = strings.NewReader(answers)
+	t.Cleanup(func() { PromptInput = previous })
+}
+
+func TestPromptsReadPipedAnswers(t *testing.T) {
+	// All answers are piped at once, like: printf 'y\nn\n2\nprod\n' | pires-cli ...
+	setPromptInput(t, "y\nn\n2\nprod\n")
+
+	first, err := Confirm("First?")
+	if err != nil || !first {
+		t.Fatalf("first Confirm = %v, %v, want true, nil", first, err)
+	}
+	second, err := Confirm("Second?")
+	if err != nil || second {
+		t.Fatalf("second Confirm = %v, %v, want false, nil", second, err)
+	}
+	selected, err := PromptSelect("Select", []string{"a", "b", "c"})
+	if err != nil || selected != 1 {
+		t.Fatalf("PromptSelect = %d, %v, want 1, nil", selected, err)
+	}
+	if err := PromptMatch("Delete?", "prod"); err != nil {
+		t.Fatalf("PromptMatch: %v", err)
+	}
+}
+
+func TestPromptReaderResetWhenInputChanges(t *testing.T) {
+	setPromptInput(t, "y\ny\n")
+	if ok, err := Confirm("First?"); err != nil || !ok {
+		t.Fatalf("Confirm = %v, %v, want true, nil", ok, err)
+	}
+
+	// The remaining buffered answer of previous input must not be used
+	PromptInput = strings.NewReader("n\n")
+	if ok, err := Confirm("Second?"); err != nil || ok {
+		t.Fatalf("Confirm = %v, %v, want false, nil", ok, err)
+	}
+}
+
+func TestPromptWithoutTrailingNewline(t *testing.T) {
+	setPromptInput(t, "yes")
+	if ok, err := Confirm("Continue?"); err != nil || !ok {
+		t.Fatalf("Confirm = %v, %v, want true, nil", ok, err)
+	}
+
+	// No more answers
+	if _, err := Confirm("Again?"); err == nil {
+		t.Fatal("expected error reading from empty input")
+	}
+}
+
+func TestPromptMatchMismatch(t *testing.T) {
+	setPromptInput(t, "staging\n")
+	if err := PromptMatch("Delete?", "prod"); err == nil {
+		t.Fatal("expected error for mismatched confirmation")
+	}
+}
+
+func TestPromptSelectInvalid(t *testing.T) {
+	setPromptInput(t, "4\n")
+	_, err := PromptSelect("Select", []string{"a", "b"})
+	if ExitCode(err) != ExitCodeValidation {
+		t.Fatalf("PromptSelect error = %v, want validation error", err)
+	}
+}
+
+func TestConfirmAssumeYes(t *testing.T) {
+	config.AssumeYes = true
+	t.Cleanup(func() { config.AssumeYes = false })
+	// The empty input would return an error if read
+	setPromptInput(t, "")
+
+	if ok, err := Confirm("Continue?"); err != nil || !ok {
+		t.Fatalf("Confirm = %v, %v, want true, nil", ok, err)
+	}
+}
@@ -0,0 +1,96 @@
+package common
+
+import (
+	"regexp"
+	"strings"
+)
+
+// semverRegex matches versions like: 1.2.3, v1.2.3, v1.2.3-rc.1 and v1.2.3-rc.1+build.5
+var semverRegex = regexp.MustCompile(`^v?(0|[1-9][0-9]*)\.(0|[1-9][0-9]*)\.(0|[1-9][0-9]*)(?:-([0-9A-Za-z-]+(?:\.[0-9A-Za-z-]+)*))?(?:\+[0-9A-Za-z-]+(?:\.[0-9A-Za-z-]+)*)?$`)
+
+// CompareSemver compares two semantic versions (the 'v' prefix is optional) and returns
+// -1 if a is older than b, 0 if they are equal and 1 if a is newer than b.
+// The pre-release versions are older than the release (e.g. 1.2.3-rc.1 < 1.2.3) and are
+// compared by their identifiers, as defined in https://semver.org/#spec-item-11.
+// The build metadata (e.g. +build.5) is ignored. An error is returned for invalid versions.
+func CompareSemver(a, b string) (int, error) {
+	partsA := semverRegex.FindStringSubmatch(strings.TrimSpace(a))
+	if partsA == nil {
+		return 0, NewValidationError("invalid semantic version '%s'. Expected format: [v]MAJOR.MINOR.PATCH[-PRERELEASE] (e.g. v1.2.3)", a)
+	}
+	partsB := semverRegex.FindStringSubmatch(strings.TrimSpace(b))
+	if partsB == nil {
+		return 0, NewValidationError("invalid semantic version '%s'. Expected format: [v]MAJOR.MINOR.PATCH[-PRERELEASE] (e.g. v1.2.3)", b)
+	}
+
+	// Major, minor and patch
+	for i := 1; i <= 3; i++ {
+		if result := compareNumericIdentifiers(partsA[i], partsB[i]); result != 0 {
+			return result, nil
+		}
+	}
+
+	// A version without pre-release is newer than the same version with pre-release
+	preReleaseA, preReleaseB := partsA[4], partsB[4]
+	switch {
+	case preReleaseA == preReleaseB:
+		return 0, nil
+	case preReleaseA == "":
+		return 1, nil
+	case preReleaseB == "":
+		return -1, nil
+	}
+
+	identifiersA := strings.Split(preReleaseA, ".")
+	identifiersB := strings.Split(preReleaseB, ".")
+	for i := 0; i < len(identifiersA) && i < len(identifiersB); i++ {
+		if result := comparePreReleaseIdentifiers(identifiersA[i], identifiersB[i]); result != 0 {
+			return result, nil
+		}
+	}
+	// A larger set of pre-release identifiers is newer, if all the preceding identifiers are equal
+	switch {
+	case len(identifiersA) < len(identifiersB):
+		return -1, nil
+	case len(identifiersA) > len(identifiersB):
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// compareNumericIdentifiers compares two numeric identifiers without leading zeros.
+// They are compared by length first, so big numbers don't overflow.
+func compareNumericIdentifiers(a, b string) int {
+	switch {
+	case len(a) != len(b):
+		if len(a) < len(b) {
+			return -1
+		}
+		return 1
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// comparePreReleaseIdentifiers compares two pre-release identifiers. The numeric identifiers are compared
+// numerically and have lower precedence than alphanumeric identifiers, which are compared in ASCII order.
+func comparePreReleaseIdentifiers(a, b string) int {
+	numericA, numericB := isNumericIdentifier(a), isNumericIdentifier(b)
+	switch {
+	case numericA && numericB:
+		return compareNumericIdentifiers(strings.TrimLeft(a, "0"), strings.TrimLeft(b, "0"))
+	case numericA:
+		return -1
+	case numericB:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+// isNumericIdentifier returns true if the identifier has only digits.
+func isNumericIdentifier(identifier string) bool {
+	return strings.Trim(identifier, "0123456789") == ""
+}
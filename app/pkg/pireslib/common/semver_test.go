@@ -0,0 +1,48 @@
+package common
+
+import "testing"
+
+func TestCompareSemver(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.2.3", "1.2.3", 0},
+		{"v1.2.3", "1.2.3", 0},
+		{"v1.2.3+build.5", "v1.2.3", 0},
+		{"v1.2.3", "v1.2.4", -1},
+		{"v1.10.0", "v1.9.9", 1},
+		{"v2.0.0", "v1.99.99", 1},
+		{"v0.9.0", "v0.10.0", -1},
+		{"v12345678901234567890.0.0", "v2.0.0", 1},
+		// https://semver.org/#spec-item-11: 1.0.0-alpha < 1.0.0-alpha.1 < 1.0.0-alpha.beta < 1.0.0-beta <
+		// 1.0.0-beta.2 < 1.0.0-beta.11 < 1.0.0-rc.1 < 1.0.0
+		{"1.0.0-alpha", "1.0.0-alpha.1", -1},
+		{"1.0.0-alpha.1", "1.0.0-alpha.beta", -1},
+		{"1.0.0-alpha.beta", "1.0.0-beta", -1},
+		{"1.0.0-beta", "1.0.0-beta.2", -1},
+		{"1.0.0-beta.2", "1.0.0-beta.11", -1},
+		{"1.0.0-beta.11", "1.0.0-rc.1", -1},
+		{"1.0.0-rc.1", "1.0.0", -1},
+		{"1.0.0", "1.0.0-rc.1", 1},
+		{"v1.0.0-rc.1", "1.0.0-rc.1", 0},
+	}
+
+	for _, tt := range tests {
+		got, err := CompareSemver(tt.a, tt.b)
+		if err != nil || got != tt.want {
+			t.Errorf("CompareSemver(%q, %q) = %d, %v, want %d", tt.a, tt.b, got, err, tt.want)
+		}
+	}
+}
+
+func TestCompareSemverInvalid(t *testing.T) {
+	for _, version := range []string{"", "1.2", "v1.2.3.4", "latest", "01.2.3", "1.2.3-", "1.2.3-rc..1", "V1.2.3"} {
+		if _, err := CompareSemver(version, "1.2.3"); ExitCode(err) != ExitCodeValidation {
+			t.Errorf("CompareSemver(%q, 1.2.3) error = %v, want validation error", version, err)
+		}
+		if _, err := CompareSemver("1.2.3", version); ExitCode(err) != ExitCodeValidation {
+			t.Errorf("CompareSemver(1.2.3, %q) error = %v, want validation error", version, err)
+		}
+	}
+}
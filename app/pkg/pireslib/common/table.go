@@ -0,0 +1,51 @@
+package common
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// WriteCSV writes the headers and rows to w in CSV format.
+// Fields containing commas, quotes or line breaks are quoted as defined in RFC 4180.
+func WriteCSV(w io.Writer, headers []string, rows [][]string) error {
+	csvWriter := csv.NewWriter(w)
+	if len(headers) > 0 {
+		if err := csvWriter.Write(headers); err != nil {
+			return fmt.Errorf("failed to write CSV headers: %w", err)
+		}
+	}
+	if err := csvWriter.WriteAll(rows); err != nil {
+		return fmt.Errorf("failed to write CSV rows: %w", err)
+	}
+	return nil
+}
+
+// WriteTable writes the headers and rows to w as a table aligned by columns, to be shown in console.
+// Tabs and line breaks in fields are replaced by spaces to keep the alignment.
+func WriteTable(w io.Writer, headers []string, rows [][]string) error {
+	sanitizer := strings.NewReplacer("\t", " ", "\r\n", " ", "\n", " ")
+	writeRow := func(tableWriter io.Writer, row []string) error {
+		fields := make([]string, len(row))
+		for i, field := range row {
+			fields[i] = sanitizer.Replace(field)
+		}
+		_, err := fmt.Fprintln(tableWriter, strings.Join(fields, "\t"))
+		return err
+	}
+
+	tableWriter := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	if len(headers) > 0 {
+		if err := writeRow(tableWriter, headers); err != nil {
+			return fmt.Errorf("failed to write table headers: %w", err)
+		}
+	}
+	for _, row := range rows {
+		if err := writeRow(tableWriter, row); err != nil {
+			return fmt.Errorf("failed to write table rows: %w", err)
+		}
+	}
+	return tableWriter.Flush()
+}
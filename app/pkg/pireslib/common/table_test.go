@@ -0,0 +1,50 @@
+package common
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWriteCSVQuotesFields(t *testing.T) {
+	var output strings.Builder
+	rows := [][]string{
+		{"allow-ssh", "tcp:22,tcp:2222"},
+		{"allow-web", `say "hi"`},
+	}
+	if err := WriteCSV(&output, []string{"name", "allowed"}, rows); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "name,allowed\nallow-ssh,\"tcp:22,tcp:2222\"\nallow-web,\"say \"\"hi\"\"\"\n"
+	if output.String() != want {
+		t.Errorf("WriteCSV() = %q, want %q", output.String(), want)
+	}
+}
+
+func TestWriteCSVWithoutHeaders(t *testing.T) {
+	var output strings.Builder
+	if err := WriteCSV(&output, nil, [][]string{{"a", "b"}}); err != nil {
+		t.Fatal(err)
+	}
+	if output.String() != "a,b\n" {
+		t.Errorf("WriteCSV() = %q, want %q", output.String(), "a,b\n")
+	}
+}
+
+func TestWriteTable(t *testing.T) {
+	var output strings.Builder
+	rows := [][]string{
+		{"allow-ssh", "tcp:22"},
+		{"allow-web-traffic", "multi\nline\tvalue"},
+	}
+	if err := WriteTable(&output, []string{"NAME", "ALLOWED"}, rows); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "NAME               ALLOWED\n" +
+		"allow-ssh          tcp:22\n" +
+		"allow-web-traffic  multi line value\n"
+	if output.String() != want {
+		t.Errorf("WriteTable() =\n%s\nwant\n%s", output.String(), want)
+	}
+}
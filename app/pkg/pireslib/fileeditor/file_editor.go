@@ -3,15 +3,21 @@ package fileeditor
 
 import (
 	"bytes"
+	"crypto/sha256"
 	"embed"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
 	"io/fs"
+	"maps"
 	"os"
 	"os/exec"
 	"path"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
 	"syscall"
@@ -37,32 +43,106 @@ var internalFS embed.FS
 
 // Package-level variables.
 var (
-	foundYqPath string    // Stores the path to the extracted yq executable
-	findYqOnce  sync.Once // Ensures yq extraction runs only once
-	err         error
-	expression  string // yq expression, reused by various functions
+	foundYqPath   string    // Stores the path to the extracted yq executable
+	foundYqSource string    // Stores the source of foundYqPath. See YqSourceInstalled and YqSourceEmbedded
+	findYqOnce    sync.Once // Ensures yq extraction runs only once
+	err           error
+	expression    string // yq expression, reused by various functions
 )
 
-// SearchForYq extracts the embedded yq executable to a temporary file
-// and makes it executable. This function is run once by GetYqPath.
-func SearchForYq() {
+// SearchForYq prepares the yq executable: the yq installed by 'yaml update-yq' command (see InstalledYqPath)
+// is preferred. Otherwise, the embedded yq is extracted to a temporary file and made executable.
+// This function is run once by GetYqPath and the error is returned by EnsureYq.
+func SearchForYq() error {
 	foundYqPath = "" // Ensure path is empty
+	foundYqPath, err = prepareYq()
+	return err
+}
+
+// Sources of the yq executable used by CLI. See YqInfo
+const (
+	// YqSourceInstalled is the yq installed by 'yaml update-yq' command
+	YqSourceInstalled = "installed"
+	// YqSourceEmbedded is the yq embedded in the CLI and extracted to a temporary file
+	YqSourceEmbedded = "embedded"
+)
+
+// prepareYq returns the path of installed yq if it exists, otherwise extracts the embedded yq.
+// The source of yq is stored in foundYqSource.
+func prepareYq() (string, error) {
+	if installedPath, errPath := InstalledYqPath(); errPath == nil {
+		if info, errStat := os.Stat(installedPath); errStat == nil && info.Mode().IsRegular() {
+			common.Logger("debug", "Using yq installed at: %s", installedPath)
+			foundYqSource = YqSourceInstalled
+			return installedPath, nil
+		}
+	}
+	foundYqSource = YqSourceEmbedded
+	return extractEmbeddedYq()
+}
+
+// YqInfo describes the yq executable used by CLI, returned by GetYqInfo function.
+type YqInfo struct {
+	Path    string `json:"path"`
+	Version string `json:"version"`
+	Source  string `json:"source"`
+}
+
+// GetYqInfo prepares the yq executable (if not prepared yet) and returns its path, version and source.
+func GetYqInfo() (YqInfo, error) {
+	version, errCheck := CheckYq()
+	if errCheck != nil {
+		return YqInfo{}, errCheck
+	}
+	return YqInfo{Path: foundYqPath, Version: version, Source: foundYqSource}, nil
+}
+
+// InstalledYqPath returns the path of yq installed by 'yaml update-yq' command, in the user cache directory
+// (e.g. $HOME/.cache/pires-cli/bin/yq on Linux). The file may not exist.
+func InstalledYqPath() (string, error) {
+	cacheDir, errCache := os.UserCacheDir()
+	if errCache != nil {
+		return "", fmt.Errorf("failed to get user cache directory: %w", errCache)
+	}
+	return filepath.Join(cacheDir, config.CLIName, "bin", "yq"), nil
+}
+
+// CheckYq prepares the yq executable (if not prepared yet) and returns its version.
+// Unlike GetYqPath, it returns an error instead of exit if yq can't be prepared or executed.
+func CheckYq() (string, error) {
+	findYqOnce.Do(func() {
+		foundYqPath, err = prepareYq()
+	})
+	if foundYqPath == "" {
+		return "", fmt.Errorf("[ERROR] yq preparation failed: %v", err)
+	}
+
+	version, errRun := RunYqCommand("--version")
+	if errRun != nil {
+		return "", errRun
+	}
+	return strings.TrimSpace(version), nil
+}
+
+// extractEmbeddedYq extracts the embedded yq executable to a temporary file,
+// makes it executable and returns its path.
+func extractEmbeddedYq() (string, error) {
 	common.Logger("debug", "Preparing embedded yq executable from internalFS")
 
 	// Path to yq within the embedded FS
 	embeddedYqPath := "internalembeds/yq"
 	yqEmbeddedBytes, errCmd := internalFS.ReadFile(embeddedYqPath)
 	if errCmd != nil {
-		common.Logger("fatal", "Failed to read embedded yq binary from '%s': %v", embeddedYqPath, errCmd)
+		return "", fmt.Errorf("[ERROR] Failed to read embedded yq binary from '%s': %v", embeddedYqPath, errCmd)
 	}
 
 	if len(yqEmbeddedBytes) == 0 {
-		common.Logger("fatal", "Embedded yq binary '%s' is empty.", embeddedYqPath)
+		return "", fmt.Errorf("[ERROR] Embedded yq binary '%s' is empty", embeddedYqPath)
 	}
 
-	tmpFile, errCreate := os.CreateTemp("", "yq-*")
+	tmpFile, errCreate := os.CreateTemp(config.TempDir, yqTempFilePrefix+"*")
 	if errCreate != nil {
-		common.Logger("fatal", "Failed to create temporary file for yq: %v", errCreate)
+		return "", fmt.Errorf("[ERROR] Failed to create temporary file for yq: %v", errCreate)
 	}
 	// Defer close here to ensure it's closed even if subsequent steps fail before explicit close.
 	// Store name before potential close if needed, though tmpFile.Name() is fine until remove.
@@ -71,36 +151,117 @@ func SearchForYq() {
 	if _, errWrite := tmpFile.Write(yqEmbeddedBytes); errWrite != nil {
 		tmpFile.Close()         // Close before removing
 		os.Remove(tempFilePath) // Clean up
-		common.Logger("fatal", "Failed to write embedded yq to temporary file '%s': %v", tempFilePath, errWrite)
+		return "", fmt.Errorf("[ERROR] Failed to write embedded yq to temporary file '%s': %v", tempFilePath, errWrite)
 	}
 
 	// Close the file before changing permissions, especially on Windows.
 	if errClose := tmpFile.Close(); errClose != nil {
-		common.Logger("fatal", "Failed to close temporary yq file '%s' before chmod: %v", tempFilePath, errClose)
+		return "", fmt.Errorf("[ERROR] Failed to close temporary yq file '%s' before chmod: %v", tempFilePath, errClose)
 	}
 
 	// Make it executable
 	if errChmod := os.Chmod(tempFilePath, config.PermissionBinary); errChmod != nil {
 		os.Remove(tempFilePath) // Clean up
-		common.Logger("fatal", "Failed to make temporary yq file '%s' executable: %v", tempFilePath, errChmod)
+		return "", fmt.Errorf("[ERROR] Failed to make temporary yq file '%s' executable: %v", tempFilePath, errChmod)
 	}
 
 	common.Logger("debug", "Embedded yq executable prepared at: %s", tempFilePath)
-	foundYqPath = tempFilePath
 	// Note: The temporary file persists for the application's lifetime or until OS cleanup.
+	return tempFilePath, nil
+}
+
+// yqTempFilePrefix is the prefix of temporary files of the embedded yq. See extractEmbeddedYq function
+const yqTempFilePrefix = "yq-"
+
+// YqTempFile is a temporary file of the embedded yq found by FindYqTempFiles function.
+type YqTempFile struct {
+	Path string
+	Size int64
+}
+
+// isYqTempFileName returns true if the name was created by os.CreateTemp with yqTempFilePrefix,
+// i.e. the prefix followed only by the random digits.
+func isYqTempFileName(name string) bool {
+	suffix, found := strings.CutPrefix(name, yqTempFilePrefix)
+	if !found || suffix == "" {
+		return false
+	}
+	_, err := strconv.ParseUint(suffix, 10, 64)
+	return err == nil
+}
+
+// FindYqTempFiles returns the temporary files of the embedded yq in dir (config.TempDir or os.TempDir() if empty),
+// left by previous runs of CLI. The yq file of the current run is ignored.
+// Only regular files matching the exact yq-<digits> name are returned, to avoid removing files of other applications.
+func FindYqTempFiles(dir string) ([]YqTempFile, error) {
+	if dir == "" {
+		dir = config.TempDir
+	}
+	if dir == "" {
+		dir = os.TempDir()
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read temporary directory '%s': %w", dir, err)
+	}
+
+	var files []YqTempFile
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || !isYqTempFileName(entry.Name()) {
+			continue
+		}
+		filePath := filepath.Join(dir, entry.Name())
+		if filePath == foundYqPath {
+			continue
+		}
+		info, errInfo := entry.Info()
+		if errInfo != nil {
+			// The file was removed in the meantime
+			continue
+		}
+		files = append(files, YqTempFile{Path: filePath, Size: info.Size()})
+	}
+	return files, nil
+}
+
+// RemoveYqTempFiles removes the temporary files of the embedded yq found by FindYqTempFiles function.
+// Returns the number and the total size of removed files.
+func RemoveYqTempFiles(files []YqTempFile) (int, int64, error) {
+	var errs []error
+	removed, size := 0, int64(0)
+	for _, file := range files {
+		if err := os.Remove(file.Path); err != nil {
+			errs = append(errs, fmt.Errorf("failed to remove '%s': %w", file.Path, err))
+			continue
+		}
+		common.Logger("debug", "Removed yq temporary file: %s", file.Path)
+		removed++
+		size += file.Size
+	}
+	return removed, size, errors.Join(errs...)
 }
 
 // GetYqPath returns the path to the (potentially extracted) yq executable.
 // The extraction logic (SearchForYq) is run only once.
 func GetYqPath() string {
 	findYqOnce.Do(func() {
-		SearchForYq()
 		// Package-level 'err' is set by SearchForYq if an error occurs.
 		// If 'err' is not nil here, 'foundYqPath' will likely be empty.
+		_ = SearchForYq()
 	})
 	return foundYqPath
 }
 
+// EnsureYq prepares the yq executable, like GetYqPath, and returns an external command error
+// if the preparation failed. It's used by the startup checks of CLI (see cmd/root.go).
+func EnsureYq() error {
+	if GetYqPath() == "" {
+		return common.NewExternalCommandError("yq preparation failed: %v", err)
+	}
+	return nil
+}
+
 // RunYqCommand executes the yq command with the given arguments.
 // It uses the yq executable obtained from GetYqPath.
 func RunYqCommand(args ...string) (string, error) {
@@ -111,7 +272,8 @@ func RunYqCommand(args ...string) (string, error) {
 	}
 
 	// Proceed with running the command
-	cmd := exec.Command(execPath, args...)
+	// The command is killed if the CLI receives SIGINT/SIGTERM
+	cmd := common.CommandContext(execPath, args...)
 
 	// Buffers to capture stdout and stderr
 	var outb, errb bytes.Buffer
@@ -125,6 +287,9 @@ func RunYqCommand(args ...string) (string, error) {
 	stderr := errb.String()
 	combinedOutput := stdout + stderr // Combine for context in case of error
 
+	if errInterrupted := common.CheckInterrupted(); errInterrupted != nil {
+		return combinedOutput, errInterrupted
+	}
 	if runCmdErr != nil {
 		exitCode := -1
 		if exitError, ok := runCmdErr.(*exec.ExitError); ok {
@@ -132,12 +297,12 @@ func RunYqCommand(args ...string) (string, error) {
 				exitCode = status.ExitStatus()
 			}
 		}
-		return combinedOutput, fmt.Errorf("[ERROR] yq command failed (exit code %d): %w\nStderr: %s", exitCode, runCmdErr, stderr)
+		return combinedOutput, common.NewExternalCommandError("[ERROR] yq command failed (exit code %d): %w\nStderr: %s", exitCode, runCmdErr, stderr)
 	}
 
 	// Check if yq wrote anything to stderr, even if exit code is 0 (might indicate warnings)
 	if stderr != "" {
-		common.Logger("warning", "yq command stderr (exit code 0):\n%s\n", stderr)
+		common.LogExternalStderr("yq", stderr)
 	}
 	return strings.TrimSpace(stdout), nil // Return trimmed stdout on success
 }
@@ -165,6 +330,75 @@ func GetYamlValue(filePath string, expression string) (string, error) {
 	return output, nil
 }
 
+// imagesExpression is the yq expression returning the string values of all 'image' fields of a manifest,
+// at any level (e.g. containers, initContainers and CronJob templates).
+const imagesExpression = `.. | select(tag == "!!map" and has("image")) | .image | select(tag == "!!str")`
+
+// ImageViolation is an image not pinned found by CheckYAMLImages function.
+type ImageViolation struct {
+	File   string
+	Image  string
+	Reason string
+}
+
+// CheckImageReference returns the reason why the image reference is not pinned (':latest' tag or no tag)
+// or an empty string if it has a digest (e.g. app@sha256:...) or another tag.
+// The port of registry (e.g. registry:5000/app) is not considered a tag.
+func CheckImageReference(image string) string {
+	if strings.Contains(image, "@") {
+		return ""
+	}
+	name := image[strings.LastIndex(image, "/")+1:]
+	separator := strings.LastIndex(name, ":")
+	switch {
+	case separator < 0:
+		return "no tag"
+	case name[separator+1:] == "latest":
+		return "uses the ':latest' tag"
+	}
+	return ""
+}
+
+// CheckYAMLImages walks the YAML files (except patch files) under rootDir, extracts all 'image' fields
+// using yq and returns the number of images checked and the images not pinned (see CheckImageReference).
+func CheckYAMLImages(rootDir string) (int, []ImageViolation, error) {
+	if rootDir == "" {
+		return 0, nil, fmt.Errorf("[ERROR] Root directory path cannot be empty")
+	}
+
+	checked := 0
+	var violations []ImageViolation
+	errWalk := filepath.WalkDir(rootDir, func(path string, d os.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return fmt.Errorf("[ERROR] Unable to access path '%s': %w", path, walkErr)
+		}
+		if d.IsDir() || !IsYAMLFile(path) {
+			return nil
+		}
+
+		// The --no-doc flag avoids the '---' separators between the results of each document
+		output, errYq := RunYqCommand("eval", "--no-doc", imagesExpression, path)
+		if errYq != nil {
+			return fmt.Errorf("[ERROR] Failed to get the images of '%s': %w", path, errYq)
+		}
+		for _, image := range strings.Split(output, "\n") {
+			image = strings.TrimSpace(image)
+			if image == "" {
+				continue
+			}
+			checked++
+			if reason := CheckImageReference(image); reason != "" {
+				violations = append(violations, ImageViolation{File: path, Image: image, Reason: reason})
+			}
+		}
+		return nil
+	})
+	if errWalk != nil {
+		return checked, nil, errWalk
+	}
+	return checked, violations, nil
+}
+
 // ModifyYamlInPlace modifies a YAML file in-place using a full yq expression.
 // If the target file or its directory structure does not exist, they will be created before modification.
 // The caller is responsible for providing a valid yq expression string.
@@ -246,6 +480,61 @@ func ModifyYamlInPlace(filePath string, fullExpression string) error {
 	return nil
 }
 
+// ModifyYamlInPlaceMulti applies several yq expressions, in order, to a YAML file.
+// The expressions are joined with '|' into a single yq program, so the file is read and written only once
+// and it is not modified if any expression fails.
+// If yq fails, the expressions are validated one by one to report the index (starting at 0) of the invalid one.
+//
+// Example:
+//
+//	ModifyYamlInPlaceMulti("values.yaml", []string{`.image.tag = "v2"`, `.replicas = 3`, `del(.debug)`})
+func ModifyYamlInPlaceMulti(filePath string, expressions []string) error {
+	if len(expressions) == 0 {
+		return fmt.Errorf("[ERROR] At least one yq expression is required")
+	}
+
+	parts := make([]string, 0, len(expressions))
+	for i, expression := range expressions {
+		if strings.TrimSpace(expression) == "" {
+			return fmt.Errorf("[ERROR] yq expression at index %d cannot be empty", i)
+		}
+		// Parentheses keep the precedence of each expression, e.g. with '//' or ',' operators
+		parts = append(parts, "("+expression+")")
+	}
+
+	errModify := ModifyYamlInPlace(filePath, strings.Join(parts, " | "))
+	if errModify == nil {
+		return nil
+	}
+	if common.ExitCode(errModify) == common.ExitCodeInterrupted {
+		return errModify
+	}
+
+	// Find which expression failed. The validation doesn't touch the file
+	for i, expression := range expressions {
+		if errValidate := ValidateYqExpression(expression); errValidate != nil {
+			return fmt.Errorf("[ERROR] yq expression at index %d failed: %w", i, errValidate)
+		}
+	}
+	return errModify
+}
+
+// ValidateYqExpression checks the syntax of a yq expression without touching any file.
+// The expression is evaluated against a null document (yq eval --null-input '<expression>').
+func ValidateYqExpression(expression string) error {
+	if expression == "" {
+		return fmt.Errorf("[ERROR] yq expression cannot be empty")
+	}
+
+	// Arguments for yq: eval --null-input '<expression>'
+	args := []string{"eval", "--null-input", expression}
+	output, cmdErr := RunYqCommand(args...)
+	if cmdErr != nil {
+		return fmt.Errorf("[ERROR] Invalid yq expression '%s': %w\nOutput:\n%s", expression, cmdErr, output)
+	}
+	return nil
+}
+
 // HasAnySuffix check short and long suffix like this: foo.bar.baz.tar.gz and foo.bar
 func HasAnySuffix(name string, suffixes ...string) bool {
 	for _, suffix := range suffixes {
@@ -256,19 +545,68 @@ func HasAnySuffix(name string, suffixes ...string) bool {
 	return false
 }
 
+// kindRegex matches the kinds of Kubernetes resources, like: Deployment or ConfigMap
+var kindRegex = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9]*$`)
+
+// BuildKindSelectExpression wraps a yq expression, so it is applied only to the documents with the kind
+// (e.g. Deployment). The other documents of multi-document files are kept unchanged.
+func BuildKindSelectExpression(expression, kind string) (string, error) {
+	if !kindRegex.MatchString(kind) {
+		return "", fmt.Errorf("[ERROR] Invalid kind '%s'. It must start with a letter and contain only letters and numbers (e.g. Deployment)", kind)
+	}
+	return fmt.Sprintf(`with(select(.kind == "%s"); %s)`, kind, expression), nil
+}
+
+// HasYAMLKind returns true if any document of the YAML file has the kind (e.g. Deployment). See GetYamlValue.
+func HasYAMLKind(filePath, kind string) (bool, error) {
+	output, errGet := GetYamlValue(filePath, ".kind")
+	if errGet != nil {
+		return false, errGet
+	}
+	for _, line := range strings.Split(output, "\n") {
+		if strings.TrimSpace(line) == kind {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// ApplyYqOptions are the options of ApplyYqExpressionRecursively function.
+type ApplyYqOptions struct {
+	// Kind (e.g. Deployment), if not empty, skips the files without documents of the kind and
+	// edits only the documents of the kind (see BuildKindSelectExpression).
+	Kind string
+	// IncludePatchFiles edits the *.patch.yaml and *.patch.yml files too (see MatchYAMLFile).
+	IncludePatchFiles bool
+	// ContinueOnError edits the other files after a file error and returns an aggregated error
+	// with all failing paths at the end (best-effort bulk edits). Otherwise, it stops at the first
+	// file error (e.g. for CI gating).
+	ContinueOnError bool
+}
+
 // ApplyYqExpressionRecursively applies a yq expression in-place to all YAML files
-// under the given directory and its subdirectories.
+// under the given directory and its subdirectories, according to the options (see ApplyYqOptions).
 // It uses the RunYqCommand helper to execute the yq command with proper logging and error handling.
-func ApplyYqExpressionRecursively(rootDir string, expressionToApply string) error {
+func ApplyYqExpressionRecursively(rootDir string, expressionToApply string, options ApplyYqOptions) error {
 	if rootDir == "" {
 		return fmt.Errorf("[ERROR] Root directory path cannot be empty")
 	}
 	if expressionToApply == "" {
 		return fmt.Errorf("[ERROR] yq expression cannot be empty")
 	}
+	if options.Kind != "" {
+		kindExpression, errKind := BuildKindSelectExpression(expressionToApply, options.Kind)
+		if errKind != nil {
+			return errKind
+		}
+		expressionToApply = kindExpression
+	}
+
+	var failedPaths []string
+	var fileErrs []error
 
 	// Traverse the directory tree and apply the expression to each .yaml/.yml file
-	return filepath.WalkDir(rootDir, func(path string, d os.DirEntry, walkErr error) error {
+	errWalk := filepath.WalkDir(rootDir, func(path string, d os.DirEntry, walkErr error) error {
 		if walkErr != nil {
 			return fmt.Errorf("[ERROR] Unable to access path '%s': %w", path, walkErr)
 		}
@@ -277,21 +615,53 @@ func ApplyYqExpressionRecursively(rootDir string, expressionToApply string) erro
 			return nil
 		}
 		// Skip non-YAML files
-		if !HasAnySuffix(path, ".yaml", ".yml", ".patch.yaml", ".patch.yml") {
-			common.Logger("debug", "Skipping non-YAML file: %s", path)
+		if !MatchYAMLFile(path, options.IncludePatchFiles) {
 			return nil
 		}
+		// Skip the files without documents of the kind, so they are not rewritten
+		if options.Kind != "" {
+			hasKind, errKind := HasYAMLKind(path, options.Kind)
+			if errKind != nil {
+				if !options.ContinueOnError || common.ExitCode(errKind) == common.ExitCodeInterrupted {
+					return errKind
+				}
+				common.Logger("warning", "Failed to get the kind of '%s'. Continuing with the next files...", path)
+				failedPaths = append(failedPaths, path)
+				fileErrs = append(fileErrs, errKind)
+				return nil
+			}
+			if !hasKind {
+				common.Logger("debug", "Skipping '%s': no documents of kind '%s'", path, options.Kind)
+				return nil
+			}
+		}
 
 		// Construct the in-place edit command: yq eval -i '<expression>' <filePath>
 		args := []string{"eval", "-i", expressionToApply, path}
 		// Run yq with custom wrapper to capture output and errors
 		output, cmdErr := RunYqCommand(args...)
 		if cmdErr != nil {
-			return fmt.Errorf("[ERROR] Failed to apply yq to '%s': %w\nOutput:\n%s", path, cmdErr, output)
+			errApply := fmt.Errorf("[ERROR] Failed to apply yq to '%s': %w\nOutput:\n%s", path, cmdErr, output)
+			// The interruption (SIGINT/SIGTERM) always stops the walk
+			if !options.ContinueOnError || common.ExitCode(cmdErr) == common.ExitCodeInterrupted {
+				return errApply
+			}
+			common.Logger("warning", "Failed to apply yq to '%s'. Continuing with the next files...", path)
+			failedPaths = append(failedPaths, path)
+			fileErrs = append(fileErrs, errApply)
+			return nil
 		}
 		common.Logger("debug", "Successfully applied yq expression to: %s\n", path)
 		return nil
 	})
+	if errWalk != nil {
+		return errWalk
+	}
+
+	if len(failedPaths) > 0 {
+		return fmt.Errorf("[ERROR] Failed to apply yq to %d file(s): %s\n%w", len(failedPaths), strings.Join(failedPaths, ", "), errors.Join(fileErrs...))
+	}
+	return nil
 }
 
 // CopyTemplateFiles copies files from an embedded source directory to a destination on disk.
@@ -352,6 +722,28 @@ func CopyTemplateFiles(embeddedSourceDirRelToInternalEmbeds string, destDir stri
 	})
 }
 
+// ListEmbeddedTemplates lists the directories (template sets) embedded in 'internalFS',
+// relative to its root 'internalembeds', e.g. "templates" and "templates/common".
+// These paths can be passed to CopyTemplateFiles function.
+func ListEmbeddedTemplates() ([]string, error) {
+	templates := []string{}
+	errWalk := fs.WalkDir(internalFS, "internalembeds", func(embedPath string, d fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return fmt.Errorf("[ERROR] Error accessing embedded path %s: %w", embedPath, walkErr)
+		}
+		// Only directories are template sets. Skip the root directory itself
+		if !d.IsDir() || embedPath == "internalembeds" {
+			return nil
+		}
+		templates = append(templates, strings.TrimPrefix(embedPath, "internalembeds/"))
+		return nil
+	})
+	if errWalk != nil {
+		return nil, errWalk
+	}
+	return templates, nil
+}
+
 // CopyFile copies a single file from source to destination.
 func CopyFile(srcFile, destFile string) error {
 	src, openErr := os.Open(srcFile)
@@ -371,8 +763,11 @@ func CopyFile(srcFile, destFile string) error {
 }
 
 // MergeYAMLFiles merges two YAML files and returns the result as a YAML string.
-// No changes needed.
-func MergeYAMLFiles(filePath1, filePath2 string) (string, error) {
+// By default, the values of filePath2 win. If preferExisting is true, the values of filePath1 win
+// and only the new keys of filePath2 are added (see MergeValuesPreferExisting).
+// If strict is true, an error is returned when a key has values of different kinds in the files
+// (see CheckMergeKinds), instead of overriding one of them.
+func MergeYAMLFiles(filePath1, filePath2 string, preferExisting, strict bool) (string, error) {
 	yamlData1, errRead1 := os.ReadFile(filePath1)
 	if errRead1 != nil {
 		return "", fmt.Errorf("[ERROR] Could not read file %s: %w", filePath1, errRead1)
@@ -391,7 +786,7 @@ func MergeYAMLFiles(filePath1, filePath2 string) (string, error) {
 	}
 
 	// Merge the root nodes
-	mergedNode, errMerge := MergeRootDocumentNodes(&rootNode1, &rootNode2)
+	mergedNode, errMerge := MergeRootDocumentNodes(&rootNode1, &rootNode2, preferExisting, strict)
 	if errMerge != nil {
 		return "", fmt.Errorf("[ERROR] Failed to merge YAML nodes: %w", errMerge)
 	}
@@ -408,14 +803,22 @@ func MergeYAMLFiles(filePath1, filePath2 string) (string, error) {
 }
 
 // MergeRootDocumentNodes merges two YAML DocumentNodes and returns the resulting merged node.
-func MergeRootDocumentNodes(docNode1, docNode2 *yaml.Node) (*yaml.Node, error) {
+// If preferExisting is true, the values of docNode1 win. See MergeMappingPreservingKeyOrder.
+// If strict is true, the kinds of values are checked before the merge. See CheckMergeKinds.
+func MergeRootDocumentNodes(docNode1, docNode2 *yaml.Node, preferExisting, strict bool) (*yaml.Node, error) {
 	if docNode1.Kind != yaml.DocumentNode || docNode2.Kind != yaml.DocumentNode {
 		return nil, fmt.Errorf("[ERROR] Expected both nodes to be DocumentNode")
 	}
 
+	if strict {
+		if errKinds := CheckMergeKinds("", docNode1.Content[0], docNode2.Content[0]); errKinds != nil {
+			return nil, errKinds
+		}
+	}
+
 	map1 := ConvertMappingNodeToMap(docNode1.Content[0])
 	map2 := ConvertMappingNodeToMap(docNode2.Content[0])
-	mergedMappingNode := MergeMappingPreservingKeyOrder(map1, map2)
+	mergedMappingNode := MergeMappingPreservingKeyOrder(map1, map2, preferExisting)
 
 	return &yaml.Node{
 		Kind:    yaml.DocumentNode,
@@ -448,7 +851,12 @@ func ConvertMappingNodeToMap(mappingNode *yaml.Node) map[string]*yaml.Node {
 }
 
 // MergeMappingPreservingKeyOrder merges two YAML maps preserving a specific key order.
-func MergeMappingPreservingKeyOrder(primaryMap, secondaryMap map[string]*yaml.Node) *yaml.Node {
+// The keys not listed in config.K8sYamlManifestsPreferredKeyOrder are added after them, sorted by name.
+// The values of preferred keys in both maps are merged by MergeValuesForKey (the secondaryMap values win)
+// or, if preferPrimary is true, by MergeValuesPreferExisting (the primaryMap values win).
+// The values of other keys in both maps are kept from primaryMap, or, if preferPrimary is true,
+// merged by MergeValuesPreferExisting too, so their new nested keys of secondaryMap are added.
+func MergeMappingPreservingKeyOrder(primaryMap, secondaryMap map[string]*yaml.Node, preferPrimary bool) *yaml.Node {
 	preferredKeyOrder := config.K8sYamlManifestsPreferredKeyOrder
 	mergedNode := &yaml.Node{Kind: yaml.MappingNode}
 	seenKeys := map[string]bool{}
@@ -466,6 +874,14 @@ func MergeMappingPreservingKeyOrder(primaryMap, secondaryMap map[string]*yaml.No
 		seenKeys[key] = true
 	}
 
+	// mergeValues merges the values of a key existing in both maps
+	mergeValues := func(key string, node1, node2 *yaml.Node) *yaml.Node {
+		if preferPrimary {
+			return MergeValuesPreferExisting(key, node1, node2)
+		}
+		return MergeValuesForKey(key, node1, node2)
+	}
+
 	// This loop is to iterate through the preferredKeyOrder and, for each key,
 	// determine how to handle it based on its presence in the two input maps.
 	// It prioritizes keys in the primaryMap but also incorporates keys from the secondaryMap
@@ -477,8 +893,7 @@ func MergeMappingPreservingKeyOrder(primaryMap, secondaryMap map[string]*yaml.No
 			// Check if the key also exists in the secondary map
 			if node2, exists2 := secondaryMap[key]; exists2 {
 				// Key exists in both maps, merge the values
-				mergedValue := MergeValuesForKey(key, node1, node2)
-				addKeyValue(key, mergedValue)
+				addKeyValue(key, mergeValues(key, node1, node2))
 			} else {
 				// Key exists only in the primary map, use its value
 				addKeyValue(key, node1)
@@ -491,15 +906,21 @@ func MergeMappingPreservingKeyOrder(primaryMap, secondaryMap map[string]*yaml.No
 		// It might be added later if it's in the remaining keys.
 	}
 
-	// Add any additional keys not listed in preferredKeyOrder
-	for key, node := range primaryMap {
-		if !seenKeys[key] {
-			addKeyValue(key, node)
+	// Add any additional keys not listed in preferredKeyOrder. The primaryMap values are kept,
+	// and only merged with the secondaryMap values when preferPrimary is true.
+	for _, key := range slices.Sorted(maps.Keys(primaryMap)) {
+		if seenKeys[key] {
+			continue
+		}
+		if node2, exists := secondaryMap[key]; exists && preferPrimary {
+			addKeyValue(key, MergeValuesPreferExisting(key, primaryMap[key], node2))
+		} else {
+			addKeyValue(key, primaryMap[key])
 		}
 	}
-	for key, node := range secondaryMap {
+	for _, key := range slices.Sorted(maps.Keys(secondaryMap)) {
 		if !seenKeys[key] {
-			addKeyValue(key, node)
+			addKeyValue(key, secondaryMap[key])
 		}
 	}
 	return mergedNode
@@ -514,8 +935,111 @@ func MergeValuesForKey(key string, value1, value2 *yaml.Node) *yaml.Node {
 	return value2
 }
 
+// MergeValuesPreferExisting merges values like MergeValuesForKey, but the existing value (value1) wins.
+// The nested mappings are merged recursively, so the new keys of value2 are added
+// without overriding the customized values of value1. The arrays are merged without duplicates.
+func MergeValuesPreferExisting(key string, value1, value2 *yaml.Node) *yaml.Node {
+	if value1.Kind == yaml.SequenceNode && value2.Kind == yaml.SequenceNode {
+		return MergeArraysUniquely(value1, value2)
+	}
+	if value1.Kind != yaml.MappingNode || value2.Kind != yaml.MappingNode {
+		return value1
+	}
+
+	merged := &yaml.Node{Kind: yaml.MappingNode, Tag: value1.Tag, Style: value1.Style}
+	map2 := ConvertMappingNodeToMap(value2)
+	seenKeys := map[string]bool{}
+	for i := 0; i+1 < len(value1.Content); i += 2 {
+		keyNode, value := value1.Content[i], value1.Content[i+1]
+		if newValue, exists := map2[keyNode.Value]; exists {
+			value = MergeValuesPreferExisting(keyNode.Value, value, newValue)
+		}
+		merged.Content = append(merged.Content, keyNode, value)
+		seenKeys[keyNode.Value] = true
+	}
+	for i := 0; i+1 < len(value2.Content); i += 2 {
+		if !seenKeys[value2.Content[i].Value] {
+			merged.Content = append(merged.Content, value2.Content[i], value2.Content[i+1])
+		}
+	}
+	return merged
+}
+
+// CheckMergeKinds returns an error with the key path (e.g. spec.template.spec) and the kinds of values
+// when a key has a scalar in one node and a mapping or sequence in the other (or a mapping and a sequence),
+// which usually is a structural mistake of an overlay. The nested mappings are checked recursively.
+// The null values (e.g. 'key:' or 'key: ~') are compatible with any kind.
+func CheckMergeKinds(keyPath string, value1, value2 *yaml.Node) error {
+	value1, value2 = resolveAlias(value1), resolveAlias(value2)
+	if isNullNode(value1) || isNullNode(value2) {
+		return nil
+	}
+	if value1.Kind != value2.Kind {
+		if keyPath == "" {
+			keyPath = "(root)"
+		}
+		return fmt.Errorf("[ERROR] Type mismatch at key '%s': %s in the first file and %s in the second file",
+			keyPath, nodeKindName(value1.Kind), nodeKindName(value2.Kind))
+	}
+	if value1.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	map2 := ConvertMappingNodeToMap(value2)
+	for i := 0; i+1 < len(value1.Content); i += 2 {
+		key := value1.Content[i].Value
+		if newValue, exists := map2[key]; exists {
+			childPath := key
+			if keyPath != "" {
+				childPath = keyPath + "." + key
+			}
+			if errKinds := CheckMergeKinds(childPath, value1.Content[i+1], newValue); errKinds != nil {
+				return errKinds
+			}
+		}
+	}
+	return nil
+}
+
+// resolveAlias returns the node referenced by an alias node, or the node itself.
+func resolveAlias(node *yaml.Node) *yaml.Node {
+	for node.Kind == yaml.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+	return node
+}
+
+// isNullNode returns true if the node is a null scalar.
+func isNullNode(node *yaml.Node) bool {
+	return node.Kind == yaml.ScalarNode && node.ShortTag() == "!!null"
+}
+
+// nodeKindName returns a readable name of a YAML node kind, used in the error messages.
+func nodeKindName(kind yaml.Kind) string {
+	switch kind {
+	case yaml.ScalarNode:
+		return "scalar"
+	case yaml.MappingNode:
+		return "mapping"
+	case yaml.SequenceNode:
+		return "sequence"
+	case yaml.AliasNode:
+		return "alias"
+	case yaml.DocumentNode:
+		return "document"
+	}
+	return "unknown"
+}
+
 // MergeArraysUniquely merges two YAML arrays avoiding duplicate entries (by serialized representation).
+// The first-seen order of items is preserved: the items of first array, followed by the new items
+// of second array, both in their original order (e.g. container args keep their order).
 func MergeArraysUniquely(array1, array2 *yaml.Node) *yaml.Node {
+	return mergeArraysUniquely(array1, array2, serializeArrayItem)
+}
+
+// mergeArraysUniquely merges two YAML arrays like MergeArraysUniquely, comparing the items by serialize.
+func mergeArraysUniquely(array1, array2 *yaml.Node, serialize func(item *yaml.Node) (string, bool)) *yaml.Node {
 	mergedArray := &yaml.Node{Kind: yaml.SequenceNode}
 	seenSerializedValues := map[string]bool{}
 
@@ -524,14 +1048,10 @@ func MergeArraysUniquely(array1, array2 *yaml.Node) *yaml.Node {
 	// node hasn't already been added. This is done by serializing the YAML node
 	// to a string and checking if that string representation has been seen before.
 	appendIfNotSeen := func(item *yaml.Node) {
-		var buffer bytes.Buffer
-		encoder := yaml.NewEncoder(&buffer)
-		if encErr := encoder.Encode(item); encErr != nil { // Use local encErr
-			common.Logger("error", "Failed to encode YAML node: %v", encErr)
+		serialized, ok := serialize(item)
+		if !ok {
 			return
 		}
-		// String representation of the YAML node
-		serialized := buffer.String()
 		if !seenSerializedValues[serialized] {
 			seenSerializedValues[serialized] = true
 			mergedArray.Content = append(mergedArray.Content, item)
@@ -552,10 +1072,38 @@ func MergeArraysUniquely(array1, array2 *yaml.Node) *yaml.Node {
 	return mergedArray
 }
 
+// serializeArrayItem returns the string used by MergeArraysUniquely to compare array items.
+// Scalar items are compared by their tag, style, anchor, comments and value, without encoding the node,
+// which is much faster for large arrays of scalars. Other items are compared by their YAML encoding.
+func serializeArrayItem(item *yaml.Node) (string, bool) {
+	if item.Kind == yaml.ScalarNode {
+		return strings.Join([]string{
+			"scalar", item.Tag, strconv.Itoa(int(item.Style)), item.Anchor,
+			item.HeadComment, item.LineComment, item.FootComment, item.Value,
+		}, "\x00"), true
+	}
+	return encodeArrayItem(item)
+}
+
+// encodeArrayItem returns the YAML encoding of an array item, used to compare items that aren't scalars.
+func encodeArrayItem(item *yaml.Node) (string, bool) {
+	var buffer bytes.Buffer
+	encoder := yaml.NewEncoder(&buffer)
+	if encErr := encoder.Encode(item); encErr != nil { // Use local encErr
+		common.Logger("error", "Failed to encode YAML node: %v", encErr)
+		return "", false
+	}
+	// String representation of the YAML node
+	return buffer.String(), true
+}
+
 // CopyAndMergeYAMLDir copies files from an embedded source to a target directory.
 // If a YAML file exists at the destination, it's merged with the embedded version.
+// By default, the embedded values win. If preferExisting is true, the destination values win
+// (e.g. customizations kept during upgrades) and only the new keys of embedded version are added.
+// If strict is true, the merge fails when a key has values of different kinds in the files (see CheckMergeKinds).
 // embeddedSourceDirRelToInternalEmbeds is path like "templates/common".
-func CopyAndMergeYAMLDir(embeddedSourceDirRelToInternalEmbeds string, targetDir string) error {
+func CopyAndMergeYAMLDir(embeddedSourceDirRelToInternalEmbeds string, targetDir string, preferExisting, strict bool) error {
 	fullEmbedSourcePath := path.Join("internalembeds", embeddedSourceDirRelToInternalEmbeds)
 
 	return fs.WalkDir(internalFS, fullEmbedSourcePath, func(embedPath string, d fs.DirEntry, walkErr error) error {
@@ -592,7 +1140,7 @@ func CopyAndMergeYAMLDir(embeddedSourceDirRelToInternalEmbeds string, targetDir
 				return fmt.Errorf("[ERROR] Failed to read embedded YAML file %s for merging: %w", embedPath, errRead)
 			}
 
-			tmpEmbedFile, errTmp := os.CreateTemp("", "embed-*.yaml")
+			tmpEmbedFile, errTmp := os.CreateTemp(config.TempDir, "embed-*.yaml")
 			if errTmp != nil {
 				return fmt.Errorf("[ERROR] Failed to create temporary file for embedded YAML %s: %w", embedPath, errTmp)
 			}
@@ -613,11 +1161,12 @@ func CopyAndMergeYAMLDir(embeddedSourceDirRelToInternalEmbeds string, targetDir
 				// Depending on OS, MergeYAMLFiles might fail if file not properly closed.
 			}
 
-			merged, errMerge := MergeYAMLFiles(destPath, tmpEmbedFile.Name()) // destPath is existing, tmpEmbedFile.Name() is new from embed
+			merged, errMerge := MergeYAMLFiles(destPath, tmpEmbedFile.Name(), preferExisting, strict) // destPath is existing, tmpEmbedFile.Name() is new from embed
 			if errMerge != nil {
 				return fmt.Errorf("[ERROR] Failed to merge %s and embedded %s (from temp %s): %w", destPath, embedPath, tmpEmbedFile.Name(), errMerge)
 			}
-			errWrite := os.WriteFile(destPath, []byte(merged), config.PermissionFile)
+			// The merged file replaces a customized file, so it's written atomically
+			errWrite := common.WriteFileAtomic(destPath, []byte(merged), config.PermissionFile)
 			if errWrite != nil {
 				return fmt.Errorf("[ERROR] Failed to write merged YAML to %s: %w", destPath, errWrite)
 			}
@@ -639,10 +1188,18 @@ func CopyAndMergeYAMLDir(embeddedSourceDirRelToInternalEmbeds string, targetDir
 }
 
 // IsYAMLFile checks if the filename has a YAML extension (.yaml or .yml), excluding patch files.
+// It is used by CopyAndMergeYAMLDir, so *.patch.yaml and *.patch.yml files are never merged.
 func IsYAMLFile(filename string) bool {
+	return MatchYAMLFile(filename, false)
+}
+
+// MatchYAMLFile checks if the filename has a YAML extension (.yaml or .yml).
+// Patch files (*.patch.yaml and *.patch.yml) only match if includePatchFiles is true.
+// This is the single predicate used by all functions that select YAML files.
+func MatchYAMLFile(filename string, includePatchFiles bool) bool {
 	// Conditional used to avoid merge *.patch.yaml file
 	// Skip non-YAML files and *.patch.yaml and *.patch.yml files
-	if HasAnySuffix(filename, ".patch.yaml", ".patch.yml") {
+	if !includePatchFiles && HasAnySuffix(filename, ".patch.yaml", ".patch.yml") {
 		common.Logger("debug", "Skipping *.patch.yaml or *.patch.yml file: %s", filename)
 		return false
 	}
@@ -658,3 +1215,864 @@ func FileExists(path string) bool {
 	info, errStat := os.Stat(path)
 	return errStat == nil && !info.IsDir()
 }
+
+// patchTargetKey is the optional root key of patch files with the path of base manifest,
+// relative to the directory of patch file. See ApplyPatchFiles function
+const patchTargetKey = "target"
+
+// ResolvePatchTarget returns the path of base manifest of the patch file (patchPath).
+// If the patch has the root 'target' key, its value is used (relative to the directory of patch file).
+// Otherwise, the naming convention is used: app.patch.yaml patches app.yaml or app.yml.
+func ResolvePatchTarget(patchPath string, patchNode *yaml.Node) (string, error) {
+	if target, ok := patchTargetValue(patchNode); ok {
+		if target == "" {
+			return "", fmt.Errorf("[ERROR] Empty '%s' in patch file %s", patchTargetKey, patchPath)
+		}
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(filepath.Dir(patchPath), target)
+		}
+		if !FileExists(target) {
+			return "", fmt.Errorf("[ERROR] Target %s of patch file %s not found", target, patchPath)
+		}
+		return target, nil
+	}
+
+	basePath := strings.TrimSuffix(strings.TrimSuffix(patchPath, ".patch.yaml"), ".patch.yml")
+	for _, extension := range []string{".yaml", ".yml"} {
+		if FileExists(basePath + extension) {
+			return basePath + extension, nil
+		}
+	}
+	return "", fmt.Errorf("[ERROR] Base manifest of patch file %s not found. Expected %s.yaml, %s.yml or the '%s' key", patchPath, basePath, basePath, patchTargetKey)
+}
+
+// patchTargetValue returns the value of root 'target' key of the patch document, if it exists.
+func patchTargetValue(patchNode *yaml.Node) (string, bool) {
+	if patchNode.Kind != yaml.DocumentNode || len(patchNode.Content) == 0 {
+		return "", false
+	}
+	target, exists := ConvertMappingNodeToMap(patchNode.Content[0])[patchTargetKey]
+	if !exists {
+		return "", false
+	}
+	return target.Value, true
+}
+
+// removePatchTargetKey removes the root 'target' key of the patch document, so it is not merged into the base manifest.
+func removePatchTargetKey(patchNode *yaml.Node) {
+	mappingNode := patchNode.Content[0]
+	for i := 0; i+1 < len(mappingNode.Content); i += 2 {
+		if mappingNode.Content[i].Value == patchTargetKey {
+			mappingNode.Content = append(mappingNode.Content[:i], mappingNode.Content[i+2:]...)
+			return
+		}
+	}
+}
+
+// readYAMLDocument reads the file and returns its YAML document node.
+func readYAMLDocument(filePath string) (*yaml.Node, error) {
+	yamlData, errRead := os.ReadFile(filePath)
+	if errRead != nil {
+		return nil, fmt.Errorf("[ERROR] Could not read file %s: %w", filePath, errRead)
+	}
+	var rootNode yaml.Node
+	if errUnmarshal := yaml.Unmarshal(yamlData, &rootNode); errUnmarshal != nil {
+		return nil, fmt.Errorf("[ERROR] Failed to parse YAML from %s: %w", filePath, errUnmarshal)
+	}
+	if rootNode.Kind != yaml.DocumentNode || len(rootNode.Content) == 0 || rootNode.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("[ERROR] Expected a YAML mapping in %s", filePath)
+	}
+	return &rootNode, nil
+}
+
+// MergePatchMapping merges the patch mapping on top of the base mapping recursively, preserving the key order of base.
+// The nested mappings are merged key by key, the arrays are merged by MergeValuesForKey (without duplicates)
+// and the other values of patch win. New keys of patch are appended.
+func MergePatchMapping(base, patch *yaml.Node) *yaml.Node {
+	merged := &yaml.Node{Kind: yaml.MappingNode, Tag: base.Tag, Style: base.Style}
+	patchMap := ConvertMappingNodeToMap(patch)
+	seenKeys := map[string]bool{}
+
+	for i := 0; i+1 < len(base.Content); i += 2 {
+		keyNode, value := base.Content[i], base.Content[i+1]
+		if patchValue, exists := patchMap[keyNode.Value]; exists {
+			if value.Kind == yaml.MappingNode && patchValue.Kind == yaml.MappingNode {
+				value = MergePatchMapping(value, patchValue)
+			} else {
+				value = MergeValuesForKey(keyNode.Value, value, patchValue)
+			}
+		}
+		merged.Content = append(merged.Content, keyNode, value)
+		seenKeys[keyNode.Value] = true
+	}
+	for i := 0; i+1 < len(patch.Content); i += 2 {
+		if !seenKeys[patch.Content[i].Value] {
+			merged.Content = append(merged.Content, patch.Content[i], patch.Content[i+1])
+		}
+	}
+	return merged
+}
+
+// ApplyPatchFiles applies all *.patch.yaml and *.patch.yml files under rootDir to their base manifests
+// (see ResolvePatchTarget), merging them recursively with MergePatchMapping (the values of patch win).
+// Each patch file is removed after applied. All patches are resolved before any change, so
+// no file is changed when a base manifest is missing.
+func ApplyPatchFiles(rootDir string) error {
+	if rootDir == "" {
+		return fmt.Errorf("[ERROR] Root directory path cannot be empty")
+	}
+
+	type patchFile struct {
+		path   string
+		target string
+		node   *yaml.Node
+	}
+	var patches []patchFile
+
+	errWalk := filepath.WalkDir(rootDir, func(path string, d os.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return fmt.Errorf("[ERROR] Unable to access path '%s': %w", path, walkErr)
+		}
+		if d.IsDir() || !HasAnySuffix(path, ".patch.yaml", ".patch.yml") {
+			return nil
+		}
+
+		patchNode, errRead := readYAMLDocument(path)
+		if errRead != nil {
+			return errRead
+		}
+		target, errTarget := ResolvePatchTarget(path, patchNode)
+		if errTarget != nil {
+			return errTarget
+		}
+		removePatchTargetKey(patchNode)
+		patches = append(patches, patchFile{path: path, target: target, node: patchNode})
+		return nil
+	})
+	if errWalk != nil {
+		return errWalk
+	}
+
+	for _, patch := range patches {
+		baseNode, errRead := readYAMLDocument(patch.target)
+		if errRead != nil {
+			return errRead
+		}
+		mergedNode := &yaml.Node{
+			Kind:    yaml.DocumentNode,
+			Content: []*yaml.Node{MergePatchMapping(baseNode.Content[0], patch.node.Content[0])},
+		}
+
+		var buffer bytes.Buffer
+		yamlEncoder := yaml.NewEncoder(&buffer)
+		yamlEncoder.SetIndent(2)
+		if errEncode := yamlEncoder.Encode(mergedNode); errEncode != nil {
+			return fmt.Errorf("[ERROR] Failed to encode merged YAML of %s: %w", patch.target, errEncode)
+		}
+		yamlEncoder.Close()
+
+		if errWrite := common.WriteFileAtomic(patch.target, buffer.Bytes(), config.PermissionFile); errWrite != nil {
+			return fmt.Errorf("[ERROR] Failed to write patched YAML to %s: %w", patch.target, errWrite)
+		}
+		if errRemove := os.Remove(patch.path); errRemove != nil {
+			return fmt.Errorf("[ERROR] Failed to remove patch file %s: %w", patch.path, errRemove)
+		}
+		common.Logger("info", "Applied patch file %s to %s", patch.path, patch.target)
+	}
+
+	if len(patches) == 0 {
+		common.Logger("info", "No patch files found in %s", rootDir)
+	}
+	return nil
+}
+
+// NormalizeYAML parses all documents of the YAML data and re-encodes them with
+// consistent indentation (2 spaces) and sorted keys, dropping comments.
+// This is used to compare YAML content ignoring cosmetic formatting differences.
+func NormalizeYAML(data []byte) (string, error) {
+	var buffer bytes.Buffer
+	yamlEncoder := yaml.NewEncoder(&buffer)
+	yamlEncoder.SetIndent(2)
+
+	yamlDecoder := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var document interface{}
+		errDecode := yamlDecoder.Decode(&document)
+		if errors.Is(errDecode, io.EOF) {
+			break
+		}
+		if errDecode != nil {
+			return "", fmt.Errorf("[ERROR] Failed to parse YAML: %w", errDecode)
+		}
+		if errEncode := yamlEncoder.Encode(document); errEncode != nil {
+			return "", fmt.Errorf("[ERROR] Failed to encode YAML: %w", errEncode)
+		}
+	}
+	yamlEncoder.Close()
+
+	return buffer.String(), nil
+}
+
+// documentStartMarker is the optional marker of the start of the first YAML document, kept by FormatYAML.
+const documentStartMarker = "---\n"
+
+// FormatYAML re-encodes all documents of the YAML data in the canonical style of the repository:
+// indentation of 2 spaces and no trailing whitespace. Unlike NormalizeYAML, the key order and the comments are kept,
+// so only the formatting changes. The '---' marker at the start of data is kept too.
+func FormatYAML(data []byte) ([]byte, error) {
+	return reencodeYAML(data, nil)
+}
+
+// reencodeYAML decodes all documents of the YAML data, calls transform (if not nil) for each document
+// and encodes them with indentation of 2 spaces. See FormatYAML.
+func reencodeYAML(data []byte, transform func(document *yaml.Node)) ([]byte, error) {
+	var buffer bytes.Buffer
+	if bytes.HasPrefix(data, []byte(documentStartMarker)) {
+		buffer.WriteString(documentStartMarker)
+	}
+	yamlEncoder := yaml.NewEncoder(&buffer)
+	yamlEncoder.SetIndent(2)
+
+	yamlDecoder := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var document yaml.Node
+		errDecode := yamlDecoder.Decode(&document)
+		if errors.Is(errDecode, io.EOF) {
+			break
+		}
+		if errDecode != nil {
+			return nil, fmt.Errorf("[ERROR] Failed to parse YAML: %w", errDecode)
+		}
+		if transform != nil {
+			transform(&document)
+		}
+		if errEncode := yamlEncoder.Encode(&document); errEncode != nil {
+			return nil, fmt.Errorf("[ERROR] Failed to encode YAML: %w", errEncode)
+		}
+	}
+	yamlEncoder.Close()
+
+	return buffer.Bytes(), nil
+}
+
+// CanonicalizeYAML rewrites a YAML file in its canonical form: the format of FormatYAML, with the top-level keys
+// of each document sorted by config.K8sYamlManifestsPreferredKeyOrder. The other keys follow the preferred ones,
+// in their original order, and the comments are kept. The file is written atomically and only if it changes,
+// so running it twice produces identical bytes.
+func CanonicalizeYAML(filePath string) error {
+	info, errStat := os.Stat(filePath)
+	if errStat != nil {
+		return fmt.Errorf("[ERROR] Could not access file %s: %w", filePath, errStat)
+	}
+	yamlData, errRead := os.ReadFile(filePath)
+	if errRead != nil {
+		return fmt.Errorf("[ERROR] Could not read file %s: %w", filePath, errRead)
+	}
+	canonical, errFormat := reencodeYAML(yamlData, sortTopLevelKeys)
+	if errFormat != nil {
+		return fmt.Errorf("[ERROR] Failed to canonicalize file %s: %w", filePath, errFormat)
+	}
+	if bytes.Equal(yamlData, canonical) {
+		common.Logger("debug", "YAML file already canonical: %s", filePath)
+		return nil
+	}
+	if errWrite := common.WriteFileAtomic(filePath, canonical, info.Mode().Perm()); errWrite != nil {
+		return fmt.Errorf("[ERROR] Failed to write canonical file %s: %w", filePath, errWrite)
+	}
+	return nil
+}
+
+// sortTopLevelKeys sorts the keys of the root mapping of a document by config.K8sYamlManifestsPreferredKeyOrder.
+// The keys not listed keep their original order, after the listed ones.
+func sortTopLevelKeys(document *yaml.Node) {
+	if document.Kind != yaml.DocumentNode || len(document.Content) == 0 || document.Content[0].Kind != yaml.MappingNode {
+		return
+	}
+	mapping := document.Content[0]
+
+	type keyValue struct{ key, value *yaml.Node }
+	var pairs []keyValue
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		pairs = append(pairs, keyValue{mapping.Content[i], mapping.Content[i+1]})
+	}
+	keyRank := func(pair keyValue) int {
+		if rank := slices.Index(config.K8sYamlManifestsPreferredKeyOrder, pair.key.Value); rank >= 0 {
+			return rank
+		}
+		return len(config.K8sYamlManifestsPreferredKeyOrder)
+	}
+	slices.SortStableFunc(pairs, func(a, b keyValue) int {
+		return keyRank(a) - keyRank(b)
+	})
+
+	mapping.Content = mapping.Content[:0]
+	for _, pair := range pairs {
+		mapping.Content = append(mapping.Content, pair.key, pair.value)
+	}
+}
+
+// CheckYAMLStyle walks the YAML files (including patch files) of rootDir and its subdirectories and compares each file
+// with its canonical format (see FormatYAML). It returns the number of checked files and the paths (relative to rootDir)
+// of files not canonically formatted. If fix is true, those files are rewritten with the canonical format.
+func CheckYAMLStyle(rootDir string, fix bool) (int, []string, error) {
+	files, errList := listYAMLFiles(rootDir)
+	if errList != nil {
+		return 0, nil, errList
+	}
+
+	var unformatted []string
+	for _, relPath := range files {
+		filePath := filepath.Join(rootDir, relPath)
+		info, errStat := os.Stat(filePath)
+		if errStat != nil {
+			return 0, nil, fmt.Errorf("[ERROR] Could not access file %s: %w", filePath, errStat)
+		}
+		yamlData, errRead := os.ReadFile(filePath)
+		if errRead != nil {
+			return 0, nil, fmt.Errorf("[ERROR] Could not read file %s: %w", filePath, errRead)
+		}
+		formatted, errFormat := FormatYAML(yamlData)
+		if errFormat != nil {
+			return 0, nil, fmt.Errorf("[ERROR] Failed to format file %s: %w", filePath, errFormat)
+		}
+		if bytes.Equal(yamlData, formatted) {
+			continue
+		}
+
+		unformatted = append(unformatted, relPath)
+		if fix {
+			if errWrite := common.WriteFileAtomic(filePath, formatted, info.Mode().Perm()); errWrite != nil {
+				return 0, nil, fmt.Errorf("[ERROR] Failed to write formatted file %s: %w", filePath, errWrite)
+			}
+			common.Logger("debug", "YAML file formatted: %s", filePath)
+		}
+	}
+	return len(files), unformatted, nil
+}
+
+// CheckYAMLTrailingNewline walks the YAML files (including patch files) of rootDir and its subdirectories and returns
+// the number of checked files and the paths (relative to rootDir) of files without a trailing newline, as required by POSIX.
+// The empty files are ignored. If fix is true, a newline is appended to those files, without changing the rest of content.
+func CheckYAMLTrailingNewline(rootDir string, fix bool) (int, []string, error) {
+	files, errList := listYAMLFiles(rootDir)
+	if errList != nil {
+		return 0, nil, errList
+	}
+
+	var missing []string
+	for _, relPath := range files {
+		filePath := filepath.Join(rootDir, relPath)
+		info, errStat := os.Stat(filePath)
+		if errStat != nil {
+			return 0, nil, fmt.Errorf("[ERROR] Could not access file %s: %w", filePath, errStat)
+		}
+		yamlData, errRead := os.ReadFile(filePath)
+		if errRead != nil {
+			return 0, nil, fmt.Errorf("[ERROR] Could not read file %s: %w", filePath, errRead)
+		}
+		if len(yamlData) == 0 || bytes.HasSuffix(yamlData, []byte("\n")) {
+			continue
+		}
+
+		missing = append(missing, relPath)
+		if fix {
+			if errWrite := common.WriteFileAtomic(filePath, append(yamlData, '\n'), info.Mode().Perm()); errWrite != nil {
+				return 0, nil, fmt.Errorf("[ERROR] Failed to write file %s: %w", filePath, errWrite)
+			}
+			common.Logger("debug", "Trailing newline appended to YAML file: %s", filePath)
+		}
+	}
+	return len(files), missing, nil
+}
+
+// NormalizeYAMLFile reads a YAML file and returns its normalized content. See NormalizeYAML.
+func NormalizeYAMLFile(filePath string) (string, error) {
+	yamlData, errRead := os.ReadFile(filePath)
+	if errRead != nil {
+		return "", fmt.Errorf("[ERROR] Could not read file %s: %w", filePath, errRead)
+	}
+	normalized, errNormalize := NormalizeYAML(yamlData)
+	if errNormalize != nil {
+		return "", fmt.Errorf("[ERROR] Failed to normalize file %s: %w", filePath, errNormalize)
+	}
+	return normalized, nil
+}
+
+// ComputeYAMLChecksum returns the SHA-256 digest (hex) of the YAML files (including patch files) of rootDir
+// and its subdirectories, and the number of files. The files are sorted by relative path and normalized
+// (see NormalizeYAML), so the digest is stable across cosmetic formatting changes, like indentation and key order.
+// The relative paths are hashed too, so renamed, added and removed files change the digest.
+func ComputeYAMLChecksum(rootDir string) (string, int, error) {
+	files, errList := listYAMLFiles(rootDir)
+	if errList != nil {
+		return "", 0, errList
+	}
+
+	hash := sha256.New()
+	for _, relPath := range files {
+		normalized, errNormalize := NormalizeYAMLFile(filepath.Join(rootDir, relPath))
+		if errNormalize != nil {
+			return "", 0, errNormalize
+		}
+		// The path and the content are separated by NUL, that isn't valid in both
+		fmt.Fprintf(hash, "%s\x00%s\x00", filepath.ToSlash(relPath), normalized)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), len(files), nil
+}
+
+// DiffYAMLFiles normalizes both YAML files (see NormalizeYAML) and returns the differences
+// line by line. Lines only in filePath1 start with '-' and lines only in filePath2 start with '+'.
+// An empty string is returned when the files are semantically equal.
+func DiffYAMLFiles(filePath1, filePath2 string) (string, error) {
+	normalized1, errNormalize1 := NormalizeYAMLFile(filePath1)
+	if errNormalize1 != nil {
+		return "", errNormalize1
+	}
+	normalized2, errNormalize2 := NormalizeYAMLFile(filePath2)
+	if errNormalize2 != nil {
+		return "", errNormalize2
+	}
+
+	if normalized1 == normalized2 {
+		return "", nil
+	}
+
+	diff := DiffLines(strings.Split(strings.TrimSuffix(normalized1, "\n"), "\n"), strings.Split(strings.TrimSuffix(normalized2, "\n"), "\n"))
+	return fmt.Sprintf("--- %s\n+++ %s\n%s", filePath1, filePath2, diff), nil
+}
+
+// YAMLDirDiff is the drift between two directories of YAML files. See DiffYAMLDirs.
+// The paths are relative to the directories and sorted.
+type YAMLDirDiff struct {
+	Added   []string       // Files only in the right directory
+	Removed []string       // Files only in the left directory
+	Changed []YAMLFileDiff // Files in both directories with semantic differences
+}
+
+// YAMLFileDiff is the difference of a YAML file in both directories. See DiffYAMLFiles.
+type YAMLFileDiff struct {
+	Path string
+	Diff string
+}
+
+// HasDrift returns true if any file was added, removed or changed.
+func (d *YAMLDirDiff) HasDrift() bool {
+	return len(d.Added) > 0 || len(d.Removed) > 0 || len(d.Changed) > 0
+}
+
+// DiffYAMLDirs walks both directory trees and compares their YAML files (including patch files)
+// by relative path. The files in both trees are normalized and compared by DiffYAMLFiles,
+// so cosmetic formatting differences are ignored. Non-YAML files are skipped.
+func DiffYAMLDirs(leftDir, rightDir string) (*YAMLDirDiff, error) {
+	leftFiles, errLeft := listYAMLFiles(leftDir)
+	if errLeft != nil {
+		return nil, errLeft
+	}
+	rightFiles, errRight := listYAMLFiles(rightDir)
+	if errRight != nil {
+		return nil, errRight
+	}
+
+	dirDiff := &YAMLDirDiff{}
+	for _, relPath := range leftFiles {
+		if !slices.Contains(rightFiles, relPath) {
+			dirDiff.Removed = append(dirDiff.Removed, relPath)
+			continue
+		}
+		diff, errDiff := DiffYAMLFiles(filepath.Join(leftDir, relPath), filepath.Join(rightDir, relPath))
+		if errDiff != nil {
+			return nil, errDiff
+		}
+		if diff != "" {
+			dirDiff.Changed = append(dirDiff.Changed, YAMLFileDiff{Path: relPath, Diff: diff})
+		}
+	}
+	for _, relPath := range rightFiles {
+		if !slices.Contains(leftFiles, relPath) {
+			dirDiff.Added = append(dirDiff.Added, relPath)
+		}
+	}
+	return dirDiff, nil
+}
+
+// CombineYAMLFiles writes the documents of YAML files (except patch files, see IsYAMLFile) of rootDir and its
+// subdirectories to outputPath, as a single multi-document file separated by '---'. The files are read in sorted order
+// of their paths and each document is re-encoded with indentation of 2 spaces. The empty documents are skipped and
+// outputPath is never combined into itself, when it's under rootDir. The output file is written atomically.
+func CombineYAMLFiles(rootDir, outputPath string) error {
+	files, errList := listYAMLFiles(rootDir)
+	if errList != nil {
+		return errList
+	}
+	absOutputPath, errAbs := filepath.Abs(outputPath)
+	if errAbs != nil {
+		return fmt.Errorf("[ERROR] Failed to get absolute path of '%s': %w", outputPath, errAbs)
+	}
+
+	var buffer bytes.Buffer
+	yamlEncoder := yaml.NewEncoder(&buffer)
+	yamlEncoder.SetIndent(2)
+	combinedFiles, combinedDocuments := 0, 0
+	for _, relPath := range files {
+		filePath := filepath.Join(rootDir, relPath)
+		if absPath, _ := filepath.Abs(filePath); absPath == absOutputPath || !IsYAMLFile(filePath) {
+			continue
+		}
+
+		yamlData, errRead := os.ReadFile(filePath)
+		if errRead != nil {
+			return fmt.Errorf("[ERROR] Could not read file %s: %w", filePath, errRead)
+		}
+		yamlDecoder := yaml.NewDecoder(bytes.NewReader(yamlData))
+		for {
+			var document yaml.Node
+			errDecode := yamlDecoder.Decode(&document)
+			if errors.Is(errDecode, io.EOF) {
+				break
+			}
+			if errDecode != nil {
+				return fmt.Errorf("[ERROR] Failed to parse YAML file %s: %w", filePath, errDecode)
+			}
+			if len(document.Content) == 0 || isNullNode(document.Content[0]) {
+				continue
+			}
+			if errEncode := yamlEncoder.Encode(&document); errEncode != nil {
+				return fmt.Errorf("[ERROR] Failed to encode YAML of file %s: %w", filePath, errEncode)
+			}
+			combinedDocuments++
+		}
+		combinedFiles++
+	}
+	yamlEncoder.Close()
+
+	if errMkdir := os.MkdirAll(filepath.Dir(outputPath), config.PermissionDir); errMkdir != nil {
+		return fmt.Errorf("[ERROR] Failed to create directory of %s: %w", outputPath, errMkdir)
+	}
+	if errWrite := common.WriteFileAtomic(outputPath, buffer.Bytes(), config.PermissionFile); errWrite != nil {
+		return fmt.Errorf("[ERROR] Failed to write combined file %s: %w", outputPath, errWrite)
+	}
+	common.Logger("info", "Combined %d document(s) of %d file(s) into: %s", combinedDocuments, combinedFiles, outputPath)
+	return nil
+}
+
+// splitFileNameRegex matches the characters replaced by '-' in the filenames of SplitYAMLFile function.
+var splitFileNameRegex = regexp.MustCompile(`[^a-z0-9.-]+`)
+
+// SplitYAMLFile writes each document of a multi-document YAML file to its own file in outputDir, named by the kind
+// and metadata.name of document in lowercase (e.g. deployment-app.yaml). The documents without name are named by the
+// kind (or 'document') and their position in the input file (e.g. document-3.yaml), as are the documents whose
+// name was already used. Each document is re-encoded with indentation of 2 spaces and written atomically.
+// The empty documents are skipped.
+func SplitYAMLFile(inputPath, outputDir string) error {
+	yamlData, errRead := os.ReadFile(inputPath)
+	if errRead != nil {
+		return fmt.Errorf("[ERROR] Could not read file %s: %w", inputPath, errRead)
+	}
+	if errMkdir := os.MkdirAll(outputDir, config.PermissionDir); errMkdir != nil {
+		return fmt.Errorf("[ERROR] Failed to create directory %s: %w", outputDir, errMkdir)
+	}
+
+	usedNames := map[string]bool{}
+	yamlDecoder := yaml.NewDecoder(bytes.NewReader(yamlData))
+	for index := 1; ; index++ {
+		var document yaml.Node
+		errDecode := yamlDecoder.Decode(&document)
+		if errors.Is(errDecode, io.EOF) {
+			break
+		}
+		if errDecode != nil {
+			return fmt.Errorf("[ERROR] Failed to parse YAML file %s: %w", inputPath, errDecode)
+		}
+		if len(document.Content) == 0 || isNullNode(document.Content[0]) {
+			continue
+		}
+
+		fileName := splitDocumentFileName(&document, index)
+		if usedNames[fileName] {
+			fileName = strings.TrimSuffix(fileName, ".yaml") + "-" + strconv.Itoa(index) + ".yaml"
+		}
+		usedNames[fileName] = true
+
+		var buffer bytes.Buffer
+		yamlEncoder := yaml.NewEncoder(&buffer)
+		yamlEncoder.SetIndent(2)
+		if errEncode := yamlEncoder.Encode(&document); errEncode != nil {
+			return fmt.Errorf("[ERROR] Failed to encode document %d of file %s: %w", index, inputPath, errEncode)
+		}
+		yamlEncoder.Close()
+
+		filePath := filepath.Join(outputDir, fileName)
+		if errWrite := common.WriteFileAtomic(filePath, buffer.Bytes(), config.PermissionFile); errWrite != nil {
+			return fmt.Errorf("[ERROR] Failed to write file %s: %w", filePath, errWrite)
+		}
+		common.Logger("info", "Document %d written to: %s", index, filePath)
+	}
+	return nil
+}
+
+// splitDocumentFileName returns the filename of a document split by SplitYAMLFile function.
+func splitDocumentFileName(document *yaml.Node, index int) string {
+	root := ConvertMappingNodeToMap(document.Content[0])
+	var kind, name string
+	if kindNode, ok := root["kind"]; ok && kindNode.Kind == yaml.ScalarNode {
+		kind = kindNode.Value
+	}
+	if metadataNode, ok := root["metadata"]; ok {
+		if nameNode, ok := ConvertMappingNodeToMap(metadataNode)["name"]; ok && nameNode.Kind == yaml.ScalarNode {
+			name = nameNode.Value
+		}
+	}
+
+	sanitize := func(value string) string {
+		return strings.Trim(splitFileNameRegex.ReplaceAllString(strings.ToLower(value), "-"), "-.")
+	}
+	kind, name = sanitize(kind), sanitize(name)
+	switch {
+	case kind != "" && name != "":
+		return kind + "-" + name + ".yaml"
+	case kind != "":
+		return kind + "-" + strconv.Itoa(index) + ".yaml"
+	}
+	return "document-" + strconv.Itoa(index) + ".yaml"
+}
+
+// listYAMLFiles returns the sorted paths of YAML files (including patch files) under rootDir, relative to it.
+func listYAMLFiles(rootDir string) ([]string, error) {
+	info, errStat := os.Stat(rootDir)
+	if errStat != nil {
+		return nil, fmt.Errorf("[ERROR] Failed to access directory '%s': %w", rootDir, errStat)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("[ERROR] '%s' is not a directory", rootDir)
+	}
+
+	var files []string
+	errWalk := filepath.WalkDir(rootDir, func(path string, d os.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return fmt.Errorf("[ERROR] Unable to access path '%s': %w", path, walkErr)
+		}
+		if d.IsDir() || !MatchYAMLFile(path, true) {
+			return nil
+		}
+		relPath, errRel := filepath.Rel(rootDir, path)
+		if errRel != nil {
+			return fmt.Errorf("[ERROR] Failed to get relative path of '%s': %w", path, errRel)
+		}
+		files = append(files, relPath)
+		return nil
+	})
+	if errWalk != nil {
+		return nil, errWalk
+	}
+	slices.Sort(files)
+	return files, nil
+}
+
+// DiffLines compares two lists of lines using the Myers diff algorithm (linear space variant)
+// and returns the differences. Unchanged lines start with ' ', removed lines with '-'
+// and added lines with '+'. The time is O((N+M)D) and the memory O(N+M), where D is the number
+// of changed lines, so large files with few changes (the usual case) are compared quickly.
+func DiffLines(lines1, lines2 []string) string {
+	differ := lineDiffer{lines1: lines1, lines2: lines2}
+	differ.compare(0, len(lines1), 0, len(lines2))
+	return differ.diff.String()
+}
+
+// lineDiffer keeps the state of DiffLines: the compared lines and the differences written in order.
+type lineDiffer struct {
+	lines1, lines2 []string
+	diff           strings.Builder
+}
+
+// compare writes the differences between lines1[low1:high1] and lines2[low2:high2].
+// The common prefix and suffix are written as unchanged and the remaining lines are split
+// by the middle snake (see middleSnake) and compared recursively.
+func (d *lineDiffer) compare(low1, high1, low2, high2 int) {
+	for low1 < high1 && low2 < high2 && d.lines1[low1] == d.lines2[low2] {
+		d.diff.WriteString(" " + d.lines1[low1] + "\n")
+		low1++
+		low2++
+	}
+	suffix := 0
+	for low1 < high1-suffix && low2 < high2-suffix && d.lines1[high1-suffix-1] == d.lines2[high2-suffix-1] {
+		suffix++
+	}
+	high1, high2 = high1-suffix, high2-suffix
+
+	switch {
+	case low1 == high1:
+		for ; low2 < high2; low2++ {
+			d.diff.WriteString("+" + d.lines2[low2] + "\n")
+		}
+	case low2 == high2:
+		for ; low1 < high1; low1++ {
+			d.diff.WriteString("-" + d.lines1[low1] + "\n")
+		}
+	default:
+		// Both ranges aren't empty and differ in the first and last lines, so there are at least
+		// 2 changes and the middle snake splits them in smaller problems
+		x, y, u, v := middleSnake(d.lines1[low1:high1], d.lines2[low2:high2])
+		d.compare(low1, low1+x, low2, low2+y)
+		for i := x; i < u; i++ {
+			d.diff.WriteString(" " + d.lines1[low1+i] + "\n")
+		}
+		d.compare(low1+u, high1, low2+v, high2)
+	}
+
+	for i := high1; i < high1+suffix; i++ {
+		d.diff.WriteString(" " + d.lines1[i] + "\n")
+	}
+}
+
+// middleSnake returns the start (x, y) and the end (u, v) of the middle snake (a sequence of equal lines)
+// of a shortest edit script between lines1 and lines2, searching forward from the beginning and backward
+// from the end at the same time until the paths overlap. See "An O(ND) Difference Algorithm and Its Variations"
+// (Eugene W. Myers, 1986).
+func middleSnake(lines1, lines2 []string) (x, y, u, v int) {
+	n, m := len(lines1), len(lines2)
+	delta := n - m
+	odd := delta%2 != 0
+	maxD := (n + m + 1) / 2
+	offset := maxD + 1
+	// forward[k] is the furthest x of forward paths on diagonal k (x - y) and backward[k] is the furthest
+	// x of backward paths on diagonal k, in reversed coordinates (from the end of lines)
+	forward := make([]int, 2*offset+1)
+	backward := make([]int, 2*offset+1)
+
+	for d := 0; d <= maxD; d++ {
+		for k := -d; k <= d; k += 2 {
+			var startX int
+			if k == -d || (k != d && forward[offset+k-1] < forward[offset+k+1]) {
+				startX = forward[offset+k+1]
+			} else {
+				startX = forward[offset+k-1] + 1
+			}
+			startY := startX - k
+			endX, endY := startX, startY
+			for endX < n && endY < m && lines1[endX] == lines2[endY] {
+				endX++
+				endY++
+			}
+			forward[offset+k] = endX
+
+			// The backward diagonal of forward diagonal k is delta - k
+			if reverseK := delta - k; odd && reverseK >= -(d-1) && reverseK <= d-1 && endX >= n-backward[offset+reverseK] {
+				return startX, startY, endX, endY
+			}
+		}
+
+		for k := -d; k <= d; k += 2 {
+			var startX int
+			if k == -d || (k != d && backward[offset+k-1] < backward[offset+k+1]) {
+				startX = backward[offset+k+1]
+			} else {
+				startX = backward[offset+k-1] + 1
+			}
+			startY := startX - k
+			endX, endY := startX, startY
+			for endX < n && endY < m && lines1[n-endX-1] == lines2[m-endY-1] {
+				endX++
+				endY++
+			}
+			backward[offset+k] = endX
+
+			if forwardK := delta - k; !odd && forwardK >= -d && forwardK <= d && forward[offset+forwardK] >= n-endX {
+				return n - endX, m - endY, n - startX, m - startY
+			}
+		}
+	}
+	// Unreachable: the paths always overlap until maxD
+	return 0, 0, n, m
+}
+
+// SubstituteEnvVars replaces the ${VAR} and $VAR placeholders in-place in a file with the
+// values of environment variables, like envsubst command. The '$$' escapes are not touched.
+// Unset variables are replaced with empty string, unless strict is true. In strict mode, an error
+// listing all unset variables is returned and the file is not changed.
+func SubstituteEnvVars(filePath string, strict bool) error {
+	fileInfo, errStat := os.Stat(filePath)
+	if errStat != nil {
+		return fmt.Errorf("[ERROR] Failed to access file '%s': %w", filePath, errStat)
+	}
+	content, errRead := os.ReadFile(filePath)
+	if errRead != nil {
+		return fmt.Errorf("[ERROR] Failed to read file '%s': %w", filePath, errRead)
+	}
+
+	substituted, unsetVars := SubstituteEnvVarsInString(string(content))
+	if strict && len(unsetVars) > 0 {
+		return fmt.Errorf("[ERROR] Environment variables referenced in '%s' are not set: %s", filePath, strings.Join(unsetVars, ", "))
+	}
+	for _, unsetVar := range unsetVars {
+		common.Logger("warning", "Environment variable '%s' referenced in '%s' is not set. Replaced with empty string.", unsetVar, filePath)
+	}
+
+	if errWrite := common.WriteFileAtomic(filePath, []byte(substituted), fileInfo.Mode().Perm()); errWrite != nil {
+		return fmt.Errorf("[ERROR] Failed to write file '%s': %w", filePath, errWrite)
+	}
+	common.Logger("debug", "Successfully substituted environment variables in: %s", filePath)
+	return nil
+}
+
+// SubstituteEnvVarsInString replaces the ${VAR} and $VAR placeholders in content with the values of
+// environment variables. It returns the substituted content and the unset variables (in order of first reference).
+// The '$$' escapes and '$' not followed by a valid variable name are not touched.
+func SubstituteEnvVarsInString(content string) (string, []string) {
+	var result strings.Builder
+	var unsetVars []string
+	seenUnsetVars := map[string]bool{}
+
+	isNameChar := func(c byte, first bool) bool {
+		return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (!first && c >= '0' && c <= '9')
+	}
+	lookup := func(name string) string {
+		value, exists := os.LookupEnv(name)
+		if !exists && !seenUnsetVars[name] {
+			seenUnsetVars[name] = true
+			unsetVars = append(unsetVars, name)
+		}
+		return value
+	}
+
+	for i := 0; i < len(content); i++ {
+		if content[i] != '$' || i+1 >= len(content) {
+			result.WriteByte(content[i])
+			continue
+		}
+
+		next := content[i+1]
+		switch {
+		case next == '$':
+			// Escaped '$$' is kept as is
+			result.WriteString("$$")
+			i++
+		case next == '{':
+			end := strings.IndexByte(content[i+2:], '}')
+			name := ""
+			if end > 0 {
+				name = content[i+2 : i+2+end]
+			}
+			valid := name != ""
+			for j := 0; j < len(name) && valid; j++ {
+				valid = isNameChar(name[j], j == 0)
+			}
+			if !valid {
+				result.WriteByte(content[i])
+				continue
+			}
+			result.WriteString(lookup(name))
+			i += 2 + end
+		case isNameChar(next, true):
+			end := i + 1
+			for end < len(content) && isNameChar(content[end], false) {
+				end++
+			}
+			result.WriteString(lookup(content[i+1 : end]))
+			i = end - 1
+		default:
+			result.WriteByte(content[i])
+		}
+	}
+
+	return result.String(), unsetVars
+}
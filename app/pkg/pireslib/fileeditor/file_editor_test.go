@@ -0,0 +1,1238 @@
+package fileeditor
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/aeciopires/pires-cli/internal/config"
+	"gopkg.in/yaml.v3"
+)
+
+// writeTestFile writes the content to a file of the directory and returns its path
+func writeTestFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	filePath := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(filePath), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filePath, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return filePath
+}
+
+// existingValuesYAML is a customized file on disk and templateValuesYAML is the new version of its template
+const (
+	existingValuesYAML = `replicaCount: 3
+image:
+  repository: registry.example.com/app
+  tag: "1.0.0"
+resources:
+  limits:
+    memory: 1Gi
+hosts:
+  - app.example.com
+`
+	templateValuesYAML = `replicaCount: 1
+image:
+  repository: registry.example.com/app
+  tag: "2.0.0"
+  pullPolicy: IfNotPresent
+resources:
+  limits:
+    memory: 512Mi
+    cpu: 500m
+hosts:
+  - app.example.com
+  - api.example.com
+serviceAccount:
+  create: true
+`
+)
+
+func TestMergeYAMLFilesPreferExisting(t *testing.T) {
+	dir := t.TempDir()
+	existing := writeTestFile(t, dir, "existing.yaml", existingValuesYAML)
+	template := writeTestFile(t, dir, "template.yaml", templateValuesYAML)
+
+	merged, err := MergeYAMLFiles(existing, template, true, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The keys of config.K8sYamlManifestsPreferredKeyOrder (resources) come first, then the other keys sorted
+	want := `resources:
+  limits:
+    memory: 1Gi
+    cpu: 500m
+hosts:
+  - app.example.com
+  - api.example.com
+image:
+  repository: registry.example.com/app
+  tag: "1.0.0"
+  pullPolicy: IfNotPresent
+replicaCount: 3
+serviceAccount:
+  create: true
+`
+	if merged != want {
+		t.Errorf("merged =\n%s\nwant\n%s", merged, want)
+	}
+}
+
+func TestMergeYAMLFilesTemplateWins(t *testing.T) {
+	dir := t.TempDir()
+	existing := writeTestFile(t, dir, "existing.yaml", existingValuesYAML)
+	template := writeTestFile(t, dir, "template.yaml", templateValuesYAML)
+
+	merged, err := MergeYAMLFiles(existing, template, false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The template values win only in the keys of config.K8sYamlManifestsPreferredKeyOrder (resources).
+	// The other keys existing in both files keep the existing values, and the new keys are added.
+	want := `resources:
+  limits:
+    memory: 512Mi
+    cpu: 500m
+hosts:
+  - app.example.com
+image:
+  repository: registry.example.com/app
+  tag: "1.0.0"
+replicaCount: 3
+serviceAccount:
+  create: true
+`
+	if merged != want {
+		t.Errorf("merged =\n%s\nwant\n%s", merged, want)
+	}
+}
+
+func TestMergeYAMLFilesPreferredKeyOrder(t *testing.T) {
+	dir := t.TempDir()
+	existing := writeTestFile(t, dir, "existing.yaml", "spec:\n  replicas: 2\nkind: Deployment\napiVersion: apps/v1\n")
+	template := writeTestFile(t, dir, "template.yaml", "metadata:\n  name: app\nspec:\n  replicas: 1\n  paused: false\n")
+
+	merged, err := MergeYAMLFiles(existing, template, true, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: app\nspec:\n  replicas: 2\n  paused: false\n"
+	if merged != want {
+		t.Errorf("merged =\n%s\nwant\n%s", merged, want)
+	}
+}
+
+func TestMergeYAMLFilesStrict(t *testing.T) {
+	dir := t.TempDir()
+	// 'resources' is a scalar in the first file and a mapping in the second
+	first := writeTestFile(t, dir, "first.yaml", "spec:\n  template:\n    resources: small\n")
+	second := writeTestFile(t, dir, "second.yaml", "spec:\n  template:\n    resources:\n      cpu: 100m\n")
+
+	_, err := MergeYAMLFiles(first, second, false, true)
+	if err == nil || !strings.Contains(err.Error(), "spec.template.resources") || !strings.Contains(err.Error(), "scalar in the first file and mapping in the second file") {
+		t.Fatalf("error = %v, want type mismatch of spec.template.resources", err)
+	}
+
+	// Lenient mode keeps the value of second file
+	merged, err := MergeYAMLFiles(first, second, false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(merged, "cpu: 100m") {
+		t.Errorf("merged = %s, want the mapping of second file", merged)
+	}
+}
+
+func TestMergeYAMLFilesStrictNullCompatible(t *testing.T) {
+	dir := t.TempDir()
+	first := writeTestFile(t, dir, "first.yaml", "spec:\n  resources:\n")
+	second := writeTestFile(t, dir, "second.yaml", "spec:\n  resources:\n    cpu: 100m\n")
+
+	if _, err := MergeYAMLFiles(first, second, false, true); err != nil {
+		t.Fatalf("null values must be compatible with any kind: %v", err)
+	}
+}
+
+// scalarSequence returns a YAML sequence node with the scalar values
+func scalarSequence(values ...string) *yaml.Node {
+	sequence := &yaml.Node{Kind: yaml.SequenceNode}
+	for _, value := range values {
+		sequence.Content = append(sequence.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value})
+	}
+	return sequence
+}
+
+// sequenceValues returns the values of the scalar items of a YAML sequence node
+func sequenceValues(sequence *yaml.Node) []string {
+	values := make([]string, 0, len(sequence.Content))
+	for _, item := range sequence.Content {
+		values = append(values, item.Value)
+	}
+	return values
+}
+
+func TestMergeArraysUniquelyPreservesOrder(t *testing.T) {
+	array1 := scalarSequence("--port=8080", "--verbose", "--log-level=info")
+	array2 := scalarSequence("--metrics", "--verbose", "--port=8080", "--debug")
+
+	got := sequenceValues(MergeArraysUniquely(array1, array2))
+	want := []string{"--port=8080", "--verbose", "--log-level=info", "--metrics", "--debug"}
+	if !slices.Equal(got, want) {
+		t.Errorf("MergeArraysUniquely() = %v, want %v", got, want)
+	}
+}
+
+func TestMergeArraysUniquelyScalarTags(t *testing.T) {
+	// The string "1" and the integer 1 are different items
+	array1 := &yaml.Node{Kind: yaml.SequenceNode, Content: []*yaml.Node{{Kind: yaml.ScalarNode, Tag: "!!int", Value: "1"}}}
+	array2 := scalarSequence("1")
+
+	if got := len(MergeArraysUniquely(array1, array2).Content); got != 2 {
+		t.Errorf("merged items = %d, want 2", got)
+	}
+}
+
+func TestMergeArraysUniquelyMappings(t *testing.T) {
+	var array1, array2 yaml.Node
+	if err := yaml.Unmarshal([]byte("- name: a\n  value: \"1\"\n- name: b\n"), &array1); err != nil {
+		t.Fatal(err)
+	}
+	if err := yaml.Unmarshal([]byte("- name: b\n- name: c\n"), &array2); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := len(MergeArraysUniquely(array1.Content[0], array2.Content[0]).Content); got != 3 {
+		t.Errorf("merged items = %d, want 3", got)
+	}
+}
+
+// benchmarkScalarSequences returns two sequences of n scalars, with half of items in both
+func benchmarkScalarSequences(n int) (*yaml.Node, *yaml.Node) {
+	values1, values2 := make([]string, n), make([]string, n)
+	for i := range n {
+		values1[i] = fmt.Sprintf("--flag-%d=value", i)
+		values2[i] = fmt.Sprintf("--flag-%d=value", i+n/2)
+	}
+	return scalarSequence(values1...), scalarSequence(values2...)
+}
+
+// BenchmarkMergeArraysUniquelyScalars compares the items of scalar sequences without encoding them
+func BenchmarkMergeArraysUniquelyScalars(b *testing.B) {
+	array1, array2 := benchmarkScalarSequences(1000)
+	b.ResetTimer()
+	for range b.N {
+		MergeArraysUniquely(array1, array2)
+	}
+}
+
+// BenchmarkMergeArraysUniquelyEncoded compares the items of scalar sequences by their YAML encoding (previous path)
+func BenchmarkMergeArraysUniquelyEncoded(b *testing.B) {
+	array1, array2 := benchmarkScalarSequences(1000)
+	b.ResetTimer()
+	for range b.N {
+		mergeArraysUniquely(array1, array2, encodeArrayItem)
+	}
+}
+
+func TestDiffYAMLFilesIgnoresFormatting(t *testing.T) {
+	dir := t.TempDir()
+	file1 := writeTestFile(t, dir, "a.yaml", "image:\n    tag: '1.0.0'\n    repository: app\nreplicas: 1\n")
+	file2 := writeTestFile(t, dir, "b.yaml", "image: {tag: \"1.0.0\", repository: app}\n# comment\nreplicas: 1\n")
+	file3 := writeTestFile(t, dir, "c.yaml", "image:\n  tag: '2.0.0'\n  repository: app\nreplicas: 1\n")
+
+	if diff, err := DiffYAMLFiles(file1, file2); err != nil || diff != "" {
+		t.Fatalf("DiffYAMLFiles of equivalent files = %q, %v, want no diff", diff, err)
+	}
+	diff, err := DiffYAMLFiles(file1, file3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(diff, "-  tag: 1.0.0\n") || !strings.Contains(diff, "+  tag: 2.0.0\n") || strings.Contains(diff, "-replicas") {
+		t.Errorf("unexpected diff:\n%s", diff)
+	}
+}
+
+// applyDiff rebuilds both sides of a DiffLines result and counts its changed lines
+func applyDiff(diff string) (lines1, lines2 []string, changes int) {
+	for _, line := range strings.Split(strings.TrimSuffix(diff, "\n"), "\n") {
+		if line == "" {
+			continue
+		}
+		switch line[0] {
+		case ' ':
+			lines1 = append(lines1, line[1:])
+			lines2 = append(lines2, line[1:])
+		case '-':
+			lines1 = append(lines1, line[1:])
+			changes++
+		case '+':
+			lines2 = append(lines2, line[1:])
+			changes++
+		}
+	}
+	return lines1, lines2, changes
+}
+
+// lcsLength is the reference length of the longest common subsequence of both lists of lines
+func lcsLength(lines1, lines2 []string) int {
+	previous := make([]int, len(lines2)+1)
+	for i := range lines1 {
+		current := make([]int, len(lines2)+1)
+		for j := range lines2 {
+			if lines1[i] == lines2[j] {
+				current[j+1] = previous[j] + 1
+			} else {
+				current[j+1] = max(previous[j+1], current[j])
+			}
+		}
+		previous = current
+	}
+	return previous[len(lines2)]
+}
+
+func TestDiffLines(t *testing.T) {
+	got := DiffLines([]string{"a", "b", "c", "d"}, []string{"a", "x", "c", "d", "e"})
+	want := " a\n-b\n+x\n c\n d\n+e\n"
+	if got != want {
+		t.Fatalf("DiffLines =\n%s\nwant\n%s", got, want)
+	}
+	if got := DiffLines(nil, nil); got != "" {
+		t.Errorf("DiffLines of empty lists = %q, want empty", got)
+	}
+}
+
+func TestDiffLinesShortestEditScript(t *testing.T) {
+	tests := [][2]string{
+		{"", "abc"},
+		{"abc", ""},
+		{"abcabba", "cbabac"},
+		{"abcdef", "fedcba"},
+		{"xaxbxcx", "abc"},
+		{"aaaa", "aa"},
+		{"abgdef", "gh"},
+		{"kubernetes", "kustomize"},
+		{"abcdefghij", "zabcdefghijz"},
+	}
+	for _, tt := range tests {
+		lines1, lines2 := strings.Split(tt[0], ""), strings.Split(tt[1], "")
+		diff := DiffLines(lines1, lines2)
+		got1, got2, changes := applyDiff(diff)
+		if !slices.Equal(got1, lines1) || !slices.Equal(got2, lines2) {
+			t.Errorf("DiffLines(%q, %q) doesn't rebuild the inputs:\n%s", tt[0], tt[1], diff)
+		}
+		if want := len(lines1) + len(lines2) - 2*lcsLength(lines1, lines2); changes != want {
+			t.Errorf("DiffLines(%q, %q) has %d changes, want %d", tt[0], tt[1], changes, want)
+		}
+	}
+}
+
+func TestDiffYAMLDirs(t *testing.T) {
+	left, right := t.TempDir(), t.TempDir()
+	writeTestFile(t, left, "same.yaml", "a: 1\n")
+	writeTestFile(t, right, "same.yaml", "a:   1\n")
+	writeTestFile(t, left, "apps/changed.yaml", "a: 1\n")
+	writeTestFile(t, right, "apps/changed.yaml", "a: 2\n")
+	writeTestFile(t, left, "removed.yml", "a: 1\n")
+	writeTestFile(t, right, "apps/added.yaml", "a: 1\n")
+	writeTestFile(t, right, "README.md", "not yaml\n")
+
+	dirDiff, err := DiffYAMLDirs(left, right)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !dirDiff.HasDrift() {
+		t.Fatal("expected drift")
+	}
+	if want := []string{filepath.Join("apps", "added.yaml")}; !slices.Equal(dirDiff.Added, want) {
+		t.Errorf("Added = %v, want %v", dirDiff.Added, want)
+	}
+	if want := []string{"removed.yml"}; !slices.Equal(dirDiff.Removed, want) {
+		t.Errorf("Removed = %v, want %v", dirDiff.Removed, want)
+	}
+	if len(dirDiff.Changed) != 1 || dirDiff.Changed[0].Path != filepath.Join("apps", "changed.yaml") {
+		t.Errorf("Changed = %+v, want apps/changed.yaml", dirDiff.Changed)
+	}
+}
+
+func BenchmarkDiffLinesLargeFile(b *testing.B) {
+	// A large file with a few changes, the usual case of the yaml diff commands
+	lines1 := make([]string, 50000)
+	for i := range lines1 {
+		lines1[i] = fmt.Sprintf("key%d: value%d", i, i)
+	}
+	lines2 := slices.Clone(lines1)
+	for i := 1000; i < len(lines2); i += 5000 {
+		lines2[i] = "changed: true"
+	}
+
+	for b.Loop() {
+		DiffLines(lines1, lines2)
+	}
+}
+
+func TestSubstituteEnvVarsInString(t *testing.T) {
+	t.Setenv("CLI_TEST_NAME", "app")
+	t.Setenv("CLI_TEST_EMPTY", "")
+
+	got, unsetVars := SubstituteEnvVarsInString("name: ${CLI_TEST_NAME}-$CLI_TEST_NAME\nempty: '$CLI_TEST_EMPTY'\nprice: $$5 $1 ${} $\nmissing: ${CLI_TEST_MISSING}$CLI_TEST_MISSING")
+	want := "name: app-app\nempty: ''\nprice: $$5 $1 ${} $\nmissing: "
+	if got != want {
+		t.Errorf("SubstituteEnvVarsInString =\n%q\nwant\n%q", got, want)
+	}
+	if want := []string{"CLI_TEST_MISSING"}; !slices.Equal(unsetVars, want) {
+		t.Errorf("unset variables = %v, want %v", unsetVars, want)
+	}
+}
+
+func TestSubstituteEnvVars(t *testing.T) {
+	t.Setenv("CLI_TEST_TAG", "1.2.3")
+	dir := t.TempDir()
+	filePath := writeTestFile(t, dir, "values.yaml", "tag: ${CLI_TEST_TAG}\n")
+	if err := os.Chmod(filePath, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := SubstituteEnvVars(filePath, true); err != nil {
+		t.Fatal(err)
+	}
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "tag: 1.2.3\n" {
+		t.Errorf("content = %q, want substituted tag", content)
+	}
+	if info, _ := os.Stat(filePath); info.Mode().Perm() != 0o600 {
+		t.Errorf("permissions = %v, want 0600", info.Mode().Perm())
+	}
+	// The file is replaced atomically, no temporary file is left
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("directory has %d entries, want 1", len(entries))
+	}
+}
+
+func TestSubstituteEnvVarsStrict(t *testing.T) {
+	original := "tag: ${CLI_TEST_MISSING_TAG}\n"
+	filePath := writeTestFile(t, t.TempDir(), "values.yaml", original)
+
+	err := SubstituteEnvVars(filePath, true)
+	if err == nil || !strings.Contains(err.Error(), "CLI_TEST_MISSING_TAG") {
+		t.Fatalf("SubstituteEnvVars error = %v, want unset variable error", err)
+	}
+	if content, _ := os.ReadFile(filePath); string(content) != original {
+		t.Errorf("file changed in strict mode: %q", content)
+	}
+}
+
+// fakeYq replaces the yq executable by a shell script with the body during the test.
+// The script appends its arguments to the returned log file, one call per line.
+func fakeYq(t *testing.T, body string) string {
+	t.Helper()
+	dir := t.TempDir()
+	logFile := filepath.Join(dir, "calls.log")
+	script := filepath.Join(dir, "yq")
+	content := "#!/bin/sh\necho \"$*\" >> '" + logFile + "'\n" + body + "\n"
+	if err := os.WriteFile(script, []byte(content), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	// The preparation of real yq is skipped
+	findYqOnce.Do(func() {})
+	previous := foundYqPath
+	foundYqPath = script
+	t.Cleanup(func() { foundYqPath = previous })
+	return logFile
+}
+
+// yqCalls returns the calls of fake yq, one per line
+func yqCalls(t *testing.T, logFile string) []string {
+	t.Helper()
+	content, err := os.ReadFile(logFile)
+	if err != nil && !os.IsNotExist(err) {
+		t.Fatal(err)
+	}
+	return strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+}
+
+func TestValidateYqExpression(t *testing.T) {
+	// The fake rejects the expressions with unbalanced brackets, like yq
+	logFile := fakeYq(t, `case "$3" in *"["*"]"*) exit 0 ;; *"["*) echo "Error: bad expression, could not find matching ']'" >&2; exit 1 ;; esac`)
+
+	if err := ValidateYqExpression(`.spec.containers[0].image = "app:1.0.0"`); err != nil {
+		t.Errorf("valid expression: %v", err)
+	}
+	err := ValidateYqExpression(`.spec.containers[`)
+	if err == nil || !strings.Contains(err.Error(), "matching ']'") {
+		t.Errorf("invalid expression error = %v, want yq error", err)
+	}
+	if err := ValidateYqExpression(""); err == nil {
+		t.Error("expected error for empty expression")
+	}
+
+	// The expressions are evaluated against a null document, no file is used
+	want := []string{`eval --null-input .spec.containers[0].image = "app:1.0.0"`, `eval --null-input .spec.containers[`}
+	if calls := yqCalls(t, logFile); !slices.Equal(calls, want) {
+		t.Errorf("yq calls = %q, want %q", calls, want)
+	}
+}
+
+func TestMatchYAMLFile(t *testing.T) {
+	tests := []struct {
+		name              string
+		includePatchFiles bool
+		want              bool
+	}{
+		{"deployment.yaml", false, true},
+		{"deployment.yml", false, true},
+		{"deployment.patch.yaml", false, false},
+		{"deployment.patch.yml", false, false},
+		{"deployment.patch.yaml", true, true},
+		{"deployment.patch.yml", true, true},
+		{"README.md", true, false},
+		{"yaml", true, false},
+	}
+	for _, tt := range tests {
+		if got := MatchYAMLFile(tt.name, tt.includePatchFiles); got != tt.want {
+			t.Errorf("MatchYAMLFile(%q, %t) = %t, want %t", tt.name, tt.includePatchFiles, got, tt.want)
+		}
+	}
+	// IsYAMLFile, used by the merge of templates, never matches patch files
+	if IsYAMLFile("deployment.patch.yaml") || !IsYAMLFile("deployment.yaml") {
+		t.Error("IsYAMLFile must exclude only the patch files")
+	}
+}
+
+func TestApplyYqExpressionRecursivelyPatchFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"app/deployment.yaml", "app/deployment.patch.yaml", "app/service.patch.yml", "values.yml", "README.md"} {
+		writeTestFile(t, dir, name, "a: 1\n")
+	}
+
+	for _, includePatchFiles := range []bool{false, true} {
+		logFile := fakeYq(t, "exit 0")
+		if err := ApplyYqExpressionRecursively(dir, ".a = 2", ApplyYqOptions{IncludePatchFiles: includePatchFiles}); err != nil {
+			t.Fatal(err)
+		}
+
+		// The edited files are the ones selected by MatchYAMLFile with the same option
+		var want []string
+		filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+			if !d.IsDir() && MatchYAMLFile(path, includePatchFiles) {
+				want = append(want, "eval -i .a = 2 "+path)
+			}
+			return err
+		})
+		if calls := yqCalls(t, logFile); !slices.Equal(calls, want) {
+			t.Errorf("includePatchFiles=%t: yq calls = %q, want %q", includePatchFiles, calls, want)
+		}
+	}
+}
+
+func TestListEmbeddedTemplates(t *testing.T) {
+	// The embedded files are the files of internalembeds directory
+	var want []string
+	errWalk := filepath.WalkDir("internalembeds", func(path string, d os.DirEntry, err error) error {
+		if err == nil && d.IsDir() && path != "internalembeds" {
+			want = append(want, filepath.ToSlash(strings.TrimPrefix(path, "internalembeds"+string(filepath.Separator))))
+		}
+		return err
+	})
+	if errWalk != nil {
+		t.Fatal(errWalk)
+	}
+
+	templates, err := ListEmbeddedTemplates()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(templates, want) {
+		t.Errorf("ListEmbeddedTemplates = %v, want %v", templates, want)
+	}
+}
+
+func TestCopyTemplateFiles(t *testing.T) {
+	destDir := filepath.Join(t.TempDir(), "new", "dir")
+	if err := CopyTemplateFiles(".", destDir); err != nil {
+		t.Fatal(err)
+	}
+
+	// All embedded files are copied with the same content and relative path
+	errWalk := filepath.WalkDir("internalembeds", func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		relPath, _ := filepath.Rel("internalembeds", path)
+		want, _ := os.ReadFile(path)
+		got, errRead := os.ReadFile(filepath.Join(destDir, relPath))
+		if errRead != nil || string(got) != string(want) {
+			t.Errorf("file %s not copied: %v", relPath, errRead)
+		}
+		return nil
+	})
+	if errWalk != nil {
+		t.Fatal(errWalk)
+	}
+
+	if err := CopyTemplateFiles("missing", t.TempDir()); err == nil {
+		t.Error("expected error for missing template set")
+	}
+}
+
+func TestFindYqTempFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, dir, "yq-1234567", "old yq")
+	writeTestFile(t, dir, "yq-89", "older yq binary")
+	// Files of other applications and similar names are never touched
+	writeTestFile(t, dir, "yq-config.yaml", "a: 1")
+	writeTestFile(t, dir, "yq-", "")
+	writeTestFile(t, dir, "myyq-123", "")
+	writeTestFile(t, dir, "kubectl-123", "")
+	if err := os.Mkdir(filepath.Join(dir, "yq-456"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := FindYqTempFiles(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, file := range files {
+		names = append(names, filepath.Base(file.Path))
+	}
+	if want := []string{"yq-1234567", "yq-89"}; !slices.Equal(names, want) {
+		t.Fatalf("files = %v, want %v", names, want)
+	}
+
+	removed, size, err := RemoveYqTempFiles(files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if removed != 2 || size != int64(len("old yq")+len("older yq binary")) {
+		t.Errorf("removed %d file(s) with %d bytes, want 2 with %d", removed, size, len("old yq")+len("older yq binary"))
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var remaining []string
+	for _, entry := range entries {
+		remaining = append(remaining, entry.Name())
+	}
+	if want := []string{"kubectl-123", "myyq-123", "yq-", "yq-456", "yq-config.yaml"}; !slices.Equal(remaining, want) {
+		t.Errorf("remaining files = %v, want %v", remaining, want)
+	}
+}
+
+func TestFindYqTempFilesIgnoresCurrentYq(t *testing.T) {
+	dir := t.TempDir()
+	current := writeTestFile(t, dir, "yq-111", "current yq")
+	writeTestFile(t, dir, "yq-222", "old yq")
+	findYqOnce.Do(func() {})
+	previous := foundYqPath
+	foundYqPath = current
+	t.Cleanup(func() { foundYqPath = previous })
+
+	files, err := FindYqTempFiles(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || filepath.Base(files[0].Path) != "yq-222" {
+		t.Errorf("files = %v, want only yq-222", files)
+	}
+}
+
+func TestModifyYamlInPlaceMulti(t *testing.T) {
+	// The fake writes the program to the file, so the file must be written once with all expressions
+	logFile := fakeYq(t, `printf '%s\n' "$3" > "$4"`)
+	filePath := writeTestFile(t, t.TempDir(), "values.yaml", "image:\n  tag: v1\n")
+
+	expressions := []string{`.image.tag = "v2"`, `.replicas = 3 // 1`, `del(.debug)`}
+	if err := ModifyYamlInPlaceMulti(filePath, expressions); err != nil {
+		t.Fatal(err)
+	}
+
+	program := `(.image.tag = "v2") | (.replicas = 3 // 1) | (del(.debug))`
+	if calls := yqCalls(t, logFile); !slices.Equal(calls, []string{"eval -i " + program + " " + filePath}) {
+		t.Errorf("yq calls = %q, want a single call with the combined program", calls)
+	}
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != program+"\n" {
+		t.Errorf("content = %q, want result of combined program", content)
+	}
+}
+
+func TestModifyYamlInPlaceMultiReportsFailedIndex(t *testing.T) {
+	// The fake rejects the expressions with unbalanced brackets, without touching the file
+	fakeYq(t, `case "$3" in *"["*"]"*) exit 0 ;; *"["*) echo "Error: could not find matching ']'" >&2; exit 1 ;; esac`)
+	filePath := writeTestFile(t, t.TempDir(), "values.yaml", "image:\n  tag: v1\n")
+
+	err := ModifyYamlInPlaceMulti(filePath, []string{`.image.tag = "v2"`, `.containers[`, `del(.debug)`})
+	if err == nil || !strings.Contains(err.Error(), "index 1") {
+		t.Errorf("error = %v, want failure of expression at index 1", err)
+	}
+	if content, _ := os.ReadFile(filePath); string(content) != "image:\n  tag: v1\n" {
+		t.Errorf("file modified after failure: %q", content)
+	}
+}
+
+func TestModifyYamlInPlaceMultiInvalidInput(t *testing.T) {
+	logFile := fakeYq(t, "exit 0")
+	filePath := writeTestFile(t, t.TempDir(), "values.yaml", "a: 1\n")
+
+	if err := ModifyYamlInPlaceMulti(filePath, nil); err == nil {
+		t.Error("expected error without expressions")
+	}
+	if err := ModifyYamlInPlaceMulti(filePath, []string{".a = 2", " "}); err == nil || !strings.Contains(err.Error(), "index 1") {
+		t.Errorf("error = %v, want empty expression at index 1", err)
+	}
+	if calls := yqCalls(t, logFile); !slices.Equal(calls, []string{""}) {
+		t.Errorf("yq executed with invalid input: %q", calls)
+	}
+}
+
+func TestApplyPatchFiles(t *testing.T) {
+	dir := t.TempDir()
+	base := writeTestFile(t, dir, "app/values.yaml", existingValuesYAML)
+	patch := writeTestFile(t, dir, "app/values.patch.yaml", `image:
+  tag: "1.1.0"
+hosts:
+  - api.example.com
+podLabels:
+  team: payments
+`)
+
+	if err := ApplyPatchFiles(dir); err != nil {
+		t.Fatalf("ApplyPatchFiles: %v", err)
+	}
+
+	content, err := os.ReadFile(base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `replicaCount: 3
+image:
+  repository: registry.example.com/app
+  tag: "1.1.0"
+resources:
+  limits:
+    memory: 1Gi
+hosts:
+  - app.example.com
+  - api.example.com
+podLabels:
+  team: payments
+`
+	if string(content) != want {
+		t.Errorf("patched content =\n%s\nwant\n%s", content, want)
+	}
+	if FileExists(patch) {
+		t.Error("patch file not removed after applied")
+	}
+}
+
+func TestApplyPatchFilesTargetKey(t *testing.T) {
+	dir := t.TempDir()
+	base := writeTestFile(t, dir, "base/deployment.yaml", "replicas: 1\n")
+	patch := writeTestFile(t, dir, "overlays/prod.patch.yml", "target: ../base/deployment.yaml\nreplicas: 3\n")
+
+	if err := ApplyPatchFiles(dir); err != nil {
+		t.Fatalf("ApplyPatchFiles: %v", err)
+	}
+
+	// The 'target' key is not merged into the base manifest
+	if content, _ := os.ReadFile(base); string(content) != "replicas: 3\n" {
+		t.Errorf("patched content = %q, want %q", content, "replicas: 3\n")
+	}
+	if FileExists(patch) {
+		t.Error("patch file not removed after applied")
+	}
+}
+
+func TestApplyPatchFilesMissingBase(t *testing.T) {
+	dir := t.TempDir()
+	base := writeTestFile(t, dir, "a/values.yaml", "replicas: 1\n")
+	patchA := writeTestFile(t, dir, "a/values.patch.yaml", "replicas: 2\n")
+	patchB := writeTestFile(t, dir, "b/missing.patch.yaml", "replicas: 3\n")
+
+	err := ApplyPatchFiles(dir)
+	if err == nil || !strings.Contains(err.Error(), "missing.patch.yaml") {
+		t.Fatalf("error = %v, want missing base manifest", err)
+	}
+
+	// All patches are resolved before any change
+	if content, _ := os.ReadFile(base); string(content) != "replicas: 1\n" {
+		t.Errorf("base manifest changed: %q", content)
+	}
+	if !FileExists(patchA) || !FileExists(patchB) {
+		t.Error("patch files removed after failure")
+	}
+}
+
+// setYqSource replaces the source of prepared yq during the test
+func setYqSource(t *testing.T, source string) {
+	t.Helper()
+	previous := foundYqSource
+	foundYqSource = source
+	t.Cleanup(func() { foundYqSource = previous })
+}
+
+func TestPrepareYqInstalled(t *testing.T) {
+	setYqSource(t, "")
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	installedPath, err := InstalledYqPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, filepath.Dir(installedPath), "yq", "#!/bin/sh\n")
+
+	yqPath, err := prepareYq()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if yqPath != installedPath || foundYqSource != YqSourceInstalled {
+		t.Errorf("prepareYq() = %s (%s), want %s (%s)", yqPath, foundYqSource, installedPath, YqSourceInstalled)
+	}
+}
+
+func TestPrepareYqEmbedded(t *testing.T) {
+	setYqSource(t, "")
+	// Without installed yq, the embedded yq is extracted to the temporary directory
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	previousTempDir := config.TempDir
+	config.TempDir = t.TempDir()
+	t.Cleanup(func() { config.TempDir = previousTempDir })
+
+	yqPath, err := prepareYq()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Dir(yqPath) != config.TempDir || !strings.HasPrefix(filepath.Base(yqPath), yqTempFilePrefix) {
+		t.Errorf("prepareYq() = %s, want a temporary file in %s", yqPath, config.TempDir)
+	}
+	if foundYqSource != YqSourceEmbedded {
+		t.Errorf("source = %s, want %s", foundYqSource, YqSourceEmbedded)
+	}
+}
+
+func TestGetYqInfo(t *testing.T) {
+	fakeYq(t, `echo "yq (https://github.com/mikefarah/yq/) version v4.45.1"`)
+	setYqSource(t, YqSourceInstalled)
+
+	info, err := GetYqInfo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := YqInfo{Path: foundYqPath, Version: "yq (https://github.com/mikefarah/yq/) version v4.45.1", Source: YqSourceInstalled}
+	if info != want {
+		t.Errorf("GetYqInfo() = %+v, want %+v", info, want)
+	}
+
+	fakeYq(t, "exit 1")
+	if _, err := GetYqInfo(); err == nil {
+		t.Error("expected error when yq can't be executed")
+	}
+}
+
+func TestApplyYqExpressionRecursivelyContinueOnError(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.yaml", "b-broken.yaml", "c.yaml"} {
+		writeTestFile(t, dir, name, "a: 1\n")
+	}
+	// The fake fails for the broken file
+	const failingYq = `case "$*" in *broken*) echo "Error: bad file" >&2; exit 1 ;; esac`
+
+	t.Run("fail fast", func(t *testing.T) {
+		logFile := fakeYq(t, failingYq)
+		err := ApplyYqExpressionRecursively(dir, ".a = 2", ApplyYqOptions{})
+		if err == nil || !strings.Contains(err.Error(), "b-broken.yaml") {
+			t.Fatalf("error = %v, want failure of b-broken.yaml", err)
+		}
+		// The walk stops at the broken file
+		if calls := yqCalls(t, logFile); len(calls) != 2 || strings.Contains(calls[len(calls)-1], "c.yaml") {
+			t.Errorf("yq calls = %q, want stop after b-broken.yaml", calls)
+		}
+	})
+
+	t.Run("continue on error", func(t *testing.T) {
+		logFile := fakeYq(t, failingYq)
+		err := ApplyYqExpressionRecursively(dir, ".a = 2", ApplyYqOptions{ContinueOnError: true})
+		if err == nil || !strings.Contains(err.Error(), "1 file(s): "+filepath.Join(dir, "b-broken.yaml")) {
+			t.Fatalf("error = %v, want aggregated failure of b-broken.yaml", err)
+		}
+		// All files are edited
+		want := []string{
+			"eval -i .a = 2 " + filepath.Join(dir, "a.yaml"),
+			"eval -i .a = 2 " + filepath.Join(dir, "b-broken.yaml"),
+			"eval -i .a = 2 " + filepath.Join(dir, "c.yaml"),
+		}
+		if calls := yqCalls(t, logFile); !slices.Equal(calls, want) {
+			t.Errorf("yq calls = %q, want %q", calls, want)
+		}
+	})
+}
+
+func TestCheckImageReference(t *testing.T) {
+	tests := map[string]string{
+		"registry.example.com/app@sha256:0123abcd":        "",
+		"registry.example.com/app:1.2.3":                  "",
+		"registry.example.com:5000/team/app:1.2.3":        "",
+		"registry.example.com/app:latest":                 "uses the ':latest' tag",
+		"nginx":                                           "no tag",
+		"registry.example.com:5000/team/app":              "no tag",
+		"registry.example.com/app:latest@sha256:0123abcd": "",
+	}
+	for image, want := range tests {
+		if got := CheckImageReference(image); got != want {
+			t.Errorf("CheckImageReference(%q) = %q, want %q", image, got, want)
+		}
+	}
+}
+
+func TestCheckYAMLImages(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, dir, "pinned/deployment.yaml", "containers:\n  - image: registry.example.com/app@sha256:0123abcd\n  - image: registry.example.com/sidecar:1.0.0\n")
+	unpinned := writeTestFile(t, dir, "unpinned/cronjob.yml", "containers:\n  - image: registry.example.com/job:latest\n")
+	writeTestFile(t, dir, "unpinned/cronjob.patch.yaml", "containers:\n  - image: nginx\n")
+	writeTestFile(t, dir, "README.md", "image: nginx\n")
+	// The fake returns the values of 'image' fields of the file, like the images expression
+	fakeYq(t, `grep -o 'image: *[^ ]*' "$4" | sed 's/image: *//'`)
+
+	checked, violations, err := CheckYAMLImages(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The patch files and the non-YAML files are not checked
+	if checked != 3 {
+		t.Errorf("checked images = %d, want 3", checked)
+	}
+	want := []ImageViolation{{File: unpinned, Image: "registry.example.com/job:latest", Reason: "uses the ':latest' tag"}}
+	if !slices.Equal(violations, want) {
+		t.Errorf("violations = %+v, want %+v", violations, want)
+	}
+}
+
+func TestCheckYAMLImagesYqFailure(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, dir, "deployment.yaml", "image: nginx\n")
+	fakeYq(t, "exit 1")
+
+	if _, _, err := CheckYAMLImages(dir); err == nil || !strings.Contains(err.Error(), "deployment.yaml") {
+		t.Errorf("error = %v, want failure of deployment.yaml", err)
+	}
+}
+
+func TestFormatYAML(t *testing.T) {
+	input := "---\n# Deployment\nkind: Deployment\nspec:\n    replicas: 2   \n    containers:\n        - name: app\n---\nkind: Service\n"
+	want := "---\n# Deployment\nkind: Deployment\nspec:\n  replicas: 2\n  containers:\n    - name: app\n---\nkind: Service\n"
+
+	formatted, err := FormatYAML([]byte(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(formatted) != want {
+		t.Errorf("FormatYAML() =\n%s\nwant\n%s", formatted, want)
+	}
+	// The canonical format doesn't change
+	if again, err := FormatYAML(formatted); err != nil || string(again) != want {
+		t.Errorf("FormatYAML() of formatted YAML = %q, %v", again, err)
+	}
+	if _, err := FormatYAML([]byte("key: [unclosed\n")); err == nil {
+		t.Error("expected error for invalid YAML")
+	}
+}
+
+func TestCheckYAMLStyle(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, dir, "formatted.yaml", "spec:\n  replicas: 2\n")
+	misIndented := writeTestFile(t, dir, "apps/mis-indented.yaml", "spec:\n    replicas: 2\n")
+	writeTestFile(t, dir, "notes.txt", "spec:\n    replicas: 2\n")
+
+	checked, unformatted, err := CheckYAMLStyle(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if checked != 2 || !slices.Equal(unformatted, []string{filepath.Join("apps", "mis-indented.yaml")}) {
+		t.Errorf("CheckYAMLStyle() = %d, %v, want 2 files checked and only the mis-indented flagged", checked, unformatted)
+	}
+	if content, _ := os.ReadFile(misIndented); string(content) != "spec:\n    replicas: 2\n" {
+		t.Errorf("file changed without fix: %q", content)
+	}
+
+	// The file is fixed, then no file is flagged
+	if _, unformatted, err := CheckYAMLStyle(dir, true); err != nil || len(unformatted) != 1 {
+		t.Fatalf("CheckYAMLStyle() with fix = %v, %v", unformatted, err)
+	}
+	if content, _ := os.ReadFile(misIndented); string(content) != "spec:\n  replicas: 2\n" {
+		t.Errorf("fixed file = %q", content)
+	}
+	if _, unformatted, err := CheckYAMLStyle(dir, false); err != nil || len(unformatted) != 0 {
+		t.Errorf("CheckYAMLStyle() after fix = %v, %v, want no file flagged", unformatted, err)
+	}
+}
+
+func TestBuildKindSelectExpression(t *testing.T) {
+	got, err := BuildKindSelectExpression(".spec.replicas = 2", "Deployment")
+	if want := `with(select(.kind == "Deployment"); .spec.replicas = 2)`; err != nil || got != want {
+		t.Errorf("BuildKindSelectExpression() = %s, %v, want %s", got, err, want)
+	}
+	for _, kind := range []string{"", "2Deployment", `Deployment" or .kind == "Service`, "apps/v1"} {
+		if _, err := BuildKindSelectExpression(".a = 1", kind); err == nil {
+			t.Errorf("BuildKindSelectExpression(%q): expected error", kind)
+		}
+	}
+}
+
+func TestApplyYqExpressionRecursivelyKind(t *testing.T) {
+	dir := t.TempDir()
+	deployment := writeTestFile(t, dir, "deployment.yaml", "kind: Deployment\n")
+	mixed := writeTestFile(t, dir, "apps/all.yaml", "kind: Service\n---\nkind: Deployment\n")
+	writeTestFile(t, dir, "service.yaml", "kind: Service\n")
+	writeTestFile(t, dir, "config.yaml", "kind: ConfigMap\n")
+	// The fake returns the kinds of the documents, like 'yq eval .kind <file>', and doesn't edit the files
+	logFile := fakeYq(t, `[ "$1 $2" = "eval .kind" ] && sed -n 's/^kind: //p' "$3"; exit 0`)
+
+	if err := ApplyYqExpressionRecursively(dir, ".spec.replicas = 2", ApplyYqOptions{Kind: "Deployment"}); err != nil {
+		t.Fatal(err)
+	}
+
+	var edited []string
+	for _, call := range yqCalls(t, logFile) {
+		if strings.HasPrefix(call, "eval -i ") {
+			edited = append(edited, call)
+		}
+	}
+	// Only the documents of kind are edited in the multi-document file
+	const expression = `with(select(.kind == "Deployment"); .spec.replicas = 2)`
+	want := []string{"eval -i " + expression + " " + mixed, "eval -i " + expression + " " + deployment}
+	if !slices.Equal(edited, want) {
+		t.Errorf("edits = %q, want only the files with Deployment: %q", edited, want)
+	}
+
+	if err := ApplyYqExpressionRecursively(dir, ".spec.replicas = 2", ApplyYqOptions{Kind: "apps/v1"}); err == nil {
+		t.Error("expected error for invalid kind")
+	}
+}
+
+func TestComputeYAMLChecksum(t *testing.T) {
+	dir := t.TempDir()
+	deployment := writeTestFile(t, dir, "apps/deployment.yaml", "kind: Deployment\nspec:\n  replicas: 2\n")
+	writeTestFile(t, dir, "service.patch.yaml", "kind: Service\n")
+	writeTestFile(t, dir, "README.md", "not hashed")
+
+	digest, files, err := ComputeYAMLChecksum(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if files != 2 || len(digest) != 64 {
+		t.Fatalf("ComputeYAMLChecksum() = %s, %d, want SHA-256 of 2 files", digest, files)
+	}
+
+	// The cosmetic reformat (indentation, key order and comments) doesn't change the digest
+	writeTestFile(t, dir, "apps/deployment.yaml", "# Application\nspec:\n    replicas: 2   \nkind: Deployment\n")
+	if again, _, err := ComputeYAMLChecksum(dir); err != nil || again != digest {
+		t.Errorf("digest after reformat = %s, %v, want %s", again, err, digest)
+	}
+
+	// The changes of values and file names change the digest
+	writeTestFile(t, dir, "apps/deployment.yaml", "kind: Deployment\nspec:\n  replicas: 3\n")
+	if changed, _, err := ComputeYAMLChecksum(dir); err != nil || changed == digest {
+		t.Errorf("digest after change of value = %s, %v, want different from %s", changed, err, digest)
+	}
+	writeTestFile(t, dir, "apps/deployment.yaml", "kind: Deployment\nspec:\n  replicas: 2\n")
+	if err := os.Rename(deployment, filepath.Join(dir, "apps", "app.yaml")); err != nil {
+		t.Fatal(err)
+	}
+	if renamed, _, err := ComputeYAMLChecksum(dir); err != nil || renamed == digest {
+		t.Errorf("digest after rename = %s, %v, want different from %s", renamed, err, digest)
+	}
+
+	writeTestFile(t, dir, "broken.yaml", "key: [unclosed\n")
+	if _, _, err := ComputeYAMLChecksum(dir); err == nil || !strings.Contains(err.Error(), "broken.yaml") {
+		t.Errorf("error = %v, want failure of broken.yaml", err)
+	}
+}
+
+func TestCanonicalizeYAML(t *testing.T) {
+	dir := t.TempDir()
+	filePath := writeTestFile(t, dir, "deployment.yaml", `spec:
+    replicas: 2
+# Application
+metadata:
+    name: app
+custom: value
+kind: Deployment
+apiVersion: apps/v1
+---
+kind: Service
+apiVersion: v1
+`)
+	want := `apiVersion: apps/v1
+kind: Deployment
+# Application
+metadata:
+  name: app
+spec:
+  replicas: 2
+custom: value
+---
+apiVersion: v1
+kind: Service
+`
+
+	if err := CanonicalizeYAML(filePath); err != nil {
+		t.Fatal(err)
+	}
+	first, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(first) != want {
+		t.Errorf("canonical file =\n%s\nwant\n%s", first, want)
+	}
+
+	// Running twice yields identical bytes
+	if err := CanonicalizeYAML(filePath); err != nil {
+		t.Fatal(err)
+	}
+	if second, _ := os.ReadFile(filePath); string(second) != string(first) {
+		t.Errorf("second run changed the file:\n%s", second)
+	}
+
+	if err := CanonicalizeYAML(filepath.Join(dir, "missing.yaml")); err == nil {
+		t.Error("expected error for missing file")
+	}
+}
+
+func TestCombineYAMLFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, dir, "b-service.yaml", "kind: Service\nmetadata:\n    name: app\n")
+	writeTestFile(t, dir, "a-deployment.yaml", "---\n---\nkind: Deployment\n")
+	writeTestFile(t, dir, "config/configmap.yml", "kind: ConfigMap\n")
+	writeTestFile(t, dir, "a-deployment.patch.yaml", "kind: Patch\n")
+	outputPath := filepath.Join(dir, "combined.yaml")
+	// The output of previous run under rootDir is not combined into itself
+	writeTestFile(t, dir, "combined.yaml", "kind: Previous\n")
+
+	if err := CombineYAMLFiles(dir, outputPath); err != nil {
+		t.Fatal(err)
+	}
+	content, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The documents are in sorted order of paths, separated by '---', without the empty document and the patch file
+	want := "kind: Deployment\n---\nkind: Service\nmetadata:\n  name: app\n---\nkind: ConfigMap\n"
+	if string(content) != want {
+		t.Errorf("combined file =\n%s\nwant\n%s", content, want)
+	}
+	if documents := strings.Count(string(content), "---\n") + 1; documents != 3 {
+		t.Errorf("%d document(s), want 3", documents)
+	}
+
+	writeTestFile(t, dir, "broken.yaml", "kind: [unclosed\n")
+	if err := CombineYAMLFiles(dir, outputPath); err == nil || !strings.Contains(err.Error(), "broken.yaml") {
+		t.Errorf("error = %v, want failure of broken.yaml", err)
+	}
+}
+
+func TestSplitYAMLFile(t *testing.T) {
+	dir := t.TempDir()
+	inputPath := writeTestFile(t, dir, "bundle.yaml", `kind: Deployment
+metadata:
+    name: App
+---
+kind: Service
+metadata:
+  name: ../etc
+---
+---
+kind: ConfigMap
+---
+replicas: 2
+---
+kind: Deployment
+metadata:
+  name: app
+`)
+	outputDir := filepath.Join(dir, "split")
+
+	if err := SplitYAMLFile(inputPath, outputDir); err != nil {
+		t.Fatal(err)
+	}
+
+	// The empty document 3 is skipped, but it counts in the position of the next documents
+	want := map[string]string{
+		"deployment-app.yaml":   "kind: Deployment\nmetadata:\n  name: App\n",
+		"service-etc.yaml":      "kind: Service\nmetadata:\n  name: ../etc\n",
+		"configmap-4.yaml":      "kind: ConfigMap\n",
+		"document-5.yaml":       "replicas: 2\n",
+		"deployment-app-6.yaml": "kind: Deployment\nmetadata:\n  name: app\n",
+	}
+	entries, err := os.ReadDir(outputDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != len(want) {
+		t.Errorf("files = %v, want %d files", entries, len(want))
+	}
+	for name, wantContent := range want {
+		content, err := os.ReadFile(filepath.Join(outputDir, name))
+		if err != nil {
+			t.Errorf("file %s: %v", name, err)
+			continue
+		}
+		if string(content) != wantContent {
+			t.Errorf("file %s = %q, want %q", name, content, wantContent)
+		}
+	}
+
+	if err := SplitYAMLFile(filepath.Join(dir, "missing.yaml"), outputDir); err == nil {
+		t.Error("expected error for missing file")
+	}
+}
+
+func TestCheckYAMLTrailingNewline(t *testing.T) {
+	dir := t.TempDir()
+	missing := writeTestFile(t, dir, "apps/deployment.yaml", "kind: Deployment")
+	writeTestFile(t, dir, "service.yaml", "kind: Service\n")
+	writeTestFile(t, dir, "deployment.patch.yaml", "kind: Deployment")
+	writeTestFile(t, dir, "empty.yaml", "")
+
+	checked, files, err := CheckYAMLTrailingNewline(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{filepath.Join("apps", "deployment.yaml"), "deployment.patch.yaml"}
+	slices.Sort(files)
+	if checked != 4 || !slices.Equal(files, want) {
+		t.Errorf("CheckYAMLTrailingNewline() = %d, %v, want 4, %v", checked, files, want)
+	}
+	// The files are not changed without fix
+	if content, _ := os.ReadFile(missing); string(content) != "kind: Deployment" {
+		t.Errorf("file changed without fix: %q", content)
+	}
+
+	if _, files, err := CheckYAMLTrailingNewline(dir, true); err != nil || len(files) != 2 {
+		t.Fatalf("CheckYAMLTrailingNewline(fix) = %v, %v, want the 2 files fixed", files, err)
+	}
+	if content, _ := os.ReadFile(missing); string(content) != "kind: Deployment\n" {
+		t.Errorf("fixed file = %q, want the trailing newline appended", content)
+	}
+	if _, files, err := CheckYAMLTrailingNewline(dir, false); err != nil || len(files) != 0 {
+		t.Errorf("CheckYAMLTrailingNewline() after fix = %v, %v, want no files", files, err)
+	}
+}
@@ -3,20 +3,72 @@ package gcp
 
 import (
 	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"os/exec"
+	"os"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/aeciopires/pires-cli/internal/config"
 	"github.com/aeciopires/pires-cli/pkg/pireslib/common"
 )
 
+// appendImpersonationArg appends the --impersonate-service-account argument to the args of gcloud,
+// if config.GCPImpersonateServiceAccount is set. The local commands (config and auth groups) don't call GCP APIs,
+// so they run with the credentials of active account.
+func appendImpersonationArg(args []string) []string {
+	if config.GCPImpersonateServiceAccount == "" || len(args) == 0 || args[0] == "config" || args[0] == "auth" {
+		return args
+	}
+	return append(slices.Clone(args), "--impersonate-service-account="+config.GCPImpersonateServiceAccount)
+}
+
+// appendExtraArgs appends config.GcloudExtraArgs to the args of gcloud, so they are always the last arguments
+// and take precedence over the arguments built by CLI. Like appendImpersonationArg, the local commands
+// (config and auth groups) are not changed, because they are only used internally to read the gcloud configuration.
+func appendExtraArgs(args []string) []string {
+	if len(config.GcloudExtraArgs) == 0 || len(args) == 0 || args[0] == "config" || args[0] == "auth" {
+		return args
+	}
+	return append(slices.Clone(args), config.GcloudExtraArgs...)
+}
+
 // RunGcloudCommand executes a gcloud command with the given arguments.
 // It captures and returns stdout and stderr.
-// Assumes gcloud is in the system PATH.
+// The binary is defined by config.GcloudPath (gcloud in the system PATH by default).
 func RunGcloudCommand(args ...string) (stdout string, stderr string, err error) {
+	return runGcloudCommand("", false, args...)
+}
+
+// RunGcloudPrimaryCommand executes the gcloud command of the operation requested by the user, like RunGcloudCommand,
+// and appends config.GcloudExtraArgs to the end of args. The helper commands of the same operation (e.g. the list
+// of resources to be changed, the describe before a patch or the wait of operations) use RunGcloudCommand,
+// so the extra arguments are not passed to them.
+func RunGcloudPrimaryCommand(args ...string) (stdout string, stderr string, err error) {
+	return runGcloudCommand("", true, args...)
+}
+
+// RunGcloudCommandWithInput executes a gcloud command like RunGcloudCommand, writing input to its stdin.
+// It is used to pass secrets without exposing them in the arguments, e.g. '--data-file=-'.
+func RunGcloudCommandWithInput(input string, args ...string) (stdout string, stderr string, err error) {
+	return runGcloudCommand(input, false, args...)
+}
+
+// runGcloudCommand executes the gcloud command of RunGcloudCommand, RunGcloudPrimaryCommand and RunGcloudCommandWithInput.
+func runGcloudCommand(input string, extraArgs bool, args ...string) (stdout string, stderr string, err error) {
+	args = appendImpersonationArg(args)
+	if extraArgs {
+		args = appendExtraArgs(args)
+	}
+
 	// Proceed with running the command
-	cmd := exec.Command("gcloud", args...)
+	// The command is killed if the CLI receives SIGINT/SIGTERM
+	cmd := common.CommandContext(config.GcloudPath, args...)
+	if input != "" {
+		cmd.Stdin = strings.NewReader(input)
+	}
 
 	// Buffers to capture stdout and stderr
 	var outb, errb bytes.Buffer
@@ -29,12 +81,15 @@ func RunGcloudCommand(args ...string) (stdout string, stderr string, err error)
 	stdout = outb.String()
 	stderr = errb.String()
 
+	if errInterrupted := common.CheckInterrupted(); errInterrupted != nil {
+		return stdout, stderr, errInterrupted
+	}
 	if err != nil {
-		return stdout, stderr, fmt.Errorf("gcloud command 'gcloud %s' failed: %w\nStderr: %s", strings.Join(args, " "), err, stderr)
+		return stdout, stderr, common.NewExternalCommandError("gcloud command 'gcloud %s' failed: %w\nStderr: %s", strings.Join(args, " "), err, stderr)
 	}
 
 	if stderr != "" {
-		common.Logger("info", "gcloud command stderr (exit code 0):\n%s", stderr)
+		common.LogExternalStderr("gcloud", stderr)
 	}
 
 	return stdout, stderr, nil
@@ -42,10 +97,11 @@ func RunGcloudCommand(args ...string) (stdout string, stderr string, err error)
 
 // RunPsqlCommand executes a psql command with the given arguments.
 // It captures and returns stdout and stderr.
-// Assumes psql is in the system PATH.
+// The binary is defined by config.PsqlPath (psql in the system PATH by default).
 func RunPsqlCommand(args ...string) (stdout string, stderr string, err error) {
 	// Proceed with running the command
-	cmd := exec.Command("psql", args...)
+	// The command is killed if the CLI receives SIGINT/SIGTERM
+	cmd := common.CommandContext(config.PsqlPath, args...)
 
 	// Buffers to capture stdout and stderr
 	var outb, errb bytes.Buffer
@@ -58,44 +114,120 @@ func RunPsqlCommand(args ...string) (stdout string, stderr string, err error) {
 	stdout = outb.String()
 	stderr = errb.String()
 
+	if errInterrupted := common.CheckInterrupted(); errInterrupted != nil {
+		return stdout, stderr, errInterrupted
+	}
 	if err != nil {
-		return stdout, stderr, fmt.Errorf("psql command 'psql %s' failed: %w\nStderr: %s", strings.Join(args, " "), err, stderr)
+		return stdout, stderr, common.NewExternalCommandError("psql command 'psql %s' failed: %w\nStderr: %s", strings.Join(args, " "), err, stderr)
 	}
 
 	if stderr != "" {
-		common.Logger("info", "psql command stderr (exit code 0):\n%s", stderr)
+		common.LogExternalStderr("psql", stderr)
 	}
 
 	return stdout, stderr, nil
 }
 
-// CheckGcloudAuth verifies if gcloud is authenticated by checking the active account.
-func CheckGcloudAuth() string {
+// psqlRetryableErrors are the messages of transient connection errors of psql, e.g. network drops or Cloud SQL maintenance.
+var psqlRetryableErrors = []string{
+	"connection reset",
+	"server closed the connection unexpectedly",
+	"could not connect to server",
+	"connection refused",
+	"connection timed out",
+	"timeout expired",
+	"ssl syscall error",
+	"the database system is starting up",
+	"the database system is shutting down",
+}
+
+// isRetryablePsqlError returns true if the stderr of psql contains a transient connection error.
+// Other errors, like permission denied or syntax error, are not retryable.
+func isRetryablePsqlError(stderr string) bool {
+	stderr = strings.ToLower(stderr)
+	for _, message := range psqlRetryableErrors {
+		if strings.Contains(stderr, message) {
+			return true
+		}
+	}
+	return false
+}
+
+// RunPsqlCommandWithRetry executes a psql command like RunPsqlCommand, retrying up to attempts times
+// on transient connection errors. The wait between attempts grows linearly (backoff, 2*backoff...).
+// Non-retryable errors and interruptions (SIGINT/SIGTERM) are returned immediately.
+func RunPsqlCommandWithRetry(attempts int, backoff time.Duration, args ...string) (stdout string, stderr string, err error) {
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	for attempt := 1; ; attempt++ {
+		stdout, stderr, err = RunPsqlCommand(args...)
+		if err == nil || attempt >= attempts || !isRetryablePsqlError(stderr) || common.ExitCode(err) == common.ExitCodeInterrupted {
+			return stdout, stderr, err
+		}
+
+		wait := backoff * time.Duration(attempt)
+		common.Logger("warning", "psql command failed with a transient error (attempt %d of %d). Retrying in %s...", attempt, attempts, wait)
+		select {
+		case <-common.Context().Done():
+			return stdout, stderr, common.CheckInterrupted()
+		case <-time.After(wait):
+		}
+	}
+}
+
+// CheckGcloudAuth verifies if gcloud is authenticated by checking the active account and returns the account.
+func CheckGcloudAuth() (string, error) {
+	activeAccount, err := GetGcloudActiveAccount()
+	if err != nil {
+		if common.ExitCode(err) == common.ExitCodeInterrupted {
+			return "", err
+		}
+		return "", common.NewPermissionDeniedError("failed to check gcloud auth status. Ensure gcloud is installed and authenticated using account: %v. Please run 'gcloud auth login' and 'gcloud auth application-default login' commands", err)
+	}
+
+	common.Logger("debug", "gcloud is authenticated with account: %s", activeAccount)
+	return activeAccount, nil
+}
+
+// GetGcloudActiveAccount returns the active account of gcloud, or an error if gcloud is not authenticated.
+func GetGcloudActiveAccount() (string, error) {
 	common.Logger("debug", "Checking gcloud authentication status...")
 	// `gcloud auth list --filter=status:ACTIVE --format="value(account)"` is more robust
 	// but `gcloud config get-value account` is simpler for a basic check.
 	stdout, stderr, err := RunGcloudCommand("config", "get-value", "account")
-	activeAccount := strings.TrimSpace(stdout)
-	if err != nil || activeAccount == "" {
-		common.Logger("error", "Failed to check gcloud auth status. Ensure gcloud is installed and authenticated using account: %w. Stderr: %s", err, stderr)
-		common.Logger("fatal", "Please run 'gcloud auth login' \n 'gcloud auth application-default login' commands.")
+	if err != nil {
+		return "", fmt.Errorf("%w. Stderr: %s", err, stderr)
 	}
 
-	common.Logger("debug", "gcloud is authenticated with account: %s", activeAccount)
-	return activeAccount
+	activeAccount := strings.TrimSpace(stdout)
+	if activeAccount == "" {
+		return "", errors.New("no active account found in gcloud")
+	}
+	return activeAccount, nil
 }
 
 // CheckGcloudAdminPermissions verifies if the current gcloud credentials have a set of administrative permissions on the project.
 // This function uses `gcloud projects test-iam-permissions`.
-func CheckGcloudAdminPermissions(projectID string) {
+// It returns a permission denied error if gcloud isn't authenticated or the role is missing.
+func CheckGcloudAdminPermissions(projectID string) error {
 	if projectID == "" {
-		common.Logger("fatal", "Project ID is required to check admin permissions in CheckGcloudAdminPermissions function.")
+		return common.NewValidationError("project ID is required to check admin permissions in CheckGcloudAdminPermissions function")
 	}
 	common.Logger("debug", "Checking if current gcloud user has '%s' on project '%s'...", config.GCPRequiredRole, projectID)
 
 	// Get the currently authenticated gcloud account email
-	activeAccount := CheckGcloudAuth()
+	activeAccount, err := CheckGcloudAuth()
+	if err != nil {
+		return err
+	}
 	memberIdentifier := "user:" + activeAccount
+	// The gcloud commands run as the impersonated service account, so its permissions are checked instead of the user
+	if config.GCPImpersonateServiceAccount != "" {
+		activeAccount = config.GCPImpersonateServiceAccount
+		memberIdentifier = "serviceAccount:" + activeAccount
+	}
 	common.Logger("debug", "Checking '%s' for member: %s", config.GCPRequiredRole, memberIdentifier)
 
 	// Command to check if the member has the 'roles/owner' role
@@ -116,14 +248,225 @@ func CheckGcloudAdminPermissions(projectID string) {
 		// This error means the `gcloud projects get-iam-policy` command itself failed.
 		// This could be due to the project not existing, or the user not having
 		// even 'resourcemanager.projects.getIamPolicy' permission.
-		common.Logger("fatal", "Execution of 'gcloud projects get-iam-policy' command for project '%s' failed. \nReview stderr output from gcloud for details. \nStdout: %w . \nStderr from gcloud: %s", projectID, errCmd, stderrCmd)
+		if common.ExitCode(errCmd) == common.ExitCodeInterrupted {
+			return errCmd
+		}
+		return common.NewExternalCommandError("Execution of 'gcloud projects get-iam-policy' command for project '%s' failed. \nReview stderr output from gcloud for details. \nStdout: %w . \nStderr from gcloud: %s", projectID, errCmd, stderrCmd)
 	}
 
 	// Check the output
 	outputRole := strings.TrimSpace(stdout)
 	if outputRole != config.GCPRequiredRole {
-		common.Logger("fatal", "Current gcloud user ('%s') does NOT have '%s' on project '%s'. Insufficient permissions for administrative tasks.", activeAccount, config.GCPRequiredRole, projectID)
+		return common.NewPermissionDeniedError("Current gcloud user ('%s') does NOT have '%s' on project '%s'. Insufficient permissions for administrative tasks.", activeAccount, config.GCPRequiredRole, projectID)
 	}
 
 	common.Logger("debug", "Current gcloud user ('%s') has '%s' on project '%s'. Administrative permissions check passed.", activeAccount, config.GCPRequiredRole, projectID)
+	return nil
+}
+
+// GcloudAuthStatus is the authentication status of gcloud returned by GetGcloudAuthStatus function.
+type GcloudAuthStatus struct {
+	Authenticated bool   `json:"authenticated"`
+	Account       string `json:"account"`
+	ADCAvailable  bool   `json:"adcAvailable"`
+	Project       string `json:"project"`
+	GcloudProject string `json:"gcloudProject"`
+}
+
+// GetGcloudAuthStatus returns the active account of gcloud, the status of Application Default Credentials (ADC)
+// and the project configured in CLI (projectID) and in gcloud. Unlike CheckGcloudAuth, it doesn't exit when
+// gcloud is not authenticated.
+func GetGcloudAuthStatus(projectID string) GcloudAuthStatus {
+	status := GcloudAuthStatus{Project: projectID}
+
+	account, err := GetGcloudActiveAccount()
+	if err != nil {
+		common.Logger("debug", "gcloud is not authenticated: %v", err)
+	} else {
+		status.Authenticated = true
+		status.Account = account
+	}
+
+	if errADC := CheckGcloudADC(); errADC != nil {
+		common.Logger("debug", "%v", errADC)
+	} else {
+		status.ADCAvailable = true
+	}
+
+	if stdout, _, errProject := RunGcloudCommand("config", "get-value", "project"); errProject == nil {
+		status.GcloudProject = strings.TrimSpace(stdout)
+	}
+
+	return status
+}
+
+// CheckGcloudADC checks if the Application Default Credentials (ADC) of gcloud are valid,
+// running 'gcloud auth application-default print-access-token'. The access token is never printed or returned,
+// only the success of command is checked.
+func CheckGcloudADC() error {
+	if _, stderr, err := RunGcloudCommand("auth", "application-default", "print-access-token"); err != nil {
+		if common.ExitCode(err) == common.ExitCodeInterrupted {
+			return err
+		}
+		return common.NewExternalCommandError("Application Default Credentials are not available or expired. Stderr: %s", strings.TrimSpace(stderr))
+	}
+	return nil
+}
+
+// RefreshGcloudADC runs 'gcloud auth application-default login' attached to the terminal,
+// so the user can follow the login flow of gcloud (browser or verification code).
+func RefreshGcloudADC() error {
+	if !common.IsInteractive() {
+		return common.NewValidationError("'gcloud auth application-default login' requires an interactive terminal")
+	}
+
+	args := []string{"auth", "application-default", "login"}
+	// The command is killed if the CLI receives SIGINT/SIGTERM
+	cmd := common.CommandContext(config.GcloudPath, args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	common.Logger("debug", "Executing command: gcloud %s", strings.Join(args, " "))
+	err := cmd.Run()
+	if errInterrupted := common.CheckInterrupted(); errInterrupted != nil {
+		return errInterrupted
+	}
+	if err != nil {
+		return common.NewExternalCommandError("gcloud command 'gcloud %s' failed: %w", strings.Join(args, " "), err)
+	}
+	return nil
+}
+
+// ValidateGCPRegion checks if the region exists and is available for the project,
+// using 'gcloud compute regions describe' command.
+// Returns a validation error for unknown, invalid or unavailable regions.
+func ValidateGCPRegion(projectID, region string) error {
+	if projectID == "" || region == "" {
+		return common.NewValidationError("projectID and region are required in ValidateGCPRegion function")
+	}
+
+	common.Logger("debug", "Validating GCP region '%s' for project '%s'", region, projectID)
+	args := []string{
+		"compute", "regions", "describe", region,
+		"--project", projectID,
+		"--format=value(status)",
+	}
+	stdout, _, err := RunGcloudCommand(args...)
+	if err != nil {
+		if common.ExitCode(err) == common.ExitCodeInterrupted {
+			return err
+		}
+		return common.NewValidationError("GCP region '%s' doesn't exist or isn't enabled for project '%s'. Check the -R option or CLI_GCP_REGION variable: %v", region, projectID, err)
+	}
+
+	if status := strings.TrimSpace(stdout); status != "" && status != "UP" {
+		return common.NewValidationError("GCP region '%s' is not available for project '%s' (status: %s)", region, projectID, status)
+	}
+	return nil
+}
+
+// GcloudConfigStatus is the active configuration of gcloud compared with the configuration of CLI,
+// returned by GetGcloudConfigStatus function.
+type GcloudConfigStatus struct {
+	Account         string   `json:"account"`
+	GcloudProject   string   `json:"gcloudProject"`
+	GcloudRegion    string   `json:"gcloudRegion"`
+	Project         string   `json:"project"`
+	Region          string   `json:"region"`
+	ProjectMismatch bool     `json:"projectMismatch"`
+	RegionMismatch  bool     `json:"regionMismatch"`
+	Warnings        []string `json:"warnings,omitempty"`
+}
+
+// ParseGcloudConfigList parses the output of 'gcloud config list --format=json' and compares
+// the active project and region of gcloud with the project (projectID) and region configured in CLI.
+// The unset values of gcloud are not considered mismatches.
+func ParseGcloudConfigList(data []byte, projectID, region string) (GcloudConfigStatus, error) {
+	var gcloudConfig struct {
+		Core struct {
+			Account string `json:"account"`
+			Project string `json:"project"`
+		} `json:"core"`
+		Compute struct {
+			Region string `json:"region"`
+		} `json:"compute"`
+	}
+	if err := json.Unmarshal(data, &gcloudConfig); err != nil {
+		return GcloudConfigStatus{}, fmt.Errorf("failed to parse gcloud config: %w", err)
+	}
+
+	status := GcloudConfigStatus{
+		Account:       gcloudConfig.Core.Account,
+		GcloudProject: gcloudConfig.Core.Project,
+		GcloudRegion:  gcloudConfig.Compute.Region,
+		Project:       projectID,
+		Region:        region,
+	}
+	if status.GcloudProject != "" && status.GcloudProject != projectID {
+		status.ProjectMismatch = true
+		status.Warnings = append(status.Warnings, fmt.Sprintf("The active project of gcloud ('%s') differs from the project of CLI ('%s')", status.GcloudProject, projectID))
+	}
+	if status.GcloudRegion != "" && status.GcloudRegion != region {
+		status.RegionMismatch = true
+		status.Warnings = append(status.Warnings, fmt.Sprintf("The active region of gcloud ('%s') differs from the region of CLI ('%s')", status.GcloudRegion, region))
+	}
+	return status, nil
+}
+
+// GetGcloudConfigStatus runs 'gcloud config list' and compares the active project and region of gcloud
+// with the project (projectID) and region configured in CLI.
+func GetGcloudConfigStatus(projectID, region string) (GcloudConfigStatus, error) {
+	stdout, _, err := RunGcloudCommand("config", "list", "--format=json")
+	if err != nil {
+		if common.ExitCode(err) == common.ExitCodeInterrupted {
+			return GcloudConfigStatus{}, err
+		}
+		return GcloudConfigStatus{}, common.NewExternalCommandError("failed to read gcloud config: %w", err)
+	}
+	return ParseGcloudConfigList([]byte(stdout), projectID, region)
+}
+
+// GetGcloudConfigValue runs 'gcloud config get-value <property>' and returns the value of property
+// (e.g. project or compute/region) in the active configuration. An empty string is returned if it is not set.
+func GetGcloudConfigValue(property string) (string, error) {
+	stdout, _, err := RunGcloudCommand("config", "get-value", property)
+	if err != nil {
+		if common.ExitCode(err) == common.ExitCodeInterrupted {
+			return "", err
+		}
+		return "", common.NewExternalCommandError("failed to read the property '%s' of gcloud config: %w", property, err)
+	}
+	value := strings.TrimSpace(stdout)
+	// gcloud prints '(unset)' in some versions when the property is not set
+	if value == "(unset)" {
+		value = ""
+	}
+	return value, nil
+}
+
+// BuildGcloudConfigSetArgs returns the arguments of 'gcloud config set' command for the property and value.
+func BuildGcloudConfigSetArgs(property, value string) []string {
+	return []string{"config", "set", property, value}
+}
+
+// SetGcloudConfigValue sets the property (e.g. project or compute/region) of the active configuration
+// of gcloud to value and returns the value before the change (empty if it was not set).
+func SetGcloudConfigValue(property, value string) (string, error) {
+	if value == "" {
+		return "", common.NewValidationError("the value of gcloud property '%s' cannot be empty", property)
+	}
+
+	before, err := GetGcloudConfigValue(property)
+	if err != nil {
+		return "", err
+	}
+
+	if _, _, err := RunGcloudCommand(BuildGcloudConfigSetArgs(property, value)...); err != nil {
+		if common.ExitCode(err) == common.ExitCodeInterrupted {
+			return before, err
+		}
+		return before, common.NewExternalCommandError("failed to set the property '%s' of gcloud config to '%s': %w", property, value, err)
+	}
+	return before, nil
 }
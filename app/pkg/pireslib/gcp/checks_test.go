@@ -0,0 +1,451 @@
+package gcp
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aeciopires/pires-cli/internal/config"
+	"github.com/aeciopires/pires-cli/pkg/pireslib/common"
+)
+
+// echoArgsScript is the script of a fake gcloud that prints its arguments, one per line
+const echoArgsScript = `printf '%s\n' "$@"`
+
+// fakeGcloud replaces config.GcloudPath by a shell script with the body during the test
+func fakeGcloud(t testing.TB, body string) {
+	t.Helper()
+	script := filepath.Join(t.TempDir(), "gcloud")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\n"+body+"\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	previous := config.GcloudPath
+	config.GcloudPath = script
+	t.Cleanup(func() { config.GcloudPath = previous })
+}
+
+// setGcloudExtraArgs replaces config.GcloudExtraArgs and config.GCPImpersonateServiceAccount during the test
+func setGcloudExtraArgs(t *testing.T, impersonate string, extraArgs ...string) {
+	t.Helper()
+	previousArgs, previousImpersonate := config.GcloudExtraArgs, config.GCPImpersonateServiceAccount
+	config.GcloudExtraArgs, config.GCPImpersonateServiceAccount = extraArgs, impersonate
+	t.Cleanup(func() {
+		config.GcloudExtraArgs, config.GCPImpersonateServiceAccount = previousArgs, previousImpersonate
+	})
+}
+
+func TestRunGcloudPrimaryCommandAppendsExtraArgsLast(t *testing.T) {
+	fakeGcloud(t, echoArgsScript)
+	setGcloudExtraArgs(t, "admin@p.iam.gserviceaccount.com", "--labels=team=ops", "--async")
+
+	stdout, _, err := RunGcloudPrimaryCommand("sql", "instances", "create", "db", "--project", "p")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := strings.Fields(stdout)
+	want := []string{"sql", "instances", "create", "db", "--project", "p", "--impersonate-service-account=admin@p.iam.gserviceaccount.com", "--labels=team=ops", "--async"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("args = %v, want %v", got, want)
+	}
+}
+
+func TestRunGcloudCommandWithoutExtraArgs(t *testing.T) {
+	fakeGcloud(t, echoArgsScript)
+	setGcloudExtraArgs(t, "", "--labels=team=ops")
+
+	// The helper commands (e.g. describe of operations) don't receive the extra arguments
+	stdout, _, err := RunGcloudCommand("sql", "operations", "describe", "op-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(stdout, "--labels") {
+		t.Errorf("helper command received extra arguments: %q", stdout)
+	}
+}
+
+func TestRunGcloudPrimaryCommandLocalCommands(t *testing.T) {
+	fakeGcloud(t, echoArgsScript)
+	setGcloudExtraArgs(t, "admin@p.iam.gserviceaccount.com", "--labels=team=ops")
+
+	// The config and auth groups only read the local configuration of gcloud
+	stdout, _, err := RunGcloudPrimaryCommand("config", "get-value", "account")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(strings.Fields(stdout), " "); got != "config get-value account" {
+		t.Errorf("args = %q, want 'config get-value account'", got)
+	}
+}
+
+func TestRunGcloudCommandInterrupted(t *testing.T) {
+	// The fake takes 10 seconds, but the context of CLI is cancelled in the middle of the command
+	fakeGcloud(t, "sleep 10")
+	ctx, cancel := context.WithCancel(context.Background())
+	previous := common.Context()
+	common.SetContext(ctx)
+	t.Cleanup(func() { common.SetContext(previous) })
+
+	time.AfterFunc(50*time.Millisecond, cancel)
+	start := time.Now()
+	_, _, err := RunGcloudCommand("sql", "instances", "list")
+	if common.ExitCode(err) != common.ExitCodeInterrupted {
+		t.Errorf("error = %v, want interrupted error", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("returned after %s, want early return", elapsed)
+	}
+}
+
+func TestGetGcloudAuthStatusAuthenticated(t *testing.T) {
+	fakeGcloud(t, `case "$*" in
+"config get-value account") echo operator@example.com ;;
+"config get-value project") echo gcloud-project ;;
+"auth application-default print-access-token") echo secret-token ;;
+*) exit 1 ;;
+esac`)
+
+	status := GetGcloudAuthStatus("cli-project")
+	want := GcloudAuthStatus{
+		Authenticated: true,
+		Account:       "operator@example.com",
+		ADCAvailable:  true,
+		Project:       "cli-project",
+		GcloudProject: "gcloud-project",
+	}
+	if status != want {
+		t.Errorf("GetGcloudAuthStatus() = %+v, want %+v", status, want)
+	}
+}
+
+func TestGetGcloudAuthStatusNotAuthenticated(t *testing.T) {
+	fakeGcloud(t, `case "$*" in
+"config get-value project") echo gcloud-project ;;
+"auth application-default print-access-token") echo "ERROR: Reauthentication failed" >&2; exit 1 ;;
+*) exit 0 ;;
+esac`)
+
+	// The status is returned without exiting, even if gcloud is not authenticated
+	status := GetGcloudAuthStatus("cli-project")
+	want := GcloudAuthStatus{Project: "cli-project", GcloudProject: "gcloud-project"}
+	if status != want {
+		t.Errorf("GetGcloudAuthStatus() = %+v, want %+v", status, want)
+	}
+
+	if err := CheckGcloudADC(); common.ExitCode(err) != common.ExitCodeExternalCommand {
+		t.Errorf("CheckGcloudADC() = %v, want external command error", err)
+	}
+}
+
+func TestRunPsqlCommandUsesConfiguredPath(t *testing.T) {
+	logFile := fakePsql(t, `echo "configured psql"`)
+
+	stdout, _, err := RunPsqlCommand("host=127.0.0.1 dbname=app", "-At", "-c", "SELECT 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(stdout) != "configured psql" {
+		t.Errorf("stdout = %q, want output of configured psql", stdout)
+	}
+	if calls, _ := os.ReadFile(logFile); strings.TrimSpace(string(calls)) != "app" {
+		t.Errorf("psql connections = %q, want app", calls)
+	}
+}
+
+// regionsScript is a fake gcloud describing the regions us-central1 (UP) and us-west9 (DOWN). Other regions don't exist.
+const regionsScript = `case "$*" in
+"compute regions describe us-central1 --project my-project --format=value(status)") echo UP ;;
+"compute regions describe us-west9 --project my-project --format=value(status)") echo DOWN ;;
+*) echo "ERROR: (gcloud.compute.regions.describe) Could not fetch resource: Unknown region." >&2; exit 1 ;;
+esac`
+
+func TestValidateGCPRegion(t *testing.T) {
+	fakeGcloud(t, regionsScript)
+
+	tests := []struct {
+		region   string
+		wantCode int
+	}{
+		{"us-central1", 0},
+		{"us-west9", common.ExitCodeValidation},
+		{"moon-base1", common.ExitCodeValidation},
+		{"", common.ExitCodeValidation},
+	}
+	for _, tt := range tests {
+		t.Run(tt.region, func(t *testing.T) {
+			if got := common.ExitCode(ValidateGCPRegion("my-project", tt.region)); got != tt.wantCode {
+				t.Errorf("exit code = %d, want %d", got, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestValidateGCPRegionUnknownMessage(t *testing.T) {
+	fakeGcloud(t, regionsScript)
+
+	err := ValidateGCPRegion("my-project", "moon-base1")
+	if err == nil || !strings.Contains(err.Error(), "GCP region 'moon-base1' doesn't exist") || !strings.Contains(err.Error(), "-R option") {
+		t.Errorf("error = %v, want actionable message of unknown region", err)
+	}
+}
+
+// failingOncePsqlScript is a fake psql failing with the error of $CLI_TEST_PSQL_ERROR in the first call.
+// The calls are counted in the file of $CLI_TEST_COUNTER.
+const failingOncePsqlScript = `count=$(cat "$CLI_TEST_COUNTER" 2>/dev/null || echo 0)
+echo $((count + 1)) > "$CLI_TEST_COUNTER"
+if [ "$count" = 0 ]; then echo "$CLI_TEST_PSQL_ERROR" >&2; exit 2; fi
+echo "app_user|public.orders|SELECT"`
+
+// fakeFailingOncePsql configures failingOncePsqlScript and returns the file with the number of calls
+func fakeFailingOncePsql(t *testing.T, psqlError string) string {
+	t.Helper()
+	fakePsql(t, failingOncePsqlScript)
+	counter := filepath.Join(t.TempDir(), "counter")
+	t.Setenv("CLI_TEST_COUNTER", counter)
+	t.Setenv("CLI_TEST_PSQL_ERROR", psqlError)
+	return counter
+}
+
+func TestIsRetryablePsqlError(t *testing.T) {
+	tests := map[string]bool{
+		"psql: error: connection to server at \"10.0.0.5\", port 5432 failed: Connection refused": true,
+		"server closed the connection unexpectedly":                                               true,
+		"SSL SYSCALL error: EOF detected":                                                         true,
+		"FATAL: permission denied for database \"app\"":                                           false,
+		"ERROR: syntax error at or near \"SELEC\"":                                                false,
+	}
+	for stderr, want := range tests {
+		if got := isRetryablePsqlError(stderr); got != want {
+			t.Errorf("isRetryablePsqlError(%q) = %t, want %t", stderr, got, want)
+		}
+	}
+}
+
+func TestRunPsqlCommandWithRetryTransientError(t *testing.T) {
+	counter := fakeFailingOncePsql(t, "could not receive data from server: Connection reset by peer")
+
+	stdout, _, err := RunPsqlCommandWithRetry(3, time.Millisecond, "dbname=app", "-At", "-c", "SELECT 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(stdout) != "app_user|public.orders|SELECT" {
+		t.Errorf("stdout = %q, want rows of second attempt", stdout)
+	}
+	if calls, _ := os.ReadFile(counter); strings.TrimSpace(string(calls)) != "2" {
+		t.Errorf("psql executed %s time(s), want 2", calls)
+	}
+}
+
+func TestRunPsqlCommandWithRetryPermanentError(t *testing.T) {
+	counter := fakeFailingOncePsql(t, "FATAL: permission denied for database \"app\"")
+
+	if _, _, err := RunPsqlCommandWithRetry(3, time.Millisecond, "dbname=app", "-At", "-c", "SELECT 1"); err == nil {
+		t.Fatal("expected error of permission denied")
+	}
+	if calls, _ := os.ReadFile(counter); strings.TrimSpace(string(calls)) != "1" {
+		t.Errorf("psql executed %s time(s), want 1 (no retry)", calls)
+	}
+}
+
+func TestRunGcloudCommandImpersonation(t *testing.T) {
+	fakeGcloud(t, echoArgsScript)
+	setGcloudExtraArgs(t, "admin@p.iam.gserviceaccount.com")
+
+	// The helper commands run as the impersonated service account too
+	stdout, _, err := RunGcloudCommand("sql", "instances", "list", "--project", "p")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "sql instances list --project p --impersonate-service-account=admin@p.iam.gserviceaccount.com"
+	if got := strings.Join(strings.Fields(stdout), " "); got != want {
+		t.Errorf("args = %q, want %q", got, want)
+	}
+}
+
+func TestCheckGcloudAdminPermissionsImpersonatedAccount(t *testing.T) {
+	// Only the impersonated service account has the required role, the user hasn't
+	fakeGcloud(t, `case "$*" in
+"config get-value account") echo someone@example.com ;;
+*"bindings.members:serviceAccount:admin@p.iam.gserviceaccount.com"*"--impersonate-service-account=admin@p.iam.gserviceaccount.com") echo `+config.GCPRequiredRole+` ;;
+esac`)
+	setGcloudExtraArgs(t, "admin@p.iam.gserviceaccount.com")
+
+	if err := CheckGcloudAdminPermissions("p"); err != nil {
+		t.Errorf("CheckGcloudAdminPermissions() = %v, want the role of impersonated account", err)
+	}
+}
+
+func TestCheckGcloudAdminPermissionsErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		gcloud   string
+		wantCode int
+	}{
+		{"not authenticated", "exit 0", common.ExitCodePermissionDenied},
+		{"authentication failure", "exit 1", common.ExitCodePermissionDenied},
+		{"missing role", `[ "$*" = "config get-value account" ] && echo someone@example.com; exit 0`, common.ExitCodePermissionDenied},
+		{"policy not readable", `[ "$*" = "config get-value account" ] && echo someone@example.com && exit 0; exit 1`, common.ExitCodeExternalCommand},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeGcloud(t, tt.gcloud)
+			if got := common.ExitCode(CheckGcloudAdminPermissions("p")); got != tt.wantCode {
+				t.Errorf("exit code = %d, want %d", got, tt.wantCode)
+			}
+		})
+	}
+
+	if got := common.ExitCode(CheckGcloudAdminPermissions("")); got != common.ExitCodeValidation {
+		t.Errorf("exit code without project = %d, want %d", got, common.ExitCodeValidation)
+	}
+}
+
+func TestParseGcloudConfigListProjectMismatch(t *testing.T) {
+	data := []byte(`{"core": {"account": "operator@example.com", "project": "other-project"}, "compute": {"region": "us-central1"}}`)
+
+	status, err := ParseGcloudConfigList(data, "my-project", "us-central1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Account != "operator@example.com" || status.GcloudProject != "other-project" {
+		t.Errorf("status = %+v", status)
+	}
+	if !status.ProjectMismatch || status.RegionMismatch {
+		t.Errorf("ProjectMismatch = %v, RegionMismatch = %v, want only project mismatch", status.ProjectMismatch, status.RegionMismatch)
+	}
+	if len(status.Warnings) != 1 || !strings.Contains(status.Warnings[0], "other-project") {
+		t.Errorf("Warnings = %v, want the project mismatch", status.Warnings)
+	}
+}
+
+func TestParseGcloudConfigListUnsetValues(t *testing.T) {
+	// The unset project and region of gcloud are not mismatches
+	status, err := ParseGcloudConfigList([]byte(`{"core": {"account": "operator@example.com"}}`), "my-project", "us-central1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.ProjectMismatch || status.RegionMismatch || len(status.Warnings) != 0 {
+		t.Errorf("status = %+v, want no mismatch", status)
+	}
+
+	if _, err := ParseGcloudConfigList([]byte("not json"), "my-project", "us-central1"); err == nil {
+		t.Error("expected error for invalid JSON")
+	}
+}
+
+func TestGetGcloudConfigStatus(t *testing.T) {
+	fakeGcloud(t, `[ "$*" = "config list --format=json" ] || exit 1
+echo '{"core": {"project": "my-project"}, "compute": {"region": "us-east1"}}'`)
+
+	status, err := GetGcloudConfigStatus("my-project", "us-central1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.ProjectMismatch || !status.RegionMismatch {
+		t.Errorf("status = %+v, want only region mismatch", status)
+	}
+
+	fakeGcloud(t, "exit 1")
+	if _, err := GetGcloudConfigStatus("my-project", "us-central1"); common.ExitCode(err) != common.ExitCodeExternalCommand {
+		t.Errorf("error = %v, want external command error", err)
+	}
+}
+
+// gcloudConfigScript is a fake gcloud storing the properties of 'config set' in the directory $CLI_TEST_CONFIG
+// and logging the calls of 'config set' in $CLI_TEST_CONFIG/calls
+const gcloudConfigScript = `property_file="$CLI_TEST_CONFIG/$(echo "$3" | tr / _)"
+case "$1 $2" in
+"config get-value") if [ -f "$property_file" ]; then cat "$property_file"; else echo "(unset)"; fi ;;
+"config set") echo "$*" >> "$CLI_TEST_CONFIG/calls"; echo "$4" > "$property_file" ;;
+*) exit 1 ;;
+esac`
+
+func TestSetGcloudConfigValue(t *testing.T) {
+	configDir := t.TempDir()
+	t.Setenv("CLI_TEST_CONFIG", configDir)
+	fakeGcloud(t, gcloudConfigScript)
+
+	// The unset value is returned as empty
+	before, err := SetGcloudConfigValue("project", "nonprod")
+	if err != nil || before != "" {
+		t.Fatalf("first SetGcloudConfigValue() = %q, %v, want empty value before", before, err)
+	}
+	before, err = SetGcloudConfigValue("project", "prod")
+	if err != nil || before != "nonprod" {
+		t.Fatalf("second SetGcloudConfigValue() = %q, %v, want nonprod before", before, err)
+	}
+	if _, err := SetGcloudConfigValue("compute/region", "us-central1"); err != nil {
+		t.Fatal(err)
+	}
+
+	if value, err := GetGcloudConfigValue("project"); err != nil || value != "prod" {
+		t.Errorf("GetGcloudConfigValue(project) = %q, %v, want prod", value, err)
+	}
+	calls, err := os.ReadFile(filepath.Join(configDir, "calls"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "config set project nonprod\nconfig set project prod\nconfig set compute/region us-central1\n"
+	if string(calls) != want {
+		t.Errorf("gcloud calls = %q, want %q", calls, want)
+	}
+}
+
+func TestSetGcloudConfigValueErrors(t *testing.T) {
+	configDir := t.TempDir()
+	t.Setenv("CLI_TEST_CONFIG", configDir)
+	fakeGcloud(t, gcloudConfigScript)
+
+	if _, err := SetGcloudConfigValue("project", ""); common.ExitCode(err) != common.ExitCodeValidation {
+		t.Errorf("error = %v, want validation error", err)
+	}
+	if _, err := os.Stat(filepath.Join(configDir, "calls")); !os.IsNotExist(err) {
+		t.Error("gcloud config set executed with an empty value")
+	}
+
+	fakeGcloud(t, `[ "$2" = "get-value" ] && echo nonprod || exit 1`)
+	before, err := SetGcloudConfigValue("project", "prod")
+	if common.ExitCode(err) != common.ExitCodeExternalCommand || before != "nonprod" {
+		t.Errorf("SetGcloudConfigValue() = %q, %v, want nonprod and external command error", before, err)
+	}
+}
+
+func TestCheckGcloudADC(t *testing.T) {
+	fakeGcloud(t, `[ "$*" = "auth application-default print-access-token" ] && echo ya29.secret-token`)
+	if err := CheckGcloudADC(); err != nil {
+		t.Errorf("CheckGcloudADC() = %v, want valid ADC", err)
+	}
+
+	fakeGcloud(t, `echo "ERROR: (gcloud.auth.application-default.print-access-token) File not found" >&2; exit 1`)
+	err := CheckGcloudADC()
+	if common.ExitCode(err) != common.ExitCodeExternalCommand || !strings.Contains(err.Error(), "File not found") {
+		t.Errorf("CheckGcloudADC() = %v, want external command error with stderr of gcloud", err)
+	}
+}
+
+func TestRefreshGcloudADC(t *testing.T) {
+	previous := common.IsInteractive
+	t.Cleanup(func() { common.IsInteractive = previous })
+	argsFile := filepath.Join(t.TempDir(), "args")
+	t.Setenv("CLI_TEST_ARGS", argsFile)
+	fakeGcloud(t, `echo "$*" > "$CLI_TEST_ARGS"`)
+
+	// The login flow requires a terminal
+	common.IsInteractive = func() bool { return false }
+	if err := RefreshGcloudADC(); common.ExitCode(err) != common.ExitCodeValidation {
+		t.Errorf("RefreshGcloudADC() = %v, want validation error without terminal", err)
+	}
+	if _, err := os.Stat(argsFile); !os.IsNotExist(err) {
+		t.Error("gcloud was executed without terminal")
+	}
+
+	common.IsInteractive = func() bool { return true }
+	if err := RefreshGcloudADC(); err != nil {
+		t.Fatal(err)
+	}
+	if content, _ := os.ReadFile(argsFile); string(content) != "auth application-default login\n" {
+		t.Errorf("gcloud args = %q, want login of ADC", content)
+	}
+}
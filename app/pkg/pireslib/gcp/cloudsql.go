@@ -2,16 +2,48 @@
 package gcp
 
 import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
 	"strings"
+	"time"
 
+	"github.com/aeciopires/pires-cli/internal/config"
 	"github.com/aeciopires/pires-cli/pkg/pireslib/common"
 )
 
+// Regular expressions used to validate the parts of an instance connection name
+var (
+	cloudSQLRegionRegex     = regexp.MustCompile(`^[a-z]+-[a-z]+[0-9]+$`)
+	cloudSQLInstanceIDRegex = regexp.MustCompile(`^[a-z][a-z0-9-]*$`)
+)
+
+// CloudSQLOperation represents the relevant fields of a Cloud SQL operation
+// returned by 'gcloud sql operations describe --format=json'.
+type CloudSQLOperation struct {
+	Name          string `json:"name"`
+	OperationType string `json:"operationType"`
+	Status        string `json:"status"`
+	TargetID      string `json:"targetId"`
+	// BackupContext is returned only by the operations of backups
+	BackupContext *struct {
+		BackupID string `json:"backupId"`
+	} `json:"backupContext,omitempty"`
+	Error *struct {
+		Errors []struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"errors"`
+	} `json:"error,omitempty"`
+}
+
 // CreateGCPCloudSQLUser creates a new user in a Cloud SQL instance using gcloud command.
 // host defaults to '%' if empty.
-func CreateGCPCloudSQLUser(projectID, instanceID, userName, password, host string) {
+// If the user already exists, a warning is logged and nil is returned.
+func CreateGCPCloudSQLUser(projectID, instanceID, userName, password, host string) error {
 	if projectID == "" || instanceID == "" || userName == "" {
-		common.Logger("fatal", "projectID, instanceID and userName are required to create SQL user in CreateGCPCloudSQLUser function.")
+		return common.NewValidationError("projectID, instanceID and userName are required to create SQL user in CreateGCPCloudSQLUser function")
 	}
 	// Password can be empty for some DB types or if managed externally (e.g., IAM DB auth)
 
@@ -32,26 +64,100 @@ func CreateGCPCloudSQLUser(projectID, instanceID, userName, password, host strin
 	if password != "" {
 		args = append(args, "--password", password)
 	} else {
-		common.Logger("fatal", "No password provided for SQL user '%s'. `gcloud` might prompt if interactive, or creation might expect IAM authentication / no password.", userName)
+		return common.NewValidationError("no password provided for SQL user '%s'. `gcloud` might prompt if interactive, or creation might expect IAM authentication / no password", userName)
 	}
 
-	_, stderr, err := RunGcloudCommand(args...)
+	_, stderr, err := RunGcloudPrimaryCommand(args...)
 	if err != nil {
 		// Check stderr for common issues like user already exists
 		if strings.Contains(stderr, "already exists") {
 			common.Logger("warning", "SQL user '%s'@'%s' already exists on instance '%s' on project '%s'.", userName, host, instanceID, projectID)
-		} else {
-			common.Logger("fatal", "Failed to create SQL user '%s' on instance '%s' on project '%s': %w. Stderr: %s", userName, instanceID, projectID, err, stderr)
+			return nil
 		}
+		if common.ExitCode(err) == common.ExitCodeInterrupted {
+			return err
+		}
+		return common.NewExternalCommandError("failed to create SQL user '%s' on instance '%s' on project '%s': %v. Stderr: %s", userName, instanceID, projectID, err, stderr)
 	}
 
 	common.Logger("info", "SQL user '%s'@'%s' created successfully for instance '%s' on project '%s'.", userName, host, instanceID, projectID)
+	return nil
+}
+
+// SetGCPCloudSQLUserPassword changes the password of a user of a Cloud SQL instance using gcloud command.
+// host is required only for MySQL users. The password is registered as secret and never displayed by the logs or errors.
+func SetGCPCloudSQLUserPassword(projectID, instanceID, userName, host, password string) error {
+	if projectID == "" || instanceID == "" || userName == "" || password == "" {
+		return common.NewValidationError("projectID, instanceID, userName and password are required to set password in SetGCPCloudSQLUserPassword function")
+	}
+	common.RegisterSecret(password)
+
+	common.Logger("info", "Setting password of SQL user '%s' in instance '%s' on project '%s'...", userName, instanceID, projectID)
+	args := []string{
+		"sql", "users", "set-password", userName,
+		"--instance", instanceID,
+		"--project", projectID,
+		"--password", password,
+	}
+	if host != "" {
+		args = append(args, "--host", host)
+	}
+
+	if _, stderr, err := RunGcloudPrimaryCommand(args...); err != nil {
+		if common.ExitCode(err) == common.ExitCodeInterrupted {
+			return err
+		}
+		// The error of RunGcloudCommand contains the arguments, so a new error is returned without the password
+		return common.NewExternalCommandError("failed to set password of SQL user '%s' in instance '%s' on project '%s'. Stderr: %s", userName, instanceID, projectID, common.RedactSecrets(stderr))
+	}
+
+	common.Logger("info", "Password of SQL user '%s' changed successfully in instance '%s' on project '%s'.", userName, instanceID, projectID)
+	return nil
+}
+
+// CreateGCPCloudSQLInstance creates a Cloud SQL instance using 'gcloud sql instances create' command.
+// dbVersion is like POSTGRES_16 or MYSQL_8_0 and tier is like db-custom-2-7680 or db-f1-micro.
+// The creation is asynchronous: it returns the ID of operation, that can be waited by WaitForGCPCloudSQLOperation function.
+// If the instance already exists, a warning is logged and an empty operation ID is returned.
+func CreateGCPCloudSQLInstance(projectID, instanceID, dbVersion, tier, region string) (string, error) {
+	if projectID == "" || instanceID == "" || dbVersion == "" || tier == "" || region == "" {
+		return "", common.NewValidationError("projectID, instanceID, dbVersion, tier and region are required to create SQL instance in CreateGCPCloudSQLInstance function")
+	}
+	if !cloudSQLInstanceIDRegex.MatchString(instanceID) {
+		return "", common.NewValidationError("invalid instance ID '%s'. It must start with a letter and contain only lowercase letters, numbers and hyphens", instanceID)
+	}
+
+	common.Logger("info", "Creating SQL instance '%s' (%s, %s) in region '%s' on project '%s'...", instanceID, dbVersion, tier, region, projectID)
+	args := []string{
+		"sql", "instances", "create", instanceID,
+		"--project", projectID,
+		"--database-version", dbVersion,
+		"--tier", tier,
+		"--region", region,
+		// Return the operation instead of blocking. See WaitForGCPCloudSQLOperation function
+		"--async",
+		"--format=value(name)",
+	}
+
+	stdout, stderr, err := RunGcloudPrimaryCommand(args...)
+	if err != nil {
+		if strings.Contains(stderr, "already exists") {
+			common.Logger("warning", "SQL instance '%s' already exists on project '%s'.", instanceID, projectID)
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to create SQL instance '%s' on project '%s': %w", instanceID, projectID, err)
+	}
+
+	operationID := strings.TrimSpace(stdout)
+	common.Logger("info", "Creation of SQL instance '%s' started. Operation: %s", instanceID, operationID)
+	return operationID, nil
 }
 
 // CreateGCPCloudSQLDatabase creates a new database in a Cloud SQL instance using gcloud command.
-func CreateGCPCloudSQLDatabase(projectID, instanceID, dbName, charset, collation string) {
+// If the database already exists, a warning is logged and nil is returned.
+func CreateGCPCloudSQLDatabase(projectID, instanceID, dbName, charset, collation string) error {
 	if projectID == "" || instanceID == "" || dbName == "" {
-		common.Logger("fatal", "projectID, instanceID, and dbName are required to create SQL database in CreateGCPCloudSQLDatabase function.")
+		return common.NewValidationError("projectID, instanceID, and dbName are required to create SQL database in CreateGCPCloudSQLDatabase function")
 	}
 
 	common.Logger("info", "Creating SQL database '%s' for instance '%s' on project '%s' ...", dbName, instanceID, projectID)
@@ -68,14 +174,487 @@ func CreateGCPCloudSQLDatabase(projectID, instanceID, dbName, charset, collation
 		args = append(args, "--collation", collation)
 	}
 
-	_, stderr, err := RunGcloudCommand(args...)
+	_, stderr, err := RunGcloudPrimaryCommand(args...)
 	if err != nil {
 		if strings.Contains(stderr, "already exists") {
 			common.Logger("warning", "SQL database '%s' already exists on instance '%s' on project '%s'.", dbName, instanceID, projectID)
-		} else {
-			common.Logger("fatal", "Failed to create SQL database '%s' on instance '%s' on project '%s': %w. Stderr: %s", dbName, instanceID, projectID, err, stderr)
+			return nil
+		}
+		if common.ExitCode(err) == common.ExitCodeInterrupted {
+			return err
 		}
+		return common.NewExternalCommandError("failed to create SQL database '%s' on instance '%s' on project '%s': %v. Stderr: %s", dbName, instanceID, projectID, err, stderr)
 	}
 
 	common.Logger("info", "SQL database '%s' created successfully for instance '%s' on project '%s'.", dbName, instanceID, projectID)
+	return nil
+}
+
+// DescribeGCPCloudSQLOperation returns the current state of a Cloud SQL operation using gcloud command.
+func DescribeGCPCloudSQLOperation(projectID, operationID string) (*CloudSQLOperation, error) {
+	args := []string{
+		"sql", "operations", "describe", operationID,
+		"--project", projectID,
+		"--format=json",
+	}
+
+	stdout, _, err := RunGcloudCommand(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var operation CloudSQLOperation
+	if err := json.Unmarshal([]byte(stdout), &operation); err != nil {
+		return nil, fmt.Errorf("failed to parse Cloud SQL operation '%s': %w", operationID, err)
+	}
+	return &operation, nil
+}
+
+// WaitForGCPCloudSQLOperation polls a Cloud SQL operation until its status is DONE or the timeout is reached.
+// The poll interval is defined by config.GCPCloudSQLOperationPollInterval.
+// If the operation finished with errors, they are returned.
+func WaitForGCPCloudSQLOperation(projectID, operationID string, timeout time.Duration) error {
+	if projectID == "" || operationID == "" {
+		return common.NewValidationError("projectID and operationID are required to wait for operation in WaitForGCPCloudSQLOperation function")
+	}
+
+	common.Logger("info", "Waiting for Cloud SQL operation '%s' on project '%s' (timeout: %s)...", operationID, projectID, timeout)
+	deadline := time.Now().Add(timeout)
+
+	for {
+		operation, err := DescribeGCPCloudSQLOperation(projectID, operationID)
+		if err != nil {
+			return err
+		}
+		common.Logger("debug", "Cloud SQL operation '%s' status: %s", operationID, operation.Status)
+
+		if operation.Status == "DONE" {
+			if operation.Error != nil && len(operation.Error.Errors) > 0 {
+				var messages []string
+				for _, opErr := range operation.Error.Errors {
+					messages = append(messages, fmt.Sprintf("%s: %s", opErr.Code, opErr.Message))
+				}
+				return fmt.Errorf("Cloud SQL operation '%s' (%s) finished with errors: %s", operationID, operation.OperationType, strings.Join(messages, "; "))
+			}
+			common.Logger("info", "Cloud SQL operation '%s' (%s) finished successfully.", operationID, operation.OperationType)
+			return nil
+		}
+
+		if time.Now().Add(config.GCPCloudSQLOperationPollInterval).After(deadline) {
+			return fmt.Errorf("timeout after %s waiting for Cloud SQL operation '%s' (last status: %s)", timeout, operationID, operation.Status)
+		}
+		time.Sleep(config.GCPCloudSQLOperationPollInterval)
+	}
+}
+
+// CloudSQLInstance represents the relevant fields of a Cloud SQL instance
+// returned by 'gcloud sql instances list --format=json'.
+type CloudSQLInstance struct {
+	Name            string `json:"name"`
+	DatabaseVersion string `json:"databaseVersion"`
+	Region          string `json:"region"`
+	State           string `json:"state"`
+}
+
+// ListGCPCloudSQLInstances lists the Cloud SQL instances of a project using gcloud command.
+// An empty slice is returned when the project has no instances.
+func ListGCPCloudSQLInstances(projectID string) ([]CloudSQLInstance, error) {
+	if projectID == "" {
+		return nil, common.NewValidationError("projectID is required to list Cloud SQL instances in ListGCPCloudSQLInstances function")
+	}
+
+	common.Logger("debug", "Listing Cloud SQL instances on project '%s'...", projectID)
+	args := []string{
+		"sql", "instances", "list",
+		"--project", projectID,
+		"--format=json",
+	}
+
+	stdout, _, err := RunGcloudCommand(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseGCPCloudSQLInstances(stdout)
+}
+
+// ParseGCPCloudSQLInstances parses the JSON output of 'gcloud sql instances list --format=json'.
+func ParseGCPCloudSQLInstances(jsonOutput string) ([]CloudSQLInstance, error) {
+	instances := []CloudSQLInstance{}
+	if strings.TrimSpace(jsonOutput) == "" {
+		return instances, nil
+	}
+	if err := json.Unmarshal([]byte(jsonOutput), &instances); err != nil {
+		return nil, fmt.Errorf("failed to parse Cloud SQL instances: %w", err)
+	}
+	return instances, nil
+}
+
+// BuildGCPCloudSQLBackupURI returns the GCS URI of a backup of a Cloud SQL instance,
+// with a timestamped object name. The bucket can be passed with or without the 'gs://' prefix.
+// Example: gs://my-bucket/nonprod-psql-20250101-120000.sql.gz
+func BuildGCPCloudSQLBackupURI(bucket, instanceID string, now time.Time) string {
+	bucket = strings.TrimSuffix(strings.TrimPrefix(bucket, "gs://"), "/")
+	return fmt.Sprintf("gs://%s/%s-%s.sql.gz", bucket, instanceID, now.Format("20060102-150405"))
+}
+
+// ExportGCPCloudSQLBackup exports the databases of a Cloud SQL instance to a GCS bucket
+// using 'gcloud sql export sql' command and returns the gs:// URI of the exported file.
+// If databases is empty, all databases are exported (only supported by MySQL instances,
+// PostgreSQL instances require one database).
+// The service account of the instance needs write permission on the bucket.
+func ExportGCPCloudSQLBackup(projectID, instanceID, bucket string, databases []string) (string, error) {
+	if projectID == "" || instanceID == "" {
+		return "", common.NewValidationError("projectID and instanceID are required to export backup in ExportGCPCloudSQLBackup function")
+	}
+	if strings.TrimPrefix(bucket, "gs://") == "" {
+		return "", common.NewValidationError("bucket is required to export backup of instance '%s'", instanceID)
+	}
+
+	backupURI := BuildGCPCloudSQLBackupURI(bucket, instanceID, time.Now())
+	common.Logger("info", "Exporting backup of instance '%s' on project '%s' to '%s'...", instanceID, projectID, backupURI)
+
+	args := []string{
+		"sql", "export", "sql", instanceID, backupURI,
+		"--project", projectID,
+	}
+	if len(databases) > 0 {
+		args = append(args, "--database", strings.Join(databases, ","))
+	}
+
+	if _, _, err := RunGcloudPrimaryCommand(args...); err != nil {
+		return "", err
+	}
+
+	common.Logger("info", "Successfully exported backup of instance '%s' on project '%s' to: %s", instanceID, projectID, backupURI)
+	return backupURI, nil
+}
+
+// CloudSQLBackup represents the relevant fields of a backup of a Cloud SQL instance
+// returned by 'gcloud sql backups list --format=json'.
+type CloudSQLBackup struct {
+	ID              string    `json:"id"`
+	WindowStartTime time.Time `json:"windowStartTime"`
+	Status          string    `json:"status"`
+	Type            string    `json:"type"`
+}
+
+// ListGCPCloudSQLBackups lists the backups of a Cloud SQL instance using gcloud command.
+// An empty slice is returned when the instance has no backups.
+func ListGCPCloudSQLBackups(projectID, instanceID string) ([]CloudSQLBackup, error) {
+	if projectID == "" || instanceID == "" {
+		return nil, common.NewValidationError("projectID and instanceID are required to list backups in ListGCPCloudSQLBackups function")
+	}
+
+	common.Logger("debug", "Listing backups of instance '%s' on project '%s'...", instanceID, projectID)
+	args := []string{
+		"sql", "backups", "list",
+		"--instance", instanceID,
+		"--project", projectID,
+		"--format=json",
+	}
+
+	stdout, _, err := RunGcloudPrimaryCommand(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseGCPCloudSQLBackups(stdout)
+}
+
+// ParseGCPCloudSQLBackups parses the JSON output of 'gcloud sql backups list --format=json'.
+func ParseGCPCloudSQLBackups(jsonOutput string) ([]CloudSQLBackup, error) {
+	backups := []CloudSQLBackup{}
+	if strings.TrimSpace(jsonOutput) == "" {
+		return backups, nil
+	}
+	if err := json.Unmarshal([]byte(jsonOutput), &backups); err != nil {
+		return nil, fmt.Errorf("failed to parse Cloud SQL backups: %w", err)
+	}
+	return backups, nil
+}
+
+// CreateGCPCloudSQLBackup starts an on-demand backup of a Cloud SQL instance using 'gcloud sql backups create' command.
+// The description is optional. The creation is asynchronous: it returns the ID of operation, that can be waited by
+// WaitForGCPCloudSQLOperation function. The ID of backup is returned by GetGCPCloudSQLBackupID function.
+func CreateGCPCloudSQLBackup(projectID, instanceID, description string) (string, error) {
+	if projectID == "" || instanceID == "" {
+		return "", common.NewValidationError("projectID and instanceID are required to create backup in CreateGCPCloudSQLBackup function")
+	}
+
+	common.Logger("info", "Creating on-demand backup of instance '%s' on project '%s'...", instanceID, projectID)
+	args := []string{
+		"sql", "backups", "create",
+		"--instance", instanceID,
+		"--project", projectID,
+		// Return the operation instead of blocking. See WaitForGCPCloudSQLOperation function
+		"--async",
+		"--format=value(name)",
+	}
+	if description != "" {
+		args = append(args, "--description", description)
+	}
+
+	stdout, _, err := RunGcloudPrimaryCommand(args...)
+	if err != nil {
+		return "", fmt.Errorf("failed to create backup of instance '%s' on project '%s': %w", instanceID, projectID, err)
+	}
+
+	operationID := strings.TrimSpace(stdout)
+	common.Logger("info", "Backup of instance '%s' started. Operation: %s", instanceID, operationID)
+	return operationID, nil
+}
+
+// GetGCPCloudSQLBackupID returns the ID of backup created by a Cloud SQL operation (see CreateGCPCloudSQLBackup function).
+// An error is returned if the operation has no backup, e.g. it isn't a backup operation.
+func GetGCPCloudSQLBackupID(projectID, operationID string) (string, error) {
+	operation, err := DescribeGCPCloudSQLOperation(projectID, operationID)
+	if err != nil {
+		return "", err
+	}
+	if operation.BackupContext == nil || operation.BackupContext.BackupID == "" {
+		return "", fmt.Errorf("Cloud SQL operation '%s' (%s) has no backup ID", operationID, operation.OperationType)
+	}
+	return operation.BackupContext.BackupID, nil
+}
+
+// CloudSQLDatabaseFlag represents a database flag of a Cloud SQL instance.
+type CloudSQLDatabaseFlag struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// GetGCPCloudSQLInstanceFlags returns the database flags currently set in a Cloud SQL instance.
+func GetGCPCloudSQLInstanceFlags(projectID, instanceID string) ([]CloudSQLDatabaseFlag, error) {
+	if projectID == "" || instanceID == "" {
+		return nil, common.NewValidationError("projectID and instanceID are required to get database flags in GetGCPCloudSQLInstanceFlags function")
+	}
+
+	args := []string{
+		"sql", "instances", "describe", instanceID,
+		"--project", projectID,
+		"--format=json(settings.databaseFlags)",
+	}
+	stdout, _, err := RunGcloudCommand(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var instance struct {
+		Settings struct {
+			DatabaseFlags []CloudSQLDatabaseFlag `json:"databaseFlags"`
+		} `json:"settings"`
+	}
+	if strings.TrimSpace(stdout) == "" {
+		return []CloudSQLDatabaseFlag{}, nil
+	}
+	if err := json.Unmarshal([]byte(stdout), &instance); err != nil {
+		return nil, fmt.Errorf("failed to parse database flags of instance '%s': %w", instanceID, err)
+	}
+	return instance.Settings.DatabaseFlags, nil
+}
+
+// MergeGCPCloudSQLDatabaseFlags returns the current flags with the flag set to value.
+// The flag is updated if it already exists, otherwise it's appended. Unrelated flags are never dropped.
+func MergeGCPCloudSQLDatabaseFlags(current []CloudSQLDatabaseFlag, flag, value string) []CloudSQLDatabaseFlag {
+	merged := make([]CloudSQLDatabaseFlag, 0, len(current)+1)
+	found := false
+	for _, currentFlag := range current {
+		if currentFlag.Name == flag {
+			currentFlag.Value = value
+			found = true
+		}
+		merged = append(merged, currentFlag)
+	}
+	if !found {
+		merged = append(merged, CloudSQLDatabaseFlag{Name: flag, Value: value})
+	}
+	return merged
+}
+
+// FormatGCPCloudSQLDatabaseFlags formats the flags to the value of '--database-flags' option of gcloud.
+// If any value contains comma, the alternative delimiter syntax of gcloud is used (see 'gcloud topic escaping').
+func FormatGCPCloudSQLDatabaseFlags(flags []CloudSQLDatabaseFlag) string {
+	delimiter := ","
+	items := make([]string, 0, len(flags))
+	for _, flag := range flags {
+		if strings.Contains(flag.Value, ",") {
+			delimiter = ";"
+		}
+		if flag.Value == "" {
+			items = append(items, flag.Name)
+			continue
+		}
+		items = append(items, flag.Name+"="+flag.Value)
+	}
+
+	if delimiter != "," {
+		return "^" + delimiter + "^" + strings.Join(items, delimiter)
+	}
+	return strings.Join(items, delimiter)
+}
+
+// SetGCPCloudSQLInstanceFlag sets a database flag of a Cloud SQL instance, preserving the other flags
+// (gcloud replaces all flags of instance with the value of '--database-flags' option).
+// ATTENTION!!! Some flags require the restart of instance.
+func SetGCPCloudSQLInstanceFlag(projectID, instanceID, flag, value string) error {
+	if flag == "" {
+		return common.NewValidationError("flag is required to set database flag of instance '%s'", instanceID)
+	}
+
+	currentFlags, err := GetGCPCloudSQLInstanceFlags(projectID, instanceID)
+	if err != nil {
+		return err
+	}
+	mergedFlags := MergeGCPCloudSQLDatabaseFlags(currentFlags, flag, value)
+
+	common.Logger("info", "Setting database flag '%s=%s' of instance '%s' on project '%s'...", flag, value, instanceID, projectID)
+	args := []string{
+		"sql", "instances", "patch", instanceID,
+		"--project", projectID,
+		"--database-flags", FormatGCPCloudSQLDatabaseFlags(mergedFlags),
+		// The confirmation is done by CLI
+		"--quiet",
+	}
+	if _, _, err := RunGcloudPrimaryCommand(args...); err != nil {
+		return err
+	}
+
+	common.Logger("info", "Successfully set database flag '%s' of instance '%s' on project '%s'.", flag, instanceID, projectID)
+	return nil
+}
+
+// ParseGCPCloudSQLConnectionName parses the connection name of a Cloud SQL instance
+// in 'project:region:instance' format and returns its parts.
+// Domain-scoped projects are supported (e.g. 'example.com:project:region:instance').
+func ParseGCPCloudSQLConnectionName(connectionName string) (projectID, region, instanceID string, err error) {
+	parts := strings.Split(connectionName, ":")
+	if len(parts) < 3 {
+		return "", "", "", common.NewValidationError("invalid instance connection name '%s'. Expected format: project:region:instance", connectionName)
+	}
+
+	projectID = strings.Join(parts[:len(parts)-2], ":")
+	region = parts[len(parts)-2]
+	instanceID = parts[len(parts)-1]
+	if projectID == "" || !cloudSQLRegionRegex.MatchString(region) || !cloudSQLInstanceIDRegex.MatchString(instanceID) {
+		return "", "", "", common.NewValidationError("invalid instance connection name '%s'. Expected format: project:region:instance", connectionName)
+	}
+	return projectID, region, instanceID, nil
+}
+
+// GetGCPCloudSQLInstanceAddress returns the IP address of a Cloud SQL instance.
+// The private IP is preferred, if the instance has one.
+func GetGCPCloudSQLInstanceAddress(projectID, instanceID string) (string, error) {
+	args := []string{
+		"sql", "instances", "describe", instanceID,
+		"--project", projectID,
+		"--format=json(ipAddresses)",
+	}
+	stdout, _, err := RunGcloudCommand(args...)
+	if err != nil {
+		return "", err
+	}
+
+	var instance struct {
+		IPAddresses []struct {
+			IPAddress string `json:"ipAddress"`
+			Type      string `json:"type"`
+		} `json:"ipAddresses"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &instance); err != nil {
+		return "", fmt.Errorf("failed to parse IP addresses of instance '%s': %w", instanceID, err)
+	}
+	if len(instance.IPAddresses) == 0 {
+		return "", fmt.Errorf("instance '%s' on project '%s' has no IP address", instanceID, projectID)
+	}
+
+	for _, ipAddress := range instance.IPAddresses {
+		if ipAddress.Type == "PRIVATE" {
+			return ipAddress.IPAddress, nil
+		}
+	}
+	return instance.IPAddresses[0].IPAddress, nil
+}
+
+// Status of Cloud SQL server CA certificates returned by CheckGCPCloudSQLServerCACerts function
+const (
+	CloudSQLCertStatusOK       = "OK"
+	CloudSQLCertStatusExpiring = "EXPIRING"
+	CloudSQLCertStatusExpired  = "EXPIRED"
+)
+
+// CloudSQLServerCACert represents the relevant fields of a server CA certificate of a Cloud SQL instance
+// returned by 'gcloud sql ssl server-ca-certs list --format=json'.
+type CloudSQLServerCACert struct {
+	CommonName      string    `json:"commonName"`
+	SHA1Fingerprint string    `json:"sha1Fingerprint"`
+	CreateTime      time.Time `json:"createTime"`
+	ExpirationTime  time.Time `json:"expirationTime"`
+}
+
+// CloudSQLServerCACertCheck is the expiration check of a server CA certificate.
+type CloudSQLServerCACertCheck struct {
+	Cert     CloudSQLServerCACert
+	DaysLeft int
+	Status   string
+}
+
+// ListGCPCloudSQLServerCACerts lists the server CA certificates of a Cloud SQL instance using gcloud command.
+func ListGCPCloudSQLServerCACerts(projectID, instanceID string) ([]CloudSQLServerCACert, error) {
+	if projectID == "" || instanceID == "" {
+		return nil, common.NewValidationError("projectID and instanceID are required to list server CA certificates in ListGCPCloudSQLServerCACerts function")
+	}
+
+	common.Logger("debug", "Listing server CA certificates of instance '%s' on project '%s'...", instanceID, projectID)
+	args := []string{
+		"sql", "ssl", "server-ca-certs", "list",
+		"--instance", instanceID,
+		"--project", projectID,
+		"--format=json",
+	}
+
+	stdout, _, err := RunGcloudCommand(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseGCPCloudSQLServerCACerts(stdout)
+}
+
+// ParseGCPCloudSQLServerCACerts parses the JSON output of 'gcloud sql ssl server-ca-certs list --format=json'.
+func ParseGCPCloudSQLServerCACerts(jsonOutput string) ([]CloudSQLServerCACert, error) {
+	certs := []CloudSQLServerCACert{}
+	if strings.TrimSpace(jsonOutput) == "" {
+		return certs, nil
+	}
+	if err := json.Unmarshal([]byte(jsonOutput), &certs); err != nil {
+		return nil, fmt.Errorf("failed to parse Cloud SQL server CA certificates: %w", err)
+	}
+	return certs, nil
+}
+
+// CheckGCPCloudSQLServerCACerts checks the expiration of certificates at the time now.
+// Certificates expiring in daysThreshold days or less have the EXPIRING status.
+// The checks are sorted by expiration time (the nearest first).
+func CheckGCPCloudSQLServerCACerts(certs []CloudSQLServerCACert, now time.Time, daysThreshold int) []CloudSQLServerCACertCheck {
+	checks := make([]CloudSQLServerCACertCheck, 0, len(certs))
+	for _, cert := range certs {
+		timeLeft := cert.ExpirationTime.Sub(now)
+		check := CloudSQLServerCACertCheck{
+			Cert:     cert,
+			DaysLeft: int(timeLeft.Hours() / 24),
+			Status:   CloudSQLCertStatusOK,
+		}
+		if timeLeft <= 0 {
+			check.Status = CloudSQLCertStatusExpired
+		} else if timeLeft <= time.Duration(daysThreshold)*24*time.Hour {
+			check.Status = CloudSQLCertStatusExpiring
+		}
+		checks = append(checks, check)
+	}
+
+	sort.Slice(checks, func(i, j int) bool {
+		return checks[i].Cert.ExpirationTime.Before(checks[j].Cert.ExpirationTime)
+	})
+	return checks
 }
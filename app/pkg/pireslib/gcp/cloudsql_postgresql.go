@@ -2,65 +2,134 @@
 package gcp
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
+	"strconv"
 	"strings"
-	"time"
+	"sync"
 
 	"github.com/aeciopires/pires-cli/internal/config"
 	"github.com/aeciopires/pires-cli/pkg/pireslib/common"
 )
 
+// ExportPostgresOptions are the options of ExportPostgresUsersAndPermissions function.
+type ExportPostgresOptions struct {
+	ProjectID  string
+	InstanceID string
+	// Host, Port, User and Password are the connection of psql (see BuildPostgresConnInfo).
+	Host     string
+	Port     string
+	User     string
+	Password string
+	// SSLRequired requires SSL in the connection of psql.
+	SSLRequired bool
+	// OutputDir is the directory of report and state file.
+	OutputDir string
+	// IncludeDatabases, if not empty, are the only databases checked and ExcludePattern is ignored.
+	IncludeDatabases []string
+	// ExcludePattern is a regex of the databases to skip (cloudsqladmin is always skipped).
+	ExcludePattern string
+	// FilenameTemplate is the template of report filename (config.PostgresPermissionsFilenameTemplate if empty).
+	FilenameTemplate string
+	// Format is PermissionsReportFormatText or PermissionsReportFormatJSON.
+	Format string
+	// Resume skips the databases of the state file and completes the report of the interrupted or failed export.
+	Resume bool
+	// Metadata are the custom header and footer of report.
+	Metadata common.ReportMetadata
+}
+
 // ExportPostgresUsersAndPermissions connects to a PostgreSQL Cloud SQL instance
-// using the psql CLI, iterates through all databases (except those matching excludePattern or cloudsqladmin),
-// and exports a detailed list of user permissions per table to a TXT file.
-func ExportPostgresUsersAndPermissions(projectID, instanceID, dbHost, dbPort, dbUser, dbPassword, outputDir, excludePattern string, sslRequired bool) {
-	common.Logger("info", "Exporting user permissions from instance '%s' in project '%s'\n", instanceID, projectID)
+// using the psql CLI, iterates through all databases (except those matching ExcludePattern or cloudsqladmin),
+// and exports a detailed list of user permissions per table to a TXT file (see ExportPostgresOptions).
+// The format is PermissionsReportFormatText or PermissionsReportFormatJSON (see BuildPostgresPermissionsJSON,
+// with .json extension instead of .txt). The header and footer of metadata are only written in text format.
+// The report starts with a header (custom title, timestamp, operator account and CLI version) and ends with the custom note of metadata.
+// The completed databases are saved in a state file of OutputDir (see config.PostgresPermissionsStateFilenameTemplate)
+// while the export runs. If Resume is true, the databases of the state file are skipped and the report of the
+// interrupted or failed export is completed, with its original filename. The state file is removed when all databases succeed.
+func ExportPostgresUsersAndPermissions(options ExportPostgresOptions) error {
+	common.Logger("info", "Exporting user permissions from instance '%s' in project '%s'\n", options.InstanceID, options.ProjectID)
 
 	// Compile regex if provided
 	var excludeRegex *regexp.Regexp
 	var err error
-	if excludePattern != "" {
-		excludeRegex, err = regexp.Compile(excludePattern)
+	if options.ExcludePattern != "" {
+		excludeRegex, err = regexp.Compile(options.ExcludePattern)
 		if err != nil {
-			common.Logger("fatal", "Invalid exclude pattern regex '%s': %v", excludePattern, err)
+			return common.NewValidationError("invalid exclude pattern regex '%s': %v", options.ExcludePattern, err)
 		}
 	}
 
-	// Ensure output dir exists
-	if outputDir != "" {
-		if err := os.MkdirAll(outputDir, config.PermissionDir); err != nil {
-			common.Logger("fatal", "Failed to create output directory '%s': %v", outputDir, err)
+	// Generate the filename
+	if options.FilenameTemplate == "" {
+		options.FilenameTemplate = config.PostgresPermissionsFilenameTemplate
+	}
+	filenameVars := common.ReportFilenameVars(options.ProjectID, options.InstanceID)
+	statePath := filepath.Join(options.OutputDir, common.BuildReportFilename(config.PostgresPermissionsStateFilenameTemplate, filenameVars))
+	state, errState := loadPostgresPermissionsExportState(statePath, options.Resume)
+	if errState != nil {
+		return errState
+	}
+	if state != nil {
+		if state.Format != options.Format {
+			return common.NewValidationError("the export of state file '%s' uses format '%s'. Use --format %s to resume it", statePath, state.Format, state.Format)
+		}
+		common.Logger("info", "Resuming export of '%s': %d database(s) already completed", state.ReportFile, len(state.Completed))
+	} else {
+		fileName := common.BuildReportFilename(options.FilenameTemplate, filenameVars)
+		if options.Format == PermissionsReportFormatJSON && filepath.Ext(fileName) == ".txt" {
+			fileName = strings.TrimSuffix(fileName, ".txt") + ".json"
+		}
+		state = &postgresPermissionsExportState{
+			ReportFile: filepath.Join(options.OutputDir, fileName),
+			Timestamp:  filenameVars["timestamp"],
+			Format:     options.Format,
+			Completed:  map[string]string{},
 		}
 	}
+	filePath, timestamp := state.ReportFile, state.Timestamp
 
-	var output strings.Builder
-	timestamp := time.Now().Format("20060102-150405")
-	output.WriteString(fmt.Sprintf("User and Role Permissions Report for Instance: '%s' in project: '%s'. Generated is: '%s'\n\n", instanceID, projectID, timestamp))
+	// Ensure output dir exists. The filename template can contain directories too.
+	if err := os.MkdirAll(filepath.Dir(filePath), config.PermissionDir); err != nil {
+		return fmt.Errorf("failed to create output directory '%s': %w", filepath.Dir(filePath), err)
+	}
 
-	sslMode := "disable"
-	if sslRequired {
-		// if user forces sslmode, use require
-		sslMode = "require"
+	// The operator is only informative, so the report is generated even if the account is unknown
+	operator, errAccount := GetGcloudActiveAccount()
+	if errAccount != nil {
+		common.Logger("warning", "Could not get the operator account for the report header: %v", errAccount)
 	}
 
+	var output strings.Builder
+	defaultTitle := fmt.Sprintf("User and Role Permissions Report for Instance: '%s' in project: '%s'", options.InstanceID, options.ProjectID)
+	output.WriteString(common.BuildReportHeader(options.Metadata, defaultTitle, timestamp, operator))
+
 	runPSQL := func(dbName, sql string) (string, error) {
 		args := []string{
-			fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s", dbHost, dbPort, dbUser, dbPassword, dbName, sslMode),
+			BuildPostgresConnInfo(options.Host, options.Port, options.User, options.Password, dbName, options.SSLRequired),
 			"-At",
 			"-c", sql,
 		}
 
-		stdout, stderr, err := RunPsqlCommand(args...)
+		// Transient connection errors are retried, so the report is complete on flaky networks
+		stdout, stderr, err := RunPsqlCommandWithRetry(config.PostgresQueryAttempts, config.PostgresQueryRetryBackoff, args...)
 		common.Logger("debug", "Executing command: psql %s", strings.Join(args, " "))
 		if err != nil {
-			common.Logger("fatal", "psql command 'psql %s' failed: %w\nStderr: %s", strings.Join(args, " "), err, stderr)
+			if common.ExitCode(err) == common.ExitCodeInterrupted {
+				return "", err
+			}
+			return "", common.NewExternalCommandError("psql query failed: %s", strings.TrimSpace(stderr))
 		}
 
 		if stderr != "" {
-			common.Logger("fatal", "psql command stderr (exit code 0):\n%s", stderr)
+			return "", common.NewExternalCommandError("psql command stderr (exit code 0):\n%s", stderr)
 		}
 
 		return stdout, nil
@@ -70,29 +139,436 @@ func ExportPostgresUsersAndPermissions(projectID, instanceID, dbHost, dbPort, db
 	dbListSQL := `SELECT datname FROM pg_database WHERE datistemplate = false;`
 	dbListOut, err := runPSQL("postgres", dbListSQL)
 	if err != nil {
-		common.Logger("fatal", "Failed to list databases: %v", err)
+		return fmt.Errorf("failed to list databases: %w", err)
 	}
 
-	dbNames := strings.Fields(dbListOut)
+	dbNames := FilterPostgresDatabases(strings.Fields(dbListOut), options.IncludeDatabases, excludeRegex)
+
+	// Check the databases concurrently, limited by config.PostgresMaxOpenConns, because each psql
+	// opens its own connection and large instances could hit "too many connections".
+	// The connections can't be reused across databases: a PostgreSQL connection is bound to the database
+	// of its startup and there is no driver or pool in the process (psql is executed), so the export opens
+	// 1 + len(dbNames) connections (plus the retries), the minimum for PostgreSQL.
+	// The sections are written in the order of databases.
+	// Each completed database is saved in the state file, so a failed or cancelled export can be resumed.
+	permOuts := make([]string, len(dbNames))
+	permErrs := make([]error, len(dbNames))
+	var stateMutex sync.Mutex
+	if err := state.save(statePath, &stateMutex); err != nil {
+		return err
+	}
+	common.ForEachConcurrentlyWithLimit(len(dbNames), config.PostgresMaxOpenConns, func(i int) {
+		stateMutex.Lock()
+		permOut, completed := state.Completed[dbNames[i]]
+		stateMutex.Unlock()
+		if completed {
+			common.Logger("info", "Skipping database already completed: %s", dbNames[i])
+			permOuts[i] = permOut
+			return
+		}
 
-	// Iterate each database
-	for _, dbName := range dbNames {
-		if dbName == "cloudsqladmin" {
-			common.Logger("info", "Skipping internal database 'cloudsqladmin'")
+		permOuts[i], permErrs[i] = queryPostgresPermissions(dbNames[i], runPSQL)
+		if permErrs[i] != nil {
+			return
+		}
+		stateMutex.Lock()
+		state.Completed[dbNames[i]] = permOuts[i]
+		stateMutex.Unlock()
+		if err := state.save(statePath, &stateMutex); err != nil {
+			common.Logger("warning", "Could not save the progress of export: %v", err)
+		}
+	})
+
+	for _, permErr := range permErrs {
+		if common.ExitCode(permErr) == common.ExitCodeInterrupted {
+			// The report is only written at the end, so no partial file is left when cancelled.
+			// The completed databases are in the state file, so the export can be resumed.
+			return permErr
+		}
+	}
+
+	var content []byte
+	if options.Format == PermissionsReportFormatJSON {
+		permissions := make(map[string]PostgresTablePermissions, len(dbNames))
+		for i, dbName := range dbNames {
+			// The failed databases were logged as warning and are not in the report
+			if permErrs[i] == nil {
+				permissions[dbName] = ParsePostgresPermissions(permOuts[i])
+			}
+		}
+		content, err = BuildPostgresPermissionsJSON(permissions)
+		if err != nil {
+			return fmt.Errorf("failed to encode permissions report as JSON: %w", err)
+		}
+	} else {
+		for i, dbName := range dbNames {
+			output.WriteString(buildPostgresPermissionsSection(dbName, permOuts[i], permErrs[i]))
+		}
+		output.WriteString(common.BuildReportFooter(options.Metadata))
+		content = []byte(output.String())
+	}
+
+	// Write report to file
+	if err := common.WriteFileAtomic(filePath, content, config.PermissionFile); err != nil {
+		return fmt.Errorf("failed to write permissions report to file '%s': %w", filePath, err)
+	}
+
+	var failed int
+	for _, permErr := range permErrs {
+		if permErr != nil {
+			failed++
+		}
+	}
+	if failed > 0 {
+		common.Logger("warning", "Permissions of %d database(s) could not be exported. Run the command again with --resume to retry them (state file: %s)", failed, statePath)
+	} else if err := os.Remove(statePath); err != nil && !os.IsNotExist(err) {
+		common.Logger("warning", "Could not remove the state file '%s': %v", statePath, err)
+	}
+
+	common.Logger("info", "Successfully exported detailed database permissions to: %s\n", filePath)
+	return nil
+}
+
+// postgresPermissionsExportState is the progress of ExportPostgresUsersAndPermissions, saved as JSON to resume the export
+type postgresPermissionsExportState struct {
+	ReportFile string            `json:"reportFile"`
+	Timestamp  string            `json:"timestamp"`
+	Format     string            `json:"format"`
+	Completed  map[string]string `json:"completed"` // Output of permissions query by database
+}
+
+// loadPostgresPermissionsExportState returns the state of a previous export when resume is true, or nil if
+// there isn't a state file. When resume is false, the existing state file is ignored (and overwritten by the export).
+func loadPostgresPermissionsExportState(statePath string, resume bool) (*postgresPermissionsExportState, error) {
+	data, err := os.ReadFile(statePath)
+	if os.IsNotExist(err) {
+		if resume {
+			common.Logger("warning", "No state file '%s' to resume. Starting a new export", statePath)
+		}
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state file '%s': %w", statePath, err)
+	}
+	if !resume {
+		common.Logger("warning", "The previous export was not completed (state file: %s). Starting a new export. Use --resume to continue it", statePath)
+		return nil, nil
+	}
+
+	var state postgresPermissionsExportState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse state file '%s': %w. Remove it to start a new export", statePath, err)
+	}
+	if state.Completed == nil {
+		state.Completed = map[string]string{}
+	}
+	return &state, nil
+}
+
+// save writes the state to statePath atomically. mutex protects the state against concurrent updates,
+// and it's held until the file is written, so an older state never overwrites a newer one.
+func (s *postgresPermissionsExportState) save(statePath string, mutex *sync.Mutex) error {
+	mutex.Lock()
+	defer mutex.Unlock()
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode state of export: %w", err)
+	}
+	if err := common.WriteFileAtomic(statePath, data, config.PermissionFile); err != nil {
+		return fmt.Errorf("failed to write state file '%s': %w", statePath, err)
+	}
+	return nil
+}
+
+// PostgresDatabaseSize is the size (in bytes) and the number of tables of a database. See ExportPostgresDatabaseSizes function
+type PostgresDatabaseSize struct {
+	Name   string
+	Size   int64
+	Tables int
+	Err    error // Error of query, the size and tables are unknown
+}
+
+// ExportPostgresDatabaseSizes connects to a PostgreSQL Cloud SQL instance like ExportPostgresUsersAndPermissions
+// (same connection, retries and databases filter) and exports the size (pg_database_size) and the number of tables
+// (except system schemas) of each database to a TXT file, for capacity planning.
+// The filename is defined by filenameTemplate (config.PostgresDatabaseSizesFilenameTemplate if empty).
+// The databases that couldn't be queried are reported with the error, and an error is returned at the end.
+func ExportPostgresDatabaseSizes(projectID, instanceID, dbHost, dbPort, dbUser, dbPassword, outputDir, excludePattern, filenameTemplate string, includeDatabases []string, sslRequired bool) error {
+	common.Logger("info", "Exporting database sizes from instance '%s' in project '%s'\n", instanceID, projectID)
+
+	var excludeRegex *regexp.Regexp
+	if excludePattern != "" {
+		var err error
+		excludeRegex, err = regexp.Compile(excludePattern)
+		if err != nil {
+			return common.NewValidationError("invalid exclude pattern regex '%s': %v", excludePattern, err)
+		}
+	}
+
+	if filenameTemplate == "" {
+		filenameTemplate = config.PostgresDatabaseSizesFilenameTemplate
+	}
+	filenameVars := common.ReportFilenameVars(projectID, instanceID)
+	filePath := filepath.Join(outputDir, common.BuildReportFilename(filenameTemplate, filenameVars))
+	if err := os.MkdirAll(filepath.Dir(filePath), config.PermissionDir); err != nil {
+		return fmt.Errorf("failed to create output directory '%s': %w", filepath.Dir(filePath), err)
+	}
+
+	runPSQL := func(dbName, sql string) (string, error) {
+		args := []string{
+			BuildPostgresConnInfo(dbHost, dbPort, dbUser, dbPassword, dbName, sslRequired),
+			"-At",
+			"-c", sql,
+		}
+		stdout, stderr, err := RunPsqlCommandWithRetry(config.PostgresQueryAttempts, config.PostgresQueryRetryBackoff, args...)
+		if err != nil {
+			if common.ExitCode(err) == common.ExitCodeInterrupted {
+				return "", err
+			}
+			return "", common.NewExternalCommandError("psql query failed in database '%s': %s", dbName, strings.TrimSpace(stderr))
+		}
+		return stdout, nil
+	}
+
+	dbListOut, err := runPSQL("postgres", `SELECT datname FROM pg_database WHERE datistemplate = false;`)
+	if err != nil {
+		return fmt.Errorf("failed to list databases: %w", err)
+	}
+	dbNames := FilterPostgresDatabases(strings.Fields(dbListOut), includeDatabases, excludeRegex)
+
+	// pg_database_size works for any database, but the tables are only visible from a connection to their database
+	sizeSQL := `
+SELECT
+    pg_database_size(current_database()) || '|' || count(*)
+FROM
+    information_schema.tables
+WHERE
+    table_type = 'BASE TABLE' AND table_schema NOT IN ('pg_catalog', 'information_schema');
+`
+	sizes := make([]PostgresDatabaseSize, len(dbNames))
+	common.ForEachConcurrentlyWithLimit(len(dbNames), config.PostgresMaxOpenConns, func(i int) {
+		sizes[i].Name = dbNames[i]
+		common.Logger("info", "Checking size of database: %s", dbNames[i])
+		sizeOut, errQuery := runPSQL(dbNames[i], sizeSQL)
+		if errQuery != nil {
+			sizes[i].Err = errQuery
+			return
+		}
+		sizes[i].Size, sizes[i].Tables, sizes[i].Err = ParsePostgresDatabaseSize(sizeOut)
+	})
+
+	var failed []string
+	for _, size := range sizes {
+		if size.Err != nil {
+			if common.ExitCode(size.Err) == common.ExitCodeInterrupted {
+				// The report is only written at the end, so no partial file is left when cancelled
+				return size.Err
+			}
+			common.Logger("warning", "Could not query size of database '%s': %v", size.Name, size.Err)
+			failed = append(failed, size.Name)
+		}
+	}
+
+	// The operator is only informative, so the report is generated even if the account is unknown
+	operator, errAccount := GetGcloudActiveAccount()
+	if errAccount != nil {
+		common.Logger("warning", "Could not get the operator account for the report header: %v", errAccount)
+	}
+	defaultTitle := fmt.Sprintf("Database Sizes Report for Instance: '%s' in project: '%s'", instanceID, projectID)
+	report := common.BuildReportHeader(common.ReportMetadata{}, defaultTitle, filenameVars["timestamp"], operator) + BuildPostgresDatabaseSizesReport(sizes)
+
+	if err := common.WriteFileAtomic(filePath, []byte(report), config.PermissionFile); err != nil {
+		return fmt.Errorf("failed to write database sizes report to file '%s': %w", filePath, err)
+	}
+	common.Logger("info", "Successfully exported database sizes to: %s\n", filePath)
+
+	if len(failed) > 0 {
+		return fmt.Errorf("could not query the size of %d database(s): %s", len(failed), strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// ParsePostgresDatabaseSize parses the output of the size query of ExportPostgresDatabaseSizes (size|tables)
+// and returns the size in bytes and the number of tables.
+func ParsePostgresDatabaseSize(sizeOut string) (int64, int, error) {
+	size, tables, found := strings.Cut(strings.TrimSpace(sizeOut), "|")
+	if !found {
+		return 0, 0, fmt.Errorf("unexpected output of size query: '%s'", strings.TrimSpace(sizeOut))
+	}
+	sizeBytes, errSize := strconv.ParseInt(size, 10, 64)
+	if errSize != nil {
+		return 0, 0, fmt.Errorf("invalid database size '%s': %w", size, errSize)
+	}
+	tableCount, errTables := strconv.Atoi(tables)
+	if errTables != nil {
+		return 0, 0, fmt.Errorf("invalid number of tables '%s': %w", tables, errTables)
+	}
+	return sizeBytes, tableCount, nil
+}
+
+// BuildPostgresDatabaseSizesReport returns the table of database sizes (human-readable, like 1.5 GiB) and tables,
+// sorted by size (largest first), with the total size of the databases and the errors of queries at the end.
+func BuildPostgresDatabaseSizesReport(sizes []PostgresDatabaseSize) string {
+	sorted := slices.Clone(sizes)
+	slices.SortStableFunc(sorted, func(a, b PostgresDatabaseSize) int {
+		switch {
+		case a.Size > b.Size:
+			return -1
+		case a.Size < b.Size:
+			return 1
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	var total int64
+	var errorLines []string
+	rows := make([][]string, 0, len(sorted))
+	for _, size := range sorted {
+		if size.Err != nil {
+			rows = append(rows, []string{size.Name, "ERROR", "-", "-"})
+			errorLines = append(errorLines, fmt.Sprintf("  - %s: %v\n", size.Name, size.Err))
 			continue
 		}
-		if excludeRegex != nil && excludeRegex.MatchString(dbName) {
-			common.Logger("info", "Skipping database '%s' (matches exclude pattern)", dbName)
+		total += size.Size
+		rows = append(rows, []string{size.Name, common.FormatBytes(size.Size), strconv.Itoa(size.Tables), strconv.FormatInt(size.Size, 10)})
+	}
+
+	var report strings.Builder
+	if len(rows) == 0 {
+		report.WriteString("No databases found.\n")
+		return report.String()
+	}
+	_ = common.WriteTable(&report, []string{"DATABASE", "SIZE", "TABLES", "BYTES"}, rows)
+	report.WriteString(fmt.Sprintf("\nTotal: %d database(s), %s\n", len(sorted)-len(errorLines), common.FormatBytes(total)))
+	if len(errorLines) > 0 {
+		report.WriteString(fmt.Sprintf("\nCould not query %d database(s):\n", len(errorLines)))
+		report.WriteString(strings.Join(errorLines, ""))
+	}
+	return report.String()
+}
+
+// BuildPostgresConnInfo returns the connection string of psql for the database (dbName),
+// with the connect timeout of config.PostgresConnectTimeout.
+func BuildPostgresConnInfo(dbHost, dbPort, dbUser, dbPassword, dbName string, sslRequired bool) string {
+	sslMode := "disable"
+	if sslRequired {
+		// if user forces sslmode, use require
+		sslMode = "require"
+	}
+
+	// The connect_timeout of libpq is in seconds, so the timeout is rounded up
+	connectTimeoutSeconds := int(math.Ceil(config.PostgresConnectTimeout.Seconds()))
+
+	return fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s connect_timeout=%d", dbHost, dbPort, dbUser, dbPassword, dbName, sslMode, connectTimeoutSeconds)
+}
+
+// PostgresGrantablePrivileges are the table privileges accepted by BuildPostgresGrantSQL function.
+var PostgresGrantablePrivileges = []string{"SELECT", "INSERT", "UPDATE", "DELETE", "TRUNCATE", "REFERENCES", "TRIGGER", "ALL"}
+
+// quotePostgresIdentifier quotes a PostgreSQL identifier (e.g. user with '@' or '-'), escaping the double quotes.
+func quotePostgresIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// BuildPostgresGrantSQL returns the GRANT statements giving the privileges on all tables of schema
+// in the database (dbName) to the user, plus the CONNECT on database and USAGE on schema required to use them.
+// The privileges are case insensitive and validated against PostgresGrantablePrivileges. The duplicates are ignored.
+func BuildPostgresGrantSQL(dbName, schema, user string, privileges []string) ([]string, error) {
+	if dbName == "" || schema == "" || user == "" {
+		return nil, common.NewValidationError("database, schema and user cannot be empty")
+	}
+
+	var normalized []string
+	for _, privilege := range privileges {
+		privilege = strings.ToUpper(strings.TrimSpace(privilege))
+		if !slices.Contains(PostgresGrantablePrivileges, privilege) {
+			return nil, common.NewValidationError("unsupported privilege '%s'. Supported values: %s", privilege, strings.Join(PostgresGrantablePrivileges, ", "))
+		}
+		if !slices.Contains(normalized, privilege) {
+			normalized = append(normalized, privilege)
+		}
+	}
+	if len(normalized) == 0 {
+		return nil, common.NewValidationError("at least one privilege is required. Supported values: %s", strings.Join(PostgresGrantablePrivileges, ", "))
+	}
+	// ALL includes the other privileges
+	if slices.Contains(normalized, "ALL") {
+		normalized = []string{"ALL"}
+	}
+
+	return []string{
+		fmt.Sprintf("GRANT CONNECT ON DATABASE %s TO %s;", quotePostgresIdentifier(dbName), quotePostgresIdentifier(user)),
+		fmt.Sprintf("GRANT USAGE ON SCHEMA %s TO %s;", quotePostgresIdentifier(schema), quotePostgresIdentifier(user)),
+		fmt.Sprintf("GRANT %s ON ALL TABLES IN SCHEMA %s TO %s;", strings.Join(normalized, ", "), quotePostgresIdentifier(schema), quotePostgresIdentifier(user)),
+	}, nil
+}
+
+// GrantPostgresDatabaseAccess runs the GRANT statements (see BuildPostgresGrantSQL) in the database (dbName)
+// using the psql CLI. All statements are sent in a single command, so they run in a single transaction.
+func GrantPostgresDatabaseAccess(dbHost, dbPort, dbUser, dbPassword, dbName string, sslRequired bool, statements []string) error {
+	args := []string{
+		BuildPostgresConnInfo(dbHost, dbPort, dbUser, dbPassword, dbName, sslRequired),
+		"-v", "ON_ERROR_STOP=1",
+		"-c", strings.Join(statements, "\n"),
+	}
+
+	// Transient connection errors are retried. The GRANT statements are idempotent.
+	_, stderr, err := RunPsqlCommandWithRetry(config.PostgresQueryAttempts, config.PostgresQueryRetryBackoff, args...)
+	if err != nil {
+		if common.ExitCode(err) == common.ExitCodeInterrupted {
+			return err
+		}
+		return common.NewExternalCommandError("failed to grant access in database '%s': %s", dbName, strings.TrimSpace(stderr))
+	}
+	return nil
+}
+
+// Formats of the permissions report. See ExportPostgresUsersAndPermissions function
+const (
+	PermissionsReportFormatText = "text"
+	PermissionsReportFormatJSON = "json"
+)
+
+// PostgresTablePermissions are the privileges of tables in a database, by grantee and table:
+// {grantee: {schema.table: [privileges]}}
+type PostgresTablePermissions map[string]map[string][]string
+
+// ParsePostgresPermissions parses the output of permissions query (grantee|schema.table|privilege per line)
+// and returns the privileges by grantee and table. The PUBLIC role and malformed lines are skipped.
+func ParsePostgresPermissions(permOut string) PostgresTablePermissions {
+	permissions := PostgresTablePermissions{}
+	for _, line := range strings.Split(strings.TrimSpace(permOut), "\n") {
+		parts := strings.Split(line, "|")
+		if len(parts) != 3 || parts[0] == "PUBLIC" {
 			continue
 		}
+		grantee, table, privilege := parts[0], parts[1], parts[2]
 
-		common.Logger("info", "Checking permissions in database: %s", dbName)
+		if permissions[grantee] == nil {
+			permissions[grantee] = map[string][]string{}
+		}
+		permissions[grantee][table] = append(permissions[grantee][table], privilege)
+	}
+	return permissions
+}
+
+// BuildPostgresPermissionsJSON returns the permissions by database encoded as indented JSON:
+// {database: {grantee: {schema.table: [privileges]}}}. The keys are sorted.
+func BuildPostgresPermissionsJSON(permissions map[string]PostgresTablePermissions) ([]byte, error) {
+	return json.MarshalIndent(permissions, "", "  ")
+}
 
-		output.WriteString(fmt.Sprintf("========================================\n"))
-		output.WriteString(fmt.Sprintf(" DATABASE: %s\n", dbName))
-		output.WriteString(fmt.Sprintf("========================================\n\n"))
+// queryPostgresPermissions queries the permissions of tables in the database (dbName) using runPSQL
+// and returns the output of query (grantee|schema.table|privilege per line).
+func queryPostgresPermissions(dbName string, runPSQL func(dbName, sql string) (string, error)) (string, error) {
+	// Stop early if the CLI received SIGINT/SIGTERM
+	if errInterrupted := common.CheckInterrupted(); errInterrupted != nil {
+		return "", errInterrupted
+	}
+
+	common.Logger("info", "Checking permissions in database: %s", dbName)
 
-		permSQL := `
+	permSQL := `
 SELECT 
     grantee || '|' || table_schema || '.' || table_name || '|' || privilege_type
 FROM 
@@ -102,52 +578,183 @@ WHERE
 ORDER BY 
     grantee, table_schema, table_name;
 `
-		permOut, err := runPSQL(dbName, permSQL)
-		if err != nil {
-			output.WriteString(fmt.Sprintf("Could not query permissions in %s: %v\n\n", dbName, err))
+	permOut, err := runPSQL(dbName, permSQL)
+	if err != nil {
+		common.Logger("warning", "Could not query permissions in database '%s': %v", dbName, err)
+	}
+	return permOut, err
+}
+
+// buildPostgresPermissionsSection returns the section of text report for the database (dbName),
+// with the output of permissions query (permOut) or the error of query (err).
+func buildPostgresPermissionsSection(dbName, permOut string, err error) string {
+	var section strings.Builder
+
+	section.WriteString(fmt.Sprintf("========================================\n"))
+	section.WriteString(fmt.Sprintf(" DATABASE: %s\n", dbName))
+	section.WriteString(fmt.Sprintf("========================================\n\n"))
+
+	if err != nil {
+		section.WriteString(fmt.Sprintf("Could not query permissions in %s: %v\n\n", dbName, err))
+		return section.String()
+	}
+
+	if strings.TrimSpace(permOut) == "" {
+		section.WriteString("No specific user permissions found on tables in this database.\n\n")
+		return section.String()
+	}
+
+	lines := strings.Split(strings.TrimSpace(permOut), "\n")
+
+	currentUser := ""
+	for _, line := range lines {
+		parts := strings.Split(line, "|")
+		if len(parts) != 3 {
 			continue
 		}
+		grantee, table, privilege := parts[0], parts[1], parts[2]
 
-		if strings.TrimSpace(permOut) == "" {
-			output.WriteString("No specific user permissions found on tables in this database.\n\n")
+		if grantee == "PUBLIC" {
+			// Skip PUBLIC role
 			continue
 		}
 
-		lines := strings.Split(strings.TrimSpace(permOut), "\n")
+		if grantee != currentUser {
+			section.WriteString(fmt.Sprintf("  User/Role: %s\n", grantee))
+			currentUser = grantee
+		}
+		section.WriteString(fmt.Sprintf("    - Table: %s\n", table))
+		section.WriteString(fmt.Sprintf("      Permission: %s\n", privilege))
+	}
 
-		currentUser := ""
-		for _, line := range lines {
-			parts := strings.Split(line, "|")
-			if len(parts) != 3 {
-				continue
-			}
-			grantee, table, privilege := parts[0], parts[1], parts[2]
+	section.WriteString("\n")
+	return section.String()
+}
 
-			if grantee == "PUBLIC" {
-				// Skip PUBLIC role
-				continue
-			}
+// Formats of the audit logs export. See ExportPostgresAuditLogs function
+const (
+	AuditLogsFormatText = "text"
+	AuditLogsFormatJSON = "json"
+)
 
-			if grantee != currentUser {
-				output.WriteString(fmt.Sprintf("  User/Role: %s\n", grantee))
-				currentUser = grantee
-			}
-			output.WriteString(fmt.Sprintf("    - Table: %s\n", table))
-			output.WriteString(fmt.Sprintf("      Permission: %s\n", privilege))
+// Database flags and log of pgaudit checked by CheckPostgresAuditReadiness function.
+// More details: https://cloud.google.com/sql/docs/postgres/pg-audit
+const (
+	PgauditEnableFlag = "cloudsql.enable_pgaudit"
+	PgauditLogFlag    = "pgaudit.log"
+)
+
+// postgresLogName returns the name of log of PostgreSQL instances of a project in Cloud Logging,
+// where the pgaudit entries are written.
+func postgresLogName(projectID string) string {
+	return fmt.Sprintf("projects/%s/logs/cloudsql.googleapis.com%%2Fpostgres.log", projectID)
+}
+
+// AuditReadiness is the result of CheckPostgresAuditReadiness function.
+type AuditReadiness struct {
+	Instance       string   `json:"instance"`
+	PgauditEnabled bool     `json:"pgauditEnabled"`
+	PgauditLog     string   `json:"pgauditLog"`
+	LogName        string   `json:"logName"`
+	LogExists      bool     `json:"logExists"`
+	Ready          bool     `json:"ready"`
+	Problems       []string `json:"problems,omitempty"`
+	Remediations   []string `json:"remediations,omitempty"`
+}
+
+// EvaluatePostgresAuditFlags checks the database flags required by the export of audit logs (see ExportPostgresAuditLogs):
+// cloudsql.enable_pgaudit must be on and pgaudit.log, if set, must include the write class (or all) of DML statements.
+// It fills the flags, problems and remediations of readiness, pointing to 'cloudsql set-flag' command.
+func EvaluatePostgresAuditFlags(readiness *AuditReadiness, flags []CloudSQLDatabaseFlag) {
+	for _, flag := range flags {
+		switch flag.Name {
+		case PgauditEnableFlag:
+			readiness.PgauditEnabled = strings.EqualFold(flag.Value, "on")
+		case PgauditLogFlag:
+			readiness.PgauditLog = flag.Value
 		}
+	}
 
-		output.WriteString("\n")
+	if !readiness.PgauditEnabled {
+		readiness.Problems = append(readiness.Problems, fmt.Sprintf("the database flag '%s' is not on", PgauditEnableFlag))
+		readiness.Remediations = append(readiness.Remediations,
+			fmt.Sprintf("pires-cli gcp cloudsql set-flag -i %s -f %s -v on", readiness.Instance, PgauditEnableFlag))
 	}
 
-	// Write report to file
-	fileName := fmt.Sprintf("%s_%s_database_permissions_%s.txt", projectID, instanceID, timestamp)
-	filePath := filepath.Join(outputDir, fileName)
+	classes := strings.Split(strings.ToLower(readiness.PgauditLog), ",")
+	for i := range classes {
+		classes[i] = strings.TrimSpace(classes[i])
+	}
+	if !slices.Contains(classes, "write") && !slices.Contains(classes, "all") {
+		current := readiness.PgauditLog
+		if current == "" {
+			current = "unset"
+		}
+		readiness.Problems = append(readiness.Problems, fmt.Sprintf("the database flag '%s' doesn't include the 'write' class of INSERT, UPDATE and DELETE statements (current: %s)", PgauditLogFlag, current))
+		readiness.Remediations = append(readiness.Remediations,
+			fmt.Sprintf("pires-cli gcp cloudsql set-flag -i %s -f %s -v write", readiness.Instance, PgauditLogFlag))
+	}
+}
 
-	if err := os.WriteFile(filePath, []byte(output.String()), config.PermissionFile); err != nil {
-		common.Logger("fatal", "Failed to write permissions report to file '%s': %v", filePath, err)
+// CheckPostgresAuditReadiness checks if the audit logs of a PostgreSQL instance can be exported by ExportPostgresAuditLogs:
+// the database flags of pgaudit (see EvaluatePostgresAuditFlags) and the log of PostgreSQL in Cloud Logging.
+// The log is created only after the first entry, so its absence is a problem too. It doesn't change anything.
+func CheckPostgresAuditReadiness(projectID, instanceID string) (AuditReadiness, error) {
+	readiness := AuditReadiness{Instance: instanceID, LogName: postgresLogName(projectID)}
+
+	flags, err := GetGCPCloudSQLInstanceFlags(projectID, instanceID)
+	if err != nil {
+		return readiness, err
 	}
+	EvaluatePostgresAuditFlags(&readiness, flags)
 
-	common.Logger("info", "Successfully exported detailed database permissions to: %s\n", filePath)
+	stdout, _, err := RunGcloudCommand("logging", "logs", "list", "--project", projectID, "--format=value(.)")
+	if err != nil {
+		return readiness, err
+	}
+	for _, logName := range strings.Split(stdout, "\n") {
+		if strings.TrimSpace(logName) == readiness.LogName {
+			readiness.LogExists = true
+			break
+		}
+	}
+	if !readiness.LogExists {
+		readiness.Problems = append(readiness.Problems, fmt.Sprintf("the log '%s' doesn't exist in Cloud Logging of project '%s'", readiness.LogName, projectID))
+		readiness.Remediations = append(readiness.Remediations,
+			"run INSERT, UPDATE or DELETE statements in the instance after enabling pgaudit, so the log is created (see https://cloud.google.com/sql/docs/postgres/pg-audit)")
+	}
+
+	readiness.Ready = len(readiness.Problems) == 0
+	return readiness, nil
+}
+
+// AuditLogEntry is a structured entry of audit logs, with the fields of
+// 'gcloud logging read --format=json' command used by downstream tools.
+type AuditLogEntry struct {
+	Timestamp   string            `json:"timestamp"`
+	Severity    string            `json:"severity"`
+	TextPayload string            `json:"textPayload"`
+	Labels      map[string]string `json:"labels,omitempty"`
+}
+
+// auditLogsGcloudFormat returns the --format argument of gcloud logging read for the export format.
+func auditLogsGcloudFormat(format string) string {
+	if format == AuditLogsFormatJSON {
+		return "--format=json"
+	}
+	return "--format=value(timestamp,textPayload)"
+}
+
+// ParseAuditLogEntries parses the output of 'gcloud logging read --format=json' command
+// and returns the entries encoded as indented JSON.
+func ParseAuditLogEntries(jsonOutput string) ([]byte, error) {
+	entries := []AuditLogEntry{}
+	if strings.TrimSpace(jsonOutput) != "" {
+		if err := json.Unmarshal([]byte(jsonOutput), &entries); err != nil {
+			return nil, fmt.Errorf("failed to parse audit logs: %w", err)
+		}
+	}
+	return json.MarshalIndent(entries, "", "  ")
 }
 
 // ExportPostgresAuditLogs fetches logs for INSERT, UPDATE, and DELETE statements
@@ -155,8 +762,12 @@ ORDER BY
 // This requires the 'cloudsql.enable_pgaudit' flag to be enabled on the instance.
 // More details: https://cloud.google.com/sql/docs/postgres/flags and
 // https://cloud.google.com/sql/docs/postgres/pg-audit
-// The logs are saved to a specified output directory with a timestamped filename.
-func ExportPostgresAuditLogs(projectID, instanceID, outputDir string) {
+// The logs are saved to a specified output directory with a timestamped filename,
+// defined by filenameTemplate (config.PostgresAuditLogsFilenameTemplate if empty).
+// If appendTo is not empty, the logs are appended to that file instead (relative to outputDir).
+// The format is AuditLogsFormatText (timestamp and statement per line) or AuditLogsFormatJSON
+// (array of AuditLogEntry, with .json extension instead of .txt). The JSON format can't be appended.
+func ExportPostgresAuditLogs(projectID, instanceID, outputDir, filenameTemplate, appendTo, format string) error {
 	common.Logger("info", "Exporting audit logs for instance '%s' in project '%s'", instanceID, projectID)
 
 	// Build the filter to get logs for DML statements.
@@ -165,9 +776,9 @@ func ExportPostgresAuditLogs(projectID, instanceID, outputDir string) {
 	filter := fmt.Sprintf(`
 resource.type="cloudsql_database"
 resource.labels.database_id="%s:%s"
-logName="projects/%s/logs/cloudsql.googleapis.com%%2Fpostgres.log"
+logName="%s"
 (textPayload:"statement: INSERT" OR textPayload:"statement: UPDATE" OR textPayload:"statement: DELETE")
-`, projectID, instanceID, projectID)
+`, projectID, instanceID, postgresLogName(projectID))
 
 	fmt.Printf("Using log filter:\n%s\n", filter)
 
@@ -177,35 +788,96 @@ logName="projects/%s/logs/cloudsql.googleapis.com%%2Fpostgres.log"
 		"read",
 		filter,
 		"--project", projectID,
-		"--format=value(timestamp,textPayload)",
+		auditLogsGcloudFormat(format),
 	}
 
 	// Run the gcloud command
-	stdout, stderr, err := RunGcloudCommand(args...)
+	stdout, stderr, err := RunGcloudPrimaryCommand(args...)
 	if err != nil {
-		common.Logger("fatal", "Failed to read audit logs for instance '%s' in project '%s': %w", instanceID, projectID, stderr)
+		return fmt.Errorf("failed to read audit logs for instance '%s' in project '%s': %w. Stderr: %s", instanceID, projectID, err, stderr)
 	}
 
-	if stdout == "" {
-		common.Logger("fatal", "No audit logs found. Ensure the 'cloudsql.enable_pgaudit' flag is enabled on your Cloud SQL instance. More details: https://cloud.google.com/sql/docs/postgres/flags and https://cloud.google.com/sql/docs/postgres/pg-audit")
+	if strings.TrimSpace(stdout) == "" || strings.TrimSpace(stdout) == "[]" {
+		return errors.New("no audit logs found. Ensure the 'cloudsql.enable_pgaudit' flag is enabled on your Cloud SQL instance (see 'cloudsql check-audit-readiness' command). More details: https://cloud.google.com/sql/docs/postgres/flags and https://cloud.google.com/sql/docs/postgres/pg-audit")
 	}
 
-	// Create the output directory if it doesn't exist
-	if outputDir != "" {
-		if err := os.MkdirAll(outputDir, config.PermissionDir); err != nil {
-			common.Logger("fatal", "Failed to create custom output directory '%s': %w", outputDir, err)
+	// Generate the filename
+	if filenameTemplate == "" {
+		filenameTemplate = config.PostgresAuditLogsFilenameTemplate
+	}
+	fileName := common.BuildReportFilename(filenameTemplate, common.ReportFilenameVars(projectID, instanceID))
+	content := []byte(stdout)
+	if format == AuditLogsFormatJSON {
+		if filepath.Ext(fileName) == ".txt" {
+			fileName = strings.TrimSuffix(fileName, ".txt") + ".json"
+		}
+		content, err = ParseAuditLogEntries(stdout)
+		if err != nil {
+			return err
 		}
 	}
-
-	// Generate the filename
-	timestamp := time.Now().Format("20060102-150405")
-	fileName := fmt.Sprintf("%s_%s_audit_logs_%s.txt", projectID, instanceID, timestamp)
 	filePath := filepath.Join(outputDir, fileName)
+	if appendTo != "" {
+		filePath = common.ResolveAppendPath(outputDir, appendTo)
+	}
+
+	// Create the output directory if it doesn't exist. The filename template can contain directories too.
+	if err := os.MkdirAll(filepath.Dir(filePath), config.PermissionDir); err != nil {
+		return fmt.Errorf("failed to create custom output directory '%s': %w", filepath.Dir(filePath), err)
+	}
 
 	// Write the output to the file
-	if err := os.WriteFile(filePath, []byte(stdout), config.PermissionFile); err != nil {
-		common.Logger("fatal", "Failed to write audit logs to file '%s': %w", filePath, err)
+	if appendTo != "" {
+		if err := common.AppendToFile(filePath, content, config.PermissionFile); err != nil {
+			return fmt.Errorf("failed to append audit logs to file '%s': %w", filePath, err)
+		}
+	} else if err := common.WriteFileAtomic(filePath, content, config.PermissionFile); err != nil {
+		return fmt.Errorf("failed to write audit logs to file '%s': %w", filePath, err)
 	}
 
 	common.Logger("info", "Successfully exported audit logs to: %s\n", filePath)
+	return nil
+}
+
+// FilterPostgresDatabases returns the databases to be checked by the permissions export.
+// If includeDatabases is not empty, only the databases in that list are returned (in the instance order)
+// and a warning is logged for each one not found in the instance. Otherwise, the internal
+// database 'cloudsqladmin' and the databases matching excludeRegex are skipped.
+func FilterPostgresDatabases(dbNames, includeDatabases []string, excludeRegex *regexp.Regexp) []string {
+	var filtered []string
+
+	if len(includeDatabases) > 0 {
+		included := make(map[string]bool, len(includeDatabases))
+		for _, dbName := range includeDatabases {
+			included[dbName] = true
+		}
+
+		found := make(map[string]bool, len(dbNames))
+		for _, dbName := range dbNames {
+			found[dbName] = true
+			if included[dbName] {
+				filtered = append(filtered, dbName)
+			}
+		}
+
+		for _, dbName := range includeDatabases {
+			if !found[dbName] {
+				common.Logger("warning", "Database '%s' not found in instance. Skipping...", dbName)
+			}
+		}
+		return filtered
+	}
+
+	for _, dbName := range dbNames {
+		if dbName == "cloudsqladmin" {
+			common.Logger("info", "Skipping internal database 'cloudsqladmin'")
+			continue
+		}
+		if excludeRegex != nil && excludeRegex.MatchString(dbName) {
+			common.Logger("info", "Skipping database '%s' (matches exclude pattern)", dbName)
+			continue
+		}
+		filtered = append(filtered, dbName)
+	}
+	return filtered
 }
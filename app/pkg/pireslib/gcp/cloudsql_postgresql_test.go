@@ -0,0 +1,704 @@
+package gcp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aeciopires/pires-cli/internal/config"
+	"github.com/aeciopires/pires-cli/pkg/pireslib/common"
+)
+
+// psqlDatabasesScript is the body of a fake psql listing the databases of $CLI_TEST_DATABASES
+// and returning one grant by database (the variable db has the database of the connection)
+const psqlDatabasesScript = `case "$4" in
+*pg_database*) printf '%s\n' $CLI_TEST_DATABASES ;;
+*role_table_grants*) echo "app_user|public.orders_$db|SELECT" ;;
+esac`
+
+// fakePsql replaces config.PsqlPath by a script running body during the test. The script logs
+// the database of each connection (dbname of the connection string) in the returned file.
+func fakePsql(t testing.TB, body string) string {
+	t.Helper()
+	dir := t.TempDir()
+	logFile := filepath.Join(dir, "psql.log")
+	script := filepath.Join(dir, "psql")
+	content := "#!/bin/sh\n" +
+		"db=$(printf '%s\\n' \"$1\" | sed -n 's/.*dbname=\\([^ ]*\\).*/\\1/p')\n" +
+		"echo \"$db\" >> '" + logFile + "'\n" +
+		body + "\n"
+	if err := os.WriteFile(script, []byte(content), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	previous := config.PsqlPath
+	config.PsqlPath = script
+	t.Cleanup(func() { config.PsqlPath = previous })
+	return logFile
+}
+
+// psqlDatabases returns the databases connected by the fake psql, sorted
+func psqlDatabases(t *testing.T, logFile string) []string {
+	t.Helper()
+	data, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	databases := strings.Fields(string(data))
+	slices.Sort(databases)
+	return databases
+}
+
+// testExportPostgresOptions are the options of ExportPostgresUsersAndPermissions in the tests,
+// with the connection of fakePsql and a text report of all databases.
+func testExportPostgresOptions(outputDir string) ExportPostgresOptions {
+	return ExportPostgresOptions{
+		ProjectID:        "my-project",
+		InstanceID:       "my-instance",
+		Host:             "127.0.0.1",
+		Port:             "5432",
+		User:             "postgres",
+		Password:         "secret",
+		OutputDir:        outputDir,
+		FilenameTemplate: "report.txt",
+		Format:           PermissionsReportFormatText,
+	}
+}
+
+func TestFilterPostgresDatabases(t *testing.T) {
+	dbNames := []string{"postgres", "app", "audit", "cloudsqladmin", "app_test"}
+
+	tests := []struct {
+		name    string
+		include []string
+		exclude string
+		want    []string
+	}{
+		{"all but cloudsqladmin", nil, "", []string{"postgres", "app", "audit", "app_test"}},
+		{"exclude pattern", nil, "_test$", []string{"postgres", "app", "audit"}},
+		{"include keeps instance order", []string{"audit", "app"}, "", []string{"app", "audit"}},
+		{"include has precedence over exclude", []string{"app_test"}, "_test$", []string{"app_test"}},
+		{"include skips missing databases", []string{"app", "missing"}, "", []string{"app"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var excludeRegex *regexp.Regexp
+			if tt.exclude != "" {
+				excludeRegex = regexp.MustCompile(tt.exclude)
+			}
+			if got := FilterPostgresDatabases(dbNames, tt.include, excludeRegex); !slices.Equal(got, tt.want) {
+				t.Errorf("FilterPostgresDatabases() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExportPostgresUsersAndPermissionsIncludeDatabases(t *testing.T) {
+	t.Setenv("CLI_TEST_DATABASES", "postgres app audit cloudsqladmin")
+	logFile := fakePsql(t, psqlDatabasesScript)
+	fakeGcloud(t, "echo operator@example.com")
+	outputDir := t.TempDir()
+
+	options := testExportPostgresOptions(outputDir)
+	options.IncludeDatabases = []string{"app", "missing"}
+	err := ExportPostgresUsersAndPermissions(options)
+	if err != nil {
+		t.Fatalf("ExportPostgresUsersAndPermissions: %v", err)
+	}
+
+	// The databases are listed from 'postgres' and only 'app' is queried
+	if got, want := psqlDatabases(t, logFile), []string{"app", "postgres"}; !slices.Equal(got, want) {
+		t.Errorf("connected databases = %v, want %v", got, want)
+	}
+
+	report, err := os.ReadFile(filepath.Join(outputDir, "report.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(report), "DATABASE: app\n") || !strings.Contains(string(report), "orders_app") {
+		t.Errorf("report doesn't contain the permissions of 'app':\n%s", report)
+	}
+	if strings.Contains(string(report), "DATABASE: audit") || strings.Contains(string(report), "DATABASE: missing") {
+		t.Errorf("report contains databases not included:\n%s", report)
+	}
+}
+
+func TestExportPostgresAuditLogsAppendsAcrossRuns(t *testing.T) {
+	fakeGcloud(t, `echo "$CLI_TEST_LOG_LINE"`)
+	outputDir := t.TempDir()
+
+	for _, line := range []string{"2025-01-02T03:04:05Z statement: INSERT INTO orders", "2025-01-03T03:04:05Z statement: DELETE FROM orders"} {
+		t.Setenv("CLI_TEST_LOG_LINE", line)
+		if err := ExportPostgresAuditLogs("p", "nonprod-psql", outputDir, "", "audit/daily.log", AuditLogsFormatText); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	content, err := os.ReadFile(filepath.Join(outputDir, "audit", "daily.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "2025-01-02T03:04:05Z statement: INSERT INTO orders\n2025-01-03T03:04:05Z statement: DELETE FROM orders\n"
+	if string(content) != want {
+		t.Errorf("content = %q, want %q", content, want)
+	}
+}
+
+func TestExportPostgresUsersAndPermissionsReportMetadata(t *testing.T) {
+	t.Setenv("CLI_TEST_DATABASES", "app")
+	fakePsql(t, psqlDatabasesScript)
+	fakeGcloud(t, `[ "$*" = "config get-value account" ] && echo operator@example.com`)
+	outputDir := t.TempDir()
+
+	metadata := common.ReportMetadata{Title: "Audit of ticket OPS-123", Note: "Reviewed by the security team"}
+	options := testExportPostgresOptions(outputDir)
+	options.Metadata = metadata
+	err := ExportPostgresUsersAndPermissions(options)
+	if err != nil {
+		t.Fatalf("ExportPostgresUsersAndPermissions: %v", err)
+	}
+
+	report, err := os.ReadFile(filepath.Join(outputDir, "report.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(report), "Audit of ticket OPS-123\n") {
+		t.Errorf("report doesn't start with the title:\n%s", report)
+	}
+	if !strings.Contains(string(report), "Operator: operator@example.com\n") {
+		t.Errorf("report doesn't contain the operator account:\n%s", report)
+	}
+	if !strings.HasSuffix(string(report), "Reviewed by the security team\n") {
+		t.Errorf("report doesn't end with the note:\n%s", report)
+	}
+}
+
+// auditLogsScript is a fake gcloud returning the audit logs in the format of --format argument (the last one)
+const auditLogsScript = `for arg; do format="$arg"; done
+case "$format" in
+--format=json) echo '[{"timestamp": "2025-01-02T03:04:05Z", "severity": "INFO", "textPayload": "statement: INSERT INTO orders", "labels": {"LOG_BUCKET_NUM": "1"}, "insertId": "abc"}]' ;;
+"--format=value(timestamp,textPayload)") printf '2025-01-02T03:04:05Z\tstatement: INSERT INTO orders\n' ;;
+*) exit 1 ;;
+esac`
+
+func TestExportPostgresAuditLogsFormats(t *testing.T) {
+	fakeGcloud(t, auditLogsScript)
+
+	tests := []struct {
+		format   string
+		wantFile string
+		want     string
+	}{
+		{AuditLogsFormatText, "audit.txt", "2025-01-02T03:04:05Z\tstatement: INSERT INTO orders\n"},
+		{AuditLogsFormatJSON, "audit.json", `[
+  {
+    "timestamp": "2025-01-02T03:04:05Z",
+    "severity": "INFO",
+    "textPayload": "statement: INSERT INTO orders",
+    "labels": {
+      "LOG_BUCKET_NUM": "1"
+    }
+  }
+]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			outputDir := t.TempDir()
+			if err := ExportPostgresAuditLogs("p", "nonprod-psql", outputDir, "audit.txt", "", tt.format); err != nil {
+				t.Fatal(err)
+			}
+
+			content, err := os.ReadFile(filepath.Join(outputDir, tt.wantFile))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(content) != tt.want {
+				t.Errorf("content = %q, want %q", content, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseAuditLogEntriesInvalid(t *testing.T) {
+	if _, err := ParseAuditLogEntries("not json"); err == nil {
+		t.Error("expected error for invalid JSON")
+	}
+	if content, err := ParseAuditLogEntries(""); err != nil || string(content) != "[]" {
+		t.Errorf("ParseAuditLogEntries(\"\") = %s, %v, want empty array", content, err)
+	}
+}
+
+func TestExportPostgresUsersAndPermissionsRetriesTransientErrors(t *testing.T) {
+	// The connection of 'app' is reset once, then the permissions are returned
+	t.Setenv("CLI_TEST_DATABASES", "app")
+	counter := filepath.Join(t.TempDir(), "counter")
+	t.Setenv("CLI_TEST_COUNTER", counter)
+	fakePsql(t, `if [ "$db" = app ] && [ ! -e "$CLI_TEST_COUNTER" ]; then
+  touch "$CLI_TEST_COUNTER"; echo "server closed the connection unexpectedly" >&2; exit 2
+fi
+`+psqlDatabasesScript)
+	fakeGcloud(t, "exit 1")
+	previousBackoff := config.PostgresQueryRetryBackoff
+	config.PostgresQueryRetryBackoff = time.Millisecond
+	t.Cleanup(func() { config.PostgresQueryRetryBackoff = previousBackoff })
+	outputDir := t.TempDir()
+
+	err := ExportPostgresUsersAndPermissions(testExportPostgresOptions(outputDir))
+	if err != nil {
+		t.Fatalf("ExportPostgresUsersAndPermissions: %v", err)
+	}
+
+	report, err := os.ReadFile(filepath.Join(outputDir, "report.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(report), "Could not query") || !strings.Contains(string(report), "orders_app") {
+		t.Errorf("report isn't complete after the retry:\n%s", report)
+	}
+}
+
+func TestBuildPostgresConnInfo(t *testing.T) {
+	previous := config.PostgresConnectTimeout
+	t.Cleanup(func() { config.PostgresConnectTimeout = previous })
+
+	tests := []struct {
+		timeout     time.Duration
+		sslRequired bool
+		want        string
+	}{
+		{10 * time.Second, false, "host=10.0.0.5 port=5432 user=postgres password=secret dbname=app sslmode=disable connect_timeout=10"},
+		// libpq accepts only seconds, so the timeout is rounded up
+		{1500 * time.Millisecond, true, "host=10.0.0.5 port=5432 user=postgres password=secret dbname=app sslmode=require connect_timeout=2"},
+	}
+	for _, tt := range tests {
+		config.PostgresConnectTimeout = tt.timeout
+		if got := BuildPostgresConnInfo("10.0.0.5", "5432", "postgres", "secret", "app", tt.sslRequired); got != tt.want {
+			t.Errorf("BuildPostgresConnInfo() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestExportPostgresUsersAndPermissionsConnectionLimits(t *testing.T) {
+	previousTimeout, previousMaxOpenConns, previousMaxConcurrency := config.PostgresConnectTimeout, config.PostgresMaxOpenConns, config.MaxConcurrency
+	config.PostgresConnectTimeout, config.PostgresMaxOpenConns, config.MaxConcurrency = 30*time.Second, 1, 8
+	t.Cleanup(func() {
+		config.PostgresConnectTimeout, config.PostgresMaxOpenConns, config.MaxConcurrency = previousTimeout, previousMaxOpenConns, previousMaxConcurrency
+	})
+	t.Setenv("CLI_TEST_DATABASES", "app audit billing")
+	running := filepath.Join(t.TempDir(), "running")
+	t.Setenv("CLI_TEST_RUNNING", running)
+	// The psql fails if other connection is opened at the same time
+	fakePsql(t, `case "$1" in *connect_timeout=30*) ;; *) echo "missing connect_timeout" >&2; exit 1 ;; esac
+if ! mkdir "$CLI_TEST_RUNNING" 2>/dev/null; then echo "too many connections" >&2; exit 1; fi
+sleep 0.05
+`+psqlDatabasesScript+`
+rmdir "$CLI_TEST_RUNNING"`)
+	fakeGcloud(t, "exit 1")
+	outputDir := t.TempDir()
+
+	err := ExportPostgresUsersAndPermissions(testExportPostgresOptions(outputDir))
+	if err != nil {
+		t.Fatalf("ExportPostgresUsersAndPermissions: %v", err)
+	}
+
+	report, err := os.ReadFile(filepath.Join(outputDir, "report.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(report), "Could not query") {
+		t.Errorf("report has failed connections with --db-max-open-conns 1:\n%s", report)
+	}
+	for _, table := range []string{"orders_app", "orders_audit", "orders_billing"} {
+		if !strings.Contains(string(report), table) {
+			t.Errorf("report doesn't contain %s:\n%s", table, report)
+		}
+	}
+}
+
+func TestExportPostgresUsersAndPermissionsConnectionSetups(t *testing.T) {
+	t.Setenv("CLI_TEST_DATABASES", "app audit billing")
+	logFile := fakePsql(t, psqlDatabasesScript)
+	fakeGcloud(t, "exit 1")
+
+	err := ExportPostgresUsersAndPermissions(testExportPostgresOptions(t.TempDir()))
+	if err != nil {
+		t.Fatalf("ExportPostgresUsersAndPermissions: %v", err)
+	}
+
+	// One connection lists the databases and one connection checks each database: 1 + 3
+	if got, want := psqlDatabases(t, logFile), []string{"app", "audit", "billing", "postgres"}; !slices.Equal(got, want) {
+		t.Errorf("connected databases = %v, want one connection each", got)
+	}
+}
+
+// BenchmarkExportPostgresUsersAndPermissions measures the export by number of databases and reports the
+// connection setups of each export (connections/op). A PostgreSQL connection is bound to its database, so
+// a shared connection can't check other databases and the minimum is 1 + the number of databases.
+func BenchmarkExportPostgresUsersAndPermissions(b *testing.B) {
+	previousMaxConcurrency := config.MaxConcurrency
+	config.MaxConcurrency = 8
+	b.Cleanup(func() { config.MaxConcurrency = previousMaxConcurrency })
+	fakeGcloud(b, "exit 1")
+
+	for _, databases := range []int{1, 10, 50} {
+		b.Run(fmt.Sprintf("databases=%d", databases), func(b *testing.B) {
+			var names []string
+			for i := range databases {
+				names = append(names, fmt.Sprintf("db%d", i))
+			}
+			b.Setenv("CLI_TEST_DATABASES", strings.Join(names, " "))
+			logFile := fakePsql(b, psqlDatabasesScript)
+			outputDir := b.TempDir()
+
+			b.ResetTimer()
+			for range b.N {
+				err := ExportPostgresUsersAndPermissions(testExportPostgresOptions(outputDir))
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.StopTimer()
+
+			content, err := os.ReadFile(logFile)
+			if err != nil {
+				b.Fatal(err)
+			}
+			b.ReportMetric(float64(len(strings.Fields(string(content))))/float64(b.N), "connections/op")
+		})
+	}
+}
+
+func TestParsePostgresPermissions(t *testing.T) {
+	permOut := "app_user|public.orders|SELECT\napp_user|public.orders|INSERT\napp_user|public.items|SELECT\nPUBLIC|public.orders|SELECT\nmalformed\nreporter|sales.totals|SELECT\n"
+
+	permissions := ParsePostgresPermissions(permOut)
+
+	want := PostgresTablePermissions{
+		"app_user": {"public.orders": {"SELECT", "INSERT"}, "public.items": {"SELECT"}},
+		"reporter": {"sales.totals": {"SELECT"}},
+	}
+	if !reflect.DeepEqual(permissions, want) {
+		t.Errorf("ParsePostgresPermissions() = %v, want %v", permissions, want)
+	}
+	if permissions := ParsePostgresPermissions(""); len(permissions) != 0 {
+		t.Errorf("ParsePostgresPermissions(\"\") = %v, want empty", permissions)
+	}
+}
+
+func TestBuildPostgresPermissionsJSON(t *testing.T) {
+	permissions := map[string]PostgresTablePermissions{
+		"app":   {"app_user": {"public.orders": {"SELECT", "INSERT"}}},
+		"audit": {},
+	}
+
+	content, err := BuildPostgresPermissionsJSON(permissions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{
+  "app": {
+    "app_user": {
+      "public.orders": [
+        "SELECT",
+        "INSERT"
+      ]
+    }
+  },
+  "audit": {}
+}`
+	if string(content) != want {
+		t.Errorf("BuildPostgresPermissionsJSON() =\n%s\nwant\n%s", content, want)
+	}
+}
+
+func TestExportPostgresUsersAndPermissionsJSON(t *testing.T) {
+	t.Setenv("CLI_TEST_DATABASES", "app audit")
+	fakePsql(t, psqlDatabasesScript)
+	fakeGcloud(t, "exit 1")
+	outputDir := t.TempDir()
+
+	options := testExportPostgresOptions(outputDir)
+	options.Format = PermissionsReportFormatJSON
+	options.Metadata = common.ReportMetadata{Title: "Audit"}
+	err := ExportPostgresUsersAndPermissions(options)
+	if err != nil {
+		t.Fatalf("ExportPostgresUsersAndPermissions: %v", err)
+	}
+
+	// The .txt extension of template is replaced and the header isn't written
+	content, err := os.ReadFile(filepath.Join(outputDir, "report.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var report map[string]PostgresTablePermissions
+	if err := json.Unmarshal(content, &report); err != nil {
+		t.Fatalf("report isn't valid JSON: %v\n%s", err, content)
+	}
+	want := map[string]PostgresTablePermissions{
+		"app":   {"app_user": {"public.orders_app": {"SELECT"}}},
+		"audit": {"app_user": {"public.orders_audit": {"SELECT"}}},
+	}
+	if !reflect.DeepEqual(report, want) {
+		t.Errorf("report = %v, want %v", report, want)
+	}
+}
+
+func TestBuildPostgresGrantSQL(t *testing.T) {
+	statements, err := BuildPostgresGrantSQL("app", "public", "name.surname@company.com", []string{"select", " INSERT", "Select"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		`GRANT CONNECT ON DATABASE "app" TO "name.surname@company.com";`,
+		`GRANT USAGE ON SCHEMA "public" TO "name.surname@company.com";`,
+		`GRANT SELECT, INSERT ON ALL TABLES IN SCHEMA "public" TO "name.surname@company.com";`,
+	}
+	if !slices.Equal(statements, want) {
+		t.Errorf("BuildPostgresGrantSQL() =\n%s\nwant\n%s", strings.Join(statements, "\n"), strings.Join(want, "\n"))
+	}
+
+	// ALL includes the other privileges and the double quotes of identifiers are escaped
+	statements, err = BuildPostgresGrantSQL("app", `sales"x`, "reporter", []string{"SELECT", "ALL"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `GRANT ALL ON ALL TABLES IN SCHEMA "sales""x" TO "reporter";`; statements[2] != want {
+		t.Errorf("statement = %s, want %s", statements[2], want)
+	}
+}
+
+func TestBuildPostgresGrantSQLInvalid(t *testing.T) {
+	tests := []struct {
+		name       string
+		user       string
+		privileges []string
+	}{
+		{"unsupported privilege", "reporter", []string{"SELECT", "DROP"}},
+		{"no privileges", "reporter", nil},
+		{"empty user", "", []string{"SELECT"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := BuildPostgresGrantSQL("app", "public", tt.user, tt.privileges); common.ExitCode(err) != common.ExitCodeValidation {
+				t.Errorf("error = %v, want validation error", err)
+			}
+		})
+	}
+}
+
+func TestGrantPostgresDatabaseAccess(t *testing.T) {
+	sqlFile := filepath.Join(t.TempDir(), "sql")
+	t.Setenv("CLI_TEST_SQL", sqlFile)
+	logFile := fakePsql(t, `[ "$2 $3 $4" = "-v ON_ERROR_STOP=1 -c" ] || exit 1
+printf '%s' "$5" > "$CLI_TEST_SQL"`)
+	statements := []string{`GRANT CONNECT ON DATABASE "app" TO "reporter";`, `GRANT SELECT ON ALL TABLES IN SCHEMA "public" TO "reporter";`}
+
+	if err := GrantPostgresDatabaseAccess("127.0.0.1", "5432", "postgres", "secret", "app", false, statements); err != nil {
+		t.Fatal(err)
+	}
+
+	// All statements are sent in a single command, connected to the database
+	if got := psqlDatabases(t, logFile); !slices.Equal(got, []string{"app"}) {
+		t.Errorf("connected databases = %v, want [app]", got)
+	}
+	if content, _ := os.ReadFile(sqlFile); string(content) != strings.Join(statements, "\n") {
+		t.Errorf("SQL = %q, want the statements", content)
+	}
+}
+
+func TestGrantPostgresDatabaseAccessFailure(t *testing.T) {
+	fakePsql(t, `echo 'ERROR:  role "reporter" does not exist' >&2; exit 3`)
+
+	err := GrantPostgresDatabaseAccess("127.0.0.1", "5432", "postgres", "secret", "app", false, []string{"GRANT ..."})
+	if common.ExitCode(err) != common.ExitCodeExternalCommand || !strings.Contains(err.Error(), `role "reporter" does not exist`) {
+		t.Errorf("error = %v, want external command error with psql stderr", err)
+	}
+}
+
+func TestEvaluatePostgresAuditFlags(t *testing.T) {
+	tests := []struct {
+		name         string
+		flags        []CloudSQLDatabaseFlag
+		wantEnabled  bool
+		wantProblems int
+	}{
+		{"ready", []CloudSQLDatabaseFlag{{PgauditEnableFlag, "on"}, {PgauditLogFlag, "read, WRITE"}}, true, 0},
+		{"all classes", []CloudSQLDatabaseFlag{{PgauditEnableFlag, "ON"}, {PgauditLogFlag, "all"}}, true, 0},
+		{"without flags", nil, false, 2},
+		{"pgaudit off", []CloudSQLDatabaseFlag{{PgauditEnableFlag, "off"}, {PgauditLogFlag, "write"}}, false, 1},
+		{"without write class", []CloudSQLDatabaseFlag{{PgauditEnableFlag, "on"}, {PgauditLogFlag, "ddl"}}, true, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			readiness := AuditReadiness{Instance: "nonprod-psql"}
+			EvaluatePostgresAuditFlags(&readiness, tt.flags)
+			if readiness.PgauditEnabled != tt.wantEnabled || len(readiness.Problems) != tt.wantProblems || len(readiness.Remediations) != tt.wantProblems {
+				t.Errorf("readiness = %+v, want enabled %t and %d problem(s) with remediation", readiness, tt.wantEnabled, tt.wantProblems)
+			}
+			for _, remediation := range readiness.Remediations {
+				if !strings.Contains(remediation, "set-flag -i nonprod-psql") {
+					t.Errorf("remediation = %q, want set-flag command of instance", remediation)
+				}
+			}
+		})
+	}
+}
+
+// auditReadinessScript is a fake gcloud with the database flags of $CLI_TEST_FLAGS and the logs of $CLI_TEST_LOGS
+const auditReadinessScript = `case "$*" in
+  "sql instances describe nonprod-psql --project p --format=json(settings.databaseFlags)") echo "{\"settings\": {\"databaseFlags\": $CLI_TEST_FLAGS}}" ;;
+  "logging logs list --project p --format=value(.)") printf '%s\n' $CLI_TEST_LOGS ;;
+  *) exit 1 ;;
+esac`
+
+func TestCheckPostgresAuditReadiness(t *testing.T) {
+	fakeGcloud(t, auditReadinessScript)
+	t.Setenv("CLI_TEST_FLAGS", `[{"name": "cloudsql.enable_pgaudit", "value": "on"}, {"name": "pgaudit.log", "value": "write"}]`)
+	t.Setenv("CLI_TEST_LOGS", "projects/p/logs/cloudaudit.googleapis.com%2Factivity projects/p/logs/cloudsql.googleapis.com%2Fpostgres.log")
+
+	readiness, err := CheckPostgresAuditReadiness("p", "nonprod-psql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !readiness.Ready || !readiness.LogExists || len(readiness.Problems) != 0 {
+		t.Errorf("readiness = %+v, want ready", readiness)
+	}
+}
+
+func TestCheckPostgresAuditReadinessNotReady(t *testing.T) {
+	fakeGcloud(t, auditReadinessScript)
+	t.Setenv("CLI_TEST_FLAGS", `[{"name": "max_connections", "value": "100"}]`)
+	t.Setenv("CLI_TEST_LOGS", "projects/p/logs/cloudaudit.googleapis.com%2Factivity")
+
+	readiness, err := CheckPostgresAuditReadiness("p", "nonprod-psql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The flags and the log are missing
+	if readiness.Ready || readiness.PgauditEnabled || readiness.LogExists || len(readiness.Problems) != 3 {
+		t.Errorf("readiness = %+v, want 3 problems", readiness)
+	}
+	if !strings.Contains(readiness.Remediations[0], "-f cloudsql.enable_pgaudit -v on") {
+		t.Errorf("remediations = %q, want to enable pgaudit first", readiness.Remediations)
+	}
+}
+
+func TestParsePostgresDatabaseSize(t *testing.T) {
+	size, tables, err := ParsePostgresDatabaseSize("8593923|12\n")
+	if err != nil || size != 8593923 || tables != 12 {
+		t.Errorf("ParsePostgresDatabaseSize() = %d, %d, %v, want 8593923, 12", size, tables, err)
+	}
+	for _, output := range []string{"", "8593923", "big|12", "8593923|many"} {
+		if _, _, err := ParsePostgresDatabaseSize(output); err == nil {
+			t.Errorf("ParsePostgresDatabaseSize(%q): expected error", output)
+		}
+	}
+}
+
+func TestBuildPostgresDatabaseSizesReport(t *testing.T) {
+	report := BuildPostgresDatabaseSizesReport([]PostgresDatabaseSize{
+		{Name: "app", Size: 5 * 1024 * 1024, Tables: 12},
+		{Name: "audit", Size: 3 * 1024 * 1024 * 1024 / 2, Tables: 3},
+		{Name: "broken", Err: errors.New("connection refused")},
+		{Name: "empty", Size: 7 * 1024 * 1024, Tables: 0},
+	})
+
+	lines := strings.Split(report, "\n")
+	// The largest databases first, with the failed ones at the end
+	for i, want := range []string{"DATABASE SIZE", "audit 1.5 GiB", "empty 7.0 MiB", "app 5.0 MiB", "broken ERROR"} {
+		if i >= len(lines) || !strings.HasPrefix(strings.Join(strings.Fields(lines[i]), " "), want) {
+			t.Errorf("line %d does not start with %q in report:\n%s", i+1, want, report)
+		}
+	}
+	for _, want := range []string{"Total: 3 database(s), 1.5 GiB", "Could not query 1 database(s):", "broken: connection refused"} {
+		if !strings.Contains(report, want) {
+			t.Errorf("report does not contain %q:\n%s", want, report)
+		}
+	}
+
+	if report := BuildPostgresDatabaseSizesReport(nil); report != "No databases found.\n" {
+		t.Errorf("report without databases = %q", report)
+	}
+}
+
+func TestExportPostgresDatabaseSizes(t *testing.T) {
+	t.Setenv("CLI_TEST_DATABASES", "postgres app cloudsqladmin")
+	fakePsql(t, `case "$4" in
+*pg_database_size*) [ "$db" = app ] && echo "5242880|12" || echo "1048576|0" ;;
+*) `+psqlDatabasesScript+` ;;
+esac`)
+	fakeGcloud(t, "echo operator@example.com")
+	outputDir := t.TempDir()
+
+	if err := ExportPostgresDatabaseSizes("my-project", "my-instance", "127.0.0.1", "5432", "postgres", "secret", outputDir, "", "sizes.txt", nil, false); err != nil {
+		t.Fatal(err)
+	}
+	report, err := os.ReadFile(filepath.Join(outputDir, "sizes.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"Database Sizes Report for Instance: 'my-instance'", "operator@example.com", "5242880", "1048576", "Total: 2 database(s), 6.0 MiB"} {
+		if !strings.Contains(string(report), want) {
+			t.Errorf("report does not contain %q:\n%s", want, report)
+		}
+	}
+	if strings.Contains(string(report), "cloudsqladmin") {
+		t.Errorf("report contains the database of Cloud SQL:\n%s", report)
+	}
+}
+
+func TestExportPostgresUsersAndPermissionsResume(t *testing.T) {
+	// The permissions of $CLI_TEST_FAIL_DB can't be queried
+	t.Setenv("CLI_TEST_DATABASES", "app billing")
+	t.Setenv("CLI_TEST_FAIL_DB", "billing")
+	fakeGcloud(t, "exit 1")
+	outputDir := t.TempDir()
+	statePath := filepath.Join(outputDir, ".my-project_my-instance_database_permissions.state.json")
+	export := func(resume bool) {
+		t.Helper()
+		options := testExportPostgresOptions(outputDir)
+		options.Resume = resume
+		err := ExportPostgresUsersAndPermissions(options)
+		if err != nil {
+			t.Fatalf("ExportPostgresUsersAndPermissions(resume=%t): %v", resume, err)
+		}
+	}
+	script := `if [ "$db" = "$CLI_TEST_FAIL_DB" ]; then echo "ERROR: canceling statement due to statement timeout" >&2; exit 1; fi
+` + psqlDatabasesScript
+
+	fakePsql(t, script)
+	export(false)
+	state, err := os.ReadFile(statePath)
+	if err != nil {
+		t.Fatalf("state file not kept after the failure: %v", err)
+	}
+	if !strings.Contains(string(state), "orders_app") || strings.Contains(string(state), "orders_billing") {
+		t.Errorf("state file doesn't have only the completed database:\n%s", state)
+	}
+
+	// The resumed export only queries the failed database and completes the same report
+	t.Setenv("CLI_TEST_FAIL_DB", "")
+	psqlLog := fakePsql(t, script)
+	export(true)
+	if got, want := psqlDatabases(t, psqlLog), []string{"billing", "postgres"}; !slices.Equal(got, want) {
+		t.Errorf("databases connected by the resumed export = %v, want %v", got, want)
+	}
+	report, err := os.ReadFile(filepath.Join(outputDir, "report.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(report), "Could not query") || !strings.Contains(string(report), "orders_app") || !strings.Contains(string(report), "orders_billing") {
+		t.Errorf("report isn't complete after the resume:\n%s", report)
+	}
+	if _, err := os.Stat(statePath); !os.IsNotExist(err) {
+		t.Errorf("state file not removed after the successful export: %v", err)
+	}
+}
@@ -0,0 +1,566 @@
+package gcp
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aeciopires/pires-cli/internal/config"
+	"github.com/aeciopires/pires-cli/pkg/pireslib/common"
+)
+
+// setPollInterval replaces config.GCPCloudSQLOperationPollInterval during the test
+func setPollInterval(t *testing.T, interval time.Duration) {
+	t.Helper()
+	previous := config.GCPCloudSQLOperationPollInterval
+	config.GCPCloudSQLOperationPollInterval = interval
+	t.Cleanup(func() { config.GCPCloudSQLOperationPollInterval = previous })
+}
+
+// operationStatusesScript is a fake gcloud that returns the next status of $CLI_TEST_STATUSES (separated by spaces)
+// in each call of 'sql operations describe'. The last status is repeated. The index is kept in $CLI_TEST_COUNTER.
+const operationStatusesScript = `count=$(cat "$CLI_TEST_COUNTER" 2>/dev/null || echo 0)
+echo $((count + 1)) > "$CLI_TEST_COUNTER"
+set -- $CLI_TEST_STATUSES
+shift $(( count < $# ? count : $# - 1 ))
+case "$1" in
+  FAILED) echo '{"name": "op-1", "operationType": "CREATE", "status": "DONE", "error": {"errors": [{"code": "ERROR_RDBMS", "message": "out of quota"}]}}' ;;
+  *) echo "{\"name\": \"op-1\", \"operationType\": \"CREATE\", \"status\": \"$1\"}" ;;
+esac`
+
+// fakeOperationStatuses configures operationStatusesScript and returns the file with the number of calls
+func fakeOperationStatuses(t *testing.T, statuses string) string {
+	t.Helper()
+	fakeGcloud(t, operationStatusesScript)
+	counter := filepath.Join(t.TempDir(), "counter")
+	t.Setenv("CLI_TEST_COUNTER", counter)
+	t.Setenv("CLI_TEST_STATUSES", statuses)
+	return counter
+}
+
+func TestWaitForGCPCloudSQLOperation(t *testing.T) {
+	setPollInterval(t, time.Millisecond)
+	counter := fakeOperationStatuses(t, "PENDING RUNNING DONE")
+
+	if err := WaitForGCPCloudSQLOperation("p", "op-1", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if calls, _ := os.ReadFile(counter); strings.TrimSpace(string(calls)) != "3" {
+		t.Errorf("operation described %s time(s), want 3", calls)
+	}
+}
+
+func TestWaitForGCPCloudSQLOperationFailed(t *testing.T) {
+	setPollInterval(t, time.Millisecond)
+	fakeOperationStatuses(t, "RUNNING FAILED")
+
+	err := WaitForGCPCloudSQLOperation("p", "op-1", time.Minute)
+	if err == nil || !strings.Contains(err.Error(), "ERROR_RDBMS: out of quota") {
+		t.Errorf("error = %v, want error of operation", err)
+	}
+}
+
+func TestWaitForGCPCloudSQLOperationTimeout(t *testing.T) {
+	setPollInterval(t, 10*time.Millisecond)
+	fakeOperationStatuses(t, "RUNNING")
+
+	err := WaitForGCPCloudSQLOperation("p", "op-1", 30*time.Millisecond)
+	if err == nil || !strings.Contains(err.Error(), "last status: RUNNING") {
+		t.Errorf("error = %v, want timeout", err)
+	}
+}
+
+// sampleInstancesJSON is a sample output of 'gcloud sql instances list --format=json', with fields not used by CLI
+const sampleInstancesJSON = `[
+  {"name": "nonprod-psql", "databaseVersion": "POSTGRES_15", "region": "us-central1", "state": "RUNNABLE", "tier": "db-custom-2-7680"},
+  {"name": "legacy-mysql", "databaseVersion": "MYSQL_8_0", "region": "us-east1", "state": "SUSPENDED"}
+]`
+
+func TestParseGCPCloudSQLInstances(t *testing.T) {
+	instances, err := ParseGCPCloudSQLInstances(sampleInstancesJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []CloudSQLInstance{
+		{Name: "nonprod-psql", DatabaseVersion: "POSTGRES_15", Region: "us-central1", State: "RUNNABLE"},
+		{Name: "legacy-mysql", DatabaseVersion: "MYSQL_8_0", Region: "us-east1", State: "SUSPENDED"},
+	}
+	if !slices.Equal(instances, want) {
+		t.Errorf("instances = %+v, want %+v", instances, want)
+	}
+
+	for _, empty := range []string{"", "[]", "\n"} {
+		if instances, err := ParseGCPCloudSQLInstances(empty); err != nil || instances == nil || len(instances) != 0 {
+			t.Errorf("ParseGCPCloudSQLInstances(%q) = %v, %v, want empty slice", empty, instances, err)
+		}
+	}
+	if _, err := ParseGCPCloudSQLInstances("not json"); err == nil {
+		t.Error("expected error for invalid JSON")
+	}
+}
+
+func TestListGCPCloudSQLInstances(t *testing.T) {
+	fakeGcloud(t, `[ "$*" = "sql instances list --project p --format=json" ] || exit 1
+echo '`+sampleInstancesJSON+`'`)
+
+	instances, err := ListGCPCloudSQLInstances("p")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(instances) != 2 || instances[0].Name != "nonprod-psql" {
+		t.Errorf("instances = %+v, want 2 instances", instances)
+	}
+}
+
+func TestBuildGCPCloudSQLBackupURI(t *testing.T) {
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	for _, bucket := range []string{"my-bucket", "gs://my-bucket", "gs://my-bucket/"} {
+		if got, want := BuildGCPCloudSQLBackupURI(bucket, "nonprod-psql", now), "gs://my-bucket/nonprod-psql-20250102-030405.sql.gz"; got != want {
+			t.Errorf("BuildGCPCloudSQLBackupURI(%q) = %s, want %s", bucket, got, want)
+		}
+	}
+}
+
+func TestExportGCPCloudSQLBackup(t *testing.T) {
+	fakeGcloud(t, echoArgsScript+` > "$CLI_TEST_ARGS"`)
+	argsFile := filepath.Join(t.TempDir(), "args")
+	t.Setenv("CLI_TEST_ARGS", argsFile)
+
+	backupURI, err := ExportGCPCloudSQLBackup("p", "nonprod-psql", "gs://my-bucket", []string{"app", "audit"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !regexp.MustCompile(`^gs://my-bucket/nonprod-psql-\d{8}-\d{6}\.sql\.gz$`).MatchString(backupURI) {
+		t.Errorf("backup URI = %s, want timestamped object of bucket", backupURI)
+	}
+
+	content, err := os.ReadFile(argsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"sql", "export", "sql", "nonprod-psql", backupURI, "--project", "p", "--database", "app,audit"}
+	if got := strings.Split(strings.TrimSpace(string(content)), "\n"); !slices.Equal(got, want) {
+		t.Errorf("args = %q, want %q", got, want)
+	}
+}
+
+func TestExportGCPCloudSQLBackupWithoutBucket(t *testing.T) {
+	fakeGcloud(t, "exit 1")
+	for _, bucket := range []string{"", "gs://"} {
+		if _, err := ExportGCPCloudSQLBackup("p", "nonprod-psql", bucket, nil); common.ExitCode(err) != common.ExitCodeValidation {
+			t.Errorf("bucket %q: error = %v, want validation error", bucket, err)
+		}
+	}
+}
+
+// sampleBackupsJSON is a sample output of 'gcloud sql backups list --format=json'
+const sampleBackupsJSON = `[{"id": "1736000000000", "windowStartTime": "2025-01-04T14:13:20.000Z", "status": "SUCCESSFUL", "type": "AUTOMATED", "instance": "nonprod-psql"},
+{"id": "1736100000000", "windowStartTime": "2025-01-05T18:00:00.000Z", "status": "RUNNING", "type": "ON_DEMAND"}]`
+
+func TestParseGCPCloudSQLBackups(t *testing.T) {
+	backups, err := ParseGCPCloudSQLBackups(sampleBackupsJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []CloudSQLBackup{
+		{ID: "1736000000000", WindowStartTime: time.Date(2025, 1, 4, 14, 13, 20, 0, time.UTC), Status: "SUCCESSFUL", Type: "AUTOMATED"},
+		{ID: "1736100000000", WindowStartTime: time.Date(2025, 1, 5, 18, 0, 0, 0, time.UTC), Status: "RUNNING", Type: "ON_DEMAND"},
+	}
+	if len(backups) != len(want) {
+		t.Fatalf("ParseGCPCloudSQLBackups() = %+v, want %+v", backups, want)
+	}
+	for i := range want {
+		if backups[i].ID != want[i].ID || !backups[i].WindowStartTime.Equal(want[i].WindowStartTime) || backups[i].Status != want[i].Status || backups[i].Type != want[i].Type {
+			t.Errorf("backup %d = %+v, want %+v", i, backups[i], want[i])
+		}
+	}
+
+	// Without backups, the list is empty but not nil
+	for _, output := range []string{"", "[]\n"} {
+		if backups, err := ParseGCPCloudSQLBackups(output); err != nil || backups == nil || len(backups) != 0 {
+			t.Errorf("ParseGCPCloudSQLBackups(%q) = %v, %v, want empty slice", output, backups, err)
+		}
+	}
+	if _, err := ParseGCPCloudSQLBackups("not json"); err == nil {
+		t.Error("expected error for invalid JSON")
+	}
+}
+
+func TestListGCPCloudSQLBackups(t *testing.T) {
+	fakeGcloud(t, `[ "$*" = "sql backups list --instance nonprod-psql --project p --format=json" ] || exit 1
+echo '`+sampleBackupsJSON+`'`)
+
+	backups, err := ListGCPCloudSQLBackups("p", "nonprod-psql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 2 || backups[0].ID != "1736000000000" {
+		t.Errorf("ListGCPCloudSQLBackups() = %+v, want the 2 sample backups", backups)
+	}
+
+	if _, err := ListGCPCloudSQLBackups("p", ""); common.ExitCode(err) != common.ExitCodeValidation {
+		t.Errorf("error = %v, want validation error without instance", err)
+	}
+}
+
+func TestCreateGCPCloudSQLBackup(t *testing.T) {
+	fakeGcloud(t, echoArgsScript+` > "$CLI_TEST_ARGS"; echo "backup-op-1"`)
+	argsFile := filepath.Join(t.TempDir(), "args")
+	t.Setenv("CLI_TEST_ARGS", argsFile)
+
+	operationID, err := CreateGCPCloudSQLBackup("p", "nonprod-psql", "before upgrade")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if operationID != "backup-op-1" {
+		t.Errorf("operation ID = %q, want backup-op-1", operationID)
+	}
+
+	content, err := os.ReadFile(argsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"sql", "backups", "create", "--instance", "nonprod-psql", "--project", "p", "--async", "--format=value(name)", "--description", "before upgrade"}
+	if got := strings.Split(strings.TrimSpace(string(content)), "\n"); !slices.Equal(got, want) {
+		t.Errorf("args = %q, want %q", got, want)
+	}
+}
+
+func TestCreateGCPCloudSQLBackupErrors(t *testing.T) {
+	fakeGcloud(t, `echo "ERROR: instance not found" >&2; exit 1`)
+
+	if _, err := CreateGCPCloudSQLBackup("p", "", ""); common.ExitCode(err) != common.ExitCodeValidation {
+		t.Errorf("error = %v, want validation error without instance", err)
+	}
+	if _, err := CreateGCPCloudSQLBackup("p", "missing-psql", ""); err == nil || !strings.Contains(err.Error(), "missing-psql") {
+		t.Errorf("error = %v, want failure of gcloud with the instance", err)
+	}
+}
+
+func TestGetGCPCloudSQLBackupID(t *testing.T) {
+	fakeGcloud(t, `case "$*" in
+*"describe backup-op-1"*) echo '{"name": "backup-op-1", "operationType": "BACKUP_VOLUME", "status": "DONE", "backupContext": {"backupId": "1736000000000"}}' ;;
+*) echo '{"name": "op-2", "operationType": "UPDATE", "status": "DONE"}' ;;
+esac`)
+
+	if backupID, err := GetGCPCloudSQLBackupID("p", "backup-op-1"); err != nil || backupID != "1736000000000" {
+		t.Errorf("GetGCPCloudSQLBackupID() = %q, %v, want 1736000000000", backupID, err)
+	}
+	if _, err := GetGCPCloudSQLBackupID("p", "op-2"); err == nil {
+		t.Error("expected error for operation without backup")
+	}
+}
+
+func TestMergeGCPCloudSQLDatabaseFlags(t *testing.T) {
+	current := []CloudSQLDatabaseFlag{{Name: "cloudsql.enable_pgaudit", Value: "on"}, {Name: "max_connections", Value: "100"}}
+
+	tests := []struct {
+		name  string
+		flag  string
+		value string
+		want  []CloudSQLDatabaseFlag
+	}{
+		{"new flag is appended", "log_min_duration_statement", "500", append(slices.Clone(current), CloudSQLDatabaseFlag{Name: "log_min_duration_statement", Value: "500"})},
+		{"existing flag is updated", "max_connections", "200", []CloudSQLDatabaseFlag{{Name: "cloudsql.enable_pgaudit", Value: "on"}, {Name: "max_connections", Value: "200"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MergeGCPCloudSQLDatabaseFlags(current, tt.flag, tt.value); !slices.Equal(got, tt.want) {
+				t.Errorf("MergeGCPCloudSQLDatabaseFlags() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if current[1].Value != "100" {
+		t.Errorf("current flags modified: %v", current)
+	}
+}
+
+func TestFormatGCPCloudSQLDatabaseFlags(t *testing.T) {
+	tests := []struct {
+		name  string
+		flags []CloudSQLDatabaseFlag
+		want  string
+	}{
+		{"comma delimiter", []CloudSQLDatabaseFlag{{Name: "a", Value: "1"}, {Name: "b", Value: "on"}}, "a=1,b=on"},
+		{"flag without value", []CloudSQLDatabaseFlag{{Name: "skip_show_database"}, {Name: "b", Value: "on"}}, "skip_show_database,b=on"},
+		{"value with comma", []CloudSQLDatabaseFlag{{Name: "pgaudit.log", Value: "read,write"}, {Name: "b", Value: "on"}}, "^;^pgaudit.log=read,write;b=on"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FormatGCPCloudSQLDatabaseFlags(tt.flags); got != tt.want {
+				t.Errorf("FormatGCPCloudSQLDatabaseFlags() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetGCPCloudSQLInstanceFlagPreservesFlags(t *testing.T) {
+	fakeGcloud(t, `case "$*" in
+*"instances describe"*) echo '{"settings": {"databaseFlags": [{"name": "cloudsql.enable_pgaudit", "value": "on"}, {"name": "max_connections", "value": "100"}]}}' ;;
+*"instances patch"*) `+echoArgsScript+` > "$CLI_TEST_ARGS" ;;
+esac`)
+	argsFile := filepath.Join(t.TempDir(), "args")
+	t.Setenv("CLI_TEST_ARGS", argsFile)
+
+	if err := SetGCPCloudSQLInstanceFlag("p", "nonprod-psql", "log_min_duration_statement", "500"); err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := os.ReadFile(argsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"sql", "instances", "patch", "nonprod-psql", "--project", "p",
+		"--database-flags", "cloudsql.enable_pgaudit=on,max_connections=100,log_min_duration_statement=500", "--quiet",
+	}
+	if got := strings.Split(strings.TrimSpace(string(content)), "\n"); !slices.Equal(got, want) {
+		t.Errorf("args = %q, want %q", got, want)
+	}
+}
+
+func TestSetGCPCloudSQLInstanceFlagDescribeFailure(t *testing.T) {
+	// The instance is never patched without the current flags, which would be dropped
+	fakeGcloud(t, `case "$*" in
+*"instances patch"*) touch "$CLI_TEST_ARGS" ;;
+*) exit 1 ;;
+esac`)
+	argsFile := filepath.Join(t.TempDir(), "args")
+	t.Setenv("CLI_TEST_ARGS", argsFile)
+
+	if err := SetGCPCloudSQLInstanceFlag("p", "nonprod-psql", "max_connections", "200"); err == nil {
+		t.Fatal("expected error when the current flags can't be read")
+	}
+	if _, err := os.Stat(argsFile); !os.IsNotExist(err) {
+		t.Error("instance patched without the current flags")
+	}
+}
+
+func TestParseGCPCloudSQLConnectionName(t *testing.T) {
+	tests := []struct {
+		connectionName string
+		wantProject    string
+		wantRegion     string
+		wantInstance   string
+		wantErr        bool
+	}{
+		{"other-project:us-central1:nonprod-psql", "other-project", "us-central1", "nonprod-psql", false},
+		{"example.com:other-project:us-central1:nonprod-psql", "example.com:other-project", "us-central1", "nonprod-psql", false},
+		{"other-project:nonprod-psql", "", "", "", true},
+		{":us-central1:nonprod-psql", "", "", "", true},
+		{"other-project:us-central1:Invalid_Instance", "", "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.connectionName, func(t *testing.T) {
+			projectID, region, instanceID, err := ParseGCPCloudSQLConnectionName(tt.connectionName)
+			if tt.wantErr {
+				if common.ExitCode(err) != common.ExitCodeValidation {
+					t.Errorf("error = %v, want validation error", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if projectID != tt.wantProject || region != tt.wantRegion || instanceID != tt.wantInstance {
+				t.Errorf("ParseGCPCloudSQLConnectionName() = %s, %s, %s, want %s, %s, %s", projectID, region, instanceID, tt.wantProject, tt.wantRegion, tt.wantInstance)
+			}
+		})
+	}
+}
+
+func TestGetGCPCloudSQLInstanceAddressPrefersPrivate(t *testing.T) {
+	fakeGcloud(t, `echo '{"ipAddresses": [{"ipAddress": "34.1.2.3", "type": "PRIMARY"}, {"ipAddress": "10.0.0.5", "type": "PRIVATE"}]}'`)
+
+	address, err := GetGCPCloudSQLInstanceAddress("other-project", "nonprod-psql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if address != "10.0.0.5" {
+		t.Errorf("address = %s, want private address 10.0.0.5", address)
+	}
+}
+
+func TestSetGCPCloudSQLUserPasswordRedactsPassword(t *testing.T) {
+	password, err := common.GeneratePassword(20, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// gcloud fails echoing its arguments, like some errors of gcloud
+	fakeGcloud(t, `echo "ERROR: invalid request: $*" >&2; exit 1`)
+
+	err = SetGCPCloudSQLUserPassword("p", "nonprod-psql", "app_user", "", password)
+	if err == nil {
+		t.Fatal("expected error of gcloud")
+	}
+	if strings.Contains(err.Error(), password) {
+		t.Errorf("password displayed in error: %v", err)
+	}
+}
+
+func TestCreateGCPCloudSQLInstance(t *testing.T) {
+	fakeGcloud(t, echoArgsScript+` > "$CLI_TEST_ARGS"; echo op-create-1`)
+	argsFile := filepath.Join(t.TempDir(), "args")
+	t.Setenv("CLI_TEST_ARGS", argsFile)
+
+	operationID, err := CreateGCPCloudSQLInstance("p", "nonprod-psql", "POSTGRES_16", "db-custom-2-7680", "us-central1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if operationID != "op-create-1" {
+		t.Errorf("operation = %q, want op-create-1", operationID)
+	}
+
+	content, err := os.ReadFile(argsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"sql", "instances", "create", "nonprod-psql", "--project", "p",
+		"--database-version", "POSTGRES_16", "--tier", "db-custom-2-7680", "--region", "us-central1",
+		"--async", "--format=value(name)",
+	}
+	if got := strings.Split(strings.TrimSpace(string(content)), "\n"); !slices.Equal(got, want) {
+		t.Errorf("args = %q, want %q", got, want)
+	}
+}
+
+func TestCreateGCPCloudSQLInstanceAlreadyExists(t *testing.T) {
+	fakeGcloud(t, `echo "ERROR: (gcloud.sql.instances.create) Resource in projects [p] is the subject of a conflict: The Cloud SQL instance already exists." >&2; exit 1`)
+
+	operationID, err := CreateGCPCloudSQLInstance("p", "nonprod-psql", "POSTGRES_16", "db-custom-2-7680", "us-central1")
+	if err != nil || operationID != "" {
+		t.Errorf("CreateGCPCloudSQLInstance() = %q, %v, want warning without operation", operationID, err)
+	}
+}
+
+func TestCreateGCPCloudSQLInstanceValidation(t *testing.T) {
+	fakeGcloud(t, "exit 1")
+
+	tests := map[string][]string{
+		"without version":     {"nonprod-psql", "", "db-f1-micro", "us-central1"},
+		"without tier":        {"nonprod-psql", "POSTGRES_16", "", "us-central1"},
+		"without region":      {"nonprod-psql", "POSTGRES_16", "db-f1-micro", ""},
+		"invalid instance":    {"Nonprod_PSQL", "POSTGRES_16", "db-f1-micro", "us-central1"},
+		"instance with digit": {"1-psql", "POSTGRES_16", "db-f1-micro", "us-central1"},
+	}
+	for name, args := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := CreateGCPCloudSQLInstance("p", args[0], args[1], args[2], args[3]); common.ExitCode(err) != common.ExitCodeValidation {
+				t.Errorf("error = %v, want validation error", err)
+			}
+		})
+	}
+}
+
+// sampleServerCACertsJSON is the output of 'gcloud sql ssl server-ca-certs list --format=json' with
+// an expired certificate, a certificate expiring soon and a valid one
+const sampleServerCACertsJSON = `[
+  {"commonName": "C=US,O=Google Inc,CN=Google Cloud SQL Server CA", "sha1Fingerprint": "valid", "createTime": "2024-06-01T00:00:00Z", "expirationTime": "2034-06-01T00:00:00Z"},
+  {"commonName": "C=US,O=Google Inc,CN=Google Cloud SQL Server CA", "sha1Fingerprint": "expiring", "createTime": "2015-01-20T00:00:00Z", "expirationTime": "2025-01-20T00:00:00Z"},
+  {"commonName": "C=US,O=Google Inc,CN=Google Cloud SQL Server CA", "sha1Fingerprint": "expired", "createTime": "2014-12-01T00:00:00Z", "expirationTime": "2024-12-01T00:00:00Z"}
+]`
+
+func TestCheckGCPCloudSQLServerCACerts(t *testing.T) {
+	certs, err := ParseGCPCloudSQLServerCACerts(sampleServerCACertsJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	checks := CheckGCPCloudSQLServerCACerts(certs, now, 30)
+
+	// The checks are sorted by expiration time
+	want := []struct {
+		fingerprint string
+		daysLeft    int
+		status      string
+	}{
+		{"expired", -31, CloudSQLCertStatusExpired},
+		{"expiring", 19, CloudSQLCertStatusExpiring},
+		{"valid", 3438, CloudSQLCertStatusOK},
+	}
+	if len(checks) != len(want) {
+		t.Fatalf("checks = %+v, want %d checks", checks, len(want))
+	}
+	for i, w := range want {
+		if checks[i].Cert.SHA1Fingerprint != w.fingerprint || checks[i].DaysLeft != w.daysLeft || checks[i].Status != w.status {
+			t.Errorf("checks[%d] = %s, %d days, %s, want %s, %d days, %s", i,
+				checks[i].Cert.SHA1Fingerprint, checks[i].DaysLeft, checks[i].Status, w.fingerprint, w.daysLeft, w.status)
+		}
+	}
+
+	// The certificate expiring in 19 days is OK with a shorter threshold
+	if checks := CheckGCPCloudSQLServerCACerts(certs, now, 7); checks[1].Status != CloudSQLCertStatusOK {
+		t.Errorf("status with threshold of 7 days = %s, want %s", checks[1].Status, CloudSQLCertStatusOK)
+	}
+}
+
+func TestParseGCPCloudSQLServerCACertsInvalid(t *testing.T) {
+	if certs, err := ParseGCPCloudSQLServerCACerts(""); err != nil || len(certs) != 0 {
+		t.Errorf("ParseGCPCloudSQLServerCACerts(\"\") = %v, %v, want empty list", certs, err)
+	}
+	if _, err := ParseGCPCloudSQLServerCACerts("not json"); err == nil {
+		t.Error("expected error for invalid JSON")
+	}
+}
+
+func TestListGCPCloudSQLServerCACerts(t *testing.T) {
+	fakeGcloud(t, `[ "$*" = "sql ssl server-ca-certs list --instance nonprod-psql --project my-project --format=json" ] || exit 1
+echo '`+sampleServerCACertsJSON+`'`)
+
+	certs, err := ListGCPCloudSQLServerCACerts("my-project", "nonprod-psql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(certs) != 3 || certs[0].SHA1Fingerprint != "valid" {
+		t.Errorf("certs = %+v", certs)
+	}
+
+	if _, err := ListGCPCloudSQLServerCACerts("my-project", ""); common.ExitCode(err) != common.ExitCodeValidation {
+		t.Errorf("error = %v, want validation error", err)
+	}
+}
+
+func TestCreateGCPCloudSQLUserAndDatabase(t *testing.T) {
+	fakeGcloud(t, `case "$*" in
+*" app-user "*|*" app-db "*) exit 0 ;;
+*" existing-user "*|*" existing-db "*) echo "ERROR: (gcloud.sql.users.create) Resource already exists." >&2; exit 1 ;;
+*) echo "ERROR: (gcloud.sql) The instance does not exist." >&2; exit 1 ;;
+esac`)
+
+	if err := CreateGCPCloudSQLUser("p", "nonprod-psql", "app-user", "secret", ""); err != nil {
+		t.Errorf("CreateGCPCloudSQLUser() = %v", err)
+	}
+	if err := CreateGCPCloudSQLDatabase("p", "nonprod-psql", "app-db", "", ""); err != nil {
+		t.Errorf("CreateGCPCloudSQLDatabase() = %v", err)
+	}
+
+	// The existing user and database are only warnings
+	if err := CreateGCPCloudSQLUser("p", "nonprod-psql", "existing-user", "secret", ""); err != nil {
+		t.Errorf("CreateGCPCloudSQLUser(existing) = %v, want warning", err)
+	}
+	if err := CreateGCPCloudSQLDatabase("p", "nonprod-psql", "existing-db", "", ""); err != nil {
+		t.Errorf("CreateGCPCloudSQLDatabase(existing) = %v, want warning", err)
+	}
+
+	// The failures of gcloud are returned with the exit code of external commands
+	if err := CreateGCPCloudSQLUser("p", "missing-psql", "other-user", "secret", ""); common.ExitCode(err) != common.ExitCodeExternalCommand || !strings.Contains(err.Error(), "does not exist") {
+		t.Errorf("CreateGCPCloudSQLUser(failure) = %v, want external command error with stderr", err)
+	}
+	if err := CreateGCPCloudSQLDatabase("p", "missing-psql", "other-db", "", ""); common.ExitCode(err) != common.ExitCodeExternalCommand {
+		t.Errorf("CreateGCPCloudSQLDatabase(failure) = %v, want external command error", err)
+	}
+
+	if err := CreateGCPCloudSQLUser("p", "nonprod-psql", "app-user", "", ""); common.ExitCode(err) != common.ExitCodeValidation {
+		t.Errorf("CreateGCPCloudSQLUser(no password) = %v, want validation error", err)
+	}
+	if err := CreateGCPCloudSQLDatabase("p", "", "app-db", "", ""); common.ExitCode(err) != common.ExitCodeValidation {
+		t.Errorf("CreateGCPCloudSQLDatabase(no instance) = %v, want validation error", err)
+	}
+}
@@ -2,21 +2,99 @@
 package gcp
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
-	"time"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/aeciopires/pires-cli/internal/config"
 	"github.com/aeciopires/pires-cli/pkg/pireslib/common"
 )
 
+// firewallRulesCSVColumns maps the columns supported by ExportGCPFirewallRulesToCSV to their gcloud format projections.
+var firewallRulesCSVColumns = map[string]string{
+	"name":                  "name",
+	"network":               "network",
+	"direction":             "direction",
+	"priority":              "priority",
+	"sourceRanges":          "sourceRanges.list():label=SOURCE_RANGES",
+	"destinationRanges":     "destinationRanges.list():label=DESTINATION_RANGES",
+	"allowed":               "allowed.list():label=ALLOWED",
+	"denied":                "denied.list():label=DENIED",
+	"sourceTags":            "sourceTags.list():label=SOURCE_TAGS",
+	"targetTags":            "targetTags.list():label=TARGET_TAGS",
+	"sourceServiceAccounts": "sourceServiceAccounts.list():label=SOURCE_SERVICE_ACCOUNTS",
+	"targetServiceAccounts": "targetServiceAccounts.list():label=TARGET_SERVICE_ACCOUNTS",
+	"disabled":              "disabled",
+	"logConfig":             "logConfig.enable:label=LOG_CONFIG",
+	"description":           "description",
+	"creationTimestamp":     "creationTimestamp",
+}
+
+// SupportedGCPFirewallRulesColumns returns the sorted names of columns supported by ExportGCPFirewallRulesToCSV.
+func SupportedGCPFirewallRulesColumns() []string {
+	columns := make([]string, 0, len(firewallRulesCSVColumns))
+	for column := range firewallRulesCSVColumns {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+	return columns
+}
+
+// BuildGCPFirewallRulesCSVFormat builds the --format=csv(...) argument of gcloud for the columns, in the same order.
+// Unknown and duplicated columns are rejected with a validation error.
+func BuildGCPFirewallRulesCSVFormat(columns []string) (string, error) {
+	if len(columns) == 0 {
+		return "", common.NewValidationError("at least one column is required. Supported columns: %s", strings.Join(SupportedGCPFirewallRulesColumns(), ", "))
+	}
+
+	projections := make([]string, 0, len(columns))
+	seenColumns := map[string]bool{}
+	for _, column := range columns {
+		column = strings.TrimSpace(column)
+		projection, exists := firewallRulesCSVColumns[column]
+		if !exists {
+			return "", common.NewValidationError("unknown column '%s'. Supported columns: %s", column, strings.Join(SupportedGCPFirewallRulesColumns(), ", "))
+		}
+		if seenColumns[column] {
+			return "", common.NewValidationError("duplicated column '%s'", column)
+		}
+		seenColumns[column] = true
+		projections = append(projections, projection)
+	}
+	return "--format=csv(" + strings.Join(projections, ",") + ")", nil
+}
+
 // ExportGCPFirewallRulesToCSV exports all firewall rules from a given GCP project to a CSV file.
-// The filename includes the project ID and a timestamp.
+// The filename includes the project ID and a timestamp, according to filenameTemplate
+// (config.GCPFirewallRulesFilenameTemplate if empty). See common.BuildReportFilename function.
 // The file can be saved to a custom directory.
-func ExportGCPFirewallRulesToCSV(projectID, outputDir string) error {
+// If appendTo is not empty, the rules are appended to that file instead (relative to outputDir),
+// without the CSV header if the file is not empty.
+// The columns of CSV are defined by columns (config.GCPFirewallRulesColumns if empty). See BuildGCPFirewallRulesCSVFormat function.
+func ExportGCPFirewallRulesToCSV(projectID, outputDir, filenameTemplate, appendTo string, columns []string) error {
+	return exportGCPFirewallRulesToCSV(projectID, outputDir, filenameTemplate, appendTo, columns, false)
+}
+
+// exportGCPFirewallRulesToCSV exports the firewall rules like ExportGCPFirewallRulesToCSV.
+// If projectColumn is true, the project ID is added as the first column of CSV, so the rules of many projects
+// appended to the same file can be distinguished.
+func exportGCPFirewallRulesToCSV(projectID, outputDir, filenameTemplate, appendTo string, columns []string, projectColumn bool) error {
 	common.Logger("debug", "====> Exporting firewall rules for GCP project: %s", projectID)
 
+	if len(columns) == 0 {
+		columns = config.GCPFirewallRulesColumns
+	}
+	csvFormat, errFormat := BuildGCPFirewallRulesCSVFormat(columns)
+	if errFormat != nil {
+		return errFormat
+	}
+
 	// Define arguments for the gcloud command
 	args := []string{
 		"compute",
@@ -24,36 +102,55 @@ func ExportGCPFirewallRulesToCSV(projectID, outputDir string) error {
 		"list",
 		"--project",
 		projectID,
-		"--format=csv(name,network,direction,priority,sourceRanges.list():label=SOURCE_RANGES,destinationRanges.list():label=DESTINATION_RANGES,allowed.list():label=ALLOWED,denied.list():label=DENIED,sourceTags.list():label=SOURCE_TAGS,targetTags.list():label=TARGET_TAGS,disabled)",
+		csvFormat,
 	}
 
 	// Run the gcloud command
-	stdout, stderr, err := RunGcloudCommand(args...)
+	stdout, stderr, err := RunGcloudPrimaryCommand(args...)
 	if err != nil {
-		common.Logger("fatal", "Failed to export firewall rules for project '%s'... Stdout: %s, Stderr: %s", projectID, stdout, stderr)
+		return fmt.Errorf("failed to export firewall rules for project '%s': %w. Stdout: %s, Stderr: %s", projectID, err, stdout, stderr)
 	}
 
 	if stdout == "" {
 		common.Logger("warning", "gcloud command returned no firewall rules for project '%s'. The output file will be empty.", projectID)
 	}
-
-	// Create the output directory if it doesn't exist
-	if outputDir != "" {
-		if errMkdir := os.MkdirAll(outputDir, config.PermissionDir); errMkdir != nil {
-			common.Logger("fatal", "Failed to create custom output directory '%s': %s", outputDir, errMkdir)
-		}
+	if projectColumn {
+		stdout = AddGCPFirewallRulesProjectColumn(stdout, projectID)
 	}
 
 	// Generate the filename with timestamp
-	timestamp := time.Now().Format("20060102-150405")
-	fileName := fmt.Sprintf("%s-%s-%s.csv", config.GCPFirewallRulesPrefix, projectID, timestamp)
+	if filenameTemplate == "" {
+		filenameTemplate = config.GCPFirewallRulesFilenameTemplate
+	}
+	fileName := common.BuildReportFilename(filenameTemplate, common.ReportFilenameVars(projectID, ""))
 	// If outputDir is "", it joins to the current dir
 	filePath := filepath.Join(outputDir, fileName)
+	if appendTo != "" {
+		filePath = common.ResolveAppendPath(outputDir, appendTo)
+	}
+
+	// Create the output directory if it doesn't exist. The filename template can contain directories too.
+	if errMkdir := os.MkdirAll(filepath.Dir(filePath), config.PermissionDir); errMkdir != nil {
+		return fmt.Errorf("failed to create custom output directory '%s': %w", filepath.Dir(filePath), errMkdir)
+	}
 
 	// Write the CSV output to the file
-	errWrite := os.WriteFile(filePath, []byte(stdout), config.PermissionFile)
+	var errWrite error
+	if appendTo != "" {
+		// The header is written only once, in the beginning of file
+		if fileInfo, errStat := os.Stat(filePath); errStat == nil && fileInfo.Size() > 0 {
+			if _, rows, found := strings.Cut(stdout, "\n"); found {
+				stdout = rows
+			} else {
+				stdout = ""
+			}
+		}
+		errWrite = common.AppendToFile(filePath, []byte(stdout), config.PermissionFile)
+	} else {
+		errWrite = common.WriteFileAtomic(filePath, []byte(stdout), config.PermissionFile)
+	}
 	if errWrite != nil {
-		common.Logger("fatal", "Failed to write firewall rules to file '%s': %s", filePath, errWrite)
+		return fmt.Errorf("failed to write firewall rules to file '%s': %w", filePath, errWrite)
 	}
 
 	common.Logger("info", "Successfully exported firewall rules for project '%s' to: %s", projectID, filePath)
@@ -61,3 +158,466 @@ func ExportGCPFirewallRulesToCSV(projectID, outputDir string) error {
 	// Return nil if everything went well
 	return nil
 }
+
+// AddGCPFirewallRulesProjectColumn adds the 'project' column, with the projectID value, to the beginning of
+// each line of CSV output of gcloud (header included).
+func AddGCPFirewallRulesProjectColumn(csvOutput, projectID string) string {
+	if csvOutput == "" {
+		return ""
+	}
+
+	lines := strings.Split(strings.TrimSuffix(csvOutput, "\n"), "\n")
+	lines[0] = "project," + lines[0]
+	for i := 1; i < len(lines); i++ {
+		lines[i] = projectID + "," + lines[i]
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// ExportGCPFirewallRulesForProjects exports the firewall rules of each project like ExportGCPFirewallRulesToCSV,
+// one report per project. The projects are exported in parallel (see config.MaxConcurrency), except when
+// appendTo is not empty, because all projects are appended to the same file, with a 'project' column
+// (see AddGCPFirewallRulesProjectColumn function). A failure in a project doesn't abort the others:
+// each failure is logged and the failed projects are listed in the returned error.
+// With more than one project, the filenameTemplate must contain {project}, otherwise the reports would overwrite each other.
+func ExportGCPFirewallRulesForProjects(projectIDs []string, outputDir, filenameTemplate, appendTo string, columns []string) error {
+	if len(projectIDs) == 0 {
+		return common.NewValidationError("at least one project is required")
+	}
+	multipleProjects := len(projectIDs) > 1
+	if multipleProjects && appendTo == "" && filenameTemplate != "" && !strings.Contains(filenameTemplate, "{project}") {
+		return common.NewValidationError("the filename template '%s' must contain {project} to export more than one project, otherwise the reports would overwrite each other", filenameTemplate)
+	}
+
+	limit := config.MaxConcurrency
+	if appendTo != "" {
+		limit = 1
+	}
+	errs := make([]error, len(projectIDs))
+	common.ForEachConcurrentlyWithLimit(len(projectIDs), limit, func(i int) {
+		errs[i] = exportGCPFirewallRulesToCSV(projectIDs[i], outputDir, filenameTemplate, appendTo, columns, appendTo != "" && multipleProjects)
+	})
+
+	var failed []string
+	for i, errExport := range errs {
+		if errExport != nil {
+			common.Logger("warning", "  - FAILED: %s: %v", projectIDs[i], errExport)
+			failed = append(failed, projectIDs[i])
+		}
+	}
+	common.Logger("info", "Firewall rules exported for %d of %d project(s).", len(projectIDs)-len(failed), len(projectIDs))
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to export firewall rules for %d of %d project(s): %s", len(failed), len(projectIDs), strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// LoadGCPProjectsFile reads a file with one project ID per line.
+// Blank lines and comments (starting with '#') are skipped.
+func LoadGCPProjectsFile(filePath string) ([]string, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, common.NewValidationError("could not read projects file '%s': %w", filePath, err)
+	}
+
+	var projectIDs []string
+	for _, line := range strings.Split(string(data), "\n") {
+		projectID := strings.TrimSpace(line)
+		if projectID == "" || strings.HasPrefix(projectID, "#") {
+			continue
+		}
+		projectIDs = append(projectIDs, projectID)
+	}
+	return projectIDs, nil
+}
+
+// FirewallRuleProtocol represents the protocol and ports allowed or denied by a firewall rule.
+type FirewallRuleProtocol struct {
+	IPProtocol string   `json:"IPProtocol"`
+	Ports      []string `json:"ports,omitempty"`
+}
+
+// FirewallRule represents the fields used by CLI of a firewall rule returned by
+// 'gcloud compute firewall-rules list --format=json' command.
+type FirewallRule struct {
+	Name                  string                 `json:"name"`
+	Network               string                 `json:"network"`
+	Direction             string                 `json:"direction"`
+	Priority              int                    `json:"priority"`
+	SourceRanges          []string               `json:"sourceRanges,omitempty"`
+	DestinationRanges     []string               `json:"destinationRanges,omitempty"`
+	SourceTags            []string               `json:"sourceTags,omitempty"`
+	TargetTags            []string               `json:"targetTags,omitempty"`
+	TargetServiceAccounts []string               `json:"targetServiceAccounts,omitempty"`
+	Allowed               []FirewallRuleProtocol `json:"allowed,omitempty"`
+	Denied                []FirewallRuleProtocol `json:"denied,omitempty"`
+	Disabled              bool                   `json:"disabled"`
+}
+
+// Action returns ALLOW or DENY, according to the protocols of rule.
+func (r FirewallRule) Action() string {
+	if len(r.Denied) > 0 {
+		return "DENY"
+	}
+	return "ALLOW"
+}
+
+// ProtocolsString returns the protocols and ports of rule, like: tcp:80,443;icmp
+func (r FirewallRule) ProtocolsString() string {
+	protocols := r.Allowed
+	if len(r.Denied) > 0 {
+		protocols = r.Denied
+	}
+
+	items := make([]string, 0, len(protocols))
+	for _, protocol := range protocols {
+		if len(protocol.Ports) == 0 {
+			items = append(items, protocol.IPProtocol)
+			continue
+		}
+		items = append(items, protocol.IPProtocol+":"+strings.Join(protocol.Ports, ","))
+	}
+	return strings.Join(items, ";")
+}
+
+// ListGCPFirewallRules lists the firewall rules of a project.
+func ListGCPFirewallRules(projectID string) ([]FirewallRule, error) {
+	return ListGCPFirewallRulesWithFilter(projectID, "")
+}
+
+// ListGCPFirewallRulesWithFilter lists the firewall rules of a project matching the gcloud filter expression
+// (e.g. "name~^incident-" or "network=default"). All rules are listed if filter is empty.
+func ListGCPFirewallRulesWithFilter(projectID, filter string) ([]FirewallRule, error) {
+	if projectID == "" {
+		return nil, common.NewValidationError("projectID is required to list firewall rules in ListGCPFirewallRulesWithFilter function")
+	}
+
+	args := []string{
+		"compute", "firewall-rules", "list",
+		"--project", projectID,
+		"--format=json",
+	}
+	if filter != "" {
+		args = append(args, "--filter", filter)
+	}
+	stdout, _, err := RunGcloudCommand(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseGCPFirewallRules(stdout)
+}
+
+// ParseGCPFirewallRules parses the JSON output of 'gcloud compute firewall-rules list --format=json'.
+func ParseGCPFirewallRules(jsonOutput string) ([]FirewallRule, error) {
+	rules := []FirewallRule{}
+	if strings.TrimSpace(jsonOutput) == "" {
+		return rules, nil
+	}
+	if err := json.Unmarshal([]byte(jsonOutput), &rules); err != nil {
+		return nil, fmt.Errorf("failed to parse firewall rules: %w", err)
+	}
+	return rules, nil
+}
+
+// FilterGCPFirewallRulesForTag returns the rules applied to VMs with the network tag: the rules whose target tags
+// include the tag and the rules without targets (applied to all instances of network).
+// The rules are sorted by effective order: priority (lower first), DENY before ALLOW in the same priority, and name.
+func FilterGCPFirewallRulesForTag(rules []FirewallRule, tag string) []FirewallRule {
+	filtered := []FirewallRule{}
+	for _, rule := range rules {
+		appliesToAll := len(rule.TargetTags) == 0 && len(rule.TargetServiceAccounts) == 0
+		if appliesToAll || slices.Contains(rule.TargetTags, tag) {
+			filtered = append(filtered, rule)
+		}
+	}
+
+	sort.SliceStable(filtered, func(i, j int) bool {
+		if filtered[i].Priority != filtered[j].Priority {
+			return filtered[i].Priority < filtered[j].Priority
+		}
+		// In the same priority, deny rules take precedence over allow rules
+		if filtered[i].Action() != filtered[j].Action() {
+			return filtered[i].Action() == "DENY"
+		}
+		return filtered[i].Name < filtered[j].Name
+	})
+	return filtered
+}
+
+// BuildGCPFirewallRuleDeleteArgs returns the arguments of gcloud to delete a firewall rule without prompt.
+func BuildGCPFirewallRuleDeleteArgs(projectID, ruleName string) []string {
+	return []string{
+		"compute", "firewall-rules", "delete", ruleName,
+		"--project", projectID,
+		"--quiet",
+	}
+}
+
+// DeleteGCPFirewallRules deletes each firewall rule of a project, aggregating the deleted rules and the failures.
+// A failure in a rule doesn't abort the others.
+// If dryRun is true, the rules are only logged and nothing is deleted.
+// The error is returned only if the CLI is interrupted, stopping the deletion of the remaining rules.
+func DeleteGCPFirewallRules(projectID string, rules []FirewallRule, dryRun bool) ([]string, map[string]error, error) {
+	deleted := []string{}
+	failed := map[string]error{}
+
+	for _, rule := range rules {
+		if dryRun {
+			common.Logger("info", "[DRY-RUN] Would delete firewall rule '%s' on project '%s'", rule.Name, projectID)
+			deleted = append(deleted, rule.Name)
+			continue
+		}
+
+		common.Logger("info", "Deleting firewall rule '%s' on project '%s'...", rule.Name, projectID)
+		if _, _, err := RunGcloudPrimaryCommand(BuildGCPFirewallRuleDeleteArgs(projectID, rule.Name)...); err != nil {
+			if common.ExitCode(err) == common.ExitCodeInterrupted {
+				return deleted, failed, err
+			}
+			common.Logger("error", "Failed to delete firewall rule '%s': %v", rule.Name, err)
+			failed[rule.Name] = err
+			continue
+		}
+		deleted = append(deleted, rule.Name)
+	}
+
+	return deleted, failed, nil
+}
+
+// FirewallRuleChange is a rule of the desired state that differs from the rule with the same name in the project.
+// Changes has the names of the different fields (e.g. sourceRanges, priority).
+type FirewallRuleChange struct {
+	Rule    FirewallRule
+	Changes []string
+}
+
+// FirewallSyncPlan is the result of PlanGCPFirewallSync function.
+type FirewallSyncPlan struct {
+	Create    []FirewallRule       // Rules missing in the project
+	Update    []FirewallRuleChange // Rules updatable by 'gcloud compute firewall-rules update'
+	Replace   []FirewallRuleChange // Rules with network, direction or action changed, which gcloud can't update
+	Unchanged []string             // Names of rules equal in the desired state and the project
+}
+
+// firewallRuleImmutableFields are the fields not supported by 'gcloud compute firewall-rules update'.
+var firewallRuleImmutableFields = []string{"network", "direction", "action"}
+
+// LoadGCPFirewallRulesFile reads the firewall rules of a JSON file, in the format of
+// 'gcloud compute firewall-rules list --format=json' command. See ParseGCPFirewallRules function.
+func LoadGCPFirewallRulesFile(filePath string) ([]FirewallRule, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, common.NewValidationError("could not read firewall rules file '%s': %w", filePath, err)
+	}
+	rules, err := ParseGCPFirewallRules(string(data))
+	if err != nil {
+		return nil, common.NewValidationError("invalid firewall rules file '%s': %w", filePath, err)
+	}
+	for i, rule := range rules {
+		if rule.Name == "" {
+			return nil, common.NewValidationError("the rule %d of firewall rules file '%s' has no name", i+1, filePath)
+		}
+	}
+	return rules, nil
+}
+
+// PlanGCPFirewallSync compares the desired rules with the current rules of the project, by name, and returns
+// the rules to create, update and replace, and the unchanged ones. The current rules missing in the desired state
+// are ignored (they are never deleted). The order of list fields (e.g. sourceRanges) doesn't matter and
+// the network is compared by name, so a network URL matches its name.
+func PlanGCPFirewallSync(desired, current []FirewallRule) FirewallSyncPlan {
+	currentByName := map[string]FirewallRule{}
+	for _, rule := range current {
+		currentByName[rule.Name] = rule
+	}
+
+	plan := FirewallSyncPlan{}
+	for _, rule := range desired {
+		currentRule, exists := currentByName[rule.Name]
+		if !exists {
+			plan.Create = append(plan.Create, rule)
+			continue
+		}
+
+		changes := DiffGCPFirewallRules(currentRule, rule)
+		switch {
+		case len(changes) == 0:
+			plan.Unchanged = append(plan.Unchanged, rule.Name)
+		case slices.ContainsFunc(changes, func(field string) bool { return slices.Contains(firewallRuleImmutableFields, field) }):
+			plan.Replace = append(plan.Replace, FirewallRuleChange{Rule: rule, Changes: changes})
+		default:
+			plan.Update = append(plan.Update, FirewallRuleChange{Rule: rule, Changes: changes})
+		}
+	}
+	return plan
+}
+
+// DiffGCPFirewallRules returns the names of fields different in the rules a and b.
+func DiffGCPFirewallRules(a, b FirewallRule) []string {
+	var changes []string
+	addIfDifferent := func(field string, different bool) {
+		if different {
+			changes = append(changes, field)
+		}
+	}
+	addIfDifferent("network", firewallRuleNetwork(a) != firewallRuleNetwork(b))
+	addIfDifferent("direction", firewallRuleDirection(a) != firewallRuleDirection(b))
+	addIfDifferent("action", a.Action() != b.Action())
+	addIfDifferent("priority", a.Priority != b.Priority)
+	addIfDifferent("rules", !slices.Equal(firewallRuleGcloudRules(a), firewallRuleGcloudRules(b)))
+	addIfDifferent("sourceRanges", !equalUnordered(a.SourceRanges, b.SourceRanges))
+	addIfDifferent("destinationRanges", !equalUnordered(a.DestinationRanges, b.DestinationRanges))
+	addIfDifferent("sourceTags", !equalUnordered(a.SourceTags, b.SourceTags))
+	addIfDifferent("targetTags", !equalUnordered(a.TargetTags, b.TargetTags))
+	addIfDifferent("targetServiceAccounts", !equalUnordered(a.TargetServiceAccounts, b.TargetServiceAccounts))
+	addIfDifferent("disabled", a.Disabled != b.Disabled)
+	return changes
+}
+
+// firewallRuleNetwork returns the name of network of rule, also when it's an URL
+// (e.g. https://www.googleapis.com/compute/v1/projects/nonprod/global/networks/default). The default network of GCP is default.
+func firewallRuleNetwork(rule FirewallRule) string {
+	if rule.Network == "" {
+		return "default"
+	}
+	return path.Base(rule.Network)
+}
+
+// firewallRuleDirection returns the direction of rule. The default direction of GCP is INGRESS.
+func firewallRuleDirection(rule FirewallRule) string {
+	if rule.Direction == "" {
+		return "INGRESS"
+	}
+	return strings.ToUpper(rule.Direction)
+}
+
+// firewallRuleGcloudRules returns the sorted protocols and ports of rule in the format of --rules option of gcloud,
+// like: [icmp tcp:22 tcp:80].
+func firewallRuleGcloudRules(rule FirewallRule) []string {
+	protocols := rule.Allowed
+	if len(rule.Denied) > 0 {
+		protocols = rule.Denied
+	}
+
+	var items []string
+	for _, protocol := range protocols {
+		if len(protocol.Ports) == 0 {
+			items = append(items, protocol.IPProtocol)
+			continue
+		}
+		for _, port := range protocol.Ports {
+			items = append(items, protocol.IPProtocol+":"+port)
+		}
+	}
+	slices.Sort(items)
+	return items
+}
+
+// equalUnordered returns true if the lists have the same items, in any order.
+func equalUnordered(a, b []string) bool {
+	sortedA, sortedB := slices.Clone(a), slices.Clone(b)
+	slices.Sort(sortedA)
+	slices.Sort(sortedB)
+	return slices.Equal(sortedA, sortedB)
+}
+
+// BuildGCPFirewallRuleCreateArgs returns the arguments of gcloud to create a firewall rule.
+func BuildGCPFirewallRuleCreateArgs(projectID string, rule FirewallRule) []string {
+	args := []string{
+		"compute", "firewall-rules", "create", rule.Name,
+		"--project", projectID,
+		"--direction", firewallRuleDirection(rule),
+		"--action", rule.Action(),
+		"--priority", strconv.Itoa(rule.Priority),
+		"--network", firewallRuleNetwork(rule),
+	}
+	listOptions := []struct {
+		option string
+		values []string
+	}{
+		{"--rules", firewallRuleGcloudRules(rule)},
+		{"--source-ranges", rule.SourceRanges},
+		{"--destination-ranges", rule.DestinationRanges},
+		{"--source-tags", rule.SourceTags},
+		{"--target-tags", rule.TargetTags},
+		{"--target-service-accounts", rule.TargetServiceAccounts},
+	}
+	for _, listOption := range listOptions {
+		if len(listOption.values) > 0 {
+			args = append(args, listOption.option+"="+strings.Join(listOption.values, ","))
+		}
+	}
+	if rule.Disabled {
+		args = append(args, "--disabled")
+	}
+	return args
+}
+
+// BuildGCPFirewallRuleUpdateArgs returns the arguments of gcloud to update the changed fields of a firewall rule.
+// The emptied lists are cleared with an empty value (e.g. --target-tags=).
+func BuildGCPFirewallRuleUpdateArgs(projectID string, change FirewallRuleChange) []string {
+	rule := change.Rule
+	args := []string{
+		"compute", "firewall-rules", "update", rule.Name,
+		"--project", projectID,
+	}
+	for _, field := range change.Changes {
+		switch field {
+		case "priority":
+			args = append(args, "--priority", strconv.Itoa(rule.Priority))
+		case "rules":
+			args = append(args, "--rules="+strings.Join(firewallRuleGcloudRules(rule), ","))
+		case "sourceRanges":
+			args = append(args, "--source-ranges="+strings.Join(rule.SourceRanges, ","))
+		case "destinationRanges":
+			args = append(args, "--destination-ranges="+strings.Join(rule.DestinationRanges, ","))
+		case "sourceTags":
+			args = append(args, "--source-tags="+strings.Join(rule.SourceTags, ","))
+		case "targetTags":
+			args = append(args, "--target-tags="+strings.Join(rule.TargetTags, ","))
+		case "targetServiceAccounts":
+			args = append(args, "--target-service-accounts="+strings.Join(rule.TargetServiceAccounts, ","))
+		case "disabled":
+			if rule.Disabled {
+				args = append(args, "--disabled")
+			} else {
+				args = append(args, "--no-disabled")
+			}
+		}
+	}
+	return args
+}
+
+// ApplyGCPFirewallSync creates and updates the rules of plan in the project, aggregating the applied rules
+// and the failures. A failure in a rule doesn't abort the others. The rules to replace are not touched.
+// The error is returned only if the CLI is interrupted, stopping the sync of the remaining rules.
+func ApplyGCPFirewallSync(projectID string, plan FirewallSyncPlan) ([]string, map[string]error, error) {
+	applied := []string{}
+	failed := map[string]error{}
+
+	run := func(ruleName, operation string, args []string) error {
+		common.Logger("info", "%s firewall rule '%s' on project '%s'...", operation, ruleName, projectID)
+		if _, _, err := RunGcloudPrimaryCommand(args...); err != nil {
+			if common.ExitCode(err) == common.ExitCodeInterrupted {
+				return err
+			}
+			common.Logger("error", "Failed to sync firewall rule '%s': %v", ruleName, err)
+			failed[ruleName] = err
+			return nil
+		}
+		applied = append(applied, ruleName)
+		return nil
+	}
+
+	for _, rule := range plan.Create {
+		if err := run(rule.Name, "Creating", BuildGCPFirewallRuleCreateArgs(projectID, rule)); err != nil {
+			return applied, failed, err
+		}
+	}
+	for _, change := range plan.Update {
+		if err := run(change.Rule.Name, "Updating", BuildGCPFirewallRuleUpdateArgs(projectID, change)); err != nil {
+			return applied, failed, err
+		}
+	}
+	return applied, failed, nil
+}
@@ -0,0 +1,467 @@
+package gcp
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/aeciopires/pires-cli/internal/config"
+	"github.com/aeciopires/pires-cli/pkg/pireslib/common"
+)
+
+// fakeFirewallGcloudScript lists the firewall rules of projects as CSV. The project 'broken' fails.
+const fakeFirewallGcloudScript = `
+project=""
+while [ $# -gt 0 ]; do
+  if [ "$1" = "--project" ]; then project="$2"; fi
+  shift
+done
+if [ "$project" = "broken" ]; then
+  echo "ERROR: project not found" >&2
+  exit 1
+fi
+printf 'name,network\nallow-ssh-%s,default\nallow-http-%s,default\n' "$project" "$project"`
+
+func TestExportGCPFirewallRulesForProjects(t *testing.T) {
+	fakeGcloud(t, fakeFirewallGcloudScript)
+	outputDir := t.TempDir()
+
+	err := ExportGCPFirewallRulesForProjects([]string{"nonprod", "prod"}, outputDir, "{project}.csv", "", []string{"name", "network"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, projectID := range []string{"nonprod", "prod"} {
+		content, errRead := os.ReadFile(filepath.Join(outputDir, projectID+".csv"))
+		if errRead != nil {
+			t.Fatal(errRead)
+		}
+		if !strings.Contains(string(content), "allow-ssh-"+projectID) {
+			t.Errorf("report of %s = %q, want its rules", projectID, content)
+		}
+	}
+}
+
+func TestExportGCPFirewallRulesForProjectsAggregatesErrors(t *testing.T) {
+	fakeGcloud(t, fakeFirewallGcloudScript)
+	outputDir := t.TempDir()
+
+	err := ExportGCPFirewallRulesForProjects([]string{"broken", "prod"}, outputDir, "{project}.csv", "", nil)
+	if err == nil || !strings.Contains(err.Error(), "1 of 2 project(s): broken") {
+		t.Fatalf("error = %v, want failure of project 'broken'", err)
+	}
+	// The failure doesn't abort the other projects
+	if _, errStat := os.Stat(filepath.Join(outputDir, "prod.csv")); errStat != nil {
+		t.Errorf("report of prod wasn't exported: %v", errStat)
+	}
+}
+
+func TestExportGCPFirewallRulesForProjectsAppendTo(t *testing.T) {
+	fakeGcloud(t, fakeFirewallGcloudScript)
+	outputDir := t.TempDir()
+
+	err := ExportGCPFirewallRulesForProjects([]string{"nonprod", "prod"}, outputDir, "", "all.csv", []string{"name", "network"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := os.ReadFile(filepath.Join(outputDir, "all.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "project,name,network\n" +
+		"nonprod,allow-ssh-nonprod,default\nnonprod,allow-http-nonprod,default\n" +
+		"prod,allow-ssh-prod,default\nprod,allow-http-prod,default\n"
+	if string(content) != want {
+		t.Errorf("content = %q, want %q", content, want)
+	}
+}
+
+func TestExportGCPFirewallRulesForProjectsTemplateWithoutProject(t *testing.T) {
+	err := ExportGCPFirewallRulesForProjects([]string{"nonprod", "prod"}, t.TempDir(), "rules-{timestamp}.csv", "", nil)
+	if err == nil || !strings.Contains(err.Error(), "{project}") {
+		t.Fatalf("error = %v, want validation error of {project}", err)
+	}
+}
+
+func TestAddGCPFirewallRulesProjectColumn(t *testing.T) {
+	tests := map[string]struct {
+		input string
+		want  string
+	}{
+		"empty":          {"", ""},
+		"header only":    {"name\n", "project,name\n"},
+		"rows":           {"name,network\nssh,default\n", "project,name,network\nprod,ssh,default\n"},
+		"without ending": {"name\nssh", "project,name\nprod,ssh\n"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := AddGCPFirewallRulesProjectColumn(tt.input, "prod"); got != tt.want {
+				t.Errorf("AddGCPFirewallRulesProjectColumn(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExportGCPFirewallRulesToCSVAppendsAcrossRuns(t *testing.T) {
+	fakeGcloud(t, fakeFirewallGcloudScript)
+	outputDir := t.TempDir()
+
+	for run := 0; run < 2; run++ {
+		if err := ExportGCPFirewallRulesToCSV("prod", outputDir, "", "daily.csv", []string{"name", "network"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	content, err := os.ReadFile(filepath.Join(outputDir, "daily.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The header is written only by the first run
+	want := "name,network\nallow-ssh-prod,default\nallow-http-prod,default\n" +
+		"allow-ssh-prod,default\nallow-http-prod,default\n"
+	if string(content) != want {
+		t.Errorf("content = %q, want %q", content, want)
+	}
+	// No timestamped file is created
+	if entries, _ := os.ReadDir(outputDir); len(entries) != 1 {
+		t.Errorf("files in output directory = %d, want only daily.csv", len(entries))
+	}
+}
+
+// sampleFirewallRulesJSON is a sample output of 'gcloud compute firewall-rules list --format=json'
+const sampleFirewallRulesJSON = `[
+  {"name": "allow-web", "network": "default", "direction": "INGRESS", "priority": 1000, "targetTags": ["web"], "allowed": [{"IPProtocol": "tcp", "ports": ["80", "443"]}]},
+  {"name": "deny-web-telnet", "network": "default", "direction": "INGRESS", "priority": 1000, "targetTags": ["web", "db"], "denied": [{"IPProtocol": "tcp", "ports": ["23"]}]},
+  {"name": "allow-db", "network": "default", "direction": "INGRESS", "priority": 900, "targetTags": ["db"], "allowed": [{"IPProtocol": "tcp", "ports": ["5432"]}]},
+  {"name": "allow-internal", "network": "default", "direction": "INGRESS", "priority": 65534, "sourceRanges": ["10.0.0.0/8"], "allowed": [{"IPProtocol": "icmp"}]},
+  {"name": "allow-health-checks", "network": "default", "direction": "INGRESS", "priority": 1000, "allowed": [{"IPProtocol": "tcp"}]},
+  {"name": "allow-sa", "network": "default", "direction": "INGRESS", "priority": 100, "targetServiceAccounts": ["app@p.iam.gserviceaccount.com"], "allowed": [{"IPProtocol": "tcp"}]}
+]`
+
+func TestFilterGCPFirewallRulesForTag(t *testing.T) {
+	rules, err := ParseGCPFirewallRules(sampleFirewallRulesJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, rule := range FilterGCPFirewallRulesForTag(rules, "web") {
+		names = append(names, rule.Name)
+	}
+	// In the tie of priority 1000, DENY comes first and then the names in order.
+	// The rules of other tags and of service accounts are not applied.
+	want := []string{"deny-web-telnet", "allow-health-checks", "allow-web", "allow-internal"}
+	if !slices.Equal(names, want) {
+		t.Errorf("rules for tag web = %v, want %v", names, want)
+	}
+}
+
+func TestFirewallRuleActionAndProtocols(t *testing.T) {
+	rules, err := ParseGCPFirewallRules(sampleFirewallRulesJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]struct {
+		action    string
+		protocols string
+	}{
+		"allow-web":       {"ALLOW", "tcp:80,443"},
+		"deny-web-telnet": {"DENY", "tcp:23"},
+		"allow-internal":  {"ALLOW", "icmp"},
+	}
+	for _, rule := range rules {
+		tt, ok := tests[rule.Name]
+		if !ok {
+			continue
+		}
+		if rule.Action() != tt.action || rule.ProtocolsString() != tt.protocols {
+			t.Errorf("%s: action, protocols = %s, %s, want %s, %s", rule.Name, rule.Action(), rule.ProtocolsString(), tt.action, tt.protocols)
+		}
+	}
+}
+
+func TestListGCPFirewallRulesWithFilter(t *testing.T) {
+	fakeGcloud(t, echoArgsScript+` > "$CLI_TEST_ARGS"; echo '[{"name": "incident-1", "priority": 10}]'`)
+	argsFile := filepath.Join(t.TempDir(), "args")
+	t.Setenv("CLI_TEST_ARGS", argsFile)
+
+	rules, err := ListGCPFirewallRulesWithFilter("prod", "name~^incident-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rules) != 1 || rules[0].Name != "incident-1" || rules[0].Priority != 10 {
+		t.Errorf("rules = %+v", rules)
+	}
+
+	content, err := os.ReadFile(argsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"compute", "firewall-rules", "list", "--project", "prod", "--format=json", "--filter", "name~^incident-"}
+	if got := strings.Split(strings.TrimSpace(string(content)), "\n"); !slices.Equal(got, want) {
+		t.Errorf("args = %q, want %q", got, want)
+	}
+}
+
+func TestBuildGCPFirewallRulesCSVFormat(t *testing.T) {
+	tests := []struct {
+		columns []string
+		want    string
+	}{
+		// The default columns match the output before the --columns flag
+		{config.GCPFirewallRulesColumns, "--format=csv(name,network,direction,priority,sourceRanges.list():label=SOURCE_RANGES,destinationRanges.list():label=DESTINATION_RANGES,allowed.list():label=ALLOWED,denied.list():label=DENIED,sourceTags.list():label=SOURCE_TAGS,targetTags.list():label=TARGET_TAGS,disabled)"},
+		{[]string{"name", " logConfig", "priority"}, "--format=csv(name,logConfig.enable:label=LOG_CONFIG,priority)"},
+	}
+	for _, tt := range tests {
+		got, err := BuildGCPFirewallRulesCSVFormat(tt.columns)
+		if err != nil || got != tt.want {
+			t.Errorf("BuildGCPFirewallRulesCSVFormat(%v) = %q, %v, want %q", tt.columns, got, err, tt.want)
+		}
+	}
+}
+
+func TestBuildGCPFirewallRulesCSVFormatInvalid(t *testing.T) {
+	for _, columns := range [][]string{nil, {"name", "owner"}, {"name", "name"}} {
+		_, err := BuildGCPFirewallRulesCSVFormat(columns)
+		if common.ExitCode(err) != common.ExitCodeValidation {
+			t.Errorf("BuildGCPFirewallRulesCSVFormat(%v) error = %v, want validation error", columns, err)
+		}
+	}
+
+	// The unknown column is rejected with the supported columns
+	_, err := BuildGCPFirewallRulesCSVFormat([]string{"owner"})
+	if err == nil || !strings.Contains(err.Error(), "logConfig") {
+		t.Errorf("error = %v, want the supported columns", err)
+	}
+}
+
+func TestExportGCPFirewallRulesToCSVColumns(t *testing.T) {
+	fakeGcloud(t, echoArgsScript)
+	outputDir := t.TempDir()
+
+	if err := ExportGCPFirewallRulesToCSV("nonprod", outputDir, "rules.csv", "", []string{"name", "logConfig"}); err != nil {
+		t.Fatal(err)
+	}
+	content, err := os.ReadFile(filepath.Join(outputDir, "rules.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(content), "--format=csv(name,logConfig.enable:label=LOG_CONFIG)\n") {
+		t.Errorf("gcloud arguments = %q, want the format of columns", content)
+	}
+}
+
+func TestDeleteGCPFirewallRules(t *testing.T) {
+	// Each call is logged in $CLI_TEST_CALLS and the rule 'incident-2' fails
+	callsFile := filepath.Join(t.TempDir(), "calls")
+	t.Setenv("CLI_TEST_CALLS", callsFile)
+	fakeGcloud(t, `echo "$*" >> "$CLI_TEST_CALLS"
+case "$*" in *incident-2*) echo "ERROR: rule is in use" >&2; exit 1 ;; esac`)
+	rules := []FirewallRule{{Name: "incident-1"}, {Name: "incident-2"}, {Name: "incident-3"}}
+
+	deleted, failed, err := DeleteGCPFirewallRules("prod", rules, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The failure doesn't abort the other rules
+	if want := []string{"incident-1", "incident-3"}; !slices.Equal(deleted, want) {
+		t.Errorf("deleted = %v, want %v", deleted, want)
+	}
+	if len(failed) != 1 || failed["incident-2"] == nil {
+		t.Errorf("failed = %v, want only incident-2", failed)
+	}
+
+	content, err := os.ReadFile(callsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "compute firewall-rules delete incident-1 --project prod --quiet\n" +
+		"compute firewall-rules delete incident-2 --project prod --quiet\n" +
+		"compute firewall-rules delete incident-3 --project prod --quiet\n"
+	if string(content) != want {
+		t.Errorf("gcloud calls = %q, want %q", content, want)
+	}
+}
+
+func TestDeleteGCPFirewallRulesDryRun(t *testing.T) {
+	// gcloud must not be executed in dry-run
+	fakeGcloud(t, "exit 1")
+
+	deleted, failed, err := DeleteGCPFirewallRules("prod", []FirewallRule{{Name: "incident-1"}}, true)
+	if err != nil || !slices.Equal(deleted, []string{"incident-1"}) || len(failed) != 0 {
+		t.Errorf("deleted, failed = %v, %v, %v, want only incident-1 deleted", deleted, failed, err)
+	}
+}
+
+// cancelContext cancels the context of CLI during the test, like SIGINT/SIGTERM
+func cancelContext(t *testing.T) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	previous := common.Context()
+	common.SetContext(ctx)
+	t.Cleanup(func() { common.SetContext(previous) })
+}
+
+func TestDeleteGCPFirewallRulesInterrupted(t *testing.T) {
+	callsFile := filepath.Join(t.TempDir(), "calls")
+	t.Setenv("CLI_TEST_CALLS", callsFile)
+	fakeGcloud(t, `echo "$*" >> "$CLI_TEST_CALLS"`)
+	cancelContext(t)
+
+	// The interruption stops the loop and is returned, instead of exiting the process
+	deleted, failed, err := DeleteGCPFirewallRules("prod", []FirewallRule{{Name: "incident-1"}, {Name: "incident-2"}}, false)
+	if common.ExitCode(err) != common.ExitCodeInterrupted {
+		t.Errorf("error = %v, want interrupted error", err)
+	}
+	if len(deleted) != 0 || len(failed) != 0 {
+		t.Errorf("deleted, failed = %v, %v, want no rule after the interruption", deleted, failed)
+	}
+	if content, _ := os.ReadFile(callsFile); strings.Count(string(content), "\n") > 1 {
+		t.Errorf("gcloud calls = %q, want the loop stopped", content)
+	}
+}
+
+// firewallSyncCurrent are the current rules of project in the tests of firewall sync
+var firewallSyncCurrent = []FirewallRule{
+	{Name: "allow-ssh", Network: "https://www.googleapis.com/compute/v1/projects/prod/global/networks/default", Direction: "INGRESS", Priority: 1000,
+		SourceRanges: []string{"10.0.0.0/8", "35.235.240.0/20"}, Allowed: []FirewallRuleProtocol{{IPProtocol: "tcp", Ports: []string{"22"}}}},
+	{Name: "allow-http", Network: "default", Direction: "INGRESS", Priority: 1000,
+		SourceRanges: []string{"0.0.0.0/0"}, TargetTags: []string{"web"}, Allowed: []FirewallRuleProtocol{{IPProtocol: "tcp", Ports: []string{"80"}}}},
+	{Name: "deny-db", Network: "default", Direction: "INGRESS", Priority: 900, Denied: []FirewallRuleProtocol{{IPProtocol: "tcp", Ports: []string{"5432"}}}},
+	{Name: "not-managed", Network: "default", Priority: 1000, Allowed: []FirewallRuleProtocol{{IPProtocol: "icmp"}}},
+}
+
+func TestPlanGCPFirewallSync(t *testing.T) {
+	desired := []FirewallRule{
+		// Same rule, with the network by name and the ranges in other order
+		{Name: "allow-ssh", Network: "default", Priority: 1000,
+			SourceRanges: []string{"35.235.240.0/20", "10.0.0.0/8"}, Allowed: []FirewallRuleProtocol{{IPProtocol: "tcp", Ports: []string{"22"}}}},
+		{Name: "allow-http", Network: "default", Direction: "INGRESS", Priority: 1000,
+			SourceRanges: []string{"0.0.0.0/0"}, Allowed: []FirewallRuleProtocol{{IPProtocol: "tcp", Ports: []string{"80", "443"}}}},
+		// The action can't be updated
+		{Name: "deny-db", Network: "default", Direction: "INGRESS", Priority: 900, Allowed: []FirewallRuleProtocol{{IPProtocol: "tcp", Ports: []string{"5432"}}}},
+		{Name: "allow-https", Network: "default", Priority: 1000, Allowed: []FirewallRuleProtocol{{IPProtocol: "tcp", Ports: []string{"443"}}}},
+	}
+
+	plan := PlanGCPFirewallSync(desired, firewallSyncCurrent)
+
+	if len(plan.Create) != 1 || plan.Create[0].Name != "allow-https" {
+		t.Errorf("create = %+v, want allow-https", plan.Create)
+	}
+	if len(plan.Update) != 1 || plan.Update[0].Rule.Name != "allow-http" || !slices.Equal(plan.Update[0].Changes, []string{"rules", "targetTags"}) {
+		t.Errorf("update = %+v, want rules and targetTags of allow-http", plan.Update)
+	}
+	if len(plan.Replace) != 1 || plan.Replace[0].Rule.Name != "deny-db" || !slices.Equal(plan.Replace[0].Changes, []string{"action"}) {
+		t.Errorf("replace = %+v, want action of deny-db", plan.Replace)
+	}
+	// The rules missing in the desired state are ignored
+	if !slices.Equal(plan.Unchanged, []string{"allow-ssh"}) {
+		t.Errorf("unchanged = %v, want allow-ssh", plan.Unchanged)
+	}
+}
+
+func TestBuildGCPFirewallRuleCreateArgs(t *testing.T) {
+	rule := FirewallRule{Name: "allow-web", Priority: 1000, SourceRanges: []string{"0.0.0.0/0"}, TargetTags: []string{"web", "lb"},
+		Allowed: []FirewallRuleProtocol{{IPProtocol: "tcp", Ports: []string{"443", "80"}}, {IPProtocol: "icmp"}}, Disabled: true}
+
+	want := []string{"compute", "firewall-rules", "create", "allow-web", "--project", "prod", "--direction", "INGRESS", "--action", "ALLOW",
+		"--priority", "1000", "--network", "default", "--rules=icmp,tcp:443,tcp:80", "--source-ranges=0.0.0.0/0", "--target-tags=web,lb", "--disabled"}
+	if got := BuildGCPFirewallRuleCreateArgs("prod", rule); !slices.Equal(got, want) {
+		t.Errorf("BuildGCPFirewallRuleCreateArgs() = %q, want %q", got, want)
+	}
+}
+
+func TestBuildGCPFirewallRuleUpdateArgs(t *testing.T) {
+	change := FirewallRuleChange{
+		Rule:    FirewallRule{Name: "allow-http", Priority: 800, Allowed: []FirewallRuleProtocol{{IPProtocol: "tcp", Ports: []string{"80"}}}},
+		Changes: []string{"priority", "rules", "targetTags", "disabled"},
+	}
+
+	// The emptied target tags are cleared and the rule is enabled
+	want := []string{"compute", "firewall-rules", "update", "allow-http", "--project", "prod", "--priority", "800", "--rules=tcp:80", "--target-tags=", "--no-disabled"}
+	if got := BuildGCPFirewallRuleUpdateArgs("prod", change); !slices.Equal(got, want) {
+		t.Errorf("BuildGCPFirewallRuleUpdateArgs() = %q, want %q", got, want)
+	}
+}
+
+func TestApplyGCPFirewallSync(t *testing.T) {
+	callsFile := filepath.Join(t.TempDir(), "calls")
+	t.Setenv("CLI_TEST_CALLS", callsFile)
+	fakeGcloud(t, `echo "$*" >> "$CLI_TEST_CALLS"
+case "$*" in *broken*) echo "ERROR: invalid rule" >&2; exit 1 ;; esac`)
+	plan := FirewallSyncPlan{
+		Create:  []FirewallRule{{Name: "allow-https", Priority: 1000}, {Name: "broken", Priority: 1000}},
+		Update:  []FirewallRuleChange{{Rule: FirewallRule{Name: "allow-http", Priority: 800}, Changes: []string{"priority"}}},
+		Replace: []FirewallRuleChange{{Rule: FirewallRule{Name: "deny-db"}, Changes: []string{"action"}}},
+	}
+
+	applied, failed, err := ApplyGCPFirewallSync("prod", plan)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"allow-https", "allow-http"}; !slices.Equal(applied, want) {
+		t.Errorf("applied = %v, want %v", applied, want)
+	}
+	if len(failed) != 1 || failed["broken"] == nil {
+		t.Errorf("failed = %v, want only broken", failed)
+	}
+	// The rules to replace are not touched
+	content, err := os.ReadFile(callsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(content), "deny-db") || strings.Count(string(content), "\n") != 3 {
+		t.Errorf("gcloud calls = %q, want only create and update", content)
+	}
+}
+
+func TestApplyGCPFirewallSyncInterrupted(t *testing.T) {
+	callsFile := filepath.Join(t.TempDir(), "calls")
+	t.Setenv("CLI_TEST_CALLS", callsFile)
+	fakeGcloud(t, `echo "$*" >> "$CLI_TEST_CALLS"`)
+	cancelContext(t)
+	plan := FirewallSyncPlan{
+		Create: []FirewallRule{{Name: "allow-https", Priority: 1000}},
+		Update: []FirewallRuleChange{{Rule: FirewallRule{Name: "allow-http", Priority: 800}, Changes: []string{"priority"}}},
+	}
+
+	// The interruption stops the sync and is returned, instead of exiting the process
+	applied, failed, err := ApplyGCPFirewallSync("prod", plan)
+	if common.ExitCode(err) != common.ExitCodeInterrupted {
+		t.Errorf("error = %v, want interrupted error", err)
+	}
+	if len(applied) != 0 || len(failed) != 0 {
+		t.Errorf("applied, failed = %v, %v, want no rule after the interruption", applied, failed)
+	}
+	if content, _ := os.ReadFile(callsFile); strings.Count(string(content), "\n") > 1 {
+		t.Errorf("gcloud calls = %q, want the sync stopped", content)
+	}
+}
+
+func TestLoadGCPFirewallRulesFile(t *testing.T) {
+	dir := t.TempDir()
+	validFile := filepath.Join(dir, "rules.json")
+	if err := os.WriteFile(validFile, []byte(`[{"name": "allow-ssh", "priority": 1000, "allowed": [{"IPProtocol": "tcp", "ports": ["22"]}]}]`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	rules, err := LoadGCPFirewallRulesFile(validFile)
+	if err != nil || len(rules) != 1 || rules[0].Name != "allow-ssh" || rules[0].ProtocolsString() != "tcp:22" {
+		t.Errorf("LoadGCPFirewallRulesFile() = %+v, %v", rules, err)
+	}
+
+	withoutName := filepath.Join(dir, "without-name.json")
+	if err := os.WriteFile(withoutName, []byte(`[{"priority": 1000}]`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, filePath := range []string{withoutName, filepath.Join(dir, "missing.json")} {
+		if _, err := LoadGCPFirewallRulesFile(filePath); common.ExitCode(err) != common.ExitCodeValidation {
+			t.Errorf("LoadGCPFirewallRulesFile(%s) error = %v, want validation error", filePath, err)
+		}
+	}
+}
@@ -2,40 +2,196 @@
 package gcp
 
 import (
+	"encoding/json"
+	"fmt"
 	"strings"
 
 	"github.com/aeciopires/pires-cli/pkg/pireslib/common"
+	"github.com/aeciopires/pires-cli/pkg/pireslib/k8s"
 )
 
-// ConnectToGKECluster uses gcloud command to configure kubectl to connect to the specified GKE cluster.
-func ConnectToGKECluster(projectID, location, clusterName string) {
-	if projectID == "" || location == "" || clusterName == "" {
-		common.Logger("fatal", "projectID, location (region/zone), and clusterName are required to connect to GKE cluster")
+// GKECluster represents the fields used by CLI of a GKE cluster returned by
+// 'gcloud container clusters list --format=json' command.
+type GKECluster struct {
+	Name                 string `json:"name"`
+	Location             string `json:"location"`
+	Status               string `json:"status"`
+	CurrentMasterVersion string `json:"currentMasterVersion"`
+}
+
+// GKEConnectResult stores the kubeconfig contexts created and the failures
+// of ConnectAllGKEClusters function, both indexed by cluster name.
+type GKEConnectResult struct {
+	Contexts map[string]string
+	Failed   map[string]error
+}
+
+// ListGKEClusters lists the GKE clusters of a project.
+func ListGKEClusters(projectID string) ([]GKECluster, error) {
+	if projectID == "" {
+		return nil, common.NewValidationError("projectID is required to list GKE clusters in ListGKEClusters function")
 	}
 
-	common.Logger("info", "Attempting to configure kubectl for GKE cluster '%s' in region/zone '%s' (project: '%s')...", clusterName, location, projectID)
+	common.Logger("debug", "Listing GKE clusters on project '%s'...", projectID)
+	args := []string{
+		"container", "clusters", "list",
+		"--project", projectID,
+		"--format=json",
+	}
 
+	stdout, _, err := RunGcloudCommand(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseGKEClusters(stdout)
+}
+
+// ParseGKEClusters parses the JSON output of 'gcloud container clusters list --format=json'.
+func ParseGKEClusters(jsonOutput string) ([]GKECluster, error) {
+	clusters := []GKECluster{}
+	if strings.TrimSpace(jsonOutput) == "" {
+		return clusters, nil
+	}
+	if err := json.Unmarshal([]byte(jsonOutput), &clusters); err != nil {
+		return nil, fmt.Errorf("failed to parse GKE clusters: %w", err)
+	}
+	return clusters, nil
+}
+
+// BuildGKEContextName returns the name of kubeconfig context created by
+// 'gcloud container clusters get-credentials' command.
+// Example: gke_my-project_us-central1_my-cluster
+func BuildGKEContextName(projectID, location, clusterName string) string {
+	return fmt.Sprintf("gke_%s_%s_%s", projectID, location, clusterName)
+}
+
+// ParseGKEContextName returns the project, location and cluster of a kubeconfig context name
+// created by 'gcloud container clusters get-credentials' command (see BuildGKEContextName).
+// The ok is false if the context name is not in this format (e.g. renamed contexts).
+func ParseGKEContextName(contextName string) (projectID, location, clusterName string, ok bool) {
+	parts := strings.SplitN(contextName, "_", 4)
+	if len(parts) != 4 || parts[0] != "gke" || parts[1] == "" || parts[2] == "" || parts[3] == "" {
+		return "", "", "", false
+	}
+	return parts[1], parts[2], parts[3], true
+}
+
+// GKEContextStatus is the active project of gcloud compared with the project of current kubectl context,
+// returned by GetGKEContextStatus function.
+type GKEContextStatus struct {
+	GcloudProject  string   `json:"gcloudProject"`
+	KubectlContext string   `json:"kubectlContext"`
+	ContextProject string   `json:"contextProject"`
+	Mismatch       bool     `json:"mismatch"`
+	Warnings       []string `json:"warnings,omitempty"`
+}
+
+// CompareGKEContext compares the active project of gcloud (gcloudProject) with the project
+// embedded in the current kubectl context name (currentContext, empty if not set).
+// The mismatch is only reported when both projects are known.
+func CompareGKEContext(gcloudProject, currentContext string) GKEContextStatus {
+	status := GKEContextStatus{GcloudProject: gcloudProject, KubectlContext: currentContext}
+
+	if gcloudProject == "" {
+		status.Warnings = append(status.Warnings, "No active project set in gcloud")
+	}
+	if currentContext == "" {
+		status.Warnings = append(status.Warnings, "No current context set in kubectl")
+		return status
+	}
+
+	contextProject, _, _, ok := ParseGKEContextName(currentContext)
+	if !ok {
+		status.Warnings = append(status.Warnings, fmt.Sprintf("Could not find the project in kubectl context '%s'. Expected format: gke_{project}_{location}_{cluster}", currentContext))
+		return status
+	}
+	status.ContextProject = contextProject
+
+	if gcloudProject != "" && gcloudProject != contextProject {
+		status.Mismatch = true
+		status.Warnings = append(status.Warnings, fmt.Sprintf("The active project of gcloud ('%s') differs from the project of kubectl context '%s' ('%s')", gcloudProject, currentContext, contextProject))
+	}
+	return status
+}
+
+// GetGKEContextStatus runs 'gcloud config get-value project' and 'kubectl config current-context'
+// and compares the projects (see CompareGKEContext). The absence of current context is not an error.
+func GetGKEContextStatus() (GKEContextStatus, error) {
+	gcloudProject, err := GetGcloudConfigValue("project")
+	if err != nil {
+		return GKEContextStatus{}, err
+	}
+
+	currentContext, err := k8s.GetCurrentContext()
+	if err != nil {
+		if common.ExitCode(err) == common.ExitCodeInterrupted {
+			return GKEContextStatus{}, err
+		}
+		common.Logger("debug", "Could not get the current kubectl context: %v", err)
+		currentContext = ""
+	}
+
+	return CompareGKEContext(gcloudProject, currentContext), nil
+}
+
+// GetGKEClusterCredentials adds the credentials of a GKE cluster to kubeconfig file
+// (the KUBECONFIG environment variable is respected) and returns the context name.
+// If contextPrefix is not empty, the context is renamed to contextPrefix + cluster name.
+func GetGKEClusterCredentials(projectID string, cluster GKECluster, contextPrefix string) (string, error) {
 	args := []string{
-		"container", "clusters", "get-credentials", clusterName,
+		"container", "clusters", "get-credentials", cluster.Name,
+		"--location", cluster.Location,
 		"--project", projectID,
 	}
+	if _, _, err := RunGcloudPrimaryCommand(args...); err != nil {
+		return "", err
+	}
+
+	contextName := BuildGKEContextName(projectID, cluster.Location, cluster.Name)
+	if contextPrefix == "" {
+		return contextName, nil
+	}
 
-	// Add --zone or --region based on whether location contains '-' (typical for zones)
-	if strings.Contains(location, "-") && (strings.Count(location, "-") == 2) { // Heuristic for zone, e.g., us-central1-a
-		args = append(args, "--zone", location)
-	} else {
-		args = append(args, "--region", location)
+	newContextName := contextPrefix + cluster.Name
+	if err := k8s.RenameContext(contextName, newContextName); err != nil {
+		return "", err
+	}
+	return newContextName, nil
+}
+
+// ConnectAllGKEClusters adds the credentials of all GKE clusters of a project to kubeconfig file.
+// The failure of one cluster doesn't abort the others. The error is returned only if the clusters can't be listed.
+func ConnectAllGKEClusters(projectID, contextPrefix string) (GKEConnectResult, error) {
+	result := GKEConnectResult{
+		Contexts: map[string]string{},
+		Failed:   map[string]error{},
 	}
 
-	stdout, stderr, err := RunGcloudCommand(args...)
+	clusters, err := ListGKEClusters(projectID)
 	if err != nil {
-		common.Logger("fatal", "Failed to get GKE cluster credentials for '%s' in  region/zone '%s' (project: '%s')... Stdout: %s, Stderr: %s", clusterName, location, projectID, stdout, stderr)
+		return result, err
 	}
+	if len(clusters) == 0 {
+		common.Logger("warning", "No GKE clusters found on project '%s'.", projectID)
+		return result, nil
+	}
+
+	for _, cluster := range clusters {
+		// Stop early if the CLI received SIGINT/SIGTERM
+		if errInterrupted := common.CheckInterrupted(); errInterrupted != nil {
+			return result, errInterrupted
+		}
 
-	// gcloud get-credentials output usually includes "Fetching cluster endpoint and auth data."
-	// and "kubeconfig entry generated for <cluster_name>."
-	if stdout != "" {
-		common.Logger("debug", "gcloud get-credentials stdout: %s", stdout)
+		common.Logger("info", "Getting credentials of GKE cluster '%s' (%s)...", cluster.Name, cluster.Location)
+		contextName, errGet := GetGKEClusterCredentials(projectID, cluster, contextPrefix)
+		if errGet != nil {
+			common.Logger("error", "Failed to get credentials of GKE cluster '%s': %v", cluster.Name, errGet)
+			result.Failed[cluster.Name] = errGet
+			continue
+		}
+		result.Contexts[cluster.Name] = contextName
 	}
-	common.Logger("info", "Successfully configured kubectl for GKE cluster '%s' in region/zone '%s' (project: '%s')...", clusterName, location, projectID)
+
+	return result, nil
 }
@@ -0,0 +1,164 @@
+package gcp
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// gkeClustersScript is the body of a fake gcloud listing two clusters, where the credentials
+// of cluster 'broken' can't be obtained
+const gkeClustersScript = `case "$*" in
+*"clusters list"*) echo '[{"name":"apps","location":"us-central1","status":"RUNNING"},{"name":"broken","location":"us-east1-b","status":"ERROR"}]' ;;
+*"get-credentials broken"*) echo "ERROR: cluster is unreachable" >&2; exit 1 ;;
+esac`
+
+// fakeKubectlPath adds to PATH a kubectl script running body and logging its arguments in the returned file
+func fakeKubectlPath(t *testing.T, body string) string {
+	t.Helper()
+	dir := t.TempDir()
+	logFile := filepath.Join(dir, "kubectl.log")
+	script := "#!/bin/sh\necho \"$*\" >> '" + logFile + "'\n" + body + "\n"
+	if err := os.WriteFile(filepath.Join(dir, "kubectl"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	return logFile
+}
+
+func TestParseGKEClusters(t *testing.T) {
+	clusters, err := ParseGKEClusters(`[{"name":"apps","location":"us-central1","status":"RUNNING","currentMasterVersion":"1.30.1"}]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(clusters) != 1 || clusters[0].Name != "apps" || clusters[0].Location != "us-central1" || clusters[0].CurrentMasterVersion != "1.30.1" {
+		t.Errorf("ParseGKEClusters() = %+v", clusters)
+	}
+
+	if clusters, err := ParseGKEClusters(""); err != nil || len(clusters) != 0 {
+		t.Errorf("ParseGKEClusters(\"\") = %v, %v, want empty list", clusters, err)
+	}
+	if _, err := ParseGKEClusters("not json"); err == nil {
+		t.Error("expected error for invalid JSON")
+	}
+}
+
+func TestConnectAllGKEClusters(t *testing.T) {
+	fakeGcloud(t, gkeClustersScript)
+	kubectlLog := fakeKubectlPath(t, "")
+
+	result, err := ConnectAllGKEClusters("my-project", "")
+	if err != nil {
+		t.Fatalf("ConnectAllGKEClusters: %v", err)
+	}
+
+	// The failure of 'broken' doesn't abort 'apps'
+	if got, want := result.Contexts["apps"], "gke_my-project_us-central1_apps"; got != want || len(result.Contexts) != 1 {
+		t.Errorf("Contexts = %v, want only apps: %s", result.Contexts, want)
+	}
+	if result.Failed["broken"] == nil || len(result.Failed) != 1 {
+		t.Errorf("Failed = %v, want only broken", result.Failed)
+	}
+
+	// The contexts are not renamed without prefix
+	if _, err := os.Stat(kubectlLog); !os.IsNotExist(err) {
+		t.Errorf("kubectl was executed without context prefix")
+	}
+}
+
+func TestConnectAllGKEClustersContextPrefix(t *testing.T) {
+	fakeGcloud(t, gkeClustersScript)
+	kubectlLog := fakeKubectlPath(t, "")
+
+	result, err := ConnectAllGKEClusters("my-project", "prod-")
+	if err != nil {
+		t.Fatalf("ConnectAllGKEClusters: %v", err)
+	}
+	if got := result.Contexts["apps"]; got != "prod-apps" {
+		t.Errorf("context of apps = %q, want %q", got, "prod-apps")
+	}
+
+	calls, err := os.ReadFile(kubectlLog)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(calls), "config rename-context gke_my-project_us-central1_apps prod-apps") {
+		t.Errorf("context not renamed, kubectl calls:\n%s", calls)
+	}
+	if strings.Contains(string(calls), "broken") {
+		t.Errorf("context of failed cluster renamed, kubectl calls:\n%s", calls)
+	}
+}
+
+func TestConnectAllGKEClustersListFailure(t *testing.T) {
+	fakeGcloud(t, "exit 1")
+
+	if _, err := ConnectAllGKEClusters("my-project", ""); err == nil {
+		t.Fatal("expected error when the clusters can't be listed")
+	}
+}
+
+func TestParseGKEContextName(t *testing.T) {
+	projectID, location, clusterName, ok := ParseGKEContextName("gke_my-project_us-central1_apps_v2")
+	if !ok || projectID != "my-project" || location != "us-central1" || clusterName != "apps_v2" {
+		t.Errorf("ParseGKEContextName() = %s, %s, %s, %t", projectID, location, clusterName, ok)
+	}
+	for _, contextName := range []string{"prod-apps", "gke_my-project_us-central1", "eks_my-project_us-east-1_apps", "gke__us-central1_apps"} {
+		if _, _, _, ok := ParseGKEContextName(contextName); ok {
+			t.Errorf("ParseGKEContextName(%q) ok = true, want false", contextName)
+		}
+	}
+}
+
+func TestCompareGKEContext(t *testing.T) {
+	tests := []struct {
+		name           string
+		gcloudProject  string
+		currentContext string
+		wantMismatch   bool
+		wantProject    string
+		wantWarnings   int
+	}{
+		{"same project", "prod", "gke_prod_us-central1_apps", false, "prod", 0},
+		{"mismatch", "nonprod", "gke_prod_us-central1_apps", true, "prod", 1},
+		{"no current context", "prod", "", false, "", 1},
+		{"renamed context", "prod", "prod-apps", false, "", 1},
+		{"no gcloud project", "", "gke_prod_us-central1_apps", false, "prod", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := CompareGKEContext(tt.gcloudProject, tt.currentContext)
+			if status.Mismatch != tt.wantMismatch || status.ContextProject != tt.wantProject || len(status.Warnings) != tt.wantWarnings {
+				t.Errorf("CompareGKEContext() = %+v, want mismatch %t, project %q and %d warning(s)", status, tt.wantMismatch, tt.wantProject, tt.wantWarnings)
+			}
+		})
+	}
+}
+
+func TestGetGKEContextStatusMismatch(t *testing.T) {
+	fakeGcloud(t, `[ "$*" = "config get-value project" ] && echo nonprod`)
+	fakeKubectlPath(t, "echo gke_prod_us-central1_apps")
+
+	status, err := GetGKEContextStatus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !status.Mismatch || status.GcloudProject != "nonprod" || status.ContextProject != "prod" {
+		t.Errorf("status = %+v, want mismatch of nonprod and prod", status)
+	}
+}
+
+func TestGetGKEContextStatusWithoutCurrentContext(t *testing.T) {
+	fakeGcloud(t, "echo prod")
+	// kubectl fails without current context
+	fakeKubectlPath(t, `echo "error: current-context is not set" >&2; exit 1`)
+
+	status, err := GetGKEContextStatus()
+	if err != nil {
+		t.Fatalf("error = %v, want the absence of context handled", err)
+	}
+	if status.Mismatch || status.KubectlContext != "" || len(status.Warnings) != 1 {
+		t.Errorf("status = %+v, want only the warning of missing context", status)
+	}
+}
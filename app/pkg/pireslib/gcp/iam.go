@@ -2,16 +2,27 @@
 package gcp
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
 	"strings"
+	"time"
 
+	"github.com/aeciopires/pires-cli/internal/config"
 	"github.com/aeciopires/pires-cli/pkg/pireslib/common"
+	"gopkg.in/yaml.v3"
 )
 
 // CreateGCPIAMServiceAccount creates a new service account in the specified project using gcloud command.
-func CreateGCPIAMServiceAccount(projectID, accountID, description string) {
+// An existing service account is only logged as warning.
+func CreateGCPIAMServiceAccount(projectID, accountID, description string) error {
 	if projectID == "" || accountID == "" {
-		common.Logger("fatal", "projectID and accountID are required to create a service account on CreateGCPIAMServiceAccount function")
+		return common.NewValidationError("projectID and accountID are required to create a service account on CreateGCPIAMServiceAccount function")
 	}
 
 	common.Logger("info", "Creating service account '%s' in project '%s'...", accountID, projectID)
@@ -27,15 +38,15 @@ func CreateGCPIAMServiceAccount(projectID, accountID, description string) {
 
 	// gcloud iam service-accounts create prints the email of the created SA to stdout on success,
 	// or an error to stderr.
-	_, stderr, err := RunGcloudCommand(args...)
+	_, stderr, err := RunGcloudPrimaryCommand(args...)
 	if err != nil {
 		// Check if SA already exists
 		if strings.Contains(stderr, "already exists") {
 			saEmail := fmt.Sprintf("%s@%s.iam.gserviceaccount.com", accountID, projectID)
 			common.Logger("warning", "Service account '%s' already exists.", saEmail)
-		} else {
-			common.Logger("fatal", "Failed to create service account '%s' on project '%s': %w. Stderr: %s", accountID, projectID, err, stderr)
+			return nil
 		}
+		return common.NewExternalCommandError("failed to create service account '%s' on project '%s': %v. Stderr: %s", accountID, projectID, err, stderr)
 	}
 
 	// Expected output on success: "Created service account [sa-id]."
@@ -43,35 +54,1011 @@ func CreateGCPIAMServiceAccount(projectID, accountID, description string) {
 	// For now, constructing it is safer.
 	createdSAEmail := fmt.Sprintf("%s@%s.iam.gserviceaccount.com", accountID, projectID)
 	common.Logger("info", "Service account '%s' created successfully. Email: %s on project '%s'.", accountID, createdSAEmail, projectID)
+	return nil
+}
+
+// IAMCondition represents an IAM condition attached to a role binding.
+// Reference: https://cloud.google.com/iam/docs/conditions-overview
+type IAMCondition struct {
+	Title       string `yaml:"title" json:"title"`
+	Expression  string `yaml:"expression" json:"expression"`
+	Description string `yaml:"description,omitempty" json:"description,omitempty"`
+}
+
+// IAMBinding represents a member/role pair, with an optional condition, to be granted on a project.
+type IAMBinding struct {
+	Member    string        `yaml:"member" json:"member"`
+	Role      string        `yaml:"role" json:"role"`
+	Condition *IAMCondition `yaml:"condition,omitempty" json:"condition,omitempty"`
+}
+
+// IAMBindingsFile represents the content of a bindings file used by 'iam apply-bindings' command.
+// JSON files are supported too, because JSON is a subset of YAML.
+//
+// Example:
+//
+//	bindings:
+//	  - member: user:name.surname@company.com
+//	    role: roles/cloudsql.client
+//	  - member: serviceAccount:app-name-gsa@change-project.iam.gserviceaccount.com
+//	    role: roles/storage.objectViewer
+//	    condition:
+//	      title: only-app-bucket
+//	      expression: resource.name.startsWith("projects/_/buckets/app-bucket")
+type IAMBindingsFile struct {
+	Bindings []IAMBinding `yaml:"bindings" json:"bindings"`
+}
+
+// IAMBindingsResult groups the bindings granted with success, the bindings that failed
+// and the bindings that would be granted (dry-run), which aren't granted.
+type IAMBindingsResult struct {
+	Succeeded []IAMBinding
+	Failed    map[string]error // Key is the string representation of the binding
+	DryRun    []IAMBinding
+}
+
+var (
+	// Member formats supported by gcloud. Example: user:name.surname@company.com
+	iamMemberRegex = regexp.MustCompile(`^(allUsers|allAuthenticatedUsers|(user|serviceAccount|group|domain|principal|principalSet|deleted:user|deleted:serviceAccount|deleted:group):\S+)$`)
+	// Role formats supported by gcloud. Example: roles/storage.objectViewer or projects/my-project/roles/myCustomRole
+	iamRoleRegex = regexp.MustCompile(`^(roles/[A-Za-z0-9_.]+|(projects/[a-z0-9-]+|organizations/[0-9]+)/roles/[A-Za-z0-9_.]+)$`)
+)
+
+// String returns a human readable representation of the binding.
+func (b IAMBinding) String() string {
+	if b.Condition != nil {
+		return fmt.Sprintf("%s => %s (condition: %s)", b.Member, b.Role, b.Condition.Title)
+	}
+	return fmt.Sprintf("%s => %s", b.Member, b.Role)
+}
+
+// ValidateGCPIAMMember checks if the member has a format supported by gcloud, like: "user:email@example.com".
+func ValidateGCPIAMMember(member string) error {
+	if !iamMemberRegex.MatchString(member) {
+		return common.NewValidationError("invalid member '%s'. Expected format: user:{email}, serviceAccount:{email}, group:{email} or domain:{domain}", member)
+	}
+	return nil
+}
+
+// ValidateGCPIAMRole checks if the role has a format supported by gcloud, like: "roles/storage.objectViewer".
+func ValidateGCPIAMRole(role string) error {
+	if !iamRoleRegex.MatchString(role) {
+		return common.NewValidationError("invalid role '%s'. Expected format: roles/{SERVICE_NAME}.{ROLE_NAME} or projects/{PROJECT_ID}/roles/{CUSTOM_ROLE_ID}", role)
+	}
+	return nil
+}
+
+// Validate checks the member, role and condition of the binding.
+func (b IAMBinding) Validate() error {
+	if err := ValidateGCPIAMMember(b.Member); err != nil {
+		return err
+	}
+	if err := ValidateGCPIAMRole(b.Role); err != nil {
+		return err
+	}
+	if b.Condition != nil && (b.Condition.Title == "" || b.Condition.Expression == "") {
+		return common.NewValidationError("condition of binding '%s' requires 'title' and 'expression'", b)
+	}
+	if _, err := b.conditionArg(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// iamConditionDelimiters are the candidates of delimiter of --condition argument of gcloud.
+// The expressions usually have commas (e.g. functions with many arguments), so the default
+// delimiter of gcloud (comma) can't be used. See 'gcloud topic escaping'.
+var iamConditionDelimiters = []string{";", "|", "~", "#", "@@"}
+
+// conditionArg returns the value of the --condition argument of gcloud.
+// The fields are separated by the first delimiter of iamConditionDelimiters that isn't used in the values,
+// using the alternate delimiter syntax of gcloud, like: --condition=^;^expression=...;title=...
+func (b IAMBinding) conditionArg() (string, error) {
+	if b.Condition == nil {
+		return "--condition=None", nil
+	}
+
+	fields := []string{"expression=" + b.Condition.Expression, "title=" + b.Condition.Title}
+	if b.Condition.Description != "" {
+		fields = append(fields, "description="+b.Condition.Description)
+	}
+	for _, delimiter := range iamConditionDelimiters {
+		if !slices.ContainsFunc(fields, func(field string) bool { return strings.Contains(field, delimiter) }) {
+			return "--condition=^" + delimiter + "^" + strings.Join(fields, delimiter), nil
+		}
+	}
+	return "", common.NewValidationError("condition of binding '%s' uses all supported delimiters (%s) in its fields", b, strings.Join(iamConditionDelimiters, " "))
+}
+
+// AddGCPIAMPolicyBinding grants the role of binding to its member on a project using gcloud command.
+func AddGCPIAMPolicyBinding(projectID string, binding IAMBinding) error {
+	if projectID == "" {
+		return common.NewValidationError("projectID is required to grant IAM role on AddGCPIAMPolicyBinding function")
+	}
+	if err := binding.Validate(); err != nil {
+		return err
+	}
+
+	conditionArg, err := binding.conditionArg()
+	if err != nil {
+		return err
+	}
+	args := []string{
+		"projects", "add-iam-policy-binding", projectID,
+		"--member", binding.Member,
+		"--role", binding.Role,
+		conditionArg,
+		"--project", projectID,
+	}
+
+	// `add-iam-policy-binding` is idempotent. If the binding already exists, it won't error.
+	_, stderr, err := RunGcloudPrimaryCommand(args...)
+	if err != nil {
+		// Check stderr for specific permission denied errors for the operation itself
+		if strings.Contains(stderr, "PERMISSION_DENIED") && strings.Contains(stderr, "resourcemanager.projects.setIamPolicy") {
+			return common.NewPermissionDeniedError("permission denied to set IAM policy for project '%s': %w", projectID, err)
+		}
+		return fmt.Errorf("failed to grant role '%s' to member '%s' on project '%s': %w", binding.Role, binding.Member, projectID, err)
+	}
+	return nil
 }
 
 // GrantGCPIAMRoleToMember grants a specific IAM role to a member on a project using gcloud command.
 // Member format: "user:email@example.com", "serviceAccount:sa-email@project.iam.gserviceaccount.com", etc.
 // Role format: "roles/rolename" (e.g., "roles/storage.objectViewer")
-func GrantGCPIAMRoleToMember(projectID, member, role string) {
+func GrantGCPIAMRoleToMember(projectID, member, role string) error {
 	if projectID == "" || member == "" || role == "" {
-		common.Logger("fatal", "projectID, member, and role are required to grant IAM role on GrantGCPIAMRoleToMember function")
+		return common.NewValidationError("projectID, member, and role are required to grant IAM role on GrantGCPIAMRoleToMember function")
 	}
 
 	common.Logger("info", "Granting role '%s' to member '%s' on project '%s'...", role, member, projectID)
 
+	if err := AddGCPIAMPolicyBinding(projectID, IAMBinding{Member: member, Role: role}); err != nil {
+		return err
+	}
+
+	common.Logger("info", "Successfully granted (or ensured) role '%s' to member '%s' on project '%s'.", role, member, projectID)
+	return nil
+}
+
+// LoadGCPIAMBindingsFile reads a YAML or JSON file with a list of bindings.
+// All bindings are validated and an error listing all invalid entries is returned if any is found.
+func LoadGCPIAMBindingsFile(filePath string) ([]IAMBinding, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, common.NewValidationError("could not read bindings file '%s': %w", filePath, err)
+	}
+
+	var bindingsFile IAMBindingsFile
+	if err := yaml.Unmarshal(data, &bindingsFile); err != nil {
+		return nil, common.NewValidationError("failed to parse bindings file '%s': %w", filePath, err)
+	}
+	if len(bindingsFile.Bindings) == 0 {
+		return nil, common.NewValidationError("no bindings found in file '%s'. Expected a 'bindings' list", filePath)
+	}
+
+	var invalidEntries []string
+	for i, binding := range bindingsFile.Bindings {
+		if err := binding.Validate(); err != nil {
+			invalidEntries = append(invalidEntries, fmt.Sprintf("  - entry %d: %v", i+1, err))
+		}
+	}
+	if len(invalidEntries) > 0 {
+		return nil, common.NewValidationError("invalid entries in bindings file '%s':\n%s", filePath, strings.Join(invalidEntries, "\n"))
+	}
+
+	return bindingsFile.Bindings, nil
+}
+
+// LoadGCPIAMMembersFile reads a file with one member per line, like: user:name.surname@company.com.
+// Blank lines and comments (starting with '#') are skipped. Invalid members don't abort the reading:
+// they are returned in invalidLines with their line numbers, so the valid members can still be used.
+func LoadGCPIAMMembersFile(filePath string) (members []string, invalidLines []string, err error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, nil, common.NewValidationError("could not read members file '%s': %w", filePath, err)
+	}
+
+	for i, line := range strings.Split(string(data), "\n") {
+		member := strings.TrimSpace(line)
+		if member == "" || strings.HasPrefix(member, "#") {
+			continue
+		}
+		if errMember := ValidateGCPIAMMember(member); errMember != nil {
+			invalidLines = append(invalidLines, fmt.Sprintf("line %d: %v", i+1, errMember))
+			continue
+		}
+		members = append(members, member)
+	}
+	return members, invalidLines, nil
+}
+
+// ApplyGCPIAMBindings grants each binding on the project, aggregating successes and failures.
+// A failure in a binding doesn't abort the others.
+// If dryRun is true, the bindings are only logged and nothing is changed.
+func ApplyGCPIAMBindings(projectID string, bindings []IAMBinding, dryRun bool) IAMBindingsResult {
+	result := IAMBindingsResult{Failed: map[string]error{}}
+
+	for _, binding := range bindings {
+		if dryRun {
+			common.Logger("info", "[DRY-RUN] Would grant binding: %s on project '%s'", binding, projectID)
+			result.DryRun = append(result.DryRun, binding)
+			continue
+		}
+
+		common.Logger("info", "Granting binding: %s on project '%s'...", binding, projectID)
+		if err := AddGCPIAMPolicyBinding(projectID, binding); err != nil {
+			common.Logger("error", "Failed to grant binding %s: %v", binding, err)
+			result.Failed[binding.String()] = err
+			continue
+		}
+		result.Succeeded = append(result.Succeeded, binding)
+	}
+
+	return result
+}
+
+// iamPolicyFlattenedEntry represents an entry of 'gcloud projects get-iam-policy --flatten=bindings[].members --format=json'.
+type iamPolicyFlattenedEntry struct {
+	Bindings struct {
+		Members   string        `json:"members"`
+		Role      string        `json:"role"`
+		Condition *IAMCondition `json:"condition,omitempty"`
+	} `json:"bindings"`
+}
+
+// getGCPIAMPolicyFlattened returns the bindings of the project IAM policy flattened by member.
+func getGCPIAMPolicyFlattened(projectID string) ([]iamPolicyFlattenedEntry, error) {
 	args := []string{
-		"projects", "add-iam-policy-binding", projectID,
+		"projects", "get-iam-policy", projectID,
+		"--flatten=bindings[].members",
+		"--format=json",
+	}
+
+	stdout, _, err := RunGcloudCommand(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []iamPolicyFlattenedEntry{}
+	if strings.TrimSpace(stdout) == "" {
+		return entries, nil
+	}
+	if err := json.Unmarshal([]byte(stdout), &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse IAM policy of project '%s': %w", projectID, err)
+	}
+	return entries, nil
+}
+
+// ListGCPIAMRolesForMember lists the roles granted directly to a member on a project.
+// The member must match exactly, e.g. "user:name.surname@company.com". The roles are sorted and unique.
+func ListGCPIAMRolesForMember(projectID, member string) ([]string, error) {
+	if projectID == "" || member == "" {
+		return nil, common.NewValidationError("projectID and member are required to list IAM roles on ListGCPIAMRolesForMember function")
+	}
+
+	entries, err := getGCPIAMPolicyFlattened(projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	roles, _ := rolesForMember(entries, member)
+	return roles, nil
+}
+
+// rolesForMember returns the sorted and unique roles of member in the flattened policy entries,
+// and the bindings of member with condition.
+func rolesForMember(entries []iamPolicyFlattenedEntry, member string) ([]string, []IAMBinding) {
+	roles := []string{}
+	conditionalBindings := []IAMBinding{}
+	for _, entry := range entries {
+		if entry.Bindings.Members != member {
+			continue
+		}
+		if entry.Bindings.Condition != nil {
+			conditionalBindings = append(conditionalBindings, IAMBinding{Member: member, Role: entry.Bindings.Role, Condition: entry.Bindings.Condition})
+		}
+		if !slices.Contains(roles, entry.Bindings.Role) {
+			roles = append(roles, entry.Bindings.Role)
+		}
+	}
+	sort.Strings(roles)
+	return roles, conditionalBindings
+}
+
+// ListGCPIAMMembersForRole lists the members granted directly with a role on a project, like "user:name.surname@company.com"
+// or "serviceAccount:app@project.iam.gserviceaccount.com" (with the type prefix). The members of bindings with condition
+// are included. The members are sorted and unique. An empty slice is returned when the role has no members.
+func ListGCPIAMMembersForRole(projectID, role string) ([]string, error) {
+	if projectID == "" || role == "" {
+		return nil, common.NewValidationError("projectID and role are required to list IAM members on ListGCPIAMMembersForRole function")
+	}
+	if err := ValidateGCPIAMRole(role); err != nil {
+		return nil, err
+	}
+
+	entries, err := getGCPIAMPolicyFlattened(projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	return membersForRole(entries, role), nil
+}
+
+// membersForRole returns the sorted and unique members of role in the flattened policy entries.
+func membersForRole(entries []iamPolicyFlattenedEntry, role string) []string {
+	members := []string{}
+	for _, entry := range entries {
+		if entry.Bindings.Role == role && !slices.Contains(members, entry.Bindings.Members) {
+			members = append(members, entry.Bindings.Members)
+		}
+	}
+	sort.Strings(members)
+	return members
+}
+
+// IAMServiceAccount represents a service account returned by 'gcloud iam service-accounts list --format=json'.
+type IAMServiceAccount struct {
+	Email       string `json:"email"`
+	DisplayName string `json:"displayName,omitempty"`
+	Disabled    bool   `json:"disabled"`
+}
+
+// ListGCPIAMServiceAccounts lists the service accounts of a project.
+func ListGCPIAMServiceAccounts(projectID string) ([]IAMServiceAccount, error) {
+	if projectID == "" {
+		return nil, common.NewValidationError("projectID is required to list service accounts on ListGCPIAMServiceAccounts function")
+	}
+
+	args := []string{
+		"iam", "service-accounts", "list",
+		"--project", projectID,
+		"--format=json",
+	}
+	stdout, _, err := RunGcloudCommand(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	serviceAccounts := []IAMServiceAccount{}
+	if strings.TrimSpace(stdout) == "" {
+		return serviceAccounts, nil
+	}
+	if err := json.Unmarshal([]byte(stdout), &serviceAccounts); err != nil {
+		return nil, fmt.Errorf("failed to parse service accounts of project '%s': %w", projectID, err)
+	}
+	return serviceAccounts, nil
+}
+
+// IAMRole represents a role returned by 'gcloud iam roles list --format=json'.
+// Custom is true for the custom roles of project (not returned by gcloud).
+type IAMRole struct {
+	Name   string `json:"name"`
+	Title  string `json:"title"`
+	Stage  string `json:"stage,omitempty"`
+	Custom bool   `json:"custom"`
+}
+
+// ParseGCPIAMRoles parses the output of 'gcloud iam roles list --format=json' command.
+func ParseGCPIAMRoles(jsonOutput string) ([]IAMRole, error) {
+	roles := []IAMRole{}
+	if strings.TrimSpace(jsonOutput) == "" {
+		return roles, nil
+	}
+	if err := json.Unmarshal([]byte(jsonOutput), &roles); err != nil {
+		return nil, fmt.Errorf("failed to parse IAM roles: %w", err)
+	}
+	return roles, nil
+}
+
+// ListGCPIAMGrantableRoles lists the predefined roles and the custom roles of project,
+// merged and sorted by name.
+func ListGCPIAMGrantableRoles(projectID string) ([]IAMRole, error) {
+	if projectID == "" {
+		return nil, common.NewValidationError("projectID is required to list roles on ListGCPIAMGrantableRoles function")
+	}
+
+	stdout, _, err := RunGcloudCommand("iam", "roles", "list", "--format=json(name,title,stage)")
+	if err != nil {
+		return nil, err
+	}
+	roles, err := ParseGCPIAMRoles(stdout)
+	if err != nil {
+		return nil, err
+	}
+
+	stdout, _, err = RunGcloudCommand("iam", "roles", "list", "--project", projectID, "--format=json(name,title,stage)")
+	if err != nil {
+		return nil, err
+	}
+	customRoles, err := ParseGCPIAMRoles(stdout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse custom roles of project '%s': %w", projectID, err)
+	}
+	for _, role := range customRoles {
+		role.Custom = true
+		roles = append(roles, role)
+	}
+
+	slices.SortFunc(roles, func(a, b IAMRole) int { return strings.Compare(a.Name, b.Name) })
+	return roles, nil
+}
+
+// customRoleIDRegex matches the ID of a custom role, like: appDeployer or app.deployer
+var customRoleIDRegex = regexp.MustCompile(`^[a-zA-Z0-9_.]{3,64}$`)
+
+// IAMCustomRole represents a custom role of project returned by 'gcloud iam roles list/describe --format=json'.
+// IncludedPermissions is only returned by describe.
+type IAMCustomRole struct {
+	Name                string   `json:"name"`
+	Title               string   `json:"title"`
+	Description         string   `json:"description,omitempty"`
+	Stage               string   `json:"stage,omitempty"`
+	Deleted             bool     `json:"deleted,omitempty"`
+	Etag                string   `json:"etag,omitempty"`
+	IncludedPermissions []string `json:"includedPermissions,omitempty"`
+}
+
+// ID returns the ID of custom role, the last segment of name (e.g. appDeployer of projects/nonprod/roles/appDeployer).
+func (r IAMCustomRole) ID() string {
+	return r.Name[strings.LastIndex(r.Name, "/")+1:]
+}
+
+// ParseGCPIAMCustomRoles parses the output of 'gcloud iam roles list --project --format=json' command, sorted by name.
+func ParseGCPIAMCustomRoles(jsonOutput string) ([]IAMCustomRole, error) {
+	roles := []IAMCustomRole{}
+	if strings.TrimSpace(jsonOutput) == "" {
+		return roles, nil
+	}
+	if err := json.Unmarshal([]byte(jsonOutput), &roles); err != nil {
+		return nil, fmt.Errorf("failed to parse custom roles: %w", err)
+	}
+	slices.SortFunc(roles, func(a, b IAMCustomRole) int { return strings.Compare(a.Name, b.Name) })
+	return roles, nil
+}
+
+// ParseGCPIAMCustomRole parses the output of 'gcloud iam roles describe --project --format=json' command.
+// The included permissions are sorted.
+func ParseGCPIAMCustomRole(jsonOutput string) (*IAMCustomRole, error) {
+	var role IAMCustomRole
+	if err := json.Unmarshal([]byte(jsonOutput), &role); err != nil {
+		return nil, fmt.Errorf("failed to parse custom role: %w", err)
+	}
+	slices.Sort(role.IncludedPermissions)
+	return &role, nil
+}
+
+// ListGCPIAMCustomRoles lists the custom roles of a project (without the permissions) using 'gcloud iam roles list' command.
+func ListGCPIAMCustomRoles(projectID string) ([]IAMCustomRole, error) {
+	if projectID == "" {
+		return nil, common.NewValidationError("projectID is required to list custom roles on ListGCPIAMCustomRoles function")
+	}
+
+	stdout, _, err := RunGcloudPrimaryCommand("iam", "roles", "list", "--project", projectID, "--format=json")
+	if err != nil {
+		return nil, err
+	}
+	roles, err := ParseGCPIAMCustomRoles(stdout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse custom roles of project '%s': %w", projectID, err)
+	}
+	return roles, nil
+}
+
+// DescribeGCPIAMCustomRole returns a custom role of project with its permissions using 'gcloud iam roles describe' command.
+// The roleID can be the ID (e.g. appDeployer) or the full name of role (e.g. projects/nonprod/roles/appDeployer).
+func DescribeGCPIAMCustomRole(projectID, roleID string) (*IAMCustomRole, error) {
+	if projectID == "" {
+		return nil, common.NewValidationError("projectID is required to describe a custom role on DescribeGCPIAMCustomRole function")
+	}
+	roleID = strings.TrimPrefix(roleID, "projects/"+projectID+"/roles/")
+	if !customRoleIDRegex.MatchString(roleID) {
+		return nil, common.NewValidationError("invalid custom role ID '%s'. Expected 3 to 64 letters, digits, underscores or periods (e.g. appDeployer), or the full name of a role of project '%s'", roleID, projectID)
+	}
+
+	stdout, _, err := RunGcloudPrimaryCommand("iam", "roles", "describe", roleID, "--project", projectID, "--format=json")
+	if err != nil {
+		return nil, err
+	}
+	role, err := ParseGCPIAMCustomRole(stdout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse custom role '%s' of project '%s': %w", roleID, projectID, err)
+	}
+	return role, nil
+}
+
+// FilterGCPIAMRoles returns the roles whose name or title contains the filter (case insensitive).
+// All roles are returned if the filter is empty.
+func FilterGCPIAMRoles(roles []IAMRole, filter string) []IAMRole {
+	filter = strings.ToLower(strings.TrimSpace(filter))
+	if filter == "" {
+		return roles
+	}
+
+	var filtered []IAMRole
+	for _, role := range roles {
+		if strings.Contains(strings.ToLower(role.Name), filter) || strings.Contains(strings.ToLower(role.Title), filter) {
+			filtered = append(filtered, role)
+		}
+	}
+	return filtered
+}
+
+// Formats of the service accounts roles report. See ExportGCPIAMServiceAccountsRoles function
+const (
+	ServiceAccountsRolesFormatCSV = "csv"
+	ServiceAccountsRolesFormatTXT = "txt"
+)
+
+// BuildGCPIAMServiceAccountsRolesRows returns one row per service account (email, display name, disabled and roles)
+// with the roles granted directly on the project, sorted by email.
+func BuildGCPIAMServiceAccountsRolesRows(serviceAccounts []IAMServiceAccount, rolesByEmail map[string][]string) [][]string {
+	sorted := slices.Clone(serviceAccounts)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Email < sorted[j].Email })
+
+	rows := make([][]string, 0, len(sorted))
+	for _, serviceAccount := range sorted {
+		roles := strings.Join(rolesByEmail[serviceAccount.Email], ";")
+		if roles == "" {
+			roles = "(none)"
+		}
+		rows = append(rows, []string{serviceAccount.Email, serviceAccount.DisplayName, fmt.Sprint(serviceAccount.Disabled), roles})
+	}
+	return rows
+}
+
+// ExportGCPIAMServiceAccountsRoles writes a report mapping every service account of a project to the roles granted
+// directly to it on the project. The roles of each service account are listed by ListGCPIAMRolesForMember,
+// in parallel (see --max-concurrency option). The report isn't written if any lookup fails.
+// The format is ServiceAccountsRolesFormatCSV or ServiceAccountsRolesFormatTXT (table). The filename is defined by
+// config.IAMServiceAccountsRolesFilenameTemplate, with the extension of format. It returns the path of report.
+func ExportGCPIAMServiceAccountsRoles(projectID, outputDir, format string) (string, error) {
+	if format != ServiceAccountsRolesFormatCSV && format != ServiceAccountsRolesFormatTXT {
+		return "", common.NewValidationError("Unsupported format '%s'. Supported values: csv or txt", format)
+	}
+
+	serviceAccounts, err := ListGCPIAMServiceAccounts(projectID)
+	if err != nil {
+		return "", err
+	}
+
+	roles := make([][]string, len(serviceAccounts))
+	errs := make([]error, len(serviceAccounts))
+	common.ForEachConcurrently(len(serviceAccounts), func(i int) {
+		// Skip the remaining service accounts if the CLI received SIGINT/SIGTERM
+		if common.CheckInterrupted() != nil {
+			return
+		}
+		roles[i], errs[i] = ListGCPIAMRolesForMember(projectID, "serviceAccount:"+serviceAccounts[i].Email)
+	})
+	if errInterrupted := common.CheckInterrupted(); errInterrupted != nil {
+		return "", errInterrupted
+	}
+	if err := errors.Join(errs...); err != nil {
+		return "", fmt.Errorf("failed to list roles of service accounts of project '%s': %w", projectID, err)
+	}
+
+	rolesByEmail := make(map[string][]string, len(serviceAccounts))
+	for i, serviceAccount := range serviceAccounts {
+		rolesByEmail[serviceAccount.Email] = roles[i]
+	}
+	rows := BuildGCPIAMServiceAccountsRolesRows(serviceAccounts, rolesByEmail)
+	headers := []string{"EMAIL", "DISPLAY_NAME", "DISABLED", "ROLES"}
+
+	var content strings.Builder
+	if format == ServiceAccountsRolesFormatCSV {
+		err = common.WriteCSV(&content, headers, rows)
+	} else {
+		err = common.WriteTable(&content, headers, rows)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	fileName := common.BuildReportFilename(config.IAMServiceAccountsRolesFilenameTemplate, common.ReportFilenameVars(projectID, ""))
+	fileName = strings.TrimSuffix(fileName, filepath.Ext(fileName)) + "." + format
+	filePath := filepath.Join(outputDir, fileName)
+	if err := os.MkdirAll(filepath.Dir(filePath), config.PermissionDir); err != nil {
+		return "", fmt.Errorf("failed to create output directory '%s': %w", filepath.Dir(filePath), err)
+	}
+	if err := common.WriteFileAtomic(filePath, []byte(content.String()), config.PermissionFile); err != nil {
+		return "", fmt.Errorf("failed to write service accounts roles report '%s': %w", filePath, err)
+	}
+
+	common.Logger("info", "Exported roles of %d service account(s) of project '%s' to: %s", len(serviceAccounts), projectID, filePath)
+	return filePath, nil
+}
+
+// IAMMemberDescription is the summary of the access of a member on a project. See DescribeGCPIAMMember function
+type IAMMemberDescription struct {
+	Member              string             `json:"member"`
+	Roles               []string           `json:"roles"`
+	ConditionalBindings []IAMBinding       `json:"conditionalBindings"`
+	IsServiceAccount    bool               `json:"isServiceAccount"`
+	ServiceAccount      *IAMServiceAccount `json:"serviceAccount,omitempty"` // nil if the service account is not in the project
+}
+
+// DescribeGCPIAMMember summarizes the access of a member on a project: the roles granted directly
+// (with or without condition), the bindings with condition and, for service accounts,
+// its status in the service accounts of project.
+func DescribeGCPIAMMember(projectID, member string) (*IAMMemberDescription, error) {
+	if projectID == "" {
+		return nil, common.NewValidationError("projectID is required to describe a member on DescribeGCPIAMMember function")
+	}
+	if err := ValidateGCPIAMMember(member); err != nil {
+		return nil, err
+	}
+
+	entries, err := getGCPIAMPolicyFlattened(projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	description := &IAMMemberDescription{Member: member}
+	description.Roles, description.ConditionalBindings = rolesForMember(entries, member)
+
+	email, isServiceAccount := strings.CutPrefix(member, "serviceAccount:")
+	description.IsServiceAccount = isServiceAccount
+	if !isServiceAccount {
+		return description, nil
+	}
+
+	serviceAccounts, err := ListGCPIAMServiceAccounts(projectID)
+	if err != nil {
+		return nil, err
+	}
+	for _, serviceAccount := range serviceAccounts {
+		if serviceAccount.Email == email {
+			description.ServiceAccount = &serviceAccount
+			break
+		}
+	}
+	return description, nil
+}
+
+// DescribeGCPIAMServiceAccount returns a service account of a project using 'gcloud iam service-accounts describe' command.
+func DescribeGCPIAMServiceAccount(projectID, email string) (*IAMServiceAccount, error) {
+	if projectID == "" {
+		return nil, common.NewValidationError("projectID is required to describe a service account on DescribeGCPIAMServiceAccount function")
+	}
+	if err := config.ValidateServiceAccountEmail(email); err != nil {
+		return nil, common.NewValidationError("%w", err)
+	}
+
+	args := []string{
+		"iam", "service-accounts", "describe", email,
+		"--project", projectID,
+		"--format=json",
+	}
+	stdout, _, err := RunGcloudCommand(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var serviceAccount IAMServiceAccount
+	if err := json.Unmarshal([]byte(stdout), &serviceAccount); err != nil {
+		return nil, fmt.Errorf("failed to parse service account '%s': %w", email, err)
+	}
+	return &serviceAccount, nil
+}
+
+// Status of service account keys checked by CheckGCPIAMServiceAccountKeys function
+const (
+	IAMKeyStatusOK       = "OK"
+	IAMKeyStatusOld      = "OLD"
+	IAMKeyStatusExpired  = "EXPIRED"
+	IAMKeyStatusDisabled = "DISABLED"
+)
+
+// Types of service account keys. The system managed keys are rotated by Google.
+const (
+	IAMKeyTypeUserManaged   = "USER_MANAGED"
+	IAMKeyTypeSystemManaged = "SYSTEM_MANAGED"
+)
+
+// IAMServiceAccountKey represents the relevant fields of a service account key
+// returned by 'gcloud iam service-accounts keys list --format=json'.
+type IAMServiceAccountKey struct {
+	Name            string    `json:"name"` // projects/{project}/serviceAccounts/{email}/keys/{id}
+	KeyType         string    `json:"keyType"`
+	ValidAfterTime  time.Time `json:"validAfterTime"`
+	ValidBeforeTime time.Time `json:"validBeforeTime"`
+	Disabled        bool      `json:"disabled"`
+}
+
+// ID returns the ID of key, i.e. the last part of its name.
+func (k IAMServiceAccountKey) ID() string {
+	return k.Name[strings.LastIndex(k.Name, "/")+1:]
+}
+
+// IAMServiceAccountKeyCheck is the check of a service account key. See CheckGCPIAMServiceAccountKeys function.
+type IAMServiceAccountKeyCheck struct {
+	Key     IAMServiceAccountKey
+	AgeDays int
+	Status  string
+}
+
+// ListGCPIAMServiceAccountKeys lists the keys of a service account using gcloud command.
+func ListGCPIAMServiceAccountKeys(projectID, email string) ([]IAMServiceAccountKey, error) {
+	if projectID == "" || email == "" {
+		return nil, common.NewValidationError("projectID and email are required to list service account keys on ListGCPIAMServiceAccountKeys function")
+	}
+
+	args := []string{
+		"iam", "service-accounts", "keys", "list",
+		"--iam-account", email,
+		"--project", projectID,
+		"--format=json",
+	}
+	stdout, _, err := RunGcloudCommand(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseGCPIAMServiceAccountKeys(stdout)
+}
+
+// ParseGCPIAMServiceAccountKeys parses the JSON output of 'gcloud iam service-accounts keys list --format=json'.
+func ParseGCPIAMServiceAccountKeys(jsonOutput string) ([]IAMServiceAccountKey, error) {
+	keys := []IAMServiceAccountKey{}
+	if strings.TrimSpace(jsonOutput) == "" {
+		return keys, nil
+	}
+	if err := json.Unmarshal([]byte(jsonOutput), &keys); err != nil {
+		return nil, fmt.Errorf("failed to parse service account keys: %w", err)
+	}
+	return keys, nil
+}
+
+// CheckGCPIAMServiceAccountKeys checks the keys at the time now. The disabled keys have the DISABLED status and the keys
+// after their validity have the EXPIRED status. The user managed keys older than maxAgeDays have the OLD status
+// (the system managed keys are rotated by Google). The checks are sorted by age (the oldest first).
+func CheckGCPIAMServiceAccountKeys(keys []IAMServiceAccountKey, now time.Time, maxAgeDays int) []IAMServiceAccountKeyCheck {
+	checks := make([]IAMServiceAccountKeyCheck, 0, len(keys))
+	for _, key := range keys {
+		age := now.Sub(key.ValidAfterTime)
+		check := IAMServiceAccountKeyCheck{
+			Key:     key,
+			AgeDays: int(age.Hours() / 24),
+			Status:  IAMKeyStatusOK,
+		}
+		switch {
+		case key.Disabled:
+			check.Status = IAMKeyStatusDisabled
+		case !key.ValidBeforeTime.IsZero() && !now.Before(key.ValidBeforeTime):
+			check.Status = IAMKeyStatusExpired
+		case key.KeyType == IAMKeyTypeUserManaged && age > time.Duration(maxAgeDays)*24*time.Hour:
+			check.Status = IAMKeyStatusOld
+		}
+		checks = append(checks, check)
+	}
+
+	sort.Slice(checks, func(i, j int) bool {
+		return checks[i].Key.ValidAfterTime.Before(checks[j].Key.ValidAfterTime)
+	})
+	return checks
+}
+
+// RemoveGCPIAMPolicyBinding revokes a role of a member on a project using gcloud command.
+// All bindings of the member with that role are removed, with or without condition.
+func RemoveGCPIAMPolicyBinding(projectID, member, role string) error {
+	args := []string{
+		"projects", "remove-iam-policy-binding", projectID,
 		"--member", member,
 		"--role", role,
-		"--condition=None", // Explicitly set no condition for simplicity, can be parameterized
+		"--all", // Remove the bindings with any condition
 		"--project", projectID,
 	}
 
-	// `add-iam-policy-binding` is idempotent. If the binding already exists, it won't error.
-	_, stderr, err := RunGcloudCommand(args...)
+	_, stderr, err := RunGcloudPrimaryCommand(args...)
 	if err != nil {
-		// Check stderr for specific permission denied errors for the operation itself
-		if strings.Contains(stderr, "PERMISSION_DENIED") && strings.Contains(stderr, "resourcemanager.projects.setIamPolicy") {
-			common.Logger("fatal", "Permission denied to set IAM policy for project '%s': %w. Stderr: %s", projectID, err, stderr)
+		if strings.Contains(stderr, "PERMISSION_DENIED") {
+			return common.NewPermissionDeniedError("permission denied to set IAM policy for project '%s': %w", projectID, err)
 		}
-		common.Logger("fatal", "Failed to grant role '%s' to member '%s' on project '%s': %w. Stderr: %s", role, member, projectID, err, stderr)
+		return fmt.Errorf("failed to revoke role '%s' of member '%s' on project '%s': %w", role, member, projectID, err)
+	}
+	return nil
+}
+
+// RevokeAllGCPIAMRolesForMember revokes every role granted directly to a member on a project.
+// Bindings of other members are never changed. A failure in a role doesn't abort the others.
+// If dryRun is true, the roles are only logged and nothing is changed.
+// It returns the revoked roles and the failures by role.
+func RevokeAllGCPIAMRolesForMember(projectID, member string, dryRun bool) ([]string, map[string]error, error) {
+	if err := ValidateGCPIAMMember(member); err != nil {
+		return nil, nil, err
 	}
 
-	common.Logger("info", "Successfully granted (or ensured) role '%s' to member '%s' on project '%s'.", role, member, projectID)
+	roles, err := ListGCPIAMRolesForMember(projectID, member)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(roles) == 0 {
+		common.Logger("warning", "Member '%s' has no roles on project '%s'.", member, projectID)
+	}
+
+	revoked := []string{}
+	failed := map[string]error{}
+	for _, role := range roles {
+		if dryRun {
+			common.Logger("info", "[DRY-RUN] Would revoke role '%s' of member '%s' on project '%s'", role, member, projectID)
+			revoked = append(revoked, role)
+			continue
+		}
+
+		common.Logger("info", "Revoking role '%s' of member '%s' on project '%s'...", role, member, projectID)
+		if err := RemoveGCPIAMPolicyBinding(projectID, member, role); err != nil {
+			common.Logger("error", "%v", err)
+			failed[role] = err
+			continue
+		}
+		revoked = append(revoked, role)
+	}
+
+	return revoked, failed, nil
+}
+
+// IAMPolicy represents the IAM policy of a project returned by 'gcloud projects get-iam-policy --format=json'.
+type IAMPolicy struct {
+	Bindings []IAMPolicyBinding `json:"bindings"`
+	Etag     string             `json:"etag,omitempty"`
+	Version  int                `json:"version,omitempty"`
+}
+
+// IAMPolicyBinding represents a binding of an IAM policy: a role granted to many members.
+type IAMPolicyBinding struct {
+	Role      string        `json:"role"`
+	Members   []string      `json:"members"`
+	Condition *IAMCondition `json:"condition,omitempty"`
+}
+
+// Flatten returns one IAMBinding (member/role pair) per member of each binding of policy.
+func (p IAMPolicy) Flatten() []IAMBinding {
+	bindings := []IAMBinding{}
+	for _, policyBinding := range p.Bindings {
+		for _, member := range policyBinding.Members {
+			bindings = append(bindings, IAMBinding{Member: member, Role: policyBinding.Role, Condition: policyBinding.Condition})
+		}
+	}
+	return bindings
+}
+
+// GetGCPIAMPolicy returns the raw IAM policy of a project in JSON format.
+func GetGCPIAMPolicy(projectID string) (string, error) {
+	if projectID == "" {
+		return "", common.NewValidationError("projectID is required to get IAM policy in GetGCPIAMPolicy function")
+	}
+
+	args := []string{
+		"projects", "get-iam-policy", projectID,
+		"--format=json",
+	}
+	stdout, _, err := RunGcloudCommand(args...)
+	if err != nil {
+		return "", err
+	}
+	return stdout, nil
+}
+
+// Formats of the IAM policy export. See ExportGCPIAMPolicy function
+const (
+	IAMPolicyFormatJSON = "json"
+	IAMPolicyFormatYAML = "yaml"
+)
+
+// BuildGCPIAMPolicyFilename returns the filename of IAM policy export, defined by config.IAMPolicyFilenameTemplate
+// with the extension of format (e.g. nonprod_iam_policy_20250101-120000.yaml).
+func BuildGCPIAMPolicyFilename(projectID, format string) string {
+	fileName := common.BuildReportFilename(config.IAMPolicyFilenameTemplate, common.ReportFilenameVars(projectID, ""))
+	return strings.TrimSuffix(fileName, filepath.Ext(fileName)) + "." + format
+}
+
+// ExportGCPIAMPolicy writes the full IAM policy of a project, as returned by 'gcloud projects get-iam-policy',
+// to a timestamped file in outputDir (see BuildGCPIAMPolicyFilename) and returns its path.
+// The format is IAMPolicyFormatJSON or IAMPolicyFormatYAML. It only requires the
+// resourcemanager.projects.getIamPolicy permission on the project.
+func ExportGCPIAMPolicy(projectID, outputDir, format string) (string, error) {
+	if projectID == "" {
+		return "", common.NewValidationError("projectID is required to export IAM policy in ExportGCPIAMPolicy function")
+	}
+	if format != IAMPolicyFormatJSON && format != IAMPolicyFormatYAML {
+		return "", common.NewValidationError("Unsupported format '%s'. Supported values: json or yaml", format)
+	}
+
+	args := []string{
+		"projects", "get-iam-policy", projectID,
+		"--format=" + format,
+	}
+	stdout, stderr, err := RunGcloudPrimaryCommand(args...)
+	if err != nil {
+		if strings.Contains(stderr, "PERMISSION_DENIED") || strings.Contains(stderr, "does not have permission") {
+			return "", common.NewPermissionDeniedError("permission 'resourcemanager.projects.getIamPolicy' is required to export the IAM policy of project '%s': %w", projectID, err)
+		}
+		return "", err
+	}
+
+	filePath := filepath.Join(outputDir, BuildGCPIAMPolicyFilename(projectID, format))
+	if err := os.MkdirAll(filepath.Dir(filePath), config.PermissionDir); err != nil {
+		return "", fmt.Errorf("failed to create output directory '%s': %w", filepath.Dir(filePath), err)
+	}
+	if err := common.WriteFileAtomic(filePath, []byte(stdout), config.PermissionFile); err != nil {
+		return "", fmt.Errorf("failed to write IAM policy '%s': %w", filePath, err)
+	}
+
+	common.Logger("info", "Exported IAM policy of project '%s' to: %s", projectID, filePath)
+	return filePath, nil
+}
+
+// SaveGCPIAMPolicySnapshot writes the IAM policy of a project in JSON format to outputFile.
+// The snapshots can be compared later by DiffGCPIAMPolicies function.
+func SaveGCPIAMPolicySnapshot(projectID, outputFile string) error {
+	policy, err := GetGCPIAMPolicy(projectID)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(outputFile), config.PermissionDir); err != nil {
+		return fmt.Errorf("failed to create output directory '%s': %w", filepath.Dir(outputFile), err)
+	}
+	if err := common.WriteFileAtomic(outputFile, []byte(policy), config.PermissionFile); err != nil {
+		return fmt.Errorf("failed to write IAM policy snapshot '%s': %w", outputFile, err)
+	}
+
+	common.Logger("info", "IAM policy of project '%s' saved to: %s", projectID, outputFile)
+	return nil
+}
+
+// LoadGCPIAMPolicySnapshot reads an IAM policy in JSON format saved by SaveGCPIAMPolicySnapshot function.
+func LoadGCPIAMPolicySnapshot(filePath string) (*IAMPolicy, error) {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, common.NewValidationError("failed to read IAM policy snapshot '%s': %w", filePath, err)
+	}
+
+	policy := &IAMPolicy{}
+	if err := json.Unmarshal(content, policy); err != nil {
+		return nil, common.NewValidationError("failed to parse IAM policy snapshot '%s': %w", filePath, err)
+	}
+	return policy, nil
+}
+
+// DiffGCPIAMPolicies compares two IAM policies by member/role pair (and condition) ignoring the order of bindings and members.
+// It returns the bindings added and removed in newPolicy, sorted by their string representation.
+func DiffGCPIAMPolicies(oldPolicy, newPolicy *IAMPolicy) (added, removed []IAMBinding) {
+	bindingKey := func(b IAMBinding) string {
+		if b.Condition != nil {
+			return b.Member + "|" + b.Role + "|" + b.Condition.Title + "|" + b.Condition.Expression
+		}
+		return b.Member + "|" + b.Role
+	}
+	indexBindings := func(policy *IAMPolicy) map[string]IAMBinding {
+		index := map[string]IAMBinding{}
+		for _, binding := range policy.Flatten() {
+			index[bindingKey(binding)] = binding
+		}
+		return index
+	}
+
+	oldBindings := indexBindings(oldPolicy)
+	newBindings := indexBindings(newPolicy)
+
+	added = []IAMBinding{}
+	for key, binding := range newBindings {
+		if _, exists := oldBindings[key]; !exists {
+			added = append(added, binding)
+		}
+	}
+	removed = []IAMBinding{}
+	for key, binding := range oldBindings {
+		if _, exists := newBindings[key]; !exists {
+			removed = append(removed, binding)
+		}
+	}
+
+	sortBindings := func(bindings []IAMBinding) {
+		sort.Slice(bindings, func(i, j int) bool { return bindings[i].String() < bindings[j].String() })
+	}
+	sortBindings(added)
+	sortBindings(removed)
+	return added, removed
 }
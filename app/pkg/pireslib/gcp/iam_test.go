@@ -0,0 +1,766 @@
+package gcp
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aeciopires/pires-cli/pkg/pireslib/common"
+)
+
+func TestIAMBindingConditionArg(t *testing.T) {
+	tests := []struct {
+		name      string
+		condition *IAMCondition
+		want      string
+	}{
+		{"without condition", nil, "--condition=None"},
+		{
+			"expression with commas",
+			&IAMCondition{Title: "expires", Expression: `request.time < timestamp("2026-01-01T00:00:00Z") && resource.name.extract("{a},{b}") != ""`},
+			`--condition=^;^expression=request.time < timestamp("2026-01-01T00:00:00Z") && resource.name.extract("{a},{b}") != "";title=expires`,
+		},
+		{
+			"description with semicolon",
+			&IAMCondition{Title: "bucket", Expression: `resource.name.startsWith("projects/_/buckets/app")`, Description: "app; temporary"},
+			`--condition=^|^expression=resource.name.startsWith("projects/_/buckets/app")|title=bucket|description=app; temporary`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := IAMBinding{Member: "user:a@example.com", Role: "roles/viewer", Condition: tt.condition}.conditionArg()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("conditionArg() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIAMBindingConditionArgWithoutDelimiter(t *testing.T) {
+	binding := IAMBinding{
+		Member:    "user:a@example.com",
+		Role:      "roles/viewer",
+		Condition: &IAMCondition{Title: "all", Expression: `"; | ~ # @@"`},
+	}
+	if err := binding.Validate(); common.ExitCode(err) != common.ExitCodeValidation {
+		t.Fatalf("Validate() = %v, want validation error", err)
+	}
+}
+
+func TestLoadGCPIAMBindingsFile(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "bindings.yaml")
+	content := `bindings:
+  - member: user:name.surname@company.com
+    role: roles/storage.objectViewer
+  - member: serviceAccount:app@project.iam.gserviceaccount.com
+    role: roles/cloudsql.client
+    condition:
+      title: expires
+      expression: request.time < timestamp("2026-01-01T00:00:00Z")
+`
+	if err := os.WriteFile(filePath, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	bindings, err := LoadGCPIAMBindingsFile(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bindings) != 2 || bindings[1].Condition == nil || bindings[1].Condition.Title != "expires" {
+		t.Errorf("bindings = %+v", bindings)
+	}
+}
+
+func TestLoadGCPIAMBindingsFileInvalidEntry(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "bindings.json")
+	content := `{"bindings": [
+  {"member": "user:name.surname@company.com", "role": "roles/viewer"},
+  {"member": "name.surname@company.com", "role": "roles/viewer"}
+]}`
+	if err := os.WriteFile(filePath, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := LoadGCPIAMBindingsFile(filePath)
+	if common.ExitCode(err) != common.ExitCodeValidation || !strings.Contains(err.Error(), "entry 2") {
+		t.Fatalf("error = %v, want validation error of entry 2", err)
+	}
+}
+
+func TestLoadGCPIAMMembersFile(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "members.txt")
+	content := `# Team of payments
+user:name.surname@company.com
+
+  group:payments@company.com  
+name.surname@company.com
+# serviceAccount:old@project.iam.gserviceaccount.com
+serviceAccount:app@project.iam.gserviceaccount.com
+`
+	if err := os.WriteFile(filePath, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	members, invalidLines, err := LoadGCPIAMMembersFile(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantMembers := []string{"user:name.surname@company.com", "group:payments@company.com", "serviceAccount:app@project.iam.gserviceaccount.com"}
+	if !slices.Equal(members, wantMembers) {
+		t.Errorf("members = %v, want %v", members, wantMembers)
+	}
+	// The invalid member is reported with its line number
+	if len(invalidLines) != 1 || !strings.HasPrefix(invalidLines[0], "line 5:") {
+		t.Errorf("invalid lines = %v, want line 5", invalidLines)
+	}
+}
+
+func TestLoadGCPIAMMembersFileMissing(t *testing.T) {
+	_, _, err := LoadGCPIAMMembersFile(filepath.Join(t.TempDir(), "missing.txt"))
+	if common.ExitCode(err) != common.ExitCodeValidation {
+		t.Fatalf("error = %v, want validation error", err)
+	}
+}
+
+func TestApplyGCPIAMBindingsDryRun(t *testing.T) {
+	// gcloud must not be executed in dry-run
+	fakeGcloud(t, "exit 1")
+	bindings := []IAMBinding{{Member: "user:a@example.com", Role: "roles/viewer"}}
+
+	result := ApplyGCPIAMBindings("project", bindings, true)
+	if len(result.Succeeded) != 0 || len(result.Failed) != 0 || len(result.DryRun) != 1 {
+		t.Errorf("result = %+v, want only 1 dry-run binding", result)
+	}
+}
+
+func TestApplyGCPIAMBindings(t *testing.T) {
+	// The member 'user:fail@example.com' fails
+	fakeGcloud(t, `case "$*" in *user:fail@example.com*) exit 1;; esac`)
+	bindings := []IAMBinding{
+		{Member: "user:a@example.com", Role: "roles/viewer"},
+		{Member: "user:fail@example.com", Role: "roles/viewer"},
+	}
+
+	result := ApplyGCPIAMBindings("project", bindings, false)
+	if len(result.Succeeded) != 1 || len(result.Failed) != 1 || len(result.DryRun) != 0 {
+		t.Errorf("result = %+v, want 1 succeeded and 1 failed binding", result)
+	}
+	if _, failed := result.Failed[bindings[1].String()]; !failed {
+		t.Errorf("failed bindings = %v, want %s", result.Failed, bindings[1])
+	}
+}
+
+func TestGrantGCPIAMRoleToMember(t *testing.T) {
+	fakeGcloud(t, `case "$*" in
+*user:fail@example.com*) echo "ERROR: PERMISSION_DENIED: resourcemanager.projects.setIamPolicy" >&2; exit 1 ;;
+*user:broken@example.com*) exit 1 ;;
+esac`)
+
+	if err := GrantGCPIAMRoleToMember("project", "user:a@example.com", "roles/viewer"); err != nil {
+		t.Errorf("GrantGCPIAMRoleToMember() = %v", err)
+	}
+	// The failures are returned instead of interrupting the program
+	if err := GrantGCPIAMRoleToMember("project", "user:fail@example.com", "roles/viewer"); common.ExitCode(err) != common.ExitCodePermissionDenied {
+		t.Errorf("error = %v, want permission denied error", err)
+	}
+	if err := GrantGCPIAMRoleToMember("project", "user:broken@example.com", "roles/viewer"); err == nil {
+		t.Error("expected error when gcloud fails")
+	}
+	if err := GrantGCPIAMRoleToMember("project", "", "roles/viewer"); common.ExitCode(err) != common.ExitCodeValidation {
+		t.Errorf("error = %v, want validation error without member", err)
+	}
+}
+
+func TestBuildGCPIAMServiceAccountsRolesRows(t *testing.T) {
+	serviceAccounts := []IAMServiceAccount{
+		{Email: "deployer@p.iam.gserviceaccount.com", DisplayName: "Deployer"},
+		{Email: "app@p.iam.gserviceaccount.com", DisplayName: "App", Disabled: true},
+		{Email: "unused@p.iam.gserviceaccount.com"},
+	}
+	rolesByEmail := map[string][]string{
+		"app@p.iam.gserviceaccount.com":      {"roles/logging.logWriter", "roles/storage.objectViewer"},
+		"deployer@p.iam.gserviceaccount.com": {"roles/container.developer"},
+	}
+
+	got := BuildGCPIAMServiceAccountsRolesRows(serviceAccounts, rolesByEmail)
+	want := [][]string{
+		{"app@p.iam.gserviceaccount.com", "App", "true", "roles/logging.logWriter;roles/storage.objectViewer"},
+		{"deployer@p.iam.gserviceaccount.com", "Deployer", "false", "roles/container.developer"},
+		{"unused@p.iam.gserviceaccount.com", "", "false", "(none)"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("rows = %v, want %v", got, want)
+	}
+	for i := range want {
+		if strings.Join(got[i], ",") != strings.Join(want[i], ",") {
+			t.Errorf("row %d = %v, want %v", i, got[i], want[i])
+		}
+	}
+}
+
+// serviceAccountsRolesScript is a fake gcloud with two service accounts and the IAM policy of project.
+// Each call of 'projects get-iam-policy' is logged to the file of $CLI_TEST_POLICY_LOG.
+const serviceAccountsRolesScript = `case "$*" in
+  "iam service-accounts list"*)
+    echo '[{"email": "b@p.iam.gserviceaccount.com", "displayName": "B"}, {"email": "a@p.iam.gserviceaccount.com", "displayName": "A"}]' ;;
+  "projects get-iam-policy"*)
+    echo get-iam-policy >> "$CLI_TEST_POLICY_LOG"
+    echo '[{"bindings": {"role": "roles/viewer", "members": "serviceAccount:a@p.iam.gserviceaccount.com"}},
+           {"bindings": {"role": "roles/editor", "members": "serviceAccount:a@p.iam.gserviceaccount.com"}},
+           {"bindings": {"role": "roles/viewer", "members": "user:someone@example.com"}}]' ;;
+  *) exit 1 ;;
+esac`
+
+func TestExportGCPIAMServiceAccountsRoles(t *testing.T) {
+	fakeGcloud(t, serviceAccountsRolesScript)
+	policyLog := filepath.Join(t.TempDir(), "policy.log")
+	t.Setenv("CLI_TEST_POLICY_LOG", policyLog)
+
+	filePath, err := ExportGCPIAMServiceAccountsRoles("p", t.TempDir(), ServiceAccountsRolesFormatCSV)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "EMAIL,DISPLAY_NAME,DISABLED,ROLES\n" +
+		"a@p.iam.gserviceaccount.com,A,false,roles/editor;roles/viewer\n" +
+		"b@p.iam.gserviceaccount.com,B,false,(none)\n"
+	if string(content) != want {
+		t.Errorf("report =\n%s\nwant\n%s", content, want)
+	}
+
+	// The roles are listed per service account
+	calls, _ := os.ReadFile(policyLog)
+	if got := strings.Count(string(calls), "get-iam-policy"); got != 2 {
+		t.Errorf("IAM policy read %d time(s), want 2", got)
+	}
+}
+
+func TestExportGCPIAMServiceAccountsRolesFailedLookup(t *testing.T) {
+	// The IAM policy can't be read, so the report would be incomplete
+	fakeGcloud(t, `case "$*" in "iam service-accounts list"*) echo '[{"email": "a@p.iam.gserviceaccount.com"}]' ;; *) exit 1 ;; esac`)
+	outputDir := t.TempDir()
+
+	if _, err := ExportGCPIAMServiceAccountsRoles("p", outputDir, ServiceAccountsRolesFormatTXT); err == nil {
+		t.Fatal("expected error when the roles lookup fails")
+	}
+	if entries, _ := os.ReadDir(outputDir); len(entries) != 0 {
+		t.Errorf("output directory has %d file(s), want none", len(entries))
+	}
+}
+
+// revokeAllScript is a fake gcloud with a member with three roles (one with condition) and another member.
+// Each call of 'projects remove-iam-policy-binding' is logged to the file of $CLI_TEST_REVOKE_LOG.
+// The revoke of roles/storage.admin fails.
+const revokeAllScript = `case "$*" in
+  "projects get-iam-policy"*)
+    echo '[{"bindings": {"role": "roles/viewer", "members": "user:leaver@example.com"}},
+           {"bindings": {"role": "roles/editor", "members": "user:leaver@example.com", "condition": {"title": "temp", "expression": "true"}}},
+           {"bindings": {"role": "roles/storage.admin", "members": "user:leaver@example.com"}},
+           {"bindings": {"role": "roles/viewer", "members": "user:stayer@example.com"}}]' ;;
+  "projects remove-iam-policy-binding"*)
+    echo "$*" >> "$CLI_TEST_REVOKE_LOG"
+    case "$*" in *roles/storage.admin*) exit 1 ;; esac ;;
+  *) exit 1 ;;
+esac`
+
+func TestRevokeAllGCPIAMRolesForMember(t *testing.T) {
+	fakeGcloud(t, revokeAllScript)
+	revokeLog := filepath.Join(t.TempDir(), "revoke.log")
+	t.Setenv("CLI_TEST_REVOKE_LOG", revokeLog)
+
+	revoked, failed, err := RevokeAllGCPIAMRolesForMember("p", "user:leaver@example.com", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"roles/editor", "roles/viewer"}; !slices.Equal(revoked, want) {
+		t.Errorf("revoked = %v, want %v", revoked, want)
+	}
+	if len(failed) != 1 || failed["roles/storage.admin"] == nil {
+		t.Errorf("failed = %v, want roles/storage.admin", failed)
+	}
+
+	// Three revokes are attempted, all of them for the member only
+	calls, err := os.ReadFile(revokeLog)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(calls)), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("revoke calls = %q, want 3", lines)
+	}
+	for _, line := range lines {
+		if !strings.Contains(line, "--member user:leaver@example.com") || !strings.Contains(line, "--all") {
+			t.Errorf("unexpected revoke call %q", line)
+		}
+	}
+}
+
+func TestRevokeAllGCPIAMRolesForMemberDryRun(t *testing.T) {
+	fakeGcloud(t, revokeAllScript)
+	revokeLog := filepath.Join(t.TempDir(), "revoke.log")
+	t.Setenv("CLI_TEST_REVOKE_LOG", revokeLog)
+
+	revoked, failed, err := RevokeAllGCPIAMRolesForMember("p", "user:leaver@example.com", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(revoked) != 3 || len(failed) != 0 {
+		t.Errorf("revoked = %v and failed = %v, want 3 roles in dry-run", revoked, failed)
+	}
+	if _, err := os.Stat(revokeLog); !os.IsNotExist(err) {
+		t.Error("roles revoked in dry-run")
+	}
+}
+
+// oldPolicyJSON and newPolicyJSON are sample snapshots of an IAM policy. The order of bindings
+// and members changed, a member was added to viewer, editor was removed and a conditional binding was added.
+const (
+	oldPolicyJSON = `{"bindings": [
+  {"role": "roles/editor", "members": ["user:bob@example.com"]},
+  {"role": "roles/viewer", "members": ["user:alice@example.com", "group:ops@example.com"]}
+], "etag": "BwX1", "version": 1}`
+	newPolicyJSON = `{"bindings": [
+  {"role": "roles/viewer", "members": ["group:ops@example.com", "user:carol@example.com", "user:alice@example.com"]},
+  {"role": "roles/editor", "members": ["user:alice@example.com"], "condition": {"title": "temporary", "expression": "request.time < timestamp('2026-01-01T00:00:00Z')"}}
+], "etag": "BwX2", "version": 3}`
+)
+
+// bindingStrings returns the string representation of bindings
+func bindingStrings(bindings []IAMBinding) []string {
+	strs := make([]string, 0, len(bindings))
+	for _, binding := range bindings {
+		strs = append(strs, binding.String())
+	}
+	return strs
+}
+
+func TestDiffGCPIAMPolicies(t *testing.T) {
+	dir := t.TempDir()
+	oldFile, newFile := filepath.Join(dir, "old.json"), filepath.Join(dir, "new.json")
+	for file, content := range map[string]string{oldFile: oldPolicyJSON, newFile: newPolicyJSON} {
+		if err := os.WriteFile(file, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	oldPolicy, err := LoadGCPIAMPolicySnapshot(oldFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newPolicy, err := LoadGCPIAMPolicySnapshot(newFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	added, removed := DiffGCPIAMPolicies(oldPolicy, newPolicy)
+	wantAdded := []string{
+		"user:alice@example.com => roles/editor (condition: temporary)",
+		"user:carol@example.com => roles/viewer",
+	}
+	if got := bindingStrings(added); !slices.Equal(got, wantAdded) {
+		t.Errorf("added = %q, want %q", got, wantAdded)
+	}
+	if got, want := bindingStrings(removed), []string{"user:bob@example.com => roles/editor"}; !slices.Equal(got, want) {
+		t.Errorf("removed = %q, want %q", got, want)
+	}
+
+	// The same policy in other order has no differences
+	added, removed = DiffGCPIAMPolicies(newPolicy, newPolicy)
+	if len(added) != 0 || len(removed) != 0 {
+		t.Errorf("diff of same policy = %v, %v, want no differences", added, removed)
+	}
+}
+
+func TestLoadGCPIAMPolicySnapshotInvalid(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "policy.json")
+	if err := os.WriteFile(file, []byte("bindings: ["), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadGCPIAMPolicySnapshot(file); common.ExitCode(err) != common.ExitCodeValidation {
+		t.Errorf("error = %v, want validation error", err)
+	}
+	if _, err := LoadGCPIAMPolicySnapshot(filepath.Join(t.TempDir(), "missing.json")); common.ExitCode(err) != common.ExitCodeValidation {
+		t.Errorf("error = %v, want validation error for missing file", err)
+	}
+}
+
+func TestSaveGCPIAMPolicySnapshot(t *testing.T) {
+	fakeGcloud(t, `case "$*" in
+"projects get-iam-policy my-project --format=json") cat <<'JSON'
+`+oldPolicyJSON+`
+JSON
+;;
+*) exit 1 ;;
+esac`)
+	outputFile := filepath.Join(t.TempDir(), "snapshots", "before.json")
+
+	if err := SaveGCPIAMPolicySnapshot("my-project", outputFile); err != nil {
+		t.Fatal(err)
+	}
+	policy, err := LoadGCPIAMPolicySnapshot(outputFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(policy.Flatten()) != 3 || policy.Etag != "BwX1" {
+		t.Errorf("snapshot = %+v, want the policy of project", policy)
+	}
+}
+
+// describeMemberScript is a fake gcloud with the flattened IAM policy and the service accounts of project
+const describeMemberScript = `case "$*" in
+  "projects get-iam-policy p --flatten=bindings[].members --format=json")
+    echo '[{"bindings": {"role": "roles/viewer", "members": "serviceAccount:app@p.iam.gserviceaccount.com"}},
+           {"bindings": {"role": "roles/storage.admin", "members": "serviceAccount:app@p.iam.gserviceaccount.com", "condition": {"title": "temporary", "expression": "request.time < timestamp(\"2026-01-01T00:00:00Z\")"}}},
+           {"bindings": {"role": "roles/editor", "members": "user:someone@example.com"}}]' ;;
+  "iam service-accounts list --project p --format=json")
+    echo '[{"email": "app@p.iam.gserviceaccount.com", "displayName": "App", "disabled": true}]' ;;
+  *) exit 1 ;;
+esac`
+
+func TestDescribeGCPIAMMemberServiceAccount(t *testing.T) {
+	fakeGcloud(t, describeMemberScript)
+
+	description, err := DescribeGCPIAMMember("p", "serviceAccount:app@p.iam.gserviceaccount.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"roles/storage.admin", "roles/viewer"}; !slices.Equal(description.Roles, want) {
+		t.Errorf("roles = %v, want %v", description.Roles, want)
+	}
+	if got := bindingStrings(description.ConditionalBindings); !slices.Equal(got, []string{"serviceAccount:app@p.iam.gserviceaccount.com => roles/storage.admin (condition: temporary)"}) {
+		t.Errorf("conditional bindings = %q", got)
+	}
+	if !description.IsServiceAccount || description.ServiceAccount == nil || !description.ServiceAccount.Disabled || description.ServiceAccount.DisplayName != "App" {
+		t.Errorf("service account = %t, %+v, want disabled service account App", description.IsServiceAccount, description.ServiceAccount)
+	}
+}
+
+func TestDescribeGCPIAMMemberUser(t *testing.T) {
+	fakeGcloud(t, describeMemberScript)
+
+	description, err := DescribeGCPIAMMember("p", "user:someone@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := IAMMemberDescription{Member: "user:someone@example.com", Roles: []string{"roles/editor"}, ConditionalBindings: []IAMBinding{}}
+	if description.Member != want.Member || !slices.Equal(description.Roles, want.Roles) || len(description.ConditionalBindings) != 0 ||
+		description.IsServiceAccount || description.ServiceAccount != nil {
+		t.Errorf("description = %+v, want %+v", description, want)
+	}
+}
+
+func TestDescribeGCPIAMMemberServiceAccountNotInProject(t *testing.T) {
+	fakeGcloud(t, describeMemberScript)
+
+	description, err := DescribeGCPIAMMember("p", "serviceAccount:ci@other.iam.gserviceaccount.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !description.IsServiceAccount || description.ServiceAccount != nil || len(description.Roles) != 0 {
+		t.Errorf("description = %+v, want service account of other project without roles", description)
+	}
+}
+
+func TestDescribeGCPIAMMemberInvalid(t *testing.T) {
+	fakeGcloud(t, describeMemberScript)
+
+	if _, err := DescribeGCPIAMMember("p", "someone@example.com"); common.ExitCode(err) != common.ExitCodeValidation {
+		t.Errorf("error = %v, want validation error for member without type", err)
+	}
+}
+
+func TestBuildGCPIAMPolicyFilename(t *testing.T) {
+	filenameRegex := regexp.MustCompile(`^nonprod_iam_policy_\d{8}-\d{6}\.(json|yaml)$`)
+	for _, format := range []string{IAMPolicyFormatJSON, IAMPolicyFormatYAML} {
+		fileName := BuildGCPIAMPolicyFilename("nonprod", format)
+		if !filenameRegex.MatchString(fileName) || filepath.Ext(fileName) != "."+format {
+			t.Errorf("BuildGCPIAMPolicyFilename(%s) = %s, want timestamped file with extension .%s", format, fileName, format)
+		}
+	}
+}
+
+func TestExportGCPIAMPolicy(t *testing.T) {
+	// The policy is returned in the format of --format argument
+	fakeGcloud(t, `case "$*" in
+"projects get-iam-policy nonprod --format=json") echo '{"bindings": []}' ;;
+"projects get-iam-policy nonprod --format=yaml") echo 'bindings: []' ;;
+*) exit 1 ;;
+esac`)
+
+	tests := map[string]string{
+		IAMPolicyFormatJSON: "{\"bindings\": []}\n",
+		IAMPolicyFormatYAML: "bindings: []\n",
+	}
+	for format, want := range tests {
+		t.Run(format, func(t *testing.T) {
+			outputDir := filepath.Join(t.TempDir(), "policies")
+			filePath, err := ExportGCPIAMPolicy("nonprod", outputDir, format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if filepath.Dir(filePath) != outputDir || filepath.Ext(filePath) != "."+format {
+				t.Errorf("path = %s, want .%s file in %s", filePath, format, outputDir)
+			}
+			if content, _ := os.ReadFile(filePath); string(content) != want {
+				t.Errorf("content = %q, want %q", content, want)
+			}
+		})
+	}
+}
+
+func TestExportGCPIAMPolicyErrors(t *testing.T) {
+	fakeGcloud(t, `echo "ERROR: (gcloud.projects.get-iam-policy) PERMISSION_DENIED: caller does not have permission" >&2; exit 1`)
+	outputDir := t.TempDir()
+
+	if _, err := ExportGCPIAMPolicy("nonprod", outputDir, "xml"); common.ExitCode(err) != common.ExitCodeValidation {
+		t.Errorf("unsupported format: error = %v, want validation error", err)
+	}
+	if _, err := ExportGCPIAMPolicy("nonprod", outputDir, IAMPolicyFormatJSON); common.ExitCode(err) != common.ExitCodePermissionDenied {
+		t.Errorf("error = %v, want permission denied error", err)
+	}
+	if entries, _ := os.ReadDir(outputDir); len(entries) != 0 {
+		t.Errorf("files written after failure: %v", entries)
+	}
+}
+
+// rolesListScript is a fake gcloud listing the predefined roles and the custom roles of project 'nonprod'
+const rolesListScript = `case "$*" in
+"iam roles list --format=json(name,title,stage)") echo '[{"name": "roles/storage.objectViewer", "title": "Storage Object Viewer", "stage": "GA"}, {"name": "roles/cloudsql.client", "title": "Cloud SQL Client", "stage": "GA"}]' ;;
+"iam roles list --project nonprod --format=json(name,title,stage)") echo '[{"name": "projects/nonprod/roles/appDeployer", "title": "App Deployer", "stage": "BETA"}]' ;;
+*) exit 1 ;;
+esac`
+
+func TestListGCPIAMGrantableRoles(t *testing.T) {
+	fakeGcloud(t, rolesListScript)
+
+	roles, err := ListGCPIAMGrantableRoles("nonprod")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The predefined and custom roles are merged and sorted by name
+	want := []IAMRole{
+		{Name: "projects/nonprod/roles/appDeployer", Title: "App Deployer", Stage: "BETA", Custom: true},
+		{Name: "roles/cloudsql.client", Title: "Cloud SQL Client", Stage: "GA"},
+		{Name: "roles/storage.objectViewer", Title: "Storage Object Viewer", Stage: "GA"},
+	}
+	if !slices.Equal(roles, want) {
+		t.Errorf("roles = %+v, want %+v", roles, want)
+	}
+}
+
+func TestListGCPIAMGrantableRolesErrors(t *testing.T) {
+	if _, err := ListGCPIAMGrantableRoles(""); common.ExitCode(err) != common.ExitCodeValidation {
+		t.Errorf("error = %v, want validation error", err)
+	}
+
+	// The custom roles can't be listed
+	fakeGcloud(t, `case "$*" in *--project*) exit 1 ;; *) echo '[]' ;; esac`)
+	if _, err := ListGCPIAMGrantableRoles("nonprod"); err == nil {
+		t.Error("expected error when the custom roles can't be listed")
+	}
+
+	if _, err := ParseGCPIAMRoles("not json"); err == nil {
+		t.Error("expected error for invalid JSON")
+	}
+}
+
+func TestFilterGCPIAMRoles(t *testing.T) {
+	roles := []IAMRole{
+		{Name: "roles/cloudsql.client", Title: "Cloud SQL Client"},
+		{Name: "roles/storage.objectViewer", Title: "Storage Object Viewer"},
+		{Name: "projects/nonprod/roles/appDeployer", Title: "App Deployer", Custom: true},
+	}
+
+	tests := []struct {
+		filter string
+		want   []string
+	}{
+		{"", []string{"roles/cloudsql.client", "roles/storage.objectViewer", "projects/nonprod/roles/appDeployer"}},
+		{"CLOUDSQL", []string{"roles/cloudsql.client"}},
+		// The title is matched too
+		{"viewer", []string{"roles/storage.objectViewer"}},
+		{"deployer ", []string{"projects/nonprod/roles/appDeployer"}},
+		{"missing", nil},
+	}
+	for _, tt := range tests {
+		var names []string
+		for _, role := range FilterGCPIAMRoles(roles, tt.filter) {
+			names = append(names, role.Name)
+		}
+		if !slices.Equal(names, tt.want) {
+			t.Errorf("FilterGCPIAMRoles(%q) = %v, want %v", tt.filter, names, tt.want)
+		}
+	}
+}
+
+func TestListGCPIAMMembersForRole(t *testing.T) {
+	fakeGcloud(t, `[ "$*" = "projects get-iam-policy p --flatten=bindings[].members --format=json" ] || exit 1
+echo '[{"bindings": {"role": "roles/owner", "members": "user:someone@example.com"}},
+       {"bindings": {"role": "roles/owner", "members": "group:admins@example.com"}},
+       {"bindings": {"role": "roles/owner", "members": "user:someone@example.com", "condition": {"title": "temporary"}}},
+       {"bindings": {"role": "roles/viewer", "members": "serviceAccount:app@p.iam.gserviceaccount.com"}}]'`)
+
+	members, err := ListGCPIAMMembersForRole("p", "roles/owner")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"group:admins@example.com", "user:someone@example.com"}; !slices.Equal(members, want) {
+		t.Errorf("members = %v, want %v", members, want)
+	}
+
+	// A role without members returns an empty slice
+	if members, err := ListGCPIAMMembersForRole("p", "roles/editor"); err != nil || members == nil || len(members) != 0 {
+		t.Errorf("ListGCPIAMMembersForRole(roles/editor) = %v, %v, want empty slice", members, err)
+	}
+	for _, role := range []string{"", "owner"} {
+		if _, err := ListGCPIAMMembersForRole("p", role); common.ExitCode(err) != common.ExitCodeValidation {
+			t.Errorf("role %q: error = %v, want validation error", role, err)
+		}
+	}
+}
+
+func TestCheckGCPIAMServiceAccountKeys(t *testing.T) {
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	daysAgo := func(days int) time.Time { return now.AddDate(0, 0, -days) }
+	keys := []IAMServiceAccountKey{
+		{Name: "projects/p/serviceAccounts/app@p.iam.gserviceaccount.com/keys/recent", KeyType: IAMKeyTypeUserManaged, ValidAfterTime: daysAgo(10)},
+		{Name: "projects/p/serviceAccounts/app@p.iam.gserviceaccount.com/keys/old", KeyType: IAMKeyTypeUserManaged, ValidAfterTime: daysAgo(200)},
+		{Name: "projects/p/serviceAccounts/app@p.iam.gserviceaccount.com/keys/system", KeyType: IAMKeyTypeSystemManaged, ValidAfterTime: daysAgo(300), ValidBeforeTime: now.AddDate(0, 0, 30)},
+		{Name: "projects/p/serviceAccounts/app@p.iam.gserviceaccount.com/keys/expired", KeyType: IAMKeyTypeUserManaged, ValidAfterTime: daysAgo(50), ValidBeforeTime: daysAgo(1)},
+		{Name: "projects/p/serviceAccounts/app@p.iam.gserviceaccount.com/keys/disabled", KeyType: IAMKeyTypeUserManaged, ValidAfterTime: daysAgo(400), Disabled: true},
+	}
+
+	checks := CheckGCPIAMServiceAccountKeys(keys, now, 90)
+
+	// The oldest keys first
+	want := []struct {
+		id      string
+		ageDays int
+		status  string
+	}{
+		{"disabled", 400, IAMKeyStatusDisabled},
+		{"system", 300, IAMKeyStatusOK},
+		{"old", 200, IAMKeyStatusOld},
+		{"expired", 50, IAMKeyStatusExpired},
+		{"recent", 10, IAMKeyStatusOK},
+	}
+	if len(checks) != len(want) {
+		t.Fatalf("CheckGCPIAMServiceAccountKeys() = %+v, want %d checks", checks, len(want))
+	}
+	for i, w := range want {
+		if checks[i].Key.ID() != w.id || checks[i].AgeDays != w.ageDays || checks[i].Status != w.status {
+			t.Errorf("check %d = %s, %d days, %s, want %s, %d days, %s", i, checks[i].Key.ID(), checks[i].AgeDays, checks[i].Status, w.id, w.ageDays, w.status)
+		}
+	}
+}
+
+func TestListGCPIAMServiceAccountKeys(t *testing.T) {
+	fakeGcloud(t, `[ "$*" = "iam service-accounts keys list --iam-account app@p.iam.gserviceaccount.com --project p --format=json" ] || exit 1
+echo '[{"name": "projects/p/serviceAccounts/app@p.iam.gserviceaccount.com/keys/abc123", "keyType": "USER_MANAGED", "validAfterTime": "2025-01-01T00:00:00Z", "validBeforeTime": "9999-12-31T23:59:59Z"}]'`)
+
+	keys, err := ListGCPIAMServiceAccountKeys("p", "app@p.iam.gserviceaccount.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 1 || keys[0].ID() != "abc123" || keys[0].KeyType != IAMKeyTypeUserManaged || !keys[0].ValidAfterTime.Equal(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("ListGCPIAMServiceAccountKeys() = %+v", keys)
+	}
+
+	if keys, err := ParseGCPIAMServiceAccountKeys(""); err != nil || keys == nil || len(keys) != 0 {
+		t.Errorf("ParseGCPIAMServiceAccountKeys(\"\") = %v, %v, want empty slice", keys, err)
+	}
+	if _, err := ParseGCPIAMServiceAccountKeys("not json"); err == nil {
+		t.Error("expected error for invalid JSON")
+	}
+}
+
+func TestDescribeGCPIAMServiceAccount(t *testing.T) {
+	fakeGcloud(t, `[ "$*" = "iam service-accounts describe app@p.iam.gserviceaccount.com --project p --format=json" ] || exit 1
+echo '{"email": "app@p.iam.gserviceaccount.com", "displayName": "App", "disabled": true}'`)
+
+	serviceAccount, err := DescribeGCPIAMServiceAccount("p", "app@p.iam.gserviceaccount.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if serviceAccount.Email != "app@p.iam.gserviceaccount.com" || !serviceAccount.Disabled {
+		t.Errorf("DescribeGCPIAMServiceAccount() = %+v, want disabled service account", serviceAccount)
+	}
+	if _, err := DescribeGCPIAMServiceAccount("p", "app@example.com"); common.ExitCode(err) != common.ExitCodeValidation {
+		t.Errorf("error = %v, want validation error for invalid email", err)
+	}
+}
+
+func TestParseGCPIAMCustomRole(t *testing.T) {
+	role, err := ParseGCPIAMCustomRole(`{
+  "name": "projects/p/roles/appDeployer",
+  "title": "App Deployer",
+  "stage": "GA",
+  "etag": "BwYmHk=",
+  "includedPermissions": ["container.deployments.update", "container.deployments.get", "container.pods.list"]
+}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if role.ID() != "appDeployer" || role.Title != "App Deployer" || role.Stage != "GA" {
+		t.Errorf("ParseGCPIAMCustomRole() = %+v", role)
+	}
+	want := []string{"container.deployments.get", "container.deployments.update", "container.pods.list"}
+	if !slices.Equal(role.IncludedPermissions, want) {
+		t.Errorf("IncludedPermissions = %v, want sorted %v", role.IncludedPermissions, want)
+	}
+	if _, err := ParseGCPIAMCustomRole("not json"); err == nil {
+		t.Error("expected error for invalid JSON")
+	}
+}
+
+func TestListGCPIAMCustomRoles(t *testing.T) {
+	fakeGcloud(t, `[ "$*" = "iam roles list --project p --format=json" ] || exit 1
+echo '[{"name": "projects/p/roles/viewer_ext", "title": "Viewer Ext"}, {"name": "projects/p/roles/appDeployer", "title": "App Deployer", "deleted": true}]'`)
+
+	roles, err := ListGCPIAMCustomRoles("p")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(roles) != 2 || roles[0].ID() != "appDeployer" || !roles[0].Deleted || roles[1].ID() != "viewer_ext" {
+		t.Errorf("ListGCPIAMCustomRoles() = %+v, want roles sorted by name", roles)
+	}
+
+	if roles, err := ParseGCPIAMCustomRoles(""); err != nil || roles == nil || len(roles) != 0 {
+		t.Errorf("ParseGCPIAMCustomRoles(\"\") = %v, %v, want empty slice", roles, err)
+	}
+	if _, err := ListGCPIAMCustomRoles(""); common.ExitCode(err) != common.ExitCodeValidation {
+		t.Errorf("error = %v, want validation error without project", err)
+	}
+}
+
+func TestDescribeGCPIAMCustomRole(t *testing.T) {
+	fakeGcloud(t, `[ "$*" = "iam roles describe appDeployer --project p --format=json" ] || exit 1
+echo '{"name": "projects/p/roles/appDeployer", "includedPermissions": ["container.pods.list"]}'`)
+
+	// The full name of role is accepted too
+	for _, roleID := range []string{"appDeployer", "projects/p/roles/appDeployer"} {
+		role, err := DescribeGCPIAMCustomRole("p", roleID)
+		if err != nil {
+			t.Fatalf("DescribeGCPIAMCustomRole(%q): %v", roleID, err)
+		}
+		if role.ID() != "appDeployer" || !slices.Equal(role.IncludedPermissions, []string{"container.pods.list"}) {
+			t.Errorf("DescribeGCPIAMCustomRole(%q) = %+v", roleID, role)
+		}
+	}
+	for _, roleID := range []string{"ab", "roles/viewer", "projects/other/roles/appDeployer", "app deployer"} {
+		if _, err := DescribeGCPIAMCustomRole("p", roleID); common.ExitCode(err) != common.ExitCodeValidation {
+			t.Errorf("DescribeGCPIAMCustomRole(%q) error = %v, want validation error", roleID, err)
+		}
+	}
+}
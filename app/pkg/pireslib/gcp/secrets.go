@@ -0,0 +1,33 @@
+// Package gcp have public and private functions to connect to GCP services, like: IAM, CloudSQL, GKE, etc.
+package gcp
+
+import (
+	"strings"
+
+	"github.com/aeciopires/pires-cli/pkg/pireslib/common"
+)
+
+// AddGCPSecretVersion adds a new version with the data to a secret of Secret Manager using gcloud command.
+// The data is passed by stdin, so it's never exposed in the arguments or logs. The secret must exist.
+// It returns the ID of the new version (e.g. 3).
+func AddGCPSecretVersion(projectID, secretName, data string) (string, error) {
+	if projectID == "" || secretName == "" || data == "" {
+		return "", common.NewValidationError("projectID, secretName and data are required to add secret version in AddGCPSecretVersion function")
+	}
+
+	common.Logger("info", "Adding new version to secret '%s' on project '%s'...", secretName, projectID)
+	args := []string{
+		"secrets", "versions", "add", secretName,
+		"--project", projectID,
+		"--data-file=-",
+		"--format=value(name.basename())",
+	}
+	stdout, _, err := RunGcloudCommandWithInput(data, args...)
+	if err != nil {
+		return "", err
+	}
+
+	version := strings.TrimSpace(stdout)
+	common.Logger("info", "Added version '%s' to secret '%s' on project '%s'.", version, secretName, projectID)
+	return version, nil
+}
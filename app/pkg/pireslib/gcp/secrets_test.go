@@ -0,0 +1,49 @@
+package gcp
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aeciopires/pires-cli/pkg/pireslib/common"
+)
+
+func TestAddGCPSecretVersion(t *testing.T) {
+	// The data is read from stdin and the arguments are logged
+	fakeGcloud(t, `cat > "$CLI_TEST_DATA"; echo "$*" > "$CLI_TEST_ARGS"; echo 3`)
+	dir := t.TempDir()
+	dataFile, argsFile := filepath.Join(dir, "data"), filepath.Join(dir, "args")
+	t.Setenv("CLI_TEST_DATA", dataFile)
+	t.Setenv("CLI_TEST_ARGS", argsFile)
+
+	version, err := AddGCPSecretVersion("p", "db-password", "s3cr3t-Value")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != "3" {
+		t.Errorf("version = %q, want 3", version)
+	}
+
+	data, err := os.ReadFile(dataFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "s3cr3t-Value" {
+		t.Errorf("stdin = %q, want the secret data", data)
+	}
+	args, err := os.ReadFile(argsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(args), "s3cr3t-Value") || !strings.Contains(string(args), "--data-file=-") {
+		t.Errorf("args = %q, want data passed by stdin", args)
+	}
+}
+
+func TestAddGCPSecretVersionWithoutData(t *testing.T) {
+	fakeGcloud(t, "exit 1")
+	if _, err := AddGCPSecretVersion("p", "db-password", ""); common.ExitCode(err) != common.ExitCodeValidation {
+		t.Errorf("error = %v, want validation error", err)
+	}
+}
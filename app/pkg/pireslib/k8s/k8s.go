@@ -0,0 +1,183 @@
+// Package k8s have public and private functions to interact with Kubernetes clusters using kubectl.
+package k8s
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aeciopires/pires-cli/pkg/pireslib/common"
+	"github.com/aeciopires/pires-cli/pkg/pireslib/fileeditor"
+)
+
+// RunKubectlCommand executes a kubectl command with the given arguments.
+// It captures and returns stdout and stderr.
+// Assumes kubectl is in the system PATH.
+func RunKubectlCommand(args ...string) (stdout string, stderr string, err error) {
+	// Proceed with running the command
+	// The command is killed if the CLI receives SIGINT/SIGTERM
+	cmd := common.CommandContext("kubectl", args...)
+
+	// Buffers to capture stdout and stderr
+	var outb, errb bytes.Buffer
+	cmd.Stdout = &outb
+	cmd.Stderr = &errb
+
+	common.Logger("debug", "Executing command: kubectl %s", strings.Join(args, " "))
+	err = cmd.Run()
+
+	stdout = outb.String()
+	stderr = errb.String()
+
+	if errInterrupted := common.CheckInterrupted(); errInterrupted != nil {
+		return stdout, stderr, errInterrupted
+	}
+	if err != nil {
+		return stdout, stderr, common.NewExternalCommandError("kubectl command 'kubectl %s' failed: %w\nStderr: %s", strings.Join(args, " "), err, stderr)
+	}
+
+	if stderr != "" {
+		common.LogExternalStderr("kubectl", stderr)
+	}
+
+	return stdout, stderr, nil
+}
+
+// RenameContext renames a context of kubeconfig. If a context with the new name
+// already exists (e.g. created by a previous execution), it is replaced.
+func RenameContext(oldName, newName string) error {
+	if oldName == newName {
+		return nil
+	}
+
+	// Ignore the error, because the context with new name usually doesn't exist
+	_, _, _ = RunKubectlCommand("config", "delete-context", newName)
+
+	_, _, err := RunKubectlCommand("config", "rename-context", oldName, newName)
+	return err
+}
+
+// Supported modes of ValidateManifests function
+const (
+	DryRunModeClient = "client"
+	DryRunModeServer = "server"
+)
+
+// GetCurrentContext returns the current context of kubeconfig.
+func GetCurrentContext() (string, error) {
+	stdout, _, err := RunKubectlCommand("config", "current-context")
+	if err != nil {
+		return "", err
+	}
+
+	currentContext := strings.TrimSpace(stdout)
+	if currentContext == "" {
+		return "", common.NewValidationError("no current context set in kubeconfig")
+	}
+	return currentContext, nil
+}
+
+// CheckKubectlContext checks that kubectl has a current context and that its cluster is reachable,
+// running 'kubectl config current-context' and 'kubectl cluster-info'. It's the precheck of k8s commands
+// that require a cluster, so they fail early with a clear message instead of in the middle of operations.
+func CheckKubectlContext() error {
+	currentContext, err := GetCurrentContext()
+	if err != nil {
+		return common.NewValidationError("no current context of kubectl. Run 'pires-cli gcp gke connect-all' to get the credentials of GKE clusters or 'kubectl config use-context' to select one: %w", err)
+	}
+
+	if _, _, err := RunKubectlCommand("cluster-info"); err != nil {
+		return common.NewExternalCommandError("the cluster of kubectl context '%s' is unreachable (check the VPN and 'pires-cli gcp gke test-connection' command): %w", currentContext, err)
+	}
+	common.Logger("debug", "The cluster of kubectl context '%s' is reachable.", currentContext)
+	return nil
+}
+
+// ValidateManifests runs 'kubectl apply --dry-run=<mode>' for each YAML file (except patch files)
+// under rootDir and its subdirectories. The server mode sends the manifests to the cluster of current context,
+// which detects deprecated or removed APIs, so the caller should check the context with CheckKubectlContext before.
+// The client mode doesn't send the manifests, but kubectl still uses the API server to discover the resource types.
+// It returns the validated files and the failures indexed by file. The error is returned only
+// if the validation can't be performed (e.g. invalid mode).
+func ValidateManifests(rootDir, dryRunMode string) ([]string, map[string]error, error) {
+	failed := map[string]error{}
+
+	if dryRunMode != DryRunModeClient && dryRunMode != DryRunModeServer {
+		return nil, failed, common.NewValidationError("unsupported dry-run mode '%s'. Supported values: %s or %s", dryRunMode, DryRunModeClient, DryRunModeServer)
+	}
+	common.Logger("info", "Validating manifests using kubectl %s dry-run...", dryRunMode)
+
+	files, errFind := findManifestFiles(rootDir)
+	if errFind != nil {
+		return nil, failed, errFind
+	}
+
+	// The files are validated in parallel (see --max-concurrency option)
+	errs := make([]error, len(files))
+	common.ForEachConcurrently(len(files), func(i int) {
+		// Skip the remaining files if the CLI received SIGINT/SIGTERM
+		if common.CheckInterrupted() != nil {
+			return
+		}
+		_, _, errs[i] = RunKubectlCommand("apply", "--dry-run="+dryRunMode, "-f", files[i])
+	})
+	if errInterrupted := common.CheckInterrupted(); errInterrupted != nil {
+		return files, failed, errInterrupted
+	}
+
+	for i, file := range files {
+		if errs[i] != nil {
+			common.Logger("debug", "Validation of '%s' failed: %v", file, errs[i])
+			failed[file] = errs[i]
+		}
+	}
+
+	return files, failed, nil
+}
+
+// findManifestFiles returns the YAML files (except patch files) of rootDir and its subdirectories.
+func findManifestFiles(rootDir string) ([]string, error) {
+	var files []string
+	errWalk := filepath.WalkDir(rootDir, func(path string, d os.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return fmt.Errorf("[ERROR] Unable to access path '%s': %w", path, walkErr)
+		}
+		// Skip directories and non-YAML files
+		if d.IsDir() || !fileeditor.MatchYAMLFile(path, false) {
+			return nil
+		}
+		files = append(files, path)
+		return nil
+	})
+	if errWalk != nil {
+		return nil, errWalk
+	}
+	return files, nil
+}
+
+// TestConnection checks if the cluster of current context is reachable, running 'kubectl get --raw /healthz'.
+// If kubeconfig is not empty, it's used instead of the default kubeconfig file.
+// It returns the current context and the error of kubectl, if the cluster is unreachable.
+func TestConnection(kubeconfig string) (string, error) {
+	var kubeconfigArgs []string
+	if kubeconfig != "" {
+		kubeconfigArgs = []string{"--kubeconfig", kubeconfig}
+	}
+
+	stdout, _, err := RunKubectlCommand(append(kubeconfigArgs, "config", "current-context")...)
+	if err != nil {
+		return "", err
+	}
+	currentContext := strings.TrimSpace(stdout)
+
+	stdout, _, err = RunKubectlCommand(append(kubeconfigArgs, "get", "--raw", "/healthz")...)
+	if err != nil {
+		return currentContext, err
+	}
+	if status := strings.TrimSpace(stdout); status != "ok" {
+		return currentContext, fmt.Errorf("unexpected health status of cluster: '%s'", status)
+	}
+	return currentContext, nil
+}
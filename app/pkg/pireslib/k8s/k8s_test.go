@@ -0,0 +1,159 @@
+package k8s
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/aeciopires/pires-cli/pkg/pireslib/common"
+)
+
+// fakeKubectl puts a kubectl shell script with the body first in PATH during the test.
+// The script appends its arguments to the returned log file, one call per line.
+func fakeKubectl(t *testing.T, body string) string {
+	t.Helper()
+	dir := t.TempDir()
+	logFile := filepath.Join(dir, "calls.log")
+	script := "#!/bin/sh\necho \"$*\" >> '" + logFile + "'\n" + body + "\n"
+	if err := os.WriteFile(filepath.Join(dir, "kubectl"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	return logFile
+}
+
+// kubectlCalls returns the calls of fake kubectl
+func kubectlCalls(t *testing.T, logFile string) []string {
+	t.Helper()
+	content, err := os.ReadFile(logFile)
+	if err != nil && !os.IsNotExist(err) {
+		t.Fatal(err)
+	}
+	return strings.Fields(strings.ReplaceAll(string(content), " ", "_"))
+}
+
+// writeManifest writes a manifest file under dir
+func writeManifest(t *testing.T, dir, name string) string {
+	t.Helper()
+	filePath := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(filePath), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filePath, []byte("apiVersion: v1\nkind: ConfigMap\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return filePath
+}
+
+func TestValidateManifests(t *testing.T) {
+	// The fake fails for broken.yaml, like a removed API version
+	logFile := fakeKubectl(t, `case "$*" in
+  *broken.yaml*) echo 'no matches for kind "PodSecurityPolicy" in version "policy/v1beta1"' >&2; exit 1 ;;
+esac`)
+	dir := t.TempDir()
+	valid := writeManifest(t, dir, "apps/deployment.yaml")
+	broken := writeManifest(t, dir, "apps/broken.yaml")
+	writeManifest(t, dir, "apps/deployment.patch.yaml")
+	writeManifest(t, dir, "README.md")
+
+	files, failed, err := ValidateManifests(dir, DryRunModeServer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{broken, valid}; !slices.Equal(files, want) {
+		t.Errorf("files = %v, want %v", files, want)
+	}
+	if len(failed) != 1 || failed[broken] == nil || !strings.Contains(failed[broken].Error(), "PodSecurityPolicy") {
+		t.Errorf("failed = %v, want only %s", failed, broken)
+	}
+
+	// Only the dry-run calls, the context is checked by the command before (see CheckKubectlContext)
+	calls := kubectlCalls(t, logFile)
+	if len(calls) != 2 {
+		t.Fatalf("kubectl calls = %v, want 2 apply calls", calls)
+	}
+	for _, call := range calls {
+		if !strings.HasPrefix(call, "apply_--dry-run=server_-f_") {
+			t.Errorf("unexpected kubectl call %q", call)
+		}
+	}
+}
+
+func TestValidateManifestsInvalidMode(t *testing.T) {
+	_, _, err := ValidateManifests(t.TempDir(), "offline")
+	if common.ExitCode(err) != common.ExitCodeValidation {
+		t.Errorf("ValidateManifests error = %v, want validation error", err)
+	}
+}
+
+func TestCheckKubectlContext(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		wantCode int
+	}{
+		{"reachable", `[ "$1" = config ] && echo gke_p_us-central1_c; exit 0`, 0},
+		{"no current context", `[ "$1" = config ] && exit 0; exit 0`, common.ExitCodeValidation},
+		{"unreachable", `[ "$1" = config ] && echo gke_p_us-central1_c && exit 0; exit 1`, common.ExitCodeExternalCommand},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeKubectl(t, tt.body)
+			if got := common.ExitCode(CheckKubectlContext()); got != tt.wantCode {
+				t.Errorf("exit code = %d, want %d", got, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestTestConnectionHealthy(t *testing.T) {
+	logFile := fakeKubectl(t, `case "$*" in
+  *current-context*) echo gke_my-project_us-central1_apps ;;
+  *healthz*) printf ok ;;
+esac`)
+
+	currentContext, err := TestConnection("/tmp/custom-kubeconfig")
+	if err != nil {
+		t.Fatalf("TestConnection: %v", err)
+	}
+	if currentContext != "gke_my-project_us-central1_apps" {
+		t.Errorf("current context = %q", currentContext)
+	}
+
+	want := []string{
+		"--kubeconfig_/tmp/custom-kubeconfig_config_current-context",
+		"--kubeconfig_/tmp/custom-kubeconfig_get_--raw_/healthz",
+	}
+	if got := kubectlCalls(t, logFile); !slices.Equal(got, want) {
+		t.Errorf("kubectl calls = %q, want %q", got, want)
+	}
+}
+
+func TestTestConnectionUnhealthy(t *testing.T) {
+	fakeKubectl(t, `case "$*" in
+  *current-context*) echo gke_my-project_us-central1_apps ;;
+  *healthz*) echo 'Unable to connect to the server: dial tcp 10.0.0.2:443: i/o timeout' >&2; exit 1 ;;
+esac`)
+
+	currentContext, err := TestConnection("")
+	if err == nil || !strings.Contains(err.Error(), "i/o timeout") {
+		t.Errorf("error = %v, want error of kubectl", err)
+	}
+	// The context is returned to report which cluster is unreachable
+	if currentContext != "gke_my-project_us-central1_apps" {
+		t.Errorf("current context = %q", currentContext)
+	}
+}
+
+func TestTestConnectionUnexpectedStatus(t *testing.T) {
+	fakeKubectl(t, `case "$*" in
+  *current-context*) echo my-context ;;
+  *healthz*) echo '[-]etcd failed' ;;
+esac`)
+
+	if _, err := TestConnection(""); err == nil || !strings.Contains(err.Error(), "unexpected health status") {
+		t.Errorf("error = %v, want unexpected health status", err)
+	}
+}
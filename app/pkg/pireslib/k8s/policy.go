@@ -0,0 +1,128 @@
+package k8s
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/aeciopires/pires-cli/pkg/pireslib/common"
+)
+
+// ConftestCommand is the command used by CheckManifestPolicies. It's in the optional commands of config.OptionalCommandsToCheck.
+const ConftestCommand = "conftest"
+
+// Results of policy checks. See PolicyResult type
+const (
+	PolicyResultFailure = "FAIL"
+	PolicyResultWarning = "WARN"
+)
+
+// PolicyResult is a failure (deny/violation rules) or warning (warn rules) of a policy for a manifest file.
+type PolicyResult struct {
+	File    string `json:"file"`
+	Result  string `json:"result"`
+	Rule    string `json:"rule,omitempty"` // Query of rule, like data.main.deny, when reported by conftest
+	Message string `json:"message"`
+}
+
+// conftestResult is an entry of 'conftest test --output json' output, one per file and namespace.
+type conftestResult struct {
+	Filename  string            `json:"filename"`
+	Namespace string            `json:"namespace"`
+	Warnings  []conftestMessage `json:"warnings"`
+	Failures  []conftestMessage `json:"failures"`
+}
+
+// conftestMessage is a failure or warning of conftestResult.
+type conftestMessage struct {
+	Msg      string                 `json:"msg"`
+	Metadata map[string]interface{} `json:"metadata"`
+}
+
+// CheckManifestPolicies runs 'conftest test' with the policies of policyDir over the YAML files (except patch files)
+// of rootDir and its subdirectories, and returns the number of checked files and the failures and warnings,
+// with their files and rules. If allNamespaces is true, the rules of all namespaces of policies are used,
+// instead of only the 'main' namespace. The error is returned only if the check can't be performed
+// (e.g. conftest isn't installed or the policies are invalid).
+func CheckManifestPolicies(policyDir, rootDir string, allNamespaces bool) (int, []PolicyResult, error) {
+	conftestPath, errLook := exec.LookPath(ConftestCommand)
+	if errLook != nil {
+		return 0, nil, common.NewValidationError("'%s' was not found in PATH. Install it following https://www.conftest.dev/install/ (e.g. 'brew install conftest') and try again", ConftestCommand)
+	}
+	info, errStat := os.Stat(policyDir)
+	if errStat != nil {
+		return 0, nil, fmt.Errorf("[ERROR] Failed to access policy directory '%s': %w", policyDir, errStat)
+	}
+	if !info.IsDir() {
+		return 0, nil, fmt.Errorf("[ERROR] '%s' is not a directory", policyDir)
+	}
+
+	files, errFind := findManifestFiles(rootDir)
+	if errFind != nil {
+		return 0, nil, errFind
+	}
+	if len(files) == 0 {
+		return 0, nil, nil
+	}
+
+	args := []string{"test", "--policy", policyDir, "--output", "json", "--no-color"}
+	if allNamespaces {
+		args = append(args, "--all-namespaces")
+	}
+	args = append(args, files...)
+
+	// The command is killed if the CLI receives SIGINT/SIGTERM
+	cmd := common.CommandContext(conftestPath, args...)
+	var outb, errb bytes.Buffer
+	cmd.Stdout = &outb
+	cmd.Stderr = &errb
+	common.Logger("debug", "Executing command: %s %s", ConftestCommand, strings.Join(args, " "))
+	errRun := cmd.Run()
+	if errInterrupted := common.CheckInterrupted(); errInterrupted != nil {
+		return 0, nil, errInterrupted
+	}
+
+	// conftest exits with non-zero code when any policy fails, so the output is parsed before checking the error
+	results, errParse := ParseConftestResults(outb.String())
+	if errParse != nil {
+		if errRun != nil {
+			return 0, nil, common.NewExternalCommandError("%s command failed: %w\nStderr: %s", ConftestCommand, errRun, strings.TrimSpace(errb.String()))
+		}
+		return 0, nil, errParse
+	}
+	var exitErr *exec.ExitError
+	if errRun != nil && !errors.As(errRun, &exitErr) {
+		return 0, nil, common.NewExternalCommandError("%s command failed: %w", ConftestCommand, errRun)
+	}
+	return len(files), results, nil
+}
+
+// ParseConftestResults parses the output of 'conftest test --output json' command and returns the failures
+// and warnings, in order of files. The rule is read from the 'query' metadata, if available.
+func ParseConftestResults(jsonOutput string) ([]PolicyResult, error) {
+	var entries []conftestResult
+	if err := json.Unmarshal([]byte(jsonOutput), &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse output of %s: %w", ConftestCommand, err)
+	}
+
+	var results []PolicyResult
+	for _, entry := range entries {
+		for _, failure := range entry.Failures {
+			results = append(results, PolicyResult{File: entry.Filename, Result: PolicyResultFailure, Rule: conftestRule(failure), Message: failure.Msg})
+		}
+		for _, warning := range entry.Warnings {
+			results = append(results, PolicyResult{File: entry.Filename, Result: PolicyResultWarning, Rule: conftestRule(warning), Message: warning.Msg})
+		}
+	}
+	return results, nil
+}
+
+// conftestRule returns the query of rule of a conftest message (e.g. data.main.deny), or empty if it isn't reported.
+func conftestRule(message conftestMessage) string {
+	query, _ := message.Metadata["query"].(string)
+	return query
+}
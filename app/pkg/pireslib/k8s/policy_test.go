@@ -0,0 +1,101 @@
+package k8s
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/aeciopires/pires-cli/pkg/pireslib/common"
+)
+
+// conftestDenialOutput is the output of 'conftest test --output json' with a denial and a warning of a Deployment
+const conftestDenialOutput = `[
+  {"filename": "DEPLOYMENT", "namespace": "main", "successes": 3,
+   "failures": [{"msg": "containers must not run as root", "metadata": {"query": "data.main.deny"}}],
+   "warnings": [{"msg": "image tag should be pinned"}]},
+  {"filename": "SERVICE", "namespace": "main", "successes": 4}
+]`
+
+// fakeConftest puts a conftest shell script with the body first in PATH during the test.
+// The script writes its arguments to the returned log file, one per line.
+func fakeConftest(t *testing.T, body string) string {
+	t.Helper()
+	dir := t.TempDir()
+	logFile := filepath.Join(dir, "calls.log")
+	script := "#!/bin/sh\nprintf '%s\\n' \"$@\" > '" + logFile + "'\n" + body + "\n"
+	if err := os.WriteFile(filepath.Join(dir, ConftestCommand), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	return logFile
+}
+
+func TestParseConftestResults(t *testing.T) {
+	results, err := ParseConftestResults(strings.NewReplacer("DEPLOYMENT", "deployment.yaml", "SERVICE", "service.yaml").Replace(conftestDenialOutput))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []PolicyResult{
+		{File: "deployment.yaml", Result: PolicyResultFailure, Rule: "data.main.deny", Message: "containers must not run as root"},
+		{File: "deployment.yaml", Result: PolicyResultWarning, Message: "image tag should be pinned"},
+	}
+	if !slices.Equal(results, want) {
+		t.Errorf("ParseConftestResults() =\n%+v\nwant\n%+v", results, want)
+	}
+
+	if _, err := ParseConftestResults("not json"); err == nil {
+		t.Error("expected error for invalid JSON")
+	}
+}
+
+func TestCheckManifestPoliciesDenial(t *testing.T) {
+	rootDir := t.TempDir()
+	policyDir := t.TempDir()
+	deployment := writeManifest(t, rootDir, "apps/deployment.yaml")
+	service := writeManifest(t, rootDir, "service.yaml")
+	writeManifest(t, rootDir, "apps/deployment.patch.yaml")
+	// conftest exits with non-zero code on failures
+	output := strings.NewReplacer("DEPLOYMENT", deployment, "SERVICE", service).Replace(conftestDenialOutput)
+	logFile := fakeConftest(t, "cat <<'EOF'\n"+output+"\nEOF\nexit 1")
+
+	checked, results, err := CheckManifestPolicies(policyDir, rootDir, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if checked != 2 || len(results) != 2 || results[0].File != deployment || results[0].Result != PolicyResultFailure || results[0].Rule != "data.main.deny" {
+		t.Errorf("CheckManifestPolicies() = %d, %+v, want the denial of %s", checked, results, deployment)
+	}
+
+	calls, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	args := strings.Fields(string(calls))
+	if !slices.Equal(args[:7], []string{"test", "--policy", policyDir, "--output", "json", "--no-color", "--all-namespaces"}) || slices.Contains(args, filepath.Join(rootDir, "apps/deployment.patch.yaml")) {
+		t.Errorf("conftest arguments = %v", args)
+	}
+}
+
+func TestCheckManifestPoliciesErrors(t *testing.T) {
+	rootDir := t.TempDir()
+	writeManifest(t, rootDir, "deployment.yaml")
+
+	// conftest isn't installed
+	t.Setenv("PATH", t.TempDir())
+	_, _, err := CheckManifestPolicies(t.TempDir(), rootDir, false)
+	if common.ExitCode(err) != common.ExitCodeValidation || !strings.Contains(err.Error(), "https://www.conftest.dev/install/") {
+		t.Errorf("error = %v, want validation error with the install instructions", err)
+	}
+
+	// The policies are invalid, so there is no JSON output
+	fakeConftest(t, `echo "Error: load: loading policies: 1 error occurred" >&2; exit 1`)
+	if _, _, err := CheckManifestPolicies(t.TempDir(), rootDir, false); common.ExitCode(err) != common.ExitCodeExternalCommand {
+		t.Errorf("error = %v, want external command error", err)
+	}
+
+	if _, _, err := CheckManifestPolicies(filepath.Join(rootDir, "missing"), rootDir, false); err == nil {
+		t.Error("expected error for missing policy directory")
+	}
+}
@@ -0,0 +1,186 @@
+package k8s
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Kinds of resources referenced by the manifests. See CheckManifestRefs function
+const (
+	KindConfigMap = "ConfigMap"
+	KindSecret    = "Secret"
+)
+
+// refKeys are the keys of references to ConfigMaps and Secrets in the pod specs, with the kind of referenced resource
+// and the key of its name. E.g. envFrom[].configMapRef.name and volumes[].secret.secretName.
+var refKeys = []struct {
+	key     string
+	kind    string
+	nameKey string
+}{
+	{"configMapRef", KindConfigMap, "name"},    // envFrom
+	{"configMapKeyRef", KindConfigMap, "name"}, // env[].valueFrom
+	{"configMap", KindConfigMap, "name"},       // volumes and projected volumes
+	{"secretRef", KindSecret, "name"},          // envFrom
+	{"secretKeyRef", KindSecret, "name"},       // env[].valueFrom
+	{"secret", KindSecret, "secretName"},       // volumes
+	{"secret", KindSecret, "name"},             // projected volumes
+}
+
+// ManifestResource identifies a resource of the manifests.
+type ManifestResource struct {
+	Kind      string
+	Namespace string
+	Name      string
+}
+
+// DanglingRef is a reference to a ConfigMap or Secret without declaration in the manifests.
+type DanglingRef struct {
+	File     string
+	Resource ManifestResource // Resource with the reference, like a Deployment
+	Ref      ManifestResource // Referenced ConfigMap or Secret
+}
+
+// CheckManifestRefs parses the YAML files (except patch files) of rootDir and its subdirectories, including the
+// multi-document files, and returns the references to ConfigMaps and Secrets (envFrom, env[].valueFrom, volumes and
+// imagePullSecrets) without a matching declaration in the same files. The optional references are ignored.
+// A reference without namespace matches a declaration in any namespace (and vice versa), because the namespace is
+// usually defined when the manifests are applied. It doesn't require access to a cluster.
+func CheckManifestRefs(rootDir string) ([]DanglingRef, error) {
+	files, errFind := findManifestFiles(rootDir)
+	if errFind != nil {
+		return nil, errFind
+	}
+
+	declared := map[ManifestResource]bool{}
+	var refs []DanglingRef
+	for _, file := range files {
+		documents, errRead := readManifestDocuments(file)
+		if errRead != nil {
+			return nil, errRead
+		}
+		for _, document := range documents {
+			resource := manifestResource(document)
+			if resource.Kind == KindConfigMap || resource.Kind == KindSecret {
+				declared[resource] = true
+				continue
+			}
+			// The same resource can be referenced many times, e.g. by envFrom and volumes
+			documentRefs := collectRefs(document, resource.Namespace)
+			slices.SortFunc(documentRefs, compareResources)
+			for _, ref := range slices.Compact(documentRefs) {
+				refs = append(refs, DanglingRef{File: file, Resource: resource, Ref: ref})
+			}
+		}
+	}
+
+	var dangling []DanglingRef
+	for _, ref := range refs {
+		if !isDeclared(declared, ref.Ref) {
+			dangling = append(dangling, ref)
+		}
+	}
+	return dangling, nil
+}
+
+// readManifestDocuments returns the non-empty documents of a YAML file.
+func readManifestDocuments(filePath string) ([]map[string]interface{}, error) {
+	data, errRead := os.ReadFile(filePath)
+	if errRead != nil {
+		return nil, fmt.Errorf("[ERROR] Could not read file %s: %w", filePath, errRead)
+	}
+
+	var documents []map[string]interface{}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var document map[string]interface{}
+		errDecode := decoder.Decode(&document)
+		if errors.Is(errDecode, io.EOF) {
+			break
+		}
+		if errDecode != nil {
+			return nil, fmt.Errorf("[ERROR] Failed to parse YAML file %s: %w", filePath, errDecode)
+		}
+		if document != nil {
+			documents = append(documents, document)
+		}
+	}
+	return documents, nil
+}
+
+// manifestResource returns the kind, namespace and name of a document.
+func manifestResource(document map[string]interface{}) ManifestResource {
+	resource := ManifestResource{Kind: stringValue(document, "kind")}
+	if metadata, ok := document["metadata"].(map[string]interface{}); ok {
+		resource.Namespace = stringValue(metadata, "namespace")
+		resource.Name = stringValue(metadata, "name")
+	}
+	return resource
+}
+
+// collectRefs walks a document and returns the references to ConfigMaps and Secrets, in the namespace of the document.
+func collectRefs(node interface{}, namespace string) []ManifestResource {
+	var refs []ManifestResource
+	switch value := node.(type) {
+	case map[string]interface{}:
+		for _, refKey := range refKeys {
+			ref, ok := value[refKey.key].(map[string]interface{})
+			if !ok || ref["optional"] == true {
+				continue
+			}
+			if name := stringValue(ref, refKey.nameKey); name != "" {
+				refs = append(refs, ManifestResource{Kind: refKey.kind, Namespace: namespace, Name: name})
+			}
+		}
+		if pullSecrets, ok := value["imagePullSecrets"].([]interface{}); ok {
+			for _, pullSecret := range pullSecrets {
+				if secret, ok := pullSecret.(map[string]interface{}); ok && stringValue(secret, "name") != "" {
+					refs = append(refs, ManifestResource{Kind: KindSecret, Namespace: namespace, Name: stringValue(secret, "name")})
+				}
+			}
+		}
+		for _, child := range value {
+			refs = append(refs, collectRefs(child, namespace)...)
+		}
+	case []interface{}:
+		for _, child := range value {
+			refs = append(refs, collectRefs(child, namespace)...)
+		}
+	}
+	return refs
+}
+
+// isDeclared returns true if the referenced resource is declared. An empty namespace matches any namespace.
+func isDeclared(declared map[ManifestResource]bool, ref ManifestResource) bool {
+	for resource := range declared {
+		if resource.Kind == ref.Kind && resource.Name == ref.Name &&
+			(resource.Namespace == ref.Namespace || resource.Namespace == "" || ref.Namespace == "") {
+			return true
+		}
+	}
+	return false
+}
+
+// compareResources orders the resources by kind, namespace and name.
+func compareResources(a, b ManifestResource) int {
+	switch {
+	case a.Kind != b.Kind:
+		return strings.Compare(a.Kind, b.Kind)
+	case a.Namespace != b.Namespace:
+		return strings.Compare(a.Namespace, b.Namespace)
+	}
+	return strings.Compare(a.Name, b.Name)
+}
+
+// stringValue returns the string value of a key of a map, or empty if the key is missing or isn't a string.
+func stringValue(values map[string]interface{}, key string) string {
+	value, _ := values[key].(string)
+	return value
+}
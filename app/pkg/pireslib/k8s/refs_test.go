@@ -0,0 +1,135 @@
+package k8s
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// refsDeployment is a Deployment referencing ConfigMaps and Secrets in all supported ways
+const refsDeployment = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+  namespace: apps
+spec:
+  template:
+    spec:
+      imagePullSecrets:
+        - name: registry
+      containers:
+        - name: app
+          envFrom:
+            - configMapRef:
+                name: app-config
+            - secretRef:
+                name: app-secret
+          env:
+            - name: FEATURE
+              valueFrom:
+                configMapKeyRef:
+                  name: missing-config
+                  key: feature
+            - name: OPTIONAL
+              valueFrom:
+                secretKeyRef:
+                  name: optional-secret
+                  key: value
+                  optional: true
+      volumes:
+        - name: config
+          configMap:
+            name: app-config
+        - name: certs
+          secret:
+            secretName: missing-certs
+`
+
+// refsDeclarations declares some of the resources referenced by refsDeployment, in a multi-document file
+const refsDeclarations = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app-config
+  namespace: apps
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: app-secret
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: registry
+  namespace: other
+`
+
+// writeManifestContent writes a manifest file with the content under dir
+func writeManifestContent(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	filePath := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(filePath), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filePath, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return filePath
+}
+
+func TestCheckManifestRefs(t *testing.T) {
+	dir := t.TempDir()
+	deployment := writeManifestContent(t, dir, "apps/deployment.yaml", refsDeployment)
+	writeManifestContent(t, dir, "declarations.yaml", refsDeclarations)
+	// The patch files are not checked
+	writeManifestContent(t, dir, "apps/deployment.patch.yaml", "kind: Deployment\nspec:\n  secretRef:\n    name: patch-secret\n")
+
+	dangling, err := CheckManifestRefs(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	app := ManifestResource{Kind: "Deployment", Namespace: "apps", Name: "app"}
+	want := []DanglingRef{
+		{File: deployment, Resource: app, Ref: ManifestResource{Kind: KindConfigMap, Namespace: "apps", Name: "missing-config"}},
+		{File: deployment, Resource: app, Ref: ManifestResource{Kind: KindSecret, Namespace: "apps", Name: "missing-certs"}},
+		// The Secret of another namespace doesn't match
+		{File: deployment, Resource: app, Ref: ManifestResource{Kind: KindSecret, Namespace: "apps", Name: "registry"}},
+	}
+	if !slices.Equal(dangling, want) {
+		t.Errorf("CheckManifestRefs() =\n%+v\nwant\n%+v", dangling, want)
+	}
+}
+
+func TestCheckManifestRefsAllDeclared(t *testing.T) {
+	dir := t.TempDir()
+	writeManifestContent(t, dir, "all.yaml", `kind: Pod
+metadata:
+  name: app
+spec:
+  containers:
+    - name: app
+      envFrom:
+        - configMapRef:
+            name: app-config
+---
+kind: ConfigMap
+metadata:
+  name: app-config
+  namespace: apps
+`)
+
+	if dangling, err := CheckManifestRefs(dir); err != nil || len(dangling) != 0 {
+		t.Errorf("CheckManifestRefs() = %+v, %v, want no dangling references", dangling, err)
+	}
+}
+
+func TestCheckManifestRefsInvalidYAML(t *testing.T) {
+	dir := t.TempDir()
+	writeManifestContent(t, dir, "broken.yaml", "kind: [unclosed\n")
+
+	if _, err := CheckManifestRefs(dir); err == nil {
+		t.Error("expected error for invalid YAML")
+	}
+}